@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -22,6 +23,11 @@ var (
 	addOptQueryRE           string
 	addOptLeadingCommentRE  string
 	addOptTrailingCommentRE string
+	addOptMaxConcurrency    int
+	addOptMaxQPS            int
+	addOptQueueTimeout      time.Duration
+	addOptMaxExecutionTime  time.Duration
+	addOptIndexHints        map[string]string
 	// TODO: other stuff, bind vars etc
 )
 
@@ -53,6 +59,25 @@ func runAdd(cmd *cobra.Command, args []string) {
 			log.Fatalf("Trailing comment condition invalid '%v': %v", addOptTrailingCommentRE, err)
 		}
 	}
+	switch ruleAction {
+	case vtrules.QRThrottle:
+		if err := rule.SetThrottle(addOptMaxConcurrency, addOptMaxQPS, addOptQueueTimeout); err != nil {
+			log.Fatalf("Throttle invalid: %v", err)
+		}
+	case vtrules.QRMaxExecutionTime:
+		if err := rule.SetMaxExecutionTime(addOptMaxExecutionTime); err != nil {
+			log.Fatalf("Max execution time invalid: %v", err)
+		}
+	case vtrules.QRRewrite:
+		if len(addOptIndexHints) == 0 {
+			log.Fatalf("At least one index hint is required to rewrite queries")
+		}
+		for table, hint := range addOptIndexHints {
+			if err := rule.AddIndexHint(table, hint); err != nil {
+				log.Fatalf("Index hint invalid '%v': %v", hint, err)
+			}
+		}
+	}
 
 	var rules *vtrules.Rules
 	_, err := os.Stat(configFile)
@@ -105,6 +130,12 @@ func mkAction() vtrules.Action {
 		return vtrules.QRFailRetry
 	case "continue":
 		return vtrules.QRContinue
+	case "throttle":
+		return vtrules.QRThrottle
+	case "max_execution_time":
+		return vtrules.QRMaxExecutionTime
+	case "rewrite":
+		return vtrules.QRRewrite
 	default:
 		log.Fatalf("Unknown action '%v'", addOptAction)
 	}
@@ -139,7 +170,7 @@ func Add() *cobra.Command {
 		&addOptAction,
 		"action", "a",
 		"",
-		"What action should be taken when this rule is matched {continue, fail, fail-retry} (required)")
+		"What action should be taken when this rule is matched {continue, fail, fail-retry, throttle, max_execution_time, rewrite} (required)")
 	addCmd.Flags().StringSliceVarP(
 		&addOptPlans,
 		"plan", "p",
@@ -165,6 +196,31 @@ func Add() *cobra.Command {
 		"trailing-comment", "r",
 		"",
 		"A regexp that will be applied to comments after a SQL statement")
+	addCmd.Flags().IntVar(
+		&addOptMaxConcurrency,
+		"max-concurrency",
+		0,
+		"For the throttle action, the maximum number of matching queries that can run concurrently")
+	addCmd.Flags().IntVar(
+		&addOptMaxQPS,
+		"max-qps",
+		0,
+		"For the throttle action, the maximum number of matching queries that can run per second")
+	addCmd.Flags().DurationVar(
+		&addOptQueueTimeout,
+		"queue-timeout",
+		0,
+		"For the throttle action, how long a matching query can wait before it is rejected; 0 waits until the query times out")
+	addCmd.Flags().DurationVar(
+		&addOptMaxExecutionTime,
+		"max-execution-time",
+		0,
+		"For the max_execution_time action, the timeout of the matching queries")
+	addCmd.Flags().StringToStringVar(
+		&addOptIndexHints,
+		"index-hint",
+		nil,
+		"For the rewrite action, the index hint of a table, e.g. t1='FORCE INDEX (idx)'; may be specified multiple times")
 
 	for _, f := range []string{"name", "action"} {
 		addCmd.MarkFlagRequired(f)
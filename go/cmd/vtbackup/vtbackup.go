@@ -54,6 +54,11 @@ The command-line parameters to vtbackup specify a policy for when a new backup
 is needed, and when old backups should be removed. If the existing backups
 already satisfy the policy, then vtbackup will do nothing and return success
 immediately.
+
+With --verify_backup, vtbackup verifies the most recent backup instead of
+taking a new one: it checks the files of the backup against its MANIFEST,
+restores it into its local mysqld, and runs the --verify_backup_queries. The
+result of the verification is recorded in the backup storage.
 */
 package main
 
@@ -116,6 +121,9 @@ var (
 
 	restartBeforeBackup = flag.Bool("restart_before_backup", false, "Perform a mysqld clean/full restart after applying binlogs, but before taking the backup. Only makes sense to work around xtrabackup bugs.")
 
+	verifyBackup        = flag.Bool("verify_backup", false, "Instead of taking a new backup, verify the most recent backup of the shard: check its files against the MANIFEST, restore it into a scratch mysqld, and run the --verify_backup_queries. The result is recorded in the backup storage. Old backups are not pruned in this mode.")
+	verifyBackupQueries repeatedStringValue
+
 	// vttablet-like flags
 	initDbNameOverride = flag.String("init_db_name_override", "", "(init parameter) override the name of the db used by vttablet")
 	initKeyspace       = flag.String("init_keyspace", "", "(init parameter) keyspace to use for this tablet")
@@ -130,6 +138,26 @@ var (
 	detachedMode  = flag.Bool("detach", false, "detached mode - run backups detached from the terminal")
 )
 
+func init() {
+	flag.Var(&verifyBackupQueries, "verify_backup_queries", "Sanity query run against the restored backup by --verify_backup. Repeat the flag to run several queries. Each query must succeed and return at least one row.")
+}
+
+// repeatedStringValue is a flag.Value that collects the value of every
+// occurrence of a repeated flag. Unlike flagutil.StringListValue, it doesn't
+// split the values on commas, which are common in SQL queries.
+type repeatedStringValue []string
+
+// Set is part of the flag.Value interface.
+func (value *repeatedStringValue) Set(v string) error {
+	*value = append(*value, v)
+	return nil
+}
+
+// String is part of the flag.Value interface.
+func (value repeatedStringValue) String() string {
+	return strings.Join(value, "; ")
+}
+
 func main() {
 	defer exit.Recover()
 	dbconfigs.RegisterFlags(dbconfigs.All...)
@@ -170,10 +198,18 @@ func main() {
 	topoServer := topo.Open()
 	defer topoServer.Close()
 
+	backupDir := mysqlctl.GetBackupDir(*initKeyspace, *initShard)
+	if *verifyBackup {
+		if err := verifyLatestBackup(ctx, backupStorage, backupDir); err != nil {
+			log.Errorf("Failed to verify backup: %v", err)
+			exit.Return(1)
+		}
+		return
+	}
+
 	// Try to take a backup, if it's been long enough since the last one.
 	// Skip pruning if backup wasn't fully successful. We don't want to be
 	// deleting things if the backup process is not healthy.
-	backupDir := mysqlctl.GetBackupDir(*initKeyspace, *initShard)
 	doBackup, err := shouldBackup(ctx, topoServer, backupStorage, backupDir)
 	if err != nil {
 		log.Errorf("Can't take backup: %v", err)
@@ -194,51 +230,11 @@ func main() {
 }
 
 func takeBackup(ctx context.Context, topoServer *topo.Server, backupStorage backupstorage.BackupStorage) error {
-	// This is an imaginary tablet alias. The value doesn't matter for anything,
-	// except that we generate a random UID to ensure the target backup
-	// directory is unique if multiple vtbackup instances are launched for the
-	// same shard, at exactly the same second, pointed at the same backup
-	// storage location.
-	bigN, err := rand.Int(rand.Reader, big.NewInt(math.MaxUint32))
+	mysqld, mycnf, tabletAlias, cleanup, err := initMysqld(ctx)
 	if err != nil {
-		return fmt.Errorf("can't generate random tablet UID: %v", err)
-	}
-	tabletAlias := &topodatapb.TabletAlias{
-		Cell: "vtbackup",
-		Uid:  uint32(bigN.Uint64()),
-	}
-
-	// Clean up our temporary data dir if we exit for any reason, to make sure
-	// every invocation of vtbackup starts with a clean slate, and it does not
-	// accumulate garbage (and run out of disk space) if it's restarted.
-	tabletDir := mysqlctl.TabletDir(tabletAlias.Uid)
-	defer func() {
-		log.Infof("Removing temporary tablet directory: %v", tabletDir)
-		if err := os.RemoveAll(tabletDir); err != nil {
-			log.Warningf("Failed to remove temporary tablet directory: %v", err)
-		}
-	}()
-
-	// Start up mysqld as if we are mysqlctld provisioning a fresh tablet.
-	mysqld, mycnf, err := mysqlctl.CreateMysqldAndMycnf(tabletAlias.Uid, *mysqlSocket, int32(*mysqlPort))
-	if err != nil {
-		return fmt.Errorf("failed to initialize mysql config: %v", err)
-	}
-	initCtx, initCancel := context.WithTimeout(ctx, *mysqlTimeout)
-	defer initCancel()
-	if err := mysqld.Init(initCtx, mycnf, *initDBSQLFile); err != nil {
-		return fmt.Errorf("failed to initialize mysql data dir and start mysqld: %v", err)
+		return err
 	}
-	// Shut down mysqld when we're done.
-	defer func() {
-		// Be careful not to use the original context, because we don't want to
-		// skip shutdown just because we timed out waiting for other things.
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		if err := mysqld.Shutdown(ctx, mycnf, false); err != nil {
-			log.Errorf("failed to shutdown mysqld: %v", err)
-		}
-	}()
+	defer cleanup()
 
 	extraEnv := map[string]string{
 		"TABLET_ALIAS": topoproto.TabletAliasString(tabletAlias),
@@ -431,6 +427,148 @@ func takeBackup(ctx context.Context, topoServer *topo.Server, backupStorage back
 	return nil
 }
 
+// initMysqld starts up a local mysqld as if we are mysqlctld provisioning a
+// fresh tablet. The returned cleanup function shuts it down and removes its
+// data.
+func initMysqld(ctx context.Context) (mysqld *mysqlctl.Mysqld, mycnf *mysqlctl.Mycnf, tabletAlias *topodatapb.TabletAlias, cleanup func(), err error) {
+	// This is an imaginary tablet alias. The value doesn't matter for anything,
+	// except that we generate a random UID to ensure the target backup
+	// directory is unique if multiple vtbackup instances are launched for the
+	// same shard, at exactly the same second, pointed at the same backup
+	// storage location.
+	bigN, err := rand.Int(rand.Reader, big.NewInt(math.MaxUint32))
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("can't generate random tablet UID: %v", err)
+	}
+	tabletAlias = &topodatapb.TabletAlias{
+		Cell: "vtbackup",
+		Uid:  uint32(bigN.Uint64()),
+	}
+
+	// Clean up our temporary data dir if we exit for any reason, to make sure
+	// every invocation of vtbackup starts with a clean slate, and it does not
+	// accumulate garbage (and run out of disk space) if it's restarted.
+	tabletDir := mysqlctl.TabletDir(tabletAlias.Uid)
+	removeTabletDir := func() {
+		log.Infof("Removing temporary tablet directory: %v", tabletDir)
+		if err := os.RemoveAll(tabletDir); err != nil {
+			log.Warningf("Failed to remove temporary tablet directory: %v", err)
+		}
+	}
+
+	// Start up mysqld as if we are mysqlctld provisioning a fresh tablet.
+	mysqld, mycnf, err = mysqlctl.CreateMysqldAndMycnf(tabletAlias.Uid, *mysqlSocket, int32(*mysqlPort))
+	if err != nil {
+		removeTabletDir()
+		return nil, nil, nil, nil, fmt.Errorf("failed to initialize mysql config: %v", err)
+	}
+	initCtx, initCancel := context.WithTimeout(ctx, *mysqlTimeout)
+	defer initCancel()
+	if err := mysqld.Init(initCtx, mycnf, *initDBSQLFile); err != nil {
+		removeTabletDir()
+		return nil, nil, nil, nil, fmt.Errorf("failed to initialize mysql data dir and start mysqld: %v", err)
+	}
+
+	cleanup = func() {
+		// Shut down mysqld when we're done.
+		// Be careful not to use the original context, because we don't want to
+		// skip shutdown just because we timed out waiting for other things.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := mysqld.Shutdown(ctx, mycnf, false); err != nil {
+			log.Errorf("failed to shutdown mysqld: %v", err)
+		}
+		removeTabletDir()
+	}
+	return mysqld, mycnf, tabletAlias, cleanup, nil
+}
+
+// verifyLatestBackup verifies the most recent backup of the shard, and records
+// the result of the verification, whether it passed or not.
+func verifyLatestBackup(ctx context.Context, backupStorage backupstorage.BackupStorage, backupDir string) error {
+	bhs, err := backupStorage.ListBackups(ctx, backupDir)
+	if err != nil {
+		return fmt.Errorf("can't list backups: %v", err)
+	}
+	logger := logutil.NewConsoleLogger()
+	bh, manifest, err := mysqlctl.FindLatestSuccessfulBackup(ctx, logger, bhs)
+	if err != nil {
+		return fmt.Errorf("can't find a backup to verify: %v", err)
+	}
+	log.Infof("Verifying backup %v/%v", backupDir, bh.Name())
+
+	verifyErr := mysqlctl.VerifyBackupFiles(ctx, logger, bh, *concurrency)
+	restored := false
+	if verifyErr == nil && !manifest.Incremental {
+		// An incremental backup only contains binary logs: it can't be restored on its own.
+		restored = true
+		verifyErr = restoreAndCheckBackup(ctx, manifest)
+	}
+	if ctx.Err() != nil {
+		// Don't record a failure if we were just interrupted.
+		return ctx.Err()
+	}
+
+	verification := mysqlctl.NewBackupVerification(verifyErr, restored)
+	if err := mysqlctl.RecordBackupVerification(ctx, backupStorage, backupDir, bh.Name(), verification); err != nil {
+		return fmt.Errorf("can't record the verification of backup %v: %v", bh.Name(), err)
+	}
+	if verifyErr != nil {
+		return fmt.Errorf("backup %v failed its verification: %v", bh.Name(), verifyErr)
+	}
+	log.Infof("Backup %v passed its verification", bh.Name())
+	return nil
+}
+
+// restoreAndCheckBackup restores the backup with the given MANIFEST into a
+// scratch mysqld, and runs the sanity queries against it.
+func restoreAndCheckBackup(ctx context.Context, manifest *mysqlctl.BackupManifest) error {
+	backupTime, err := time.Parse(time.RFC3339, manifest.BackupTime)
+	if err != nil {
+		return fmt.Errorf("can't parse backup time %v: %v", manifest.BackupTime, err)
+	}
+
+	mysqld, mycnf, tabletAlias, cleanup, err := initMysqld(ctx)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	dbName := *initDbNameOverride
+	if dbName == "" {
+		dbName = fmt.Sprintf("vt_%s", *initKeyspace)
+	}
+	params := mysqlctl.RestoreParams{
+		Cnf:                 mycnf,
+		Mysqld:              mysqld,
+		Logger:              logutil.NewConsoleLogger(),
+		Concurrency:         *concurrency,
+		HookExtraEnv:        map[string]string{"TABLET_ALIAS": topoproto.TabletAliasString(tabletAlias)},
+		LocalMetadata:       map[string]string{},
+		DeleteBeforeRestore: true,
+		DbName:              dbName,
+		Keyspace:            *initKeyspace,
+		Shard:               *initShard,
+		// Restore the backup that we verified, even if a newer one showed up.
+		StartTime: backupTime,
+	}
+	if _, err := mysqlctl.Restore(ctx, params); err != nil {
+		return fmt.Errorf("can't restore from backup: %v", err)
+	}
+
+	for _, query := range verifyBackupQueries {
+		qr, err := mysqld.FetchSuperQuery(ctx, query)
+		if err != nil {
+			return fmt.Errorf("sanity query %q failed: %v", query, err)
+		}
+		if len(qr.Rows) == 0 {
+			return fmt.Errorf("sanity query %q returned no rows", query)
+		}
+		log.Infof("Sanity query %q returned %v rows", query, len(qr.Rows))
+	}
+	return nil
+}
+
 func resetReplication(ctx context.Context, pos mysql.Position, mysqld mysqlctl.MysqlDaemon) error {
 	cmds := []string{
 		"STOP SLAVE",
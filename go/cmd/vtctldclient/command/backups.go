@@ -29,13 +29,28 @@ import (
 	"vitess.io/vitess/go/vt/mysqlctl"
 	"vitess.io/vitess/go/vt/topo/topoproto"
 
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
 )
 
 var (
+	// ApplyBackupRetention makes an ApplyBackupRetention gRPC call to a vtctld.
+	ApplyBackupRetention = &cobra.Command{
+		Use:   "ApplyBackupRetention [--dry-run] [<keyspace>|<keyspace/shard>]",
+		Short: "Removes the backups that are not kept by the backup retention policies from the BackupStorage used by vtctld.",
+		Long: `Removes the backups that are not kept by the backup retention policies from the BackupStorage used by vtctld.
+
+The policies are applied to the given shard, to all shards of the given keyspace, or to all shards of all keyspaces.
+Shards without a backup retention policy, either of their own or of their keyspace, are left alone.
+The backups of a shard are removed under the shard lock. With --dry-run, the backups that would be removed are
+reported but not removed, and the shard lock is not taken.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.MaximumNArgs(1),
+		RunE:                  commandApplyBackupRetention,
+	}
 	// Backup makes a Backup gRPC call to a vtctld.
 	Backup = &cobra.Command{
-		Use:                   "Backup [--concurrency <concurrency>] [--allow-primary] <tablet_alias>",
+		Use:                   "Backup [--concurrency <concurrency>] [--allow-primary] [--incremental-from-pos=<pos>|auto] <tablet_alias>",
 		Short:                 "Uses the BackupStorage service on the given tablet to create and store a new backup.",
 		DisableFlagsInUseLine: true,
 		Args:                  cobra.ExactArgs(1),
@@ -70,17 +85,83 @@ If no replica-type tablet can be found, the backup can be taken on the primary i
 	}
 	// RestoreFromBackup makes a RestoreFromBackup gRPC call to a vtctld.
 	RestoreFromBackup = &cobra.Command{
-		Use:                   "RestoreFromBackup [--backup-timestamp|-t <YYYY-mm-DD.HHMMSS>] <tablet_alias>",
-		Short:                 "Stops mysqld on the specified tablet and restores the data from either the latest backup or closest before `backup-timestamp`.",
+		Use:                   "RestoreFromBackup [--backup-timestamp|-t <YYYY-mm-DD.HHMMSS>] [--restore-to-pos <pos> | --restore-to-timestamp <RFC3339 time>] <tablet_alias>",
+		Short:                 "Stops mysqld on the specified tablet and restores the data from either the latest backup or closest before `backup-timestamp`, or to the point in time given by `restore-to-pos` or `restore-to-timestamp`.",
 		DisableFlagsInUseLine: true,
 		Args:                  cobra.ExactArgs(1),
 		RunE:                  commandRestoreFromBackup,
 	}
+	// SetBackupRetentionPolicy makes a SetBackupRetentionPolicy gRPC call to a vtctld.
+	SetBackupRetentionPolicy = &cobra.Command{
+		Use:   "SetBackupRetentionPolicy [--keep-hourly-for <duration>] [--keep-daily-for <duration>] [--keep-monthly-for <duration>] [--min-backups <count>] [--min-verified-backups <count>] [--clear] <keyspace>|<keyspace/shard>",
+		Short: "Sets the backup retention policy of a keyspace, or of a shard.",
+		Long: `Sets the backup retention policy of a keyspace, or of a shard.
+
+A backup is kept if any of the rules of the policy keeps it, and the most recent complete backup is always kept.
+The policy of a shard takes precedence over the policy of its keyspace. The policy replaces the previous one,
+and --clear removes it. The policies are enforced by ApplyBackupRetention, and by vtctld if it runs with
+--backup_retention_interval.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  commandSetBackupRetentionPolicy,
+	}
+	// VerifyBackup makes a VerifyBackup gRPC call to a vtctld.
+	VerifyBackup = &cobra.Command{
+		Use:   "VerifyBackup [--name <backup name>] [--concurrency <concurrency>] <keyspace/shard>",
+		Short: "Reads every file of a backup from the BackupStorage used by vtctld and checks them against the backup MANIFEST.",
+		Long: `Reads every file of a backup from the BackupStorage used by vtctld and checks them against the backup MANIFEST.
+
+The most recent complete backup of the shard is verified, unless --name is specified.
+The result of the verification is recorded in the backup storage. Restoring the backup into a
+scratch mysqld and running sanity queries is done by vtbackup, with --verify_backup.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  commandVerifyBackup,
+	}
 )
 
+var applyBackupRetentionOptions = struct {
+	DryRun bool
+}{}
+
+func commandApplyBackupRetention(cmd *cobra.Command, args []string) error {
+	req := &vtctldatapb.ApplyBackupRetentionRequest{
+		DryRun: applyBackupRetentionOptions.DryRun,
+	}
+
+	if arg := cmd.Flags().Arg(0); strings.Contains(arg, "/") {
+		keyspace, shard, err := topoproto.ParseKeyspaceShard(arg)
+		if err != nil {
+			return err
+		}
+
+		req.Keyspace = keyspace
+		req.Shard = shard
+	} else {
+		req.Keyspace = arg
+	}
+
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.ApplyBackupRetention(commandCtx, req)
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
 var backupOptions = struct {
-	AllowPrimary bool
-	Concurrency  uint64
+	AllowPrimary       bool
+	Concurrency        uint64
+	IncrementalFromPos string
 }{}
 
 func commandBackup(cmd *cobra.Command, args []string) error {
@@ -92,9 +173,10 @@ func commandBackup(cmd *cobra.Command, args []string) error {
 	cli.FinishedParsing(cmd)
 
 	stream, err := client.Backup(commandCtx, &vtctldatapb.BackupRequest{
-		TabletAlias:  tabletAlias,
-		AllowPrimary: backupOptions.AllowPrimary,
-		Concurrency:  backupOptions.Concurrency,
+		TabletAlias:        tabletAlias,
+		AllowPrimary:       backupOptions.AllowPrimary,
+		Concurrency:        backupOptions.Concurrency,
+		IncrementalFromPos: backupOptions.IncrementalFromPos,
 	})
 	if err != nil {
 		return err
@@ -210,7 +292,9 @@ func commandRemoveBackup(cmd *cobra.Command, args []string) error {
 }
 
 var restoreFromBackupOptions = struct {
-	BackupTimestamp string
+	BackupTimestamp    string
+	RestoreToPos       string
+	RestoreToTimestamp string
 }{}
 
 func commandRestoreFromBackup(cmd *cobra.Command, args []string) error {
@@ -219,8 +303,13 @@ func commandRestoreFromBackup(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if restoreFromBackupOptions.RestoreToPos != "" && restoreFromBackupOptions.RestoreToTimestamp != "" {
+		return fmt.Errorf("--restore-to-pos and --restore-to-timestamp are mutually exclusive")
+	}
+
 	req := &vtctldatapb.RestoreFromBackupRequest{
-		TabletAlias: alias,
+		TabletAlias:  alias,
+		RestoreToPos: restoreFromBackupOptions.RestoreToPos,
 	}
 
 	if restoreFromBackupOptions.BackupTimestamp != "" {
@@ -232,6 +321,15 @@ func commandRestoreFromBackup(cmd *cobra.Command, args []string) error {
 		req.BackupTime = protoutil.TimeToProto(t)
 	}
 
+	if restoreFromBackupOptions.RestoreToTimestamp != "" {
+		t, err := time.Parse(time.RFC3339, restoreFromBackupOptions.RestoreToTimestamp)
+		if err != nil {
+			return err
+		}
+
+		req.RestoreToTimestamp = protoutil.TimeToProto(t)
+	}
+
 	cli.FinishedParsing(cmd)
 
 	stream, err := client.RestoreFromBackup(commandCtx, req)
@@ -252,9 +350,112 @@ func commandRestoreFromBackup(cmd *cobra.Command, args []string) error {
 	}
 }
 
+var setBackupRetentionPolicyOptions = struct {
+	KeepHourlyFor      time.Duration
+	KeepDailyFor       time.Duration
+	KeepMonthlyFor     time.Duration
+	MinBackups         uint32
+	MinVerifiedBackups uint32
+	Clear              bool
+}{}
+
+func commandSetBackupRetentionPolicy(cmd *cobra.Command, args []string) error {
+	req := &vtctldatapb.SetBackupRetentionPolicyRequest{}
+
+	if arg := cmd.Flags().Arg(0); strings.Contains(arg, "/") {
+		keyspace, shard, err := topoproto.ParseKeyspaceShard(arg)
+		if err != nil {
+			return err
+		}
+
+		req.Keyspace = keyspace
+		req.Shard = shard
+	} else {
+		req.Keyspace = arg
+	}
+
+	if !setBackupRetentionPolicyOptions.Clear {
+		policy := &topodatapb.BackupRetentionPolicy{
+			MinBackups:         setBackupRetentionPolicyOptions.MinBackups,
+			MinVerifiedBackups: setBackupRetentionPolicyOptions.MinVerifiedBackups,
+		}
+
+		if setBackupRetentionPolicyOptions.KeepHourlyFor > 0 {
+			policy.KeepHourlyFor = protoutil.DurationToProto(setBackupRetentionPolicyOptions.KeepHourlyFor)
+		}
+
+		if setBackupRetentionPolicyOptions.KeepDailyFor > 0 {
+			policy.KeepDailyFor = protoutil.DurationToProto(setBackupRetentionPolicyOptions.KeepDailyFor)
+		}
+
+		if setBackupRetentionPolicyOptions.KeepMonthlyFor > 0 {
+			policy.KeepMonthlyFor = protoutil.DurationToProto(setBackupRetentionPolicyOptions.KeepMonthlyFor)
+		}
+
+		req.Policy = policy
+	}
+
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.SetBackupRetentionPolicy(commandCtx, req)
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+var verifyBackupOptions = struct {
+	Name        string
+	Concurrency uint64
+}{}
+
+func commandVerifyBackup(cmd *cobra.Command, args []string) error {
+	keyspace, shard, err := topoproto.ParseKeyspaceShard(cmd.Flags().Arg(0))
+	if err != nil {
+		return err
+	}
+
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.VerifyBackup(commandCtx, &vtctldatapb.VerifyBackupRequest{
+		Keyspace:    keyspace,
+		Shard:       shard,
+		Name:        verifyBackupOptions.Name,
+		Concurrency: verifyBackupOptions.Concurrency,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	if resp.Status != mysqlctl.BackupVerificationPassed {
+		return fmt.Errorf("backup %v failed its verification: %v", resp.Name, resp.Error)
+	}
+
+	return nil
+}
+
 func init() {
+	ApplyBackupRetention.Flags().BoolVar(&applyBackupRetentionOptions.DryRun, "dry-run", false, "Report the backups that would be removed, without removing them.")
+	Root.AddCommand(ApplyBackupRetention)
+
 	Backup.Flags().BoolVar(&backupOptions.AllowPrimary, "allow-primary", false, "Allow the primary of a shard to be used for the backup. WARNING: If using the builtin backup engine, this will shutdown mysqld on the primary and stop writes for the duration of the backup.")
 	Backup.Flags().Uint64Var(&backupOptions.Concurrency, "concurrency", 4, "Specifies the number of compression/checksum jobs to run simultaneously.")
+	Backup.Flags().StringVar(&backupOptions.IncrementalFromPos, "incremental-from-pos", "", "Position of the previous backup. Takes an incremental backup of the binary logs since that position, without stopping mysqld. Use 'auto' to start from the last successful backup.")
 	Root.AddCommand(Backup)
 
 	BackupShard.Flags().BoolVar(&backupShardOptions.AllowPrimary, "allow-primary", false, "Allow the primary of a shard to be used for the backup. WARNING: If using the builtin backup engine, this will shutdown mysqld on the primary and stop writes for the duration of the backup.")
@@ -268,5 +469,19 @@ func init() {
 	Root.AddCommand(RemoveBackup)
 
 	RestoreFromBackup.Flags().StringVarP(&restoreFromBackupOptions.BackupTimestamp, "backup-timestamp", "t", "", "Use the backup taken at, or closest before, this timestamp. Omit to use the latest backup. Timestamp format is \"YYYY-mm-DD.HHMMSS\".")
+	RestoreFromBackup.Flags().StringVar(&restoreFromBackupOptions.RestoreToPos, "restore-to-pos", "", "Run a point in time recovery that ends with the given position. This will attempt to use one full backup followed by zero or more incremental backups.")
+	RestoreFromBackup.Flags().StringVar(&restoreFromBackupOptions.RestoreToTimestamp, "restore-to-timestamp", "", "Run a point in time recovery that restores up to, and excluding, the given timestamp in RFC3339 format (e.g. '2006-01-02T15:04:05Z'). This will attempt to use one full backup followed by zero or more incremental backups.")
 	Root.AddCommand(RestoreFromBackup)
+
+	SetBackupRetentionPolicy.Flags().DurationVar(&setBackupRetentionPolicyOptions.KeepHourlyFor, "keep-hourly-for", 0, "Keep the most recent backup of each hour, for the backups taken within this duration.")
+	SetBackupRetentionPolicy.Flags().DurationVar(&setBackupRetentionPolicyOptions.KeepDailyFor, "keep-daily-for", 0, "Keep the most recent backup of each day, for the backups taken within this duration.")
+	SetBackupRetentionPolicy.Flags().DurationVar(&setBackupRetentionPolicyOptions.KeepMonthlyFor, "keep-monthly-for", 0, "Keep the most recent backup of each month, for the backups taken within this duration.")
+	SetBackupRetentionPolicy.Flags().Uint32Var(&setBackupRetentionPolicyOptions.MinBackups, "min-backups", 1, "Always keep this number of most recent complete backups.")
+	SetBackupRetentionPolicy.Flags().Uint32Var(&setBackupRetentionPolicyOptions.MinVerifiedBackups, "min-verified-backups", 0, "Always keep this number of most recent backups that passed their verification.")
+	SetBackupRetentionPolicy.Flags().BoolVar(&setBackupRetentionPolicyOptions.Clear, "clear", false, "Clear the backup retention policy instead of setting it.")
+	Root.AddCommand(SetBackupRetentionPolicy)
+
+	VerifyBackup.Flags().StringVar(&verifyBackupOptions.Name, "name", "", "Name of the backup to verify. Omit to verify the most recent complete backup.")
+	VerifyBackup.Flags().Uint64Var(&verifyBackupOptions.Concurrency, "concurrency", 4, "Specifies the number of backup files to read simultaneously.")
+	Root.AddCommand(VerifyBackup)
 }
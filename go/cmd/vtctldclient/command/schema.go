@@ -40,7 +40,7 @@ import (
 var (
 	// ApplySchema makes an ApplySchema gRPC call to a vtctld.
 	ApplySchema = &cobra.Command{
-		Use:   "ApplySchema [--allow-long-unavailability] [--ddl-strategy <strategy>] [--uuid <uuid> ...] [--migration-context <context>] [--wait-replicas-timeout <duration>] [--skip-preflight] [--caller-id <caller_id>] {--sql-file <file> | --sql <sql>} <keyspace>",
+		Use:   "ApplySchema [--allow-long-unavailability] [--ddl-strategy <strategy>] [--uuid <uuid> ...] [--migration-context <context>] [--wait-replicas-timeout <duration>] [--skip-preflight] [--dry-run] [--caller-id <caller_id>] {--sql-file <file> | --sql <sql>} <keyspace>",
 		Short: "Applies the schema change to the specified keyspace on every primary, running in parallel on all shards. The changes are then propagated to replicas via replication.",
 		Long: `Applies the schema change to the specified keyspace on every primary, running in parallel on all shards. The changes are then propagated to replicas via replication.
 
@@ -48,6 +48,8 @@ If --allow-long-unavailability is set, schema changes affecting a large number o
 --ddl-strategy is used to instruct migrations via vreplication, gh-ost or pt-osc with optional parameters.
 --migration-context allows the user to specify a custom migration context for online DDL migrations.
 If --skip-preflight, SQL goes directly to shards without going through sanity checks.
+If --dry-run, online DDL migrations are not submitted. Instead, every shard estimates the duration and the extra disk usage
+of each migration, and reports whether it is eligible for INSTANT DDL and whether it makes risky changes.
 
 The --uuid and --sql flags are repeatable, so they can be passed multiple times to build a list of values.
 For --uuid, this is used like "--uuid $first_uuid --uuid $second_uuid".
@@ -102,6 +104,7 @@ var applySchemaOptions = struct {
 	MigrationContext        string
 	WaitReplicasTimeout     time.Duration
 	SkipPreflight           bool
+	DryRun                  bool
 	CallerID                string
 }{}
 
@@ -146,11 +149,22 @@ func commandApplySchema(cmd *cobra.Command, args []string) error {
 		MigrationContext:        applySchemaOptions.MigrationContext,
 		WaitReplicasTimeout:     protoutil.DurationToProto(applySchemaOptions.WaitReplicasTimeout),
 		CallerId:                cid,
+		DryRun:                  applySchemaOptions.DryRun,
 	})
 	if err != nil {
 		return err
 	}
 
+	if applySchemaOptions.DryRun {
+		data, err := cli.MarshalJSON(resp.MigrationEstimates)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s\n", data)
+		return nil
+	}
+
 	fmt.Println(strings.Join(resp.UuidList, "\n"))
 	return nil
 }
@@ -292,6 +306,7 @@ func init() {
 	ApplySchema.Flags().StringVar(&applySchemaOptions.MigrationContext, "migration-context", "", "For Online DDL, optionally supply a custom unique string used as context for the migration(s) in this command. By default a unique context is auto-generated by Vitess.")
 	ApplySchema.Flags().DurationVar(&applySchemaOptions.WaitReplicasTimeout, "wait-replicas-timeout", wrangler.DefaultWaitReplicasTimeout, "Amount of time to wait for replicas to receive the schema change via replication.")
 	ApplySchema.Flags().BoolVar(&applySchemaOptions.SkipPreflight, "skip-preflight", false, "Skip pre-apply schema checks, and directly forward schema change query to shards.")
+	ApplySchema.Flags().BoolVar(&applySchemaOptions.DryRun, "dry-run", false, "Do not submit online DDL migrations; instead, report per shard estimates of their duration and extra disk usage.")
 	ApplySchema.Flags().StringVar(&applySchemaOptions.CallerID, "caller-id", "", "Effective caller ID used for the operation and should map to an ACL name which grants this identity the necessary permissions to perform the operation (this is only necessary when strict table ACLs are used).")
 	ApplySchema.Flags().StringSliceVar(&applySchemaOptions.SQL, "sql", nil, "Semicolon-delimited, repeatable SQL commands to apply. Exactly one of --sql|--sql-file is required.")
 	ApplySchema.Flags().StringVar(&applySchemaOptions.SQLFile, "sql-file", "", "Path to a file containing semicolon-delimited SQL commands to apply. Exactly one of --sql|--sql-file is required.")
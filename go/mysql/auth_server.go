@@ -619,6 +619,32 @@ func negotiateAuthMethod(conn *Conn, as AuthServer, user string, requestedAuth A
 	return nil, vterrors.Errorf(vtrpc.Code_INTERNAL, "unknown auth method requested: %s", string(requestedAuth))
 }
 
+// AuthenticateWithPassword authenticates a user that sent its password in
+// clear text outside of the MySQL protocol, e.g. over HTTP, with the first auth
+// method of the AuthServer that can check a password: mysql_clear_password,
+// dialog or mysql_native_password. There is no MySQL connection, so the
+// methods that rely on one (e.g. client certificates) reject the user.
+func AuthenticateWithPassword(as AuthServer, user, password string, remoteAddr net.Addr) (Getter, error) {
+	conn := &Conn{User: user}
+	for _, m := range as.AuthMethods() {
+		if !m.HandleUser(conn, user) {
+			continue
+		}
+		switch m.Name() {
+		case MysqlClearPassword, MysqlDialog:
+			return m.HandleAuthPluginData(conn, user, nil, append([]byte(password), 0), remoteAddr)
+		case MysqlNativePassword:
+			serverAuthPluginData, err := m.AuthPluginData()
+			if err != nil {
+				return nil, err
+			}
+			salt := serverAuthPluginData[:len(serverAuthPluginData)-1]
+			return m.HandleAuthPluginData(conn, user, serverAuthPluginData, ScrambleMysqlNativePassword(salt, []byte(password)), remoteAddr)
+		}
+	}
+	return nil, NewSQLError(ERAccessDeniedError, SSAccessDeniedError, "Access denied for user '%v'", user)
+}
+
 func readPacketPasswordString(c *Conn) (string, error) {
 	// Read a packet, the password is the payload, as a
 	// zero terminated string.
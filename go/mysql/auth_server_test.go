@@ -17,9 +17,11 @@ limitations under the license.
 package mysql
 
 import (
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestVerifyHashedMysqlNativePassword(t *testing.T) {
@@ -53,3 +55,24 @@ func TestVerifyHashedCachingSha2Password(t *testing.T) {
 	passwordHash[0] = 0x00
 	assert.False(t, VerifyHashedMysqlNativePassword(reply, salt, passwordHash), "password hash match")
 }
+
+func TestAuthenticateWithPassword(t *testing.T) {
+	jsonConfig := `{"mysql_user": [{"Password": "password", "UserData": "user.name"}]}`
+	auth := NewAuthServerStatic("", jsonConfig, 0)
+	defer auth.close()
+	addr := &net.IPAddr{IP: net.ParseIP("127.0.0.1"), Zone: ""}
+
+	getter, err := AuthenticateWithPassword(auth, "mysql_user", "password", addr)
+	require.NoError(t, err)
+	assert.Equal(t, "user.name", getter.Get().Username)
+
+	_, err = AuthenticateWithPassword(auth, "mysql_user", "wrong", addr)
+	assert.Error(t, err)
+
+	_, err = AuthenticateWithPassword(auth, "unknown_user", "password", addr)
+	assert.Error(t, err)
+
+	// The none auth server takes all comers.
+	_, err = AuthenticateWithPassword(NewAuthServerNone(), "anyone", "", addr)
+	assert.NoError(t, err)
+}
@@ -173,7 +173,7 @@ func Connect(ctx context.Context, params *ConnParams) (*Conn, error) {
 // Ping implements mysql ping command.
 func (c *Conn) Ping() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComPing
 
@@ -299,6 +299,26 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		return NewSQLError(CRSSLConnectionError, SSUnknownSQLState, "server doesn't support ClientSessionTrack but client asked for it")
 	}
 
+	// Use the compressed protocol if the server supports the algorithm we
+	// asked for. If it doesn't, we silently fall back to no compression.
+	switch params.Compression {
+	case "":
+	case CompressionZlib:
+		c.Capabilities |= capabilities & CapabilityClientCompress
+	case CompressionZstd:
+		level := params.ZstdCompressionLevel
+		if level == 0 {
+			level = DefaultZstdCompressionLevel
+		}
+		if !validZstdCompressionLevel(level) {
+			return NewSQLError(CRUnknownError, SSUnknownSQLState, "invalid zstd compression level: %v", params.ZstdCompressionLevel)
+		}
+		c.Capabilities |= capabilities & CapabilityClientZstdCompressionAlgorithm
+		c.zstdCompressionLevel = level
+	default:
+		return NewSQLError(CRUnknownError, SSUnknownSQLState, "unsupported compression algorithm: %v", params.Compression)
+	}
+
 	// Build and send our handshake response 41.
 	// Note this one will never have SSL flag on.
 	if err := c.writeHandshakeResponse41(capabilities, scrambledPassword, charset, params); err != nil {
@@ -310,6 +330,11 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		return err
 	}
 
+	// The server compresses all the packets after the OK packet.
+	if err := c.enableCompression(); err != nil {
+		return NewSQLError(CRUnknownError, SSUnknownSQLState, "cannot enable compression: %v", err)
+	}
+
 	// If the server didn't support DbName in its handshake, set
 	// it now. This is what the 'mysql' client does.
 	if capabilities&CapabilityClientConnectWithDB == 0 && params.DbName != "" {
@@ -541,7 +566,9 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		CapabilityClientFoundRows&uint32(params.Flags) |
 		// If the server supported
 		// CapabilityClientSessionTrack, we also support it.
-		c.Capabilities&CapabilityClientSessionTrack
+		c.Capabilities&CapabilityClientSessionTrack |
+		// The compression algorithm we picked, if any.
+		c.Capabilities&(CapabilityClientCompress|CapabilityClientZstdCompressionAlgorithm)
 
 	// FIXME(alainjobart) add multi statement.
 
@@ -568,6 +595,11 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		length++
 	}
 
+	// The zstd compression level comes last.
+	if capabilityFlags&CapabilityClientZstdCompressionAlgorithm != 0 {
+		length++
+	}
+
 	data, pos := c.startEphemeralPacketWithHeader(length)
 
 	// Client capability flags.
@@ -604,6 +636,10 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 	// Assume native client during response
 	pos = writeNullString(data, pos, string(c.authPluginName))
 
+	if capabilityFlags&CapabilityClientZstdCompressionAlgorithm != 0 {
+		pos = writeByte(data, pos, byte(c.zstdCompressionLevel))
+	}
+
 	// Sanity-check the length.
 	if pos != len(data) {
 		return NewSQLError(CRMalformedPacket, SSUnknownSQLState, "writeHandshakeResponse41: only packed %v bytes, out of %v allocated", pos, len(data))
@@ -18,6 +18,7 @@ package collations
 
 import (
 	"math"
+	"unicode"
 
 	"vitess.io/vitess/go/mysql/collations/internal/charset"
 )
@@ -176,6 +177,34 @@ func Slice(collation Collation, input []byte, from, to int) []byte {
 	return charset.Slice(collation.Charset(), input, from, to)
 }
 
+// Length returns the number of characters in `input`, which is encoded with
+// the charset for the given collation.
+func Length(collation Collation, input []byte) int {
+	return charset.Length(collation.Charset(), input)
+}
+
+// ToUpper returns a copy of `input`, which is encoded with the charset for the given
+// collation, with all its characters mapped to upper case. Binary strings have no
+// notion of case, so they are returned unchanged.
+func ToUpper(collation Collation, input []byte) []byte {
+	return mapCase(collation, input, unicode.ToUpper)
+}
+
+// ToLower returns a copy of `input`, which is encoded with the charset for the given
+// collation, with all its characters mapped to lower case. Binary strings have no
+// notion of case, so they are returned unchanged.
+func ToLower(collation Collation, input []byte) []byte {
+	return mapCase(collation, input, unicode.ToLower)
+}
+
+func mapCase(collation Collation, input []byte, mapping func(rune) rune) []byte {
+	cs := collation.Charset()
+	if _, binary := cs.(charset.Charset_binary); binary {
+		return append([]byte(nil), input...)
+	}
+	return charset.MapRunes(make([]byte, 0, len(input)), cs, input, mapping)
+}
+
 // Validate returns whether the given `input` is properly encoded with the
 // character set for the given collation.
 func Validate(collation Collation, input []byte) bool {
@@ -21,14 +21,38 @@ func Slice(charset Charset, input []byte, from, to int) []byte {
 		return charset.Slice(input, from, to)
 	}
 	iter := input
+	start := len(input)
 	for i := 0; i < to; i++ {
+		if i == from {
+			start = len(input) - len(iter)
+		}
 		r, size := charset.DecodeRune(iter)
 		if r == RuneError && size < 2 {
 			break
 		}
 		iter = iter[size:]
 	}
-	return input[:len(input)-len(iter)]
+	end := len(input) - len(iter)
+	if start > end {
+		return input[end:end]
+	}
+	return input[start:end]
+}
+
+func Length(charset Charset, input []byte) int {
+	if charset, ok := charset.(interface{ Length([]byte) int }); ok {
+		return charset.Length(input)
+	}
+	var count int
+	for len(input) > 0 {
+		_, size := charset.DecodeRune(input)
+		if size < 1 {
+			size = 1
+		}
+		input = input[size:]
+		count++
+	}
+	return count
 }
 
 func Validate(charset Charset, input []byte) bool {
@@ -44,3 +68,28 @@ func Validate(charset Charset, input []byte) bool {
 	}
 	return true
 }
+
+// MapRunes appends to dst the result of applying mapping to every codepoint in input,
+// which is encoded with the given charset. Codepoints that cannot be decoded, or whose
+// mapping cannot be encoded with the charset, are copied unchanged.
+func MapRunes(dst []byte, charset Charset, input []byte, mapping func(rune) rune) []byte {
+	var buf [4]byte
+	for len(input) > 0 {
+		r, size := charset.DecodeRune(input)
+		if size < 1 {
+			size = 1
+		}
+		if r != RuneError || size > 1 {
+			if mapped := mapping(r); mapped != r {
+				if w := charset.EncodeRune(buf[:], mapped); w > 0 {
+					dst = append(dst, buf[:w]...)
+					input = input[size:]
+					continue
+				}
+			}
+		}
+		dst = append(dst, input[:size]...)
+		input = input[size:]
+	}
+	return dst
+}
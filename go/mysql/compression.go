@@ -0,0 +1,405 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"vitess.io/vitess/go/stats"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// The compressed protocol wraps regular packets into compressed frames.
+// Each frame has a 7 bytes header: the length of the compressed payload (3 bytes),
+// a sequence number that is independent from the one of the packets inside the
+// frame (1 byte), and the length of the payload before compression (3 bytes),
+// which is 0 if the payload was sent uncompressed.
+// See https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_basic_compression.html
+const (
+	compressedHeaderSize = 7
+
+	// minCompressLength is the size under which payloads are sent uncompressed,
+	// the same threshold that MySQL uses.
+	minCompressLength = 50
+
+	// DefaultZstdCompressionLevel is the zstd level used by clients that
+	// don't request a specific one. It is the same default as MySQL.
+	DefaultZstdCompressionLevel = 3
+
+	// minZstdCompressionLevel and maxZstdCompressionLevel are the bounds
+	// of the zstd levels accepted by MySQL.
+	minZstdCompressionLevel = 1
+	maxZstdCompressionLevel = 22
+
+	// maxUncompressedFrameLength is the largest uncompressed length that the
+	// 3 bytes of the frame header can hold.
+	maxUncompressedFrameLength = 1<<24 - 1
+)
+
+const (
+	// CompressionZlib is the name of the zlib algorithm in ConnParams.Compression.
+	CompressionZlib = "zlib"
+
+	// CompressionZstd is the name of the zstd algorithm in ConnParams.Compression.
+	CompressionZstd = "zstd"
+)
+
+const (
+	compressionDirectionRead  = "read"
+	compressionDirectionWrite = "write"
+)
+
+var (
+	compressionRawBytes        = stats.NewCountersWithSingleLabel("MysqlCompressionRawBytes", "Size before compression of the data sent and received by MySQL connections using the compressed protocol", "direction")
+	compressionCompressedBytes = stats.NewCountersWithSingleLabel("MysqlCompressionCompressedBytes", "Size on the wire of the data sent and received by MySQL connections using the compressed protocol", "direction")
+)
+
+var (
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+
+	zstdEncodersMu sync.Mutex
+	zstdEncoders   = map[zstd.EncoderLevel]*zstd.Encoder{}
+)
+
+// getZstdDecoder returns the decoder shared by all the connections. DecodeAll
+// can be called concurrently on a single zstd.Decoder.
+func getZstdDecoder() (*zstd.Decoder, error) {
+	zstdDecoderOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxUncompressedFrameLength))
+	})
+	return zstdDecoder, zstdDecoderErr
+}
+
+// validZstdCompressionLevel returns true if MySQL accepts the given zstd
+// compression level.
+func validZstdCompressionLevel(level int) bool {
+	return level >= minZstdCompressionLevel && level <= maxZstdCompressionLevel
+}
+
+// getZstdEncoder returns the encoder shared by all the connections that use
+// the given compression level. EncodeAll can be called concurrently on a
+// single zstd.Encoder.
+func getZstdEncoder(level int) (*zstd.Encoder, error) {
+	if !validZstdCompressionLevel(level) {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid zstd compression level: %v", level)
+	}
+	encLevel := zstd.EncoderLevelFromZstd(level)
+
+	zstdEncodersMu.Lock()
+	defer zstdEncodersMu.Unlock()
+	if enc, ok := zstdEncoders[encLevel]; ok {
+		return enc, nil
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(encLevel))
+	if err != nil {
+		return nil, err
+	}
+	zstdEncoders[encLevel] = enc
+	return enc, nil
+}
+
+// compressor compresses and decompresses the payload of compressed frames.
+type compressor interface {
+	compress(dst, src []byte) ([]byte, error)
+	// decompress appends the decompressed src to dst. It fails without decompressing
+	// more than uncompressedLength bytes if src is larger once decompressed, so that
+	// a small frame can't use up the memory of the process.
+	decompress(dst, src []byte, uncompressedLength int) ([]byte, error)
+}
+
+func errUncompressedLength(uncompressedLength int) error {
+	return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid compressed packet, more than %v uncompressed bytes", uncompressedLength)
+}
+
+type zlibCompressor struct {
+	buf    bytes.Buffer
+	writer *zlib.Writer
+	reader io.ReadCloser
+}
+
+func (z *zlibCompressor) compress(dst, src []byte) ([]byte, error) {
+	z.buf.Reset()
+	if z.writer == nil {
+		z.writer = zlib.NewWriter(&z.buf)
+	} else {
+		z.writer.Reset(&z.buf)
+	}
+	if _, err := z.writer.Write(src); err != nil {
+		return nil, err
+	}
+	if err := z.writer.Close(); err != nil {
+		return nil, err
+	}
+	return append(dst, z.buf.Bytes()...), nil
+}
+
+func (z *zlibCompressor) decompress(dst, src []byte, uncompressedLength int) ([]byte, error) {
+	var err error
+	if z.reader == nil {
+		z.reader, err = zlib.NewReader(bytes.NewReader(src))
+	} else {
+		err = z.reader.(zlib.Resetter).Reset(bytes.NewReader(src), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cap(dst)-len(dst) < uncompressedLength+1 {
+		dst = append(make([]byte, 0, len(dst)+uncompressedLength+1), dst...)
+	}
+	out := bytes.NewBuffer(dst)
+	n, err := out.ReadFrom(io.LimitReader(z.reader, int64(uncompressedLength)+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > int64(uncompressedLength) {
+		return nil, errUncompressedLength(uncompressedLength)
+	}
+	return out.Bytes(), nil
+}
+
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor(level int) (*zstdCompressor, error) {
+	encoder, err := getZstdEncoder(level)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := getZstdDecoder()
+	if err != nil {
+		return nil, err
+	}
+	return &zstdCompressor{encoder: encoder, decoder: decoder}, nil
+}
+
+func (z *zstdCompressor) compress(dst, src []byte) ([]byte, error) {
+	return z.encoder.EncodeAll(src, dst), nil
+}
+
+func (z *zstdCompressor) decompress(dst, src []byte, uncompressedLength int) ([]byte, error) {
+	var header zstd.Header
+	if err := header.Decode(src); err == nil && header.HasFCS && header.FrameContentSize > uint64(uncompressedLength) {
+		return nil, errUncompressedLength(uncompressedLength)
+	}
+	if cap(dst)-len(dst) < uncompressedLength {
+		dst = append(make([]byte, 0, len(dst)+uncompressedLength), dst...)
+	}
+	// The decoder doesn't decompress more than maxUncompressedFrameLength bytes.
+	data, err := z.decoder.DecodeAll(src, dst)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)-len(dst) > uncompressedLength {
+		return nil, errUncompressedLength(uncompressedLength)
+	}
+	return data, nil
+}
+
+// compressedReader reads compressed frames from the underlying reader,
+// and returns the packets they contain.
+type compressedReader struct {
+	c          *Conn
+	r          io.Reader
+	compressor compressor
+
+	// header is the header of the current frame
+	header [compressedHeaderSize]byte
+	// payload is the compressed payload of the current frame
+	payload []byte
+	// data is the uncompressed content of the current frame, and pos is the
+	// position in data of the next byte to return.
+	data []byte
+	pos  int
+}
+
+// Read implements io.Reader.
+func (cr *compressedReader) Read(p []byte) (int, error) {
+	for cr.pos == len(cr.data) {
+		if err := cr.readFrame(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, cr.data[cr.pos:])
+	cr.pos += n
+	return n, nil
+}
+
+func (cr *compressedReader) readFrame() error {
+	if _, err := io.ReadFull(cr.r, cr.header[:]); err != nil {
+		return err
+	}
+
+	length := int(uint32(cr.header[0]) | uint32(cr.header[1])<<8 | uint32(cr.header[2])<<16)
+	sequence := cr.header[3]
+	uncompressedLength := int(uint32(cr.header[4]) | uint32(cr.header[5])<<8 | uint32(cr.header[6])<<16)
+
+	if sequence != cr.c.compressedSequence {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid compressed sequence, expected %v got %v", cr.c.compressedSequence, sequence)
+	}
+	cr.c.compressedSequence++
+
+	if cap(cr.payload) < length {
+		cr.payload = make([]byte, length)
+	}
+	cr.payload = cr.payload[:length]
+	if _, err := io.ReadFull(cr.r, cr.payload); err != nil {
+		return vterrors.Wrapf(err, "io.ReadFull(compressed packet body of length %v) failed", length)
+	}
+	compressionCompressedBytes.Add(compressionDirectionRead, int64(compressedHeaderSize+length))
+
+	cr.pos = 0
+	if uncompressedLength == 0 {
+		// The payload was too small to be compressed.
+		cr.data = append(cr.data[:0], cr.payload...)
+	} else {
+		data, err := cr.compressor.decompress(cr.data[:0], cr.payload, uncompressedLength)
+		if err != nil {
+			return vterrors.Wrapf(err, "cannot decompress packet")
+		}
+		if len(data) != uncompressedLength {
+			return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid compressed packet, expected %v uncompressed bytes got %v", uncompressedLength, len(data))
+		}
+		cr.data = data
+	}
+	compressionRawBytes.Add(compressionDirectionRead, int64(len(cr.data)))
+	return nil
+}
+
+// compressedWriter wraps all the data written to it into compressed frames
+// written to the underlying writer. Every call to Write is sent right away
+// in one or more frames, so the writer should be buffered to get a good
+// compression ratio.
+type compressedWriter struct {
+	c          *Conn
+	w          io.Writer
+	compressor compressor
+	frame      []byte
+}
+
+// Write implements io.Writer.
+func (cw *compressedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > MaxPacketSize {
+			chunk = chunk[:MaxPacketSize]
+		}
+		if err := cw.writeFrame(chunk); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+func (cw *compressedWriter) writeFrame(data []byte) error {
+	// reserve space for the header, which is filled once the payload is known
+	frame := append(cw.frame[:0], 0, 0, 0, 0, 0, 0, 0)
+	uncompressedLength := 0
+	if len(data) >= minCompressLength {
+		compressed, err := cw.compressor.compress(frame, data)
+		if err != nil {
+			return vterrors.Wrapf(err, "cannot compress packet")
+		}
+		// Only use the compressed payload if it is actually smaller.
+		if len(compressed)-compressedHeaderSize < len(data) {
+			frame = compressed
+			uncompressedLength = len(data)
+		}
+	}
+	if uncompressedLength == 0 {
+		frame = append(frame[:compressedHeaderSize], data...)
+	}
+	cw.frame = frame
+
+	length := len(frame) - compressedHeaderSize
+	frame[0] = byte(length)
+	frame[1] = byte(length >> 8)
+	frame[2] = byte(length >> 16)
+	frame[3] = cw.c.compressedSequence
+	frame[4] = byte(uncompressedLength)
+	frame[5] = byte(uncompressedLength >> 8)
+	frame[6] = byte(uncompressedLength >> 16)
+
+	if n, err := cw.w.Write(frame); err != nil {
+		return vterrors.Wrapf(err, "Write(compressed packet) failed")
+	} else if n != len(frame) {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "Write(compressed packet) returned a short write: %v < %v", n, len(frame))
+	}
+	cw.c.compressedSequence++
+
+	compressionRawBytes.Add(compressionDirectionWrite, int64(len(data)))
+	compressionCompressedBytes.Add(compressionDirectionWrite, int64(len(frame)))
+	return nil
+}
+
+// enableCompression switches the connection to the compressed protocol, using
+// the algorithm negotiated in the handshake. It must be called right after the
+// server has sent the OK packet that ends the authentication phase, on both sides.
+func (c *Conn) enableCompression() error {
+	var newCompressor func() (compressor, error)
+	switch {
+	case c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0:
+		level := c.zstdCompressionLevel
+		if level == 0 {
+			level = DefaultZstdCompressionLevel
+		}
+		newCompressor = func() (compressor, error) { return newZstdCompressor(level) }
+	case c.Capabilities&CapabilityClientCompress != 0:
+		newCompressor = func() (compressor, error) { return &zlibCompressor{}, nil }
+	default:
+		return nil
+	}
+
+	// The reader and the writer can be used concurrently, so they can't share a compressor.
+	readCompressor, err := newCompressor()
+	if err != nil {
+		return err
+	}
+	writeCompressor, err := newCompressor()
+	if err != nil {
+		return err
+	}
+
+	c.compressedReader = &compressedReader{c: c, r: c.getReader(), compressor: readCompressor}
+	c.compressedWriter = &compressedWriter{c: c, w: c.conn, compressor: writeCompressor}
+	return nil
+}
+
+// resetSequence resets the sequence numbers of the packets and, if the
+// compressed protocol is used, of the compressed frames. It must be called
+// at the start of every command.
+func (c *Conn) resetSequence() {
+	c.sequence = 0
+	c.compressedSequence = 0
+}
+
+// IsCompressed returns true if the connection uses the compressed protocol.
+func (c *Conn) IsCompressed() bool {
+	return c.compressedReader != nil
+}
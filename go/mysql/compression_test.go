@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressedFrames(t *testing.T) {
+	random := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	payloads := map[string][]byte{
+		"small":           []byte("select 1"),
+		"compressible":    bytes.Repeat([]byte("select * from t1 where id = 1; "), 100),
+		"incompressible":  random,
+		"multiple frames": bytes.Repeat([]byte{'a'}, MaxPacketSize+1000),
+	}
+
+	compressors := map[string]func() compressor{
+		CompressionZlib: func() compressor { return &zlibCompressor{} },
+		CompressionZstd: func() compressor {
+			z, err := newZstdCompressor(DefaultZstdCompressionLevel)
+			require.NoError(t, err)
+			return z
+		},
+	}
+
+	for algorithm, newCompressor := range compressors {
+		for name, payload := range payloads {
+			t.Run(algorithm+"/"+name, func(t *testing.T) {
+				var wire bytes.Buffer
+				writer := &compressedWriter{c: &Conn{}, w: &wire, compressor: newCompressor()}
+				reader := &compressedReader{c: &Conn{}, r: &wire, compressor: newCompressor()}
+
+				// Write the payload twice, to check the compressors can be reused.
+				for i := 0; i < 2; i++ {
+					n, err := writer.Write(payload)
+					require.NoError(t, err)
+					assert.Equal(t, len(payload), n)
+				}
+
+				got := make([]byte, 2*len(payload))
+				_, err := io.ReadFull(reader, got)
+				require.NoError(t, err)
+				assert.True(t, bytes.Equal(got, append(payload, payload...)), "payload mismatch")
+				assert.Equal(t, writer.c.compressedSequence, reader.c.compressedSequence)
+				assert.Zero(t, wire.Len())
+			})
+		}
+	}
+}
+
+func TestCompressedSequenceMismatch(t *testing.T) {
+	var wire bytes.Buffer
+	writer := &compressedWriter{c: &Conn{}, w: &wire, compressor: &zlibCompressor{}}
+	reader := &compressedReader{c: &Conn{compressedSequence: 1}, r: &wire, compressor: &zlibCompressor{}}
+
+	_, err := writer.Write([]byte("select 1"))
+	require.NoError(t, err)
+
+	_, err = reader.Read(make([]byte, 16))
+	assert.EqualError(t, err, "invalid compressed sequence, expected 1 got 0")
+}
+
+func TestCompressedFrameUncompressedLength(t *testing.T) {
+	payload := bytes.Repeat([]byte{'a'}, 1<<20)
+	compressors := map[string]func() compressor{
+		CompressionZlib: func() compressor { return &zlibCompressor{} },
+		CompressionZstd: func() compressor {
+			z, err := newZstdCompressor(DefaultZstdCompressionLevel)
+			require.NoError(t, err)
+			return z
+		},
+	}
+	for algorithm, newCompressor := range compressors {
+		t.Run(algorithm, func(t *testing.T) {
+			compressed, err := newCompressor().compress(nil, payload)
+			require.NoError(t, err)
+
+			// A frame that claims to be much smaller than its payload once decompressed.
+			const uncompressedLength = 100
+			frame := []byte{byte(len(compressed)), byte(len(compressed) >> 8), byte(len(compressed) >> 16), 0, uncompressedLength, 0, 0}
+			reader := &compressedReader{c: &Conn{}, r: bytes.NewReader(append(frame, compressed...)), compressor: newCompressor()}
+			_, err = reader.Read(make([]byte, 16))
+			assert.ErrorContains(t, err, "more than 100 uncompressed bytes")
+		})
+	}
+}
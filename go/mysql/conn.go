@@ -156,8 +156,8 @@ type Conn struct {
 	// the client and the server, and currently in use.
 	// It is set during the initial handshake.
 	//
-	// It is only used for CapabilityClientDeprecateEOF
-	// and CapabilityClientFoundRows.
+	// It is only used for CapabilityClientDeprecateEOF,
+	// CapabilityClientFoundRows and the compression capabilities.
 	Capabilities uint32
 
 	// closed is set to true when Close() is called on the connection.
@@ -199,6 +199,20 @@ type Conn struct {
 	// enableQueryInfo controls whether we parse the INFO field in QUERY_OK packets
 	// See: ConnParams.EnableQueryInfo
 	enableQueryInfo bool
+
+	// compressedReader and compressedWriter are set once the handshake is
+	// over if the compressed protocol was negotiated. All the reads and
+	// writes go through them from then on.
+	compressedReader *compressedReader
+	compressedWriter *compressedWriter
+
+	// compressedSequence is the sequence number of the compressed frames.
+	// It is reset together with sequence at the start of every command.
+	compressedSequence uint8
+
+	// zstdCompressionLevel is the compression level used when the
+	// zstd algorithm was negotiated.
+	zstdCompressionLevel int
 }
 
 // splitStatementFunciton is the function that is used to split the statement in case of a multi-statement query.
@@ -264,7 +278,7 @@ func (c *Conn) startWriterBuffering() {
 	defer c.bufMu.Unlock()
 
 	c.bufferedWriter = writersPool.Get().(*bufio.Writer)
-	c.bufferedWriter.Reset(c.conn)
+	c.bufferedWriter.Reset(c.rawWriter())
 }
 
 // endWriterBuffering must be called to terminate startWriteBuffering.
@@ -300,7 +314,16 @@ func (c *Conn) getWriter() (w io.Writer, unget func()) {
 		}
 	}
 	c.bufMu.Unlock()
-	return c.conn, func() {}
+	return c.rawWriter(), func() {}
+}
+
+// rawWriter returns the unbuffered writer for the connection: either the
+// network connection, or the writer for the compressed protocol.
+func (c *Conn) rawWriter() io.Writer {
+	if c.compressedWriter != nil {
+		return c.compressedWriter
+	}
+	return c.conn
 }
 
 // startFlushTimer must be called while holding lock on bufMu.
@@ -327,8 +350,12 @@ func (c *Conn) stopFlushTimer() {
 }
 
 // getReader returns reader for connection. It can be *bufio.Reader or net.Conn
-// depending on which buffer size was passed to newServerConn.
+// depending on which buffer size was passed to newServerConn, or the reader
+// for the compressed protocol.
 func (c *Conn) getReader() io.Reader {
+	if c.compressedReader != nil {
+		return c.compressedReader
+	}
 	if c.bufferedReader != nil {
 		return c.bufferedReader
 	}
@@ -356,7 +383,12 @@ func (c *Conn) readHeaderFrom(r io.Reader) (int, error) {
 	}
 
 	sequence := uint8(c.header[3])
-	if sequence != c.sequence {
+	if c.compressedReader != nil {
+		// Like MySQL, we don't check the sequence of the packets inside
+		// compressed frames: the frames have their own sequence, which
+		// is checked by the compressedReader.
+		c.sequence = sequence
+	} else if sequence != c.sequence {
 		return 0, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid sequence, expected %v got %v", c.sequence, sequence)
 	}
 
@@ -660,7 +692,7 @@ func (c *Conn) recycleWritePacket() {
 // Returns SQLError(CRServerGone) if it can't.
 func (c *Conn) writeComQuit() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComQuit
@@ -862,7 +894,7 @@ func (c *Conn) writeEOFPacket(flags uint16, warnings uint16) error {
 // handleNextCommand is called in the server loop to process
 // incoming packets.
 func (c *Conn) handleNextCommand(handler Handler) bool {
-	c.sequence = 0
+	c.resetSequence()
 	data, err := c.readEphemeralPacket()
 	if err != nil {
 		// Don't log EOF errors. They cause too much spam.
@@ -61,6 +61,15 @@ type ConnParams struct {
 	// for informative purposes. It has no programmatic value. Returning this field is
 	// disabled by default.
 	EnableQueryInfo bool
+
+	// Compression is the algorithm used for the compressed protocol: either
+	// CompressionZlib or CompressionZstd. If it is empty, or if the server
+	// does not support the algorithm, the connection is not compressed.
+	Compression string `json:"compression,omitempty"`
+
+	// ZstdCompressionLevel is the compression level, from 1 to 22, used when
+	// Compression is CompressionZstd. If it is 0, DefaultZstdCompressionLevel is used.
+	ZstdCompressionLevel int `json:"zstd_compression_level,omitempty"`
 }
 
 // EnableSSL will set the right flag on the parameters.
@@ -210,3 +210,155 @@ func testSSLConnectionBasics(t *testing.T, params *ConnParams) {
 	// Send a ComQuit to avoid the error message on the server side.
 	conn.writeComQuit()
 }
+
+// TestCompressedProtocol creates a server that allows compression, and
+// clients that negotiate each of the supported algorithms.
+func TestCompressedProtocol(t *testing.T) {
+	th := &testHandler{}
+
+	authServer := NewAuthServerStatic("", "", 0)
+	authServer.entries["user1"] = []*AuthServerStaticEntry{
+		{Password: "password1"},
+	}
+	defer authServer.close()
+
+	l, err := NewListener("tcp", "127.0.0.1:", authServer, th, 0, 0, false)
+	if err != nil {
+		t.Fatalf("NewListener failed: %v", err)
+	}
+	defer l.Close()
+	host := l.Addr().(*net.TCPAddr).IP.String()
+	port := l.Addr().(*net.TCPAddr).Port
+	go func() {
+		l.Accept()
+	}()
+
+	connect := func(t *testing.T, compression string) *Conn {
+		params := &ConnParams{
+			Host:        host,
+			Port:        port,
+			Uname:       "user1",
+			Pass:        "password1",
+			Compression: compression,
+		}
+		conn, err := Connect(context.Background(), params)
+		if err != nil {
+			t.Fatalf("unexpected connection error: %v", err)
+		}
+		return conn
+	}
+
+	runQueries := func(t *testing.T, conn *Conn) {
+		for i := 0; i < 3; i++ {
+			result, err := conn.ExecuteFetch("select rows", 10000, true)
+			if err != nil {
+				t.Fatalf("ExecuteFetch failed: %v", err)
+			}
+			utils.MustMatch(t, result, selectRowsResult)
+
+			// Large enough for both the query and the result to be compressed.
+			query := benchmarkQueryPrefix + strings.Repeat("padding ", 100)
+			result, err = conn.ExecuteFetch(query, 10000, true)
+			if err != nil {
+				t.Fatalf("ExecuteFetch failed: %v", err)
+			}
+			if len(result.Rows) != 1 || result.Rows[0][0].ToString() != query {
+				t.Fatalf("unexpected result: %v", result.Rows)
+			}
+		}
+	}
+
+	t.Run("disabled on the server", func(t *testing.T) {
+		conn := connect(t, CompressionZstd)
+		defer conn.Close()
+		if conn.IsCompressed() {
+			t.Fatalf("connection should not be compressed")
+		}
+		runQueries(t, conn)
+		conn.writeComQuit()
+	})
+
+	l.AllowCompression.Set(true)
+
+	for _, compression := range []string{CompressionZlib, CompressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			rawWritten := compressionRawBytes.Counts()[compressionDirectionWrite]
+			compressedWritten := compressionCompressedBytes.Counts()[compressionDirectionWrite]
+
+			conn := connect(t, compression)
+			defer conn.Close()
+			if !conn.IsCompressed() {
+				t.Fatalf("connection should be compressed")
+			}
+			runQueries(t, conn)
+			conn.writeComQuit()
+
+			rawWritten = compressionRawBytes.Counts()[compressionDirectionWrite] - rawWritten
+			compressedWritten = compressionCompressedBytes.Counts()[compressionDirectionWrite] - compressedWritten
+			if rawWritten == 0 || compressedWritten >= rawWritten {
+				t.Errorf("unexpected compression stats: %v raw bytes, %v compressed bytes", rawWritten, compressedWritten)
+			}
+		})
+	}
+
+	t.Run("not requested by the client", func(t *testing.T) {
+		conn := connect(t, "")
+		defer conn.Close()
+		if conn.IsCompressed() {
+			t.Fatalf("connection should not be compressed")
+		}
+		runQueries(t, conn)
+		conn.writeComQuit()
+	})
+}
+
+// zstdHandshakePacket returns a client handshake response that asks for zstd
+// compression at the given level, after the given connection attributes.
+func zstdHandshakePacket(attrs []byte, level byte) []byte {
+	flags := uint32(CapabilityClientProtocol41 | CapabilityClientSecureConnection | CapabilityClientConnAttr | CapabilityClientZstdCompressionAlgorithm)
+	data := make([]byte, 4+4+1+23+lenNullString("user1")+1+len(attrs)+1)
+	pos := writeUint32(data, 0, flags)
+	pos = writeUint32(data, pos, 0)
+	pos = writeByte(data, pos, 0)
+	pos = writeZeroes(data, pos, 23)
+	pos = writeNullString(data, pos, "user1")
+	pos = writeByte(data, pos, 0)
+	pos += copy(data[pos:], attrs)
+	writeByte(data, pos, level)
+	return data
+}
+
+func TestParseClientHandshakeZstdLevel(t *testing.T) {
+	l := &Listener{}
+	l.AllowCompression.Set(true)
+
+	// A valid attribute, and one whose value length goes past the
+	// announced total length of the attributes.
+	validAttrs := []byte{0x06, 0x02, 'k', '1', 0x02, 'v', '1'}
+	invalidAttrs := []byte{0x06, 0x02, 'k', '1', 0x09, 'v', '1'}
+
+	for _, attrs := range [][]byte{validAttrs, invalidAttrs} {
+		c := &Conn{}
+		user, _, _, err := l.parseClientHandshakePacket(c, true, zstdHandshakePacket(attrs, 7))
+		if err != nil {
+			t.Fatalf("parseClientHandshakePacket failed: %v", err)
+		}
+		if user != "user1" {
+			t.Errorf("unexpected user: %v", user)
+		}
+		if c.zstdCompressionLevel != 7 {
+			t.Errorf("unexpected zstd compression level: %v", c.zstdCompressionLevel)
+		}
+	}
+
+	for _, level := range []byte{0, 23, 255} {
+		c := &Conn{}
+		_, _, _, err := l.parseClientHandshakePacket(c, true, zstdHandshakePacket(validAttrs, level))
+		if err == nil || !strings.Contains(err.Error(), "invalid zstd compression level") {
+			t.Errorf("level %v: unexpected error: %v", level, err)
+		}
+		if c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0 {
+			t.Errorf("level %v: zstd compression should not be enabled", level)
+		}
+	}
+}
@@ -99,6 +99,21 @@ from _vt.schemacopy
 where table_schema = database()
 order by table_name, ordinal_position`
 
+	// fetchForeignKeys is the base query used to fetch the foreign key definitions of the tables
+	fetchForeignKeys = `select kcu.table_name, kcu.constraint_name, kcu.column_name, kcu.referenced_table_name, kcu.referenced_column_name, rc.update_rule, rc.delete_rule
+from information_schema.key_column_usage kcu
+	join information_schema.referential_constraints rc on rc.constraint_schema = kcu.constraint_schema and rc.table_name = kcu.table_name and rc.constraint_name = kcu.constraint_name
+where kcu.table_schema = database() and kcu.referenced_table_name is not null`
+
+	// FetchForeignKeys queries fetches all the foreign keys of the tables
+	FetchForeignKeys = fetchForeignKeys + `
+order by kcu.table_name, kcu.constraint_name, kcu.ordinal_position`
+
+	// FetchUpdatedForeignKeys queries fetches the foreign keys of the updated tables
+	FetchUpdatedForeignKeys = fetchForeignKeys + ` and
+	kcu.table_name in ::tableNames
+order by kcu.table_name, kcu.constraint_name, kcu.ordinal_position`
+
 	// GetColumnNamesQueryPatternForTable is used for mocking queries in unit tests
 	GetColumnNamesQueryPatternForTable = `SELECT COLUMN_NAME.*TABLE_NAME.*%s.*`
 )
@@ -171,6 +171,11 @@ type Listener struct {
 	// by the server when TLS is not in use.
 	AllowClearTextWithoutTLS sync2.AtomicBool
 
+	// AllowCompression needs to be set for the server to advertise the
+	// compressed protocol, with either zlib or zstd, and to use it with
+	// the clients that request it.
+	AllowCompression sync2.AtomicBool
+
 	// SlowConnectWarnThreshold if non-zero specifies an amount of time
 	// beyond which a warning is logged to identify the slow connection
 	SlowConnectWarnThreshold sync2.AtomicDuration
@@ -336,7 +341,7 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 	defer connCount.Add(-1)
 
 	// First build and send the server handshake packet.
-	serverAuthPluginData, err := c.writeHandshakeV10(l.ServerVersion, l.authServer, l.TLSConfig.Load() != nil)
+	serverAuthPluginData, err := c.writeHandshakeV10(l.ServerVersion, l.authServer, l.TLSConfig.Load() != nil, l.AllowCompression.Get())
 	if err != nil {
 		if err != io.EOF {
 			log.Errorf("Cannot send HandshakeV10 packet to %s: %v", c, err)
@@ -479,6 +484,12 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 		return
 	}
 
+	// If the client asked for it, all the packets after the OK packet are compressed.
+	if err := c.enableCompression(); err != nil {
+		log.Errorf("Cannot enable compression for %s: %v", c, err)
+		return
+	}
+
 	// Record how long we took to establish the connection
 	timings.Record(connectTimingKey, acceptTime)
 
@@ -520,7 +531,7 @@ func (l *Listener) isShutdown() bool {
 
 // writeHandshakeV10 writes the Initial Handshake Packet, server side.
 // It returns the salt data.
-func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, enableTLS bool) ([]byte, error) {
+func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, enableTLS bool, enableCompression bool) ([]byte, error) {
 	capabilities := CapabilityClientLongPassword |
 		CapabilityClientFoundRows |
 		CapabilityClientLongFlag |
@@ -537,6 +548,9 @@ func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, en
 	if enableTLS {
 		capabilities |= CapabilityClientSSL
 	}
+	if enableCompression {
+		capabilities |= CapabilityClientCompress | CapabilityClientZstdCompressionAlgorithm
+	}
 
 	// Grab the default auth method. This can only be either
 	// mysql_native_password or caching_sha2_password. Both
@@ -754,14 +768,47 @@ func (l *Listener) parseClientHandshakePacket(c *Conn, firstTime bool, data []by
 
 	// Decode connection attributes send by the client
 	if clientFlags&CapabilityClientConnAttr != 0 {
-		if _, _, err := parseConnAttrs(data, pos); err != nil {
+		if _, attrsEnd, err := parseConnAttrs(data, pos); err != nil {
 			log.Warningf("Decode connection attributes send by the client: %v", err)
+			pos = skipConnAttrs(data, pos)
+		} else {
+			pos = attrsEnd
+		}
+	}
+
+	// Pick the compression algorithm, if the client asked for one.
+	// We prefer zstd when the client supports both.
+	if l.AllowCompression.Get() {
+		if clientFlags&CapabilityClientZstdCompressionAlgorithm != 0 {
+			level, _, ok := readByte(data, pos)
+			if !ok {
+				return "", "", nil, vterrors.Errorf(vtrpc.Code_INTERNAL, "parseClientHandshakePacket: can't read zstd compression level")
+			}
+			if !validZstdCompressionLevel(int(level)) {
+				return "", "", nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "parseClientHandshakePacket: invalid zstd compression level: %v", level)
+			}
+			c.Capabilities |= CapabilityClientZstdCompressionAlgorithm
+			c.zstdCompressionLevel = int(level)
+		} else if clientFlags&CapabilityClientCompress != 0 {
+			c.Capabilities |= CapabilityClientCompress
 		}
 	}
 
 	return username, AuthMethodDescription(authMethod), authResponse, nil
 }
 
+// skipConnAttrs returns the position right after the connection attributes
+// that start at pos, using only their total length. It is used when the
+// attributes can't be decoded, so the fields after them are still read from
+// the right offset. If even the length can't be read, it returns len(data).
+func skipConnAttrs(data []byte, pos int) int {
+	attrLen, pos, ok := readLenEncInt(data, pos)
+	if !ok || attrLen > uint64(len(data)-pos) {
+		return len(data)
+	}
+	return pos + int(attrLen)
+}
+
 func parseConnAttrs(data []byte, pos int) (map[string]string, int, error) {
 	var attrLen uint64
 
@@ -101,6 +101,13 @@ type Stats struct {
 
 	VReplicationLags     *stats.Timings
 	VReplicationLagRates *stats.Rates
+
+	// ParallelApplyQueueDepth is the number of transactions received by
+	// a stream that applies them concurrently, whose position is not yet
+	// saved. ParallelApplyLagSeconds is how far, in seconds, the saved
+	// position trails the last transaction received.
+	ParallelApplyQueueDepth sync2.AtomicInt64
+	ParallelApplyLagSeconds sync2.AtomicInt64
 }
 
 // RecordHeartbeat updates the time the last heartbeat from vstreamer was seen
@@ -47,6 +47,9 @@ const (
 	backupInnodbLogGroupHomeDir = "InnoDBLog"
 	backupData                  = "Data"
 
+	// backupBinlogDir is the base for the binary logs of incremental backups
+	backupBinlogDir = "BinLog"
+
 	// backupManifestFileName is the MANIFEST file name within a backup.
 	backupManifestFileName = "MANIFEST"
 	// RestoreState is the name of the sentinel file used to detect whether a previous restore
@@ -54,6 +57,9 @@ const (
 	RestoreState = "restore_in_progress"
 	// BackupTimestampFormat is the format in which we save BackupTime and FinishedTime
 	BackupTimestampFormat = "2006-01-02.150405"
+	// AutoIncrementalFromPos is the special value of IncrementalFromPos that
+	// requests an incremental backup from the position of the last successful backup.
+	AutoIncrementalFromPos = "auto"
 )
 
 const (
@@ -112,9 +118,16 @@ func Backup(ctx context.Context, params BackupParams) error {
 		return vterrors.Wrap(err, "StartBackup failed")
 	}
 
-	be, err := GetBackupEngine()
-	if err != nil {
-		return vterrors.Wrap(err, "failed to find backup engine")
+	var be BackupEngine
+	if params.IncrementalFromPos != "" {
+		// Incremental backups copy binary logs, which only the builtin
+		// engine knows how to do, whatever engine takes full backups.
+		be = BackupRestoreEngineMap[builtinBackupEngineName]
+	} else {
+		be, err = GetBackupEngine()
+		if err != nil {
+			return vterrors.Wrap(err, "failed to find backup engine")
+		}
 	}
 
 	// Take the backup, and either AbortBackup or EndBackup.
@@ -262,11 +275,50 @@ func ShouldRestore(ctx context.Context, params RestoreParams) (bool, error) {
 	return checkNoDB(ctx, params.Mysqld, params.DbName)
 }
 
+// restoreIncrementalBackups applies the binary logs of the given incremental
+// backups to mysqld, which must be running with the full backup restored.
+// The position of the manifest is updated to the position reached.
+func restoreIncrementalBackups(ctx context.Context, params RestoreParams, manifest *BackupManifest, incrementals RecoveryPath) error {
+	// mysqld must know about the transactions of the full backup, so that it
+	// skips them when they are found again in the binary logs.
+	params.Logger.Infof("Restore: setting executed GTIDs to the full backup position %v", manifest.Position)
+	if err := params.Mysqld.ResetReplication(ctx); err != nil {
+		return vterrors.Wrap(err, "failed to reset replication")
+	}
+	if err := params.Mysqld.SetReplicationPosition(ctx, manifest.Position); err != nil {
+		return vterrors.Wrap(err, "failed to set replication position")
+	}
+
+	for _, incremental := range incrementals {
+		re, err := GetRestoreEngine(ctx, incremental.Handle)
+		if err != nil {
+			return vterrors.Wrap(err, "Failed to find restore engine")
+		}
+		if _, err := re.ExecuteRestore(ctx, params, incremental.Handle); err != nil {
+			return err
+		}
+	}
+
+	pos, err := params.Mysqld.PrimaryPosition()
+	if err != nil {
+		return vterrors.Wrap(err, "failed to read the restored position")
+	}
+	params.Logger.Infof("Restore: point-in-time recovery reached position %v", pos)
+	manifest.Position = pos
+	return nil
+}
+
 // Restore is the main entry point for backup restore.  If there is no
 // appropriate backup on the BackupStorage, Restore logs an error
 // and returns ErrNoBackup. Any other error is returned.
 func Restore(ctx context.Context, params RestoreParams) (*BackupManifest, error) {
 	startTs := time.Now()
+	if !params.RestoreToPos.IsZero() && !params.RestoreToTimestamp.IsZero() {
+		return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "cannot restore to both a position and a timestamp")
+	}
+	if params.IsIncrementalRecovery() && !params.StartTime.IsZero() {
+		return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "cannot restore from a backup time and to a point in time")
+	}
 	// find the right backup handle: most recent one, with a MANIFEST
 	params.Logger.Infof("Restore: looking for a suitable backup to restore")
 	bs, err := backupstorage.GetBackupStorage()
@@ -306,9 +358,20 @@ func Restore(ctx context.Context, params RestoreParams) (*BackupManifest, error)
 		return nil, ErrNoBackup
 	}
 
-	bh, err := FindBackupToRestore(ctx, params, bhs)
-	if err != nil {
-		return nil, err
+	var bh backupstorage.BackupHandle
+	var recoveryPath RecoveryPath
+	if params.IsIncrementalRecovery() {
+		recoveryPath, err = FindRecoveryPath(ctx, params, bhs)
+		if err != nil {
+			return nil, err
+		}
+		bh = recoveryPath[0].Handle
+		params.Logger.Infof("Restore: found recovery path of %v backups, starting with full backup %v %v", len(recoveryPath), bh.Directory(), bh.Name())
+	} else {
+		bh, err = FindBackupToRestore(ctx, params, bhs)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	re, err := GetRestoreEngine(ctx, bh)
@@ -355,6 +418,12 @@ func Restore(ctx context.Context, params RestoreParams) (*BackupManifest, error)
 		return nil, vterrors.Wrap(err, "mysql_upgrade failed")
 	}
 
+	if params.IsIncrementalRecovery() {
+		if err := restoreIncrementalBackups(ctx, params, manifest, recoveryPath[1:]); err != nil {
+			return nil, err
+		}
+	}
+
 	// Add backupTime and restorePosition to LocalMetadata
 	params.LocalMetadata["RestoredBackupTime"] = manifest.BackupTime
 	params.LocalMetadata["RestorePosition"] = mysql.EncodePosition(manifest.Position)
@@ -0,0 +1,252 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"vitess.io/vitess/go/protoutil"
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/proto/vttime"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// retentionBackup is a backup of a shard, as seen by a backup retention policy.
+type retentionBackup struct {
+	bh   backupstorage.BackupHandle
+	time time.Time
+
+	// complete is true if the backup has a MANIFEST.
+	complete bool
+	// incremental is true for incremental backups, which can only be
+	// restored on top of an older full backup.
+	incremental bool
+	// verified is true if the backup passed its last verification.
+	verified bool
+}
+
+// retentionBucket groups the backups kept by one of the time based rules of
+// a backup retention policy: one backup is kept per bucket.
+type retentionBucket struct {
+	window time.Duration
+	layout string
+}
+
+// ValidateBackupRetentionPolicy returns an error if the durations of the
+// policy can't be used.
+func ValidateBackupRetentionPolicy(policy *topodatapb.BackupRetentionPolicy) error {
+	_, err := backupRetentionBuckets(policy)
+	return err
+}
+
+func backupRetentionBuckets(policy *topodatapb.BackupRetentionPolicy) ([]retentionBucket, error) {
+	var buckets []retentionBucket
+	for _, rule := range []struct {
+		name   string
+		window *vttime.Duration
+		layout string
+	}{
+		{"keep_hourly_for", policy.KeepHourlyFor, "2006-01-02T15"},
+		{"keep_daily_for", policy.KeepDailyFor, "2006-01-02"},
+		{"keep_monthly_for", policy.KeepMonthlyFor, "2006-01"},
+	} {
+		window, ok, err := protoutil.DurationFromProto(rule.window)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "invalid %v", rule.name)
+		}
+		if !ok {
+			continue
+		}
+		if window < 0 {
+			return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "%v must not be negative, got %v", rule.name, window)
+		}
+		buckets = append(buckets, retentionBucket{window: window, layout: rule.layout})
+	}
+	return buckets, nil
+}
+
+// chooseBackupsToRemove returns the backups that are not kept by the policy.
+// The backups must be sorted by time, oldest first.
+func chooseBackupsToRemove(backups []*retentionBackup, policy *topodatapb.BackupRetentionPolicy, now time.Time) ([]*retentionBackup, error) {
+	buckets, err := backupRetentionBuckets(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var full []*retentionBackup
+	var newestComplete time.Time
+	for _, b := range backups {
+		if !b.complete {
+			continue
+		}
+		newestComplete = b.time
+		if !b.incremental {
+			full = append(full, b)
+		}
+	}
+
+	kept := map[*retentionBackup]bool{}
+	// The most recent full backups, verified or not. The latest full backup is
+	// always kept, whatever the policy says.
+	minBackups := int(policy.MinBackups)
+	if minBackups < 1 {
+		minBackups = 1
+	}
+	for i := len(full) - 1; i >= 0 && i >= len(full)-minBackups; i-- {
+		kept[full[i]] = true
+	}
+	// The most recent verified full backups.
+	verified := 0
+	for i := len(full) - 1; i >= 0 && verified < int(policy.MinVerifiedBackups); i-- {
+		if full[i].verified {
+			kept[full[i]] = true
+			verified++
+		}
+	}
+	// The most recent full backup of each hour, day or month.
+	for _, bucket := range buckets {
+		seen := map[string]bool{}
+		for i := len(full) - 1; i >= 0; i-- {
+			if now.Sub(full[i].time) > bucket.window {
+				break
+			}
+			key := full[i].time.UTC().Format(bucket.layout)
+			if !seen[key] {
+				seen[key] = true
+				kept[full[i]] = true
+			}
+		}
+	}
+
+	var oldestKept time.Time
+	for _, b := range full {
+		if kept[b] {
+			oldestKept = b.time
+			break
+		}
+	}
+
+	var removed []*retentionBackup
+	for _, b := range backups {
+		switch {
+		case kept[b]:
+		case !b.complete:
+			// A backup newer than all complete backups may still be running.
+			if newestComplete.IsZero() || !b.time.Before(newestComplete) {
+				continue
+			}
+			removed = append(removed, b)
+		case b.incremental:
+			// Incremental backups are kept as long as a full backup they can
+			// be restored on top of is kept.
+			if oldestKept.IsZero() || b.time.After(oldestKept) {
+				continue
+			}
+			removed = append(removed, b)
+		default:
+			removed = append(removed, b)
+		}
+	}
+	return removed, nil
+}
+
+// ChooseBackupsToRemove returns the backups of a shard that are not kept by
+// the backup retention policy, given the verifications of the backups as
+// returned by GetBackupVerifications. Backups with a name that doesn't hold
+// the time of the backup are always kept.
+func ChooseBackupsToRemove(ctx context.Context, logger logutil.Logger, bhs []backupstorage.BackupHandle, verifications map[string]*BackupVerification, policy *topodatapb.BackupRetentionPolicy, now time.Time) ([]backupstorage.BackupHandle, error) {
+	backups := make([]*retentionBackup, 0, len(bhs))
+	for _, bh := range bhs {
+		btime, _, err := ParseBackupName(bh.Directory(), bh.Name())
+		if err != nil || btime == nil {
+			logger.Warningf("Keeping backup %v/%v: can't parse the time of the backup from its name", bh.Directory(), bh.Name())
+			continue
+		}
+
+		b := &retentionBackup{bh: bh, time: *btime}
+		if bm, err := GetBackupManifest(ctx, bh); err == nil {
+			b.complete = true
+			b.incremental = bm.Incremental
+		}
+		if v, ok := verifications[bh.Name()]; ok {
+			b.verified = v.Status == BackupVerificationPassed
+		}
+		backups = append(backups, b)
+	}
+	if err := ctx.Err(); err != nil {
+		// Don't mistake backups for incomplete ones if we were interrupted.
+		return nil, err
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].time.Before(backups[j].time)
+	})
+
+	removed, err := chooseBackupsToRemove(backups, policy, now)
+	if err != nil {
+		return nil, err
+	}
+	bhsToRemove := make([]backupstorage.BackupHandle, 0, len(removed))
+	for _, b := range removed {
+		bhsToRemove = append(bhsToRemove, b.bh)
+	}
+	return bhsToRemove, nil
+}
+
+// ApplyBackupRetention removes the backups in the given directory that are
+// not kept by the backup retention policy, and returns them. In a dry run,
+// the backups are returned but not removed.
+func ApplyBackupRetention(ctx context.Context, logger logutil.Logger, bs backupstorage.BackupStorage, dir string, policy *topodatapb.BackupRetentionPolicy, now time.Time, dryRun bool) ([]backupstorage.BackupHandle, error) {
+	bhs, err := bs.ListBackups(ctx, dir)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "ListBackups failed")
+	}
+	verifications, err := GetBackupVerifications(ctx, bs, dir)
+	if err != nil {
+		return nil, err
+	}
+	bhsToRemove, err := ChooseBackupsToRemove(ctx, logger, bhs, verifications, policy, now)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		for _, bh := range bhsToRemove {
+			logger.Infof("Would remove backup %v/%v", dir, bh.Name())
+		}
+		return bhsToRemove, nil
+	}
+
+	removed := make([]backupstorage.BackupHandle, 0, len(bhsToRemove))
+	for _, bh := range bhsToRemove {
+		logger.Infof("Removing backup %v/%v", dir, bh.Name())
+		if err := bs.RemoveBackup(ctx, dir, bh.Name()); err != nil {
+			return removed, vterrors.Wrapf(err, "can't remove backup %v/%v", dir, bh.Name())
+		}
+		if _, ok := verifications[bh.Name()]; ok {
+			if err := RemoveBackupVerification(ctx, bs, dir, bh.Name()); err != nil {
+				logger.Warningf("Failed to remove the verification of backup %v/%v: %v", dir, bh.Name(), err)
+			}
+		}
+		removed = append(removed, bh)
+	}
+	return removed, nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/protoutil"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+func TestChooseBackupsToRemove(t *testing.T) {
+	now := time.Date(2022, time.September, 15, 12, 30, 0, 0, time.UTC)
+	at := func(s string) time.Time {
+		bt, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return bt
+	}
+
+	tt := []struct {
+		name        string
+		policy      *topodatapb.BackupRetentionPolicy
+		backups     []*retentionBackup
+		expectKept  []string
+		expectError string
+	}{
+		{
+			name:   "latest full backup is always kept",
+			policy: &topodatapb.BackupRetentionPolicy{},
+			backups: []*retentionBackup{
+				{time: at("2022-09-15T10:00:00Z"), complete: true},
+				{time: at("2022-09-15T11:00:00Z"), complete: true},
+			},
+			expectKept: []string{"2022-09-15T11:00:00Z"},
+		},
+		{
+			name:   "min backups",
+			policy: &topodatapb.BackupRetentionPolicy{MinBackups: 2},
+			backups: []*retentionBackup{
+				{time: at("2022-09-15T09:00:00Z"), complete: true},
+				{time: at("2022-09-15T10:00:00Z"), complete: true},
+				{time: at("2022-09-15T11:00:00Z"), complete: true},
+			},
+			expectKept: []string{"2022-09-15T10:00:00Z", "2022-09-15T11:00:00Z"},
+		},
+		{
+			name:   "min verified backups",
+			policy: &topodatapb.BackupRetentionPolicy{MinVerifiedBackups: 2},
+			backups: []*retentionBackup{
+				{time: at("2022-09-15T08:00:00Z"), complete: true, verified: true},
+				{time: at("2022-09-15T09:00:00Z"), complete: true, verified: true},
+				{time: at("2022-09-15T10:00:00Z"), complete: true},
+				{time: at("2022-09-15T11:00:00Z"), complete: true, verified: true},
+			},
+			expectKept: []string{"2022-09-15T09:00:00Z", "2022-09-15T11:00:00Z"},
+		},
+		{
+			name: "hourly and daily",
+			policy: &topodatapb.BackupRetentionPolicy{
+				KeepHourlyFor: protoutil.DurationToProto(2 * time.Hour),
+				KeepDailyFor:  protoutil.DurationToProto(3 * 24 * time.Hour),
+			},
+			backups: []*retentionBackup{
+				{time: at("2022-09-11T23:00:00Z"), complete: true},
+				{time: at("2022-09-13T01:00:00Z"), complete: true},
+				{time: at("2022-09-13T23:00:00Z"), complete: true},
+				{time: at("2022-09-14T23:00:00Z"), complete: true},
+				{time: at("2022-09-15T10:00:00Z"), complete: true},
+				{time: at("2022-09-15T11:10:00Z"), complete: true},
+				{time: at("2022-09-15T11:40:00Z"), complete: true},
+				{time: at("2022-09-15T12:10:00Z"), complete: true},
+			},
+			expectKept: []string{
+				"2022-09-13T23:00:00Z",
+				"2022-09-14T23:00:00Z",
+				"2022-09-15T11:40:00Z",
+				"2022-09-15T12:10:00Z",
+			},
+		},
+		{
+			name: "monthly",
+			policy: &topodatapb.BackupRetentionPolicy{
+				KeepMonthlyFor: protoutil.DurationToProto(365 * 24 * time.Hour),
+			},
+			backups: []*retentionBackup{
+				{time: at("2021-08-31T00:00:00Z"), complete: true},
+				{time: at("2022-07-01T00:00:00Z"), complete: true},
+				{time: at("2022-07-31T00:00:00Z"), complete: true},
+				{time: at("2022-08-15T00:00:00Z"), complete: true},
+				{time: at("2022-09-01T00:00:00Z"), complete: true},
+			},
+			expectKept: []string{"2022-07-31T00:00:00Z", "2022-08-15T00:00:00Z", "2022-09-01T00:00:00Z"},
+		},
+		{
+			name:   "incremental backups follow the oldest kept full backup",
+			policy: &topodatapb.BackupRetentionPolicy{MinBackups: 2},
+			backups: []*retentionBackup{
+				{time: at("2022-09-15T08:00:00Z"), complete: true},
+				{time: at("2022-09-15T08:30:00Z"), complete: true, incremental: true},
+				{time: at("2022-09-15T09:00:00Z"), complete: true},
+				{time: at("2022-09-15T09:30:00Z"), complete: true, incremental: true},
+				{time: at("2022-09-15T10:00:00Z"), complete: true},
+				{time: at("2022-09-15T10:30:00Z"), complete: true, incremental: true},
+			},
+			expectKept: []string{
+				"2022-09-15T09:00:00Z",
+				"2022-09-15T09:30:00Z",
+				"2022-09-15T10:00:00Z",
+				"2022-09-15T10:30:00Z",
+			},
+		},
+		{
+			name:   "incomplete backups",
+			policy: &topodatapb.BackupRetentionPolicy{MinBackups: 3},
+			backups: []*retentionBackup{
+				{time: at("2022-09-15T09:00:00Z")},
+				{time: at("2022-09-15T10:00:00Z"), complete: true},
+				{time: at("2022-09-15T11:00:00Z")},
+			},
+			expectKept: []string{"2022-09-15T10:00:00Z", "2022-09-15T11:00:00Z"},
+		},
+		{
+			name:   "no complete backups",
+			policy: &topodatapb.BackupRetentionPolicy{},
+			backups: []*retentionBackup{
+				{time: at("2022-09-15T09:00:00Z")},
+				{time: at("2022-09-15T10:00:00Z")},
+			},
+			expectKept: []string{"2022-09-15T09:00:00Z", "2022-09-15T10:00:00Z"},
+		},
+		{
+			name: "negative duration",
+			policy: &topodatapb.BackupRetentionPolicy{
+				KeepDailyFor: protoutil.DurationToProto(-time.Hour),
+			},
+			expectError: "keep_daily_for must not be negative",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			removed, err := chooseBackupsToRemove(tc.backups, tc.policy, now)
+			if tc.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+
+			isRemoved := map[*retentionBackup]bool{}
+			for _, b := range removed {
+				isRemoved[b] = true
+			}
+			var kept []string
+			for _, b := range tc.backups {
+				if !isRemoved[b] {
+					kept = append(kept, b.time.Format(time.RFC3339))
+				}
+			}
+			assert.Equal(t, tc.expectKept, kept)
+		})
+	}
+}
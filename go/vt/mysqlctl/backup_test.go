@@ -22,10 +22,13 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/vt/logutil"
 )
 
 func TestFindFilesToBackup(t *testing.T) {
@@ -130,6 +133,122 @@ func TestFindFilesToBackup(t *testing.T) {
 	}
 }
 
+func TestFindRecoveryPath(t *testing.T) {
+	const sid = "16b1039f-22b6-11ed-b765-0a43f95f28a3"
+	pos := func(gtids string) mysql.Position {
+		return mysql.MustParsePosition(mysql.Mysql56FlavorID, sid+":"+gtids)
+	}
+	full := func(name, backupTime, gtids string) *BackupManifestHandle {
+		return &BackupManifestHandle{Manifest: &BackupManifest{BackupMethod: name, BackupTime: backupTime, Position: pos(gtids)}}
+	}
+	incremental := func(name, backupTime, fromGTIDs, gtids string) *BackupManifestHandle {
+		return &BackupManifestHandle{Manifest: &BackupManifest{BackupMethod: name, BackupTime: backupTime, Incremental: true, FromPosition: pos(fromGTIDs), Position: pos(gtids)}}
+	}
+	manifests := func() []*BackupManifestHandle {
+		return []*BackupManifestHandle{
+			full("full1", "2022-08-01T10:00:00Z", "1-100"),
+			incremental("incr1", "2022-08-01T11:00:00Z", "1-90", "1-150"),
+			incremental("incr2", "2022-08-01T12:00:00Z", "1-150", "1-200"),
+			full("full2", "2022-08-01T13:00:00Z", "1-210"),
+			incremental("incr3", "2022-08-01T14:00:00Z", "1-200", "1-260"),
+			incremental("incr4", "2022-08-01T15:00:00Z", "1-270", "1-300"),
+		}
+	}
+	parseTime := func(s string) time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return tm
+	}
+
+	tt := []struct {
+		name        string
+		pos         string
+		timestamp   string
+		expectPath  []string
+		expectError string
+	}{
+		{
+			name:       "position of a full backup",
+			pos:        "1-100",
+			expectPath: []string{"full1"},
+		},
+		{
+			name:       "position in the first incremental backup",
+			pos:        "1-120",
+			expectPath: []string{"full1", "incr1"},
+		},
+		{
+			name:       "position in the second incremental backup",
+			pos:        "1-180",
+			expectPath: []string{"full1", "incr1", "incr2"},
+		},
+		{
+			name:       "position after the second full backup",
+			pos:        "1-250",
+			expectPath: []string{"full2", "incr3"},
+		},
+		{
+			name:        "gap between incremental backups",
+			pos:         "1-280",
+			expectError: "no incremental backups found",
+		},
+		{
+			name:        "position before all full backups",
+			pos:         "1-50",
+			expectError: "no full backup found",
+		},
+		{
+			name:       "timestamp of a full backup",
+			timestamp:  "2022-08-01T10:00:00Z",
+			expectPath: []string{"full1"},
+		},
+		{
+			name:       "timestamp between incremental backups",
+			timestamp:  "2022-08-01T11:30:00Z",
+			expectPath: []string{"full1", "incr1", "incr2"},
+		},
+		{
+			name:       "timestamp after the second full backup",
+			timestamp:  "2022-08-01T13:30:00Z",
+			expectPath: []string{"full2", "incr3"},
+		},
+		{
+			name:        "timestamp after all incremental backups",
+			timestamp:   "2022-08-01T16:00:00Z",
+			expectError: "no incremental backups found",
+		},
+		{
+			name:        "timestamp before all full backups",
+			timestamp:   "2022-08-01T09:00:00Z",
+			expectError: "no full backup found",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var restoreToPos mysql.Position
+			var restoreToTimestamp time.Time
+			if tc.pos != "" {
+				restoreToPos = pos(tc.pos)
+			}
+			if tc.timestamp != "" {
+				restoreToTimestamp = parseTime(tc.timestamp)
+			}
+			path, err := findRecoveryPath(logutil.NewMemoryLogger(), manifests(), restoreToPos, restoreToTimestamp)
+			if tc.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			var names []string
+			for _, m := range path {
+				names = append(names, m.Manifest.BackupMethod)
+			}
+			assert.Equal(t, tc.expectPath, names)
+		})
+	}
+}
+
 type forTest []FileEntry
 
 func (f forTest) Len() int           { return len(f) }
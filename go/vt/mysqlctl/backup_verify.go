@@ -0,0 +1,260 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/sync2"
+	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+const (
+	// backupVerificationDirectory is the root directory of the BackupStorage
+	// for the verifications of backups.
+	backupVerificationDirectory = "backup_verifications"
+
+	// backupVerificationFileName is the file that holds the result of the
+	// last verification of a backup.
+	backupVerificationFileName = "VERIFICATION"
+
+	// BackupVerificationPassed is the status of a backup that was verified successfully.
+	BackupVerificationPassed = "PASSED"
+
+	// BackupVerificationFailed is the status of a backup that failed its verification.
+	BackupVerificationFailed = "FAILED"
+)
+
+// BackupVerification is the result of the verification of a backup.
+type BackupVerification struct {
+	// Status is BackupVerificationPassed or BackupVerificationFailed.
+	Status string
+
+	// VerifiedTime is when the verification finished, in RFC3339 format.
+	VerifiedTime string
+
+	// Restored is true if the verification included a restore of the backup
+	// into a scratch mysqld, and false if only the files were checked.
+	Restored bool
+
+	// Error describes why the verification failed.
+	Error string `json:",omitempty"`
+}
+
+// NewBackupVerification returns the verification result for the given error,
+// which is nil if the verification passed.
+func NewBackupVerification(err error, restored bool) *BackupVerification {
+	v := &BackupVerification{
+		Status:       BackupVerificationPassed,
+		VerifiedTime: time.Now().UTC().Format(time.RFC3339),
+		Restored:     restored,
+	}
+	if err != nil {
+		v.Status = BackupVerificationFailed
+		v.Error = err.Error()
+	}
+	return v
+}
+
+// VerifyBackupFiles reads every file of a backup from the BackupStorage, and
+// checks it against the MANIFEST. For the builtin engine, the hash of every
+// file is checked. Other engines don't record hashes, so their files are only
+// checked to be readable.
+func VerifyBackupFiles(ctx context.Context, logger logutil.Logger, bh backupstorage.BackupHandle, parallelism int) error {
+	bm, err := GetBackupManifest(ctx, bh)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	hashes := map[string]string{}
+	switch bm.BackupMethod {
+	case builtinBackupEngineName, "":
+		var bbm builtinBackupManifest
+		if err := getBackupManifestInto(ctx, bh, &bbm); err != nil {
+			return err
+		}
+		for i, fe := range bbm.FileEntries {
+			name := fmt.Sprintf("%v", i)
+			files = append(files, name)
+			hashes[name] = fe.Hash
+		}
+	case xtrabackupEngineName:
+		var xbm xtraBackupManifest
+		if err := getBackupManifestInto(ctx, bh, &xbm); err != nil {
+			return err
+		}
+		baseFileName := xbm.FileName
+		if baseFileName == "" {
+			baseFileName = (&XtrabackupEngine{}).backupFileName()
+		}
+		if xbm.NumStripes <= 1 {
+			files = append(files, baseFileName)
+		} else {
+			for i := 0; i < int(xbm.NumStripes); i++ {
+				files = append(files, stripeFileName(baseFileName, i))
+			}
+		}
+	default:
+		return vterrors.Errorf(vtrpc.Code_UNIMPLEMENTED, "can't verify backup created with %q engine", bm.BackupMethod)
+	}
+
+	logger.Infof("Verifying %v files of backup %v/%v", len(files), bh.Directory(), bh.Name())
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	sema := sync2.NewSemaphore(parallelism, 0)
+	rec := concurrency.AllErrorRecorder{}
+	wg := sync.WaitGroup{}
+	for _, name := range files {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			sema.Acquire()
+			defer sema.Release()
+			if rec.HasErrors() {
+				return
+			}
+
+			hash, err := readBackupFile(ctx, bh, name)
+			if err != nil {
+				rec.RecordError(vterrors.Wrapf(err, "can't read file %v", name))
+				return
+			}
+			if expected, ok := hashes[name]; ok && hash != expected {
+				rec.RecordError(vterrors.Errorf(vtrpc.Code_DATA_LOSS, "hash mismatch for file %v, got %v expected %v", name, hash, expected))
+			}
+		}(name)
+	}
+	wg.Wait()
+	return rec.Error()
+}
+
+// readBackupFile reads a whole file of a backup, and returns its hash.
+func readBackupFile(ctx context.Context, bh backupstorage.BackupHandle, name string) (string, error) {
+	source, err := bh.ReadFile(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	br := newBackupReader(name, 0, source)
+	defer br.Close()
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		return "", err
+	}
+	return br.HashString(), nil
+}
+
+// backupVerificationDir returns the directory of the BackupStorage that holds
+// the verifications of the backups in dir. Each verification is stored as a
+// backup of its own, with the name of the verified backup. Keeping them apart
+// means that recording a verification never modifies a completed backup.
+func backupVerificationDir(dir string) string {
+	return path.Join(backupVerificationDirectory, dir)
+}
+
+// RecordBackupVerification stores the result of the verification of a backup,
+// replacing the result of any previous verification.
+func RecordBackupVerification(ctx context.Context, bs backupstorage.BackupStorage, dir, name string, v *BackupVerification) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return vterrors.Wrapf(err, "cannot JSON encode %v", backupVerificationFileName)
+	}
+
+	vdir := backupVerificationDir(dir)
+	if err := bs.RemoveBackup(ctx, vdir, name); err != nil {
+		return vterrors.Wrapf(err, "can't remove previous verification of backup %v/%v", dir, name)
+	}
+	bh, err := bs.StartBackup(ctx, vdir, name)
+	if err != nil {
+		return vterrors.Wrapf(err, "can't record verification of backup %v/%v", dir, name)
+	}
+	if err := writeBackupVerification(ctx, bh, data); err != nil {
+		if abortErr := bh.AbortBackup(ctx); abortErr != nil {
+			log.Warningf("Failed to abort the verification of backup %v/%v: %v", dir, name, abortErr)
+		}
+		return err
+	}
+	return bh.EndBackup(ctx)
+}
+
+func writeBackupVerification(ctx context.Context, bh backupstorage.BackupHandle, data []byte) error {
+	wc, err := bh.AddFile(ctx, backupVerificationFileName, int64(len(data)))
+	if err != nil {
+		return vterrors.Wrapf(err, "cannot add %v to backup", backupVerificationFileName)
+	}
+	if _, err := wc.Write(data); err != nil {
+		wc.Close()
+		return vterrors.Wrapf(err, "cannot write %v", backupVerificationFileName)
+	}
+	return wc.Close()
+}
+
+// RemoveBackupVerification removes the result of the verification of a backup,
+// if there is one. It is called when the backup itself is removed.
+func RemoveBackupVerification(ctx context.Context, bs backupstorage.BackupStorage, dir, name string) error {
+	return bs.RemoveBackup(ctx, backupVerificationDir(dir), name)
+}
+
+// GetBackupVerifications returns the result of the last verification of the
+// backups in dir, by backup name. Backups that were never verified are not
+// in the map.
+func GetBackupVerifications(ctx context.Context, bs backupstorage.BackupStorage, dir string) (map[string]*BackupVerification, error) {
+	bhs, err := bs.ListBackups(ctx, backupVerificationDir(dir))
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "can't list verifications of backups in %v", dir)
+	}
+
+	verifications := make(map[string]*BackupVerification, len(bhs))
+	for _, bh := range bhs {
+		v, err := readBackupVerification(ctx, bh)
+		if err != nil {
+			// The verification was interrupted before it was recorded.
+			log.Warningf("Ignoring verification of backup %v/%v: %v", dir, bh.Name(), err)
+			continue
+		}
+		verifications[bh.Name()] = v
+	}
+	return verifications, nil
+}
+
+func readBackupVerification(ctx context.Context, bh backupstorage.BackupHandle) (*BackupVerification, error) {
+	file, err := bh.ReadFile(ctx, backupVerificationFileName)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "can't read %v", backupVerificationFileName)
+	}
+	defer file.Close()
+
+	v := &BackupVerification{}
+	if err := json.NewDecoder(file).Decode(v); err != nil {
+		return nil, vterrors.Wrapf(err, "can't decode %v", backupVerificationFileName)
+	}
+	return v, nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/mysqlctl/filebackupstorage"
+)
+
+// createTestBuiltinBackup writes a backup with the given file contents, and a
+// MANIFEST that lists their hashes, the way the builtin engine does.
+func createTestBuiltinBackup(t *testing.T, bs backupstorage.BackupStorage, dir, name string, contents []string) backupstorage.BackupHandle {
+	ctx := context.Background()
+	bh, err := bs.StartBackup(ctx, dir, name)
+	require.NoError(t, err)
+
+	bm := &builtinBackupManifest{
+		BackupManifest: BackupManifest{BackupMethod: builtinBackupEngineName},
+	}
+	for i, content := range contents {
+		wc, err := bh.AddFile(ctx, fmt.Sprintf("%v", i), int64(len(content)))
+		require.NoError(t, err)
+		bw := newBackupWriter(fmt.Sprintf("%v", i), int64(len(content)), wc)
+		_, err = bw.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, bw.Close())
+		require.NoError(t, wc.Close())
+		bm.FileEntries = append(bm.FileEntries, FileEntry{Base: backupData, Name: fmt.Sprintf("file%v", i), Hash: bw.HashString()})
+	}
+
+	wc, err := bh.AddFile(ctx, backupManifestFileName, backupstorage.FileSizeUnknown)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(wc).Encode(bm))
+	require.NoError(t, wc.Close())
+	require.NoError(t, bh.EndBackup(ctx))
+
+	bhs, err := bs.ListBackups(ctx, dir)
+	require.NoError(t, err)
+	for _, bh := range bhs {
+		if bh.Name() == name {
+			return bh
+		}
+	}
+	t.Fatalf("backup %v not found", name)
+	return nil
+}
+
+func TestVerifyBackupFiles(t *testing.T) {
+	oldRoot := filebackupstorage.FileBackupStorageRoot
+	filebackupstorage.FileBackupStorageRoot = t.TempDir()
+	defer func() { filebackupstorage.FileBackupStorageRoot = oldRoot }()
+
+	ctx := context.Background()
+	logger := logutil.NewMemoryLogger()
+	bs := &filebackupstorage.FileBackupStorage{}
+	dir := "ks/-80"
+
+	bh := createTestBuiltinBackup(t, bs, dir, "good", []string{"foo", "bar", ""})
+	require.NoError(t, VerifyBackupFiles(ctx, logger, bh, 2))
+
+	bh = createTestBuiltinBackup(t, bs, dir, "corrupt", []string{"foo", "bar"})
+	require.NoError(t, os.WriteFile(path.Join(filebackupstorage.FileBackupStorageRoot, dir, "corrupt", "1"), []byte("baz"), 0644))
+	err := VerifyBackupFiles(ctx, logger, bh, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hash mismatch for file 1")
+
+	bh = createTestBuiltinBackup(t, bs, dir, "missing", []string{"foo", "bar"})
+	require.NoError(t, os.Remove(path.Join(filebackupstorage.FileBackupStorageRoot, dir, "missing", "0")))
+	err = VerifyBackupFiles(ctx, logger, bh, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't read file 0")
+}
+
+func TestRecordBackupVerification(t *testing.T) {
+	oldRoot := filebackupstorage.FileBackupStorageRoot
+	filebackupstorage.FileBackupStorageRoot = t.TempDir()
+	defer func() { filebackupstorage.FileBackupStorageRoot = oldRoot }()
+
+	ctx := context.Background()
+	bs := &filebackupstorage.FileBackupStorage{}
+	dir := "ks/-80"
+	bh := createTestBuiltinBackup(t, bs, dir, "backup", []string{"foo"})
+
+	vs, err := GetBackupVerifications(ctx, bs, dir)
+	require.NoError(t, err)
+	assert.Empty(t, vs, "backup was never verified")
+
+	require.NoError(t, RecordBackupVerification(ctx, bs, dir, bh.Name(), NewBackupVerification(nil, false)))
+	vs, err = GetBackupVerifications(ctx, bs, dir)
+	require.NoError(t, err)
+	require.Contains(t, vs, bh.Name())
+	v := vs[bh.Name()]
+	assert.Equal(t, BackupVerificationPassed, v.Status)
+	assert.False(t, v.Restored)
+	assert.NotEmpty(t, v.VerifiedTime)
+
+	// A new verification replaces the previous one.
+	require.NoError(t, RecordBackupVerification(ctx, bs, dir, bh.Name(), NewBackupVerification(fmt.Errorf("sanity query failed"), true)))
+	vs, err = GetBackupVerifications(ctx, bs, dir)
+	require.NoError(t, err)
+	require.Len(t, vs, 1)
+	v = vs[bh.Name()]
+	assert.Equal(t, BackupVerificationFailed, v.Status)
+	assert.True(t, v.Restored)
+	assert.Equal(t, "sanity query failed", v.Error)
+
+	// The backup itself is left untouched.
+	bhs, err := bs.ListBackups(ctx, dir)
+	require.NoError(t, err)
+	require.Len(t, bhs, 1)
+	_, err = bhs[0].ReadFile(ctx, backupVerificationFileName)
+	require.Error(t, err)
+	require.NoError(t, VerifyBackupFiles(ctx, logutil.NewMemoryLogger(), bhs[0], 1))
+
+	require.NoError(t, RemoveBackupVerification(ctx, bs, dir, bh.Name()))
+	vs, err = GetBackupVerifications(ctx, bs, dir)
+	require.NoError(t, err)
+	assert.Empty(t, vs)
+}
@@ -65,6 +65,10 @@ type BackupParams struct {
 	TabletAlias string
 	// BackupTime is the time at which the backup is being started
 	BackupTime time.Time
+	// IncrementalFromPos, if set, requests an incremental backup of the binary
+	// logs from this position to the current position. The special value
+	// AutoIncrementalFromPos uses the position of the last successful backup.
+	IncrementalFromPos string
 }
 
 // RestoreParams is the struct that holds all params passed to ExecuteRestore
@@ -91,6 +95,20 @@ type RestoreParams struct {
 	// StartTime: if non-zero, look for a backup that was taken at or before this time
 	// Otherwise, find the most recent backup
 	StartTime time.Time
+	// RestoreToPos: if non-empty, the restore is a point-in-time recovery: the
+	// most recent full backup at or before this position is restored, followed
+	// by the incremental backups needed to reach exactly this position.
+	RestoreToPos mysql.Position
+	// RestoreToTimestamp: if non-zero, the restore is a point-in-time recovery:
+	// the most recent full backup taken at or before this time is restored,
+	// followed by the binary logs of the incremental backups up to this time.
+	RestoreToTimestamp time.Time
+}
+
+// IsIncrementalRecovery returns true when the restore is a point-in-time
+// recovery, which applies incremental backups on top of a full backup.
+func (p *RestoreParams) IsIncrementalRecovery() bool {
+	return !p.RestoreToPos.IsZero() || !p.RestoreToTimestamp.IsZero()
 }
 
 // RestoreEngine is the interface to restore a backup with a given engine.
@@ -181,6 +199,14 @@ type BackupManifest struct {
 	// Position is the replication position at which the backup was taken.
 	Position mysql.Position
 
+	// Incremental is true if this is an incremental backup, which only
+	// contains the binary logs between FromPosition and Position.
+	Incremental bool `json:",omitempty"`
+
+	// FromPosition is the replication position at which the binary logs of an
+	// incremental backup start. It is empty for full backups.
+	FromPosition mysql.Position
+
 	// BackupTime is when the backup was taken in UTC time (RFC 3339 format)
 	BackupTime string
 
@@ -208,6 +234,11 @@ func FindBackupToRestore(ctx context.Context, params RestoreParams, bhs []backup
 			continue
 		}
 
+		if bm.Incremental {
+			// An incremental backup only contains binary logs, it can't be restored on its own.
+			continue
+		}
+
 		var backupTime time.Time
 		if checkBackupTime {
 			backupTime, err = time.Parse(time.RFC3339, bm.BackupTime)
@@ -238,6 +269,128 @@ func FindBackupToRestore(ctx context.Context, params RestoreParams, bhs []backup
 	return bh, nil
 }
 
+// FindLatestSuccessfulBackup returns the handle and MANIFEST of the most recent
+// backup that is complete, either full or incremental.
+func FindLatestSuccessfulBackup(ctx context.Context, logger logutil.Logger, bhs []backupstorage.BackupHandle) (backupstorage.BackupHandle, *BackupManifest, error) {
+	for index := len(bhs) - 1; index >= 0; index-- {
+		bh := bhs[index]
+		// Check that the backup MANIFEST exists and can be successfully decoded.
+		bm, err := GetBackupManifest(ctx, bh)
+		if err != nil {
+			logger.Warningf("Possibly incomplete backup %v in directory %v on BackupStorage: can't read MANIFEST: %v)", bh.Name(), bh.Directory(), err)
+			continue
+		}
+		return bh, bm, nil
+	}
+	return nil, nil, ErrNoCompleteBackup
+}
+
+// BackupManifestHandle pairs a backup with its MANIFEST.
+type BackupManifestHandle struct {
+	Handle   backupstorage.BackupHandle
+	Manifest *BackupManifest
+
+	backupTime time.Time
+}
+
+// RecoveryPath is the list of backups to restore for a point-in-time
+// recovery: a full backup, followed by incremental backups in the order in
+// which their binary logs must be applied.
+type RecoveryPath []*BackupManifestHandle
+
+// FindRecoveryPath returns the backups to restore to reach the point in time
+// requested in params, which must be an incremental recovery.
+func FindRecoveryPath(ctx context.Context, params RestoreParams, bhs []backupstorage.BackupHandle) (RecoveryPath, error) {
+	var manifests []*BackupManifestHandle
+	for _, bh := range bhs {
+		bm, err := GetBackupManifest(ctx, bh)
+		if err != nil {
+			params.Logger.Warningf("Possibly incomplete backup %v in directory %v on BackupStorage: can't read MANIFEST: %v)", bh.Name(), bh.Directory(), err)
+			continue
+		}
+		manifests = append(manifests, &BackupManifestHandle{Handle: bh, Manifest: bm})
+	}
+	return findRecoveryPath(params.Logger, manifests, params.RestoreToPos, params.RestoreToTimestamp)
+}
+
+// findRecoveryPath chooses the backups to restore among the given ones, which
+// are sorted by backup time. Exactly one of restoreToPos and restoreToTimestamp
+// must be set.
+func findRecoveryPath(logger logutil.Logger, manifests []*BackupManifestHandle, restoreToPos mysql.Position, restoreToTimestamp time.Time) (RecoveryPath, error) {
+	var candidates []*BackupManifestHandle
+	for _, m := range manifests {
+		backupTime, err := time.Parse(time.RFC3339, m.Manifest.BackupTime)
+		if err != nil {
+			logger.Warningf("Restore: skipping backup %v/%v with invalid time %v: %v", m.Handle.Directory(), m.Handle.Name(), m.Manifest.BackupTime, err)
+			continue
+		}
+		m.backupTime = backupTime
+		candidates = append(candidates, m)
+	}
+
+	// reached returns true if a recovery that ends with the given backup
+	// reaches the requested point in time.
+	reached := func(m *BackupManifestHandle, pos mysql.Position) bool {
+		if restoreToTimestamp.IsZero() {
+			return pos.AtLeast(restoreToPos)
+		}
+		return !m.backupTime.Before(restoreToTimestamp)
+	}
+
+	// First, the most recent full backup before the requested point in time.
+	fullIndex := -1
+	for i := len(candidates) - 1; i >= 0; i-- {
+		m := candidates[i]
+		if m.Manifest.Incremental {
+			continue
+		}
+		if restoreToTimestamp.IsZero() {
+			if !restoreToPos.AtLeast(m.Manifest.Position) {
+				continue
+			}
+		} else if m.backupTime.After(restoreToTimestamp) {
+			continue
+		}
+		fullIndex = i
+		break
+	}
+	if fullIndex < 0 {
+		return nil, vterrors.Errorf(vtrpc.Code_NOT_FOUND, "no full backup found before the requested point in time")
+	}
+	full := candidates[fullIndex]
+	path := RecoveryPath{full}
+	pos := full.Manifest.Position
+	if reached(full, pos) {
+		return path, nil
+	}
+
+	// Then, the incremental backups that follow it, each one starting at or
+	// before the position reached by the previous ones.
+	for _, m := range candidates[fullIndex+1:] {
+		if !m.Manifest.Incremental {
+			continue
+		}
+		if pos.AtLeast(m.Manifest.Position) {
+			// Nothing new in this backup.
+			continue
+		}
+		if !pos.AtLeast(m.Manifest.FromPosition) {
+			// There would be a gap between the restored position and this backup.
+			continue
+		}
+		path = append(path, m)
+		if pos.IsZero() {
+			pos = m.Manifest.Position
+		} else {
+			pos = mysql.Position{GTIDSet: pos.GTIDSet.Union(m.Manifest.Position.GTIDSet)}
+		}
+		if reached(m, pos) {
+			return path, nil
+		}
+	}
+	return nil, vterrors.Errorf(vtrpc.Code_NOT_FOUND, "no incremental backups found to recover from position %v to the requested point in time", pos)
+}
+
 func prepareToRestore(ctx context.Context, cnf *Mycnf, mysqld MysqlDaemon, logger logutil.Logger) error {
 	// shutdown mysqld if it is running
 	logger.Infof("Restore: shutdown mysqld")
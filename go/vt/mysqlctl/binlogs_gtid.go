@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// previousGTIDsFunc returns the GTID set logged in the Previous_gtids event
+// of a binary log, i.e. the transactions executed before that binary log.
+type previousGTIDsFunc func(ctx context.Context, binlog string) (string, error)
+
+// chooseBinlogsForIncrementalBackup chooses which binary logs need to be
+// backed up in an incremental backup, given the list of binary logs of the
+// server, from the oldest to the newest. The newest binary log is expected to
+// be freshly rotated and is never included in the backup.
+// It returns the binary logs to back up, and the GTID sets at the start and
+// at the end of those binary logs. The set at the start is contained in
+// backupFromPos, and the set at the end contains it.
+func chooseBinlogsForIncrementalBackup(
+	ctx context.Context,
+	backupFromPos mysql.Position,
+	binaryLogs []string,
+	previousGTIDs previousGTIDsFunc,
+) (binaryLogsToBackup []string, incrementalBackupFromGTID, incrementalBackupToGTID string, err error) {
+	if len(binaryLogs) < 2 {
+		return nil, "", "", vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "no binary logs to back up: expected at least two binary logs, got %v", binaryLogs)
+	}
+
+	// The newest binary log was opened by the flush at the start of the
+	// backup: all the transactions that we back up were executed before it.
+	last := binaryLogs[len(binaryLogs)-1]
+	incrementalBackupToGTID, err = previousGTIDs(ctx, last)
+	if err != nil {
+		return nil, "", "", vterrors.Wrapf(err, "cannot read previous GTIDs of binary log %v", last)
+	}
+	toPos, err := mysql.ParsePosition(mysql.Mysql56FlavorID, incrementalBackupToGTID)
+	if err != nil {
+		return nil, "", "", vterrors.Wrapf(err, "cannot parse previous GTIDs of binary log %v", last)
+	}
+	if !toPos.AtLeast(backupFromPos) {
+		return nil, "", "", vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "incremental backup position %v is not contained in the executed GTIDs %v", backupFromPos, toPos)
+	}
+	if backupFromPos.AtLeast(toPos) {
+		return nil, "", "", vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "no changes to back up since position %v", backupFromPos)
+	}
+
+	// Walk back from the newest binary log, looking for the first binary log
+	// that was opened at or before the requested position.
+	for i := len(binaryLogs) - 2; i >= 0; i-- {
+		incrementalBackupFromGTID, err = previousGTIDs(ctx, binaryLogs[i])
+		if err != nil {
+			return nil, "", "", vterrors.Wrapf(err, "cannot read previous GTIDs of binary log %v", binaryLogs[i])
+		}
+		fromPos, err := mysql.ParsePosition(mysql.Mysql56FlavorID, incrementalBackupFromGTID)
+		if err != nil {
+			return nil, "", "", vterrors.Wrapf(err, "cannot parse previous GTIDs of binary log %v", binaryLogs[i])
+		}
+		if backupFromPos.AtLeast(fromPos) {
+			binaryLogsToBackup = binaryLogs[i : len(binaryLogs)-1]
+			return binaryLogsToBackup, incrementalBackupFromGTID, incrementalBackupToGTID, nil
+		}
+	}
+	// Even the oldest binary log starts after the requested position: the
+	// binary logs that we need have been purged.
+	return nil, "", "", vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "the binary logs needed for an incremental backup from position %v have been purged", backupFromPos)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql"
+)
+
+func TestChooseBinlogsForIncrementalBackup(t *testing.T) {
+	binlogs := []string{
+		"vt-bin.000001",
+		"vt-bin.000002",
+		"vt-bin.000003",
+		"vt-bin.000004",
+		"vt-bin.000005",
+	}
+	previousGTIDs := map[string]string{
+		"vt-bin.000001": "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-50",
+		"vt-bin.000002": "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-60",
+		"vt-bin.000003": "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-60",
+		"vt-bin.000004": "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-78",
+		"vt-bin.000005": "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-243",
+	}
+	getPreviousGTIDs := func(ctx context.Context, binlog string) (string, error) {
+		gtids, ok := previousGTIDs[binlog]
+		if !ok {
+			return "", fmt.Errorf("no previous GTIDs for %v", binlog)
+		}
+		return gtids, nil
+	}
+
+	tt := []struct {
+		name           string
+		backupPos      string
+		binlogs        []string
+		expectBinlogs  []string
+		expectFromGTID string
+		expectError    string
+	}{
+		{
+			name:           "exact match",
+			backupPos:      "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-78",
+			binlogs:        binlogs,
+			expectBinlogs:  []string{"vt-bin.000004"},
+			expectFromGTID: "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-78",
+		},
+		{
+			name:           "in the middle of a binary log",
+			backupPos:      "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-70",
+			binlogs:        binlogs,
+			expectBinlogs:  []string{"vt-bin.000003", "vt-bin.000004"},
+			expectFromGTID: "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-60",
+		},
+		{
+			name:           "empty binary logs",
+			backupPos:      "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-60",
+			binlogs:        binlogs,
+			expectBinlogs:  []string{"vt-bin.000003", "vt-bin.000004"},
+			expectFromGTID: "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-60",
+		},
+		{
+			name:           "from the oldest binary log",
+			backupPos:      "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-55",
+			binlogs:        binlogs,
+			expectBinlogs:  []string{"vt-bin.000001", "vt-bin.000002", "vt-bin.000003", "vt-bin.000004"},
+			expectFromGTID: "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-50",
+		},
+		{
+			name:        "purged binary logs",
+			backupPos:   "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-40",
+			binlogs:     binlogs,
+			expectError: "have been purged",
+		},
+		{
+			name:        "no changes",
+			backupPos:   "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-243",
+			binlogs:     binlogs,
+			expectError: "no changes to back up",
+		},
+		{
+			name:        "future position",
+			backupPos:   "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-300",
+			binlogs:     binlogs,
+			expectError: "is not contained in the executed GTIDs",
+		},
+		{
+			name:        "unknown server",
+			backupPos:   "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-78,0d7aaca6-1666-11ee-aeaf-0a43f95f28a3:1-3",
+			binlogs:     binlogs,
+			expectError: "is not contained in the executed GTIDs",
+		},
+		{
+			name:        "single binary log",
+			backupPos:   "16b1039f-22b6-11ed-b765-0a43f95f28a3:1-50",
+			binlogs:     []string{"vt-bin.000001"},
+			expectError: "expected at least two binary logs",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			backupPos, err := mysql.ParsePosition(mysql.Mysql56FlavorID, tc.backupPos)
+			require.NoError(t, err)
+			binlogsToBackup, fromGTID, toGTID, err := chooseBinlogsForIncrementalBackup(context.Background(), backupPos, tc.binlogs, getPreviousGTIDs)
+			if tc.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectBinlogs, binlogsToBackup)
+			assert.Equal(t, tc.expectFromGTID, fromGTID)
+			assert.Equal(t, previousGTIDs[tc.binlogs[len(tc.binlogs)-1]], toGTID)
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -92,6 +93,9 @@ type builtinBackupManifest struct {
 	// false for backups that were created before the field existed, and those
 	// backups all had compression enabled.
 	SkipCompress bool
+
+	// Encryption describes how the files were encrypted, if they were.
+	Encryption *EncryptionParams `json:",omitempty"`
 }
 
 // FileEntry is one file to backup
@@ -100,6 +104,7 @@ type FileEntry struct {
 	// - backupInnodbDataHomeDir for files that go into Mycnf.InnodbDataHomeDir
 	// - backupInnodbLogGroupHomeDir for files that go into Mycnf.InnodbLogGroupHomeDir
 	// - backupData for files that go into Mycnf.DataDir
+	// - backupBinlogDir for the binary logs of incremental backups
 	Base string
 
 	// Name is the file name, relative to Base
@@ -108,6 +113,10 @@ type FileEntry struct {
 	// Hash is the hash of the final data (transformed and
 	// compressed if specified) stored in the BackupStorage.
 	Hash string
+
+	// ParentPath is an optional directory that overrides the root of Base,
+	// used to restore files to a temporary location. It is never serialized.
+	ParentPath string `json:"-"`
 }
 
 func (fe *FileEntry) open(cnf *Mycnf, readOnly bool) (*os.File, error) {
@@ -120,9 +129,14 @@ func (fe *FileEntry) open(cnf *Mycnf, readOnly bool) (*os.File, error) {
 		root = cnf.InnodbLogGroupHomeDir
 	case backupData:
 		root = cnf.DataDir
+	case backupBinlogDir:
+		root = filepath.Dir(cnf.BinLogPath)
 	default:
 		return nil, vterrors.Errorf(vtrpc.Code_UNKNOWN, "unknown base: %v", fe.Base)
 	}
+	if fe.ParentPath != "" {
+		root = fe.ParentPath
+	}
 
 	// and open the file
 	name := path.Join(root, fe.Name)
@@ -150,6 +164,100 @@ func (be *BuiltinBackupEngine) ExecuteBackup(ctx context.Context, params BackupP
 
 	params.Logger.Infof("Hook: %v, Compress: %v", *backupStorageHook, *backupStorageCompress)
 
+	if params.IncrementalFromPos != "" {
+		return be.executeIncrementalBackup(ctx, params, bh)
+	}
+	return be.executeFullBackup(ctx, params, bh)
+}
+
+// executeIncrementalBackup backs up the binary logs that contain the
+// transactions executed since params.IncrementalFromPos. mysqld keeps running
+// during the backup: the current binary log is rotated, and all the complete
+// binary logs that are needed are copied.
+func (be *BuiltinBackupEngine) executeIncrementalBackup(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle) (bool, error) {
+	if params.IncrementalFromPos == AutoIncrementalFromPos {
+		params.Logger.Infof("auto evaluating incremental_from_pos")
+		bs, err := backupstorage.GetBackupStorage()
+		if err != nil {
+			return false, vterrors.Wrap(err, "unable to get backup storage")
+		}
+		defer bs.Close()
+		bhs, err := bs.ListBackups(ctx, GetBackupDir(params.Keyspace, params.Shard))
+		if err != nil {
+			return false, vterrors.Wrap(err, "ListBackups failed")
+		}
+		_, manifest, err := FindLatestSuccessfulBackup(ctx, params.Logger, bhs)
+		if err != nil {
+			return false, vterrors.Wrap(err, "cannot find the last successful backup")
+		}
+		params.IncrementalFromPos = mysql.EncodePosition(manifest.Position)
+		params.Logger.Infof("auto evaluated incremental_from_pos: %s", params.IncrementalFromPos)
+	}
+
+	backupFromPos, err := mysql.DecodePosition(params.IncrementalFromPos)
+	if err != nil {
+		return false, vterrors.Wrapf(err, "cannot decode position in incremental backup: %v", params.IncrementalFromPos)
+	}
+	if !backupFromPos.MatchesFlavor(mysql.Mysql56FlavorID) {
+		// incremental backups are only supported for MySQL GTID positions
+		return false, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "incremental backup only supports MySQL GTID positions. Got: %v", params.IncrementalFromPos)
+	}
+
+	_, logEnabled, _, _, err := params.Mysqld.GetBinlogInformation(ctx)
+	if err != nil {
+		return false, vterrors.Wrap(err, "can't get binlog information")
+	}
+	if !logEnabled {
+		return false, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "incremental backup requires binary logs to be enabled")
+	}
+
+	// Rotate the binary log, so that all the binary logs we back up are
+	// complete, and the new one starts at the current position.
+	if err := params.Mysqld.FlushBinaryLogs(ctx); err != nil {
+		return false, vterrors.Wrap(err, "cannot flush binary logs in incremental backup")
+	}
+	binaryLogs, err := params.Mysqld.GetBinaryLogs(ctx)
+	if err != nil {
+		return false, vterrors.Wrap(err, "cannot get binary logs in incremental backup")
+	}
+	binaryLogsToBackup, incrementalBackupFromGTID, incrementalBackupToGTID, err := chooseBinlogsForIncrementalBackup(ctx, backupFromPos, binaryLogs, params.Mysqld.GetPreviousGTIDs)
+	if err != nil {
+		return false, vterrors.Wrap(err, "cannot choose binary logs for incremental backup")
+	}
+	// The position we were asked to back up from may be in the middle of the
+	// first binary log: the backup actually starts at the beginning of that
+	// binary log, which is what we record in the MANIFEST.
+	incrementalBackupFromPosition, err := mysql.ParsePosition(mysql.Mysql56FlavorID, incrementalBackupFromGTID)
+	if err != nil {
+		return false, vterrors.Wrapf(err, "cannot parse position %v", incrementalBackupFromGTID)
+	}
+	incrementalBackupToPosition, err := mysql.ParsePosition(mysql.Mysql56FlavorID, incrementalBackupToGTID)
+	if err != nil {
+		return false, vterrors.Wrapf(err, "cannot parse position %v", incrementalBackupToGTID)
+	}
+	params.Logger.Infof("backing up binary logs %v, from position %v to position %v", binaryLogsToBackup, incrementalBackupFromPosition, incrementalBackupToPosition)
+
+	fes := make([]FileEntry, len(binaryLogsToBackup))
+	for i, binlog := range binaryLogsToBackup {
+		fes[i] = FileEntry{
+			Base: backupBinlogDir,
+			Name: binlog,
+		}
+	}
+	bm := BackupManifest{
+		Position:     incrementalBackupToPosition,
+		Incremental:  true,
+		FromPosition: incrementalBackupFromPosition,
+	}
+	if err := be.backupFiles(ctx, params, bh, fes, bm); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// executeFullBackup takes a full backup of the data files. mysqld is shut
+// down during the backup.
+func (be *BuiltinBackupEngine) executeFullBackup(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle) (bool, error) {
 	// Save initial state so we can restore.
 	replicaStartRequired := false
 	sourceIsPrimary := false
@@ -210,7 +318,13 @@ func (be *BuiltinBackupEngine) ExecuteBackup(ctx context.Context, params BackupP
 	}
 
 	// Backup everything, capture the error.
-	backupErr := be.backupFiles(ctx, params, bh, replicationPosition)
+	var backupErr error
+	fes, _, err := findFilesToBackup(params.Cnf)
+	if err != nil {
+		backupErr = vterrors.Wrap(err, "can't find files to backup")
+	} else {
+		backupErr = be.backupFiles(ctx, params, bh, fes, BackupManifest{Position: replicationPosition})
+	}
 	usable := backupErr == nil
 
 	// Try to restart mysqld, use background context in case we timed out the original context
@@ -285,16 +399,18 @@ func (be *BuiltinBackupEngine) ExecuteBackup(ctx context.Context, params BackupP
 	return usable, backupErr
 }
 
-// backupFiles finds the list of files to backup, and creates the backup.
-func (be *BuiltinBackupEngine) backupFiles(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle, replicationPosition mysql.Position) (finalErr error) {
+// backupFiles backs up the given files, and writes the MANIFEST. The
+// positions of the backup are taken from the given base manifest.
+func (be *BuiltinBackupEngine) backupFiles(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle, fes []FileEntry, baseManifest BackupManifest) (finalErr error) {
+	params.Logger.Infof("found %v files to backup", len(fes))
 
-	// Get the files to backup.
-	// We don't care about totalSize because we add each file separately.
-	fes, _, err := findFilesToBackup(params.Cnf)
+	encryption, err := newBackupEncryption(ctx)
 	if err != nil {
-		return vterrors.Wrap(err, "can't find files to backup")
+		return vterrors.Wrap(err, "cannot set up backup encryption")
+	}
+	if encryption != nil {
+		params.Logger.Infof("Encrypting backup using key manager %v, key %v", encryption.params.KeyManager, encryption.params.KeyID)
 	}
-	params.Logger.Infof("found %v files to backup", len(fes))
 
 	// Backup with the provided concurrency.
 	sema := sync2.NewSemaphore(params.Concurrency, 0)
@@ -314,7 +430,7 @@ func (be *BuiltinBackupEngine) backupFiles(ctx context.Context, params BackupPar
 
 			// Backup the individual file.
 			name := fmt.Sprintf("%v", i)
-			bh.RecordError(be.backupFile(ctx, params, bh, &fes[i], name))
+			bh.RecordError(be.backupFile(ctx, params, bh, &fes[i], name, encryption))
 		}(i)
 	}
 
@@ -348,7 +464,9 @@ func (be *BuiltinBackupEngine) backupFiles(ctx context.Context, params BackupPar
 		// Common base fields
 		BackupManifest: BackupManifest{
 			BackupMethod: builtinBackupEngineName,
-			Position:     replicationPosition,
+			Position:     baseManifest.Position,
+			Incremental:  baseManifest.Incremental,
+			FromPosition: baseManifest.FromPosition,
 			BackupTime:   params.BackupTime.UTC().Format(time.RFC3339),
 			FinishedTime: time.Now().UTC().Format(time.RFC3339),
 		},
@@ -359,6 +477,9 @@ func (be *BuiltinBackupEngine) backupFiles(ctx context.Context, params BackupPar
 		SkipCompress:      !*backupStorageCompress,
 		CompressionEngine: *CompressionEngineName,
 	}
+	if encryption != nil {
+		bm.Encryption = encryption.params
+	}
 	data, err := json.MarshalIndent(bm, "", "  ")
 	if err != nil {
 		return vterrors.Wrapf(err, "cannot JSON encode %v", backupManifestFileName)
@@ -453,8 +574,8 @@ func (bp *backupPipe) ReportProgress(period time.Duration, logger logutil.Logger
 	}
 }
 
-// backupFile backs up an individual file.
-func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle, fe *FileEntry, name string) (finalErr error) {
+// backupFile backs up an individual file, encrypting it if encryption is not nil.
+func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle, fe *FileEntry, name string, encryption *backupEncryption) (finalErr error) {
 	// Open the source file for reading.
 	source, err := fe.open(params.Cnf, true)
 	if err != nil {
@@ -490,6 +611,17 @@ func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupPara
 
 	var writer io.Writer = bw
 
+	// Create the encryption pipe, if necessary. It is the last step before
+	// the data is written to the BackupStorage.
+	var encryptor io.WriteCloser
+	if encryption != nil {
+		encryptor, err = encryption.newEncryptor(writer)
+		if err != nil {
+			return vterrors.Wrap(err, "can't create encryptor")
+		}
+		writer = encryptor
+	}
+
 	// Create the external write pipe, if any.
 	var pipe io.WriteCloser
 	var wait hook.WaitFunc
@@ -545,6 +677,13 @@ func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupPara
 		}
 	}
 
+	// Close the encryptor to write the last chunk.
+	if encryptor != nil {
+		if err := encryptor.Close(); err != nil {
+			return vterrors.Wrap(err, "cannot close encryptor")
+		}
+	}
+
 	// Close the backupPipe to finish writing on destination.
 	if err = bw.Close(); err != nil {
 		return vterrors.Wrapf(err, "cannot flush destination: %v", name)
@@ -570,6 +709,10 @@ func (be *BuiltinBackupEngine) ExecuteRestore(ctx context.Context, params Restor
 		return nil, err
 	}
 
+	if bm.Incremental {
+		return be.executeIncrementalRestore(ctx, params, bh, bm)
+	}
+
 	// mark restore as in progress
 	if err := createStateFile(params.Cnf); err != nil {
 		return nil, err
@@ -590,10 +733,47 @@ func (be *BuiltinBackupEngine) ExecuteRestore(ctx context.Context, params Restor
 	return &bm.BackupManifest, nil
 }
 
+// executeIncrementalRestore applies the binary logs of an incremental backup
+// to a running mysqld, up to the point in time requested in params.
+func (be *BuiltinBackupEngine) executeIncrementalRestore(ctx context.Context, params RestoreParams, bh backupstorage.BackupHandle, bm builtinBackupManifest) (*BackupManifest, error) {
+	params.Logger.Infof("Restore: applying incremental backup %v, from position %v to position %v", bh.Name(), bm.FromPosition, bm.Position)
+
+	// The binary logs are restored into a temporary directory, and applied
+	// from there.
+	createdDir, err := os.MkdirTemp(params.Cnf.TabletDir(), "restore-incremental-*")
+	if err != nil {
+		return nil, vterrors.Wrap(err, "can't create temporary directory for binary logs")
+	}
+	defer os.RemoveAll(createdDir)
+	for i := range bm.FileEntries {
+		bm.FileEntries[i].ParentPath = createdDir
+	}
+
+	params.Logger.Infof("Restore: copying %v binary logs", len(bm.FileEntries))
+	if err := be.restoreFiles(ctx, params, bh, bm); err != nil {
+		return nil, vterrors.Wrap(err, "failed to restore binary logs")
+	}
+
+	for _, fe := range bm.FileEntries {
+		binlogFile := path.Join(createdDir, fe.Name)
+		params.Logger.Infof("Restore: applying binary log %v", fe.Name)
+		if err := params.Mysqld.ApplyBinlogFile(ctx, binlogFile, params.RestoreToPos, params.RestoreToTimestamp); err != nil {
+			return nil, vterrors.Wrapf(err, "failed to apply binary log %v", fe.Name)
+		}
+	}
+
+	params.Logger.Infof("Restore: applied incremental backup %v", bh.Name())
+	return &bm.BackupManifest, nil
+}
+
 // restoreFiles will copy all the files from the BackupStorage to the
 // right place.
 func (be *BuiltinBackupEngine) restoreFiles(ctx context.Context, params RestoreParams, bh backupstorage.BackupHandle, bm builtinBackupManifest) error {
 	fes := bm.FileEntries
+	encryption, err := openBackupEncryption(ctx, bm.Encryption)
+	if err != nil {
+		return vterrors.Wrap(err, "cannot set up backup decryption")
+	}
 	sema := sync2.NewSemaphore(params.Concurrency, 0)
 	rec := concurrency.AllErrorRecorder{}
 	wg := sync.WaitGroup{}
@@ -613,7 +793,7 @@ func (be *BuiltinBackupEngine) restoreFiles(ctx context.Context, params RestoreP
 			// And restore the file.
 			name := fmt.Sprintf("%v", i)
 			params.Logger.Infof("Copying file %v: %v", name, fes[i].Name)
-			err := be.restoreFile(ctx, params, bh, &fes[i], bm, name)
+			err := be.restoreFile(ctx, params, bh, &fes[i], bm, name, encryption)
 			if err != nil {
 				rec.RecordError(vterrors.Wrapf(err, "can't restore file %v to %v", name, fes[i].Name))
 			}
@@ -623,8 +803,8 @@ func (be *BuiltinBackupEngine) restoreFiles(ctx context.Context, params RestoreP
 	return rec.Error()
 }
 
-// restoreFile restores an individual file.
-func (be *BuiltinBackupEngine) restoreFile(ctx context.Context, params RestoreParams, bh backupstorage.BackupHandle, fe *FileEntry, bm builtinBackupManifest, name string) (finalErr error) {
+// restoreFile restores an individual file, decrypting it if encryption is not nil.
+func (be *BuiltinBackupEngine) restoreFile(ctx context.Context, params RestoreParams, bh backupstorage.BackupHandle, fe *FileEntry, bm builtinBackupManifest, name string, encryption *backupEncryption) (finalErr error) {
 	// Open the source file for reading.
 	source, err := bh.ReadFile(ctx, name)
 	if err != nil {
@@ -654,6 +834,14 @@ func (be *BuiltinBackupEngine) restoreFile(ctx context.Context, params RestorePa
 	dst := bufio.NewWriterSize(dstFile, writerBufferSize)
 	var reader io.Reader = bp
 
+	// Create the decryption pipe, if necessary.
+	if encryption != nil {
+		reader, err = encryption.newDecryptor(reader)
+		if err != nil {
+			return vterrors.Wrap(err, "can't create decryptor")
+		}
+	}
+
 	// Create the external read pipe, if any.
 	var wait hook.WaitFunc
 	if bm.TransformHook != "" {
@@ -0,0 +1,427 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+const (
+	// AES256GCMEncryption is the only encryption algorithm supported for backups:
+	// AES-256 in GCM mode, applied to fixed size chunks of each file.
+	AES256GCMEncryption = "aes-256-gcm"
+
+	// KeyfileKeyManager is the name of the KeyManager that wraps data keys with
+	// a master key read from a local file.
+	KeyfileKeyManager = "keyfile"
+
+	encryptionKeySize        = 32
+	encryptionNoncePrefixLen = 8
+	encryptionChunkSize      = 64 * 1024
+)
+
+var (
+	// backupEncryptionKeyManager is the KeyManager used to encrypt new backups.
+	// Backups are not encrypted if it is empty.
+	backupEncryptionKeyManager = flag.String("backup-encryption-key-manager", "", "if set, the builtin backup engine encrypts the backup files with AES-256-GCM, using a data key wrapped by this key manager. Supported values: 'keyfile', or any key manager registered by a plugin.")
+
+	// backupEncryptionKeyfile is the file that holds the master key of the keyfile KeyManager.
+	backupEncryptionKeyfile = flag.String("backup-encryption-keyfile", "", "path to a file containing the 32 bytes master key, raw or hex encoded, used by the 'keyfile' backup encryption key manager.")
+)
+
+// KeyManager wraps the data keys that encrypt the backup files, so that they
+// can be stored in the MANIFEST next to the files. It is typically backed by
+// a local key or by a KMS.
+type KeyManager interface {
+	// WrapKey encrypts a data key. It returns the wrapped key, and the ID
+	// of the master key used to wrap it.
+	WrapKey(ctx context.Context, dataKey []byte) (wrappedKey []byte, keyID string, err error)
+
+	// UnwrapKey decrypts a data key wrapped by WrapKey.
+	UnwrapKey(ctx context.Context, wrappedKey []byte, keyID string) (dataKey []byte, err error)
+}
+
+var (
+	keyManagersMu sync.Mutex
+	keyManagers   = map[string]KeyManager{}
+)
+
+// RegisterKeyManager registers a KeyManager under the given name, so that it
+// can be selected with --backup-encryption-key-manager. It is meant to be
+// called from the init() of KMS plugins.
+func RegisterKeyManager(name string, km KeyManager) {
+	keyManagersMu.Lock()
+	defer keyManagersMu.Unlock()
+	if _, ok := keyManagers[name]; ok {
+		panic(fmt.Sprintf("KeyManager %v already registered", name))
+	}
+	keyManagers[name] = km
+}
+
+func getKeyManager(name string) (KeyManager, error) {
+	keyManagersMu.Lock()
+	defer keyManagersMu.Unlock()
+	km, ok := keyManagers[name]
+	if !ok {
+		return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "unknown backup encryption key manager %q", name)
+	}
+	return km, nil
+}
+
+// EncryptionParams describes how the files of a backup were encrypted. It is
+// stored in the MANIFEST, so that restores can decrypt the files without any
+// flag besides the ones needed by the KeyManager.
+type EncryptionParams struct {
+	// Algorithm is the encryption algorithm. Only AES256GCMEncryption is supported.
+	Algorithm string
+
+	// KeyManager is the name of the KeyManager that wrapped the data key.
+	KeyManager string
+
+	// KeyID identifies the master key that wrapped the data key.
+	KeyID string
+
+	// WrappedKey is the data key, wrapped by the KeyManager.
+	WrappedKey []byte
+
+	// ChunkSize is the size of the plaintext chunks that are sealed individually.
+	ChunkSize int
+}
+
+// backupEncryption holds the data key used to encrypt or decrypt the
+// files of one backup.
+type backupEncryption struct {
+	params  *EncryptionParams
+	dataKey []byte
+}
+
+// newBackupEncryption creates a data key for a new backup, using the key
+// manager configured by flags. It returns nil if encryption is not enabled.
+func newBackupEncryption(ctx context.Context) (*backupEncryption, error) {
+	if *backupEncryptionKeyManager == "" {
+		return nil, nil
+	}
+	km, err := getKeyManager(*backupEncryptionKeyManager)
+	if err != nil {
+		return nil, err
+	}
+	dataKey := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, vterrors.Wrap(err, "cannot generate backup data key")
+	}
+	wrappedKey, keyID, err := km.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot wrap backup data key with key manager %v", *backupEncryptionKeyManager)
+	}
+	return &backupEncryption{
+		params: &EncryptionParams{
+			Algorithm:  AES256GCMEncryption,
+			KeyManager: *backupEncryptionKeyManager,
+			KeyID:      keyID,
+			WrappedKey: wrappedKey,
+			ChunkSize:  encryptionChunkSize,
+		},
+		dataKey: dataKey,
+	}, nil
+}
+
+// openBackupEncryption unwraps the data key of an existing backup. It returns
+// nil if the backup is not encrypted.
+func openBackupEncryption(ctx context.Context, params *EncryptionParams) (*backupEncryption, error) {
+	if params == nil {
+		return nil, nil
+	}
+	if params.Algorithm != AES256GCMEncryption {
+		return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "unsupported backup encryption algorithm %q", params.Algorithm)
+	}
+	if params.ChunkSize <= 0 {
+		return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "invalid backup encryption chunk size %v", params.ChunkSize)
+	}
+	km, err := getKeyManager(params.KeyManager)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := km.UnwrapKey(ctx, params.WrappedKey, params.KeyID)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot unwrap backup data key with key manager %v", params.KeyManager)
+	}
+	return &backupEncryption{params: params, dataKey: dataKey}, nil
+}
+
+// The encrypted stream of a file starts with a random nonce prefix, followed by
+// the sealed chunks. The nonce of a chunk is the prefix followed by the index of
+// the chunk, and its additional data tells whether it is the last chunk, so that
+// a truncated or reordered stream fails to decrypt. There is always a last
+// chunk, which is only made of its tag if the file is empty.
+
+// newEncryptor returns a writer that encrypts the data written to it before
+// writing it to the underlying writer. Closing it flushes the last chunk but
+// doesn't close the underlying writer.
+func (e *backupEncryption) newEncryptor(w io.Writer) (io.WriteCloser, error) {
+	aead, err := newGCM(e.dataKey)
+	if err != nil {
+		return nil, vterrors.Wrap(err, "cannot create backup encryptor")
+	}
+	enc := &encryptor{
+		w:         w,
+		aead:      aead,
+		chunkSize: e.params.ChunkSize,
+		nonce:     make([]byte, aead.NonceSize()),
+	}
+	if _, err := rand.Read(enc.nonce[:encryptionNoncePrefixLen]); err != nil {
+		return nil, vterrors.Wrap(err, "cannot generate backup encryption nonce")
+	}
+	if _, err := w.Write(enc.nonce[:encryptionNoncePrefixLen]); err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+// newDecryptor returns a reader that decrypts the data read from the
+// underlying reader.
+func (e *backupEncryption) newDecryptor(r io.Reader) (io.Reader, error) {
+	aead, err := newGCM(e.dataKey)
+	if err != nil {
+		return nil, vterrors.Wrap(err, "cannot create backup decryptor")
+	}
+	dec := &decryptor{
+		r:         bufio.NewReader(r),
+		aead:      aead,
+		chunkSize: e.params.ChunkSize,
+		nonce:     make([]byte, aead.NonceSize()),
+	}
+	if _, err := io.ReadFull(dec.r, dec.nonce[:encryptionNoncePrefixLen]); err != nil {
+		return nil, vterrors.Wrap(err, "cannot read backup encryption nonce")
+	}
+	return dec, nil
+}
+
+var (
+	encryptionChunkAD     = []byte{0}
+	encryptionLastChunkAD = []byte{1}
+)
+
+type encryptor struct {
+	w         io.Writer
+	aead      cipher.AEAD
+	chunkSize int
+	nonce     []byte
+	counter   uint32
+	buf       []byte
+	sealed    []byte
+}
+
+// Write implements io.Writer.
+func (enc *encryptor) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := enc.chunkSize + 1 - len(enc.buf)
+		if n > len(p) {
+			n = len(p)
+		}
+		enc.buf = append(enc.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		// A chunk is only sealed once we know it is not the last one, so that
+		// Close always has a chunk left to seal as the last one.
+		if len(enc.buf) > enc.chunkSize {
+			if err := enc.seal(enc.buf[:enc.chunkSize], encryptionChunkAD); err != nil {
+				return written, err
+			}
+			enc.buf = append(enc.buf[:0], enc.buf[enc.chunkSize:]...)
+		}
+	}
+	return written, nil
+}
+
+// Close implements io.Closer.
+func (enc *encryptor) Close() error {
+	return enc.seal(enc.buf, encryptionLastChunkAD)
+}
+
+func (enc *encryptor) seal(chunk, ad []byte) error {
+	binary.BigEndian.PutUint32(enc.nonce[encryptionNoncePrefixLen:], enc.counter)
+	enc.counter++
+	enc.sealed = enc.aead.Seal(enc.sealed[:0], enc.nonce, chunk, ad)
+	_, err := enc.w.Write(enc.sealed)
+	return err
+}
+
+type decryptor struct {
+	r         *bufio.Reader
+	aead      cipher.AEAD
+	chunkSize int
+	nonce     []byte
+	counter   uint32
+	sealed    []byte
+	data      []byte
+	pos       int
+	done      bool
+}
+
+// Read implements io.Reader.
+func (dec *decryptor) Read(p []byte) (int, error) {
+	for dec.pos == len(dec.data) {
+		if dec.done {
+			return 0, io.EOF
+		}
+		if err := dec.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, dec.data[dec.pos:])
+	dec.pos += n
+	return n, nil
+}
+
+func (dec *decryptor) open() error {
+	size := dec.chunkSize + dec.aead.Overhead()
+	if cap(dec.sealed) < size {
+		dec.sealed = make([]byte, size)
+	}
+	dec.sealed = dec.sealed[:size]
+	n, err := io.ReadFull(dec.r, dec.sealed)
+	switch err {
+	case nil:
+		// The chunk is the last one if nothing follows it.
+		if _, err := dec.r.Peek(1); err == io.EOF {
+			dec.done = true
+		} else if err != nil {
+			return err
+		}
+	case io.ErrUnexpectedEOF:
+		dec.done = true
+	case io.EOF:
+		return vterrors.Errorf(vtrpc.Code_DATA_LOSS, "encrypted backup file is truncated")
+	default:
+		return err
+	}
+
+	ad := encryptionChunkAD
+	if dec.done {
+		ad = encryptionLastChunkAD
+	}
+	binary.BigEndian.PutUint32(dec.nonce[encryptionNoncePrefixLen:], dec.counter)
+	dec.counter++
+	dec.data, err = dec.aead.Open(dec.data[:0], dec.nonce, dec.sealed[:n], ad)
+	if err != nil {
+		return vterrors.Wrapf(err, "cannot decrypt chunk %v of encrypted backup file", dec.counter-1)
+	}
+	dec.pos = 0
+	return nil
+}
+
+// keyfileKeyManager wraps data keys with AES-256-GCM, using a master key read
+// from --backup-encryption-keyfile. The ID of the master key is a fingerprint
+// of the key, so that using the wrong key file gives a clear error.
+type keyfileKeyManager struct{}
+
+func (keyfileKeyManager) masterKey() (key []byte, keyID string, err error) {
+	if *backupEncryptionKeyfile == "" {
+		return nil, "", vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "--backup-encryption-keyfile is required by the %v key manager", KeyfileKeyManager)
+	}
+	data, err := os.ReadFile(*backupEncryptionKeyfile)
+	if err != nil {
+		return nil, "", vterrors.Wrapf(err, "cannot read backup encryption key file")
+	}
+	key, err = parseMasterKey(data)
+	if err != nil {
+		return nil, "", err
+	}
+	fingerprint := sha256.Sum256(key)
+	return key, hex.EncodeToString(fingerprint[:8]), nil
+}
+
+// parseMasterKey accepts a 32 bytes key, either raw or hex encoded.
+func parseMasterKey(data []byte) ([]byte, error) {
+	if len(data) == encryptionKeySize {
+		return data, nil
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 2*encryptionKeySize {
+		key, err := hex.DecodeString(string(trimmed))
+		if err == nil {
+			return key, nil
+		}
+	}
+	return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "backup encryption key file must contain a %v bytes key, raw or hex encoded", encryptionKeySize)
+}
+
+// WrapKey is part of the KeyManager interface.
+func (km keyfileKeyManager) WrapKey(ctx context.Context, dataKey []byte) ([]byte, string, error) {
+	key, keyID, err := km.masterKey()
+	if err != nil {
+		return nil, "", err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, "", err
+	}
+	return aead.Seal(nonce, nonce, dataKey, []byte(keyID)), keyID, nil
+}
+
+// UnwrapKey is part of the KeyManager interface.
+func (km keyfileKeyManager) UnwrapKey(ctx context.Context, wrappedKey []byte, keyID string) ([]byte, error) {
+	key, masterKeyID, err := km.masterKey()
+	if err != nil {
+		return nil, err
+	}
+	if keyID != masterKeyID {
+		return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "backup was encrypted with key %v, but --backup-encryption-keyfile contains key %v", keyID, masterKeyID)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrappedKey) < aead.NonceSize() {
+		return nil, vterrors.Errorf(vtrpc.Code_DATA_LOSS, "wrapped backup data key is too short")
+	}
+	nonce, sealed := wrappedKey[:aead.NonceSize()], wrappedKey[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, []byte(keyID))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func init() {
+	RegisterKeyManager(KeyfileKeyManager, keyfileKeyManager{})
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBackupEncryptionKeyfile(t *testing.T) {
+	key := make([]byte, encryptionKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	keyfile := path.Join(t.TempDir(), "backup.key")
+	require.NoError(t, os.WriteFile(keyfile, []byte(hex.EncodeToString(key)+"\n"), 0600))
+
+	oldKeyManager, oldKeyfile := *backupEncryptionKeyManager, *backupEncryptionKeyfile
+	*backupEncryptionKeyManager, *backupEncryptionKeyfile = KeyfileKeyManager, keyfile
+	t.Cleanup(func() {
+		*backupEncryptionKeyManager, *backupEncryptionKeyfile = oldKeyManager, oldKeyfile
+	})
+}
+
+func TestBackupEncryptionRoundTrip(t *testing.T) {
+	setupBackupEncryptionKeyfile(t)
+	ctx := context.Background()
+
+	encryption, err := newBackupEncryption(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, encryption)
+	// Use small chunks to exercise the chunk boundaries.
+	encryption.params.ChunkSize = 16
+
+	// The restore only knows about the params stored in the MANIFEST.
+	decryption, err := openBackupEncryption(ctx, encryption.params)
+	require.NoError(t, err)
+	assert.Equal(t, encryption.dataKey, decryption.dataKey)
+
+	for _, size := range []int{0, 1, 15, 16, 17, 32, 100} {
+		data := make([]byte, size)
+		_, err := rand.Read(data)
+		require.NoError(t, err)
+
+		var encrypted bytes.Buffer
+		encryptor, err := encryption.newEncryptor(&encrypted)
+		require.NoError(t, err)
+		_, err = encryptor.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, encryptor.Close())
+		if size > 0 {
+			assert.False(t, bytes.Contains(encrypted.Bytes(), data), "size %v: plaintext found in encrypted data", size)
+		}
+
+		decryptor, err := decryption.newDecryptor(bytes.NewReader(encrypted.Bytes()))
+		require.NoError(t, err)
+		decrypted, err := io.ReadAll(decryptor)
+		require.NoError(t, err, "size %v", size)
+		assert.Equal(t, data, decrypted, "size %v", size)
+
+		// Dropping the last chunk, or tampering with the data, must be detected.
+		for _, corrupted := range [][]byte{
+			encrypted.Bytes()[:encrypted.Len()-(size%16)-16],
+			append(append([]byte{}, encrypted.Bytes()[:encrypted.Len()-1]...), encrypted.Bytes()[encrypted.Len()-1]^1),
+		} {
+			decryptor, err := decryption.newDecryptor(bytes.NewReader(corrupted))
+			if err == nil {
+				_, err = io.ReadAll(decryptor)
+			}
+			assert.Error(t, err, "size %v: corrupted data was decrypted", size)
+		}
+	}
+}
+
+func TestBackupEncryptionDisabled(t *testing.T) {
+	encryption, err := newBackupEncryption(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, encryption)
+
+	decryption, err := openBackupEncryption(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Nil(t, decryption)
+}
+
+func TestBackupEncryptionWrongKey(t *testing.T) {
+	setupBackupEncryptionKeyfile(t)
+	encryption, err := newBackupEncryption(context.Background())
+	require.NoError(t, err)
+
+	// Restoring with another key file must fail.
+	setupBackupEncryptionKeyfile(t)
+	_, err = openBackupEncryption(context.Background(), encryption.params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "backup was encrypted with key")
+}
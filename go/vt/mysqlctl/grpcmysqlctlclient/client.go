@@ -29,6 +29,7 @@ import (
 
 	"context"
 
+	"vitess.io/vitess/go/protoutil"
 	"vitess.io/vitess/go/vt/grpcclient"
 	"vitess.io/vitess/go/vt/mysqlctl/mysqlctlclient"
 
@@ -100,6 +101,21 @@ func (c *client) RefreshConfig(ctx context.Context) error {
 	})
 }
 
+// ApplyBinlogFile is part of the MysqlctlClient interface.
+func (c *client) ApplyBinlogFile(ctx context.Context, binlogFileName, binlogRestorePosition string, binlogRestoreDatetime time.Time) error {
+	req := &mysqlctlpb.ApplyBinlogFileRequest{
+		BinlogFileName:        binlogFileName,
+		BinlogRestorePosition: binlogRestorePosition,
+	}
+	if !binlogRestoreDatetime.IsZero() {
+		req.BinlogRestoreDatetime = protoutil.TimeToProto(binlogRestoreDatetime)
+	}
+	return c.withRetry(ctx, func() error {
+		_, err := c.c.ApplyBinlogFile(ctx, req)
+		return err
+	})
+}
+
 // Close is part of the MysqlctlClient interface.
 func (c *client) Close() {
 	c.cc.Close()
@@ -25,6 +25,8 @@ import (
 
 	"context"
 
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/protoutil"
 	"vitess.io/vitess/go/vt/mysqlctl"
 
 	mysqlctlpb "vitess.io/vitess/go/vt/proto/mysqlctl"
@@ -62,6 +64,20 @@ func (s *server) RefreshConfig(ctx context.Context, request *mysqlctlpb.RefreshC
 	return &mysqlctlpb.RefreshConfigResponse{}, s.mysqld.RefreshConfig(ctx, s.cnf)
 }
 
+// ApplyBinlogFile implements the server side of the MysqlctlClient interface.
+func (s *server) ApplyBinlogFile(ctx context.Context, request *mysqlctlpb.ApplyBinlogFileRequest) (*mysqlctlpb.ApplyBinlogFileResponse, error) {
+	var restorePos mysql.Position
+	if request.BinlogRestorePosition != "" {
+		var err error
+		restorePos, err = mysql.DecodePosition(request.BinlogRestorePosition)
+		if err != nil {
+			return nil, err
+		}
+	}
+	restoreTime := protoutil.TimeFromProto(request.BinlogRestoreDatetime)
+	return &mysqlctlpb.ApplyBinlogFileResponse{}, s.mysqld.ApplyBinlogFile(ctx, request.BinlogFileName, restorePos, restoreTime)
+}
+
 // StartServer registers the Server for RPCs.
 func StartServer(s *grpc.Server, cnf *mysqlctl.Mycnf, mysqld *mysqlctl.Mysqld) {
 	mysqlctlpb.RegisterMysqlCtlServer(s, &server{cnf: cnf, mysqld: mysqld})
@@ -21,6 +21,7 @@ package mysqlctlclient
 import (
 	"flag"
 	"fmt"
+	"time"
 
 	"context"
 
@@ -46,6 +47,9 @@ type MysqlctlClient interface {
 	// RefreshConfig calls Mysqld.RefreshConfig remotely.
 	RefreshConfig(ctx context.Context) error
 
+	// ApplyBinlogFile calls Mysqld.ApplyBinlogFile remotely.
+	ApplyBinlogFile(ctx context.Context, binlogFileName, binlogRestorePosition string, binlogRestoreDatetime time.Time) error
+
 	// Close will terminate the connection. This object won't be used anymore.
 	Close()
 }
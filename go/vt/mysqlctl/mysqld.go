@@ -312,6 +312,107 @@ func (mysqld *Mysqld) RunMysqlUpgrade() error {
 	return err
 }
 
+// ApplyBinlogFile extracts a binary log file and applies it to MySQL. It is the equivalent of:
+// $ mysqlbinlog --include-gtids binlog.file | mysql
+// If restorePos is not empty, only the transactions of the binary log that
+// are in that position are applied. If restoreTime is not zero, the binary
+// log is applied up to, and excluding, the first event at or after that time.
+func (mysqld *Mysqld) ApplyBinlogFile(ctx context.Context, binlogFile string, restorePos mysql.Position, restoreTime time.Time) error {
+	// Execute as remote action on mysqlctld if requested.
+	if *socketFile != "" {
+		log.Infof("executing Mysqld.ApplyBinlogFile() remotely via mysqlctld server: %v", *socketFile)
+		client, err := mysqlctlclient.New("unix", *socketFile)
+		if err != nil {
+			return fmt.Errorf("can't dial mysqlctld: %v", err)
+		}
+		defer client.Close()
+		var restorePosStr string
+		if !restorePos.IsZero() {
+			restorePosStr = mysql.EncodePosition(restorePos)
+		}
+		return client.ApplyBinlogFile(ctx, binlogFile, restorePosStr, restoreTime)
+	}
+	var pipe io.ReadCloser
+	var mysqlbinlogCmd *exec.Cmd
+	var mysqlCmd *exec.Cmd
+
+	dir, err := vtenv.VtMysqlRoot()
+	if err != nil {
+		return err
+	}
+	env, err := buildLdPaths()
+	if err != nil {
+		return err
+	}
+	{
+		name, err := binaryPath(dir, "mysqlbinlog")
+		if err != nil {
+			return err
+		}
+		args := []string{}
+		if !restorePos.IsZero() {
+			args = append(args, "--include-gtids", restorePos.GTIDSet.String())
+		}
+		if !restoreTime.IsZero() {
+			// mysqlbinlog interprets --stop-datetime in the time zone of its
+			// environment, so we run it in UTC.
+			args = append(args, "--stop-datetime", restoreTime.UTC().Format("2006-01-02 15:04:05"))
+			env = append(env, "TZ=UTC")
+		}
+		args = append(args, binlogFile)
+
+		mysqlbinlogCmd = exec.Command(name, args...)
+		mysqlbinlogCmd.Dir = dir
+		mysqlbinlogCmd.Env = env
+		log.Infof("ApplyBinlogFile: running %#v", mysqlbinlogCmd)
+		pipe, err = mysqlbinlogCmd.StdoutPipe() // to be piped into mysql
+		if err != nil {
+			return err
+		}
+	}
+	{
+		name, err := binaryPath(dir, "mysql")
+		if err != nil {
+			return err
+		}
+		params, err := mysqld.dbcfgs.DbaConnector().MysqlParams()
+		if err != nil {
+			return err
+		}
+		cnf, err := mysqld.defaultsExtraFile(params)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(cnf)
+		args := []string{
+			"--defaults-extra-file=" + cnf,
+		}
+		mysqlCmd = exec.Command(name, args...)
+		mysqlCmd.Dir = dir
+		mysqlCmd.Env = env
+		mysqlCmd.Stdin = pipe // piped from mysqlbinlog
+	}
+	// Run both processes, piped:
+	if err := mysqlbinlogCmd.Start(); err != nil {
+		return err
+	}
+	if output, err := mysqlCmd.CombinedOutput(); err != nil {
+		// mysql is gone and won't read the rest of the pipe, so mysqlbinlog
+		// could block forever writing to it. Kill it and reap it.
+		if killErr := mysqlbinlogCmd.Process.Kill(); killErr != nil {
+			log.Warningf("ApplyBinlogFile: failed to kill %v: %v", mysqlbinlogCmd.Path, killErr)
+		}
+		_ = mysqlbinlogCmd.Wait()
+		return fmt.Errorf("%v: %v, output: %v", mysqlCmd.Path, err, string(output))
+	}
+	// Wait for mysqlbinlog to complete. mysql has already consumed all of
+	// its output, so it is done too.
+	if err := mysqlbinlogCmd.Wait(); err != nil {
+		return fmt.Errorf("%v: %v", mysqlbinlogCmd.Path, err)
+	}
+	return nil
+}
+
 // Start will start the mysql daemon, either by running the
 // 'mysqld_start' hook, or by running mysqld_safe in the background.
 // If a mysqlctld address is provided in a flag, Start will run
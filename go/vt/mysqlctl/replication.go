@@ -583,6 +583,46 @@ func (mysqld *Mysqld) GetGTIDMode(ctx context.Context) (string, error) {
 	return conn.GetGTIDMode()
 }
 
+// FlushBinaryLogs closes the current binary log and opens a new one.
+func (mysqld *Mysqld) FlushBinaryLogs(ctx context.Context) (err error) {
+	_, err = mysqld.FetchSuperQuery(ctx, "FLUSH BINARY LOGS")
+	return err
+}
+
+// GetBinaryLogs returns the names of the binary logs of the server, from the
+// oldest to the current one.
+func (mysqld *Mysqld) GetBinaryLogs(ctx context.Context) (binaryLogs []string, err error) {
+	qr, err := mysqld.FetchSuperQuery(ctx, "SHOW BINARY LOGS")
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range qr.Named().Rows {
+		binaryLogs = append(binaryLogs, row.AsString("Log_name", ""))
+	}
+	return binaryLogs, nil
+}
+
+// GetPreviousGTIDs returns the GTID set of all the transactions that were
+// executed before the given binary log, as logged in its Previous_gtids event.
+func (mysqld *Mysqld) GetPreviousGTIDs(ctx context.Context, binlog string) (previousGtids string, err error) {
+	query := fmt.Sprintf("SHOW BINLOG EVENTS IN '%s' LIMIT 2", binlog)
+	qr, err := mysqld.FetchSuperQuery(ctx, query)
+	if err != nil {
+		return previousGtids, err
+	}
+	previousGtidsFound := false
+	for _, row := range qr.Named().Rows {
+		if row.AsString("Event_type", "") == "Previous_gtids" {
+			previousGtids = row.AsString("Info", "")
+			previousGtidsFound = true
+		}
+	}
+	if !previousGtidsFound {
+		return previousGtids, fmt.Errorf("GetPreviousGTIDs: previous GTIDs not found in binary log %v", binlog)
+	}
+	return previousGtids, nil
+}
+
 // SetSemiSyncEnabled enables or disables semi-sync replication for
 // primary and/or replica mode.
 func (mysqld *Mysqld) SetSemiSyncEnabled(primary, replica bool) error {
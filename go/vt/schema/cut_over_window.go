@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cronTimeZonePrefix = "CRON_TZ="
+
+var (
+	cronMonthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	cronDayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// CutOverWindow is a cron-like specification of the times at which migrations may cut over.
+// It has the five fields of a crontab entry: minute, hour, day of month, month and day of week,
+// e.g. "* 1-4 * * mon-fri" for any minute between 01:00 and 04:59 on weekdays. Fields support
+// '*', values, ranges, steps and comma separated lists, and months and days of week may be
+// named. As in cron, a time matches when the day of month or the day of week match, if both are
+// restricted. Times are in UTC, unless the specification starts with a CRON_TZ=<zone> prefix.
+type CutOverWindow struct {
+	spec     string
+	location *time.Location
+
+	minutes    []bool
+	hours      []bool
+	daysOfMon  []bool
+	months     []bool
+	daysOfWeek []bool
+	// anyDayOfMonth and anyDayOfWeek are set when the field is '*'
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+// ParseCutOverWindow parses and validates a cut-over window specification
+func ParseCutOverWindow(spec string) (*CutOverWindow, error) {
+	w := &CutOverWindow{spec: spec, location: time.UTC}
+	fields := strings.Fields(spec)
+	if len(fields) > 0 && strings.HasPrefix(fields[0], cronTimeZonePrefix) {
+		location, err := time.LoadLocation(strings.TrimPrefix(fields[0], cronTimeZonePrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cut-over window %q: %v", spec, err)
+		}
+		w.location = location
+		fields = fields[1:]
+	}
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cut-over window %q: expected 5 fields, found %d", spec, len(fields))
+	}
+	var err error
+	if w.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid cut-over window %q: minute: %v", spec, err)
+	}
+	if w.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid cut-over window %q: hour: %v", spec, err)
+	}
+	if w.daysOfMon, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid cut-over window %q: day of month: %v", spec, err)
+	}
+	if w.months, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("invalid cut-over window %q: month: %v", spec, err)
+	}
+	// 7 is an alias for sunday
+	if w.daysOfWeek, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, fmt.Errorf("invalid cut-over window %q: day of week: %v", spec, err)
+	}
+	w.daysOfWeek[0] = w.daysOfWeek[0] || w.daysOfWeek[7]
+	w.anyDayOfMonth = fields[2] == "*"
+	w.anyDayOfWeek = fields[4] == "*"
+	return w, nil
+}
+
+// parseCronField parses a single crontab field, returning a bitmap of the matching values
+func parseCronField(field string, min, max int, names map[string]int) ([]bool, error) {
+	matches := make([]bool, max+1)
+	parseValue := func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", s)
+		}
+		if n < min || n > max {
+			return 0, fmt.Errorf("value %d out of range [%d, %d]", n, min, max)
+		}
+		return n, nil
+	}
+	for _, term := range strings.Split(field, ",") {
+		rangeSpec, step := term, 1
+		if i := strings.Index(term, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(term[i+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", term)
+			}
+			rangeSpec = term[:i]
+		}
+		from, to := min, max
+		switch {
+		case rangeSpec == "*":
+		case strings.Contains(rangeSpec, "-"):
+			bounds := strings.SplitN(rangeSpec, "-", 2)
+			var err error
+			if from, err = parseValue(bounds[0]); err != nil {
+				return nil, err
+			}
+			if to, err = parseValue(bounds[1]); err != nil {
+				return nil, err
+			}
+			if from > to {
+				return nil, fmt.Errorf("invalid range %q", rangeSpec)
+			}
+		default:
+			var err error
+			if from, err = parseValue(rangeSpec); err != nil {
+				return nil, err
+			}
+			if step == 1 {
+				to = from
+			}
+		}
+		for n := from; n <= to; n += step {
+			matches[n] = true
+		}
+	}
+	return matches, nil
+}
+
+// Contains returns true when the given time is within the window
+func (w *CutOverWindow) Contains(t time.Time) bool {
+	t = t.In(w.location)
+	if !w.minutes[t.Minute()] || !w.hours[t.Hour()] || !w.months[int(t.Month())] {
+		return false
+	}
+	dayOfMonth := w.daysOfMon[t.Day()]
+	dayOfWeek := w.daysOfWeek[int(t.Weekday())]
+	if w.anyDayOfMonth || w.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// String returns the specification of the window
+func (w *CutOverWindow) String() string {
+	return w.spec
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCutOverWindow(t *testing.T) {
+	// 2022-06-01 is a Wednesday
+	wednesday := func(hour, minute int) time.Time {
+		return time.Date(2022, time.June, 1, hour, minute, 0, 0, time.UTC)
+	}
+	saturday := func(hour, minute int) time.Time {
+		return time.Date(2022, time.June, 4, hour, minute, 0, 0, time.UTC)
+	}
+	tt := []struct {
+		spec    string
+		in      []time.Time
+		notIn   []time.Time
+		invalid bool
+	}{
+		{
+			spec: "* * * * *",
+			in:   []time.Time{wednesday(0, 0), saturday(23, 59)},
+		},
+		{
+			spec:  "* 1-4 * * *",
+			in:    []time.Time{wednesday(1, 0), wednesday(4, 59), saturday(3, 30)},
+			notIn: []time.Time{wednesday(0, 59), wednesday(5, 0)},
+		},
+		{
+			spec:  "*/15 3 * * mon-fri",
+			in:    []time.Time{wednesday(3, 0), wednesday(3, 45)},
+			notIn: []time.Time{wednesday(3, 10), saturday(3, 0)},
+		},
+		{
+			spec:  "0,30 22 * * 6,7",
+			in:    []time.Time{saturday(22, 30)},
+			notIn: []time.Time{saturday(22, 15), wednesday(22, 30)},
+		},
+		{
+			// day of month and day of week are OR'ed when both are restricted
+			spec:  "* * 1 * sat",
+			in:    []time.Time{wednesday(12, 0), saturday(12, 0)},
+			notIn: []time.Time{time.Date(2022, time.June, 2, 12, 0, 0, 0, time.UTC)},
+		},
+		{
+			spec:  "* * * jun-aug *",
+			in:    []time.Time{wednesday(12, 0)},
+			notIn: []time.Time{time.Date(2022, time.May, 31, 12, 0, 0, 0, time.UTC)},
+		},
+		{
+			// 02:00 in Berlin is 00:00 UTC in summer
+			spec:  "CRON_TZ=Europe/Berlin * 2 * * *",
+			in:    []time.Time{wednesday(0, 30)},
+			notIn: []time.Time{wednesday(2, 30)},
+		},
+		{spec: "", invalid: true},
+		{spec: "* * * *", invalid: true},
+		{spec: "60 * * * *", invalid: true},
+		{spec: "* 5-1 * * *", invalid: true},
+		{spec: "*/0 * * * *", invalid: true},
+		{spec: "* * 0 * *", invalid: true},
+		{spec: "* * * * funday", invalid: true},
+		{spec: "CRON_TZ=Nowhere/Land * * * * *", invalid: true},
+	}
+	for _, ts := range tt {
+		t.Run(ts.spec, func(t *testing.T) {
+			w, err := ParseCutOverWindow(ts.spec)
+			if ts.invalid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, tm := range ts.in {
+				assert.True(t, w.Contains(tm), "%v", tm)
+			}
+			for _, tm := range ts.notIn {
+				assert.False(t, w.Contains(tm), "%v", tm)
+			}
+		})
+	}
+}
@@ -19,6 +19,9 @@ package schema
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/shlex"
 )
@@ -39,6 +42,11 @@ const (
 	fastOverRevertibleFlag = "fast-over-revertible"
 	fastRangeRotationFlag  = "fast-range-rotation"
 	vreplicationTestSuite  = "vreplication-test-suite"
+	dryRunFlag             = "dry-run"
+
+	cutOverWindowFlag        = "cut-over-window"
+	cutOverMaxLagFlag        = "cut-over-max-lag"
+	cutOverMaxConcurrentFlag = "cut-over-max-concurrent"
 )
 
 // DDLStrategy suggests how an ALTER TABLE should run (e.g. "direct", "online", "gh-ost" or "pt-osc")
@@ -98,6 +106,18 @@ func ParseDDLStrategy(strategyVariable string) (*DDLStrategySetting, error) {
 	default:
 		return nil, fmt.Errorf("Unknown online DDL strategy: '%v'", strategy)
 	}
+	if _, err := setting.CutOverWindow(); err != nil {
+		return nil, err
+	}
+	if _, err := setting.CutOverMaxLag(); err != nil {
+		return nil, err
+	}
+	if _, err := setting.CutOverMaxConcurrent(); err != nil {
+		return nil, err
+	}
+	if setting.Strategy == DDLStrategyPTOSC && setting.HasCutOverPolicy() {
+		return nil, fmt.Errorf("cut-over policy is not supported by the %v strategy", DDLStrategyPTOSC)
+	}
 	return setting, nil
 }
 
@@ -112,6 +132,22 @@ func isFlag(s string, name string) bool {
 	return false
 }
 
+// flagValue returns the value of a CLI flag of the given name, given as -name=value or --name=value
+func flagValue(s string, name string) (string, bool) {
+	for _, prefix := range []string{fmt.Sprintf("-%s=", name), fmt.Sprintf("--%s=", name)} {
+		if strings.HasPrefix(s, prefix) {
+			return strings.TrimPrefix(s, prefix), true
+		}
+	}
+	return "", false
+}
+
+// isValueFlag returns true when the given string is a CLI flag of the given name, with a value
+func isValueFlag(s string, name string) bool {
+	_, ok := flagValue(s, name)
+	return ok
+}
+
 // hasFlag returns true when Options include named flag
 func (setting *DDLStrategySetting) hasFlag(name string) bool {
 	opts, _ := shlex.Split(setting.Options)
@@ -123,6 +159,17 @@ func (setting *DDLStrategySetting) hasFlag(name string) bool {
 	return false
 }
 
+// getFlagValue returns the value of the named flag in Options, or an empty string if the flag is missing
+func (setting *DDLStrategySetting) getFlagValue(name string) string {
+	opts, _ := shlex.Split(setting.Options)
+	for _, opt := range opts {
+		if value, ok := flagValue(opt, name); ok {
+			return value
+		}
+	}
+	return ""
+}
+
 // IsDeclarative checks if strategy options include -declarative
 func (setting *DDLStrategySetting) IsDeclarative() bool {
 	return setting.hasFlag(declarativeFlag)
@@ -173,6 +220,58 @@ func (setting *DDLStrategySetting) IsVreplicationTestSuite() bool {
 	return setting.hasFlag(vreplicationTestSuite)
 }
 
+// IsDryRun checks if strategy options include -dry-run. A dry run migration is not submitted; instead,
+// the tablets return an estimate of what running the migration would take.
+func (setting *DDLStrategySetting) IsDryRun() bool {
+	return setting.hasFlag(dryRunFlag)
+}
+
+// CutOverWindow returns the window given by --cut-over-window, outside of which the migration
+// does not cut over. It returns nil if the option is not set.
+func (setting *DDLStrategySetting) CutOverWindow() (*CutOverWindow, error) {
+	spec := setting.getFlagValue(cutOverWindowFlag)
+	if spec == "" {
+		return nil, nil
+	}
+	return ParseCutOverWindow(spec)
+}
+
+// CutOverMaxLag returns the replication lag given by --cut-over-max-lag, above which the
+// migration does not cut over. It returns zero if the option is not set.
+func (setting *DDLStrategySetting) CutOverMaxLag() (time.Duration, error) {
+	value := setting.getFlagValue(cutOverMaxLagFlag)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid value for --%s: %q", cutOverMaxLagFlag, value)
+	}
+	return d, nil
+}
+
+// CutOverMaxConcurrent returns the number given by --cut-over-max-concurrent, which is the
+// maximum number of migrations that cut over at once. It returns zero if the option is not set.
+func (setting *DDLStrategySetting) CutOverMaxConcurrent() (int, error) {
+	value := setting.getFlagValue(cutOverMaxConcurrentFlag)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid value for --%s: %q", cutOverMaxConcurrentFlag, value)
+	}
+	return n, nil
+}
+
+// HasCutOverPolicy returns true when strategy options include any of the cut-over policy flags:
+// --cut-over-window, --cut-over-max-lag or --cut-over-max-concurrent
+func (setting *DDLStrategySetting) HasCutOverPolicy() bool {
+	return setting.getFlagValue(cutOverWindowFlag) != "" ||
+		setting.getFlagValue(cutOverMaxLagFlag) != "" ||
+		setting.getFlagValue(cutOverMaxConcurrentFlag) != ""
+}
+
 // IsSkipTopoFlag returns 'true' if strategy options include `-skip-topo`. This flag is deprecated,
 // and this function is temporary in v14 so that we can print a deprecation message.
 func (setting *DDLStrategySetting) IsSkipTopoFlag() bool {
@@ -196,6 +295,10 @@ func (setting *DDLStrategySetting) RuntimeOptions() []string {
 		case isFlag(opt, fastOverRevertibleFlag):
 		case isFlag(opt, fastRangeRotationFlag):
 		case isFlag(opt, vreplicationTestSuite):
+		case isFlag(opt, dryRunFlag):
+		case isValueFlag(opt, cutOverWindowFlag):
+		case isValueFlag(opt, cutOverMaxLagFlag):
+		case isValueFlag(opt, cutOverMaxConcurrentFlag):
 		default:
 			validOpts = append(validOpts, opt)
 		}
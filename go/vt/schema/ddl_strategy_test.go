@@ -19,8 +19,10 @@ package schema
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsDirect(t *testing.T) {
@@ -49,6 +51,7 @@ func TestParseDDLStrategy(t *testing.T) {
 		isAllowConcurrent    bool
 		fastOverRevertible   bool
 		fastRangeRotation    bool
+		dryRun               bool
 		runtimeOptions       string
 		err                  error
 	}{
@@ -145,6 +148,14 @@ func TestParseDDLStrategy(t *testing.T) {
 			runtimeOptions:    "",
 			fastRangeRotation: true,
 		},
+		{
+			strategyVariable:  "vitess --dry-run --allow-concurrent",
+			strategy:          DDLStrategyVitess,
+			options:           "--dry-run --allow-concurrent",
+			runtimeOptions:    "",
+			isAllowConcurrent: true,
+			dryRun:            true,
+		},
 	}
 	for _, ts := range tt {
 		setting, err := ParseDDLStrategy(ts.strategyVariable)
@@ -158,6 +169,7 @@ func TestParseDDLStrategy(t *testing.T) {
 		assert.Equal(t, ts.isAllowConcurrent, setting.IsAllowConcurrent())
 		assert.Equal(t, ts.fastOverRevertible, setting.IsFastOverRevertibleFlag())
 		assert.Equal(t, ts.fastRangeRotation, setting.IsFastRangeRotationFlag())
+		assert.Equal(t, ts.dryRun, setting.IsDryRun())
 
 		runtimeOptions := strings.Join(setting.RuntimeOptions(), " ")
 		assert.Equal(t, ts.runtimeOptions, runtimeOptions)
@@ -167,3 +179,40 @@ func TestParseDDLStrategy(t *testing.T) {
 		assert.Error(t, err)
 	}
 }
+
+func TestCutOverPolicy(t *testing.T) {
+	{
+		setting, err := ParseDDLStrategy(`vitess --cut-over-window="* 1-4 * * mon-fri" --cut-over-max-lag=10s --cut-over-max-concurrent=2 --allow-concurrent`)
+		require.NoError(t, err)
+		assert.True(t, setting.HasCutOverPolicy())
+		window, err := setting.CutOverWindow()
+		require.NoError(t, err)
+		assert.Equal(t, "* 1-4 * * mon-fri", window.String())
+		maxLag, err := setting.CutOverMaxLag()
+		require.NoError(t, err)
+		assert.Equal(t, 10*time.Second, maxLag)
+		maxConcurrent, err := setting.CutOverMaxConcurrent()
+		require.NoError(t, err)
+		assert.Equal(t, 2, maxConcurrent)
+		assert.Empty(t, setting.RuntimeOptions())
+		assert.True(t, setting.IsAllowConcurrent())
+	}
+	{
+		setting, err := ParseDDLStrategy("gh-ost --max-load=Threads_running=100")
+		require.NoError(t, err)
+		assert.False(t, setting.HasCutOverPolicy())
+		window, err := setting.CutOverWindow()
+		require.NoError(t, err)
+		assert.Nil(t, window)
+	}
+	for _, strategy := range []string{
+		`vitess --cut-over-window="* 1-4 * *"`,
+		`vitess --cut-over-window="* 25 * * *"`,
+		"vitess --cut-over-max-lag=10",
+		"vitess --cut-over-max-concurrent=0",
+		"pt-osc --cut-over-max-lag=10s",
+	} {
+		_, err := ParseDDLStrategy(strategy)
+		assert.Error(t, err, strategy)
+	}
+}
@@ -25,13 +25,16 @@ import (
 	"vitess.io/vitess/go/textutil"
 )
 
-// TableGCState provides a state for the type of GC table: HOLD? PURGE? EVAC? DROP? See details below
+// TableGCState provides a state for the type of GC table: HOLD? ARCHIVE? PURGE? EVAC? DROP? See details below
 type TableGCState string
 
 const (
 	// HoldTableGCState is the state where table was just renamed away. Data is still in tact,
 	// and the user has the option to rename it back. A "safety" period.
 	HoldTableGCState TableGCState = "HOLD"
+	// ArchiveTableGCState is the state where table data is copied to the backup storage, so that
+	// it can be restored after the table is dropped. This state is optional.
+	ArchiveTableGCState TableGCState = "ARCHIVE"
 	// PurgeTableGCState is the state where we purge table data. Table in this state is "lost" to the user.
 	// if in this state, the table will be fully purged.
 	PurgeTableGCState TableGCState = "PURGE"
@@ -46,13 +49,14 @@ const (
 
 var (
 	gcUUIDRegexp      = regexp.MustCompile(`^[0-f]{32}$`)
-	gcTableNameRegexp = regexp.MustCompile(`^_vt_(HOLD|PURGE|EVAC|DROP)_([0-f]{32})_([0-9]{14})$`)
+	gcTableNameRegexp = regexp.MustCompile(`^_vt_(HOLD|ARCHIVE|PURGE|EVAC|DROP)_([0-f]{32})_([0-9]{14})$`)
 
 	gcStates = map[string]TableGCState{
-		string(HoldTableGCState):  HoldTableGCState,
-		string(PurgeTableGCState): PurgeTableGCState,
-		string(EvacTableGCState):  EvacTableGCState,
-		string(DropTableGCState):  DropTableGCState,
+		string(HoldTableGCState):    HoldTableGCState,
+		string(ArchiveTableGCState): ArchiveTableGCState,
+		string(PurgeTableGCState):   PurgeTableGCState,
+		string(EvacTableGCState):    EvacTableGCState,
+		string(DropTableGCState):    DropTableGCState,
 	}
 )
 
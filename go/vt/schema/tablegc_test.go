@@ -26,7 +26,7 @@ import (
 
 func TestIsGCTableName(t *testing.T) {
 	tm := time.Now()
-	states := []TableGCState{HoldTableGCState, PurgeTableGCState, EvacTableGCState, DropTableGCState}
+	states := []TableGCState{HoldTableGCState, ArchiveTableGCState, PurgeTableGCState, EvacTableGCState, DropTableGCState}
 	for _, state := range states {
 		for i := 0; i < 10; i++ {
 			tableName, err := generateGCTableName(state, "", tm)
@@ -76,6 +76,11 @@ func TestAnalyzeGCTableName(t *testing.T) {
 			state:     PurgeTableGCState,
 			t:         baseTime,
 		},
+		{
+			tableName: "_vt_ARCHIVE_6ace8bcef73211ea87e9f875a4d24e90_20200915120410",
+			state:     ArchiveTableGCState,
+			t:         baseTime,
+		},
 	}
 	for _, ts := range tt {
 		isGC, state, uuid, tm, err := AnalyzeGCTableName(ts.tableName)
@@ -136,6 +141,16 @@ func TestParseGCLifecycle(t *testing.T) {
 				DropTableGCState:  true,
 			},
 		},
+		{
+			lifecycle: "hold,archive,purge,evac,drop",
+			states: map[TableGCState]bool{
+				HoldTableGCState:    true,
+				ArchiveTableGCState: true,
+				PurgeTableGCState:   true,
+				EvacTableGCState:    true,
+				DropTableGCState:    true,
+			},
+		},
 		{
 			lifecycle: "hold, other, drop",
 			expectErr: true,
@@ -1861,6 +1861,21 @@ func (c *CreateTableEntity) Apply(diff EntityDiff) (Entity, error) {
 	return dup, nil
 }
 
+// ApplyAlterTable attempts to apply given ALTER TABLE statement onto the table defined by this entity.
+// This entity is unmodified. If successful, a new CREATE TABLE entity is returned.
+// The supported modifications are those supported by Apply().
+func (c *CreateTableEntity) ApplyAlterTable(alterTable *sqlparser.AlterTable) (*CreateTableEntity, error) {
+	diff := &AlterTableEntityDiff{
+		from:       c,
+		alterTable: sqlparser.CloneRefOfAlterTable(alterTable),
+	}
+	applied, err := c.Apply(diff)
+	if err != nil {
+		return nil, err
+	}
+	return applied.(*CreateTableEntity), nil
+}
+
 // postApplyNormalize runs at the end of apply() and to reorganize/edit things that
 // a MySQL will do implicitly:
 //   - edit or remove keys if referenced columns are dropped
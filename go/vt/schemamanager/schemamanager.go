@@ -25,6 +25,7 @@ import (
 
 	"vitess.io/vitess/go/vt/log"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
 )
 
 const (
@@ -75,6 +76,8 @@ type ExecuteResult struct {
 	UUIDs          []string
 	ExecutorErr    string
 	TotalTimeSpent time.Duration
+	// MigrationEstimates are the per shard estimates of dry run online DDL migrations
+	MigrationEstimates []*vtctldatapb.MigrationEstimate
 }
 
 // ShardWithError contains information why a shard failed to execute given sql
@@ -18,10 +18,14 @@ package schemamanager
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"vitess.io/vitess/go/protoutil"
+	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/sync2"
 	"vitess.io/vitess/go/timer"
 	"vitess.io/vitess/go/vt/logutil"
@@ -35,6 +39,8 @@ import (
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	vttimepb "vitess.io/vitess/go/vt/proto/vttime"
 )
 
 // TabletExecutor applies schema changes to all tablets.
@@ -51,6 +57,7 @@ type TabletExecutor struct {
 	ddlStrategySetting   *schema.DDLStrategySetting
 	uuids                []string
 	skipPreflight        bool
+	dryRun               bool
 }
 
 // NewTabletExecutor creates a new TabletExecutor instance
@@ -114,6 +121,11 @@ func (exec *TabletExecutor) SkipPreflight() {
 	exec.skipPreflight = true
 }
 
+// DryRun makes the executor estimate the cost of online DDL migrations instead of submitting them
+func (exec *TabletExecutor) DryRun() {
+	exec.dryRun = true
+}
+
 // Open opens a connection to the primary for every shard.
 func (exec *TabletExecutor) Open(ctx context.Context, keyspace string) error {
 	if !exec.isClosed {
@@ -284,6 +296,9 @@ func (exec *TabletExecutor) executeSQL(ctx context.Context, sql string, provided
 	if err != nil {
 		return false, err
 	}
+	if exec.dryRun {
+		return exec.estimateSQL(ctx, sql, stmt, execResult)
+	}
 	switch stmt := stmt.(type) {
 	case sqlparser.DDLStatement:
 		if exec.isOnlineSchemaDDL(stmt) {
@@ -320,6 +335,74 @@ func (exec *TabletExecutor) executeSQL(ctx context.Context, sql string, provided
 	return false, nil
 }
 
+// estimateSQL submits a single DDL statement as a dry run online DDL migration, and collects the
+// migration estimates returned by the tablets. Nothing is executed on the tablets.
+func (exec *TabletExecutor) estimateSQL(ctx context.Context, sql string, stmt sqlparser.Statement, execResult *ExecuteResult) (executedAsynchronously bool, err error) {
+	ddlStmt, ok := stmt.(sqlparser.DDLStatement)
+	if !ok || !exec.isOnlineSchemaDDL(ddlStmt) {
+		err := fmt.Errorf("dry run is only supported for CREATE, ALTER and DROP statements with an online DDL strategy: %s", sql)
+		execResult.ExecutorErr = err.Error()
+		return false, err
+	}
+	options := exec.ddlStrategySetting.Options
+	if !exec.ddlStrategySetting.IsDryRun() {
+		options = strings.TrimSpace(options + " --dry-run")
+	}
+	strategySetting := schema.NewDDLStrategySetting(exec.ddlStrategySetting.Strategy, options)
+	onlineDDLs, err := schema.NewOnlineDDLs(exec.keyspace, sql, ddlStmt, strategySetting, exec.migrationContext, "")
+	if err != nil {
+		execResult.ExecutorErr = err.Error()
+		return false, err
+	}
+	for _, onlineDDL := range onlineDDLs {
+		exec.executeOnAllTablets(ctx, execResult, onlineDDL.SQL, true)
+		for _, shardResult := range execResult.SuccessShards {
+			estimate, err := migrationEstimateFromResult(onlineDDL.SQL, shardResult.Result)
+			if err != nil {
+				execResult.ExecutorErr = err.Error()
+				return false, err
+			}
+			if estimate.Shard == "" {
+				estimate.Shard = shardResult.Shard
+			}
+			execResult.MigrationEstimates = append(execResult.MigrationEstimates, estimate)
+		}
+	}
+	return true, nil
+}
+
+// migrationEstimateFromResult reads the migration estimate a tablet returns for a dry run migration
+func migrationEstimateFromResult(sql string, result *querypb.QueryResult) (*vtctldatapb.MigrationEstimate, error) {
+	row := sqltypes.Proto3ToResult(result).Named().Row()
+	if row == nil {
+		return nil, fmt.Errorf("expected a single migration estimate row for %s", sql)
+	}
+	seconds := func(column string) *vttimepb.Duration {
+		return protoutil.DurationToProto(time.Duration(row.AsFloat64(column, 0) * float64(time.Second)))
+	}
+	estimate := &vtctldatapb.MigrationEstimate{
+		Keyspace:             row.AsString("keyspace", ""),
+		Shard:                row.AsString("shard", ""),
+		Table:                row.AsString("table", ""),
+		Sql:                  sql,
+		InstantDdl:           row.AsBool("instant_ddl", false),
+		TableRows:            row.AsUint64("table_rows", 0),
+		TableSize:            row.AsUint64("table_size", 0),
+		CopyRowsPerSecond:    uint64(row.AsFloat64("copy_rows_per_second", 0)),
+		CopyTime:             seconds("copy_seconds"),
+		BinlogBytesPerSecond: uint64(row.AsFloat64("binlog_bytes_per_second", 0)),
+		CatchUpTime:          seconds("catch_up_seconds"),
+		ExtraDiskBytes:       row.AsUint64("extra_disk_bytes", 0),
+		Throttled:            row.AsBool("throttled", false),
+	}
+	if warnings := row.AsString("warnings", ""); warnings != "" {
+		if err := json.Unmarshal([]byte(warnings), &estimate.Warnings); err != nil {
+			return nil, err
+		}
+	}
+	return estimate, nil
+}
+
 // Execute applies schema changes
 func (exec *TabletExecutor) Execute(ctx context.Context, sqls []string) *ExecuteResult {
 	execResult := ExecuteResult{}
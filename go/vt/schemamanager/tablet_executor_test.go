@@ -23,7 +23,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"vitess.io/vitess/go/sqltypes"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	"vitess.io/vitess/go/vt/topo/memorytopo"
 
@@ -301,3 +303,29 @@ func TestIsOnlineSchemaDDL(t *testing.T) {
 		}
 	}
 }
+
+func TestMigrationEstimateFromResult(t *testing.T) {
+	result := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"keyspace|shard|table|instant_ddl|table_rows|table_size|copy_rows_per_second|copy_seconds|binlog_bytes_per_second|catch_up_seconds|extra_disk_bytes|throttled|warnings",
+			"varchar|varchar|varchar|int64|int64|int64|float64|float64|float64|float64|int64|int64|varchar",
+		),
+		`ks|-80|t|0|1000000|314572800|10000|100|4194304|33.5|524288000|1|["throttled"]`,
+	)
+	estimate, err := migrationEstimateFromResult("alter table t add column i int", sqltypes.ResultToProto3(result))
+	require.NoError(t, err)
+	assert.Equal(t, "-80", estimate.Shard)
+	assert.Equal(t, "alter table t add column i int", estimate.Sql)
+	assert.False(t, estimate.InstantDdl)
+	assert.Equal(t, uint64(1000000), estimate.TableRows)
+	assert.Equal(t, uint64(10000), estimate.CopyRowsPerSecond)
+	assert.Equal(t, int64(100), estimate.CopyTime.Seconds)
+	assert.Equal(t, int64(33), estimate.CatchUpTime.Seconds)
+	assert.Equal(t, int32(500000000), estimate.CatchUpTime.Nanos)
+	assert.Equal(t, uint64(524288000), estimate.ExtraDiskBytes)
+	assert.True(t, estimate.Throttled)
+	assert.Equal(t, []string{"throttled"}, estimate.Warnings)
+
+	_, err = migrationEstimateFromResult("alter table t add column i int", sqltypes.ResultToProto3(&sqltypes.Result{}))
+	assert.Error(t, err)
+}
@@ -717,6 +717,7 @@ func CloneRefOfAvg(n *Avg) *Avg {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -759,6 +760,7 @@ func CloneRefOfBitAnd(n *BitAnd) *BitAnd {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -769,6 +771,7 @@ func CloneRefOfBitOr(n *BitOr) *BitOr {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -779,6 +782,7 @@ func CloneRefOfBitXor(n *BitXor) *BitXor {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -994,6 +998,7 @@ func CloneRefOfCount(n *Count) *Count {
 	}
 	out := *n
 	out.Args = CloneExprs(n.Args)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -1003,6 +1008,7 @@ func CloneRefOfCountStar(n *CountStar) *CountStar {
 		return nil
 	}
 	out := *n
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -1897,6 +1903,7 @@ func CloneRefOfMax(n *Max) *Max {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -1918,6 +1925,7 @@ func CloneRefOfMin(n *Min) *Min {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2586,6 +2594,7 @@ func CloneRefOfStd(n *Std) *Std {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2596,6 +2605,7 @@ func CloneRefOfStdDev(n *StdDev) *StdDev {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2606,6 +2616,7 @@ func CloneRefOfStdPop(n *StdPop) *StdPop {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2616,6 +2627,7 @@ func CloneRefOfStdSamp(n *StdSamp) *StdSamp {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2709,6 +2721,7 @@ func CloneRefOfSum(n *Sum) *Sum {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2967,6 +2980,7 @@ func CloneRefOfVarPop(n *VarPop) *VarPop {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2977,6 +2991,7 @@ func CloneRefOfVarSamp(n *VarSamp) *VarSamp {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2997,6 +3012,7 @@ func CloneRefOfVariance(n *Variance) *Variance {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
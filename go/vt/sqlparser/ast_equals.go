@@ -1737,7 +1737,8 @@ func EqualsRefOfAvg(a, b *Avg) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		EqualsExpr(a.Arg, b.Arg)
+		EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfBegin does deep equals between the two objects.
@@ -1786,7 +1787,8 @@ func EqualsRefOfBitAnd(a, b *BitAnd) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfBitOr does deep equals between the two objects.
@@ -1797,7 +1799,8 @@ func EqualsRefOfBitOr(a, b *BitOr) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfBitXor does deep equals between the two objects.
@@ -1808,7 +1811,8 @@ func EqualsRefOfBitXor(a, b *BitXor) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfCallProc does deep equals between the two objects.
@@ -2062,7 +2066,8 @@ func EqualsRefOfCount(a, b *Count) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		EqualsExprs(a.Args, b.Args)
+		EqualsExprs(a.Args, b.Args) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfCountStar does deep equals between the two objects.
@@ -2073,7 +2078,7 @@ func EqualsRefOfCountStar(a, b *CountStar) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return true
+	return EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfCreateDatabase does deep equals between the two objects.
@@ -3112,7 +3117,8 @@ func EqualsRefOfMax(a, b *Max) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		EqualsExpr(a.Arg, b.Arg)
+		EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfMemberOfExpr does deep equals between the two objects.
@@ -3136,7 +3142,8 @@ func EqualsRefOfMin(a, b *Min) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		EqualsExpr(a.Arg, b.Arg)
+		EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfModifyColumn does deep equals between the two objects.
@@ -3907,7 +3914,8 @@ func EqualsRefOfStd(a, b *Std) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfStdDev does deep equals between the two objects.
@@ -3918,7 +3926,8 @@ func EqualsRefOfStdDev(a, b *StdDev) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfStdPop does deep equals between the two objects.
@@ -3929,7 +3938,8 @@ func EqualsRefOfStdPop(a, b *StdPop) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfStdSamp does deep equals between the two objects.
@@ -3940,7 +3950,8 @@ func EqualsRefOfStdSamp(a, b *StdSamp) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfStream does deep equals between the two objects.
@@ -4047,7 +4058,8 @@ func EqualsRefOfSum(a, b *Sum) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		EqualsExpr(a.Arg, b.Arg)
+		EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsTableExprs does deep equals between the two objects.
@@ -4339,7 +4351,8 @@ func EqualsRefOfVarPop(a, b *VarPop) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfVarSamp does deep equals between the two objects.
@@ -4350,7 +4363,8 @@ func EqualsRefOfVarSamp(a, b *VarSamp) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsRefOfVariable does deep equals between the two objects.
@@ -4373,7 +4387,8 @@ func EqualsRefOfVariance(a, b *Variance) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return EqualsExpr(a.Arg, b.Arg)
+	return EqualsExpr(a.Arg, b.Arg) &&
+		EqualsRefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // EqualsVindexParam does deep equals between the two objects.
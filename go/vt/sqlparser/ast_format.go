@@ -2653,11 +2653,17 @@ func (node *Count) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Args)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *CountStar) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.WriteString("*)")
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *Avg) Format(buf *TrackedBuffer) {
@@ -2666,6 +2672,9 @@ func (node *Avg) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *Max) Format(buf *TrackedBuffer) {
@@ -2674,6 +2683,9 @@ func (node *Max) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *Min) Format(buf *TrackedBuffer) {
@@ -2682,6 +2694,9 @@ func (node *Min) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *Sum) Format(buf *TrackedBuffer) {
@@ -2690,56 +2705,89 @@ func (node *Sum) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *BitAnd) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *BitOr) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *BitXor) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *Std) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *StdDev) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *StdPop) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *StdSamp) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *VarPop) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *VarSamp) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *Variance) Format(buf *TrackedBuffer) {
 	buf.astPrintf(node, "%s(", node.AggrName())
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 // Format formats the node.
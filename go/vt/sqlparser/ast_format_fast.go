@@ -3479,12 +3479,20 @@ func (node *Count) formatFast(buf *TrackedBuffer) {
 	}
 	node.Args.formatFast(buf)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *CountStar) formatFast(buf *TrackedBuffer) {
 	buf.WriteString(node.AggrName())
 	buf.WriteByte('(')
 	buf.WriteString("*)")
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *Avg) formatFast(buf *TrackedBuffer) {
@@ -3495,6 +3503,10 @@ func (node *Avg) formatFast(buf *TrackedBuffer) {
 	}
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *Max) formatFast(buf *TrackedBuffer) {
@@ -3505,6 +3517,10 @@ func (node *Max) formatFast(buf *TrackedBuffer) {
 	}
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *Min) formatFast(buf *TrackedBuffer) {
@@ -3515,6 +3531,10 @@ func (node *Min) formatFast(buf *TrackedBuffer) {
 	}
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *Sum) formatFast(buf *TrackedBuffer) {
@@ -3525,6 +3545,10 @@ func (node *Sum) formatFast(buf *TrackedBuffer) {
 	}
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *BitAnd) formatFast(buf *TrackedBuffer) {
@@ -3532,6 +3556,10 @@ func (node *BitAnd) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *BitOr) formatFast(buf *TrackedBuffer) {
@@ -3539,6 +3567,10 @@ func (node *BitOr) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *BitXor) formatFast(buf *TrackedBuffer) {
@@ -3546,6 +3578,10 @@ func (node *BitXor) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *Std) formatFast(buf *TrackedBuffer) {
@@ -3553,6 +3589,10 @@ func (node *Std) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *StdDev) formatFast(buf *TrackedBuffer) {
@@ -3560,6 +3600,10 @@ func (node *StdDev) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *StdPop) formatFast(buf *TrackedBuffer) {
@@ -3567,6 +3611,10 @@ func (node *StdPop) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *StdSamp) formatFast(buf *TrackedBuffer) {
@@ -3574,6 +3622,10 @@ func (node *StdSamp) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *VarPop) formatFast(buf *TrackedBuffer) {
@@ -3581,6 +3633,10 @@ func (node *VarPop) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *VarSamp) formatFast(buf *TrackedBuffer) {
@@ -3588,6 +3644,10 @@ func (node *VarSamp) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *Variance) formatFast(buf *TrackedBuffer) {
@@ -3595,6 +3655,10 @@ func (node *Variance) formatFast(buf *TrackedBuffer) {
 	buf.WriteByte('(')
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 // formatFast formats the node.
@@ -1932,7 +1932,7 @@ func formatAddress(address string) string {
 func ContainsAggregation(e SQLNode) bool {
 	hasAggregates := false
 	_ = Walk(func(node SQLNode) (kontinue bool, err error) {
-		if _, isAggregate := node.(AggrFunc); isAggregate {
+		if IsAggregation(node) {
 			hasAggregates = true
 			return false, nil
 		}
@@ -1941,6 +1941,76 @@ func ContainsAggregation(e SQLNode) bool {
 	return hasAggregates
 }
 
+// IsAggregation returns true if the node is an aggregate function that is not
+// used as a window function
+func IsAggregation(node SQLNode) bool {
+	aggr, isAggregate := node.(AggrFunc)
+	return isAggregate && GetOverClause(aggr) == nil
+}
+
+// GetOverClause returns the OVER clause of a window function,
+// or nil if the expression is not a window function
+func GetOverClause(e Expr) *OverClause {
+	switch node := e.(type) {
+	case *ArgumentLessWindowExpr:
+		return node.OverClause
+	case *FirstOrLastValueExpr:
+		return node.OverClause
+	case *NtileExpr:
+		return node.OverClause
+	case *NTHValueExpr:
+		return node.OverClause
+	case *LagLeadExpr:
+		return node.OverClause
+	case *Count:
+		return node.OverClause
+	case *CountStar:
+		return node.OverClause
+	case *Avg:
+		return node.OverClause
+	case *Max:
+		return node.OverClause
+	case *Min:
+		return node.OverClause
+	case *Sum:
+		return node.OverClause
+	case *BitAnd:
+		return node.OverClause
+	case *BitOr:
+		return node.OverClause
+	case *BitXor:
+		return node.OverClause
+	case *Std:
+		return node.OverClause
+	case *StdDev:
+		return node.OverClause
+	case *StdPop:
+		return node.OverClause
+	case *StdSamp:
+		return node.OverClause
+	case *VarPop:
+		return node.OverClause
+	case *VarSamp:
+		return node.OverClause
+	case *Variance:
+		return node.OverClause
+	}
+	return nil
+}
+
+// ContainsWindowFunction returns true if the expression contains a window function
+func ContainsWindowFunction(e SQLNode) bool {
+	hasWindowFunc := false
+	_ = Walk(func(node SQLNode) (kontinue bool, err error) {
+		if expr, isExpr := node.(Expr); isExpr && GetOverClause(expr) != nil {
+			hasWindowFunc = true
+			return false, nil
+		}
+		return true, nil
+	}, e)
+	return hasWindowFunc
+}
+
 // GetFirstSelect gets the first select statement
 func GetFirstSelect(selStmt SelectStatement) *Select {
 	if selStmt == nil {
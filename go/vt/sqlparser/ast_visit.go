@@ -794,6 +794,9 @@ func VisitRefOfAvg(in *Avg, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfBegin(in *Begin, f Visit) error {
@@ -848,6 +851,9 @@ func VisitRefOfBitAnd(in *BitAnd, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfBitOr(in *BitOr, f Visit) error {
@@ -860,6 +866,9 @@ func VisitRefOfBitOr(in *BitOr, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfBitXor(in *BitXor, f Visit) error {
@@ -872,6 +881,9 @@ func VisitRefOfBitXor(in *BitXor, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfCallProc(in *CallProc, f Visit) error {
@@ -1155,6 +1167,9 @@ func VisitRefOfCount(in *Count, f Visit) error {
 	if err := VisitExprs(in.Args, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfCountStar(in *CountStar, f Visit) error {
@@ -1164,6 +1179,9 @@ func VisitRefOfCountStar(in *CountStar, f Visit) error {
 	if cont, err := f(in); err != nil || !cont {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfCreateDatabase(in *CreateDatabase, f Visit) error {
@@ -2387,6 +2405,9 @@ func VisitRefOfMax(in *Max, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfMemberOfExpr(in *MemberOfExpr, f Visit) error {
@@ -2414,6 +2435,9 @@ func VisitRefOfMin(in *Min, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfModifyColumn(in *ModifyColumn, f Visit) error {
@@ -3304,6 +3328,9 @@ func VisitRefOfStd(in *Std, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfStdDev(in *StdDev, f Visit) error {
@@ -3316,6 +3343,9 @@ func VisitRefOfStdDev(in *StdDev, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfStdPop(in *StdPop, f Visit) error {
@@ -3328,6 +3358,9 @@ func VisitRefOfStdPop(in *StdPop, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfStdSamp(in *StdSamp, f Visit) error {
@@ -3340,6 +3373,9 @@ func VisitRefOfStdSamp(in *StdSamp, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfStream(in *Stream, f Visit) error {
@@ -3465,6 +3501,9 @@ func VisitRefOfSum(in *Sum, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitTableExprs(in TableExprs, f Visit) error {
@@ -3812,6 +3851,9 @@ func VisitRefOfVarPop(in *VarPop, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfVarSamp(in *VarSamp, f Visit) error {
@@ -3824,6 +3866,9 @@ func VisitRefOfVarSamp(in *VarSamp, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfVariable(in *Variable, f Visit) error {
@@ -3848,6 +3893,9 @@ func VisitRefOfVariance(in *Variance, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitVindexParam(in VindexParam, f Visit) error {
@@ -19,6 +19,7 @@ package sqlparser
 import (
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -44,6 +45,10 @@ const (
 	DirectiveQueryPlanner = "PLANNER"
 	// DirectiveVtexplainRunDMLQueries tells explain format = vtexplain that it is okay to also run the query.
 	DirectiveVtexplainRunDMLQueries = "EXECUTE_DML_QUERIES"
+	// DirectiveResultCacheTTL lets vtgate cache the result of a SELECT for the given duration, e.g. 5s.
+	DirectiveResultCacheTTL = "RESULT_CACHE_TTL"
+	// DirectiveConsumerGroup selects the consumer group a STREAM of a message table receives messages for.
+	DirectiveConsumerGroup = "CONSUMER_GROUP"
 )
 
 func isNonSpace(r rune) bool {
@@ -369,3 +374,21 @@ func AllowScatterDirective(stmt Statement) bool {
 	}
 	return comments != nil && comments.Directives().IsSet(DirectiveAllowScatter)
 }
+
+// ResultCacheTTLDirective returns the duration for which the result of a
+// SELECT can be cached by vtgate, or 0 if the directive is not set or invalid.
+func ResultCacheTTLDirective(stmt Statement) time.Duration {
+	sel, ok := stmt.(*Select)
+	if !ok || sel.Comments == nil {
+		return 0
+	}
+	val, ok := sel.Comments.Directives().GetString(DirectiveResultCacheTTL, "")
+	if !ok {
+		return 0
+	}
+	ttl, err := time.ParseDuration(val)
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
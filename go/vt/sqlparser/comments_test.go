@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -468,3 +469,25 @@ func TestIgnoreMaxMaxMemoryRowsDirective(t *testing.T) {
 		})
 	}
 }
+
+func TestResultCacheTTLDirective(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected time.Duration
+	}{
+		{"select /*vt+ RESULT_CACHE_TTL=5s */ * from users", 5 * time.Second},
+		{"select /*vt+ RESULT_CACHE_TTL=1m30s */ * from users", 90 * time.Second},
+		{"select * from users", 0},
+		{"select /*vt+ RESULT_CACHE_TTL=5 */ * from users", 0},
+		{"select /*vt+ RESULT_CACHE_TTL=-5s */ * from users", 0},
+		{"update /*vt+ RESULT_CACHE_TTL=5s */ users set name=1", 0},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.query, func(t *testing.T) {
+			stmt, err := Parse(test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, ResultCacheTTLDirective(stmt))
+		})
+	}
+}
@@ -3117,6 +3117,15 @@ var (
 	}, {
 		input:  "SELECT LAG(val, 10) OVER w, LEAD('val', null) OVER w, LEAD(val, 1, ASCII(1)) OVER w FROM numbers",
 		output: "select lag(val, 10) over w, lead('val', null) over w, lead(val, 1, ASCII(1)) over w from numbers",
+	}, {
+		input:  "SELECT val, SUM(val) OVER (PARTITION BY subject ORDER BY val), COUNT(*) OVER (), COUNT(val) OVER w FROM numbers",
+		output: "select val, sum(val) over ( partition by subject order by val asc), count(*) over (), count(val) over w from numbers",
+	}, {
+		input:  "SELECT AVG(val) OVER w, MIN(val) OVER w, MAX(val) OVER w, BIT_AND(val) OVER w, BIT_OR(val) OVER w, BIT_XOR(val) OVER w FROM numbers",
+		output: "select avg(val) over w, min(val) over w, max(val) over w, bit_and(val) over w, bit_or(val) over w, bit_xor(val) over w from numbers",
+	}, {
+		input:  "SELECT STD(val) OVER w, STDDEV(val) OVER w, STDDEV_POP(val) OVER w, STDDEV_SAMP(val) OVER w, VAR_POP(val) OVER w, VAR_SAMP(val) OVER w, VARIANCE(val) OVER w FROM numbers",
+		output: "select std(val) over w, stddev(val) over w, stddev_pop(val) over w, stddev_samp(val) over w, var_pop(val) over w, var_samp(val) over w, variance(val) over w from numbers",
 	}, {
 		input:  "SELECT val, ROW_NUMBER() OVER (ORDER BY val) AS 'row_number' FROM numbers WINDOW w AS (ORDER BY val);",
 		output: "select val, row_number() over ( order by val asc) as `row_number` from numbers window w AS ( order by val asc)",
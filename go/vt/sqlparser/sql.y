@@ -433,7 +433,7 @@ func bindVariable(yylex yyLexer, bvar string) {
 %type <framePoint> frame_point
 %type <frameClause> frame_clause frame_clause_opt
 %type <windowSpecification> window_spec
-%type <overClause> over_clause
+%type <overClause> over_clause over_clause_opt
 %type <nullTreatmentType> null_treatment_type
 %type <nullTreatmentClause> null_treatment_clause null_treatment_clause_opt
 %type <fromFirstLastType> from_first_last_type
@@ -5492,6 +5492,15 @@ over_clause:
     $$ = &OverClause{WindowName: $2}
   }
 
+over_clause_opt:
+  {
+    $$ = nil
+  }
+| over_clause
+  {
+    $$ = $1
+  }
+
 null_treatment_clause_opt:
   {
     $$ = nil
@@ -5761,69 +5770,69 @@ UTC_DATE func_paren_opt
   {
     $$ = &CurTimeFuncExpr{Name:NewIdentifierCI("current_time"), Fsp: $2}
   }
-| COUNT openb '*' closeb
+| COUNT openb '*' closeb over_clause_opt
   {
-    $$ = &CountStar{}
+    $$ = &CountStar{OverClause:$5}
   }
-| COUNT openb distinct_opt expression_list closeb
+| COUNT openb distinct_opt expression_list closeb over_clause_opt
   {
-    $$ = &Count{Distinct:$3, Args:$4}
+    $$ = &Count{Distinct:$3, Args:$4, OverClause:$6}
   }
-| MAX openb distinct_opt expression closeb
+| MAX openb distinct_opt expression closeb over_clause_opt
   {
-    $$ = &Max{Distinct:$3, Arg:$4}
+    $$ = &Max{Distinct:$3, Arg:$4, OverClause:$6}
   }
-| MIN openb distinct_opt expression closeb
+| MIN openb distinct_opt expression closeb over_clause_opt
   {
-    $$ = &Min{Distinct:$3, Arg:$4}
+    $$ = &Min{Distinct:$3, Arg:$4, OverClause:$6}
   }
-| SUM openb distinct_opt expression closeb
+| SUM openb distinct_opt expression closeb over_clause_opt
   {
-    $$ = &Sum{Distinct:$3, Arg:$4}
+    $$ = &Sum{Distinct:$3, Arg:$4, OverClause:$6}
   }
-| AVG openb distinct_opt expression closeb
+| AVG openb distinct_opt expression closeb over_clause_opt
   {
-    $$ = &Avg{Distinct:$3, Arg:$4}
+    $$ = &Avg{Distinct:$3, Arg:$4, OverClause:$6}
   }
-| BIT_AND openb expression closeb
+| BIT_AND openb expression closeb over_clause_opt
   {
-    $$ = &BitAnd{Arg:$3}
+    $$ = &BitAnd{Arg:$3, OverClause:$5}
   }
-| BIT_OR openb expression closeb
+| BIT_OR openb expression closeb over_clause_opt
   {
-    $$ = &BitOr{Arg:$3}
+    $$ = &BitOr{Arg:$3, OverClause:$5}
   }
-| BIT_XOR openb expression closeb
+| BIT_XOR openb expression closeb over_clause_opt
    {
-     $$ = &BitXor{Arg:$3}
+     $$ = &BitXor{Arg:$3, OverClause:$5}
    }
-| STD openb expression closeb
+| STD openb expression closeb over_clause_opt
     {
-      $$ = &Std{Arg:$3}
+      $$ = &Std{Arg:$3, OverClause:$5}
     }
-| STDDEV openb expression closeb
+| STDDEV openb expression closeb over_clause_opt
     {
-      $$ = &StdDev{Arg:$3}
+      $$ = &StdDev{Arg:$3, OverClause:$5}
     }
-| STDDEV_POP openb expression closeb
+| STDDEV_POP openb expression closeb over_clause_opt
     {
-      $$ = &StdPop{Arg:$3}
+      $$ = &StdPop{Arg:$3, OverClause:$5}
     }
-| STDDEV_SAMP openb expression closeb
+| STDDEV_SAMP openb expression closeb over_clause_opt
     {
-      $$ = &StdSamp{Arg:$3}
+      $$ = &StdSamp{Arg:$3, OverClause:$5}
     }
-| VAR_POP openb expression closeb
+| VAR_POP openb expression closeb over_clause_opt
      {
-       $$ = &VarPop{Arg:$3}
+       $$ = &VarPop{Arg:$3, OverClause:$5}
      }
-| VAR_SAMP openb expression closeb
+| VAR_SAMP openb expression closeb over_clause_opt
      {
-       $$ = &VarSamp{Arg:$3}
+       $$ = &VarSamp{Arg:$3, OverClause:$5}
      }
-| VARIANCE openb expression closeb
+| VARIANCE openb expression closeb over_clause_opt
      {
-       $$ = &Variance{Arg:$3}
+       $$ = &Variance{Arg:$3, OverClause:$5}
      }
 | GROUP_CONCAT openb distinct_opt expression_list order_by_opt separator_opt limit_opt closeb
   {
@@ -6055,7 +6064,7 @@ UTC_DATE func_paren_opt
   }
 | NTILE openb null_int_variable_arg closeb over_clause
   {
-    $$ =  &NtileExpr{N: $3, OverClause: $5}
+    $$ =  &NtileExpr{N: $3, OverClause:$5}
   }
 | NTH_VALUE openb expression ',' null_int_variable_arg closeb from_first_last_clause_opt null_treatment_clause_opt over_clause
   {
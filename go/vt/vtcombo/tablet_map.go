@@ -578,14 +578,14 @@ func (itc *internalTabletConn) BeginStreamExecute(
 }
 
 // MessageStream is part of queryservice.QueryService
-func (itc *internalTabletConn) MessageStream(ctx context.Context, target *querypb.Target, name string, callback func(*sqltypes.Result) error) error {
-	err := itc.tablet.qsc.QueryService().MessageStream(ctx, target, name, callback)
+func (itc *internalTabletConn) MessageStream(ctx context.Context, target *querypb.Target, name, group string, callback func(*sqltypes.Result) error) error {
+	err := itc.tablet.qsc.QueryService().MessageStream(ctx, target, name, group, callback)
 	return tabletconn.ErrorFromGRPC(vterrors.ToGRPC(err))
 }
 
 // MessageAck is part of queryservice.QueryService
-func (itc *internalTabletConn) MessageAck(ctx context.Context, target *querypb.Target, name string, ids []*querypb.Value) (int64, error) {
-	count, err := itc.tablet.qsc.QueryService().MessageAck(ctx, target, name, ids)
+func (itc *internalTabletConn) MessageAck(ctx context.Context, target *querypb.Target, name, group string, ids []*querypb.Value) (int64, error) {
+	count, err := itc.tablet.qsc.QueryService().MessageAck(ctx, target, name, group, ids)
 	return count, tabletconn.ErrorFromGRPC(vterrors.ToGRPC(err))
 }
 
@@ -903,7 +903,7 @@ func (itmc *internalTabletManagerClient) Backup(context.Context, *topodatapb.Tab
 	return nil, fmt.Errorf("not implemented in vtcombo")
 }
 
-func (itmc *internalTabletManagerClient) RestoreFromBackup(context.Context, *topodatapb.Tablet, time.Time) (logutil.EventStream, error) {
+func (itmc *internalTabletManagerClient) RestoreFromBackup(context.Context, *topodatapb.Tablet, *tabletmanagerdatapb.RestoreFromBackupRequest) (logutil.EventStream, error) {
 	return nil, fmt.Errorf("not implemented in vtcombo")
 }
 
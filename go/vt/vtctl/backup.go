@@ -59,20 +59,21 @@ func init() {
 	addCommand("Tablets", command{
 		name:   "Backup",
 		method: commandBackup,
-		params: "[--concurrency=4] [--allow_primary=false] <tablet alias>",
-		help:   "Stops mysqld and uses the BackupStorage service to store a new backup. This function also remembers if the tablet was replicating so that it can restore the same state after the backup completes.",
+		params: "[--concurrency=4] [--allow_primary=false] [--incremental_from_pos=<pos>|auto] <tablet alias>",
+		help:   "Stops mysqld and uses the BackupStorage service to store a new backup. This function also remembers if the tablet was replicating so that it can restore the same state after the backup completes. With --incremental_from_pos, backs up the binary logs since the given position instead, without stopping mysqld.",
 	})
 	addCommand("Tablets", command{
 		name:   "RestoreFromBackup",
 		method: commandRestoreFromBackup,
-		params: "[--backup_timestamp=yyyy-MM-dd.HHmmss] <tablet alias>",
-		help:   "Stops mysqld and restores the data from the latest backup or if a timestamp is specified then the most recent backup at or before that time.",
+		params: "[--backup_timestamp=yyyy-MM-dd.HHmmss | --restore_to_pos=<pos> | --restore_to_timestamp=<RFC3339 time>] <tablet alias>",
+		help:   "Stops mysqld and restores the data from the latest backup or if a timestamp is specified then the most recent backup at or before that time. With --restore_to_pos or --restore_to_timestamp, restores a full backup and applies incremental backups up to that point, and leaves the tablet DRAINED.",
 	})
 }
 
 func commandBackup(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
 	concurrency := subFlags.Int("concurrency", 4, "Specifies the number of compression/checksum jobs to run simultaneously")
 	allowPrimary := subFlags.Bool("allow_primary", false, "Allows backups to be taken on primary. Warning!! If you are using the builtin backup engine, this will shutdown your primary mysql for as long as it takes to create a backup.")
+	incrementalFromPos := subFlags.String("incremental_from_pos", "", "Position of the previous backup. Takes an incremental backup of the binary logs since that position. Use 'auto' to start from the last successful backup.")
 
 	if err := subFlags.Parse(args); err != nil {
 		return err
@@ -87,9 +88,10 @@ func commandBackup(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.Fl
 	}
 
 	return wr.VtctldServer().Backup(&vtctldatapb.BackupRequest{
-		TabletAlias:  tabletAlias,
-		Concurrency:  uint64(*concurrency),
-		AllowPrimary: *allowPrimary,
+		TabletAlias:        tabletAlias,
+		Concurrency:        uint64(*concurrency),
+		AllowPrimary:       *allowPrimary,
+		IncrementalFromPos: *incrementalFromPos,
 	}, &backupEventStreamLogger{logger: wr.Logger(), ctx: ctx})
 }
 
@@ -200,6 +202,8 @@ func (b *backupRestoreEventStreamLogger) Send(resp *vtctldatapb.RestoreFromBacku
 
 func commandRestoreFromBackup(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
 	backupTimestampStr := subFlags.String("backup_timestamp", "", "Use the backup taken at or before this timestamp rather than using the latest backup.")
+	restoreToPos := subFlags.String("restore_to_pos", "", "Run a point in time recovery that ends with the given position. This will attempt to use one full backup followed by zero or more incremental backups.")
+	restoreToTimestampStr := subFlags.String("restore_to_timestamp", "", "Run a point in time recovery that restores up to, and excluding, the given timestamp in RFC3339 format (e.g. '2006-01-02T15:04:05Z'). This will attempt to use one full backup followed by zero or more incremental backups.")
 	if err := subFlags.Parse(args); err != nil {
 		return err
 	}
@@ -218,6 +222,17 @@ func commandRestoreFromBackup(ctx context.Context, wr *wrangler.Wrangler, subFla
 			return vterrors.New(vtrpcpb.Code_INVALID_ARGUMENT, fmt.Sprintf("unable to parse the backup timestamp value provided of '%s'", *backupTimestampStr))
 		}
 	}
+	restoreToTimestamp := time.Time{}
+	if *restoreToTimestampStr != "" {
+		var err error
+		restoreToTimestamp, err = time.Parse(time.RFC3339, *restoreToTimestampStr)
+		if err != nil {
+			return vterrors.New(vtrpcpb.Code_INVALID_ARGUMENT, fmt.Sprintf("unable to parse the restore_to_timestamp value provided of '%s'", *restoreToTimestampStr))
+		}
+	}
+	if *restoreToPos != "" && !restoreToTimestamp.IsZero() {
+		return vterrors.New(vtrpcpb.Code_INVALID_ARGUMENT, "--restore_to_pos and --restore_to_timestamp are mutually exclusive")
+	}
 
 	tabletAlias, err := topoproto.ParseTabletAlias(subFlags.Arg(0))
 	if err != nil {
@@ -225,12 +240,16 @@ func commandRestoreFromBackup(ctx context.Context, wr *wrangler.Wrangler, subFla
 	}
 
 	req := &vtctldatapb.RestoreFromBackupRequest{
-		TabletAlias: tabletAlias,
+		TabletAlias:  tabletAlias,
+		RestoreToPos: *restoreToPos,
 	}
 
 	if !backupTime.IsZero() {
 		req.BackupTime = protoutil.TimeToProto(backupTime)
 	}
+	if !restoreToTimestamp.IsZero() {
+		req.RestoreToTimestamp = protoutil.TimeToProto(restoreToTimestamp)
+	}
 
 	return wr.VtctldServer().RestoreFromBackup(req, &backupRestoreEventStreamLogger{logger: wr.Logger(), ctx: ctx})
 }
@@ -56,6 +56,15 @@ func (client *gRPCVtctldClient) ApplyShardRoutingRules(ctx context.Context, in *
 	return client.c.ApplyShardRoutingRules(ctx, in, opts...)
 }
 
+// ApplyBackupRetention is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) ApplyBackupRetention(ctx context.Context, in *vtctldatapb.ApplyBackupRetentionRequest, opts ...grpc.CallOption) (*vtctldatapb.ApplyBackupRetentionResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.ApplyBackupRetention(ctx, in, opts...)
+}
+
 // ApplyRoutingRules is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) ApplyRoutingRules(ctx context.Context, in *vtctldatapb.ApplyRoutingRulesRequest, opts ...grpc.CallOption) (*vtctldatapb.ApplyRoutingRulesResponse, error) {
 	if client.c == nil {
@@ -560,6 +569,15 @@ func (client *gRPCVtctldClient) RunHealthCheck(ctx context.Context, in *vtctldat
 	return client.c.RunHealthCheck(ctx, in, opts...)
 }
 
+// SetBackupRetentionPolicy is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) SetBackupRetentionPolicy(ctx context.Context, in *vtctldatapb.SetBackupRetentionPolicyRequest, opts ...grpc.CallOption) (*vtctldatapb.SetBackupRetentionPolicyResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.SetBackupRetentionPolicy(ctx, in, opts...)
+}
+
 // SetKeyspaceDurabilityPolicy is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) SetKeyspaceDurabilityPolicy(ctx context.Context, in *vtctldatapb.SetKeyspaceDurabilityPolicyRequest, opts ...grpc.CallOption) (*vtctldatapb.SetKeyspaceDurabilityPolicyResponse, error) {
 	if client.c == nil {
@@ -757,3 +775,12 @@ func (client *gRPCVtctldClient) ValidateVersionKeyspace(ctx context.Context, in
 
 	return client.c.ValidateVersionKeyspace(ctx, in, opts...)
 }
+
+// VerifyBackup is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) VerifyBackup(ctx context.Context, in *vtctldatapb.VerifyBackupRequest, opts ...grpc.CallOption) (*vtctldatapb.VerifyBackupResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.VerifyBackup(ctx, in, opts...)
+}
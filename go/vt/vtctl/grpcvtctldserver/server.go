@@ -149,6 +149,110 @@ func (s *VtctldServer) AddCellsAlias(ctx context.Context, req *vtctldatapb.AddCe
 	return &vtctldatapb.AddCellsAliasResponse{}, nil
 }
 
+// ApplyBackupRetention is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) ApplyBackupRetention(ctx context.Context, req *vtctldatapb.ApplyBackupRetentionRequest) (resp *vtctldatapb.ApplyBackupRetentionResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.ApplyBackupRetention")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("shard", req.Shard)
+	span.Annotate("dry_run", req.DryRun)
+
+	if req.Keyspace == "" && req.Shard != "" {
+		err = vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "shard %v given without a keyspace", req.Shard)
+		return nil, err
+	}
+
+	keyspaces := []string{req.Keyspace}
+	if req.Keyspace == "" {
+		keyspaces, err = s.ts.GetKeyspaces(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer bs.Close()
+
+	logger := logutil.NewConsoleLogger()
+	now := time.Now()
+	resp = &vtctldatapb.ApplyBackupRetentionResponse{}
+	rec := concurrency.AllErrorRecorder{}
+
+	for _, keyspace := range keyspaces {
+		ki, err := s.ts.GetKeyspace(ctx, keyspace)
+		if err != nil {
+			rec.RecordError(err)
+			continue
+		}
+
+		shards := []string{req.Shard}
+		if req.Shard == "" {
+			shards, err = s.ts.GetShardNames(ctx, keyspace)
+			if err != nil {
+				rec.RecordError(err)
+				continue
+			}
+		}
+
+		for _, shard := range shards {
+			removed, err := s.applyShardBackupRetention(ctx, logger, bs, ki, shard, now, req.DryRun)
+			for _, bh := range removed {
+				bi := mysqlctlproto.BackupHandleToProto(bh)
+				bi.Keyspace = keyspace
+				bi.Shard = shard
+				resp.RemovedBackups = append(resp.RemovedBackups, bi)
+			}
+			if err != nil {
+				rec.RecordError(vterrors.Wrapf(err, "failed to apply the backup retention policy of %v", filepath.Join(keyspace, shard)))
+			}
+		}
+	}
+
+	if rec.HasErrors() {
+		err = rec.Error()
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// applyShardBackupRetention applies the backup retention policy of a shard, or of its keyspace if the
+// shard has none. Unless it's a dry run, the backups are listed and removed under the shard lock, so that
+// several vtctlds applying the policies don't remove the same backups at the same time.
+func (s *VtctldServer) applyShardBackupRetention(ctx context.Context, logger logutil.Logger, bs backupstorage.BackupStorage, ki *topo.KeyspaceInfo, shard string, now time.Time, dryRun bool) (removed []backupstorage.BackupHandle, err error) {
+	keyspace := ki.KeyspaceName()
+	si, err := s.ts.GetShard(ctx, keyspace, shard)
+	if err != nil {
+		return nil, err
+	}
+
+	// The policy of the shard takes precedence over the policy of the keyspace.
+	policy := si.BackupRetentionPolicy
+	if policy == nil {
+		policy = ki.BackupRetentionPolicy
+	}
+	if policy == nil {
+		return nil, nil
+	}
+
+	if !dryRun {
+		var unlock func(*error)
+		ctx, unlock, err = s.ts.LockShard(ctx, keyspace, shard, "ApplyBackupRetention")
+		if err != nil {
+			return nil, err
+		}
+		defer unlock(&err)
+	}
+
+	return mysqlctl.ApplyBackupRetention(ctx, logger, bs, filepath.Join(keyspace, shard), policy, now, dryRun)
+}
+
 // ApplyRoutingRules is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) ApplyRoutingRules(ctx context.Context, req *vtctldatapb.ApplyRoutingRulesRequest) (resp *vtctldatapb.ApplyRoutingRulesResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.ApplyRoutingRules")
@@ -214,6 +318,7 @@ func (s *VtctldServer) ApplySchema(ctx context.Context, req *vtctldatapb.ApplySc
 	span.Annotate("keyspace", req.Keyspace)
 	span.Annotate("skip_preflight", req.SkipPreflight)
 	span.Annotate("ddl_strategy", req.DdlStrategy)
+	span.Annotate("dry_run", req.DryRun)
 
 	if len(req.Sql) == 0 {
 		err = vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "Sql must be a non-empty array")
@@ -261,6 +366,9 @@ func (s *VtctldServer) ApplySchema(ctx context.Context, req *vtctldatapb.ApplySc
 	if req.SkipPreflight {
 		executor.SkipPreflight()
 	}
+	if req.DryRun {
+		executor.DryRun()
+	}
 
 	if err = executor.SetDDLStrategy(req.DdlStrategy); err != nil {
 		err = vterrors.Wrapf(err, "invalid DdlStrategy: %s", req.DdlStrategy)
@@ -285,7 +393,8 @@ func (s *VtctldServer) ApplySchema(ctx context.Context, req *vtctldatapb.ApplySc
 	}
 
 	return &vtctldatapb.ApplySchemaResponse{
-		UuidList: execResult.UUIDs,
+		UuidList:           execResult.UUIDs,
+		MigrationEstimates: execResult.MigrationEstimates,
 	}, err
 }
 
@@ -456,7 +565,11 @@ func (s *VtctldServer) BackupShard(req *vtctldatapb.BackupShardRequest, stream v
 func (s *VtctldServer) backupTablet(ctx context.Context, tablet *topodatapb.Tablet, req *vtctldatapb.BackupRequest, stream interface {
 	Send(resp *vtctldatapb.BackupResponse) error
 }) error {
-	r := &tabletmanagerdatapb.BackupRequest{Concurrency: int64(req.Concurrency), AllowPrimary: req.AllowPrimary}
+	r := &tabletmanagerdatapb.BackupRequest{
+		Concurrency:        int64(req.Concurrency),
+		AllowPrimary:       req.AllowPrimary,
+		IncrementalFromPos: req.IncrementalFromPos,
+	}
 	logStream, err := s.tmc.Backup(ctx, tablet, r)
 	if err != nil {
 		return err
@@ -2615,6 +2728,9 @@ func (s *VtctldServer) RestoreFromBackup(req *vtctldatapb.RestoreFromBackupReque
 	if !backupTime.IsZero() {
 		span.Annotate("backup_timestamp", backupTime.Format(mysqlctl.BackupTimestampFormat))
 	}
+	if req.RestoreToPos != "" {
+		span.Annotate("restore_to_pos", req.RestoreToPos)
+	}
 
 	ti, err := s.ts.GetTablet(ctx, req.TabletAlias)
 	if err != nil {
@@ -2624,7 +2740,12 @@ func (s *VtctldServer) RestoreFromBackup(req *vtctldatapb.RestoreFromBackupReque
 	span.Annotate("keyspace", ti.Keyspace)
 	span.Annotate("shard", ti.Shard)
 
-	logStream, err := s.tmc.RestoreFromBackup(ctx, ti.Tablet, protoutil.TimeFromProto(req.BackupTime))
+	r := &tabletmanagerdatapb.RestoreFromBackupRequest{
+		BackupTime:         req.BackupTime,
+		RestoreToPos:       req.RestoreToPos,
+		RestoreToTimestamp: req.RestoreToTimestamp,
+	}
+	logStream, err := s.tmc.RestoreFromBackup(ctx, ti.Tablet, r)
 	if err != nil {
 		return err
 	}
@@ -2693,6 +2814,63 @@ func (s *VtctldServer) RunHealthCheck(ctx context.Context, req *vtctldatapb.RunH
 	return &vtctldatapb.RunHealthCheckResponse{}, nil
 }
 
+// SetBackupRetentionPolicy is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) SetBackupRetentionPolicy(ctx context.Context, req *vtctldatapb.SetBackupRetentionPolicyRequest) (resp *vtctldatapb.SetBackupRetentionPolicyResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.SetBackupRetentionPolicy")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("shard", req.Shard)
+	span.Annotate("policy", req.Policy.String())
+
+	if req.Policy != nil {
+		if err = mysqlctl.ValidateBackupRetentionPolicy(req.Policy); err != nil {
+			err = vterrors.Wrapf(err, "invalid backup retention policy")
+			return nil, err
+		}
+	}
+
+	if req.Shard != "" {
+		si, err := s.ts.UpdateShardFields(ctx, req.Keyspace, req.Shard, func(si *topo.ShardInfo) error {
+			si.BackupRetentionPolicy = req.Policy
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &vtctldatapb.SetBackupRetentionPolicyResponse{
+			Shard: si.Shard,
+		}, nil
+	}
+
+	ctx, unlock, lockErr := s.ts.LockKeyspace(ctx, req.Keyspace, "SetBackupRetentionPolicy")
+	if lockErr != nil {
+		err = lockErr
+		return nil, err
+	}
+
+	defer unlock(&err)
+
+	ki, err := s.ts.GetKeyspace(ctx, req.Keyspace)
+	if err != nil {
+		return nil, err
+	}
+
+	ki.BackupRetentionPolicy = req.Policy
+
+	err = s.ts.UpdateKeyspace(ctx, ki)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vtctldatapb.SetBackupRetentionPolicyResponse{
+		Keyspace: ki.Keyspace,
+	}, nil
+}
+
 // SetKeyspaceDurabilityPolicy is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) SetKeyspaceDurabilityPolicy(ctx context.Context, req *vtctldatapb.SetKeyspaceDurabilityPolicyRequest) (resp *vtctldatapb.SetKeyspaceDurabilityPolicyResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.SetKeyspaceDurabilityPolicy")
@@ -4178,6 +4356,79 @@ func (s *VtctldServer) ValidateVSchema(ctx context.Context, req *vtctldatapb.Val
 	return resp, err
 }
 
+// VerifyBackup is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VerifyBackup(ctx context.Context, req *vtctldatapb.VerifyBackupRequest) (resp *vtctldatapb.VerifyBackupResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VerifyBackup")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	bucket := filepath.Join(req.Keyspace, req.Shard)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("shard", req.Shard)
+	span.Annotate("bucket", bucket)
+	span.Annotate("backup_name", req.Name)
+	span.Annotate("concurrency", req.Concurrency)
+
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer bs.Close()
+
+	bhs, err := bs.ListBackups(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := logutil.NewConsoleLogger()
+
+	var bh backupstorage.BackupHandle
+	if req.Name == "" {
+		bh, _, err = mysqlctl.FindLatestSuccessfulBackup(ctx, logger, bhs)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "no backup to verify in %v", bucket)
+		}
+	} else {
+		for _, h := range bhs {
+			if h.Name() == req.Name {
+				bh = h
+				break
+			}
+		}
+		if bh == nil {
+			return nil, vterrors.Errorf(vtrpc.Code_NOT_FOUND, "backup %v not found in %v", req.Name, bucket)
+		}
+	}
+
+	verifyErr := mysqlctl.VerifyBackupFiles(ctx, logger, bh, int(req.Concurrency))
+	if ctx.Err() != nil {
+		// Don't record a failure if we were just interrupted.
+		return nil, ctx.Err()
+	}
+	if verifyErr != nil {
+		logger.Errorf("Backup %v/%v failed its verification: %v", bucket, bh.Name(), verifyErr)
+	}
+
+	verification := mysqlctl.NewBackupVerification(verifyErr, false /* restored */)
+	if err := mysqlctl.RecordBackupVerification(ctx, bs, bucket, bh.Name(), verification); err != nil {
+		return nil, err
+	}
+
+	verifiedTime, err := time.Parse(time.RFC3339, verification.VerifiedTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vtctldatapb.VerifyBackupResponse{
+		Name:         bh.Name(),
+		Status:       verification.Status,
+		VerifiedTime: protoutil.TimeToProto(verifiedTime),
+		Error:        verification.Error,
+	}, nil
+}
+
 // StartServer registers a VtctldServer for RPCs on the given gRPC server.
 func StartServer(s *grpc.Server, ts *topo.Server) {
 	vtctlservicepb.RegisterVtctldServer(s, NewVtctldServer(ts))
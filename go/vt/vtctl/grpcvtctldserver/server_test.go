@@ -230,6 +230,40 @@ func TestAddCellsAlias(t *testing.T) {
 	}
 }
 
+func TestApplyBackupRetention(t *testing.T) {
+	ctx := context.Background()
+	ts := memorytopo.NewServer("zone1")
+	testutil.AddKeyspace(ctx, t, ts, &vtctldatapb.Keyspace{
+		Name: "ks",
+		Keyspace: &topodatapb.Keyspace{
+			BackupRetentionPolicy: &topodatapb.BackupRetentionPolicy{MinBackups: 1},
+		},
+	})
+	testutil.AddShards(ctx, t, ts, &vtctldatapb.Shard{Keyspace: "ks", Name: "-"})
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	resp, err := vtctld.ApplyBackupRetention(ctx, &vtctldatapb.ApplyBackupRetentionRequest{Keyspace: "ks"})
+	require.NoError(t, err)
+	assert.Empty(t, resp.RemovedBackups)
+
+	// The backups are removed under the shard lock, so that several vtctlds don't remove them at the
+	// same time. A dry run doesn't take the lock.
+	_, unlock, err := ts.LockShard(ctx, "ks", "-", "test")
+	require.NoError(t, err)
+	defer unlock(&err)
+
+	lockCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = vtctld.ApplyBackupRetention(lockCtx, &vtctldatapb.ApplyBackupRetentionRequest{Keyspace: "ks", Shard: "-"})
+	assert.ErrorContains(t, err, "failed to apply the backup retention policy of ks/-")
+
+	resp, err = vtctld.ApplyBackupRetention(ctx, &vtctldatapb.ApplyBackupRetentionRequest{Keyspace: "ks", Shard: "-", DryRun: true})
+	require.NoError(t, err)
+	assert.Empty(t, resp.RemovedBackups)
+}
+
 func TestApplyRoutingRules(t *testing.T) {
 	t.Parallel()
 
@@ -8409,6 +8443,129 @@ func TestRunHealthCheck(t *testing.T) {
 	}
 }
 
+func TestSetBackupRetentionPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := &topodatapb.BackupRetentionPolicy{
+		KeepDailyFor: protoutil.DurationToProto(7 * 24 * time.Hour),
+		MinBackups:   2,
+	}
+
+	tests := []struct {
+		name        string
+		keyspaces   []*vtctldatapb.Keyspace
+		shards      []*vtctldatapb.Shard
+		req         *vtctldatapb.SetBackupRetentionPolicyRequest
+		expected    *vtctldatapb.SetBackupRetentionPolicyResponse
+		expectedErr string
+	}{
+		{
+			name: "keyspace policy",
+			keyspaces: []*vtctldatapb.Keyspace{
+				{
+					Name:     "ks1",
+					Keyspace: &topodatapb.Keyspace{},
+				},
+			},
+			req: &vtctldatapb.SetBackupRetentionPolicyRequest{
+				Keyspace: "ks1",
+				Policy:   policy,
+			},
+			expected: &vtctldatapb.SetBackupRetentionPolicyResponse{
+				Keyspace: &topodatapb.Keyspace{
+					BackupRetentionPolicy: policy,
+				},
+			},
+		},
+		{
+			name: "clear keyspace policy",
+			keyspaces: []*vtctldatapb.Keyspace{
+				{
+					Name: "ks1",
+					Keyspace: &topodatapb.Keyspace{
+						BackupRetentionPolicy: policy,
+					},
+				},
+			},
+			req: &vtctldatapb.SetBackupRetentionPolicyRequest{
+				Keyspace: "ks1",
+			},
+			expected: &vtctldatapb.SetBackupRetentionPolicyResponse{
+				Keyspace: &topodatapb.Keyspace{},
+			},
+		},
+		{
+			name: "shard policy",
+			shards: []*vtctldatapb.Shard{
+				{
+					Keyspace: "ks1",
+					Name:     "-",
+					Shard:    &topodatapb.Shard{},
+				},
+			},
+			req: &vtctldatapb.SetBackupRetentionPolicyRequest{
+				Keyspace: "ks1",
+				Shard:    "-",
+				Policy:   policy,
+			},
+			expected: &vtctldatapb.SetBackupRetentionPolicyResponse{
+				Shard: &topodatapb.Shard{
+					BackupRetentionPolicy: policy,
+				},
+			},
+		},
+		{
+			name: "keyspace not found",
+			req: &vtctldatapb.SetBackupRetentionPolicyRequest{
+				Keyspace: "ks1",
+				Policy:   policy,
+			},
+			expectedErr: "node doesn't exist: keyspaces/ks1",
+		},
+		{
+			name: "invalid policy",
+			keyspaces: []*vtctldatapb.Keyspace{
+				{
+					Name:     "ks1",
+					Keyspace: &topodatapb.Keyspace{},
+				},
+			},
+			req: &vtctldatapb.SetBackupRetentionPolicyRequest{
+				Keyspace: "ks1",
+				Policy: &topodatapb.BackupRetentionPolicy{
+					KeepHourlyFor: protoutil.DurationToProto(-time.Hour),
+				},
+			},
+			expectedErr: "invalid backup retention policy: keep_hourly_for must not be negative, got -1h0m0s",
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := memorytopo.NewServer("zone1")
+			testutil.AddKeyspaces(ctx, t, ts, tt.keyspaces...)
+			testutil.AddShards(ctx, t, ts, tt.shards...)
+
+			vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+				return NewVtctldServer(ts)
+			})
+			resp, err := vtctld.SetBackupRetentionPolicy(ctx, tt.req)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			utils.MustMatch(t, tt.expected, resp)
+		})
+	}
+}
+
 func TestSetKeyspaceDurabilityPolicy(t *testing.T) {
 	t.Parallel()
 
@@ -39,6 +39,11 @@ func (client *localVtctldClient) AddCellsAlias(ctx context.Context, in *vtctldat
 	return client.s.AddCellsAlias(ctx, in)
 }
 
+// ApplyBackupRetention is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) ApplyBackupRetention(ctx context.Context, in *vtctldatapb.ApplyBackupRetentionRequest, opts ...grpc.CallOption) (*vtctldatapb.ApplyBackupRetentionResponse, error) {
+	return client.s.ApplyBackupRetention(ctx, in)
+}
+
 // ApplyRoutingRules is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) ApplyRoutingRules(ctx context.Context, in *vtctldatapb.ApplyRoutingRulesRequest, opts ...grpc.CallOption) (*vtctldatapb.ApplyRoutingRulesResponse, error) {
 	return client.s.ApplyRoutingRules(ctx, in)
@@ -462,6 +467,11 @@ func (client *localVtctldClient) RunHealthCheck(ctx context.Context, in *vtctlda
 	return client.s.RunHealthCheck(ctx, in)
 }
 
+// SetBackupRetentionPolicy is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) SetBackupRetentionPolicy(ctx context.Context, in *vtctldatapb.SetBackupRetentionPolicyRequest, opts ...grpc.CallOption) (*vtctldatapb.SetBackupRetentionPolicyResponse, error) {
+	return client.s.SetBackupRetentionPolicy(ctx, in)
+}
+
 // SetKeyspaceDurabilityPolicy is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) SetKeyspaceDurabilityPolicy(ctx context.Context, in *vtctldatapb.SetKeyspaceDurabilityPolicyRequest, opts ...grpc.CallOption) (*vtctldatapb.SetKeyspaceDurabilityPolicyResponse, error) {
 	return client.s.SetKeyspaceDurabilityPolicy(ctx, in)
@@ -571,3 +581,8 @@ func (client *localVtctldClient) ValidateVSchema(ctx context.Context, in *vtctld
 func (client *localVtctldClient) ValidateVersionKeyspace(ctx context.Context, in *vtctldatapb.ValidateVersionKeyspaceRequest, opts ...grpc.CallOption) (*vtctldatapb.ValidateVersionKeyspaceResponse, error) {
 	return client.s.ValidateVersionKeyspace(ctx, in)
 }
+
+// VerifyBackup is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VerifyBackup(ctx context.Context, in *vtctldatapb.VerifyBackupRequest, opts ...grpc.CallOption) (*vtctldatapb.VerifyBackupResponse, error) {
+	return client.s.VerifyBackup(ctx, in)
+}
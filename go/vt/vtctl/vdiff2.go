@@ -61,6 +61,8 @@ func commandVDiff2(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.Fl
 	maxExtraRowsToCompare := subFlags.Int64("max_extra_rows_to_compare", 1000, "If there are collation differences between the source and target, you can have rows that are identical but simply returned in a different order from MySQL. We will do a second pass to compare the rows for any actual differences in this case and this flag allows you to control the resources used for this operation.")
 
 	autoRetry := subFlags.Bool("auto-retry", true, "Should this vdiff automatically retry and continue in case of recoverable errors")
+	incremental := subFlags.Bool("incremental", false, "Only compare the rows changed since the last completed vdiff of each table, and the rows that did not match then")
+	dryRun := subFlags.Bool("dry-run", false, "When repairing a vdiff, only show the statements that would be applied to the target")
 	checksum := subFlags.Bool("checksum", false, "Use row-level checksums to compare, not yet implemented")
 	samplePct := subFlags.Int64("sample_pct", 100, "How many rows to sample, not yet implemented")
 	verbose := subFlags.Bool("verbose", false, "Show verbose vdiff output in summaries")
@@ -116,6 +118,8 @@ func commandVDiff2(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.Fl
 			SamplePct:             *samplePct,
 			TimeoutSeconds:        int64(timeout.Seconds()),
 			MaxExtraRowsToCompare: *maxExtraRowsToCompare,
+			Incremental:           *incremental,
+			DryRun:                *dryRun,
 		},
 		ReportOptions: &tabletmanagerdatapb.VDiffReportOptions{
 			OnlyPKS:    *onlyPks,
@@ -144,7 +148,7 @@ func commandVDiff2(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.Fl
 				return fmt.Errorf("can only show a specific vdiff, please provide a valid UUID; view all with: VDiff -- --v2 %s.%s show all", keyspace, workflowName)
 			}
 		}
-	case vdiff.StopAction, vdiff.ResumeAction:
+	case vdiff.StopAction, vdiff.ResumeAction, vdiff.RepairAction:
 		vdiffUUID, err = uuid.Parse(actionArg)
 		if err != nil {
 			return fmt.Errorf("can only %s a specific vdiff, please provide a valid UUID; view all with: VDiff -- --v2 %s.%s show all", action, keyspace, workflowName)
@@ -211,6 +215,10 @@ func commandVDiff2(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.Fl
 			uuidToDisplay = vdiffUUID.String()
 		}
 		displayVDiff2ActionStatusResponse(wr, format, uuidToDisplay, action, vdiff.CompletedState)
+	case vdiff.RepairAction:
+		if err := displayVDiff2RepairResponse(wr, format, vdiffUUID.String(), output, *dryRun); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("invalid action %s; %s", action, usage)
 	}
@@ -654,6 +662,73 @@ func displayVDiff2ActionStatusResponse(wr *wrangler.Wrangler, format, uuid strin
 	}
 }
 
+// vdiffTableRepair is the repair of a table on a target shard.
+type vdiffTableRepair struct {
+	Shard          string
+	TableName      string
+	RowsRepaired   int64    `json:"RowsRepaired,omitempty"`
+	RowsMismatched int64    `json:"RowsMismatched,omitempty"`
+	Statements     []string `json:"Statements,omitempty"`
+}
+
+func displayVDiff2RepairResponse(wr *wrangler.Wrangler, format, uuid string, output *wrangler.VDiffOutput, dryRun bool) error {
+	var repairs []*vdiffTableRepair
+	shards := make([]string, 0, len(output.Responses))
+	for shard := range output.Responses {
+		shards = append(shards, shard)
+	}
+	sort.Strings(shards)
+	for _, shard := range shards {
+		resp := output.Responses[shard]
+		if resp == nil || resp.Output == nil {
+			continue
+		}
+		var repair *vdiffTableRepair
+		for _, row := range sqltypes.Proto3ToResult(resp.Output).Named().Rows {
+			tableName := row.AsString("table_name", "")
+			if repair == nil || repair.TableName != tableName {
+				repair = &vdiffTableRepair{Shard: shard, TableName: tableName}
+				repairs = append(repairs, repair)
+			}
+			if dryRun {
+				repair.Statements = append(repair.Statements, row.AsString("statement", ""))
+				continue
+			}
+			repair.RowsRepaired = row.AsInt64("rows_repaired", 0)
+			repair.RowsMismatched = row.AsInt64("rows_mismatched", 0)
+		}
+	}
+
+	if format == "json" {
+		jsonText, err := json.MarshalIndent(repairs, "", "\t")
+		if err != nil {
+			return err
+		}
+		str := string(jsonText)
+		if str == "null" {
+			str = "[]"
+		}
+		wr.Logger().Printf(str + "\n")
+		return nil
+	}
+	if len(repairs) == 0 {
+		wr.Logger().Printf("No rows to repair for vdiff %s\n", uuid)
+		return nil
+	}
+	for _, repair := range repairs {
+		if dryRun {
+			wr.Logger().Printf("-- Table %s on shard %s\n", repair.TableName, repair.Shard)
+			for _, statement := range repair.Statements {
+				wr.Logger().Printf("%s;\n", statement)
+			}
+			continue
+		}
+		wr.Logger().Printf("Table %s on shard %s: repaired %d rows, %d rows still do not match\n",
+			repair.TableName, repair.Shard, repair.RowsRepaired, repair.RowsMismatched)
+	}
+	return nil
+}
+
 func buildProgressReport(summary *vdiffSummary, rowsToCompare int64) {
 	report := &vdiff.ProgressReport{}
 	if summary.RowsCompared >= 1 {
@@ -440,13 +440,13 @@ var commands = []commandGroup{
 			{
 				name:   "Reshard",
 				method: commandReshard,
-				params: "[--source_shards=<source_shards>] [--target_shards=<target_shards>] [--cells=<cells>] [--tablet_types=<source_tablet_types>]  [--skip_schema_copy] <action> 'action must be one of the following: Create, Complete, Cancel, SwitchTraffic, ReverseTrafffic, Show, or Progress' <keyspace.workflow>",
+				params: "[--source_shards=<source_shards>] [--target_shards=<target_shards>] [--cells=<cells>] [--tablet_types=<source_tablet_types>]  [--skip_schema_copy] [--shards=<target_shards>] [--canary --canary_vtgates=<vtgates> [--canary_cells_percent=<percent>] [--canary_duration=<duration>] [--canary_max_error_rate=<rate>] [--canary_max_latency=<duration>]] <action> 'action must be one of the following: Create, Complete, Cancel, SwitchTraffic, ReverseTrafffic, Show, or Progress' <keyspace.workflow>",
 				help:   "Start a Resharding process. Example: Reshard --cells='zone1,alias1' --tablet_types='PRIMARY,REPLICA,RDONLY'  ks.workflow001 '0' '-80,80-'",
 			},
 			{
 				name:   "MoveTables",
 				method: commandMoveTables,
-				params: "[--source=<sourceKs>] [--tables=<tableSpecs>] [--cells=<cells>] [--tablet_types=<source_tablet_types>] [--all] [--exclude=<tables>] [--auto_start] [--stop_after_copy] [--source_shards=<source_shards>] <action> 'action must be one of the following: Create, Complete, Cancel, SwitchTraffic, ReverseTrafffic, Show, or Progress' <targetKs.workflow>",
+				params: "[--source=<sourceKs>] [--tables=<tableSpecs>] [--cells=<cells>] [--tablet_types=<source_tablet_types>] [--all] [--exclude=<tables>] [--auto_start] [--stop_after_copy] [--source_shards=<source_shards>] [--on_ddl=<on_ddl_action>] [--ddl_policy=<changes>] <action> 'action must be one of the following: Create, Complete, Cancel, SwitchTraffic, ReverseTrafffic, Show, or Progress' <targetKs.workflow>",
 				help:   `Move table(s) to another keyspace, table_specs is a list of tables or the tables section of the vschema for the target keyspace. Example: '{"t1":{"column_vindexes": [{"column": "id1", "name": "hash"}]}, "t2":{"column_vindexes": [{"column": "id2", "name": "hash"}]}}'.  In the case of an unsharded target keyspace the vschema for each table may be empty. Example: '{"t1":{}, "t2":{}}'.`,
 			},
 			{
@@ -509,6 +509,27 @@ var commands = []commandGroup{
 				params: "[--topo_type=etcd2|consul|zookeeper] [--topo_server=topo_url] [--topo_root=root_topo_node> [--unmount] [--list] [--show]  [<cluster_name>]",
 				help:   "Add/Remove/Display/List external cluster(s) to this vitess cluster",
 			},
+			{
+				name:   "DeadLetters",
+				method: commandDeadLetters,
+				params: "[--json] [--ids=id1,id2,...] [--limit=100] <keyspace.message_table> <list|requeue|purge>",
+				help: "Operates on the messages that were dead-lettered after exceeding the max attempts of a message table. Examples:" +
+					" \nvtctl DeadLetters commerce.orders_msg list" +
+					" \nvtctl DeadLetters --ids=7,8 commerce.orders_msg requeue" +
+					" \nvtctl DeadLetters commerce.orders_msg purge",
+			},
+			{
+				name:   "ListArchivedTables",
+				method: commandListArchivedTables,
+				params: "[--json] <keyspace>",
+				help:   "Lists the table archives that the table GC wrote to the backup storage before purging the tables of a keyspace. An archive is named after the GC UUID of its table.",
+			},
+			{
+				name:   "RestoreArchivedTable",
+				method: commandRestoreArchivedTable,
+				params: "<keyspace> <archive> <table>",
+				help:   "Creates a table on all primaries of a keyspace, with the schema and rows of a table archive written by the table GC. The table must not exist. Each shard restores its own archive, so tables archived before a reshard can't be restored.",
+			},
 		},
 	},
 	{
@@ -2135,7 +2156,7 @@ func commandMoveTables(ctx context.Context, wr *wrangler.Wrangler, subFlags *fla
 	target := subFlags.Arg(1)
 	tableSpecs := subFlags.Arg(2)
 	return wr.MoveTables(ctx, *workflow, source, target, tableSpecs, *cells, *tabletTypes, *allTables,
-		*excludes, *autoStart, *stopAfterCopy, "", *dropForeignKeys, "", nil)
+		*excludes, *autoStart, *stopAfterCopy, "", *dropForeignKeys, "", nil, "", "")
 }
 
 // VReplicationWorkflowAction defines subcommands passed to vtctl for movetables or reshard
@@ -2197,6 +2218,9 @@ func commandVRWorkflow(ctx context.Context, wr *wrangler.Wrangler, subFlags *fla
 	// note we make an opinionated decision to not allow specifying a different target time zone than UTC.
 	sourceTimeZone := subFlags.String("source_time_zone", "", "MoveTables only. Specifying this causes any DATETIME fields to be converted from given time zone into UTC")
 
+	onDDL := subFlags.String("on_ddl", "IGNORE", "MoveTables only. What the streams do at the DDLs of the source tables: IGNORE, STOP, EXEC, EXEC_IGNORE or APPLY_COMPATIBLE. APPLY_COMPATIBLE applies the changes allowed by --ddl_policy to the target tables, and stops the streams at the other DDLs.")
+	ddlPolicy := subFlags.String("ddl_policy", "", "MoveTables only. The changes (comma-separated) applied to the target tables with --on_ddl=APPLY_COMPATIBLE: add_columns, drop_columns, widen_columns and indexes. Defaults to all of them.")
+
 	// MoveTables-only params
 	renameTables := subFlags.Bool("rename_tables", false, "MoveTables only. Rename tables instead of dropping them. --rename_tables is only supported for Complete.")
 
@@ -2208,6 +2232,15 @@ func commandVRWorkflow(ctx context.Context, wr *wrangler.Wrangler, subFlags *fla
 	targetShards := subFlags.String("target_shards", "", "Reshard only. Target shards")
 	*targetShards = strings.TrimSpace(*targetShards)
 	skipSchemaCopy := subFlags.Bool("skip_schema_copy", false, "Reshard only. Skip copying of schema to target shards")
+	shards := subFlags.String("shards", "", "Reshard only. SwitchTraffic and ReverseTraffic only. Target shards (comma-separated) to switch reads for, instead of all shards. They must include all the target shards that overlap the same source shards. With --canary, these are the canary shards.")
+
+	// SwitchTraffic canary params
+	canary := subFlags.Bool("canary", false, "SwitchTraffic only. Switch reads progressively: first for the --shards or --canary_cells_percent canary, then, if the vtgates report healthy vttablet calls to the canary shards for --canary_duration, for the rest of the keyspace. Otherwise reads are switched back for the canary.")
+	canaryCellsPercent := subFlags.Int("canary_cells_percent", 0, "SwitchTraffic only. Percentage of the cells to switch reads for first, in alphabetical order, with --canary.")
+	canaryDuration := subFlags.Duration("canary_duration", 5*time.Minute, "SwitchTraffic only. How long to watch the canary for, with --canary.")
+	canaryVTGates := subFlags.String("canary_vtgates", "", "SwitchTraffic only. Web addresses (host:port, comma-separated) of the vtgates whose stats are watched, with --canary.")
+	canaryMaxErrorRate := subFlags.Float64("canary_max_error_rate", 0.01, "SwitchTraffic only. Maximum fraction of the vttablet calls to the canary shards that may fail, with --canary.")
+	canaryMaxLatency := subFlags.Duration("canary_max_latency", time.Second, "SwitchTraffic only. Maximum average latency of the vttablet calls to the canary shards, with --canary. 0 disables the latency check.")
 
 	_ = subFlags.Bool("v1", false, "Enables usage of v1 command structure. (default false). Must be added to run the command with --workflow")
 	_ = subFlags.Bool("v2", true, "")
@@ -2326,6 +2359,8 @@ func commandVRWorkflow(ctx context.Context, wr *wrangler.Wrangler, subFlags *fla
 			vrwp.ExternalCluster = externalClusterName
 			vrwp.SourceTimeZone = *sourceTimeZone
 			vrwp.DropForeignKeys = *dropForeignKeys
+			vrwp.OnDDL = *onDDL
+			vrwp.DDLPolicy = *ddlPolicy
 			if *sourceShards != "" {
 				vrwp.SourceShards = strings.Split(*sourceShards, ",")
 			}
@@ -2354,6 +2389,28 @@ func commandVRWorkflow(ctx context.Context, wr *wrangler.Wrangler, subFlags *fla
 		vrwp.Timeout = *timeout
 		vrwp.EnableReverseReplication = *reverseReplication
 		vrwp.MaxAllowedTransactionLagSeconds = int64(math.Ceil(maxReplicationLagAllowed.Seconds()))
+		if *shards != "" {
+			if workflowType != wrangler.ReshardWorkflow {
+				return fmt.Errorf("--shards is only supported for Reshard")
+			}
+			vrwp.Shards = strings.Split(*shards, ",")
+		}
+		if *canary {
+			if action != vReplicationWorkflowActionSwitchTraffic {
+				return fmt.Errorf("--canary is only supported for SwitchTraffic")
+			}
+			if *canaryCellsPercent < 0 || *canaryCellsPercent > 100 {
+				return fmt.Errorf("--canary_cells_percent must be between 0 and 100")
+			}
+			vrwp.Canary = true
+			vrwp.CanaryCellsPercent = *canaryCellsPercent
+			vrwp.CanaryDuration = *canaryDuration
+			if *canaryVTGates != "" {
+				vrwp.CanaryVTGates = strings.Split(*canaryVTGates, ",")
+			}
+			vrwp.CanaryMaxErrorRate = *canaryMaxErrorRate
+			vrwp.CanaryMaxLatency = *canaryMaxLatency
+		}
 	case vReplicationWorkflowActionCancel:
 		vrwp.KeepData = *keepData
 	case vReplicationWorkflowActionComplete:
@@ -3237,6 +3294,69 @@ func commandOnlineDDL(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag
 	return nil
 }
 
+func commandDeadLetters(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
+	json := subFlags.Bool("json", false, "Output JSON instead of human-readable table")
+	idsStr := subFlags.String("ids", "", "Specifies a comma-separated list of message ids to operate on. If empty, all dead-lettered messages are considered.")
+	limit := subFlags.Int("limit", 100, "Limit number of messages listed per shard")
+	if err := subFlags.Parse(args); err != nil {
+		return err
+	}
+	if subFlags.NArg() != 2 {
+		return fmt.Errorf("the <keyspace.message_table> and <command> arguments are required for the DeadLetters command")
+	}
+	keyspace, table, err := splitKeyspaceWorkflow(subFlags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var ids []string
+	if *idsStr != "" {
+		ids = strings.Split(*idsStr, ",")
+	}
+	qr, err := wr.DeadLetters(ctx, keyspace, table, subFlags.Arg(1), ids, *limit)
+	if err != nil {
+		return err
+	}
+	if *json {
+		return printJSON(wr.Logger(), qr)
+	}
+	printQueryResult(loggerWriter{wr.Logger()}, qr)
+	return nil
+}
+
+func commandListArchivedTables(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
+	json := subFlags.Bool("json", false, "Output JSON instead of human-readable table")
+	if err := subFlags.Parse(args); err != nil {
+		return err
+	}
+	if subFlags.NArg() != 1 {
+		return fmt.Errorf("the <keyspace> argument is required for the ListArchivedTables command")
+	}
+	qr, err := wr.ListArchivedTables(ctx, subFlags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *json {
+		return printJSON(wr.Logger(), qr)
+	}
+	printQueryResult(loggerWriter{wr.Logger()}, qr)
+	return nil
+}
+
+func commandRestoreArchivedTable(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
+	if err := subFlags.Parse(args); err != nil {
+		return err
+	}
+	if subFlags.NArg() != 3 {
+		return fmt.Errorf("the <keyspace>, <archive> and <table> arguments are required for the RestoreArchivedTable command")
+	}
+	qr, err := wr.RestoreArchivedTable(ctx, subFlags.Arg(0), subFlags.Arg(1), subFlags.Arg(2))
+	if err != nil {
+		return err
+	}
+	printQueryResult(loggerWriter{wr.Logger()}, qr)
+	return nil
+}
+
 func commandCopySchemaShard(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
 	tables := subFlags.String("tables", "", "Specifies a comma-separated list of tables to copy. Each is either an exact match, or a regular expression of the form /regexp/")
 	excludeTables := subFlags.String("exclude_tables", "", "Specifies a comma-separated list of tables to exclude. Each is either an exact match, or a regular expression of the form /regexp/")
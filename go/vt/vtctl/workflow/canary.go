@@ -0,0 +1,217 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// CanaryCheckInterval is how often a CanaryCheck samples the vtgate stats.
+var CanaryCheckInterval = 10 * time.Second
+
+// CanaryCheck watches the vttablet calls that vtgates make to a set of
+// shards after their reads were switched, and fails when the calls fail or
+// are slow. The stats are read from the /debug/vars page of the vtgates.
+type CanaryCheck struct {
+	// VTGates are the host:port addresses of the vtgate web servers.
+	VTGates     []string
+	Keyspace    string
+	Shards      []string
+	TabletTypes []topodatapb.TabletType
+	// MaxErrorRate is the maximum fraction of the vttablet calls that may fail.
+	MaxErrorRate float64
+	// MaxLatency is the maximum average latency of the vttablet calls. Zero
+	// disables the latency check.
+	MaxLatency time.Duration
+}
+
+// tabletCallStats are the vttablet call counters of the watched shards,
+// summed over all the vtgates.
+type tabletCallStats struct {
+	calls     int64
+	errors    int64
+	totalTime time.Duration
+}
+
+// vtgateDebugVars are the vtgate stats read by a CanaryCheck. Both stats are
+// keyed by "Operation.Keyspace.ShardName.DbType".
+type vtgateDebugVars struct {
+	VttabletCall struct {
+		Histograms map[string]struct {
+			Count int64
+			Time  int64
+		}
+	}
+	VttabletCallErrorCount map[string]int64
+}
+
+var getVTGateDebugVars = func(ctx context.Context, addr string) (*vtgateDebugVars, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/debug/vars", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s reading %s/debug/vars", resp.Status, addr)
+	}
+	vars := &vtgateDebugVars{}
+	if err := json.Unmarshal(body, vars); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// watches returns true when the stats key is for a call to one of the
+// watched shards.
+func (cc *CanaryCheck) watches(statsKey string) bool {
+	labels := strings.Split(statsKey, ".")
+	if len(labels) != 4 || labels[1] != cc.Keyspace {
+		return false
+	}
+	shardWatched := false
+	for _, shard := range cc.Shards {
+		if labels[2] == shard {
+			shardWatched = true
+			break
+		}
+	}
+	if !shardWatched {
+		return false
+	}
+	for _, tabletType := range cc.TabletTypes {
+		if labels[3] == topoproto.TabletTypeLString(tabletType) {
+			return true
+		}
+	}
+	return false
+}
+
+// sample reads the current vttablet call counters from all the vtgates.
+func (cc *CanaryCheck) sample(ctx context.Context) (*tabletCallStats, error) {
+	stats := &tabletCallStats{}
+	for _, vtgate := range cc.VTGates {
+		vars, err := getVTGateDebugVars(ctx, vtgate)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the stats of vtgate %s: %v", vtgate, err)
+		}
+		for statsKey, histogram := range vars.VttabletCall.Histograms {
+			if cc.watches(statsKey) {
+				stats.calls += histogram.Count
+				stats.totalTime += time.Duration(histogram.Time)
+			}
+		}
+		for statsKey, count := range vars.VttabletCallErrorCount {
+			if cc.watches(statsKey) {
+				stats.errors += count
+			}
+		}
+	}
+	return stats, nil
+}
+
+// evaluate returns an error when the vttablet calls made since the baseline
+// sample fail or are slow.
+func (cc *CanaryCheck) evaluate(baseline, current *tabletCallStats) error {
+	calls := current.calls - baseline.calls
+	if calls <= 0 {
+		return nil
+	}
+	shards := strings.Join(cc.Shards, ",")
+	errorRate := float64(current.errors-baseline.errors) / float64(calls)
+	if errorRate > cc.MaxErrorRate {
+		return fmt.Errorf("%.2f%% of the %d vttablet calls to %s/%s failed, above the maximum of %.2f%%",
+			100*errorRate, calls, cc.Keyspace, shards, 100*cc.MaxErrorRate)
+	}
+	latency := (current.totalTime - baseline.totalTime) / time.Duration(calls)
+	if cc.MaxLatency > 0 && latency > cc.MaxLatency {
+		return fmt.Errorf("the average latency of the %d vttablet calls to %s/%s is %v, above the maximum of %v",
+			calls, cc.Keyspace, shards, latency, cc.MaxLatency)
+	}
+	return nil
+}
+
+// Watch samples the vtgate stats every CanaryCheckInterval for the given
+// duration. It returns an error as soon as the vttablet calls to the watched
+// shards fail or are slow, or when the stats cannot be read.
+func (cc *CanaryCheck) Watch(ctx context.Context, duration time.Duration) error {
+	if len(cc.VTGates) == 0 {
+		return fmt.Errorf("no vtgates to read the stats of")
+	}
+	baseline, err := cc.sample(ctx)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(CanaryCheckInterval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+	for {
+		done := false
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-deadline.C:
+			done = true
+		}
+		current, err := cc.sample(ctx)
+		if err != nil {
+			return err
+		}
+		if err := cc.evaluate(baseline, current); err != nil {
+			return err
+		}
+		log.Infof("Canary check for %s/%s: %d vttablet calls, %d errors", cc.Keyspace, strings.Join(cc.Shards, ","),
+			current.calls-baseline.calls, current.errors-baseline.errors)
+		if done {
+			return nil
+		}
+	}
+}
+
+// CanaryCells returns the given percentage of the cells, rounded up, in
+// alphabetical order.
+func CanaryCells(cells []string, percent int) []string {
+	sorted := append([]string(nil), cells...)
+	sort.Strings(sorted)
+	n := int(math.Ceil(float64(len(sorted)*percent) / 100))
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	return sorted[:n]
+}
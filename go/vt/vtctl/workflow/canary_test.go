@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// fakeVTGate serves vttablet call stats on /debug/vars, adding calls
+// every time the stats are read.
+type fakeVTGate struct {
+	mu        sync.Mutex
+	calls     int64
+	errors    int64
+	totalTime time.Duration
+	// callsPerRead, errorsPerRead and latency describe the calls added
+	// on every read.
+	callsPerRead  int64
+	errorsPerRead int64
+	latency       time.Duration
+}
+
+func (fv *fakeVTGate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	fmt.Fprintf(w, `{"VttabletCall": {"TotalCount": %d, "Histograms": {"Execute.ks.-80.replica": {"500000": 0, "Count": %d, "Time": %d}, "Execute.ks.80-.replica": {"Count": 1000, "Time": 1000}, "Execute.ks.-80.primary": {"Count": 1000, "Time": 1000}}}, "VttabletCallErrorCount": {"Execute.ks.-80.replica": %d, "Execute.ks.80-.replica": 1000}}`,
+		fv.calls, fv.calls, fv.totalTime.Nanoseconds(), fv.errors)
+	fv.calls += fv.callsPerRead
+	fv.errors += fv.errorsPerRead
+	fv.totalTime += time.Duration(fv.callsPerRead) * fv.latency
+}
+
+func TestCanaryCheck(t *testing.T) {
+	defer func(interval time.Duration) { CanaryCheckInterval = interval }(CanaryCheckInterval)
+	CanaryCheckInterval = 10 * time.Millisecond
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		vtgate  *fakeVTGate
+		wantErr string
+	}{
+		{
+			name:   "healthy",
+			vtgate: &fakeVTGate{callsPerRead: 100, errorsPerRead: 0, latency: time.Millisecond},
+		},
+		{
+			name:   "no traffic",
+			vtgate: &fakeVTGate{},
+		},
+		{
+			name:    "errors",
+			vtgate:  &fakeVTGate{callsPerRead: 100, errorsPerRead: 5, latency: time.Millisecond},
+			wantErr: "5.00% of the 100 vttablet calls to ks/-80 failed, above the maximum of 1.00%",
+		},
+		{
+			name:    "slow",
+			vtgate:  &fakeVTGate{callsPerRead: 100, latency: time.Second},
+			wantErr: "the average latency of the 100 vttablet calls to ks/-80 is 1s, above the maximum of 100ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.vtgate)
+			defer server.Close()
+			cc := &CanaryCheck{
+				VTGates:      []string{strings.TrimPrefix(server.URL, "http://")},
+				Keyspace:     "ks",
+				Shards:       []string{"-80"},
+				TabletTypes:  []topodatapb.TabletType{topodatapb.TabletType_REPLICA},
+				MaxErrorRate: 0.01,
+				MaxLatency:   100 * time.Millisecond,
+			}
+			err := cc.Watch(ctx, 50*time.Millisecond)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	cc := &CanaryCheck{VTGates: []string{"localhost:0"}, Keyspace: "ks", Shards: []string{"-80"}}
+	assert.Error(t, cc.Watch(ctx, 50*time.Millisecond))
+}
+
+func TestCanaryCells(t *testing.T) {
+	cells := []string{"zone3", "zone1", "zone2", "zone4"}
+	assert.Equal(t, []string{"zone1"}, CanaryCells(cells, 10))
+	assert.Equal(t, []string{"zone1", "zone2"}, CanaryCells(cells, 50))
+	assert.Equal(t, []string{"zone1", "zone2", "zone3"}, CanaryCells(cells, 51))
+	assert.Equal(t, []string{"zone1", "zone2", "zone3", "zone4"}, CanaryCells(cells, 100))
+}
@@ -143,7 +143,7 @@ func (s *Server) GetCellsWithShardReadsSwitched(
 		var (
 			shardServedTypes []string
 			found            bool
-			noControls       bool
+			hasControl       bool
 		)
 
 		for _, partition := range srvks.GetPartitions() {
@@ -160,16 +160,12 @@ func (s *Server) GetCellsWithShardReadsSwitched(
 				}
 			}
 
-			// It is possible that there are no tablet controls if the target
-			// shards are not yet serving, or once reads and writes are both
-			// switched.
-			if len(partition.GetShardTabletControls()) == 0 {
-				noControls = true
-				break
-			}
-
+			// It is possible that the shard has no tablet control if the target
+			// shards are not yet serving, once reads and writes are both
+			// switched, or when reads were only switched for other shards.
 			for _, tabletControl := range partition.GetShardTabletControls() {
 				if key.KeyRangeEqual(tabletControl.GetKeyRange(), si.GetKeyRange()) {
+					hasControl = true
 					if !tabletControl.GetQueryServiceDisabled() {
 						shardServedTypes = append(shardServedTypes, si.ShardName())
 					}
@@ -179,7 +175,7 @@ func (s *Server) GetCellsWithShardReadsSwitched(
 			}
 		}
 
-		if found && (len(shardServedTypes) > 0 || noControls) {
+		if found && (len(shardServedTypes) > 0 || !hasControl) {
 			cellsNotSwitched = append(cellsNotSwitched, cell)
 		} else {
 			cellsSwitched = append(cellsSwitched, cell)
@@ -40,6 +40,11 @@ type State struct {
 
 	WritesSwitched bool
 
+	// Progressive Reshard info: the shards whose reads are switched in some
+	// of the cells in which reads are not switched for all shards.
+	ReplicaShardsSwitched []string
+	RdonlyShardsSwitched  []string
+
 	// Partial MoveTables info
 	WritesPartiallySwitched bool
 }
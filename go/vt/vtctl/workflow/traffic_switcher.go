@@ -31,6 +31,7 @@ import (
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/key"
 	"vitess.io/vitess/go/vt/logutil"
 	"vitess.io/vitess/go/vt/topo"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
@@ -365,6 +366,45 @@ func HashStreams(targetKeyspace string, targets map[string]*MigrationTarget) int
 
 const reverseSuffix = "_reverse"
 
+// ShardsForReads returns the source and target shards of a Reshard workflow
+// whose reads are switched when switching reads for a subset of the target
+// shards. The target shards must cover the key ranges of the source shards
+// they overlap, so that the switched key ranges are served by exactly one
+// set of shards.
+func ShardsForReads(sourceShards, targetShards []*topo.ShardInfo, shards []string) (readSources, readTargets []*topo.ShardInfo, err error) {
+	selected := sets.NewString(shards...)
+	unknown := sets.NewString(shards...)
+	for _, target := range targetShards {
+		if selected.Has(target.ShardName()) {
+			readTargets = append(readTargets, target)
+			unknown.Delete(target.ShardName())
+		}
+	}
+	if unknown.Len() > 0 {
+		return nil, nil, fmt.Errorf("shards %v are not target shards of the workflow", unknown.List())
+	}
+	for _, source := range sourceShards {
+		for _, target := range readTargets {
+			if key.KeyRangesIntersect(source.GetKeyRange(), target.GetKeyRange()) {
+				readSources = append(readSources, source)
+				break
+			}
+		}
+	}
+	for _, target := range targetShards {
+		if selected.Has(target.ShardName()) {
+			continue
+		}
+		for _, source := range readSources {
+			if key.KeyRangesIntersect(source.GetKeyRange(), target.GetKeyRange()) {
+				return nil, nil, fmt.Errorf("target shard %s also overlaps source shard %s, and must be switched with shards %s",
+					target.ShardName(), source.ShardName(), strings.Join(shards, ","))
+			}
+		}
+	}
+	return readSources, readTargets, nil
+}
+
 // ReverseWorkflowName returns the "reversed" name of a workflow. For a
 // "forward" workflow, this is the workflow name with "_reversed" appended, and
 // for a "reversed" workflow, this is the workflow name with the "_reversed"
@@ -17,11 +17,17 @@ limitations under the License.
 package workflow
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/topo"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 )
 
 type testTrafficSwitcher struct {
@@ -56,3 +62,63 @@ func TestReverseWorkflowName(t *testing.T) {
 		assert.Equal(t, test.out, got)
 	}
 }
+
+func TestShardsForReads(t *testing.T) {
+	newShards := func(names ...string) []*topo.ShardInfo {
+		var shards []*topo.ShardInfo
+		for _, name := range names {
+			keyRanges, err := key.ParseShardingSpec(name)
+			require.NoError(t, err)
+			shards = append(shards, topo.NewShardInfo("ks", name, &topodatapb.Shard{KeyRange: keyRanges[0]}, nil))
+		}
+		return shards
+	}
+	shardNames := func(shards []*topo.ShardInfo) []string {
+		var names []string
+		for _, shard := range shards {
+			names = append(names, shard.ShardName())
+		}
+		return names
+	}
+	sources := newShards("-80", "80-")
+	targets := newShards("-40", "40-80", "80-c0", "c0-")
+
+	tests := []struct {
+		shards      []string
+		wantSources []string
+		wantTargets []string
+		wantErr     string
+	}{
+		{
+			shards:      []string{"-40", "40-80"},
+			wantSources: []string{"-80"},
+			wantTargets: []string{"-40", "40-80"},
+		},
+		{
+			shards:      []string{"c0-", "80-c0", "-40", "40-80"},
+			wantSources: []string{"-80", "80-"},
+			wantTargets: []string{"-40", "40-80", "80-c0", "c0-"},
+		},
+		{
+			shards:  []string{"-40"},
+			wantErr: "target shard 40-80 also overlaps source shard -80",
+		},
+		{
+			shards:  []string{"-40", "40-80", "-80"},
+			wantErr: "shards [-80] are not target shards of the workflow",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.shards, ","), func(t *testing.T) {
+			readSources, readTargets, err := ShardsForReads(sources, targets, tt.shards)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSources, shardNames(readSources))
+			assert.Equal(t, tt.wantTargets, shardNames(readTargets))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtctld
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vtctl/grpcvtctldserver"
+
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+var backupRetentionInterval = flag.Duration("backup_retention_interval", 0, "if set, vtctld applies the backup retention policies of all shards at this interval, removing the backups they don't keep. The backups of each shard are removed under the shard lock, so several vtctlds can run with this flag.")
+
+// initBackupRetention starts applying the backup retention policies of all
+// shards in the background, if enabled.
+func initBackupRetention(ts *topo.Server) {
+	if *backupRetentionInterval <= 0 {
+		return
+	}
+
+	log.Infof("Applying backup retention policies every %v", *backupRetentionInterval)
+	go runBackupRetention(grpcvtctldserver.NewVtctldServer(ts), *backupRetentionInterval)
+}
+
+func runBackupRetention(vtctld *grpcvtctldserver.VtctldServer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		resp, err := vtctld.ApplyBackupRetention(ctx, &vtctldatapb.ApplyBackupRetentionRequest{})
+		cancel()
+		if err != nil {
+			log.Errorf("Failed to apply backup retention policies: %v", err)
+			continue
+		}
+
+		for _, bi := range resp.RemovedBackups {
+			log.Infof("Removed backup %v/%v/%v, as it is not kept by the backup retention policy", bi.Keyspace, bi.Shard, bi.Name)
+		}
+	}
+}
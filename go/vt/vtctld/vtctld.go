@@ -165,6 +165,9 @@ func InitVtctld(ts *topo.Server) error {
 	// Setup reverse proxy for all vttablets through /vttablet/.
 	initVTTabletRedirection(ts)
 
+	// Start enforcing backup retention policies, if enabled.
+	initBackupRetention(ts)
+
 	return nil
 }
 
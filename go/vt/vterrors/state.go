@@ -52,6 +52,8 @@ const (
 	CantDoThisInTransaction
 	RequiresPrimaryKey
 	OperandColumns
+	NoReferencedRow2
+	RowIsReferenced2
 
 	// not found
 	BadDb
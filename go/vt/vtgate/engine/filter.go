@@ -60,6 +60,7 @@ func (f *Filter) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[s
 		return nil, err
 	}
 	env := evalengine.EnvWithBindVars(bindVars, vcursor.ConnCollation())
+	env.TimeZone = sessionTimeZone(vcursor)
 	var rows [][]sqltypes.Value
 	env.Fields = result.Fields
 	for _, row := range result.Rows {
@@ -83,6 +84,7 @@ func (f *Filter) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[s
 // TryStreamExecute satisfies the Primitive interface.
 func (f *Filter) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
 	env := evalengine.EnvWithBindVars(bindVars, vcursor.ConnCollation())
+	env.TimeZone = sessionTimeZone(vcursor)
 	filter := func(results *sqltypes.Result) error {
 		var rows [][]sqltypes.Value
 		env.Fields = results.Fields
@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+var _ Primitive = (*FkCascade)(nil)
+
+// FkChild contains the primitive that enforces the action of a single
+// foreign key on the child table, for one row of the parent table.
+type FkChild struct {
+	// Cols are the offsets of the parent columns in the selection result.
+	// The value at Cols[i] is sent to Exec as the FkChildVarName(i) bind variable.
+	Cols []int
+	// Exec is a SELECT on the child table when Restrict is set,
+	// otherwise it's the DELETE or UPDATE that cascades the change.
+	Exec Primitive
+	// Restrict fails the query if Exec returns any rows.
+	Restrict bool
+	// Constraint describes the foreign key for error messages.
+	Constraint string
+}
+
+// FkCascade is a primitive that enforces the foreign keys referencing the table
+// modified by Parent. Cascading actions are executed before the parent itself
+// is modified, so the child rows can still be found using the old values.
+type FkCascade struct {
+	// Selection returns the referenced columns of the parent rows that will be modified.
+	Selection Primitive
+	// Children are executed in order for every row returned by Selection.
+	Children []*FkChild
+	// Parent is the DML on the parent table.
+	Parent Primitive
+
+	txNeeded
+}
+
+// FkChildVarName returns the name of the bind variable holding the value
+// of the parent column at the given position of the foreign key.
+func FkChildVarName(pos int) string {
+	return fmt.Sprintf("fkc_%d", pos)
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (fkc *FkCascade) RouteType() string {
+	return "FkCascade"
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (fkc *FkCascade) GetKeyspaceName() string {
+	return fkc.Parent.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (fkc *FkCascade) GetTableName() string {
+	return fkc.Parent.GetTableName()
+}
+
+// GetFields fetches the field info.
+func (fkc *FkCascade) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "[BUG] GetFields should not be called for FkCascade")
+}
+
+// TryExecute performs a non-streaming exec.
+func (fkc *FkCascade) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	selectionRes, err := vcursor.ExecutePrimitive(ctx, fkc.Selection, bindVars, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range selectionRes.Rows {
+		for _, child := range fkc.Children {
+			childVars, ok := child.bindVars(row)
+			if !ok {
+				// a NULL in any of the columns means that no child row references this row
+				continue
+			}
+			qr, err := vcursor.ExecutePrimitive(ctx, child.Exec, combineVars(bindVars, childVars), false)
+			if err != nil {
+				return nil, err
+			}
+			if child.Restrict && len(qr.Rows) > 0 {
+				return nil, vterrors.NewErrorf(vtrpcpb.Code_FAILED_PRECONDITION, vterrors.RowIsReferenced2, "Cannot delete or update a parent row: a foreign key constraint fails (%s)", child.Constraint)
+			}
+		}
+	}
+	return vcursor.ExecutePrimitive(ctx, fkc.Parent, bindVars, wantfields)
+}
+
+// TryStreamExecute performs a streaming exec.
+func (fkc *FkCascade) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	res, err := fkc.TryExecute(ctx, vcursor, bindVars, wantfields)
+	if err != nil {
+		return err
+	}
+	return callback(res)
+}
+
+// Inputs implements the Primitive interface.
+func (fkc *FkCascade) Inputs() []Primitive {
+	inputs := []Primitive{fkc.Selection}
+	for _, child := range fkc.Children {
+		inputs = append(inputs, child.Exec)
+	}
+	return append(inputs, fkc.Parent)
+}
+
+func (fkc *FkCascade) description() PrimitiveDescription {
+	var children []string
+	for _, child := range fkc.Children {
+		action := "Cascade"
+		if child.Restrict {
+			action = "Restrict"
+		}
+		children = append(children, fmt.Sprintf("%s %s", action, child.Constraint))
+	}
+	return PrimitiveDescription{
+		OperatorType: "FkCascade",
+		Other: map[string]any{
+			"Children": children,
+		},
+	}
+}
+
+func (child *FkChild) bindVars(row []sqltypes.Value) (map[string]*querypb.BindVariable, bool) {
+	bindVars := make(map[string]*querypb.BindVariable, len(child.Cols))
+	for i, col := range child.Cols {
+		if row[col].IsNull() {
+			return nil, false
+		}
+		bindVars[FkChildVarName(i)] = sqltypes.ValueBindVariable(row[col])
+	}
+	return bindVars, true
+}
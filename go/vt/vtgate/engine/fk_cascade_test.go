@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func TestFkCascadeExecute(t *testing.T) {
+	selection := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("id|a", "int64|int64"),
+			"1|10",
+			"2|null",
+		)},
+	}
+	restrict := &fakePrimitive{
+		results: []*sqltypes.Result{{}, {}},
+	}
+	cascade := &fakePrimitive{
+		results: []*sqltypes.Result{{RowsAffected: 1}},
+	}
+	parent := &fakePrimitive{
+		results: []*sqltypes.Result{{RowsAffected: 2}},
+	}
+	fkc := &FkCascade{
+		Selection: selection,
+		Children: []*FkChild{
+			{Cols: []int{0}, Exec: restrict, Restrict: true, Constraint: "fk_restrict"},
+			{Cols: []int{0, 1}, Exec: cascade, Constraint: "fk_cascade"},
+		},
+		Parent: parent,
+	}
+
+	bv := map[string]*querypb.BindVariable{"a": sqltypes.Int64BindVariable(1)}
+	qr, err := fkc.TryExecute(context.Background(), &noopVCursor{}, bv, true)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, qr.RowsAffected)
+
+	selection.ExpectLog(t, []string{`Execute a: type:INT64 value:"1" false`})
+	restrict.ExpectLog(t, []string{
+		`Execute a: type:INT64 value:"1" fkc_0: type:INT64 value:"1" false`,
+		`Execute a: type:INT64 value:"1" fkc_0: type:INT64 value:"2" false`,
+	})
+	// the second parent row has a NULL value, so it can't be referenced through fk_cascade
+	cascade.ExpectLog(t, []string{`Execute a: type:INT64 value:"1" fkc_0: type:INT64 value:"1" fkc_1: type:INT64 value:"10" false`})
+	parent.ExpectLog(t, []string{`Execute a: type:INT64 value:"1" true`})
+}
+
+func TestFkCascadeRestrictFails(t *testing.T) {
+	selection := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("id", "int64"),
+			"1",
+		)},
+	}
+	restrict := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("1", "int64"),
+			"1",
+		)},
+	}
+	parent := &fakePrimitive{}
+	fkc := &FkCascade{
+		Selection: selection,
+		Children:  []*FkChild{{Cols: []int{0}, Exec: restrict, Restrict: true, Constraint: "fk_restrict"}},
+		Parent:    parent,
+	}
+
+	_, err := fkc.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.EqualError(t, err, "Cannot delete or update a parent row: a foreign key constraint fails (fk_restrict)")
+	parent.ExpectLog(t, nil)
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*FkVerify)(nil)
+
+// FkParent checks that the parent rows referenced by the values
+// written to the child table exist.
+type FkParent struct {
+	// Values contains, for every row written to the child table,
+	// the values of the foreign key columns. The value at Values[row][i]
+	// is sent to Exec as the FkParentVarName(i) bind variable.
+	Values [][]evalengine.Expr
+	// Exec is a SELECT on the parent table that must return a row.
+	Exec Primitive
+	// Constraint describes the foreign key for error messages.
+	Constraint string
+}
+
+// FkVerify is a primitive that verifies the foreign keys
+// of the child table before Exec modifies it.
+type FkVerify struct {
+	Verify []*FkParent
+	// Exec is the DML on the child table.
+	Exec Primitive
+
+	txNeeded
+}
+
+// FkParentVarName returns the name of the bind variable holding the value
+// of the child column at the given position of the foreign key.
+func FkParentVarName(pos int) string {
+	return fmt.Sprintf("fkp_%d", pos)
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (fkv *FkVerify) RouteType() string {
+	return "FkVerify"
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (fkv *FkVerify) GetKeyspaceName() string {
+	return fkv.Exec.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (fkv *FkVerify) GetTableName() string {
+	return fkv.Exec.GetTableName()
+}
+
+// GetFields fetches the field info.
+func (fkv *FkVerify) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "[BUG] GetFields should not be called for FkVerify")
+}
+
+// TryExecute performs a non-streaming exec.
+func (fkv *FkVerify) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	env := evalengine.EnvWithBindVars(bindVars, vcursor.ConnCollation())
+	for _, parent := range fkv.Verify {
+		for _, row := range parent.Values {
+			parentVars, ok, err := parent.bindVars(env, row)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				// a NULL in any of the columns means that the row does not reference the parent
+				continue
+			}
+			qr, err := vcursor.ExecutePrimitive(ctx, parent.Exec, combineVars(bindVars, parentVars), false)
+			if err != nil {
+				return nil, err
+			}
+			if len(qr.Rows) == 0 {
+				return nil, vterrors.NewErrorf(vtrpcpb.Code_FAILED_PRECONDITION, vterrors.NoReferencedRow2, "Cannot add or update a child row: a foreign key constraint fails (%s)", parent.Constraint)
+			}
+		}
+	}
+	return vcursor.ExecutePrimitive(ctx, fkv.Exec, bindVars, wantfields)
+}
+
+// TryStreamExecute performs a streaming exec.
+func (fkv *FkVerify) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	res, err := fkv.TryExecute(ctx, vcursor, bindVars, wantfields)
+	if err != nil {
+		return err
+	}
+	return callback(res)
+}
+
+// Inputs implements the Primitive interface.
+func (fkv *FkVerify) Inputs() []Primitive {
+	var inputs []Primitive
+	for _, parent := range fkv.Verify {
+		inputs = append(inputs, parent.Exec)
+	}
+	return append(inputs, fkv.Exec)
+}
+
+func (fkv *FkVerify) description() PrimitiveDescription {
+	var parents []string
+	for _, parent := range fkv.Verify {
+		parents = append(parents, parent.Constraint)
+	}
+	return PrimitiveDescription{
+		OperatorType: "FkVerify",
+		Other: map[string]any{
+			"Parents": parents,
+		},
+	}
+}
+
+func (parent *FkParent) bindVars(env *evalengine.ExpressionEnv, row []evalengine.Expr) (map[string]*querypb.BindVariable, bool, error) {
+	bindVars := make(map[string]*querypb.BindVariable, len(row))
+	for i, expr := range row {
+		res, err := env.Evaluate(expr)
+		if err != nil {
+			return nil, false, err
+		}
+		val := res.Value()
+		if val.IsNull() {
+			return nil, false, nil
+		}
+		bindVars[FkParentVarName(i)] = sqltypes.ValueBindVariable(val)
+	}
+	return bindVars, true, nil
+}
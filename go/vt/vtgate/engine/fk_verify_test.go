@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func TestFkVerifyExecute(t *testing.T) {
+	parentCheck := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("1", "int64"),
+			"1",
+		)},
+	}
+	child := &fakePrimitive{
+		results: []*sqltypes.Result{{RowsAffected: 2}},
+	}
+	fkv := &FkVerify{
+		Verify: []*FkParent{{
+			Values: [][]evalengine.Expr{
+				{evalengine.NewBindVar("v1", collations.TypedCollation{})},
+				{evalengine.NullExpr},
+			},
+			Exec:       parentCheck,
+			Constraint: "fk_parent",
+		}},
+		Exec: child,
+	}
+
+	bv := map[string]*querypb.BindVariable{"v1": sqltypes.Int64BindVariable(1)}
+	qr, err := fkv.TryExecute(context.Background(), &noopVCursor{}, bv, false)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, qr.RowsAffected)
+
+	// the second row has a NULL value, so it does not need a parent row
+	parentCheck.ExpectLog(t, []string{`Execute fkp_0: type:INT64 value:"1" v1: type:INT64 value:"1" false`})
+	child.ExpectLog(t, []string{`Execute v1: type:INT64 value:"1" false`})
+}
+
+func TestFkVerifyMissingParent(t *testing.T) {
+	child := &fakePrimitive{}
+	fkv := &FkVerify{
+		Verify: []*FkParent{{
+			Values:     [][]evalengine.Expr{{evalengine.NewLiteralInt(1)}},
+			Exec:       &fakePrimitive{results: []*sqltypes.Result{{}}},
+			Constraint: "fk_parent",
+		}},
+		Exec: child,
+	}
+
+	_, err := fkv.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+	require.EqualError(t, err, "Cannot add or update a child row: a foreign key constraint fails (fk_parent)")
+	child.ExpectLog(t, nil)
+}
@@ -40,6 +40,10 @@ type MStream struct {
 	// TableName specifies the table on which stream will be executed.
 	TableName string
 
+	// ConsumerGroup specifies the consumer group to stream messages for.
+	// The default group is empty.
+	ConsumerGroup string
+
 	noTxNeeded
 
 	noInputs
@@ -71,7 +75,7 @@ func (m *MStream) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVar
 	if err != nil {
 		return err
 	}
-	return vcursor.MessageStream(ctx, rss, m.TableName, callback)
+	return vcursor.MessageStream(ctx, rss, m.TableName, m.ConsumerGroup, callback)
 }
 
 // GetFields implements the Primitive interface
@@ -85,6 +89,9 @@ func (m *MStream) description() PrimitiveDescription {
 		Keyspace:          m.Keyspace,
 		TargetDestination: m.TargetDestination,
 
-		Other: map[string]any{"Table": m.TableName},
+		Other: map[string]any{
+			"Table":         m.TableName,
+			"ConsumerGroup": m.ConsumerGroup,
+		},
 	}
 }
@@ -88,6 +88,11 @@ func (v *OnlineDDL) TryExecute(ctx context.Context, vcursor VCursor, bindVars ma
 	if err != nil {
 		return result, err
 	}
+	dryRun := v.DDLStrategySetting.IsDryRun()
+	if dryRun {
+		// Nothing is submitted in a dry run. The tablets return their estimates of the migrations instead.
+		result = &sqltypes.Result{}
+	}
 	for _, onlineDDL := range onlineDDLs {
 		// Go directly to tablets, much like Send primitive does
 		s := Send{
@@ -97,9 +102,15 @@ func (v *OnlineDDL) TryExecute(ctx context.Context, vcursor VCursor, bindVars ma
 			IsDML:             false,
 			SingleShardOnly:   false,
 		}
-		if _, err := vcursor.ExecutePrimitive(ctx, &s, bindVars, wantfields); err != nil {
+		qr, err := vcursor.ExecutePrimitive(ctx, &s, bindVars, wantfields)
+		if err != nil {
 			return result, err
 		}
+		if dryRun {
+			result.Fields = qr.Fields
+			result.Rows = append(result.Rows, qr.Rows...)
+			continue
+		}
 		result.Rows = append(result.Rows, []sqltypes.Value{
 			sqltypes.NewVarChar(onlineDDL.UUID),
 		})
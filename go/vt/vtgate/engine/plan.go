@@ -38,6 +38,14 @@ type Plan struct {
 	Warnings     []*query.QueryWarning   // Warnings that need to be yielded every time this query runs
 	TablesUsed   []string                // TablesUsed is the list of tables that this plan will query
 
+	// ResultCacheTTL is set when the query asks for its result to be cached
+	// by vtgate, with the RESULT_CACHE_TTL directive.
+	ResultCacheTTL time.Duration
+	// Nondeterministic is set for the SELECTs whose result can change even if
+	// the tables they read don't, e.g. because they call NOW() or RAND().
+	// Their result is never cached.
+	Nondeterministic bool
+
 	ExecCount    uint64 // Count of times this plan was executed
 	ExecTime     uint64 // Total execution time
 	ShardQueries uint64 // Total number of shard queries
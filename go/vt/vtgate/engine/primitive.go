@@ -18,12 +18,15 @@ package engine
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/key"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/srvtopo"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
@@ -95,7 +98,7 @@ type (
 		// KeyspaceAvailable returns true when a keyspace is visible from vtgate
 		KeyspaceAvailable(ks string) bool
 
-		MessageStream(ctx context.Context, rss []*srvtopo.ResolvedShard, tableName string, callback func(*sqltypes.Result) error) error
+		MessageStream(ctx context.Context, rss []*srvtopo.ResolvedShard, tableName, group string, callback func(*sqltypes.Result) error) error
 
 		VStream(ctx context.Context, rss []*srvtopo.ResolvedShard, filter *binlogdatapb.Filter, gtid string, callback func(evs []*binlogdatapb.VEvent) error) error
 
@@ -242,6 +245,19 @@ func Exists(m Match, p Primitive) bool {
 	return Find(m, p) != nil
 }
 
+// sessionTimeZone returns the time zone set with @@time_zone in the session, which is
+// used by the temporal functions evaluated in vtgate. It returns nil if the session
+// didn't set a time zone, or if vtgate doesn't know it.
+func sessionTimeZone(vcursor VCursor) *time.Location {
+	var tz *time.Location
+	vcursor.Session().GetSystemVariables(func(k string, v string) {
+		if k == "time_zone" {
+			tz, _ = evalengine.ParseTimeZone(strings.Trim(v, "'"))
+		}
+	})
+	return tz
+}
+
 // Inputs implements no inputs
 func (noInputs) Inputs() []Primitive {
 	return nil
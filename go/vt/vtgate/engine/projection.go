@@ -58,6 +58,7 @@ func (p *Projection) TryExecute(ctx context.Context, vcursor VCursor, bindVars m
 	}
 
 	env := evalengine.EnvWithBindVars(bindVars, vcursor.ConnCollation())
+	env.TimeZone = sessionTimeZone(vcursor)
 	env.Fields = result.Fields
 	var resultRows []sqltypes.Row
 	for _, row := range result.Rows {
@@ -85,6 +86,7 @@ func (p *Projection) TryExecute(ctx context.Context, vcursor VCursor, bindVars m
 // TryStreamExecute implements the Primitive interface
 func (p *Projection) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
 	env := evalengine.EnvWithBindVars(bindVars, vcursor.ConnCollation())
+	env.TimeZone = sessionTimeZone(vcursor)
 	var once sync.Once
 	var fields []*querypb.Field
 	return vcursor.StreamExecutePrimitive(ctx, p.Input, bindVars, wantfields, func(qr *sqltypes.Result) error {
@@ -133,6 +135,7 @@ func (p *Projection) GetFields(ctx context.Context, vcursor VCursor, bindVars ma
 		return nil, err
 	}
 	env := evalengine.EnvWithBindVars(bindVars, vcursor.ConnCollation())
+	env.TimeZone = sessionTimeZone(vcursor)
 	err = p.addFields(env, qr)
 	if err != nil {
 		return nil, err
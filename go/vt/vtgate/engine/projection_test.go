@@ -70,6 +70,24 @@ func TestMultiply(t *testing.T) {
 	assert.Equal(t, "[[UINT64(6)] [UINT64(0)] [UINT64(2)]]", fmt.Sprintf("%v", qr.Rows))
 }
 
+func TestProjectionSessionTimeZone(t *testing.T) {
+	expr, err := sqlparser.ParseExpr("unix_timestamp('2022-10-03 05:30:00')")
+	require.NoError(t, err)
+	evalExpr, err := evalengine.Translate(expr, nil)
+	require.NoError(t, err)
+	proj := &Projection{
+		Cols:  []string{"ts"},
+		Exprs: []evalengine.Expr{evalExpr},
+		Input: &fakePrimitive{
+			results: []*sqltypes.Result{sqltypes.MakeTestResult(sqltypes.MakeTestFields("a", "int64"), "1")},
+		},
+	}
+	vc := &loggingVCursor{systemVariables: map[string]string{"time_zone": "'+05:30'"}}
+	qr, err := proj.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, "[[INT64(1664755200)]]", fmt.Sprintf("%v", qr.Rows))
+}
+
 func TestEmptyInput(t *testing.T) {
 	expr := &sqlparser.BinaryExpr{
 		Operator: sqlparser.MultOp,
@@ -0,0 +1,548 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*Window)(nil)
+
+// Window is a primitive that evaluates window functions on vtgate.
+// The input must be sorted on the PartitionBy columns followed by the
+// OrderBy columns. The planner guarantees this either by pushing the
+// ordering down to the route, so that the sorted shard streams are merged,
+// or by planning a memory sort below this primitive.
+type Window struct {
+	// PartitionBy are the columns that split the input into partitions.
+	PartitionBy []OrderByParams
+	// OrderBy is the ordering inside a partition. It is used to find
+	// peer rows when computing RANK and DENSE_RANK, and the frame of the
+	// aggregate functions.
+	OrderBy   []OrderByParams
+	Functions []*WindowFunction
+
+	// Cols defines the output columns of the primitive. A non-negative value
+	// is a column number of the input, and a negative value -n refers to
+	// the result of Functions[n-1].
+	Cols  []int
+	Input Primitive
+}
+
+// WindowFunction specifies the parameters for a single window function.
+type WindowFunction struct {
+	Opcode WindowOpcode
+
+	// Col is the input column that LAG, LEAD and the aggregate functions
+	// read their value from.
+	Col int
+	// CollationID is the collation MIN and MAX compare their values with.
+	CollationID collations.ID
+	// Offset is the number of rows LAG and LEAD look behind or ahead.
+	// A nil Offset means 1.
+	Offset evalengine.Expr
+	// Default is the value LAG and LEAD return when the offset points
+	// outside of the partition. A nil Default means NULL.
+	Default evalengine.Expr
+
+	Alias string `json:",omitempty"`
+}
+
+// WindowOpcode is the window function Opcode.
+type WindowOpcode int
+
+// These constants list the possible window function opcodes.
+const (
+	WindowUnassigned = WindowOpcode(iota)
+	WindowRowNumber
+	WindowRank
+	WindowDenseRank
+	WindowLag
+	WindowLead
+	WindowCount
+	WindowCountStar
+	WindowSum
+	WindowAvg
+	WindowMin
+	WindowMax
+)
+
+// SupportedWindowFunctions maps the list of window functions
+// that can be evaluated on vtgate to their opcodes.
+var SupportedWindowFunctions = map[string]WindowOpcode{
+	"row_number": WindowRowNumber,
+	"rank":       WindowRank,
+	"dense_rank": WindowDenseRank,
+	"lag":        WindowLag,
+	"lead":       WindowLead,
+	"count":      WindowCount,
+	"count_star": WindowCountStar,
+	"sum":        WindowSum,
+	"avg":        WindowAvg,
+	"min":        WindowMin,
+	"max":        WindowMax,
+}
+
+func (code WindowOpcode) String() string {
+	for k, v := range SupportedWindowFunctions {
+		if v == code {
+			return k
+		}
+	}
+	return "ERROR"
+}
+
+// MarshalJSON serializes the WindowOpcode as a JSON string.
+// It's used for testing and diagnostics.
+func (code WindowOpcode) MarshalJSON() ([]byte, error) {
+	return ([]byte)(fmt.Sprintf("\"%s\"", code.String())), nil
+}
+
+func (wf *WindowFunction) isLagOrLead() bool {
+	return wf.Opcode == WindowLag || wf.Opcode == WindowLead
+}
+
+// isAggregate returns true for the aggregate functions, which are computed
+// over the frame of the row: the rows of the partition up to the last peer
+// of the row, or the whole partition if there is no ORDER BY.
+func (wf *WindowFunction) isAggregate() bool {
+	switch wf.Opcode {
+	case WindowCount, WindowCountStar, WindowSum, WindowAvg, WindowMin, WindowMax:
+		return true
+	}
+	return false
+}
+
+// String returns a string. Used for plan descriptions
+func (wf *WindowFunction) String() string {
+	var args string
+	if wf.isAggregate() && wf.Opcode != WindowCountStar {
+		args = strconv.Itoa(wf.Col)
+	}
+	if wf.isLagOrLead() {
+		args = strconv.Itoa(wf.Col)
+		if wf.Offset != nil {
+			args += ", " + evalengine.FormatExpr(wf.Offset)
+		}
+		if wf.Default != nil {
+			args += ", " + evalengine.FormatExpr(wf.Default)
+		}
+	}
+	if wf.Alias != "" {
+		return fmt.Sprintf("%s(%s) AS %s", wf.Opcode.String(), args, wf.Alias)
+	}
+	return fmt.Sprintf("%s(%s)", wf.Opcode.String(), args)
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (w *Window) RouteType() string {
+	return w.Input.RouteType()
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (w *Window) GetKeyspaceName() string {
+	return w.Input.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (w *Window) GetTableName() string {
+	return w.Input.GetTableName()
+}
+
+// TryExecute implements the Primitive interface
+func (w *Window) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	params, err := w.evalParams(vcursor, bindVars)
+	if err != nil {
+		return nil, err
+	}
+
+	qr, err := vcursor.ExecutePrimitive(ctx, w.Input, bindVars, wantfields)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &sqltypes.Result{
+		Fields: w.buildFields(qr.Fields),
+		Rows:   make([][]sqltypes.Value, 0, len(qr.Rows)),
+	}
+	partition := extractSlices(w.PartitionBy)
+	order := extractSlices(w.OrderBy)
+
+	start := 0
+	for i := 1; i <= len(qr.Rows); i++ {
+		if i < len(qr.Rows) {
+			same, err := rowsEqual(partition, qr.Rows[i-1], qr.Rows[i])
+			if err != nil {
+				return nil, err
+			}
+			if same {
+				continue
+			}
+		}
+		rows, err := w.evalPartition(qr.Rows[start:i], order, params)
+		if err != nil {
+			return nil, err
+		}
+		result.Rows = append(result.Rows, rows...)
+		start = i
+	}
+	return result, nil
+}
+
+// TryStreamExecute implements the Primitive interface
+func (w *Window) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	params, err := w.evalParams(vcursor, bindVars)
+	if err != nil {
+		return err
+	}
+
+	partition := extractSlices(w.PartitionBy)
+	order := extractSlices(w.OrderBy)
+
+	// current holds the rows of the partition we are reading. Since LEAD needs
+	// to look ahead, a partition is only sent once we have seen all of its rows.
+	var current [][]sqltypes.Value
+	flush := func() error {
+		if len(current) == 0 {
+			return nil
+		}
+		rows, err := w.evalPartition(current, order, params)
+		if err != nil {
+			return err
+		}
+		current = nil
+		return callback(&sqltypes.Result{Rows: rows})
+	}
+
+	err = vcursor.StreamExecutePrimitive(ctx, w.Input, bindVars, wantfields, func(qr *sqltypes.Result) error {
+		if len(qr.Fields) != 0 {
+			if err := callback(&sqltypes.Result{Fields: w.buildFields(qr.Fields)}); err != nil {
+				return err
+			}
+		}
+		for _, row := range qr.Rows {
+			if len(current) > 0 {
+				same, err := rowsEqual(partition, current[len(current)-1], row)
+				if err != nil {
+					return err
+				}
+				if !same {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+			current = append(current, row)
+		}
+		if vcursor.ExceedsMaxMemoryRows(len(current)) {
+			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// GetFields implements the Primitive interface
+func (w *Window) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	qr, err := w.Input.GetFields(ctx, vcursor, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	return &sqltypes.Result{Fields: w.buildFields(qr.Fields)}, nil
+}
+
+// NeedsTransaction implements the Primitive interface
+func (w *Window) NeedsTransaction() bool {
+	return w.Input.NeedsTransaction()
+}
+
+// Inputs implements the Primitive interface
+func (w *Window) Inputs() []Primitive {
+	return []Primitive{w.Input}
+}
+
+// windowParams holds the per execution arguments of the window functions.
+type windowParams struct {
+	offsets  []int
+	defaults []sqltypes.Value
+}
+
+// evalParams evaluates the offsets and default values of LAG and LEAD once per execution.
+func (w *Window) evalParams(vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*windowParams, error) {
+	params := &windowParams{
+		offsets:  make([]int, len(w.Functions)),
+		defaults: make([]sqltypes.Value, len(w.Functions)),
+	}
+	env := evalengine.EnvWithBindVars(bindVars, vcursor.ConnCollation())
+	for i, fn := range w.Functions {
+		params.offsets[i] = 1
+		if fn.Offset != nil {
+			res, err := env.Evaluate(fn.Offset)
+			if err != nil {
+				return nil, err
+			}
+			offset, err := res.Value().ToInt64()
+			if err != nil {
+				return nil, err
+			}
+			if offset < 0 {
+				return nil, fmt.Errorf("incorrect arguments to %s", fn.Opcode.String())
+			}
+			params.offsets[i] = int(offset)
+		}
+		if fn.Default != nil {
+			res, err := env.Evaluate(fn.Default)
+			if err != nil {
+				return nil, err
+			}
+			params.defaults[i] = res.Value()
+		}
+	}
+	return params, nil
+}
+
+// evalPartition computes the window functions for all the rows of a single partition,
+// and returns the projected output rows.
+func (w *Window) evalPartition(rows [][]sqltypes.Value, order []*comparer, params *windowParams) ([][]sqltypes.Value, error) {
+	aggregates, err := w.evalAggregates(rows, order)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]sqltypes.Value, 0, len(rows))
+	rank, denseRank := 1, 1
+	values := make([]sqltypes.Value, len(w.Functions))
+	for i, row := range rows {
+		if i > 0 {
+			peer, err := rowsEqual(order, rows[i-1], row)
+			if err != nil {
+				return nil, err
+			}
+			if !peer {
+				rank = i + 1
+				denseRank++
+			}
+		}
+		for f, fn := range w.Functions {
+			switch fn.Opcode {
+			case WindowRowNumber:
+				values[f] = sqltypes.NewUint64(uint64(i + 1))
+			case WindowRank:
+				values[f] = sqltypes.NewUint64(uint64(rank))
+			case WindowDenseRank:
+				values[f] = sqltypes.NewUint64(uint64(denseRank))
+			case WindowLag, WindowLead:
+				idx := i - params.offsets[f]
+				if fn.Opcode == WindowLead {
+					idx = i + params.offsets[f]
+				}
+				if idx >= 0 && idx < len(rows) {
+					values[f] = rows[idx][fn.Col]
+				} else {
+					values[f] = params.defaults[f]
+				}
+			case WindowCount, WindowCountStar, WindowSum, WindowAvg, WindowMin, WindowMax:
+				values[f] = aggregates[f][i]
+			default:
+				return nil, fmt.Errorf("unsupported window function: %s", fn.Opcode.String())
+			}
+		}
+		out = append(out, w.project(row, values))
+	}
+	return out, nil
+}
+
+// evalAggregates computes the aggregate functions for all the rows of a single partition.
+// The rows of a peer group share the same frame, so the functions are computed once per
+// peer group. Without ORDER BY, all the rows of the partition are peers.
+func (w *Window) evalAggregates(rows [][]sqltypes.Value, order []*comparer) ([][]sqltypes.Value, error) {
+	results := make([][]sqltypes.Value, len(w.Functions))
+	states := make([]windowAggregate, len(w.Functions))
+	for f, fn := range w.Functions {
+		if fn.isAggregate() {
+			results[f] = make([]sqltypes.Value, len(rows))
+		}
+	}
+
+	start := 0
+	for i := 1; i <= len(rows); i++ {
+		if i < len(rows) {
+			peer, err := rowsEqual(order, rows[i-1], rows[i])
+			if err != nil {
+				return nil, err
+			}
+			if peer {
+				continue
+			}
+		}
+		for f, fn := range w.Functions {
+			if results[f] == nil {
+				continue
+			}
+			for _, row := range rows[start:i] {
+				if err := states[f].add(fn, row); err != nil {
+					return nil, err
+				}
+			}
+			value, err := states[f].result(fn)
+			if err != nil {
+				return nil, err
+			}
+			for j := start; j < i; j++ {
+				results[f][j] = value
+			}
+		}
+		start = i
+	}
+	return results, nil
+}
+
+// windowAggregate is the running state of an aggregate function over a partition.
+type windowAggregate struct {
+	// count is the number of rows for COUNT(*), and the number of non NULL values otherwise.
+	count int64
+	// value is the sum, the minimum or the maximum of the non NULL values.
+	value sqltypes.Value
+}
+
+func (wa *windowAggregate) add(fn *WindowFunction, row []sqltypes.Value) error {
+	if fn.Opcode == WindowCountStar {
+		wa.count++
+		return nil
+	}
+	v := row[fn.Col]
+	if v.IsNull() {
+		return nil
+	}
+	wa.count++
+
+	var err error
+	switch fn.Opcode {
+	case WindowSum, WindowAvg:
+		wa.value, err = evalengine.NullSafeAdd(wa.value, v, sqltypes.Decimal)
+	case WindowMin:
+		wa.value, err = evalengine.Min(wa.value, v, fn.CollationID)
+	case WindowMax:
+		wa.value, err = evalengine.Max(wa.value, v, fn.CollationID)
+	}
+	return err
+}
+
+func (wa *windowAggregate) result(fn *WindowFunction) (sqltypes.Value, error) {
+	switch fn.Opcode {
+	case WindowCount, WindowCountStar:
+		return sqltypes.NewInt64(wa.count), nil
+	case WindowAvg:
+		if wa.count == 0 {
+			return sqltypes.NULL, nil
+		}
+		return evalengine.Divide(wa.value, sqltypes.NewInt64(wa.count))
+	}
+	// SUM, MIN and MAX are NULL if there are no values.
+	return wa.value, nil
+}
+
+func (w *Window) project(row, values []sqltypes.Value) []sqltypes.Value {
+	out := make([]sqltypes.Value, 0, len(w.Cols))
+	for _, col := range w.Cols {
+		if col < 0 {
+			out = append(out, values[-col-1])
+			continue
+		}
+		out = append(out, row[col])
+	}
+	return out
+}
+
+func (w *Window) buildFields(fields []*querypb.Field) []*querypb.Field {
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make([]*querypb.Field, 0, len(w.Cols))
+	for _, col := range w.Cols {
+		if col >= 0 {
+			out = append(out, fields[col])
+			continue
+		}
+		fn := w.Functions[-col-1]
+		typ := sqltypes.Uint64
+		switch fn.Opcode {
+		case WindowLag, WindowLead, WindowMin, WindowMax:
+			typ = fields[fn.Col].Type
+		case WindowCount, WindowCountStar:
+			typ = sqltypes.Int64
+		case WindowSum, WindowAvg:
+			typ = sqltypes.Decimal
+		}
+		out = append(out, &querypb.Field{
+			Name: fn.Alias,
+			Type: typ,
+		})
+	}
+	return out
+}
+
+// rowsEqual returns true if the two rows compare as equal on all the given columns
+func rowsEqual(comparers []*comparer, row1, row2 []sqltypes.Value) (bool, error) {
+	for _, c := range comparers {
+		cmp, err := c.compare(row1, row2)
+		if err != nil {
+			return false, err
+		}
+		if cmp != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (w *Window) description() PrimitiveDescription {
+	other := map[string]any{
+		"Functions": GenericJoin(w.Functions, func(i any) string {
+			return i.(*WindowFunction).String()
+		}),
+	}
+	if len(w.PartitionBy) > 0 {
+		other["PartitionBy"] = GenericJoin(w.PartitionBy, orderByParamsToString)
+	}
+	if len(w.OrderBy) > 0 {
+		other["OrderBy"] = GenericJoin(w.OrderBy, orderByParamsToString)
+	}
+	cols := make([]string, 0, len(w.Cols))
+	for _, col := range w.Cols {
+		if col < 0 {
+			cols = append(cols, "fn"+strconv.Itoa(-col))
+			continue
+		}
+		cols = append(cols, strconv.Itoa(col))
+	}
+	other["Columns"] = strings.Join(cols, ",")
+	return PrimitiveDescription{
+		OperatorType: "Window",
+		Other:        other,
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func newTestWindow(input Primitive) *Window {
+	return &Window{
+		PartitionBy: []OrderByParams{{Col: 0, WeightStringCol: -1}},
+		OrderBy:     []OrderByParams{{Col: 1, WeightStringCol: -1}},
+		Functions: []*WindowFunction{
+			{Opcode: WindowRowNumber, Alias: "rn"},
+			{Opcode: WindowRank, Alias: "rnk"},
+			{Opcode: WindowDenseRank, Alias: "drnk"},
+			{Opcode: WindowLag, Col: 2, Alias: "prev"},
+			{Opcode: WindowLead, Col: 2, Offset: evalengine.NewLiteralInt(1), Default: evalengine.NewLiteralString([]byte("x"), collations.TypedCollation{}), Alias: "next"},
+		},
+		Cols:  []int{0, 1, 2, -1, -2, -3, -4, -5},
+		Input: input,
+	}
+}
+
+func newTestWindowInput() *fakePrimitive {
+	return &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"grp|val|name",
+				"int64|int64|varchar",
+			),
+			"1|10|a",
+			"1|10|b",
+			"1|20|c",
+			"2|5|d",
+			"2|7|e",
+		)},
+	}
+}
+
+var windowTestResult = sqltypes.MakeTestResult(
+	sqltypes.MakeTestFields(
+		"grp|val|name|rn|rnk|drnk|prev|next",
+		"int64|int64|varchar|uint64|uint64|uint64|varchar|varchar",
+	),
+	"1|10|a|1|1|1|null|b",
+	"1|10|b|2|1|1|a|c",
+	"1|20|c|3|3|2|b|x",
+	"2|5|d|1|1|1|null|e",
+	"2|7|e|2|2|2|d|x",
+)
+
+func TestWindowExecute(t *testing.T) {
+	fp := newTestWindowInput()
+	w := newTestWindow(fp)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	require.Equal(t, windowTestResult, result)
+}
+
+func TestWindowStreamExecute(t *testing.T) {
+	fp := newTestWindowInput()
+	w := newTestWindow(fp)
+
+	var results []*sqltypes.Result
+	err := w.TryStreamExecute(context.Background(), &noopVCursor{}, nil, true, func(qr *sqltypes.Result) error {
+		results = append(results, qr)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// the fields are sent first, and then one result per partition
+	require.Len(t, results, 3)
+	require.Equal(t, windowTestResult.Fields, results[0].Fields)
+	require.Equal(t, windowTestResult.Rows[:3], results[1].Rows)
+	require.Equal(t, windowTestResult.Rows[3:], results[2].Rows)
+}
+
+func TestWindowWithoutPartition(t *testing.T) {
+	fp := newTestWindowInput()
+	w := newTestWindow(fp)
+	w.PartitionBy = nil
+	w.OrderBy = nil
+	w.Functions = w.Functions[:2]
+	w.Cols = []int{2, -1, -2}
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	// without an ORDER BY all the rows are peers of each other
+	require.Equal(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"name|rn|rnk",
+			"varchar|uint64|uint64",
+		),
+		"a|1|1",
+		"b|2|1",
+		"c|3|1",
+		"d|4|1",
+		"e|5|1",
+	), result)
+}
+
+func TestWindowAggregates(t *testing.T) {
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"grp|val|name",
+				"int64|int64|varchar",
+			),
+			"1|10|b",
+			"1|10|null",
+			"1|20|a",
+			"2|null|null",
+			"2|7|e",
+		)},
+	}
+	w := &Window{
+		PartitionBy: []OrderByParams{{Col: 0, WeightStringCol: -1}},
+		OrderBy:     []OrderByParams{{Col: 1, WeightStringCol: -1}},
+		Functions: []*WindowFunction{
+			{Opcode: WindowCountStar, Alias: "cnt_star"},
+			{Opcode: WindowCount, Col: 2, Alias: "cnt"},
+			{Opcode: WindowSum, Col: 1, Alias: "total"},
+			{Opcode: WindowAvg, Col: 1, Alias: "average"},
+			{Opcode: WindowMin, Col: 2, CollationID: collations.CollationUtf8mb4ID, Alias: "first"},
+			{Opcode: WindowMax, Col: 2, CollationID: collations.CollationUtf8mb4ID, Alias: "last"},
+		},
+		Cols:  []int{0, 1, -1, -2, -3, -4, -5, -6},
+		Input: fp,
+	}
+
+	// the frame of a row ends with its last peer
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	require.Equal(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"grp|val|cnt_star|cnt|total|average|first|last",
+			"int64|int64|int64|int64|decimal|decimal|varchar|varchar",
+		),
+		"1|10|2|1|20|10.0000|b|b",
+		"1|10|2|1|20|10.0000|b|b",
+		"1|20|3|2|40|13.3333|a|b",
+		"2|null|1|0|null|null|null|null",
+		"2|7|2|1|7|7.0000|e|e",
+	), result)
+
+	// without an ORDER BY the frame is the whole partition
+	w.OrderBy = nil
+	fp.rewind()
+	result, err = w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	require.Equal(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"grp|val|cnt_star|cnt|total|average|first|last",
+			"int64|int64|int64|int64|decimal|decimal|varchar|varchar",
+		),
+		"1|10|3|2|40|13.3333|a|b",
+		"1|10|3|2|40|13.3333|a|b",
+		"1|20|3|2|40|13.3333|a|b",
+		"2|null|2|1|7|7.0000|e|e",
+		"2|7|2|1|7|7.0000|e|e",
+	), result)
+}
+
+func TestWindowGetFields(t *testing.T) {
+	fp := newTestWindowInput()
+	w := newTestWindow(fp)
+
+	result, err := w.GetFields(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{})
+	require.NoError(t, err)
+	require.Equal(t, windowTestResult.Fields, result.Fields)
+}
@@ -18,6 +18,7 @@ package evalengine
 
 import (
 	"bytes"
+	"math"
 	"strconv"
 	"strings"
 
@@ -299,6 +300,70 @@ func divideNumericWithError(v1, v2 *EvalResult, precise bool, out *EvalResult) e
 	}
 }
 
+func moduloNumericWithError(v1, v2 *EvalResult, out *EvalResult) error {
+	v1.makeNumeric()
+	v2.makeNumeric()
+	switch t1, t2 := v1.typeof(), v2.typeof(); {
+	case t1 == sqltypes.Float64 || t2 == sqltypes.Float64:
+		v1f, err := v1.coerceToFloat()
+		if err != nil {
+			return err
+		}
+		v2f, err := v2.coerceToFloat()
+		if err != nil {
+			return err
+		}
+		if v2f == 0.0 {
+			out.setNull()
+			return nil
+		}
+		out.setFloat(math.Mod(v1f, v2f))
+	case t1 == sqltypes.Decimal || t2 == sqltypes.Decimal:
+		v1d := v1.coerceToDecimal()
+		v2d := v2.coerceToDecimal()
+		if v2d.IsZero() {
+			out.setNull()
+			return nil
+		}
+		out.setDecimal(v1d.Mod(v2d), maxprec(v1.length_, v2.length_))
+	case sqltypes.IsUnsigned(t1):
+		// the sign of the result is always the sign of the dividend
+		v2u := v2.uint64()
+		if sqltypes.IsSigned(t2) && v2.int64() < 0 {
+			v2u = uint64(-v2.int64())
+		}
+		if v2u == 0 {
+			out.setNull()
+			return nil
+		}
+		out.setUint64(v1.uint64() % v2u)
+	case sqltypes.IsUnsigned(t2):
+		v1i := v1.int64()
+		v1u := uint64(v1i)
+		if v1i < 0 {
+			v1u = uint64(-v1i)
+		}
+		v2u := v2.uint64()
+		if v2u == 0 {
+			out.setNull()
+			return nil
+		}
+		if v1i < 0 {
+			out.setInt64(-int64(v1u % v2u))
+		} else {
+			out.setInt64(int64(v1u % v2u))
+		}
+	default:
+		v1i, v2i := v1.int64(), v2.int64()
+		if v2i == 0 {
+			out.setNull()
+			return nil
+		}
+		out.setInt64(v1i % v2i)
+	}
+	return nil
+}
+
 // makeNumericAndPrioritize reorders the input parameters
 // to be Float64, Decimal, Uint64, Int64.
 func makeNumericAndPrioritize(i1, i2 *EvalResult) (*EvalResult, *EvalResult) {
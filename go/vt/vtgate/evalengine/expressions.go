@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"time"
 	"unicode/utf8"
 
 	"vitess.io/vitess/go/mysql/collations"
@@ -42,6 +43,13 @@ type (
 		// Row and Fields should line up
 		Row    []sqltypes.Value
 		Fields []*querypb.Field
+
+		// TimeZone is the time zone of the session, used by the temporal functions.
+		// The local time zone of the process is used if it's nil.
+		TimeZone *time.Location
+
+		// now is the current time for this evaluation; see currentTime
+		now time.Time
 	}
 
 	// Expr is the interface that all evaluating expressions must implement
@@ -30,18 +30,37 @@ import (
 )
 
 var builtinFunctions = map[string]builtin{
-	"coalesce":  builtinCoalesce{},
-	"greatest":  &builtinMultiComparison{name: "GREATEST", cmp: 1},
-	"least":     &builtinMultiComparison{name: "LEAST", cmp: -1},
-	"collation": builtinCollation{},
-	"bit_count": builtinBitCount{},
-	"hex":       builtinHex{},
-	"ceil":      builtinCeil{},
-	"ceiling":   builtinCeiling{},
+	"coalesce":     builtinCoalesce{},
+	"greatest":     &builtinMultiComparison{name: "GREATEST", cmp: 1},
+	"least":        &builtinMultiComparison{name: "LEAST", cmp: -1},
+	"collation":    builtinCollation{},
+	"bit_count":    builtinBitCount{},
+	"hex":          builtinHex{},
+	"ceil":         builtinCeil{},
+	"ceiling":      builtinCeiling{},
+	"floor":        builtinFloor{},
+	"round":        builtinRound{},
+	"abs":          builtinAbs{},
+	"pow":          builtinPow{},
+	"power":        builtinPow{},
+	"concat":       builtinConcat{},
+	"lower":        builtinChangeCase{upcase: false},
+	"lcase":        builtinChangeCase{upcase: false},
+	"upper":        builtinChangeCase{upcase: true},
+	"ucase":        builtinChangeCase{upcase: true},
+	"length":       builtinLength{},
+	"octet_length": builtinLength{},
+	"substring":    builtinSubstring{},
+	"substr":       builtinSubstring{},
+	"mid":          builtinSubstring{},
+	"replace":      builtinReplace{},
+	"date_format":  builtinDateFormat{},
 }
 
 var builtinFunctionsRewrite = map[string]builtinRewrite{
-	"isnull": builtinIsNullRewrite,
+	"isnull":         builtinIsNullRewrite,
+	"mod":            builtinModRewrite,
+	"unix_timestamp": builtinUnixTimestampRewrite,
 }
 
 type builtin interface {
@@ -49,6 +68,13 @@ type builtin interface {
 	typeof(*ExpressionEnv, []Expr) (sqltypes.Type, flag)
 }
 
+// nondeterministicBuiltin is implemented by the builtins that can return a
+// different value every time they are called, e.g. NOW(), or whose value depends
+// on the session. These calls are never simplified into constants.
+type nondeterministicBuiltin interface {
+	nondeterministic(args []Expr) bool
+}
+
 type builtinRewrite func([]Expr, TranslationLookup) (Expr, error)
 
 type CallExpr struct {
@@ -657,8 +683,10 @@ func (builtinCeil) call(env *ExpressionEnv, args []EvalResult, result *EvalResul
 		return
 	}
 
-	if sqltypes.IsIntegral(argtype) {
+	if sqltypes.IsSigned(argtype) {
 		result.setInt64(inarg.int64())
+	} else if sqltypes.IsUnsigned(argtype) {
+		result.setUint64(inarg.uint64())
 	} else if sqltypes.Decimal == argtype {
 		num := inarg.decimal()
 		num = num.Ceil()
@@ -679,13 +707,7 @@ func (builtinCeil) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag)
 		throwArgError("CEIL")
 	}
 	t, f := args[0].typeof(env)
-	if sqltypes.IsIntegral(t) {
-		return sqltypes.Int64, f
-	} else if sqltypes.Decimal == t {
-		return sqltypes.Decimal, f
-	} else {
-		return sqltypes.Float64, f
-	}
+	return numericResultType(t), nullFlags(f)
 }
 
 type builtinCeiling struct{}
@@ -698,8 +720,10 @@ func (builtinCeiling) call(env *ExpressionEnv, args []EvalResult, result *EvalRe
 		return
 	}
 
-	if sqltypes.IsIntegral(argtype) {
+	if sqltypes.IsSigned(argtype) {
 		result.setInt64(inarg.int64())
+	} else if sqltypes.IsUnsigned(argtype) {
+		result.setUint64(inarg.uint64())
 	} else if sqltypes.Decimal == argtype {
 		num := inarg.decimal()
 		num = num.Ceil()
@@ -708,3 +708,143 @@ func TestCeilandCeiling(t *testing.T) {
 		compareRemoteExpr(t, conn, fmt.Sprintf("CEILING(%s)", num))
 	}
 }
+
+func TestStringFunctions(t *testing.T) {
+	var conn = mysqlconn(t)
+	defer conn.Close()
+
+	var inputs = []string{
+		"'foobar'",
+		"'FOObar'",
+		"''",
+		"NULL",
+		"42",
+		"-1.5",
+		"_latin1 'ÁBCdé'",
+		"'ÁBCdé' COLLATE utf8mb4_bin",
+		"_binary 'fooBAR'",
+		"'  spaces  '",
+	}
+
+	for _, str := range inputs {
+		compareRemoteExpr(t, conn, fmt.Sprintf("CONCAT(%s)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("CONCAT(%s, 'suffix', 1)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("LOWER(%s)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("UPPER(%s)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("LENGTH(%s)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("TRIM(%s)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("LTRIM(%s)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("RTRIM(%s)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("TRIM(LEADING 'f' FROM %s)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("TRIM(TRAILING 'r' FROM %s)", str))
+		compareRemoteExpr(t, conn, fmt.Sprintf("REPLACE(%s, 'o', 'OO')", str))
+
+		for _, pos := range []string{"0", "1", "3", "-2", "100", "NULL"} {
+			compareRemoteExpr(t, conn, fmt.Sprintf("SUBSTRING(%s, %s)", str, pos))
+			compareRemoteExpr(t, conn, fmt.Sprintf("SUBSTRING(%s, %s, 2)", str, pos))
+		}
+	}
+}
+
+func TestMathFunctions(t *testing.T) {
+	var conn = mysqlconn(t)
+	defer conn.Close()
+
+	var inputs = []string{
+		"0",
+		"1",
+		"-1",
+		"'1.5'",
+		"NULL",
+		"'ABC'",
+		"1.5e0",
+		"-1.5e0",
+		"2.5e0",
+		"123.456",
+		"-123.456",
+		"18446744073709551615",
+		"9223372036854775807",
+	}
+
+	for _, num := range inputs {
+		compareRemoteExpr(t, conn, fmt.Sprintf("ROUND(%s)", num))
+		compareRemoteExpr(t, conn, fmt.Sprintf("ROUND(%s, 1)", num))
+		compareRemoteExpr(t, conn, fmt.Sprintf("ROUND(%s, -1)", num))
+		compareRemoteExpr(t, conn, fmt.Sprintf("FLOOR(%s)", num))
+		compareRemoteExpr(t, conn, fmt.Sprintf("ABS(%s)", num))
+		compareRemoteExpr(t, conn, fmt.Sprintf("POW(%s, 2)", num))
+
+		for _, div := range []string{"0", "3", "-3", "2.5", "1.5e0", "NULL"} {
+			compareRemoteExpr(t, conn, fmt.Sprintf("MOD(%s, %s)", num, div))
+			compareRemoteExpr(t, conn, fmt.Sprintf("%s %% %s", num, div))
+		}
+	}
+}
+
+func TestDateFunctions(t *testing.T) {
+	var conn = mysqlconn(t)
+	defer conn.Close()
+
+	var dates = []string{
+		"DATE'2022-10-03'",
+		"TIMESTAMP'2021-12-31 23:59:59'",
+		"'2020-02-29 12:30:01'",
+		"'2019-01-01'",
+		"20200101",
+		"NULL",
+		"'not a date'",
+	}
+
+	for _, date := range dates {
+		compareRemoteExpr(t, conn, fmt.Sprintf("DATE_FORMAT(%s, '%%a %%b %%c %%D %%d %%e %%f %%H %%h %%I %%i %%j %%k %%l %%M %%m %%p %%r %%S %%s %%T %%W %%w %%Y %%y %%%%')", date))
+		compareRemoteExpr(t, conn, fmt.Sprintf("DATE_FORMAT(%s, '%%U %%u %%V %%v %%X %%x')", date))
+		compareRemoteExpr(t, conn, fmt.Sprintf("UNIX_TIMESTAMP(%s)", date))
+
+		for _, unit := range []string{"MICROSECOND", "SECOND", "MINUTE", "HOUR", "DAY", "WEEK", "MONTH", "QUARTER", "YEAR"} {
+			compareRemoteExpr(t, conn, fmt.Sprintf("DATE_ADD(%s, INTERVAL 1 %s)", date, unit))
+			compareRemoteExpr(t, conn, fmt.Sprintf("DATE_SUB(%s, INTERVAL 13 %s)", date, unit))
+		}
+	}
+
+	compareRemoteExpr(t, conn, "DATE_FORMAT(NOW(), '%Y') >= '2022'")
+	compareRemoteExpr(t, conn, "NOW() > DATE'2022-01-01'")
+	compareRemoteExpr(t, conn, "UNIX_TIMESTAMP() > 0")
+}
+
+func TestJSONFunctions(t *testing.T) {
+	var conn = mysqlconn(t)
+	defer conn.Close()
+
+	var docs = []string{
+		`'{"a": 1, "b": [1, 2, {"c": "three"}], "long key": null}'`,
+		`'[1.5, true, "str", {"a": {"b": 2}}]'`,
+		`'"scalar"'`,
+		`'12345678901234567890'`,
+		`NULL`,
+	}
+	var paths = []string{
+		"'$'",
+		"'$.a'",
+		"'$.b[1]'",
+		"'$.b[last]'",
+		"'$.b[0 to 1]'",
+		"'$.b[*]'",
+		"'$[0]'",
+		"'$.*'",
+		"'$**.b'",
+		`'$."long key"'`,
+		"'$.missing'",
+	}
+
+	for _, doc := range docs {
+		for _, path := range paths {
+			compareRemoteExpr(t, conn, fmt.Sprintf("JSON_EXTRACT(%s, %s)", doc, path))
+			compareRemoteExpr(t, conn, fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, %s))", doc, path))
+		}
+		compareRemoteExpr(t, conn, fmt.Sprintf("JSON_EXTRACT(%s, '$.a', '$[0]')", doc))
+		compareRemoteExpr(t, conn, fmt.Sprintf("JSON_UNQUOTE(%s)", doc))
+	}
+
+	compareRemoteExpr(t, conn, `JSON_EXTRACT('{"a": 1', '$')`)
+	compareRemoteExpr(t, conn, `JSON_EXTRACT('{"a": 1}', '$.')`)
+}
@@ -457,6 +457,28 @@ func (d Decimal) mod(d2 Decimal) Decimal {
 	return d.sub(d2.mul(quo))
 }
 
+// Mod returns the remainder of d / d2 with the quotient truncated towards
+// zero, so the result always has the same sign as d.
+func (d Decimal) Mod(d2 Decimal) Decimal {
+	_, r := d.quoRem(d2, 0)
+	return r
+}
+
+// Floor returns the nearest integer value less than or equal to d.
+func (d Decimal) Floor() Decimal {
+	if d.isInteger() {
+		return d
+	}
+
+	exp := big.NewInt(10)
+	exp.Exp(exp, big.NewInt(-int64(d.exp)), nil)
+
+	// big.Int.Div implements Euclidean division, which rounds towards
+	// negative infinity when the divisor is positive
+	z := new(big.Int).Div(d.value, exp)
+	return Decimal{value: z, exp: 0}
+}
+
 func (d Decimal) Ceil() Decimal {
 	if d.isInteger() {
 		return d
@@ -0,0 +1,487 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evalengine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// collationJSON is the collation of JSON documents and of the strings extracted from them.
+// The ID of utf8mb4_bin is hardcoded, since the collation environment cannot be
+// initialized before the flags are parsed.
+var collationJSON = collations.TypedCollation{
+	Collation:    46,
+	Coercibility: collations.CoerceImplicit,
+	Repertoire:   collations.RepertoireUnicode,
+}
+
+// parseJSON parses a JSON document. The document is decoded into the same
+// values as encoding/json, except for numbers, which are kept as json.Number
+// so they can be written back without losing precision.
+func parseJSON(doc []byte, argpos int, fname string) interface{} {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	var value interface{}
+	err := dec.Decode(&value)
+	if err == nil {
+		// the document must not contain anything after its first value
+		if _, err = dec.Token(); err == io.EOF {
+			return value
+		}
+		if err == nil {
+			err = errors.New("The document root must not be followed by other values.")
+		}
+	}
+
+	pos := dec.InputOffset()
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		pos = syntax.Offset
+	}
+	msg := "Invalid value."
+	if err != io.EOF && err != io.ErrUnexpectedEOF && syntax == nil {
+		msg = err.Error()
+	}
+	throwEvalError(vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT,
+		"Invalid JSON text in argument %d to function %s: \"%s\" at position %d.", argpos, fname, msg, pos))
+	return nil
+}
+
+// jsonArg parses an argument to a JSON function, which must be either a JSON
+// document or a string containing one.
+func jsonArg(arg *EvalResult, argpos int, fname string) interface{} {
+	tt := arg.typeof()
+	if tt != sqltypes.TypeJSON && !arg.isTextual() {
+		throwEvalError(vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT,
+			"Invalid data type for JSON data in argument %d to function %s; a JSON string or JSON type is required.", argpos, fname))
+	}
+	return parseJSON(arg.toRawBytes(), argpos, fname)
+}
+
+// sortedJSONKeys returns the keys of a JSON object in the order
+// MySQL stores them: shorter keys first, then in binary order.
+func sortedJSONKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// appendJSON serializes a JSON value with the same formatting as MySQL.
+func appendJSON(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			return append(buf, v...)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return append(buf, v...)
+		}
+		start := len(buf)
+		buf = AppendFloat(buf, sqltypes.Float64, f)
+		if !bytes.ContainsAny(buf[start:], ".e") {
+			buf = append(buf, ".0"...)
+		}
+		return buf
+	case string:
+		return appendJSONString(buf, v)
+	case []interface{}:
+		buf = append(buf, '[')
+		for i, elem := range v {
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			buf = appendJSON(buf, elem)
+		}
+		return append(buf, ']')
+	case map[string]interface{}:
+		buf = append(buf, '{')
+		for i, key := range sortedJSONKeys(v) {
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			buf = appendJSONString(buf, key)
+			buf = append(buf, ": "...)
+			buf = appendJSON(buf, v[key])
+		}
+		return append(buf, '}')
+	default:
+		panic("unexpected JSON value")
+	}
+}
+
+func appendJSONString(buf []byte, str string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			if c < 0x20 {
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+	}
+	return append(buf, '"')
+}
+
+type jsonPathLegType int8
+
+const (
+	jsonPathMember jsonPathLegType = iota
+	jsonPathMemberWildcard
+	jsonPathArray
+	jsonPathArrayWildcard
+	jsonPathEllipsis
+)
+
+// jsonArrayIndex is an index in a JSON path, counting from the
+// start of the array or, if last is set, backwards from its end.
+type jsonArrayIndex struct {
+	n    int
+	last bool
+}
+
+func (idx jsonArrayIndex) resolve(length int) int {
+	if idx.last {
+		return length - 1 - idx.n
+	}
+	return idx.n
+}
+
+type jsonPathLeg struct {
+	typ      jsonPathLegType
+	key      string
+	from, to jsonArrayIndex
+}
+
+type jsonPath struct {
+	legs []jsonPathLeg
+	// wildcard is set if the path can match more than one value
+	wildcard bool
+}
+
+type jsonPathParser struct {
+	path string
+	pos  int
+}
+
+func (p *jsonPathParser) fail() error {
+	return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT,
+		"Invalid JSON path expression. The error is around character position %d.", p.pos)
+}
+
+func (p *jsonPathParser) skipSpaces() {
+	for p.pos < len(p.path) && (p.path[p.pos] == ' ' || p.path[p.pos] == '\t' || p.path[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *jsonPathParser) consume(s string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.path[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *jsonPathParser) parseIndex() (jsonArrayIndex, bool) {
+	var idx jsonArrayIndex
+	if p.consume("last") {
+		idx.last = true
+		if !p.consume("-") {
+			return idx, true
+		}
+	}
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.path) && p.path[p.pos] >= '0' && p.path[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.path[start:p.pos])
+	if err != nil {
+		return idx, false
+	}
+	idx.n = n
+	return idx, true
+}
+
+func (p *jsonPathParser) parseKey() (string, bool) {
+	p.skipSpaces()
+	if p.pos < len(p.path) && p.path[p.pos] == '"' {
+		end := p.pos + 1
+		for end < len(p.path) && p.path[end] != '"' {
+			if p.path[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.path) {
+			return "", false
+		}
+		key, err := strconv.Unquote(p.path[p.pos : end+1])
+		if err != nil {
+			return "", false
+		}
+		p.pos = end + 1
+		return key, true
+	}
+
+	start := p.pos
+	for p.pos < len(p.path) {
+		r, size := utf8.DecodeRuneInString(p.path[p.pos:])
+		if !(r == '_' || r == '$' || r >= utf8.RuneSelf || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (p.pos > start && r >= '0' && r <= '9')) {
+			break
+		}
+		p.pos += size
+	}
+	return p.path[start:p.pos], p.pos > start
+}
+
+func (p *jsonPathParser) parse() (*jsonPath, error) {
+	if !p.consume("$") {
+		return nil, p.fail()
+	}
+
+	var path jsonPath
+	for {
+		p.skipSpaces()
+		if p.pos == len(p.path) {
+			break
+		}
+
+		var leg jsonPathLeg
+		switch {
+		case p.consume("."):
+			if p.consume("*") {
+				leg.typ = jsonPathMemberWildcard
+				break
+			}
+			key, ok := p.parseKey()
+			if !ok {
+				return nil, p.fail()
+			}
+			leg.typ, leg.key = jsonPathMember, key
+		case p.consume("["):
+			if p.consume("*") {
+				leg.typ = jsonPathArrayWildcard
+			} else {
+				var ok bool
+				leg.typ = jsonPathArray
+				if leg.from, ok = p.parseIndex(); !ok {
+					return nil, p.fail()
+				}
+				leg.to = leg.from
+				if p.consume("to") {
+					if leg.to, ok = p.parseIndex(); !ok {
+						return nil, p.fail()
+					}
+					path.wildcard = true
+				}
+			}
+			if !p.consume("]") {
+				return nil, p.fail()
+			}
+		case p.consume("**"):
+			leg.typ = jsonPathEllipsis
+		default:
+			return nil, p.fail()
+		}
+
+		if leg.typ != jsonPathMember && leg.typ != jsonPathArray {
+			path.wildcard = true
+		}
+		path.legs = append(path.legs, leg)
+	}
+
+	if n := len(path.legs); n > 0 && path.legs[n-1].typ == jsonPathEllipsis {
+		return nil, p.fail()
+	}
+	return &path, nil
+}
+
+// parseJSONPath parses a path expression as defined in
+// https://dev.mysql.com/doc/refman/8.0/en/json.html#json-path-syntax
+func parseJSONPath(path []byte) (*jsonPath, error) {
+	p := jsonPathParser{path: string(path)}
+	return p.parse()
+}
+
+// jsonPathMatch appends to out all the values in doc that match the given path legs
+func jsonPathMatch(doc interface{}, legs []jsonPathLeg, out []interface{}) []interface{} {
+	if len(legs) == 0 {
+		return append(out, doc)
+	}
+
+	leg, rest := &legs[0], legs[1:]
+	switch leg.typ {
+	case jsonPathMember:
+		if obj, ok := doc.(map[string]interface{}); ok {
+			if value, ok := obj[leg.key]; ok {
+				out = jsonPathMatch(value, rest, out)
+			}
+		}
+	case jsonPathMemberWildcard:
+		if obj, ok := doc.(map[string]interface{}); ok {
+			for _, key := range sortedJSONKeys(obj) {
+				out = jsonPathMatch(obj[key], rest, out)
+			}
+		}
+	case jsonPathArray:
+		arr, ok := doc.([]interface{})
+		if !ok {
+			// scalars and objects are treated as an array with a single element
+			arr = []interface{}{doc}
+		}
+		from, to := leg.from.resolve(len(arr)), leg.to.resolve(len(arr))
+		if from < 0 {
+			from = 0
+		}
+		if to >= len(arr) {
+			to = len(arr) - 1
+		}
+		for i := from; i <= to; i++ {
+			out = jsonPathMatch(arr[i], rest, out)
+		}
+	case jsonPathArrayWildcard:
+		if arr, ok := doc.([]interface{}); ok {
+			for _, elem := range arr {
+				out = jsonPathMatch(elem, rest, out)
+			}
+		}
+	case jsonPathEllipsis:
+		out = jsonPathMatch(doc, rest, out)
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			for _, key := range sortedJSONKeys(v) {
+				out = jsonPathMatch(v[key], legs, out)
+			}
+		case []interface{}:
+			for _, elem := range v {
+				out = jsonPathMatch(elem, legs, out)
+			}
+		}
+	}
+	return out
+}
+
+type builtinJSONExtract struct{}
+
+func (builtinJSONExtract) call(_ *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	for i := range args {
+		if args[i].isNull() {
+			result.setNull()
+			return
+		}
+	}
+
+	doc := jsonArg(&args[0], 1, "json_extract")
+	wrap := len(args) > 2
+
+	var matches []interface{}
+	for i := range args[1:] {
+		path, err := parseJSONPath(args[i+1].toRawBytes())
+		if err != nil {
+			throwEvalError(err)
+		}
+		wrap = wrap || path.wildcard
+		matches = jsonPathMatch(doc, path.legs, matches)
+	}
+
+	switch {
+	case len(matches) == 0:
+		result.setNull()
+	case wrap:
+		result.setRaw(sqltypes.TypeJSON, appendJSON(nil, matches), collationJSON)
+	default:
+		result.setRaw(sqltypes.TypeJSON, appendJSON(nil, matches[0]), collationJSON)
+	}
+}
+
+func (builtinJSONExtract) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) < 2 {
+		throwArgError("json_extract")
+	}
+	// paths that do not match anything return NULL
+	return sqltypes.TypeJSON, flagNullable
+}
+
+type builtinJSONUnquote struct{}
+
+func (builtinJSONUnquote) call(_ *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	arg := &args[0]
+	if arg.isNull() {
+		result.setNull()
+		return
+	}
+
+	raw := arg.toRawBytes()
+	if arg.typeof() == sqltypes.TypeJSON || (len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"') {
+		if str, ok := parseJSON(raw, 1, "json_unquote").(string); ok {
+			raw = []byte(str)
+		}
+	}
+	result.setRaw(sqltypes.Text, raw, collationJSON)
+}
+
+func (builtinJSONUnquote) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 1 {
+		throwArgError("json_unquote")
+	}
+	_, f := args[0].typeof(env)
+	return sqltypes.Text, nullFlags(f)
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evalengine
+
+import (
+	"math"
+
+	"vitess.io/vitess/go/sqltypes"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine/internal/decimal"
+)
+
+// numericResultType returns the type of the result of a math function
+// that preserves the type of its numeric argument.
+func numericResultType(tt sqltypes.Type) sqltypes.Type {
+	switch {
+	case sqltypes.IsSigned(tt):
+		return sqltypes.Int64
+	case sqltypes.IsUnsigned(tt):
+		return sqltypes.Uint64
+	case tt == sqltypes.Decimal:
+		return sqltypes.Decimal
+	default:
+		return sqltypes.Float64
+	}
+}
+
+type builtinRound struct{}
+
+func (builtinRound) call(_ *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	inarg := &args[0]
+	if inarg.isNull() {
+		result.setNull()
+		return
+	}
+
+	var digits int64
+	if len(args) > 1 {
+		if args[1].isNull() {
+			result.setNull()
+			return
+		}
+		digits = intArg(&args[1])
+	}
+
+	switch tt := inarg.typeof(); {
+	case sqltypes.IsSigned(tt):
+		result.setInt64(roundSigned(inarg.int64(), digits))
+	case sqltypes.IsUnsigned(tt):
+		result.setUint64(roundUnsigned(inarg.uint64(), digits))
+	case tt == sqltypes.Decimal:
+		if digits < -decimal.MyMaxPrecision {
+			digits = -decimal.MyMaxPrecision
+		}
+		frac := digits
+		if frac < 0 {
+			frac = 0
+		}
+		if frac > decimal.MyMaxScale {
+			frac = decimal.MyMaxScale
+			digits = decimal.MyMaxScale
+		}
+		result.setDecimal(inarg.decimal().Round(int32(digits)), int32(frac))
+	default:
+		inarg.makeFloat()
+		result.setFloat(roundFloat(inarg.float64(), digits))
+	}
+}
+
+func (builtinRound) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 1 && len(args) != 2 {
+		throwArgError("ROUND")
+	}
+	t, f := args[0].typeof(env)
+	f = nullFlags(f)
+	if len(args) > 1 {
+		_, argf := args[1].typeof(env)
+		f |= nullFlags(argf)
+	}
+	return numericResultType(t), f
+}
+
+// roundSigned rounds an integer half away from zero, like MySQL does for exact values.
+func roundSigned(v, digits int64) int64 {
+	if digits >= 0 {
+		return v
+	}
+	if digits < -18 {
+		return 0
+	}
+	pow := int64(math.Pow10(int(-digits)))
+	rem := v % pow
+	v -= rem
+	switch {
+	case rem*2 >= pow:
+		if v > math.MaxInt64-pow {
+			throwEvalError(vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.DataOutOfRange, "BIGINT value is out of range in 'round(%d,%d)'", v+rem, digits))
+		}
+		v += pow
+	case rem*2 <= -pow:
+		if v < math.MinInt64+pow {
+			throwEvalError(vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.DataOutOfRange, "BIGINT value is out of range in 'round(%d,%d)'", v+rem, digits))
+		}
+		v -= pow
+	}
+	return v
+}
+
+// roundUnsigned rounds an unsigned integer half away from zero.
+func roundUnsigned(v uint64, digits int64) uint64 {
+	if digits >= 0 {
+		return v
+	}
+	if digits < -19 {
+		return 0
+	}
+	pow := uint64(math.Pow10(int(-digits)))
+	rem := v % pow
+	v -= rem
+	if rem >= pow-rem {
+		if v > math.MaxUint64-pow {
+			throwEvalError(vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.DataOutOfRange, "BIGINT UNSIGNED value is out of range in 'round(%d,%d)'", v+rem, digits))
+		}
+		v += pow
+	}
+	return v
+}
+
+// roundFloat rounds an approximate value to the nearest even number,
+// which is the behavior of the C library on the platforms that MySQL supports.
+func roundFloat(f float64, digits int64) float64 {
+	abs := digits
+	if abs < 0 {
+		abs = -abs
+	}
+	pow := math.Pow(10, float64(abs))
+	if digits < 0 {
+		if math.IsInf(pow, 0) {
+			return 0
+		}
+		return math.RoundToEven(f/pow) * pow
+	}
+	mul := f * pow
+	if math.IsInf(mul, 0) {
+		return f
+	}
+	return math.RoundToEven(mul) / pow
+}
+
+type builtinFloor struct{}
+
+func (builtinFloor) call(env *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	inarg := &args[0]
+	argtype := inarg.typeof()
+	if inarg.isNull() {
+		result.setNull()
+		return
+	}
+
+	if sqltypes.IsSigned(argtype) {
+		result.setInt64(inarg.int64())
+	} else if sqltypes.IsUnsigned(argtype) {
+		result.setUint64(inarg.uint64())
+	} else if sqltypes.Decimal == argtype {
+		num := inarg.decimal()
+		num = num.Floor()
+		intnum, isfit := num.Int64()
+		if isfit {
+			result.setInt64(intnum)
+		} else {
+			result.setDecimal(num, 0)
+		}
+	} else {
+		inarg.makeFloat()
+		result.setFloat(math.Floor(inarg.float64()))
+	}
+}
+
+func (builtinFloor) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 1 {
+		throwArgError("FLOOR")
+	}
+	t, f := args[0].typeof(env)
+	return numericResultType(t), nullFlags(f)
+}
+
+type builtinAbs struct{}
+
+func (builtinAbs) call(_ *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	inarg := &args[0]
+	if inarg.isNull() {
+		result.setNull()
+		return
+	}
+
+	switch tt := inarg.typeof(); {
+	case sqltypes.IsSigned(tt):
+		i := inarg.int64()
+		if i == math.MinInt64 {
+			throwEvalError(vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.DataOutOfRange, "BIGINT value is out of range in 'abs(%d)'", i))
+		}
+		if i < 0 {
+			i = -i
+		}
+		result.setInt64(i)
+	case sqltypes.IsUnsigned(tt):
+		result.setUint64(inarg.uint64())
+	case tt == sqltypes.Decimal:
+		dec := inarg.decimal()
+		if dec.Sign() < 0 {
+			dec = dec.Neg()
+		}
+		result.setDecimal(dec, inarg.length_)
+	default:
+		inarg.makeFloat()
+		result.setFloat(math.Abs(inarg.float64()))
+	}
+}
+
+func (builtinAbs) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 1 {
+		throwArgError("ABS")
+	}
+	t, f := args[0].typeof(env)
+	return numericResultType(t), nullFlags(f)
+}
+
+type builtinPow struct{}
+
+func (builtinPow) call(_ *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	base, exp := &args[0], &args[1]
+	if base.isNull() || exp.isNull() {
+		result.setNull()
+		return
+	}
+
+	base.makeFloat()
+	exp.makeFloat()
+	pow := math.Pow(base.float64(), exp.float64())
+	if math.IsNaN(pow) || math.IsInf(pow, 0) {
+		throwEvalError(vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.DataOutOfRange, "DOUBLE value is out of range in 'pow(%s,%s)'",
+			FormatFloat(sqltypes.Float64, base.float64()), FormatFloat(sqltypes.Float64, exp.float64())))
+	}
+	result.setFloat(pow)
+}
+
+func (builtinPow) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 2 {
+		throwArgError("POW")
+	}
+	_, f1 := args[0].typeof(env)
+	_, f2 := args[1].typeof(env)
+	return sqltypes.Float64, nullFlags(f1 | f2)
+}
+
+func builtinModRewrite(args []Expr, _ TranslationLookup) (Expr, error) {
+	if len(args) != 2 {
+		return nil, argError("MOD")
+	}
+	return &ArithmeticExpr{
+		BinaryExpr: BinaryExpr{
+			Left:  args[0],
+			Right: args[1],
+		},
+		Op: &OpModulo{},
+	}, nil
+}
@@ -163,6 +163,9 @@ func (expr *UnaryExpr) simplify(env *ExpressionEnv) error {
 }
 
 func (c *CallExpr) constant() bool {
+	if nd, ok := c.F.(nondeterministicBuiltin); ok && nd.nondeterministic(c.Arguments) {
+		return false
+	}
 	return c.Arguments.constant()
 }
 
@@ -0,0 +1,321 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evalengine
+
+import (
+	"bytes"
+	"math"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// stringResultType returns the type of the string returned by a function
+// that operates on an argument of the given type: binary strings stay binary,
+// anything else is converted to a character string.
+func stringResultType(tt sqltypes.Type) sqltypes.Type {
+	if sqltypes.IsBinary(tt) {
+		return sqltypes.VarBinary
+	}
+	return sqltypes.VarChar
+}
+
+// nullFlags returns the flags of an argument that are inherited by
+// the result of a function that returns NULL if any of its arguments is NULL.
+func nullFlags(f flag) flag {
+	return f & (flagNull | flagNullable)
+}
+
+// textCollation returns the collation of an argument when it's used as a string:
+// numbers and temporal values are converted to a string in the connection's collation.
+func textCollation(env *ExpressionEnv, arg *EvalResult) collations.TypedCollation {
+	if arg.isTextual() {
+		return arg.collation()
+	}
+	return collations.TypedCollation{
+		Collation:    env.DefaultCollation,
+		Coercibility: collations.CoerceNumeric,
+		Repertoire:   collations.RepertoireASCII,
+	}
+}
+
+// aggregateStrings converts all the arguments of a string function to strings
+// in the collation that results from aggregating all of them.
+func aggregateStrings(env *ExpressionEnv, args []EvalResult) ([][]byte, collations.TypedCollation) {
+	environment := collations.Local()
+
+	var ca collationAggregation
+	for i := range args {
+		ca.add(environment, textCollation(env, &args[i]))
+	}
+	tc := ca.result()
+	dst := environment.LookupByID(tc.Collation)
+
+	strs := make([][]byte, len(args))
+	for i := range args {
+		raw := args[i].toRawBytes()
+		if src := environment.LookupByID(textCollation(env, &args[i]).Collation); src != nil && dst != nil {
+			var err error
+			raw, err = collations.Convert(nil, dst, raw, src)
+			if err != nil {
+				throwEvalError(err)
+			}
+		}
+		strs[i] = raw
+	}
+	return strs, tc
+}
+
+// intArg returns the value of an integer argument to a string function.
+func intArg(arg *EvalResult) int64 {
+	if sqltypes.IsUnsigned(arg.typeof()) && arg.uint64() > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	arg.makeSignedIntegral()
+	return arg.int64()
+}
+
+type builtinConcat struct{}
+
+func (builtinConcat) call(env *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	tt := sqltypes.VarChar
+	for i := range args {
+		if args[i].isNull() {
+			result.setNull()
+			return
+		}
+		if sqltypes.IsBinary(args[i].typeof()) {
+			tt = sqltypes.VarBinary
+		}
+	}
+
+	strs, tc := aggregateStrings(env, args)
+	result.setRaw(tt, bytes.Join(strs, nil), tc)
+}
+
+func (builtinConcat) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) < 1 {
+		throwArgError("CONCAT")
+	}
+	tt := sqltypes.VarChar
+	var f flag
+	for _, arg := range args {
+		argt, argf := arg.typeof(env)
+		if sqltypes.IsBinary(argt) {
+			tt = sqltypes.VarBinary
+		}
+		f |= nullFlags(argf)
+	}
+	return tt, f
+}
+
+type builtinChangeCase struct {
+	upcase bool
+}
+
+func (b builtinChangeCase) call(env *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	inarg := &args[0]
+	if inarg.isNull() {
+		result.setNull()
+		return
+	}
+
+	tc := textCollation(env, inarg)
+	raw := inarg.toRawBytes()
+	if coll := collations.Local().LookupByID(tc.Collation); coll != nil {
+		if b.upcase {
+			raw = collations.ToUpper(coll, raw)
+		} else {
+			raw = collations.ToLower(coll, raw)
+		}
+	}
+	result.setRaw(stringResultType(inarg.typeof()), raw, tc)
+}
+
+func (b builtinChangeCase) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 1 {
+		if b.upcase {
+			throwArgError("UPPER")
+		}
+		throwArgError("LOWER")
+	}
+	t, f := args[0].typeof(env)
+	return stringResultType(t), nullFlags(f)
+}
+
+type builtinLength struct{}
+
+func (builtinLength) call(_ *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	inarg := &args[0]
+	if inarg.isNull() {
+		result.setNull()
+		return
+	}
+	result.setInt64(int64(len(inarg.toRawBytes())))
+}
+
+func (builtinLength) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 1 {
+		throwArgError("LENGTH")
+	}
+	_, f := args[0].typeof(env)
+	return sqltypes.Int64, nullFlags(f)
+}
+
+type builtinSubstring struct{}
+
+func (builtinSubstring) call(env *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	for i := range args {
+		if args[i].isNull() {
+			result.setNull()
+			return
+		}
+	}
+
+	str := &args[0]
+	tc := textCollation(env, str)
+	raw := str.toRawBytes()
+	tt := stringResultType(str.typeof())
+
+	coll := collations.Local().LookupByID(tc.Collation)
+	if coll == nil {
+		coll = collations.Local().LookupByID(collations.CollationBinaryID)
+	}
+	length := int64(collations.Length(coll, raw))
+
+	// positions are 1-based; negative positions count from the end of the string
+	pos := intArg(&args[1])
+	switch {
+	case pos > 0:
+		pos--
+	case pos < 0:
+		pos += length
+	default:
+		pos = length
+	}
+	end := length
+	if len(args) > 2 {
+		if n := intArg(&args[2]); n < end-pos {
+			end = pos + n
+		}
+	}
+	if pos < 0 || pos >= length || end <= pos {
+		result.setRaw(tt, []byte{}, tc)
+		return
+	}
+	result.setRaw(tt, collations.Slice(coll, raw, int(pos), int(end)), tc)
+}
+
+func (builtinSubstring) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 2 && len(args) != 3 {
+		throwArgError("SUBSTRING")
+	}
+	tt, f := args[0].typeof(env)
+	f = nullFlags(f)
+	for _, arg := range args[1:] {
+		_, argf := arg.typeof(env)
+		f |= nullFlags(argf)
+	}
+	return stringResultType(tt), f
+}
+
+type builtinTrim struct {
+	trim sqlparser.TrimType
+}
+
+func (b builtinTrim) call(env *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	for i := range args {
+		if args[i].isNull() {
+			result.setNull()
+			return
+		}
+	}
+
+	tt := stringResultType(args[0].typeof())
+	strs, tc := aggregateStrings(env, args)
+	str, remove := strs[0], []byte{' '}
+	if len(strs) > 1 {
+		remove = strs[1]
+	}
+
+	if len(remove) > 0 {
+		if b.trim != sqlparser.TrailingTrimType {
+			for bytes.HasPrefix(str, remove) {
+				str = str[len(remove):]
+			}
+		}
+		if b.trim != sqlparser.LeadingTrimType {
+			for bytes.HasSuffix(str, remove) {
+				str = str[:len(str)-len(remove)]
+			}
+		}
+	}
+	result.setRaw(tt, str, tc)
+}
+
+func (b builtinTrim) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 1 && len(args) != 2 {
+		throwArgError("TRIM")
+	}
+	tt, f := args[0].typeof(env)
+	f = nullFlags(f)
+	if len(args) > 1 {
+		_, argf := args[1].typeof(env)
+		f |= nullFlags(argf)
+	}
+	return stringResultType(tt), f
+}
+
+type builtinReplace struct{}
+
+func (builtinReplace) call(env *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	tt := sqltypes.VarChar
+	for i := range args {
+		if args[i].isNull() {
+			result.setNull()
+			return
+		}
+		if sqltypes.IsBinary(args[i].typeof()) {
+			tt = sqltypes.VarBinary
+		}
+	}
+
+	strs, tc := aggregateStrings(env, args)
+	str, from, to := strs[0], strs[1], strs[2]
+	if len(from) > 0 {
+		// REPLACE is always case-sensitive, regardless of the collation of its arguments
+		str = bytes.ReplaceAll(str, from, to)
+	}
+	result.setRaw(tt, str, tc)
+}
+
+func (builtinReplace) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 3 {
+		throwArgError("REPLACE")
+	}
+	tt := sqltypes.VarChar
+	var f flag
+	for _, arg := range args {
+		argt, argf := arg.typeof(env)
+		if sqltypes.IsBinary(argt) {
+			tt = sqltypes.VarBinary
+		}
+		f |= nullFlags(argf)
+	}
+	return tt, f
+}
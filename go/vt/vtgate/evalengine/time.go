@@ -0,0 +1,661 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evalengine
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine/internal/decimal"
+)
+
+const (
+	formatDate     = "2006-01-02"
+	formatTime     = "15:04:05"
+	formatDatetime = "2006-01-02 15:04:05"
+)
+
+// currentTime returns the time used by all the functions that return the current
+// date and time. Like in MySQL, it's constant for the whole evaluation.
+func (env *ExpressionEnv) currentTime() time.Time {
+	if env.now.IsZero() {
+		env.now = time.Now()
+	}
+	return env.now.In(env.timeZone())
+}
+
+func (env *ExpressionEnv) timeZone() *time.Location {
+	if env.TimeZone != nil {
+		return env.TimeZone
+	}
+	return time.Local
+}
+
+// ParseTimeZone parses the value of the time_zone system variable, which is either
+// SYSTEM, an offset from UTC such as '+05:30', or the name of a time zone.
+// It returns nil for SYSTEM, since vtgate then uses its own local time zone.
+func ParseTimeZone(tz string) (*time.Location, error) {
+	if strings.EqualFold(tz, "SYSTEM") {
+		return nil, nil
+	}
+	if len(tz) > 0 && (tz[0] == '+' || tz[0] == '-') {
+		hours, minutes, ok := strings.Cut(tz[1:], ":")
+		h, herr := strconv.Atoi(hours)
+		m, merr := strconv.Atoi(minutes)
+		if !ok || herr != nil || merr != nil || len(minutes) != 2 || m > 59 || h*60+m > 14*60 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unknown or incorrect time zone: '%s'", tz)
+		}
+		offset := (h*60 + m) * 60
+		if tz[0] == '-' {
+			offset = -offset
+		}
+		return time.FixedZone(tz, offset), nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unknown or incorrect time zone: '%s'", tz)
+	}
+	return loc, nil
+}
+
+// dateTimeArg parses the value of an argument to a temporal function. hasTime
+// reports whether the value contains a time part; ok is false if the value is not
+// a valid date.
+func dateTimeArg(arg *EvalResult) (t time.Time, hasTime bool, ok bool) {
+	var err error
+	switch arg.typeof() {
+	case sqltypes.Date:
+		t, err = sqlparser.ParseDate(arg.string())
+	case sqltypes.Datetime, sqltypes.Timestamp:
+		t, err = sqlparser.ParseDateTime(arg.string())
+		hasTime = true
+	case sqltypes.Time:
+		t, err = sqlparser.ParseTime(arg.string())
+		hasTime = true
+	default:
+		str := string(arg.toRawBytes())
+		if t, err = sqlparser.ParseDateTime(str); err == nil {
+			hasTime = true
+		} else {
+			t, err = sqlparser.ParseDate(str)
+		}
+	}
+	return t, hasTime, err == nil
+}
+
+// appendFrac appends the fractional seconds of t with the given number of digits.
+func appendFrac(buf []byte, t time.Time, digits int) []byte {
+	if digits <= 0 {
+		return buf
+	}
+	frac := strconv.Itoa(t.Nanosecond() / int(math.Pow10(9-digits)))
+	buf = append(buf, '.')
+	for i := len(frac); i < digits; i++ {
+		buf = append(buf, '0')
+	}
+	return append(buf, frac...)
+}
+
+// collationTemporalString is the collation of the strings that temporal functions return
+func collationTemporalString(env *ExpressionEnv) collations.TypedCollation {
+	return collations.TypedCollation{
+		Collation:    env.DefaultCollation,
+		Coercibility: collations.CoerceCoercible,
+		Repertoire:   collations.RepertoireASCII,
+	}
+}
+
+type builtinNow struct {
+	utc      bool
+	onlyTime bool
+	fsp      int
+}
+
+func (b builtinNow) call(env *ExpressionEnv, _ []EvalResult, result *EvalResult) {
+	now := env.currentTime()
+	if b.utc {
+		now = now.UTC()
+	}
+	if b.onlyTime {
+		result.setRaw(sqltypes.Time, appendFrac([]byte(now.Format(formatTime)), now, b.fsp), collationNumeric)
+	} else {
+		result.setRaw(sqltypes.Datetime, appendFrac([]byte(now.Format(formatDatetime)), now, b.fsp), collationNumeric)
+	}
+}
+
+func (b builtinNow) typeof(_ *ExpressionEnv, _ []Expr) (sqltypes.Type, flag) {
+	if b.onlyTime {
+		return sqltypes.Time, 0
+	}
+	return sqltypes.Datetime, 0
+}
+
+func (builtinNow) nondeterministic([]Expr) bool {
+	return true
+}
+
+type builtinUnixTimestamp struct {
+	// frac is the number of fractional digits in the result,
+	// or -1 if it depends on the type of the argument
+	frac int32
+}
+
+func (b builtinUnixTimestamp) precision(tt sqltypes.Type) int32 {
+	switch {
+	case b.frac >= 0:
+		return b.frac
+	case sqltypes.IsIntegral(tt) || sqltypes.IsDate(tt):
+		return 0
+	default:
+		return 6
+	}
+}
+
+func (b builtinUnixTimestamp) call(env *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	if len(args) == 0 {
+		result.setInt64(env.currentTime().Unix())
+		return
+	}
+
+	arg := &args[0]
+	if arg.isNull() {
+		result.setNull()
+		return
+	}
+	t, _, ok := dateTimeArg(arg)
+	if !ok {
+		result.setNull()
+		return
+	}
+
+	// the date is interpreted in the time zone of the session
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), env.timeZone())
+	if t.Unix() < 0 {
+		// dates before the epoch are out of range
+		t = time.Unix(0, 0)
+	}
+
+	frac := b.precision(arg.typeof())
+	if frac == 0 {
+		result.setInt64(t.Unix())
+		return
+	}
+	dec := decimal.New(t.UnixMicro(), -6)
+	result.setDecimal(dec.Round(frac), frac)
+}
+
+func (b builtinUnixTimestamp) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) > 1 {
+		throwArgError("UNIX_TIMESTAMP")
+	}
+	if len(args) == 0 {
+		return sqltypes.Int64, 0
+	}
+	tt, _ := args[0].typeof(env)
+	if b.precision(tt) == 0 {
+		return sqltypes.Int64, flagNullable
+	}
+	return sqltypes.Decimal, flagNullable
+}
+
+func (builtinUnixTimestamp) nondeterministic([]Expr) bool {
+	// a date argument is interpreted in the time zone of the session
+	return true
+}
+
+func builtinUnixTimestampRewrite(args []Expr, _ TranslationLookup) (Expr, error) {
+	if len(args) > 1 {
+		return nil, argError("UNIX_TIMESTAMP")
+	}
+	f := builtinUnixTimestamp{frac: -1}
+	if len(args) == 0 {
+		f.frac = 0
+	} else if lit, ok := args[0].(*Literal); ok {
+		f.frac = literalDatetimePrecision(lit)
+	}
+	return newCallExpr("unix_timestamp", f, args...), nil
+}
+
+// literalDatetimePrecision returns the number of fractional digits
+// of a literal that is used as a datetime.
+func literalDatetimePrecision(lit *Literal) int32 {
+	switch tt := lit.Val.typeof(); {
+	case sqltypes.IsIntegral(tt) || tt == sqltypes.Date || tt == sqltypes.Null:
+		return 0
+	case sqltypes.IsFloat(tt):
+		return 6
+	case tt == sqltypes.Decimal:
+		if lit.Val.length_ > 6 {
+			return 6
+		}
+		return lit.Val.length_
+	default:
+		raw := lit.Val.bytes()
+		dot := bytes.LastIndexByte(raw, '.')
+		if dot < 0 {
+			return 0
+		}
+		var digits int32
+		for _, c := range raw[dot+1:] {
+			if c < '0' || c > '9' || digits == 6 {
+				break
+			}
+			digits++
+		}
+		return digits
+	}
+}
+
+// intervalUnit is the unit of the INTERVAL argument to DATE_ADD and DATE_SUB
+type intervalUnit int8
+
+const (
+	intervalMicrosecond intervalUnit = iota
+	intervalSecond
+	intervalMinute
+	intervalHour
+	intervalDay
+	intervalWeek
+	intervalMonth
+	intervalQuarter
+	intervalYear
+)
+
+var intervalUnits = map[string]intervalUnit{
+	"microsecond": intervalMicrosecond,
+	"second":      intervalSecond,
+	"minute":      intervalMinute,
+	"hour":        intervalHour,
+	"day":         intervalDay,
+	"week":        intervalWeek,
+	"month":       intervalMonth,
+	"quarter":     intervalQuarter,
+	"year":        intervalYear,
+}
+
+func parseIntervalUnit(unit string) (intervalUnit, error) {
+	if u, ok := intervalUnits[strings.ToLower(unit)]; ok {
+		return u, nil
+	}
+	return 0, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported INTERVAL unit: %s", unit)
+}
+
+// dateOnly returns whether adding an interval of this unit to a date never changes its time
+func (u intervalUnit) dateOnly() bool {
+	return u >= intervalDay
+}
+
+// microseconds returns the length of an interval of this unit, for
+// the units which have a fixed length.
+func (u intervalUnit) microseconds() int64 {
+	switch u {
+	case intervalSecond:
+		return int64(time.Second / time.Microsecond)
+	case intervalMinute:
+		return int64(time.Minute / time.Microsecond)
+	case intervalHour:
+		return int64(time.Hour / time.Microsecond)
+	case intervalDay:
+		return int64(24 * time.Hour / time.Microsecond)
+	case intervalWeek:
+		return int64(7 * 24 * time.Hour / time.Microsecond)
+	default:
+		return 1
+	}
+}
+
+// intervalInteger returns the value of an INTERVAL as an integer: numbers are rounded,
+// and only the leading integer of a string is taken into account.
+func intervalInteger(arg *EvalResult) int64 {
+	if sqltypes.IsNumber(arg.typeof()) {
+		return intArg(arg)
+	}
+	str := strings.TrimSpace(arg.string())
+	end := 0
+	if end < len(str) && (str[end] == '-' || str[end] == '+') {
+		end++
+	}
+	for end < len(str) && str[end] >= '0' && str[end] <= '9' {
+		end++
+	}
+	i, _ := strconv.ParseInt(str[:end], 10, 64)
+	return i
+}
+
+type builtinDateAdd struct {
+	unit intervalUnit
+	sub  bool
+}
+
+func (b builtinDateAdd) resultType(tt sqltypes.Type) sqltypes.Type {
+	switch tt {
+	case sqltypes.Date:
+		if b.unit.dateOnly() {
+			return sqltypes.Date
+		}
+		return sqltypes.Datetime
+	case sqltypes.Datetime, sqltypes.Timestamp, sqltypes.Time:
+		return sqltypes.Datetime
+	default:
+		return sqltypes.VarChar
+	}
+}
+
+func (b builtinDateAdd) call(env *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	date, interval := &args[0], &args[1]
+	if date.isNull() || interval.isNull() {
+		result.setNull()
+		return
+	}
+	t, hasTime, ok := dateTimeArg(date)
+	if !ok {
+		result.setNull()
+		return
+	}
+
+	switch b.unit {
+	case intervalMonth, intervalQuarter, intervalYear:
+		months := intervalInteger(interval)
+		switch b.unit {
+		case intervalQuarter:
+			months *= 3
+		case intervalYear:
+			months *= 12
+		}
+		if b.sub {
+			months = -months
+		}
+		t, ok = addMonths(t, months)
+	default:
+		var micros int64
+		if b.unit == intervalSecond && !sqltypes.IsIntegral(interval.typeof()) {
+			interval.makeFloat()
+			micros = int64(math.Round(interval.float64() * 1e6))
+		} else {
+			micros = intervalInteger(interval) * b.unit.microseconds()
+		}
+		if b.sub {
+			micros = -micros
+		}
+		const microsPerDay = int64(24 * time.Hour / time.Microsecond)
+		t = t.AddDate(0, 0, int(micros/microsPerDay)).Add(time.Duration(micros%microsPerDay) * time.Microsecond)
+		ok = t.Year() >= 0 && t.Year() <= 9999
+	}
+	if !ok {
+		result.setNull()
+		return
+	}
+
+	digits := 0
+	if b.unit == intervalMicrosecond || t.Nanosecond() != 0 {
+		digits = 6
+	}
+	switch tt := b.resultType(date.typeof()); tt {
+	case sqltypes.Date:
+		result.setRaw(tt, []byte(t.Format(formatDate)), collationNumeric)
+	case sqltypes.Datetime:
+		result.setRaw(tt, appendFrac([]byte(t.Format(formatDatetime)), t, digits), collationNumeric)
+	default:
+		var raw []byte
+		if !hasTime && b.unit.dateOnly() {
+			raw = []byte(t.Format(formatDate))
+		} else {
+			raw = appendFrac([]byte(t.Format(formatDatetime)), t, digits)
+		}
+		result.setRaw(tt, raw, collationTemporalString(env))
+	}
+}
+
+func (b builtinDateAdd) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 2 {
+		if b.sub {
+			throwArgError("DATE_SUB")
+		}
+		throwArgError("DATE_ADD")
+	}
+	tt, _ := args[0].typeof(env)
+	// invalid dates and out of range results are NULL
+	return b.resultType(tt), flagNullable
+}
+
+// addMonths adds the given number of months to t. If the day of the month is
+// past the end of the resulting month, it's set to the last day of that month.
+func addMonths(t time.Time, months int64) (time.Time, bool) {
+	year, month, day := t.Date()
+	total := int64(year)*12 + int64(month) - 1 + months
+	if total < 0 || total >= 10000*12 {
+		return t, false
+	}
+	year, month = int(total/12), time.Month(total%12+1)
+	if last := daysInMonth(year, month); day > last {
+		day = last
+	}
+	return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location()), true
+}
+
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func daysInYear(year int) int {
+	if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return 366
+	}
+	return 365
+}
+
+type builtinDateFormat struct{}
+
+func (builtinDateFormat) call(env *ExpressionEnv, args []EvalResult, result *EvalResult) {
+	date, format := &args[0], &args[1]
+	if date.isNull() || format.isNull() {
+		result.setNull()
+		return
+	}
+	t, _, ok := dateTimeArg(date)
+	if !ok {
+		result.setNull()
+		return
+	}
+	result.setRaw(sqltypes.VarChar, formatDateTime(t, format.toRawBytes()), collationTemporalString(env))
+}
+
+func (builtinDateFormat) typeof(env *ExpressionEnv, args []Expr) (sqltypes.Type, flag) {
+	if len(args) != 2 {
+		throwArgError("DATE_FORMAT")
+	}
+	// invalid dates are NULL
+	return sqltypes.VarChar, flagNullable
+}
+
+const (
+	weekMondayFirst  = 1
+	weekYear         = 2
+	weekFirstWeekday = 4
+)
+
+// calcWeek returns the week of the year of t, and the year that week belongs to,
+// using the same algorithm as MySQL for the given week behavior flags.
+func calcWeek(t time.Time, behavior int) (int, int) {
+	mondayFirst := behavior&weekMondayFirst != 0
+	useWeekYear := behavior&weekYear != 0
+	firstWeekday := behavior&weekFirstWeekday != 0
+
+	year := t.Year()
+	daynr := t.YearDay() - 1
+	firstDaynr := 0
+	weekday := int(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Weekday())
+	if mondayFirst {
+		weekday = (weekday + 6) % 7
+	}
+
+	if t.Month() == time.January && t.Day() <= 7-weekday {
+		if !useWeekYear && ((firstWeekday && weekday != 0) || (!firstWeekday && weekday >= 4)) {
+			return year, 0
+		}
+		useWeekYear = true
+		year--
+		days := daysInYear(year)
+		firstDaynr -= days
+		weekday = (weekday + 53*7 - days) % 7
+	}
+
+	var days int
+	if (firstWeekday && weekday != 0) || (!firstWeekday && weekday >= 4) {
+		days = daynr - (firstDaynr + (7 - weekday))
+	} else {
+		days = daynr - (firstDaynr - weekday)
+	}
+
+	if useWeekYear && days >= 52*7 {
+		weekday = (weekday + daysInYear(year)) % 7
+		if (!firstWeekday && weekday < 4) || (firstWeekday && weekday == 0) {
+			return year + 1, 1
+		}
+	}
+	return year, days/7 + 1
+}
+
+func appendPadded(buf []byte, n, width int) []byte {
+	str := strconv.Itoa(n)
+	for i := len(str); i < width; i++ {
+		buf = append(buf, '0')
+	}
+	return append(buf, str...)
+}
+
+func daySuffix(day int) string {
+	if day >= 11 && day <= 13 {
+		return "th"
+	}
+	switch day % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	default:
+		return "th"
+	}
+}
+
+// formatDateTime formats t using the specifiers supported by MySQL's DATE_FORMAT
+func formatDateTime(t time.Time, format []byte) []byte {
+	var buf []byte
+	hour12 := t.Hour() % 12
+	if hour12 == 0 {
+		hour12 = 12
+	}
+	ampm := "AM"
+	if t.Hour() >= 12 {
+		ampm = "PM"
+	}
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i+1 == len(format) {
+			buf = append(buf, c)
+			continue
+		}
+		i++
+		switch spec := format[i]; spec {
+		case 'a':
+			buf = append(buf, t.Weekday().String()[:3]...)
+		case 'b':
+			buf = append(buf, t.Month().String()[:3]...)
+		case 'c':
+			buf = strconv.AppendInt(buf, int64(t.Month()), 10)
+		case 'D':
+			buf = strconv.AppendInt(buf, int64(t.Day()), 10)
+			buf = append(buf, daySuffix(t.Day())...)
+		case 'd':
+			buf = appendPadded(buf, t.Day(), 2)
+		case 'e':
+			buf = strconv.AppendInt(buf, int64(t.Day()), 10)
+		case 'f':
+			buf = appendPadded(buf, t.Nanosecond()/1000, 6)
+		case 'H':
+			buf = appendPadded(buf, t.Hour(), 2)
+		case 'h', 'I':
+			buf = appendPadded(buf, hour12, 2)
+		case 'i':
+			buf = appendPadded(buf, t.Minute(), 2)
+		case 'j':
+			buf = appendPadded(buf, t.YearDay(), 3)
+		case 'k':
+			buf = strconv.AppendInt(buf, int64(t.Hour()), 10)
+		case 'l':
+			buf = strconv.AppendInt(buf, int64(hour12), 10)
+		case 'M':
+			buf = append(buf, t.Month().String()...)
+		case 'm':
+			buf = appendPadded(buf, int(t.Month()), 2)
+		case 'p':
+			buf = append(buf, ampm...)
+		case 'r':
+			buf = appendPadded(buf, hour12, 2)
+			buf = append(buf, ':')
+			buf = appendPadded(buf, t.Minute(), 2)
+			buf = append(buf, ':')
+			buf = appendPadded(buf, t.Second(), 2)
+			buf = append(buf, ' ')
+			buf = append(buf, ampm...)
+		case 'S', 's':
+			buf = appendPadded(buf, t.Second(), 2)
+		case 'T':
+			buf = append(buf, t.Format(formatTime)...)
+		case 'U':
+			_, week := calcWeek(t, weekFirstWeekday)
+			buf = appendPadded(buf, week, 2)
+		case 'u':
+			_, week := calcWeek(t, weekMondayFirst)
+			buf = appendPadded(buf, week, 2)
+		case 'V':
+			_, week := calcWeek(t, weekYear|weekFirstWeekday)
+			buf = appendPadded(buf, week, 2)
+		case 'v':
+			_, week := calcWeek(t, weekYear|weekMondayFirst)
+			buf = appendPadded(buf, week, 2)
+		case 'W':
+			buf = append(buf, t.Weekday().String()...)
+		case 'w':
+			buf = strconv.AppendInt(buf, int64(t.Weekday()), 10)
+		case 'X':
+			year, _ := calcWeek(t, weekYear|weekFirstWeekday)
+			buf = appendPadded(buf, year, 4)
+		case 'x':
+			year, _ := calcWeek(t, weekYear|weekMondayFirst)
+			buf = appendPadded(buf, year, 4)
+		case 'Y':
+			buf = appendPadded(buf, t.Year(), 4)
+		case 'y':
+			buf = appendPadded(buf, t.Year()%100, 2)
+		default:
+			buf = append(buf, spec)
+		}
+	}
+	return buf
+}
@@ -237,6 +237,8 @@ func translateBinaryExpr(binary *sqlparser.BinaryExpr, lookup TranslationLookup)
 		return &ArithmeticExpr{BinaryExpr: binaryExpr, Op: &OpMultiplication{}}, nil
 	case sqlparser.DivOp:
 		return &ArithmeticExpr{BinaryExpr: binaryExpr, Op: &OpDivision{}}, nil
+	case sqlparser.ModOp:
+		return &ArithmeticExpr{BinaryExpr: binaryExpr, Op: &OpModulo{}}, nil
 	case sqlparser.BitAndOp:
 		return &BitwiseExpr{BinaryExpr: binaryExpr, Op: &OpBitAnd{}}, nil
 	case sqlparser.BitOrOp:
@@ -247,6 +249,11 @@ func translateBinaryExpr(binary *sqlparser.BinaryExpr, lookup TranslationLookup)
 		return &BitwiseExpr{BinaryExpr: binaryExpr, Op: &OpBitShiftLeft{}}, nil
 	case sqlparser.ShiftRightOp:
 		return &BitwiseExpr{BinaryExpr: binaryExpr, Op: &OpBitShiftRight{}}, nil
+	case sqlparser.JSONExtractOp:
+		return newCallExpr("json_extract", builtinJSONExtract{}, left, right), nil
+	case sqlparser.JSONUnquoteExtractOp:
+		extract := newCallExpr("json_extract", builtinJSONExtract{}, left, right)
+		return newCallExpr("json_unquote", builtinJSONUnquote{}, extract), nil
 	default:
 		return nil, translateExprNotSupported(binary)
 	}
@@ -323,7 +330,37 @@ func translateIntroducerExpr(introduced *sqlparser.IntroducerExpr, lookup Transl
 	return expr, nil
 }
 
+func newCallExpr(method string, f builtin, args ...Expr) *CallExpr {
+	return &CallExpr{
+		Arguments: args,
+		Aliases:   make([]sqlparser.IdentifierCI, len(args)),
+		Method:    method,
+		F:         f,
+	}
+}
+
+// translateExprs translates all the given expressions, skipping the optional ones that are nil
+func translateExprs(lookup TranslationLookup, exprs ...sqlparser.Expr) ([]Expr, error) {
+	var translated []Expr
+	for _, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+		e, err := translateExpr(expr, lookup)
+		if err != nil {
+			return nil, err
+		}
+		translated = append(translated, e)
+	}
+	return translated, nil
+}
+
 func translateFuncExpr(fn *sqlparser.FuncExpr, lookup TranslationLookup) (Expr, error) {
+	switch method := fn.Name.Lowered(); method {
+	case "date_add", "date_sub", "adddate", "subdate":
+		return translateDateAddExpr(method, fn, lookup)
+	}
+
 	var args TupleExpr
 	var aliases []sqlparser.IdentifierCI
 	for _, expr := range fn.Exprs {
@@ -357,6 +394,110 @@ func translateFuncExpr(fn *sqlparser.FuncExpr, lookup TranslationLookup) (Expr,
 	return nil, translateExprNotSupported(fn)
 }
 
+func translateDateAddExpr(method string, fn *sqlparser.FuncExpr, lookup TranslationLookup) (Expr, error) {
+	if len(fn.Exprs) != 2 {
+		return nil, argError(strings.ToUpper(method))
+	}
+	var exprs [2]sqlparser.Expr
+	for i, expr := range fn.Exprs {
+		aliased, ok := expr.(*sqlparser.AliasedExpr)
+		if !ok {
+			return nil, translateExprNotSupported(fn)
+		}
+		exprs[i] = aliased.Expr
+	}
+
+	f := builtinDateAdd{
+		unit: intervalDay,
+		sub:  method == "date_sub" || method == "subdate",
+	}
+	if interval, ok := exprs[1].(*sqlparser.IntervalExpr); ok {
+		unit, err := parseIntervalUnit(interval.Unit)
+		if err != nil {
+			return nil, err
+		}
+		f.unit = unit
+		exprs[1] = interval.Expr
+	} else if method == "date_add" || method == "date_sub" {
+		// only ADDDATE and SUBDATE accept a number of days instead of an INTERVAL
+		return nil, translateExprNotSupported(fn)
+	}
+
+	args, err := translateExprs(lookup, exprs[:]...)
+	if err != nil {
+		return nil, err
+	}
+	return newCallExpr(method, f, args...), nil
+}
+
+func translateSubstrExpr(substr *sqlparser.SubstrExpr, lookup TranslationLookup) (Expr, error) {
+	args, err := translateExprs(lookup, substr.Name, substr.From, substr.To)
+	if err != nil {
+		return nil, err
+	}
+	return newCallExpr("substring", builtinSubstring{}, args...), nil
+}
+
+func translateTrimFuncExpr(trim *sqlparser.TrimFuncExpr, lookup TranslationLookup) (Expr, error) {
+	method, trimType := "trim", trim.Type
+	switch trim.TrimFuncType {
+	case sqlparser.LTrimType:
+		method, trimType = "ltrim", sqlparser.LeadingTrimType
+	case sqlparser.RTrimType:
+		method, trimType = "rtrim", sqlparser.TrailingTrimType
+	}
+	args, err := translateExprs(lookup, trim.StringArg, trim.TrimArg)
+	if err != nil {
+		return nil, err
+	}
+	return newCallExpr(method, builtinTrim{trim: trimType}, args...), nil
+}
+
+func translateCurTimeFuncExpr(curtime *sqlparser.CurTimeFuncExpr, lookup TranslationLookup) (Expr, error) {
+	var f builtinNow
+	if curtime.Fsp != nil {
+		lit, ok := curtime.Fsp.(*sqlparser.Literal)
+		if !ok {
+			return nil, translateExprNotSupported(curtime)
+		}
+		fsp, _, err := translateIntegral(lit, lookup)
+		if err != nil {
+			return nil, err
+		}
+		if fsp > 6 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Too-big precision %d specified for '%s'. Maximum is 6.", fsp, curtime.Name.String())
+		}
+		f.fsp = fsp
+	}
+
+	method := curtime.Name.Lowered()
+	switch method {
+	case "utc_timestamp":
+		f.utc = true
+	case "utc_time":
+		f.utc, f.onlyTime = true, true
+	case "current_time":
+		f.onlyTime = true
+	}
+	return newCallExpr(method, f), nil
+}
+
+func translateJSONExtractExpr(extract *sqlparser.JSONExtractExpr, lookup TranslationLookup) (Expr, error) {
+	args, err := translateExprs(lookup, append([]sqlparser.Expr{extract.JSONDoc}, extract.PathList...)...)
+	if err != nil {
+		return nil, err
+	}
+	return newCallExpr("json_extract", builtinJSONExtract{}, args...), nil
+}
+
+func translateJSONUnquoteExpr(unquote *sqlparser.JSONUnquoteExpr, lookup TranslationLookup) (Expr, error) {
+	arg, err := translateExpr(unquote.JSONValue, lookup)
+	if err != nil {
+		return nil, err
+	}
+	return newCallExpr("json_unquote", builtinJSONUnquote{}, arg), nil
+}
+
 func translateIntegral(lit *sqlparser.Literal, lookup TranslationLookup) (int, bool, error) {
 	if lit == nil {
 		return 0, false, nil
@@ -624,6 +765,16 @@ func translateExpr(e sqlparser.Expr, lookup TranslationLookup) (Expr, error) {
 		return translateConvertUsingExpr(node, lookup)
 	case *sqlparser.CaseExpr:
 		return translateCaseExpr(node, lookup)
+	case *sqlparser.SubstrExpr:
+		return translateSubstrExpr(node, lookup)
+	case *sqlparser.TrimFuncExpr:
+		return translateTrimFuncExpr(node, lookup)
+	case *sqlparser.CurTimeFuncExpr:
+		return translateCurTimeFuncExpr(node, lookup)
+	case *sqlparser.JSONExtractExpr:
+		return translateJSONExtractExpr(node, lookup)
+	case *sqlparser.JSONUnquoteExpr:
+		return translateJSONUnquoteExpr(node, lookup)
 	default:
 		return nil, translateExprNotSupported(e)
 	}
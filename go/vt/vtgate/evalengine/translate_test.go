@@ -19,6 +19,7 @@ package evalengine
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"vitess.io/vitess/go/vt/sqlparser"
 
@@ -100,6 +101,31 @@ func TestTranslateSimplification(t *testing.T) {
 		{"date'2022'", err(`incorrect DATE value: '2022'`), err(`incorrect DATE value: '2022'`)},
 		{"time'2022-10-03'", err(`incorrect TIME value: '2022-10-03'`), err(`incorrect TIME value: '2022-10-03'`)},
 		{"timestamp'2022-10-03'", err(`incorrect DATETIME value: '2022-10-03'`), err(`incorrect DATETIME value: '2022-10-03'`)},
+		{"concat('foo', 'bar', 42)", ok(`CONCAT(VARCHAR("foo"), VARCHAR("bar"), INT64(42))`), ok(`VARCHAR("foobar42")`)},
+		{"10 % 3", ok("INT64(10) % INT64(3)"), ok("INT64(1)")},
+		{"mod(10, 0)", ok("INT64(10) % INT64(0)"), ok("NULL")},
+		{"date_format(date'2022-10-03', '%W %D %M %Y')", ok(`DATE_FORMAT(DATE("2022-10-03"), VARCHAR("%W %D %M %Y"))`), ok(`VARCHAR("Monday 3rd October 2022")`)},
+		{"now() > date'2022-10-03'", ok(`NOW() > DATE("2022-10-03")`), ok(`NOW() > DATE("2022-10-03")`)},
+		{"lower('FooBar')", ok(`LOWER(VARCHAR("FooBar"))`), ok(`VARCHAR("foobar")`)},
+		{"upper(_latin1 'foo')", ok(`UPPER(VARCHAR("foo"))`), ok(`VARCHAR("FOO")`)},
+		{"length('føø')", ok(`LENGTH(VARCHAR("føø"))`), ok("INT64(5)")},
+		{"substring('foobar', 2, 3)", ok(`SUBSTRING(VARCHAR("foobar"), INT64(2), INT64(3))`), ok(`VARCHAR("oob")`)},
+		{"substring('foobar', -2)", ok(`SUBSTRING(VARCHAR("foobar"), -INT64(2))`), ok(`VARCHAR("ar")`)},
+		{"trim(leading 'x' from 'xxfooxx')", ok(`TRIM(VARCHAR("xxfooxx"), VARCHAR("x"))`), ok(`VARCHAR("fooxx")`)},
+		{"replace('foobar', 'o', '0')", ok(`REPLACE(VARCHAR("foobar"), VARCHAR("o"), VARCHAR("0"))`), ok(`VARCHAR("f00bar")`)},
+		{"round(2.5)", ok("ROUND(DECIMAL(2.5))"), ok("DECIMAL(3)")},
+		{"round(-2.5e0)", ok("ROUND(-FLOAT64(2.5))"), ok("FLOAT64(-2)")},
+		{"round(1234, -2)", ok("ROUND(INT64(1234), -INT64(2))"), ok("INT64(1200)")},
+		{"floor(18446744073709551615)", ok("FLOOR(UINT64(18446744073709551615))"), ok("UINT64(18446744073709551615)")},
+		{"floor(-1.5)", ok("FLOOR(-DECIMAL(1.5))"), ok("INT64(-2)")},
+		{"ceil(18446744073709551615)", ok("CEIL(UINT64(18446744073709551615))"), ok("UINT64(18446744073709551615)")},
+		{"abs(-9223372036854775807)", ok("ABS(-INT64(9223372036854775807))"), ok("INT64(9223372036854775807)")},
+		{"pow(2, 10)", ok("POW(INT64(2), INT64(10))"), ok("FLOAT64(1024)")},
+		{"date_add(date'2022-01-31', interval 1 month)", ok(`DATE_ADD(DATE("2022-01-31"), INT64(1))`), ok(`DATE("2022-02-28")`)},
+		{"date_sub('2022-03-01 10:00:00', interval 1 day)", ok(`DATE_SUB(VARCHAR("2022-03-01 10:00:00"), INT64(1))`), ok(`VARCHAR("2022-02-28 10:00:00")`)},
+		{"unix_timestamp()", ok("UNIX_TIMESTAMP()"), ok("UNIX_TIMESTAMP()")},
+		{`json_extract('{"a": [1, 2]}', '$.a[1]')`, ok(`JSON_EXTRACT(VARCHAR("{\"a\": [1, 2]}"), VARCHAR("$.a[1]"))`), ok(`JSON("2")`)},
+		{`json_unquote(json_extract('{"a": "b"}', '$.a'))`, ok(`JSON_UNQUOTE(JSON_EXTRACT(VARCHAR("{\"a\": \"b\"}"), VARCHAR("$.a")))`), ok(`TEXT("b")`)},
 	}
 
 	for _, tc := range testCases {
@@ -302,6 +328,74 @@ func TestEvaluate(t *testing.T) {
 	}
 }
 
+func TestEvaluateTimeZone(t *testing.T) {
+	tz, err := ParseTimeZone("+05:30")
+	require.NoError(t, err)
+
+	tests := []struct {
+		expression string
+		expected   sqltypes.Value
+	}{{
+		expression: "unix_timestamp('2022-10-03 05:30:00')",
+		expected:   sqltypes.NewInt64(1664755200),
+	}, {
+		expression: "date_format(now(), '%Y-%m-%d %H:%i')",
+		expected:   sqltypes.NewVarChar("2022-10-03 05:30"),
+	}, {
+		expression: "date_format(utc_timestamp(), '%Y-%m-%d %H:%i')",
+		expected:   sqltypes.NewVarChar("2022-10-03 00:00"),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			stmt, err := sqlparser.Parse("select " + test.expression)
+			require.NoError(t, err)
+			astExpr := stmt.(*sqlparser.Select).SelectExprs[0].(*sqlparser.AliasedExpr).Expr
+			expr, err := Translate(astExpr, LookupDefaultCollation(45))
+			require.NoError(t, err)
+
+			env := EnvWithBindVars(nil, 45)
+			env.TimeZone = tz
+			env.now = time.Date(2022, 10, 3, 0, 0, 0, 0, time.UTC)
+			r, err := env.Evaluate(expr)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, r.Value())
+		})
+	}
+}
+
+func TestParseTimeZone(t *testing.T) {
+	tests := []struct {
+		tz     string
+		offset int
+		err    bool
+	}{
+		{tz: "+05:30", offset: 5*3600 + 30*60},
+		{tz: "-08:00", offset: -8 * 3600},
+		{tz: "+14:00", offset: 14 * 3600},
+		{tz: "UTC", offset: 0},
+		{tz: "+14:01", err: true},
+		{tz: "+05:3", err: true},
+		{tz: "Not/A_Zone", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.tz, func(t *testing.T) {
+			loc, err := ParseTimeZone(test.tz)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			_, offset := time.Date(2022, 1, 1, 0, 0, 0, 0, loc).Zone()
+			assert.Equal(t, test.offset, offset)
+		})
+	}
+
+	loc, err := ParseTimeZone("SYSTEM")
+	require.NoError(t, err)
+	assert.Nil(t, loc)
+}
+
 func TestEvaluateTuple(t *testing.T) {
 	type testCase struct {
 		expression string
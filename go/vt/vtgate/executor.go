@@ -106,6 +106,7 @@ type Executor struct {
 	streamSize   int
 	plans        cache.Cache
 	vschemaStats *VSchemaStats
+	results      *resultCache
 
 	normalize       bool
 	warnShardedOnly bool
@@ -143,6 +144,7 @@ func NewExecutor(
 		scatterConn:     resolver.scatterConn,
 		txConn:          resolver.scatterConn.txConn,
 		plans:           cache.NewDefaultCacheImpl(cacheCfg),
+		results:         newResultCache(*resultCacheMemory),
 		normalize:       normalize,
 		warnShardedOnly: warnOnShardedOnly,
 		streamSize:      streamSize,
@@ -151,6 +153,8 @@ func NewExecutor(
 		pv:              pv,
 	}
 
+	e.txConn.tablesCommitted = e.invalidateCommittedTables
+
 	vschemaacl.Init()
 	// we subscribe to update from the VSchemaManager
 	e.vm = &VSchemaManager{
@@ -180,6 +184,23 @@ func NewExecutor(
 		stats.NewCounterFunc("QueryPlanCacheMisses", "Query plan cache misses", func() int64 {
 			return e.plans.Misses()
 		})
+		if e.results != nil {
+			stats.NewGaugeFunc("ResultCacheLength", "Result cache length", func() int64 {
+				return int64(e.results.results.Len())
+			})
+			stats.NewGaugeFunc("ResultCacheSize", "Result cache size", func() int64 {
+				return e.results.results.UsedCapacity()
+			})
+			stats.NewGaugeFunc("ResultCacheCapacity", "Result cache capacity", func() int64 {
+				return e.results.results.MaxCapacity()
+			})
+			stats.NewCounterFunc("ResultCacheEvictions", "Result cache evictions", func() int64 {
+				return e.results.results.Evictions()
+			})
+			stats.NewCounterFunc("ResultCacheHits", "Result cache hits", e.results.hits.Get)
+			stats.NewCounterFunc("ResultCacheMisses", "Result cache misses", e.results.misses.Get)
+			stats.NewCounterFunc("ResultCacheInvalidations", "Result cache invalidations", e.results.invalidations.Get)
+		}
 		http.Handle(pathQueryPlans, e)
 		http.Handle(pathScatterStats, e)
 		http.Handle(pathVSchema, e)
@@ -310,6 +331,7 @@ func (e *Executor) StreamExecute(
 		}
 
 		if !canReturnRows(plan.Type) {
+			e.invalidateResultCache(safeSession, plan)
 			return nil
 		}
 
@@ -913,13 +935,14 @@ func (e *Executor) showVitessReplicationStatus(ctx context.Context, filter *sqlp
 
 // MessageStream is part of the vtgate service API. This is a V2 level API that's sent
 // to the Resolver.
-func (e *Executor) MessageStream(ctx context.Context, keyspace string, shard string, keyRange *topodatapb.KeyRange, name string, callback func(*sqltypes.Result) error) error {
+func (e *Executor) MessageStream(ctx context.Context, keyspace string, shard string, keyRange *topodatapb.KeyRange, name, group string, callback func(*sqltypes.Result) error) error {
 	err := e.resolver.MessageStream(
 		ctx,
 		keyspace,
 		shard,
 		keyRange,
 		name,
+		group,
 		callback,
 	)
 	return formatError(err)
@@ -1297,8 +1320,8 @@ func (e *Executor) ExecuteLock(ctx context.Context, rs *srvtopo.ResolvedShard, q
 }
 
 // ExecuteMessageStream implements the IExecutor interface
-func (e *Executor) ExecuteMessageStream(ctx context.Context, rss []*srvtopo.ResolvedShard, tableName string, callback func(reply *sqltypes.Result) error) error {
-	return e.scatterConn.MessageStream(ctx, rss, tableName, callback)
+func (e *Executor) ExecuteMessageStream(ctx context.Context, rss []*srvtopo.ResolvedShard, tableName, group string, callback func(reply *sqltypes.Result) error) error {
+	return e.scatterConn.MessageStream(ctx, rss, tableName, group, callback)
 }
 
 // ExecuteVStream implements the IExecutor interface
@@ -200,6 +200,23 @@ func (e *Executor) executePlan(
 	execStart time.Time,
 ) (*sqltypes.Result, error) {
 
+	// Serve the query from the result cache if possible.
+	var resultCacheKey string
+	var resultCacheGeneration uint64
+	resultCacheTTL := e.resultCacheTTL(safeSession, plan)
+	if resultCacheTTL > 0 {
+		var err error
+		resultCacheKey, err = newResultCacheKey(ctx, vcursor, safeSession, plan, bindVars)
+		if err != nil {
+			return nil, err
+		}
+		if qr, ok := e.results.get(resultCacheKey, time.Now()); ok {
+			e.setLogStats(logStats, plan, vcursor, execStart, nil, qr)
+			return qr, nil
+		}
+		resultCacheGeneration = e.results.currentGeneration()
+	}
+
 	// 4: Execute!
 	qr, err := vcursor.ExecutePrimitive(ctx, plan.Instructions, bindVars, true)
 
@@ -210,6 +227,10 @@ func (e *Executor) executePlan(
 	if err != nil {
 		return nil, e.rollbackExecIfNeeded(ctx, safeSession, bindVars, logStats, err)
 	}
+	if resultCacheKey != "" && len(safeSession.GetWarnings()) == 0 {
+		e.results.set(resultCacheKey, qr, plan.TablesUsed, resultCacheTTL, resultCacheGeneration, time.Now())
+	}
+	e.invalidateResultCache(safeSession, plan)
 	return qr, nil
 }
 
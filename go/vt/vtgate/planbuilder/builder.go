@@ -124,15 +124,64 @@ func BuildFromStmt(query string, stmt sqlparser.Statement, reservedVars *sqlpars
 		tablesUsed = planResult.tables
 	}
 	plan := &engine.Plan{
-		Type:         sqlparser.ASTToStatementType(stmt),
-		Original:     query,
-		Instructions: primitive,
-		BindVarNeeds: bindVarNeeds,
-		TablesUsed:   tablesUsed,
+		Type:           sqlparser.ASTToStatementType(stmt),
+		Original:       query,
+		Instructions:   primitive,
+		BindVarNeeds:   bindVarNeeds,
+		TablesUsed:     tablesUsed,
+		ResultCacheTTL: sqlparser.ResultCacheTTLDirective(stmt),
+	}
+	if plan.Type == sqlparser.StmtSelect {
+		plan.Nondeterministic = isNondeterministic(stmt)
 	}
 	return plan, nil
 }
 
+// nondeterministicFuncs are the functions that can return a different value
+// every time they are called with the same arguments. The functions that
+// return the state of the session, like LAST_INSERT_ID(), are rewritten into
+// bind variables before planning.
+var nondeterministicFuncs = map[string]bool{
+	"benchmark":                  true,
+	"connection_id":              true,
+	"curdate":                    true,
+	"current_date":               true,
+	"current_time":               true,
+	"curtime":                    true,
+	"master_pos_wait":            true,
+	"now":                        true,
+	"rand":                       true,
+	"sleep":                      true,
+	"source_pos_wait":            true,
+	"sysdate":                    true,
+	"utc_date":                   true,
+	"utc_time":                   true,
+	"utc_timestamp":              true,
+	"uuid":                       true,
+	"uuid_short":                 true,
+	"wait_for_executed_gtid_set": true,
+}
+
+// isNondeterministic returns true if the statement calls a function whose
+// result can change even if the tables it reads don't, e.g. NOW() or RAND().
+func isNondeterministic(stmt sqlparser.Statement) bool {
+	nondeterministic := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.CurTimeFuncExpr, *sqlparser.LockingFunc:
+			nondeterministic = true
+		case *sqlparser.FuncExpr:
+			name := node.Name.Lowered()
+			// UNIX_TIMESTAMP() is the current time, but not UNIX_TIMESTAMP(date).
+			if nondeterministicFuncs[name] || (name == "unix_timestamp" && len(node.Exprs) == 0) {
+				nondeterministic = true
+			}
+		}
+		return !nondeterministic, nil
+	}, stmt)
+	return nondeterministic
+}
+
 func getConfiguredPlanner(vschema plancontext.VSchema, v3planner func(string) stmtPlanner, stmt sqlparser.Statement, query string) (stmtPlanner, error) {
 	planner, ok := getPlannerFromQuery(stmt)
 	if !ok {
@@ -213,19 +262,19 @@ func createInstructionFor(query string, stmt sqlparser.Statement, reservedVars *
 		}
 		return buildRoutePlan(stmt, reservedVars, vschema, configuredPlanner)
 	case *sqlparser.Insert:
-		return buildRoutePlan(stmt, reservedVars, vschema, buildInsertPlan)
+		return buildRoutePlan(stmt, reservedVars, vschema, fkManagedPlanner(buildInsertPlan))
 	case *sqlparser.Update:
 		configuredPlanner, err := getConfiguredPlanner(vschema, buildUpdatePlan, stmt, query)
 		if err != nil {
 			return nil, err
 		}
-		return buildRoutePlan(stmt, reservedVars, vschema, configuredPlanner)
+		return buildRoutePlan(stmt, reservedVars, vschema, fkManagedPlanner(configuredPlanner))
 	case *sqlparser.Delete:
 		configuredPlanner, err := getConfiguredPlanner(vschema, buildDeletePlan, stmt, query)
 		if err != nil {
 			return nil, err
 		}
-		return buildRoutePlan(stmt, reservedVars, vschema, configuredPlanner)
+		return buildRoutePlan(stmt, reservedVars, vschema, fkManagedPlanner(configuredPlanner))
 	case *sqlparser.Union:
 		configuredPlanner, err := getConfiguredPlanner(vschema, buildUnionPlan, stmt, query)
 		if err != nil {
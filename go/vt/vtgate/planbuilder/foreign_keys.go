@@ -0,0 +1,585 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"fmt"
+	"strings"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+// fkPlanner plans the primitives that enforce the foreign keys of the table
+// modified by a DML statement when vtgate manages foreign keys.
+// MySQL can only enforce foreign keys when the parent and child rows live on the
+// same shard, so this is only done for tables in sharded keyspaces.
+type fkPlanner struct {
+	vschema      plancontext.VSchema
+	reservedVars *sqlparser.ReservedVars
+
+	// path contains the tables whose changes are being cascaded,
+	// it's used to detect cycles between the foreign keys.
+	path []string
+}
+
+// fkChecksOffHint disables the foreign key checks of MySQL for a single statement.
+// It's added to the DMLs on the tables whose foreign keys are enforced by vtgate,
+// otherwise MySQL rejects the rows whose parent row lives on another shard.
+const fkChecksOffHint = "SET_VAR(foreign_key_checks = OFF)"
+
+// fkManagedPlanner wraps the planner of a DML statement so that,
+// with --foreign_key_mode=managed, the plan also enforces the foreign keys.
+func fkManagedPlanner(planner stmtPlanner) stmtPlanner {
+	return func(stmt sqlparser.Statement, reservedVars *sqlparser.ReservedVars, vschema plancontext.VSchema) (*planResult, error) {
+		if fkStrategyMap[vschema.ForeignKeyMode()] != fkManaged {
+			return planner(stmt, reservedVars, vschema)
+		}
+		fkp := &fkPlanner{vschema: vschema, reservedVars: reservedVars}
+		return fkp.plan(planner, stmt)
+	}
+}
+
+func (fkp *fkPlanner) plan(planner stmtPlanner, stmt sqlparser.Statement) (*planResult, error) {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Insert:
+		return fkp.planInsert(planner, stmt)
+	case *sqlparser.Update:
+		return fkp.planUpdate(planner, stmt)
+	case *sqlparser.Delete:
+		return fkp.planDelete(planner, stmt)
+	}
+	return planner(stmt, fkp.reservedVars, fkp.vschema)
+}
+
+func (fkp *fkPlanner) planInsert(planner stmtPlanner, ins *sqlparser.Insert) (*planResult, error) {
+	table := fkp.findTable(ins.Table)
+	if table == nil {
+		return planner(ins, fkp.reservedVars, fkp.vschema)
+	}
+	if ins.Action == sqlparser.ReplaceAct && len(table.ChildForeignKeys) > 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: REPLACE into table %s that is referenced by foreign keys", table.Name.String())
+	}
+	for _, expr := range ins.OnDup {
+		if fkColumnsContain(table, expr.Name.Name) {
+			return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: ON DUPLICATE KEY UPDATE of foreign key column %s", expr.Name.Name.String())
+		}
+	}
+	if len(table.ParentForeignKeys) == 0 {
+		if err := disableFkChecks(ins); err != nil {
+			return nil, err
+		}
+		return planner(ins, fkp.reservedVars, fkp.vschema)
+	}
+	rows, ok := ins.Rows.(sqlparser.Values)
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: INSERT ... SELECT into table %s with foreign keys", table.Name.String())
+	}
+	columns := ins.Columns
+	if columns == nil {
+		if !table.ColumnListAuthoritative {
+			return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: INSERT without a column list into table %s with foreign keys", table.Name.String())
+		}
+		for _, col := range table.Columns {
+			columns = append(columns, col.Name)
+		}
+	}
+
+	// the values must be read before the insert is planned, since the
+	// insert planner replaces the vindex columns with bind variables.
+	var verify []*engine.FkParent
+	tc := &tableCollector{}
+	for _, fk := range table.ParentForeignKeys {
+		offsets := make([]int, 0, len(fk.Columns))
+		found := false
+		for _, col := range fk.Columns {
+			offset := columns.FindColumn(col)
+			offsets = append(offsets, offset)
+			found = found || offset >= 0
+		}
+		if !found {
+			// the inserted rows use a NULL value for the foreign key
+			continue
+		}
+		values := make([][]evalengine.Expr, 0, len(rows))
+		for _, row := range rows {
+			if len(row) != len(columns) {
+				return nil, vterrors.New(vtrpcpb.Code_INVALID_ARGUMENT, "column list doesn't match values")
+			}
+			rowValues := make([]evalengine.Expr, 0, len(offsets))
+			for _, offset := range offsets {
+				if offset < 0 {
+					rowValues = append(rowValues, evalengine.NullExpr)
+					continue
+				}
+				expr, err := evalengine.Translate(row[offset], semantics.EmptySemTable())
+				if err != nil {
+					return nil, err
+				}
+				rowValues = append(rowValues, expr)
+			}
+			values = append(values, rowValues)
+		}
+		parent, err := fkp.planParentCheck(table, fk)
+		if err != nil {
+			return nil, err
+		}
+		parent.Values = values
+		verify = append(verify, parent)
+		tc.addTable(table.Keyspace.Name, fk.ParentTable.String())
+	}
+
+	// the checks of MySQL are only disabled once vtgate enforces all the foreign keys of the DML.
+	if err := disableFkChecks(ins); err != nil {
+		return nil, err
+	}
+	res, err := planner(ins, fkp.reservedVars, fkp.vschema)
+	if err != nil || len(verify) == 0 {
+		return res, err
+	}
+	tc.addAllTables(res.tables)
+	return newPlanResult(&engine.FkVerify{Verify: verify, Exec: res.primitive}, tc.getTables()...), nil
+}
+
+func (fkp *fkPlanner) planUpdate(planner stmtPlanner, upd *sqlparser.Update) (*planResult, error) {
+	table, err := fkp.findDMLTable(upd.TableExprs)
+	if err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return planner(upd, fkp.reservedVars, fkp.vschema)
+	}
+
+	updated := map[string]sqlparser.Expr{}
+	for _, expr := range upd.Exprs {
+		updated[expr.Name.Name.Lowered()] = expr.Expr
+	}
+
+	// the new values written to the child columns must exist in the parent table
+	var verify []*engine.FkParent
+	tc := &tableCollector{}
+	for _, fk := range table.ParentForeignKeys {
+		var exprs []sqlparser.Expr
+		for _, col := range fk.Columns {
+			if expr, ok := updated[col.Lowered()]; ok {
+				exprs = append(exprs, expr)
+			}
+		}
+		if len(exprs) == 0 {
+			continue
+		}
+		if len(exprs) != len(fk.Columns) {
+			return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: update of a subset of the columns of foreign key %s", fk.Name)
+		}
+		values := make([]evalengine.Expr, 0, len(exprs))
+		allNull := true
+		for i, expr := range exprs {
+			if !isConstantExpr(expr) {
+				return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: foreign key column %s updated with a non-constant expression", fk.Columns[i].String())
+			}
+			_, isNull := expr.(*sqlparser.NullVal)
+			allNull = allNull && isNull
+			value, err := evalengine.Translate(expr, semantics.EmptySemTable())
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		if allNull {
+			continue
+		}
+		parent, err := fkp.planParentCheck(table, fk)
+		if err != nil {
+			return nil, err
+		}
+		parent.Values = [][]evalengine.Expr{values}
+		verify = append(verify, parent)
+		tc.addTable(table.Keyspace.Name, fk.ParentTable.String())
+	}
+
+	// the children that reference the updated parent columns need to be updated or checked
+	var fks []*vindexes.ForeignKey
+	for _, fk := range table.ChildForeignKeys {
+		for _, col := range fk.ParentColumns {
+			expr, ok := updated[col.Lowered()]
+			if !ok {
+				continue
+			}
+			if !isConstantExpr(expr) {
+				return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: column %s referenced by foreign key %s updated with a non-constant expression", col.String(), fk.Name)
+			}
+			fks = append(fks, fk)
+			break
+		}
+	}
+
+	childStatement := func(fk *vindexes.ForeignKey) (sqlparser.Statement, bool, error) {
+		switch fk.OnUpdate {
+		case sqlparser.Cascade:
+			var exprs sqlparser.UpdateExprs
+			for i, col := range fk.ParentColumns {
+				var expr sqlparser.Expr = sqlparser.NewArgument(engine.FkChildVarName(i))
+				if newValue, ok := updated[col.Lowered()]; ok {
+					expr = sqlparser.CloneExpr(newValue)
+				}
+				exprs = append(exprs, &sqlparser.UpdateExpr{Name: &sqlparser.ColName{Name: fk.Columns[i]}, Expr: expr})
+			}
+			return fkp.childUpdate(table, fk, exprs), false, nil
+		case sqlparser.SetNull:
+			return fkp.childUpdate(table, fk, setNullExprs(fk)), false, nil
+		case sqlparser.SetDefault:
+			return nil, false, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: ON UPDATE SET DEFAULT for foreign key %s", fk.Name)
+		default:
+			return fkp.childCheck(table, fk), true, nil
+		}
+	}
+
+	if err := disableFkChecks(upd); err != nil {
+		return nil, err
+	}
+	var res *planResult
+	if len(fks) == 0 {
+		res, err = planner(upd, fkp.reservedVars, fkp.vschema)
+	} else {
+		res, err = fkp.planCascade(planner, upd, table, fks, upd.TableExprs, upd.Where, upd.OrderBy, upd.Limit, childStatement)
+	}
+	if err != nil || len(verify) == 0 {
+		return res, err
+	}
+	tc.addAllTables(res.tables)
+	return newPlanResult(&engine.FkVerify{Verify: verify, Exec: res.primitive}, tc.getTables()...), nil
+}
+
+func (fkp *fkPlanner) planDelete(planner stmtPlanner, del *sqlparser.Delete) (*planResult, error) {
+	table, err := fkp.findDMLTable(del.TableExprs)
+	if err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return planner(del, fkp.reservedVars, fkp.vschema)
+	}
+	if len(table.ChildForeignKeys) == 0 {
+		if err := disableFkChecks(del); err != nil {
+			return nil, err
+		}
+		return planner(del, fkp.reservedVars, fkp.vschema)
+	}
+
+	childStatement := func(fk *vindexes.ForeignKey) (sqlparser.Statement, bool, error) {
+		switch fk.OnDelete {
+		case sqlparser.Cascade:
+			return &sqlparser.Delete{
+				TableExprs: fkp.tableExprs(table, fk.Table),
+				Where:      sqlparser.NewWhere(sqlparser.WhereClause, childWhere(fk)),
+			}, false, nil
+		case sqlparser.SetNull:
+			return fkp.childUpdate(table, fk, setNullExprs(fk)), false, nil
+		case sqlparser.SetDefault:
+			return nil, false, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: ON DELETE SET DEFAULT for foreign key %s", fk.Name)
+		default:
+			return fkp.childCheck(table, fk), true, nil
+		}
+	}
+	if err := disableFkChecks(del); err != nil {
+		return nil, err
+	}
+	return fkp.planCascade(planner, del, table, table.ChildForeignKeys, del.TableExprs, del.Where, del.OrderBy, del.Limit, childStatement)
+}
+
+// planCascade plans the FkCascade primitive for a DML that modifies the referenced columns of
+// the given foreign keys. The selection of the parent rows uses the same table expressions,
+// filter, ordering and limit as the DML, so that it reads the rows that are going to be modified.
+func (fkp *fkPlanner) planCascade(
+	planner stmtPlanner,
+	stmt sqlparser.Statement,
+	table *vindexes.Table,
+	fks []*vindexes.ForeignKey,
+	tableExprs sqlparser.TableExprs,
+	where *sqlparser.Where,
+	orderBy sqlparser.OrderBy,
+	limit *sqlparser.Limit,
+	childStatement func(*vindexes.ForeignKey) (sqlparser.Statement, bool, error),
+) (*planResult, error) {
+	tableName := table.ToString()
+	for _, tbl := range fkp.path {
+		if tbl == tableName {
+			return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: cyclic foreign keys on table %s", table.Name.String())
+		}
+	}
+	child := &fkPlanner{
+		vschema:      fkp.vschema,
+		reservedVars: fkp.reservedVars,
+		path:         append(append([]string{}, fkp.path...), tableName),
+	}
+
+	tc := &tableCollector{}
+	var selectExprs sqlparser.SelectExprs
+	offsets := map[string]int{}
+	var restricts, cascades []*engine.FkChild
+	for _, fk := range fks {
+		childStmt, restrict, err := childStatement(fk)
+		if err != nil {
+			return nil, err
+		}
+		fkChild := &engine.FkChild{Restrict: restrict, Constraint: fkConstraint(table.Keyspace.Name, fk)}
+		for _, col := range fk.ParentColumns {
+			offset, ok := offsets[col.Lowered()]
+			if !ok {
+				offset = len(selectExprs)
+				offsets[col.Lowered()] = offset
+				selectExprs = append(selectExprs, &sqlparser.AliasedExpr{Expr: &sqlparser.ColName{Name: col}})
+			}
+			fkChild.Cols = append(fkChild.Cols, offset)
+		}
+		res, err := child.planStatement(childStmt)
+		if err != nil {
+			return nil, err
+		}
+		tc.addAllTables(res.tables)
+		fkChild.Exec = res.primitive
+		if restrict {
+			restricts = append(restricts, fkChild)
+		} else {
+			cascades = append(cascades, fkChild)
+		}
+	}
+
+	// the selection locks the parent rows until the end of the transaction
+	sel := &sqlparser.Select{
+		SelectExprs: selectExprs,
+		From:        sqlparser.CloneTableExprs(tableExprs),
+		Where:       sqlparser.CloneRefOfWhere(where),
+		OrderBy:     sqlparser.CloneOrderBy(orderBy),
+		Limit:       sqlparser.CloneRefOfLimit(limit),
+		Lock:        sqlparser.ForUpdateLock,
+	}
+	selRes, err := fkp.planStatement(sel)
+	if err != nil {
+		return nil, err
+	}
+	res, err := planner(stmt, fkp.reservedVars, fkp.vschema)
+	if err != nil {
+		return nil, err
+	}
+	tc.addAllTables(res.tables)
+
+	// all the RESTRICT checks run before any change is cascaded
+	return newPlanResult(&engine.FkCascade{
+		Selection: selRes.primitive,
+		Children:  append(restricts, cascades...),
+		Parent:    res.primitive,
+	}, tc.getTables()...), nil
+}
+
+// planStatement plans the statements generated to enforce the foreign keys,
+// with the planner that is configured for the session.
+func (fkp *fkPlanner) planStatement(stmt sqlparser.Statement) (*planResult, error) {
+	query := sqlparser.String(stmt)
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select:
+		planner, err := getConfiguredPlanner(fkp.vschema, buildSelectPlan, stmt, query)
+		if err != nil {
+			return nil, err
+		}
+		return planner(stmt, fkp.reservedVars, fkp.vschema)
+	case *sqlparser.Update:
+		planner, err := getConfiguredPlanner(fkp.vschema, buildUpdatePlan, stmt, query)
+		if err != nil {
+			return nil, err
+		}
+		return fkp.plan(planner, stmt)
+	case *sqlparser.Delete:
+		planner, err := getConfiguredPlanner(fkp.vschema, buildDeletePlan, stmt, query)
+		if err != nil {
+			return nil, err
+		}
+		return fkp.plan(planner, stmt)
+	}
+	return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "[BUG] unexpected statement type for foreign keys: %T", stmt)
+}
+
+// planParentCheck plans the SELECT that verifies the existence of the parent row.
+func (fkp *fkPlanner) planParentCheck(table *vindexes.Table, fk *vindexes.ForeignKey) (*engine.FkParent, error) {
+	var exprs []sqlparser.Expr
+	for i, col := range fk.ParentColumns {
+		exprs = append(exprs, &sqlparser.ComparisonExpr{
+			Operator: sqlparser.EqualOp,
+			Left:     &sqlparser.ColName{Name: col},
+			Right:    sqlparser.NewArgument(engine.FkParentVarName(i)),
+		})
+	}
+	sel := &sqlparser.Select{
+		SelectExprs: sqlparser.SelectExprs{&sqlparser.AliasedExpr{Expr: sqlparser.NewIntLiteral("1")}},
+		From:        fkp.tableExprs(table, fk.ParentTable),
+		Where:       sqlparser.NewWhere(sqlparser.WhereClause, sqlparser.AndExpressions(exprs...)),
+		Limit:       &sqlparser.Limit{Rowcount: sqlparser.NewIntLiteral("1")},
+		Lock:        sqlparser.ShareModeLock,
+	}
+	res, err := fkp.planStatement(sel)
+	if err != nil {
+		return nil, err
+	}
+	return &engine.FkParent{Exec: res.primitive, Constraint: fkConstraint(table.Keyspace.Name, fk)}, nil
+}
+
+// childCheck returns the SELECT that finds a child row referencing the parent row.
+func (fkp *fkPlanner) childCheck(table *vindexes.Table, fk *vindexes.ForeignKey) *sqlparser.Select {
+	return &sqlparser.Select{
+		SelectExprs: sqlparser.SelectExprs{&sqlparser.AliasedExpr{Expr: sqlparser.NewIntLiteral("1")}},
+		From:        fkp.tableExprs(table, fk.Table),
+		Where:       sqlparser.NewWhere(sqlparser.WhereClause, childWhere(fk)),
+		Limit:       &sqlparser.Limit{Rowcount: sqlparser.NewIntLiteral("1")},
+		Lock:        sqlparser.ShareModeLock,
+	}
+}
+
+func (fkp *fkPlanner) childUpdate(table *vindexes.Table, fk *vindexes.ForeignKey, exprs sqlparser.UpdateExprs) *sqlparser.Update {
+	return &sqlparser.Update{
+		TableExprs: fkp.tableExprs(table, fk.Table),
+		Exprs:      exprs,
+		Where:      sqlparser.NewWhere(sqlparser.WhereClause, childWhere(fk)),
+	}
+}
+
+// tableExprs qualifies the table with the keyspace of the table being modified,
+// since the tables of a foreign key always belong to the same keyspace.
+func (fkp *fkPlanner) tableExprs(table *vindexes.Table, name sqlparser.IdentifierCS) sqlparser.TableExprs {
+	return sqlparser.TableExprs{&sqlparser.AliasedTableExpr{
+		Expr: sqlparser.TableName{Name: name, Qualifier: sqlparser.NewIdentifierCS(table.Keyspace.Name)},
+	}}
+}
+
+// findTable returns the vschema table if vtgate has to enforce its foreign keys.
+func (fkp *fkPlanner) findTable(name sqlparser.TableName) *vindexes.Table {
+	table, _, _, _, err := fkp.vschema.FindTable(name)
+	if err != nil || table == nil || table.Keyspace == nil || !table.Keyspace.Sharded {
+		// errors are left for the DML planner to report
+		return nil
+	}
+	if len(table.ParentForeignKeys) == 0 && len(table.ChildForeignKeys) == 0 {
+		return nil
+	}
+	return table
+}
+
+// findDMLTable returns the table modified by an UPDATE or DELETE if vtgate has to
+// enforce its foreign keys. Only single table statements are supported.
+func (fkp *fkPlanner) findDMLTable(tableExprs sqlparser.TableExprs) (*vindexes.Table, error) {
+	if len(tableExprs) == 1 {
+		if aliased, ok := tableExprs[0].(*sqlparser.AliasedTableExpr); ok {
+			if name, ok := aliased.Expr.(sqlparser.TableName); ok {
+				return fkp.findTable(name), nil
+			}
+		}
+	}
+	var table *vindexes.Table
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if aliased, ok := node.(*sqlparser.AliasedTableExpr); ok {
+			if name, ok := aliased.Expr.(sqlparser.TableName); ok && table == nil {
+				table = fkp.findTable(name)
+			}
+		}
+		return true, nil
+	}, tableExprs)
+	if table != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: multi-table DML on table %s with foreign keys", table.Name.String())
+	}
+	return nil, nil
+}
+
+// disableFkChecks adds the fkChecksOffHint to the statement.
+func disableFkChecks(stmt sqlparser.SupportOptimizerHint) error {
+	comments, err := stmt.GetParsedComments().AddQueryHint(fkChecksOffHint)
+	if err != nil {
+		return err
+	}
+	stmt.SetComments(comments)
+	return nil
+}
+
+func childWhere(fk *vindexes.ForeignKey) sqlparser.Expr {
+	var exprs []sqlparser.Expr
+	for i, col := range fk.Columns {
+		exprs = append(exprs, &sqlparser.ComparisonExpr{
+			Operator: sqlparser.EqualOp,
+			Left:     &sqlparser.ColName{Name: col},
+			Right:    sqlparser.NewArgument(engine.FkChildVarName(i)),
+		})
+	}
+	return sqlparser.AndExpressions(exprs...)
+}
+
+func setNullExprs(fk *vindexes.ForeignKey) sqlparser.UpdateExprs {
+	var exprs sqlparser.UpdateExprs
+	for _, col := range fk.Columns {
+		exprs = append(exprs, &sqlparser.UpdateExpr{Name: &sqlparser.ColName{Name: col}, Expr: &sqlparser.NullVal{}})
+	}
+	return exprs
+}
+
+// fkColumnsContain returns true if the column takes part in any of the foreign keys of the table.
+func fkColumnsContain(table *vindexes.Table, col sqlparser.IdentifierCI) bool {
+	for _, fk := range table.ParentForeignKeys {
+		for _, c := range fk.Columns {
+			if c.Equal(col) {
+				return true
+			}
+		}
+	}
+	for _, fk := range table.ChildForeignKeys {
+		for _, c := range fk.ParentColumns {
+			if c.Equal(col) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isConstantExpr returns true if the expression does not depend on the row being modified.
+func isConstantExpr(expr sqlparser.Expr) bool {
+	constant := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node.(type) {
+		case *sqlparser.ColName, *sqlparser.Subquery:
+			constant = false
+			return false, nil
+		}
+		return true, nil
+	}, expr)
+	return constant
+}
+
+// fkConstraint describes the foreign key the same way MySQL does in its error messages.
+func fkConstraint(keyspace string, fk *vindexes.ForeignKey) string {
+	return fmt.Sprintf("`%s`.`%s`, CONSTRAINT `%s` FOREIGN KEY (%s) REFERENCES `%s` (%s)",
+		keyspace, fk.Table.String(), fk.Name, quotedColumns(fk.Columns), fk.ParentTable.String(), quotedColumns(fk.ParentColumns))
+}
+
+func quotedColumns(cols []sqlparser.IdentifierCI) string {
+	quoted := make([]string, 0, len(cols))
+	for _, col := range cols {
+		quoted = append(quoted, "`"+col.String()+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -79,6 +79,11 @@ func (hp *horizonPlanning) planHorizon(ctx *plancontext.PlanningContext, plan lo
 	// If we still have a HAVING clause, it's because it could not be pushed to the WHERE,
 	// so it probably has aggregations
 	switch {
+	case hp.qp.HasWindow && !canPushDownWindowFunctions(ctx, hp.qp, plan):
+		plan, err = hp.planWindow(ctx, plan)
+		if err != nil {
+			return nil, err
+		}
 	case hp.qp.NeedsAggregation() || hp.sel.Having != nil:
 		plan, err = hp.planAggregations(ctx, plan)
 		if err != nil {
@@ -634,7 +639,7 @@ func (hp *horizonPlanning) planOrderBy(ctx *plancontext.PlanningContext, orderEx
 		return plan, nil
 	case *memorySort:
 		return plan, nil
-	case *simpleProjection:
+	case *simpleProjection, *window:
 		return hp.createMemorySortPlan(ctx, plan, orderExprs, true)
 	case *vindexFunc:
 		// This is evaluated at VTGate only, so weight_string function cannot be used.
@@ -913,7 +918,7 @@ func (hp *horizonPlanning) planDistinct(ctx *plancontext.PlanningContext, plan l
 		}
 
 		return hp.addDistinct(ctx, plan)
-	case *joinGen4, *pulloutSubquery:
+	case *joinGen4, *pulloutSubquery, *window:
 		return hp.addDistinct(ctx, plan)
 	case *orderedAggregate:
 		return hp.planDistinctOA(ctx.SemTable, p)
@@ -251,6 +251,7 @@ func TestPlan(t *testing.T) {
 	testFile(t, "vindex_func_cases.txt", testOutputTempDir, vschemaWrapper, false)
 	testFile(t, "wireup_cases.txt", testOutputTempDir, vschemaWrapper, false)
 	testFile(t, "memory_sort_cases.txt", testOutputTempDir, vschemaWrapper, false)
+	testFile(t, "window_cases.txt", testOutputTempDir, vschemaWrapper, false)
 	testFile(t, "use_cases.txt", testOutputTempDir, vschemaWrapper, false)
 	testFile(t, "set_cases.txt", testOutputTempDir, vschemaWrapper, false)
 	testFile(t, "union_cases.txt", testOutputTempDir, vschemaWrapper, false)
@@ -273,6 +274,37 @@ func TestSysVarSetDisabled(t *testing.T) {
 	testFile(t, "set_sysvar_disabled_cases.txt", makeTestOutput(t), vschemaWrapper, false)
 }
 
+func TestForeignKeyCases(t *testing.T) {
+	testFile(t, "foreign_key_cases.txt", makeTestOutput(t), fkTestVSchema(t), false)
+}
+
+// fkTestVSchema returns the test vschema with --foreign_key_mode=managed, and with
+// the foreign keys that the schema tracker would have found on the user keyspace.
+func fkTestVSchema(t *testing.T) *vschemaWrapper {
+	vschema := loadSchema(t, "schema_test.json", true)
+	tables := vschema.Keyspaces["user"].Tables
+	addForeignKey := func(name, child, childCol, parent, parentCol string, onDelete, onUpdate sqlparser.ReferenceAction) {
+		fk := &vindexes.ForeignKey{
+			Name:          name,
+			Table:         sqlparser.NewIdentifierCS(child),
+			Columns:       []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI(childCol)},
+			ParentTable:   sqlparser.NewIdentifierCS(parent),
+			ParentColumns: []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI(parentCol)},
+			OnDelete:      onDelete,
+			OnUpdate:      onUpdate,
+		}
+		tables[child].ParentForeignKeys = append(tables[child].ParentForeignKeys, fk)
+		tables[parent].ChildForeignKeys = append(tables[parent].ChildForeignKeys, fk)
+	}
+	addForeignKey("fk_extra_auth", "user_extra", "col", "authoritative", "col1", sqlparser.Cascade, sqlparser.Restrict)
+	addForeignKey("fk_music_extra_auth", "music_extra", "col", "authoritative", "col2", sqlparser.Restrict, sqlparser.SetNull)
+
+	return &vschemaWrapper{
+		v:      vschema,
+		fkMode: "managed",
+	}
+}
+
 func TestOne(t *testing.T) {
 	vschema := &vschemaWrapper{
 		v: loadSchema(t, "schema_test.json", true),
@@ -536,6 +568,7 @@ type vschemaWrapper struct {
 	dest          key.Destination
 	sysVarEnabled bool
 	version       plancontext.PlannerVersion
+	fkMode        string
 }
 
 func (vw *vschemaWrapper) GetVSchema() *vindexes.VSchema {
@@ -564,7 +597,10 @@ func (vw *vschemaWrapper) PlannerWarning(_ string) {
 }
 
 func (vw *vschemaWrapper) ForeignKeyMode() string {
-	return "allow"
+	if vw.fkMode == "" {
+		return "allow"
+	}
+	return vw.fkMode
 }
 
 func (vw *vschemaWrapper) AllKeyspace() ([]*vindexes.Keyspace, error) {
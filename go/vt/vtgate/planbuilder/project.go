@@ -73,7 +73,7 @@ func planProjection(pb *primitiveBuilder, in logicalPlan, expr *sqlparser.Aliase
 		// others. This functionality depends on the PushOrderBy to request that
 		// the rows be correctly ordered.
 	case *orderedAggregate:
-		if aggrFunc, isAggregate := expr.Expr.(sqlparser.AggrFunc); isAggregate {
+		if aggrFunc, isAggregate := expr.Expr.(sqlparser.AggrFunc); isAggregate && sqlparser.IsAggregation(aggrFunc) {
 			if _, ok := engine.SupportedAggregates[strings.ToLower(aggrFunc.AggrName())]; ok {
 				rc, colNumber, err := node.pushAggr(pb, expr, origin)
 				if err != nil {
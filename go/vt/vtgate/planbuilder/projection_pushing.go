@@ -48,6 +48,8 @@ func pushProjection(
 		return pushProjectionIntoSimpleProj(ctx, expr, node, inner, hasAggregation, reuseCol)
 	case *orderedAggregate:
 		return pushProjectionIntoOA(ctx, expr, node, inner, hasAggregation)
+	case *window:
+		return pushProjectionIntoWindow(ctx, expr, node, inner, hasAggregation)
 	case *vindexFunc:
 		return pushProjectionIntoVindexFunc(node, expr, reuseCol)
 	case *semiJoin:
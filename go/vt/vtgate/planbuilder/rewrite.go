@@ -191,8 +191,7 @@ func rewriteHavingClause(node *sqlparser.Select) {
 				}
 				return false
 			default:
-				_, isAggregate := x.(sqlparser.AggrFunc)
-				hasAggr = hasAggr || isAggregate
+				hasAggr = hasAggr || sqlparser.IsAggregation(x)
 			}
 			return true
 		}, nil)
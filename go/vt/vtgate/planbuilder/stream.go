@@ -17,6 +17,8 @@ limitations under the License.
 package planbuilder
 
 import (
+	"strings"
+
 	"vitess.io/vitess/go/vt/key"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
@@ -37,9 +39,11 @@ func buildStreamPlan(stmt *sqlparser.Stream, vschema plancontext.VSchema) (*plan
 	if dest == nil {
 		dest = key.DestinationExactKeyRange{}
 	}
+	group, _ := stmt.Comments.Directives().GetString(sqlparser.DirectiveConsumerGroup, "")
 	return newPlanResult(&engine.MStream{
 		Keyspace:          table.Keyspace,
 		TargetDestination: dest,
 		TableName:         table.Name.CompliantName(),
+		ConsumerGroup:     strings.ToLower(group),
 	}), nil
 }
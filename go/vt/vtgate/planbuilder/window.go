@@ -0,0 +1,352 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"strings"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/abstract"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+var _ logicalPlan = (*window)(nil)
+
+// window is the logicalPlan for engine.Window.
+// It is used when the window functions of a query cannot be
+// evaluated by MySQL, because the rows of a single partition
+// can live on different shards.
+type window struct {
+	gen4Plan
+	input   logicalPlan
+	eWindow *engine.Window
+
+	// outputs holds the expression produced by each of the output columns
+	outputs []*sqlparser.AliasedExpr
+}
+
+// WireupGen4 implements the logicalPlan interface
+func (w *window) WireupGen4(ctx *plancontext.PlanningContext) error {
+	return w.input.WireupGen4(ctx)
+}
+
+// Primitive implements the logicalPlan interface
+func (w *window) Primitive() engine.Primitive {
+	w.eWindow.Input = w.input.Primitive()
+	return w.eWindow
+}
+
+// Inputs implements the logicalPlan interface
+func (w *window) Inputs() []logicalPlan {
+	return []logicalPlan{w.input}
+}
+
+// Rewrite implements the logicalPlan interface
+func (w *window) Rewrite(inputs ...logicalPlan) error {
+	if len(inputs) != 1 {
+		return vterrors.New(vtrpcpb.Code_INTERNAL, "[BUG]: window: wrong number of inputs")
+	}
+	w.input = inputs[0]
+	return nil
+}
+
+// ContainsTables implements the logicalPlan interface
+func (w *window) ContainsTables() semantics.TableSet {
+	return w.input.ContainsTables()
+}
+
+// OutputColumns implements the logicalPlan interface
+func (w *window) OutputColumns() []sqlparser.SelectExpr {
+	cols := make([]sqlparser.SelectExpr, 0, len(w.outputs))
+	for _, expr := range w.outputs {
+		cols = append(cols, expr)
+	}
+	return cols
+}
+
+func (w *window) addColumn(col int, expr *sqlparser.AliasedExpr) int {
+	w.eWindow.Cols = append(w.eWindow.Cols, col)
+	w.outputs = append(w.outputs, expr)
+	return len(w.outputs) - 1
+}
+
+// findColumn returns the offset of the output column producing the given expression, or -1
+func (w *window) findColumn(expr sqlparser.Expr) int {
+	if ws, isWeightString := expr.(*sqlparser.WeightStringFuncExpr); isWeightString {
+		// the results of the window functions are numbers or values coming
+		// from the input, so there is no need for a weight_string column for them
+		offset := w.findColumn(ws.Expr)
+		if offset >= 0 && w.eWindow.Cols[offset] < 0 {
+			return offset
+		}
+		return -1
+	}
+	colName, isColName := expr.(*sqlparser.ColName)
+	for i, output := range w.outputs {
+		if sqlparser.EqualsExpr(output.Expr, expr) {
+			return i
+		}
+		if isColName && colName.Qualifier.IsEmpty() && !output.As.IsEmpty() && colName.Name.Equal(output.As) {
+			return i
+		}
+	}
+	return -1
+}
+
+func pushProjectionIntoWindow(ctx *plancontext.PlanningContext, expr *sqlparser.AliasedExpr, node *window, inner, hasAggregation bool) (int, bool, error) {
+	if offset := node.findColumn(expr.Expr); offset >= 0 {
+		return offset, false, nil
+	}
+	if sqlparser.ContainsWindowFunction(expr.Expr) {
+		return 0, false, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: window function in a complex expression in cross-shard query: %s", sqlparser.String(expr))
+	}
+	offset, _, err := pushProjection(ctx, expr, node.input, inner, true, hasAggregation)
+	if err != nil {
+		return 0, false, err
+	}
+	return node.addColumn(offset, expr), true, nil
+}
+
+// canPushDownWindowFunctions returns true if all window functions of the query can be evaluated by MySQL.
+// This is the case when the input is a single route, and every window is partitioned by a column
+// that has a unique vindex, meaning that all the rows of a partition live on the same shard.
+func canPushDownWindowFunctions(ctx *plancontext.PlanningContext, qp *abstract.QueryProjection, plan logicalPlan) bool {
+	if qp.NeedsAggregation() {
+		return false
+	}
+	if _, isRoute := plan.(*routeGen4); !isRoute {
+		return false
+	}
+	canPush := true
+	for _, e := range qp.SelectExprs {
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			expr, isExpr := node.(sqlparser.Expr)
+			if !isExpr {
+				return true, nil
+			}
+			over := sqlparser.GetOverClause(expr)
+			if over == nil {
+				return true, nil
+			}
+			if over.WindowSpec == nil || !partitionHasUniqueVindex(ctx.SemTable, over.WindowSpec.PartitionClause) {
+				canPush = false
+			}
+			return false, nil
+		}, e.Col)
+	}
+	return canPush
+}
+
+func partitionHasUniqueVindex(semTable *semantics.SemTable, partition sqlparser.Exprs) bool {
+	for _, expr := range partition {
+		if exprHasUniqueVindex(semTable, expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// planWindow plans an engine.Window on top of the given plan. The input is sorted on
+// the PARTITION BY and ORDER BY expressions of the window, so that the Window
+// primitive can evaluate the window functions while streaming through the rows.
+func (hp *horizonPlanning) planWindow(ctx *plancontext.PlanningContext, plan logicalPlan) (logicalPlan, error) {
+	if hp.qp.NeedsAggregation() || hp.sel.Having != nil {
+		return nil, vterrors.New(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: window functions with aggregation in cross-shard query")
+	}
+	spec, err := windowSpecification(hp.qp)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &window{
+		eWindow: &engine.Window{},
+	}
+	for _, e := range hp.qp.SelectExprs {
+		aliasExpr, err := e.GetAliasedExpr()
+		if err != nil {
+			return nil, err
+		}
+		if sqlparser.GetOverClause(aliasExpr.Expr) == nil {
+			if sqlparser.ContainsWindowFunction(aliasExpr.Expr) {
+				return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: window function in a complex expression in cross-shard query: %s", sqlparser.String(aliasExpr))
+			}
+			offset, _, err := pushProjection(ctx, aliasExpr, plan, true, false, false)
+			if err != nil {
+				return nil, err
+			}
+			w.addColumn(offset, aliasExpr)
+			continue
+		}
+		fn, err := createWindowFunction(ctx, aliasExpr, plan)
+		if err != nil {
+			return nil, err
+		}
+		w.eWindow.Functions = append(w.eWindow.Functions, fn)
+		w.addColumn(-len(w.eWindow.Functions), aliasExpr)
+	}
+
+	var order []abstract.OrderBy
+	for _, expr := range spec.PartitionClause {
+		params, err := pushWindowOrdering(ctx, expr, false, plan)
+		if err != nil {
+			return nil, err
+		}
+		w.eWindow.PartitionBy = append(w.eWindow.PartitionBy, params)
+		order = append(order, abstract.OrderBy{
+			Inner:         &sqlparser.Order{Expr: expr, Direction: sqlparser.AscOrder},
+			WeightStrExpr: expr,
+		})
+	}
+	for _, o := range spec.OrderClause {
+		params, err := pushWindowOrdering(ctx, o.Expr, o.Direction == sqlparser.DescOrder, plan)
+		if err != nil {
+			return nil, err
+		}
+		w.eWindow.OrderBy = append(w.eWindow.OrderBy, params)
+		order = append(order, abstract.OrderBy{
+			Inner:         o,
+			WeightStrExpr: o.Expr,
+		})
+	}
+
+	if len(order) > 0 {
+		plan, err = hp.planOrderBy(ctx, order, plan)
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.input = plan
+	return w, nil
+}
+
+func pushWindowOrdering(ctx *plancontext.PlanningContext, expr sqlparser.Expr, desc bool, plan logicalPlan) (engine.OrderByParams, error) {
+	offset, wsOffset, err := wrapAndPushExpr(ctx, expr, expr, plan)
+	if err != nil {
+		return engine.OrderByParams{}, err
+	}
+	return engine.OrderByParams{
+		Col:               offset,
+		WeightStringCol:   wsOffset,
+		Desc:              desc,
+		StarColFixedIndex: offset,
+		CollationID:       ctx.SemTable.CollationForExpr(expr),
+	}, nil
+}
+
+// windowSpecification returns the window specification shared by all the window functions
+// of the query. The Window primitive only evaluates a single window at a time.
+func windowSpecification(qp *abstract.QueryProjection) (*sqlparser.WindowSpecification, error) {
+	var spec *sqlparser.WindowSpecification
+	for _, e := range qp.SelectExprs {
+		expr, err := e.GetExpr()
+		if err != nil {
+			return nil, err
+		}
+		over := sqlparser.GetOverClause(expr)
+		if over == nil {
+			if sqlparser.ContainsWindowFunction(expr) {
+				return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: window function in a complex expression in cross-shard query: %s", sqlparser.String(expr))
+			}
+			continue
+		}
+		if over.WindowSpec == nil || !over.WindowName.IsEmpty() || !over.WindowSpec.Name.IsEmpty() {
+			return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: named window in cross-shard query: %s", sqlparser.String(expr))
+		}
+		if over.WindowSpec.FrameClause != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: window frame clause in cross-shard query: %s", sqlparser.String(expr))
+		}
+		if spec == nil {
+			spec = over.WindowSpec
+			continue
+		}
+		if !sqlparser.EqualsRefOfWindowSpecification(spec, over.WindowSpec) {
+			return nil, vterrors.New(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: window functions with different windows in cross-shard query")
+		}
+	}
+	if spec == nil {
+		return nil, vterrors.New(vtrpcpb.Code_INTERNAL, "[BUG] no window function found in the select expressions")
+	}
+	return spec, nil
+}
+
+// createWindowFunction creates the engine.WindowFunction for the given select expression,
+// and pushes the column it needs to the input plan
+func createWindowFunction(ctx *plancontext.PlanningContext, aliasExpr *sqlparser.AliasedExpr, plan logicalPlan) (*engine.WindowFunction, error) {
+	fn := &engine.WindowFunction{
+		Alias: aliasExpr.ColumnName(),
+	}
+	switch expr := aliasExpr.Expr.(type) {
+	case *sqlparser.ArgumentLessWindowExpr:
+		switch expr.Type {
+		case sqlparser.RowNumberExprType:
+			fn.Opcode = engine.WindowRowNumber
+		case sqlparser.RankExprType:
+			fn.Opcode = engine.WindowRank
+		case sqlparser.DenseRankExprType:
+			fn.Opcode = engine.WindowDenseRank
+		}
+	case *sqlparser.CountStar:
+		fn.Opcode = engine.WindowCountStar
+	case *sqlparser.Count, *sqlparser.Sum, *sqlparser.Avg, *sqlparser.Min, *sqlparser.Max:
+		aggr := expr.(sqlparser.AggrFunc)
+		if aggr.IsDistinct() || len(aggr.GetArgs()) > 1 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: window function in cross-shard query: %s", sqlparser.String(expr))
+		}
+		fn.Opcode = engine.SupportedWindowFunctions[strings.ToLower(aggr.AggrName())]
+		arg := aggr.GetArg()
+		offset, _, err := pushProjection(ctx, &sqlparser.AliasedExpr{Expr: arg}, plan, true, true, false)
+		if err != nil {
+			return nil, err
+		}
+		fn.Col = offset
+		fn.CollationID = ctx.SemTable.CollationForExpr(arg)
+	case *sqlparser.LagLeadExpr:
+		if expr.NullTreatmentClause != nil && expr.NullTreatmentClause.Type == sqlparser.IgnoreNullsType {
+			return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: IGNORE NULLS in cross-shard query: %s", sqlparser.String(expr))
+		}
+		fn.Opcode = engine.WindowLag
+		if expr.Type == sqlparser.LeadExprType {
+			fn.Opcode = engine.WindowLead
+		}
+		offset, _, err := pushProjection(ctx, &sqlparser.AliasedExpr{Expr: expr.Expr}, plan, true, true, false)
+		if err != nil {
+			return nil, err
+		}
+		fn.Col = offset
+		if expr.N != nil {
+			fn.Offset, err = evalengine.Translate(expr.N, ctx.SemTable)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if expr.Default != nil {
+			fn.Default, err = evalengine.Translate(expr.Default, ctx.SemTable)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if fn.Opcode == engine.WindowUnassigned {
+		return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "unsupported: window function in cross-shard query: %s", sqlparser.String(aliasExpr.Expr))
+	}
+	return fn, nil
+}
@@ -57,6 +57,7 @@ var (
 	mysqlAuthServerImpl           = flag.String("mysql_auth_server_impl", "static", "Which auth server implementation to use. Options: none, ldap, clientcert, static, vault.")
 	mysqlAllowClearTextWithoutTLS = flag.Bool("mysql_allow_clear_text_without_tls", false, "If set, the server will allow the use of a clear text password over non-SSL connections.")
 	mysqlProxyProtocol            = flag.Bool("proxy_protocol", false, "Enable HAProxy PROXY protocol on MySQL listener socket")
+	mysqlServerCompression        = flag.Bool("mysql_server_compression", false, "If set, the server will negotiate the compressed protocol (zlib or zstd) with the clients that request it.")
 
 	mysqlServerRequireSecureTransport = flag.Bool("mysql_server_require_secure_transport", false, "Reject insecure connections but only if mysql_server_ssl_cert and mysql_server_ssl_key are provided")
 
@@ -346,19 +347,7 @@ func (vh *vtgateHandler) ComBinlogDumpGTID(c *mysql.Conn, gtidSet mysql.GTIDSet)
 func (vh *vtgateHandler) session(c *mysql.Conn) *vtgatepb.Session {
 	session, _ := c.ClientData.(*vtgatepb.Session)
 	if session == nil {
-		u, _ := uuid.NewUUID()
-		session = &vtgatepb.Session{
-			Options: &querypb.ExecuteOptions{
-				IncludedFields: querypb.ExecuteOptions_ALL,
-				Workload:       querypb.ExecuteOptions_Workload(mysqlDefaultWorkload),
-
-				// The collation field of ExecuteOption is set right before an execution.
-			},
-			Autocommit:           true,
-			DDLStrategy:          *defaultDDLStrategy,
-			SessionUUID:          u.String(),
-			EnableSystemSettings: *sysVarSetEnabled,
-		}
+		session = newSession()
 		if c.Capabilities&mysql.CapabilityClientFoundRows != 0 {
 			session.Options.ClientFoundRows = true
 		}
@@ -367,6 +356,24 @@ func (vh *vtgateHandler) session(c *mysql.Conn) *vtgatepb.Session {
 	return session
 }
 
+// newSession returns a new session, with the default settings of the MySQL
+// connections to vtgate.
+func newSession() *vtgatepb.Session {
+	u, _ := uuid.NewUUID()
+	return &vtgatepb.Session{
+		Options: &querypb.ExecuteOptions{
+			IncludedFields: querypb.ExecuteOptions_ALL,
+			Workload:       querypb.ExecuteOptions_Workload(mysqlDefaultWorkload),
+
+			// The collation field of ExecuteOption is set right before an execution.
+		},
+		Autocommit:           true,
+		DDLStrategy:          *defaultDDLStrategy,
+		SessionUUID:          u.String(),
+		EnableSystemSettings: *sysVarSetEnabled,
+	}
+}
+
 var mysqlListener *mysql.Listener
 var mysqlUnixListener *mysql.Listener
 var sigChan chan os.Signal
@@ -410,17 +417,7 @@ func initMySQLProtocol() {
 		return
 	}
 
-	// Initialize registered AuthServer implementations (or other plugins)
-	for _, initFn := range pluginInitializers {
-		initFn()
-	}
-	authServer := mysql.GetAuthServer(*mysqlAuthServerImpl)
-
-	// Check mysql_default_workload
-	var ok bool
-	if mysqlDefaultWorkload, ok = querypb.ExecuteOptions_Workload_value[strings.ToUpper(*mysqlDefaultWorkloadName)]; !ok {
-		log.Exitf("-mysql_default_workload must be one of [OLTP, OLAP, DBA, UNSPECIFIED]")
-	}
+	authServer := initAuthServer()
 
 	switch *mysqlTCPVersion {
 	case "tcp", "tcp4", "tcp6":
@@ -449,6 +446,7 @@ func initMySQLProtocol() {
 			_ = initTLSConfig(mysqlListener, *mysqlSslCert, *mysqlSslKey, *mysqlSslCa, *mysqlSslCrl, *mysqlSslServerCA, *mysqlServerRequireSecureTransport, tlsVersion)
 		}
 		mysqlListener.AllowClearTextWithoutTLS.Set(*mysqlAllowClearTextWithoutTLS)
+		mysqlListener.AllowCompression.Set(*mysqlServerCompression)
 		// Check for the connection threshold
 		if *mysqlSlowConnectWarnThreshold != 0 {
 			log.Infof("setting mysql slow connection threshold to %v", mysqlSlowConnectWarnThreshold)
@@ -579,7 +577,29 @@ func init() {
 	servenv.OnClose(rollbackAtShutdown)
 }
 
-var pluginInitializers []func()
+var (
+	pluginInitializers []func()
+	initAuthServerOnce sync.Once
+)
+
+// initAuthServer initializes the registered AuthServer implementations (or
+// other plugins) and the default session workload, the first time it is
+// called, and returns the AuthServer selected by --mysql_auth_server_impl.
+// It is shared by the MySQL listeners and the HTTP query API.
+func initAuthServer() mysql.AuthServer {
+	initAuthServerOnce.Do(func() {
+		for _, initFn := range pluginInitializers {
+			initFn()
+		}
+
+		// Check mysql_default_workload
+		var ok bool
+		if mysqlDefaultWorkload, ok = querypb.ExecuteOptions_Workload_value[strings.ToUpper(*mysqlDefaultWorkloadName)]; !ok {
+			log.Exitf("-mysql_default_workload must be one of [OLTP, OLAP, DBA, UNSPECIFIED]")
+		}
+	})
+	return mysql.GetAuthServer(*mysqlAuthServerImpl)
+}
 
 // RegisterPluginInitializer lets plugins register themselves to be init'ed at servenv.OnRun-time
 func RegisterPluginInitializer(initializer func()) {
@@ -0,0 +1,476 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/timer"
+	"vitess.io/vitess/go/vt/callerid"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// This file implements a JSON over HTTP API to run queries on vtgate. Callers
+// are authenticated with HTTP basic auth, using the same AuthServer plugin as
+// the MySQL listener. Sessions that have an open transaction or reserved
+// connection are kept by vtgate, and callers continue them by passing their ID
+// in their next request.
+
+var (
+	enableQueryAPI         = flag.Bool("enable_http_query_api", false, "If set, vtgate serves the JSON query API on /api/query and /api/query/stream. Callers are authenticated with HTTP basic auth by the --mysql_auth_server_impl plugin.")
+	queryAPISessionTimeout = flag.Duration("http_query_api_session_timeout", time.Minute, "Sessions of the JSON query API that are not used for this long are closed, and their open transaction is rolled back.")
+	queryAPIMaxRequestSize = flag.Int64("http_query_api_max_request_size", 1024*1024, "Maximum size in bytes of the body of a request to the JSON query API.")
+	queryAPIMaxSessions    = flag.Int("http_query_api_max_sessions", 1000, "Maximum number of sessions of the JSON query API that vtgate keeps open. Requests that need a new session fail with RESOURCE_EXHAUSTED once it is reached.")
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// queryAPIRequest is the body of a request to the query API.
+type queryAPIRequest struct {
+	SQL           string         `json:"sql"`
+	BindVariables map[string]any `json:"bind_variables,omitempty"`
+	// Session is the ID of the session returned by a previous request. If
+	// empty, the query runs in a new session, which is only kept if the query
+	// leaves a transaction or reserved connection open.
+	Session string `json:"session,omitempty"`
+}
+
+type queryAPIField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// queryAPIResponse is the response to a request to /api/query.
+type queryAPIResponse struct {
+	Fields       []queryAPIField `json:"fields"`
+	Rows         [][]any         `json:"rows"`
+	RowsAffected uint64          `json:"rows_affected"`
+	InsertID     uint64          `json:"insert_id"`
+	// Session is the ID of the session, if it is kept.
+	Session string `json:"session,omitempty"`
+}
+
+// queryAPIStreamLine is one line of the response to a request to
+// /api/query/stream. The fields come first, then the rows, and the last line
+// holds either the session or the error.
+type queryAPIStreamLine struct {
+	Fields  []queryAPIField `json:"fields,omitempty"`
+	Rows    [][]any         `json:"rows,omitempty"`
+	Session string          `json:"session,omitempty"`
+	Error   *queryAPIError  `json:"error,omitempty"`
+}
+
+type queryAPIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Session is set if the request reached the executor, since a failed
+	// query can still change the session.
+	Session string `json:"session,omitempty"`
+}
+
+// queryAPICall is an authenticated and decoded request to the query API. Its
+// session is locked until the call is released.
+type queryAPICall struct {
+	ctx      context.Context
+	sql      string
+	bindVars map[string]*querypb.BindVariable
+	session  *queryAPISession
+}
+
+type queryAPI struct {
+	vtg        *VTGate
+	authServer mysql.AuthServer
+	sessions   *queryAPISessions
+}
+
+func init() {
+	servenv.OnRun(initQueryAPI)
+}
+
+func initQueryAPI() {
+	if !*enableQueryAPI {
+		return
+	}
+
+	// If no VTGate was created, just return.
+	if rpcVTGate == nil {
+		return
+	}
+
+	qa := &queryAPI{
+		vtg:        rpcVTGate,
+		authServer: initAuthServer(),
+		sessions:   newQueryAPISessions(*queryAPIMaxSessions),
+	}
+	stats.NewGaugeFunc("QueryAPISessions", "Open sessions of the JSON query API", func() int64 {
+		return int64(qa.sessions.size())
+	})
+
+	// Idle sessions are closed, so that the transactions of callers that went
+	// away are rolled back.
+	idleTimer := timer.NewTimer(*queryAPISessionTimeout / 2)
+	idleTimer.Start(func() {
+		qa.sessions.closeIdle(qa.vtg, time.Now().Add(-*queryAPISessionTimeout))
+	})
+	servenv.OnTermSync(func() {
+		idleTimer.Stop()
+		qa.sessions.closeIdle(qa.vtg, time.Now().Add(time.Hour))
+	})
+
+	http.HandleFunc(apiPrefix+"query", qa.handleQuery)
+	http.HandleFunc(apiPrefix+"query/stream", qa.handleStreamQuery)
+}
+
+func (qa *queryAPI) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed, use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	call, err := qa.parseCall(w, r)
+	if err != nil {
+		writeQueryAPIError(w, err, "")
+		return
+	}
+	defer qa.sessions.release(call.session)
+	ctx, cancel := call.withTimeout()
+	defer cancel()
+
+	span, ctx, err := startSpan(ctx, call.sql, "queryAPI.Query")
+	if err != nil {
+		writeQueryAPIError(w, vterrors.Wrap(err, "failed to extract span"), qa.sessions.keep(call.session))
+		return
+	}
+	defer span.Finish()
+
+	atomic.AddInt32(&busyConnections, 1)
+	defer atomic.AddInt32(&busyConnections, -1)
+
+	session, qr, err := qa.vtg.Execute(ctx, call.session.session, call.sql, call.bindVars)
+	call.session.session = session
+	if err != nil {
+		writeQueryAPIError(w, err, qa.sessions.keep(call.session))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &queryAPIResponse{
+		Fields:       queryAPIFields(qr.Fields),
+		Rows:         queryAPIRows(qr.Rows),
+		RowsAffected: qr.RowsAffected,
+		InsertID:     qr.InsertID,
+		Session:      qa.sessions.keep(call.session),
+	})
+}
+
+func (qa *queryAPI) handleStreamQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed, use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	call, err := qa.parseCall(w, r)
+	if err != nil {
+		writeQueryAPIError(w, err, "")
+		return
+	}
+	defer qa.sessions.release(call.session)
+	ctx, cancel := call.withTimeout()
+	defer cancel()
+
+	span, ctx, err := startSpan(ctx, call.sql, "queryAPI.StreamQuery")
+	if err != nil {
+		writeQueryAPIError(w, vterrors.Wrap(err, "failed to extract span"), qa.sessions.keep(call.session))
+		return
+	}
+	defer span.Finish()
+
+	atomic.AddInt32(&busyConnections, 1)
+	defer atomic.AddInt32(&busyConnections, -1)
+
+	// Once the first line is sent, the status can't change anymore, so
+	// errors are reported on the last line.
+	w.Header().Set("Content-Type", ndjsonContentType)
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	writeLine := func(line *queryAPIStreamLine) error {
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	err = qa.vtg.StreamExecute(ctx, call.session.session, call.sql, call.bindVars, func(qr *sqltypes.Result) error {
+		line := &queryAPIStreamLine{}
+		if len(qr.Fields) != 0 {
+			line.Fields = queryAPIFields(qr.Fields)
+		}
+		if len(qr.Rows) != 0 {
+			line.Rows = queryAPIRows(qr.Rows)
+		}
+		if line.Fields == nil && line.Rows == nil {
+			return nil
+		}
+		return writeLine(line)
+	})
+	if err != nil {
+		if err := writeLine(&queryAPIStreamLine{Error: newQueryAPIError(err, qa.sessions.keep(call.session))}); err != nil {
+			log.Warningf("Failed to send the error of a streaming query to %v: %v", r.RemoteAddr, err)
+		}
+		return
+	}
+	if err := writeLine(&queryAPIStreamLine{Session: qa.sessions.keep(call.session)}); err != nil {
+		log.Warningf("Failed to send the session of a streaming query to %v: %v", r.RemoteAddr, err)
+	}
+}
+
+// parseCall authenticates the caller, decodes the request and acquires its
+// session. The caller ID of the returned context is set the same way as for
+// the MySQL listener. The session of the call must be released by the caller.
+func (qa *queryAPI) parseCall(w http.ResponseWriter, r *http.Request) (*queryAPICall, error) {
+	im, user, err := qa.authenticate(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="vtgate"`)
+		return nil, err
+	}
+
+	var req queryAPIRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, *queryAPIMaxRequestSize))
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "can't decode request: %v", err)
+	}
+	if req.SQL == "" {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "sql is required")
+	}
+	bindVars, err := bindVariablesFromJSON(req.BindVariables)
+	if err != nil {
+		return nil, err
+	}
+	session, err := qa.sessions.acquire(req.Session, user)
+	if err != nil {
+		return nil, err
+	}
+
+	ef := callerid.NewEffectiveCallerID(
+		user,         /* principal: who */
+		r.RemoteAddr, /* component: running client process */
+		"VTGate HTTP API" /* subcomponent: part of the client */)
+	return &queryAPICall{
+		ctx:      callerid.NewContext(r.Context(), ef, im),
+		sql:      req.SQL,
+		bindVars: bindVars,
+		session:  session,
+	}, nil
+}
+
+// authenticate checks the basic auth credentials of the request against the
+// AuthServer, and returns the ImmediateCallerID for the user.
+func (qa *queryAPI) authenticate(r *http.Request) (*querypb.VTGateCallerID, string, error) {
+	user, password, ok := r.BasicAuth()
+	if *mysqlAuthServerImpl == "none" {
+		return &querypb.VTGateCallerID{Username: user}, user, nil
+	}
+	// The password is sent in clear text, like with mysql_clear_password.
+	if r.TLS == nil && !*mysqlAllowClearTextWithoutTLS {
+		return nil, "", vterrors.Errorf(vtrpcpb.Code_UNAUTHENTICATED, "the query API requires TLS, unless --mysql_allow_clear_text_without_tls is set")
+	}
+	if !ok {
+		return nil, "", vterrors.Errorf(vtrpcpb.Code_UNAUTHENTICATED, "basic auth credentials are required")
+	}
+
+	remoteAddr, _ := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+	getter, err := mysql.AuthenticateWithPassword(qa.authServer, user, password, remoteAddr)
+	if err != nil {
+		return nil, "", vterrors.Errorf(vtrpcpb.Code_UNAUTHENTICATED, "%v", err)
+	}
+	// Fill in the ImmediateCallerID with the UserData returned by the
+	// AuthServer plugin for that user, like the MySQL listener does.
+	var im *querypb.VTGateCallerID
+	if getter != nil {
+		im = getter.Get()
+	}
+	if im == nil {
+		im = &querypb.VTGateCallerID{Username: user}
+	}
+	return im, user, nil
+}
+
+func (call *queryAPICall) withTimeout() (context.Context, context.CancelFunc) {
+	if *mysqlQueryTimeout != 0 {
+		return context.WithTimeout(call.ctx, *mysqlQueryTimeout)
+	}
+	return context.WithCancel(call.ctx)
+}
+
+// bindVariablesFromJSON converts the decoded JSON bind variables of a
+// request. Numbers become integers if they can, and floats otherwise.
+// Arrays become tuples.
+func bindVariablesFromJSON(vars map[string]any) (map[string]*querypb.BindVariable, error) {
+	bindVars := make(map[string]*querypb.BindVariable, len(vars))
+	for name, v := range vars {
+		value, err := bindValueFromJSON(v)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "bind variable %v", name)
+		}
+		bv, err := sqltypes.BuildBindVariable(value)
+		if err != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "bind variable %v: %v", name, err)
+		}
+		bindVars[name] = bv
+	}
+	return bindVars, nil
+}
+
+func bindValueFromJSON(v any) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u, nil
+		}
+		return v.Float64()
+	case []any:
+		values := make([]any, 0, len(v))
+		for _, elem := range v {
+			if _, ok := elem.([]any); ok {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "nested arrays are not supported")
+			}
+			value, err := bindValueFromJSON(elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case map[string]any:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "objects are not supported, pass JSON documents as strings")
+	}
+	// string, bool or nil.
+	return v, nil
+}
+
+func queryAPIFields(fields []*querypb.Field) []queryAPIField {
+	res := make([]queryAPIField, 0, len(fields))
+	for _, f := range fields {
+		res = append(res, queryAPIField{Name: f.Name, Type: f.Type.String()})
+	}
+	return res
+}
+
+func queryAPIRows(rows [][]sqltypes.Value) [][]any {
+	res := make([][]any, 0, len(rows))
+	for _, row := range rows {
+		values := make([]any, 0, len(row))
+		for _, v := range row {
+			values = append(values, queryAPIValue(v))
+		}
+		res = append(res, values)
+	}
+	return res
+}
+
+// queryAPIValue returns the JSON representation of a value: numbers for
+// integral and float types, documents for JSON, base64 strings for binary
+// types, and strings for everything else.
+func queryAPIValue(v sqltypes.Value) any {
+	switch {
+	case v.IsNull():
+		return nil
+	case v.IsIntegral(), v.IsFloat():
+		return json.Number(v.ToString())
+	case v.Type() == querypb.Type_JSON:
+		if json.Valid(v.Raw()) {
+			return json.RawMessage(v.Raw())
+		}
+	case v.IsBinary():
+		return v.Raw()
+	}
+	return v.ToString()
+}
+
+func newQueryAPIError(err error, sessionID string) *queryAPIError {
+	return &queryAPIError{
+		Code:    vterrors.Code(err).String(),
+		Message: err.Error(),
+		Session: sessionID,
+	}
+}
+
+// writeQueryAPIError writes an error response, with the HTTP status that
+// matches the vtrpc code of the error.
+func writeQueryAPIError(w http.ResponseWriter, err error, sessionID string) {
+	writeJSON(w, queryAPIStatus(vterrors.Code(err)), struct {
+		Error *queryAPIError `json:"error"`
+	}{newQueryAPIError(err, sessionID)})
+}
+
+func queryAPIStatus(code vtrpcpb.Code) int {
+	switch code {
+	case vtrpcpb.Code_OK:
+		return http.StatusOK
+	case vtrpcpb.Code_INVALID_ARGUMENT, vtrpcpb.Code_FAILED_PRECONDITION, vtrpcpb.Code_OUT_OF_RANGE:
+		return http.StatusBadRequest
+	case vtrpcpb.Code_UNAUTHENTICATED:
+		return http.StatusUnauthorized
+	case vtrpcpb.Code_PERMISSION_DENIED:
+		return http.StatusForbidden
+	case vtrpcpb.Code_NOT_FOUND:
+		return http.StatusNotFound
+	case vtrpcpb.Code_ALREADY_EXISTS, vtrpcpb.Code_ABORTED:
+		return http.StatusConflict
+	case vtrpcpb.Code_RESOURCE_EXHAUSTED:
+		return http.StatusTooManyRequests
+	case vtrpcpb.Code_UNIMPLEMENTED:
+		return http.StatusNotImplemented
+	case vtrpcpb.Code_UNAVAILABLE:
+		return http.StatusServiceUnavailable
+	case vtrpcpb.Code_DEADLINE_EXCEEDED:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, obj any) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(w, "cannot marshal data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(status)
+	w.Write(data)
+}
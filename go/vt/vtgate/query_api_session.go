@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// queryAPICloseSessionTimeout is the timeout to roll back the transaction of
+// a session that is closed by vtgate.
+const queryAPICloseSessionTimeout = 30 * time.Second
+
+// queryAPISession is a session of the query API. Sessions that still have an
+// open transaction or reserved connection after a request are kept by vtgate,
+// and callers refer to them by a random ID that is only valid for the user
+// that created the session.
+type queryAPISession struct {
+	id   string
+	user string
+
+	// mu is held while a request uses the session, so that the queries of
+	// a session never run concurrently.
+	mu sync.Mutex
+	// session and closed are protected by mu.
+	session *vtgatepb.Session
+	closed  bool
+
+	// lastUsed and kept are protected by the mutex of queryAPISessions.
+	lastUsed time.Time
+	kept     bool
+}
+
+// queryAPISessions holds the open sessions of the query API.
+type queryAPISessions struct {
+	maxSessions int
+
+	mu       sync.Mutex
+	sessions map[string]*queryAPISession
+	// pending is the number of new sessions used by requests, which are not
+	// kept yet. They count towards maxSessions, so that a request never
+	// fails for the cap after its query ran.
+	pending int
+}
+
+func newQueryAPISessions(maxSessions int) *queryAPISessions {
+	return &queryAPISessions{
+		maxSessions: maxSessions,
+		sessions:    make(map[string]*queryAPISession),
+	}
+}
+
+// acquire returns the session with the given ID, or a new session if the ID
+// is empty. The session is locked until it is released.
+func (qas *queryAPISessions) acquire(id, user string) (*queryAPISession, error) {
+	if id == "" {
+		return qas.create(user)
+	}
+
+	qas.mu.Lock()
+	qs, ok := qas.sessions[id]
+	qas.mu.Unlock()
+	// The sessions of other users are reported as missing, so that their
+	// IDs can't be probed.
+	if !ok || qs.user != user {
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "session %v not found, it may have expired", id)
+	}
+	if !qs.mu.TryLock() {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "session %v is in use by another request", id)
+	}
+	if qs.closed {
+		qs.mu.Unlock()
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "session %v not found, it may have expired", id)
+	}
+	return qs, nil
+}
+
+// create returns a new session, which is only kept by vtgate if keep is
+// called once its request leaves it with an open transaction or reserved
+// connection.
+func (qas *queryAPISessions) create(user string) (*queryAPISession, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, vterrors.Wrap(err, "can't generate session ID")
+	}
+
+	qas.mu.Lock()
+	defer qas.mu.Unlock()
+	if len(qas.sessions)+qas.pending >= qas.maxSessions {
+		return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "too many open sessions of the query API (%d), retry later", qas.maxSessions)
+	}
+	qas.pending++
+
+	qs := &queryAPISession{
+		id:       hex.EncodeToString(buf),
+		user:     user,
+		session:  newSession(),
+		lastUsed: time.Now(),
+	}
+	qs.mu.Lock()
+	return qs, nil
+}
+
+// keep keeps a new session if it has an open transaction or reserved
+// connection, and returns the ID of the session, or an empty ID if it is not
+// kept. The session must be acquired.
+func (qas *queryAPISessions) keep(qs *queryAPISession) string {
+	qas.mu.Lock()
+	defer qas.mu.Unlock()
+	if qs.kept {
+		return qs.id
+	}
+	if !qs.session.InTransaction && !qs.session.InReservedConn {
+		return ""
+	}
+	qas.pending--
+	qs.kept = true
+	qas.sessions[qs.id] = qs
+	return qs.id
+}
+
+// release unlocks a session returned by acquire. New sessions that were not
+// kept are closed.
+func (qas *queryAPISessions) release(qs *queryAPISession) {
+	qas.mu.Lock()
+	if !qs.kept {
+		qas.pending--
+		qs.closed = true
+	}
+	qs.lastUsed = time.Now()
+	qas.mu.Unlock()
+	qs.mu.Unlock()
+}
+
+// closeIdle closes the sessions that were last used before idleSince, and
+// rolls back their open transaction. Sessions that are in use are skipped.
+func (qas *queryAPISessions) closeIdle(vtg *VTGate, idleSince time.Time) {
+	var idle []*queryAPISession
+	qas.mu.Lock()
+	for id, qs := range qas.sessions {
+		if qs.lastUsed.Before(idleSince) && qs.mu.TryLock() {
+			delete(qas.sessions, id)
+			qs.closed = true
+			idle = append(idle, qs)
+		}
+	}
+	qas.mu.Unlock()
+
+	for _, qs := range idle {
+		if qs.session.InTransaction || qs.session.InReservedConn {
+			log.Infof("Closing idle session %v of the query API, and rolling back its transaction", qs.id)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), queryAPICloseSessionTimeout)
+		if err := vtg.CloseSession(ctx, qs.session); err != nil {
+			log.Warningf("Failed to close session %v of the query API: %v", qs.id, err)
+		}
+		cancel()
+		qs.mu.Unlock()
+	}
+}
+
+// size returns the number of open sessions.
+func (qas *queryAPISessions) size() int {
+	qas.mu.Lock()
+	defer qas.mu.Unlock()
+	return len(qas.sessions)
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func TestBindVariablesFromJSON(t *testing.T) {
+	var vars map[string]any
+	dec := json.NewDecoder(strings.NewReader(`{
+		"int": -12,
+		"uint": 18446744073709551615,
+		"float": 1.5,
+		"str": "foo",
+		"bool": true,
+		"null": null,
+		"tuple": [1, "a"]
+	}`))
+	dec.UseNumber()
+	require.NoError(t, dec.Decode(&vars))
+
+	bindVars, err := bindVariablesFromJSON(vars)
+	require.NoError(t, err)
+	assert.Equal(t, sqltypes.Int64BindVariable(-12), bindVars["int"])
+	assert.Equal(t, sqltypes.Uint64BindVariable(18446744073709551615), bindVars["uint"])
+	assert.Equal(t, sqltypes.Float64BindVariable(1.5), bindVars["float"])
+	assert.Equal(t, sqltypes.StringBindVariable("foo"), bindVars["str"])
+	assert.Equal(t, sqltypes.Int8BindVariable(1), bindVars["bool"])
+	assert.Equal(t, sqltypes.NullBindVariable, bindVars["null"])
+	assert.Equal(t, querypb.Type_TUPLE, bindVars["tuple"].Type)
+	assert.Len(t, bindVars["tuple"].Values, 2)
+
+	_, err = bindVariablesFromJSON(map[string]any{"obj": map[string]any{}})
+	assert.ErrorContains(t, err, "bind variable obj: objects are not supported")
+	_, err = bindVariablesFromJSON(map[string]any{"nested": []any{[]any{}}})
+	assert.ErrorContains(t, err, "nested arrays are not supported")
+}
+
+func TestQueryAPIValue(t *testing.T) {
+	row := []sqltypes.Value{
+		sqltypes.NULL,
+		sqltypes.NewInt64(-1),
+		sqltypes.NewUint64(18446744073709551615),
+		sqltypes.NewFloat64(1.25),
+		sqltypes.NewVarChar("foo"),
+		sqltypes.NewVarBinary("\x00\x01"),
+		sqltypes.MakeTrusted(querypb.Type_JSON, []byte(`{"a": [1, 2]}`)),
+		sqltypes.MakeTrusted(querypb.Type_DECIMAL, []byte("1.10")),
+	}
+	data, err := json.Marshal(queryAPIRows([][]sqltypes.Value{row}))
+	require.NoError(t, err)
+	assert.Equal(t, `[[null,-1,18446744073709551615,1.25,"foo","AAE=",{"a":[1,2]},"1.10"]]`, string(data))
+}
+
+func TestQueryAPISessions(t *testing.T) {
+	qas := newQueryAPISessions(2)
+	qs, err := qas.acquire("", "user1")
+	require.NoError(t, err)
+	assert.Len(t, qs.id, 32)
+	assert.True(t, qs.session.Autocommit)
+	assert.NotEmpty(t, qs.session.SessionUUID)
+
+	// New sessions are only kept if they have an open transaction or
+	// reserved connection.
+	assert.Empty(t, qas.keep(qs))
+	qas.release(qs)
+	assert.Equal(t, 0, qas.size())
+	_, err = qas.acquire(qs.id, "user1")
+	assert.ErrorContains(t, err, "not found")
+
+	qs, err = qas.acquire("", "user1")
+	require.NoError(t, err)
+	qs.session.InTransaction = true
+	assert.Equal(t, qs.id, qas.keep(qs))
+	assert.Equal(t, 1, qas.size())
+
+	// A session can't be used by two requests at once.
+	_, err = qas.acquire(qs.id, "user1")
+	assert.ErrorContains(t, err, "is in use by another request")
+	qas.release(qs)
+
+	got, err := qas.acquire(qs.id, "user1")
+	require.NoError(t, err)
+	assert.Same(t, qs, got)
+	assert.Equal(t, qs.id, qas.keep(got))
+	qas.release(got)
+
+	// A session is bound to the user that created it.
+	_, err = qas.acquire(qs.id, "user2")
+	assert.ErrorContains(t, err, "not found")
+	_, err = qas.acquire("not a session", "user1")
+	assert.ErrorContains(t, err, "not found")
+
+	// New sessions in use count towards the maximum number of sessions.
+	busy, err := qas.acquire("", "user1")
+	require.NoError(t, err)
+	_, err = qas.acquire("", "user1")
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	busy.session.InReservedConn = true
+	assert.Equal(t, busy.id, qas.keep(busy))
+	_, err = qas.acquire("", "user1")
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+
+	// Idle sessions are closed, unless they are in use.
+	qas.closeIdle(rpcVTGate, time.Now().Add(time.Hour))
+	assert.Equal(t, 1, qas.size())
+	assert.True(t, qs.closed)
+	_, err = qas.acquire(qs.id, "user1")
+	assert.ErrorContains(t, err, "not found")
+
+	qas.release(busy)
+	qas.closeIdle(rpcVTGate, time.Now().Add(-time.Hour))
+	assert.Equal(t, 1, qas.size())
+	qas.closeIdle(rpcVTGate, time.Now().Add(time.Hour))
+	assert.Equal(t, 0, qas.size())
+	qs, err = qas.acquire("", "user1")
+	require.NoError(t, err)
+	qas.release(qs)
+}
+
+func TestQueryAPIHandleQuery(t *testing.T) {
+	createSandbox(KsTestUnsharded)
+	hcVTGateTest.Reset()
+	hcVTGateTest.AddTestTablet("aa", "1.1.1.1", 1001, KsTestUnsharded, "0", topodatapb.TabletType_PRIMARY, true, 1, nil)
+
+	qa := &queryAPI{vtg: rpcVTGate, authServer: mysql.NewAuthServerNone(), sessions: newQueryAPISessions(1)}
+
+	// Requests without a session run in a new one, which is only kept if it
+	// has an open transaction.
+	w := httptest.NewRecorder()
+	qa.handleQuery(w, httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(`{"sql": "select 1 from dual"}`)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), `"session"`)
+	assert.Equal(t, 0, qa.sessions.size())
+
+	w = httptest.NewRecorder()
+	qa.handleQuery(w, httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(`{"sql": "begin"}`)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp queryAPIResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Session)
+	assert.Equal(t, 1, qa.sessions.size())
+	sessionID := resp.Session
+
+	body, err := json.Marshal(&queryAPIRequest{
+		SQL:           "select id, value from t1 where id = :id",
+		BindVariables: map[string]any{"id": 1},
+		Session:       sessionID,
+	})
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	qa.handleQuery(w, httptest.NewRequest(http.MethodPost, "/api/query", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp = queryAPIResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []queryAPIField{{Name: "id", Type: "INT32"}, {Name: "value", Type: "VARCHAR"}}, resp.Fields)
+	assert.Equal(t, [][]any{{float64(1), "foo"}}, resp.Rows)
+	assert.Equal(t, sessionID, resp.Session)
+
+	// New sessions fail once the maximum number of sessions is reached.
+	w = httptest.NewRecorder()
+	qa.handleQuery(w, httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(`{"sql": "begin"}`)))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"RESOURCE_EXHAUSTED"`)
+
+	w = httptest.NewRecorder()
+	qa.handleQuery(w, httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(`{"sql": "select 1 from dual", "session": "expired"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"NOT_FOUND"`)
+
+	// Errors are returned with the status that matches their code.
+	w = httptest.NewRecorder()
+	qa.handleQuery(w, httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(`{"sql": ""}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"INVALID_ARGUMENT"`)
+
+	oldMaxRequestSize := *queryAPIMaxRequestSize
+	defer func() { *queryAPIMaxRequestSize = oldMaxRequestSize }()
+	*queryAPIMaxRequestSize = 16
+	w = httptest.NewRecorder()
+	qa.handleQuery(w, httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(`{"sql": "select 1 from dual"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "request body too large")
+
+	w = httptest.NewRecorder()
+	qa.handleQuery(w, httptest.NewRequest(http.MethodGet, "/api/query", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestQueryAPIAuthenticate(t *testing.T) {
+	oldImpl, oldClearText := *mysqlAuthServerImpl, *mysqlAllowClearTextWithoutTLS
+	defer func() {
+		*mysqlAuthServerImpl, *mysqlAllowClearTextWithoutTLS = oldImpl, oldClearText
+	}()
+	*mysqlAuthServerImpl = "static"
+
+	qa := &queryAPI{
+		vtg:        rpcVTGate,
+		authServer: mysql.NewAuthServerStatic("", `{"user1": [{"Password": "password1", "UserData": "vtuser1"}]}`, 0),
+	}
+	newRequest := func(user, password string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/query", nil)
+		if user != "" {
+			r.SetBasicAuth(user, password)
+		}
+		return r
+	}
+
+	// Clear text passwords need TLS, or the flag.
+	_, _, err := qa.authenticate(newRequest("user1", "password1"))
+	assert.ErrorContains(t, err, "requires TLS")
+
+	*mysqlAllowClearTextWithoutTLS = true
+	im, user, err := qa.authenticate(newRequest("user1", "password1"))
+	require.NoError(t, err)
+	assert.Equal(t, "user1", user)
+	assert.Equal(t, "vtuser1", im.Username)
+
+	_, _, err = qa.authenticate(newRequest("user1", "wrong"))
+	assert.ErrorContains(t, err, "Access denied")
+	_, _, err = qa.authenticate(newRequest("", ""))
+	assert.ErrorContains(t, err, "basic auth credentials are required")
+}
@@ -120,8 +120,8 @@ func (res *Resolver) Execute(
 	}
 }
 
-// MessageStream streams messages.
-func (res *Resolver) MessageStream(ctx context.Context, keyspace string, shard string, keyRange *topodatapb.KeyRange, name string, callback func(*sqltypes.Result) error) error {
+// MessageStream streams messages for a consumer group.
+func (res *Resolver) MessageStream(ctx context.Context, keyspace string, shard string, keyRange *topodatapb.KeyRange, name, group string, callback func(*sqltypes.Result) error) error {
 	var destination key.Destination
 	if shard != "" {
 		// If we pass in a shard, resolve the keyspace/shard
@@ -137,7 +137,7 @@ func (res *Resolver) MessageStream(ctx context.Context, keyspace string, shard s
 	if err != nil {
 		return err
 	}
-	return res.scatterConn.MessageStream(ctx, rss, name, callback)
+	return res.scatterConn.MessageStream(ctx, rss, name, group, callback)
 }
 
 // GetGatewayCacheStatus returns a displayable version of the Gateway cache.
@@ -0,0 +1,425 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"vitess.io/vitess/go/cache"
+	"vitess.io/vitess/go/hack"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/sync2"
+	"vitess.io/vitess/go/vt/callerid"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+var (
+	resultCacheMemory              = flag.Int64("result_cache_memory", 0, "vtgate result cache size in bytes. If set, the results of the SELECTs that use the RESULT_CACHE_TTL directive, or that only use tables with a result_cache_ttl in the VSchema, are cached in a lru cache of this capacity.")
+	resultCacheVStreamInvalidation = flag.Bool("result_cache_vstream_invalidation", false, "If set, vtgate streams the changes to the tables in the result cache from the primary tablets, and invalidates the cached results as soon as the tables change.")
+)
+
+// resultCacheWatchRetryDelay is how long the result cache waits before
+// restarting a failed invalidation stream.
+const resultCacheWatchRetryDelay = 5 * time.Second
+
+// maxResultCacheGenerations is the number of tables and keyspaces for which
+// the result cache records an invalidation generation. When it is reached,
+// the recorded generations are reset and all cached results are dropped.
+const maxResultCacheGenerations = 10000
+
+// resultCache caches the results of read-only queries, until they expire or
+// until the tables they use change.
+//
+// Invalidation is done with generations: every invalidation of a table or a
+// keyspace records the new generation of the cache for it, and a cached
+// result is only valid if none of its tables, or their keyspaces, were
+// invalidated after the generation at which its query started. Results of
+// queries that started before minGeneration are never valid, which lets the
+// recorded generations be reset.
+type resultCache struct {
+	results cache.Cache
+
+	mu                 sync.Mutex
+	generation         uint64
+	minGeneration      uint64
+	tableGenerations   map[string]uint64
+	keyspaceGeneration map[string]uint64
+	watcher            *resultCacheWatcher
+
+	hits          sync2.AtomicInt64
+	misses        sync2.AtomicInt64
+	invalidations sync2.AtomicInt64
+}
+
+type resultCacheEntry struct {
+	result     *sqltypes.Result
+	tables     []string
+	expires    time.Time
+	generation uint64
+	size       int64
+}
+
+// newResultCache returns a result cache with the given capacity in bytes, or
+// nil if the capacity is 0.
+func newResultCache(capacity int64) *resultCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &resultCache{
+		results: cache.NewLRUCache(capacity, func(val any) int64 {
+			return val.(*resultCacheEntry).size
+		}),
+		tableGenerations:   make(map[string]uint64),
+		keyspaceGeneration: make(map[string]uint64),
+	}
+}
+
+// currentGeneration returns the generation to pass to set for a query that
+// is about to be executed.
+func (rc *resultCache) currentGeneration() uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.generation
+}
+
+// get returns the cached result for the key, if it is still valid.
+func (rc *resultCache) get(key string, now time.Time) (*sqltypes.Result, bool) {
+	val, ok := rc.results.Get(key)
+	if !ok {
+		rc.misses.Add(1)
+		return nil, false
+	}
+	entry := val.(*resultCacheEntry)
+	if now.After(entry.expires) || !rc.isValid(entry) {
+		rc.results.Delete(key)
+		rc.misses.Add(1)
+		return nil, false
+	}
+	rc.hits.Add(1)
+	return entry.result.Copy(), true
+}
+
+func (rc *resultCache) isValid(entry *resultCacheEntry) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if entry.generation < rc.minGeneration {
+		return false
+	}
+	for _, table := range entry.tables {
+		if rc.tableGenerations[table] > entry.generation {
+			return false
+		}
+		if ks, _, ok := strings.Cut(table, "."); ok && rc.keyspaceGeneration[ks] > entry.generation {
+			return false
+		}
+	}
+	return true
+}
+
+// set caches the result of a query on the given tables, which started at the
+// given generation.
+func (rc *resultCache) set(key string, qr *sqltypes.Result, tables []string, ttl time.Duration, generation uint64, now time.Time) {
+	entry := &resultCacheEntry{
+		result:     qr.Copy(),
+		tables:     tables,
+		expires:    now.Add(ttl),
+		generation: generation,
+	}
+	entry.size = entry.result.CachedSize(true) + hack.RuntimeAllocSize(int64(len(key)))
+	if entry.size > rc.results.MaxCapacity() {
+		return
+	}
+	if !rc.isValid(entry) {
+		// The tables changed while the query was running.
+		return
+	}
+	rc.results.Set(key, entry)
+
+	rc.mu.Lock()
+	watcher := rc.watcher
+	rc.mu.Unlock()
+	if watcher != nil {
+		watcher.watch(tables)
+	}
+}
+
+// invalidateTables invalidates the cached results that use any of the given
+// tables, named as keyspace.table.
+func (rc *resultCache) invalidateTables(tables ...string) {
+	if len(tables) == 0 {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.generation++
+	for _, table := range tables {
+		rc.tableGenerations[table] = rc.generation
+	}
+	rc.invalidations.Add(int64(len(tables)))
+	rc.pruneGenerationsLocked()
+}
+
+// invalidateKeyspace invalidates the cached results that use any table of
+// the keyspace.
+func (rc *resultCache) invalidateKeyspace(keyspace string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.generation++
+	rc.keyspaceGeneration[keyspace] = rc.generation
+	rc.invalidations.Add(1)
+	rc.pruneGenerationsLocked()
+}
+
+// pruneGenerationsLocked resets the recorded generations once there are too
+// many of them. Since a generation can't be forgotten without risking to
+// serve a stale result, all the results cached so far are invalidated.
+// rc.mu must be held.
+func (rc *resultCache) pruneGenerationsLocked() {
+	if len(rc.tableGenerations)+len(rc.keyspaceGeneration) < maxResultCacheGenerations {
+		return
+	}
+	rc.generation++
+	rc.minGeneration = rc.generation
+	rc.tableGenerations = make(map[string]uint64)
+	rc.keyspaceGeneration = make(map[string]uint64)
+	rc.results.Clear()
+}
+
+// schemaChanged is registered with the schema tracker.
+func (rc *resultCache) schemaChanged(keyspace string, tables []string) {
+	if len(tables) == 0 {
+		rc.invalidateKeyspace(keyspace)
+		return
+	}
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, keyspace+"."+table)
+	}
+	rc.invalidateTables(names...)
+}
+
+// resultCacheTTL returns how long the result of the plan can be cached, or 0
+// if it can't be cached. The RESULT_CACHE_TTL directive takes precedence over
+// the result_cache_ttl of the tables in the VSchema.
+func (e *Executor) resultCacheTTL(safeSession *SafeSession, plan *engine.Plan) time.Duration {
+	if e.results == nil || plan.Type != sqlparser.StmtSelect || plan.Nondeterministic || len(plan.TablesUsed) == 0 {
+		return 0
+	}
+	// Results that depend on the state of the session can't be shared.
+	if safeSession.InTransaction() || safeSession.InReservedConn() || safeSession.HasSystemVariables() {
+		return 0
+	}
+	if plan.ResultCacheTTL > 0 {
+		return plan.ResultCacheTTL
+	}
+
+	vschema := e.VSchema()
+	if vschema == nil {
+		return 0
+	}
+	var ttl time.Duration
+	for _, name := range plan.TablesUsed {
+		ks, tbl, ok := strings.Cut(name, ".")
+		if !ok {
+			return 0
+		}
+		ksSchema := vschema.Keyspaces[ks]
+		if ksSchema == nil {
+			return 0
+		}
+		table := ksSchema.Tables[tbl]
+		if table == nil || table.ResultCacheTTL <= 0 {
+			return 0
+		}
+		if ttl == 0 || table.ResultCacheTTL < ttl {
+			ttl = table.ResultCacheTTL
+		}
+	}
+	return ttl
+}
+
+// invalidateResultCache invalidates the cached results that use the tables
+// written by the plan. Other sessions can cache the rows of these tables again
+// until the transaction of the session commits, so the tables are recorded in
+// the session and invalidated again by the commit.
+func (e *Executor) invalidateResultCache(safeSession *SafeSession, plan *engine.Plan) {
+	if e.results == nil || canReturnRows(plan.Type) {
+		return
+	}
+	e.results.invalidateTables(plan.TablesUsed...)
+	if safeSession.InTransaction() {
+		safeSession.AddTablesWritten(plan.TablesUsed...)
+	}
+}
+
+// invalidateCommittedTables invalidates the cached results that use the tables
+// written by a transaction that committed.
+func (e *Executor) invalidateCommittedTables(tables []string) {
+	if e.results == nil {
+		return
+	}
+	e.results.invalidateTables(tables...)
+}
+
+// newResultCacheKey returns the key of the result of a query. Queries share a
+// result if they have the same plan, target, bind variables and caller.
+func newResultCacheKey(ctx context.Context, vcursor *vcursorImpl, safeSession *SafeSession, plan *engine.Plan, bindVars map[string]*querypb.BindVariable) (string, error) {
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(vcursor.planPrefixKey(ctx)))
+	_, _ = hash.Write([]byte{':'})
+	_, _ = hash.Write([]byte(callerid.GetUsername(callerid.ImmediateCallerIDFromContext(ctx))))
+	_, _ = hash.Write([]byte{':'})
+	if safeSession.Options != nil {
+		_, _ = hash.Write([]byte(safeSession.Options.IncludedFields.String()))
+	}
+	_, _ = hash.Write([]byte{':'})
+	_, _ = hash.Write(hack.StringBytes(plan.Original))
+
+	names := make([]string, 0, len(bindVars))
+	for name := range bindVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(bindVars[name])
+		if err != nil {
+			return "", err
+		}
+		_, _ = hash.Write([]byte{':'})
+		_, _ = hash.Write([]byte(name))
+		_, _ = hash.Write([]byte{'='})
+		_, _ = hash.Write(data)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// resultCacheWatcher streams the changes to the tables of the cached results,
+// with one VStream per keyspace, and invalidates the results when the tables
+// change.
+type resultCacheWatcher struct {
+	ctx context.Context
+	rc  *resultCache
+	vsm *vstreamManager
+
+	mu        sync.Mutex
+	keyspaces map[string]*watchedKeyspace
+}
+
+type watchedKeyspace struct {
+	tables map[string]bool
+	cancel context.CancelFunc
+}
+
+// startVStreamInvalidation makes the cache invalidate the results as soon as
+// their tables change, using the vstream manager of vtgate.
+func (rc *resultCache) startVStreamInvalidation(ctx context.Context, vsm *vstreamManager) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.watcher = &resultCacheWatcher{
+		ctx:       ctx,
+		rc:        rc,
+		vsm:       vsm,
+		keyspaces: make(map[string]*watchedKeyspace),
+	}
+}
+
+// watch makes sure the changes to the tables are streamed. The stream of a
+// keyspace is restarted when a table is added to it.
+func (w *resultCacheWatcher) watch(tables []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := make(map[string]bool)
+	for _, name := range tables {
+		ks, tbl, ok := strings.Cut(name, ".")
+		if !ok {
+			continue
+		}
+		wk := w.keyspaces[ks]
+		if wk == nil {
+			wk = &watchedKeyspace{tables: make(map[string]bool)}
+			w.keyspaces[ks] = wk
+		}
+		if !wk.tables[tbl] {
+			wk.tables[tbl] = true
+			changed[ks] = true
+		}
+	}
+
+	// The changes made between the end of the previous stream and the start
+	// of the new one are missed, the TTL of the results bounds their effect.
+	for ks := range changed {
+		wk := w.keyspaces[ks]
+		if wk.cancel != nil {
+			wk.cancel()
+		}
+		filter := &binlogdatapb.Filter{}
+		for tbl := range wk.tables {
+			filter.Rules = append(filter.Rules, &binlogdatapb.Rule{Match: tbl})
+		}
+		var ctx context.Context
+		ctx, wk.cancel = context.WithCancel(w.ctx)
+		go w.stream(ctx, ks, filter)
+	}
+}
+
+func (w *resultCacheWatcher) stream(ctx context.Context, keyspace string, filter *binlogdatapb.Filter) {
+	for {
+		vgtid := &binlogdatapb.VGtid{
+			ShardGtids: []*binlogdatapb.ShardGtid{{Keyspace: keyspace, Gtid: "current"}},
+		}
+		err := w.vsm.VStream(ctx, topodatapb.TabletType_PRIMARY, vgtid, filter, &vtgatepb.VStreamFlags{}, func(events []*binlogdatapb.VEvent) error {
+			for _, ev := range events {
+				switch ev.Type {
+				case binlogdatapb.VEventType_ROW:
+					// The vstream manager qualifies the table name with the keyspace.
+					w.rc.invalidateTables(ev.RowEvent.TableName)
+				case binlogdatapb.VEventType_DDL:
+					w.rc.invalidateKeyspace(keyspace)
+				}
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warningf("Result cache invalidation stream for keyspace %v failed, restarting it: %v", keyspace, err)
+		// Changes may have been missed while the stream was down.
+		w.rc.invalidateKeyspace(keyspace)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resultCacheWatchRetryDelay):
+		}
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+func TestResultCache(t *testing.T) {
+	assert.Nil(t, newResultCache(0))
+
+	rc := newResultCache(1 << 20)
+	now := time.Now()
+	qr := sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "1", "2")
+
+	// Expiry.
+	rc.set("k1", qr, []string{"ks.t1"}, time.Second, rc.currentGeneration(), now)
+	got, ok := rc.get("k1", now.Add(500*time.Millisecond))
+	require.True(t, ok)
+	assert.Equal(t, qr.Rows, got.Rows)
+	_, ok = rc.get("k1", now.Add(2*time.Second))
+	assert.False(t, ok)
+
+	// Table invalidation only affects the results that use the table.
+	rc.set("k1", qr, []string{"ks.t1"}, time.Minute, rc.currentGeneration(), now)
+	rc.set("k2", qr, []string{"ks.t2"}, time.Minute, rc.currentGeneration(), now)
+	rc.invalidateTables("ks.t1")
+	_, ok = rc.get("k1", now)
+	assert.False(t, ok)
+	_, ok = rc.get("k2", now)
+	assert.True(t, ok)
+
+	// Schema changes of a keyspace.
+	rc.set("k1", qr, []string{"ks.t1"}, time.Minute, rc.currentGeneration(), now)
+	rc.schemaChanged("ks", []string{"t2"})
+	_, ok = rc.get("k1", now)
+	assert.True(t, ok)
+	_, ok = rc.get("k2", now)
+	assert.False(t, ok)
+	rc.schemaChanged("ks", nil)
+	_, ok = rc.get("k1", now)
+	assert.False(t, ok)
+
+	// A result is not cached if its tables changed while the query ran.
+	generation := rc.currentGeneration()
+	rc.invalidateTables("ks.t1")
+	rc.set("k1", qr, []string{"ks.t1"}, time.Minute, generation, now)
+	_, ok = rc.get("k1", now)
+	assert.False(t, ok)
+
+	assert.EqualValues(t, 3, rc.hits.Get())
+	assert.EqualValues(t, 5, rc.misses.Get())
+}
+
+func TestResultCacheTooLarge(t *testing.T) {
+	rc := newResultCache(100)
+	qr := sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "1", "2")
+	rc.set("k1", qr, []string{"ks.t1"}, time.Minute, rc.currentGeneration(), time.Now())
+	assert.Zero(t, rc.results.Len())
+}
+
+func TestResultCacheGenerationsPruned(t *testing.T) {
+	rc := newResultCache(1 << 20)
+	now := time.Now()
+	qr := sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "1", "2")
+
+	rc.set("k1", qr, []string{"ks.t1"}, time.Minute, rc.currentGeneration(), now)
+	generation := rc.currentGeneration()
+	for i := 0; i < maxResultCacheGenerations; i++ {
+		rc.invalidateTables(fmt.Sprintf("ks.other%d", i))
+	}
+	assert.Less(t, len(rc.tableGenerations), maxResultCacheGenerations)
+	_, ok := rc.get("k1", now)
+	assert.False(t, ok)
+
+	// A query that started before the generations were reset is not cached.
+	rc.set("k1", qr, []string{"ks.t1"}, time.Minute, generation, now)
+	_, ok = rc.get("k1", now)
+	assert.False(t, ok)
+	rc.set("k1", qr, []string{"ks.t1"}, time.Minute, rc.currentGeneration(), now)
+	_, ok = rc.get("k1", now)
+	assert.True(t, ok)
+}
+
+func TestExecutorResultCache(t *testing.T) {
+	executor, _, _, sbclookup := createExecutorEnv()
+	executor.pv = querypb.ExecuteOptions_Gen4
+	executor.results = newResultCache(1 << 20)
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+
+	query := "select /*vt+ RESULT_CACHE_TTL=1m */ id from main1"
+	for i := 0; i < 3; i++ {
+		_, err := executorExecSession(executor, query, nil, session)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, sbclookup.ExecCount.Get())
+
+	// Queries without the directive are not cached.
+	for i := 0; i < 2; i++ {
+		_, err := executorExecSession(executor, "select id from main1", nil, session)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 3, sbclookup.ExecCount.Get())
+
+	// Queries with nondeterministic functions are not cached.
+	for _, nondeterministic := range []string{
+		"select /*vt+ RESULT_CACHE_TTL=1m */ id, now() from main1",
+		"select /*vt+ RESULT_CACHE_TTL=1m */ id from main1 order by rand()",
+	} {
+		execCount := sbclookup.ExecCount.Get()
+		for i := 0; i < 2; i++ {
+			_, err := executorExecSession(executor, nondeterministic, nil, session)
+			require.NoError(t, err)
+		}
+		assert.EqualValues(t, execCount+2, sbclookup.ExecCount.Get(), nondeterministic)
+	}
+
+	// Writes through vtgate invalidate the results of the table.
+	_, err := executorExecSession(executor, "update main1 set id = 2", nil, session)
+	require.NoError(t, err)
+	execCount := sbclookup.ExecCount.Get()
+	_, err = executorExecSession(executor, query, nil, session)
+	require.NoError(t, err)
+	assert.EqualValues(t, execCount+1, sbclookup.ExecCount.Get())
+
+	// Results are not shared with sessions in a transaction.
+	txSession := &vtgatepb.Session{TargetString: "@primary", InTransaction: true}
+	execCount = sbclookup.ExecCount.Get()
+	_, err = executorExecSession(executor, query, nil, txSession)
+	require.NoError(t, err)
+	assert.EqualValues(t, execCount+1, sbclookup.ExecCount.Get())
+}
+
+func TestExecutorResultCacheTransaction(t *testing.T) {
+	executor, _, _, sbclookup := createExecutorEnv()
+	executor.pv = querypb.ExecuteOptions_Gen4
+	executor.results = newResultCache(1 << 20)
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+	txSession := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+
+	query := "select /*vt+ RESULT_CACHE_TTL=1m */ id from main1"
+	_, err := executorExecSession(executor, "begin", nil, txSession)
+	require.NoError(t, err)
+	_, err = executorExecSession(executor, "update main1 set id = 2", nil, txSession)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TestUnsharded.main1"}, txSession.ResultCacheTables)
+
+	// Another session caches the rows written before the commit.
+	_, err = executorExecSession(executor, query, nil, session)
+	require.NoError(t, err)
+	execCount := sbclookup.ExecCount.Get()
+	_, err = executorExecSession(executor, query, nil, session)
+	require.NoError(t, err)
+	assert.EqualValues(t, execCount, sbclookup.ExecCount.Get())
+
+	// The commit invalidates them again.
+	_, err = executorExecSession(executor, "commit", nil, txSession)
+	require.NoError(t, err)
+	assert.Empty(t, txSession.ResultCacheTables)
+	_, err = executorExecSession(executor, query, nil, session)
+	require.NoError(t, err)
+	assert.EqualValues(t, execCount+1, sbclookup.ExecCount.Get())
+}
@@ -151,6 +151,7 @@ func (session *SafeSession) ResetTx() {
 	session.Session.InTransaction = false
 	session.commitOrder = vtgatepb.CommitOrder_NORMAL
 	session.Savepoints = nil
+	session.ResultCacheTables = nil
 	if !session.Session.InReservedConn {
 		session.ShardSessions = nil
 		session.PreSessions = nil
@@ -167,6 +168,7 @@ func (session *SafeSession) Reset() {
 	session.Session.InTransaction = false
 	session.commitOrder = vtgatepb.CommitOrder_NORMAL
 	session.Savepoints = nil
+	session.ResultCacheTables = nil
 	session.ShardSessions = nil
 	session.PreSessions = nil
 	session.PostSessions = nil
@@ -179,6 +181,32 @@ func (session *SafeSession) SavePoints() []string {
 	return session.GetSavepoints()
 }
 
+// AddTablesWritten records tables written by the open transaction, whose
+// cached results are invalidated again when it commits.
+func (session *SafeSession) AddTablesWritten(tables ...string) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	for _, table := range tables {
+		found := false
+		for _, written := range session.ResultCacheTables {
+			if written == table {
+				found = true
+				break
+			}
+		}
+		if !found {
+			session.ResultCacheTables = append(session.ResultCacheTables, table)
+		}
+	}
+}
+
+// TablesWritten returns the tables written by the open transaction. It's safe to use concurrently.
+func (session *SafeSession) TablesWritten() []string {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return append([]string(nil), session.ResultCacheTables...)
+}
+
 // SetAutocommittable sets the state to autocommitable if true.
 // Otherwise, it's notAutocommitable.
 func (session *SafeSession) SetAutocommittable(flag bool) {
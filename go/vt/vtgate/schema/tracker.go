@@ -18,6 +18,7 @@ package schema
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -52,9 +53,18 @@ type (
 		ctx    context.Context
 		signal func() // a function that we'll call whenever we have new schema data
 
+		// tablesChanged is called with the tables of a keyspace whose schema
+		// changed, or with no tables when the whole keyspace was reloaded.
+		tablesChanged func(keyspace string, tables []string)
+
 		// map of keyspace currently tracked
 		tracked      map[keyspaceStr]*updateController
 		consumeDelay time.Duration
+
+		// foreign keys are only fetched when trackForeignKeys is set,
+		// they are keyed by the child table that declares them.
+		trackForeignKeys bool
+		foreignKeys      map[keyspaceStr]map[tableNameStr][]*vindexes.ForeignKey
 	}
 )
 
@@ -79,15 +89,31 @@ func NewTracker(ch chan *discovery.TabletHealth, user *string) *Tracker {
 		tables:       &tableMap{m: map[keyspaceStr]map[tableNameStr][]vindexes.Column{}},
 		tracked:      map[keyspaceStr]*updateController{},
 		consumeDelay: defaultConsumeDelay,
+		foreignKeys:  map[keyspaceStr]map[tableNameStr][]*vindexes.ForeignKey{},
 	}
 }
 
+// TrackForeignKeys makes the tracker load the foreign key definitions
+// along with the columns of the tables. It must be called before Start.
+func (t *Tracker) TrackForeignKeys() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.trackForeignKeys = true
+}
+
 // LoadKeyspace loads the keyspace schema.
 func (t *Tracker) LoadKeyspace(conn queryservice.QueryService, target *querypb.Target) error {
 	res, err := conn.Execute(t.ctx, target, mysql.FetchTables, nil, 0, 0, nil)
 	if err != nil {
 		return err
 	}
+	var fkRes *sqltypes.Result
+	if t.shouldTrackForeignKeys() {
+		fkRes, err = conn.Execute(t.ctx, target, mysql.FetchForeignKeys, nil, 0, 0, nil)
+		if err != nil {
+			return err
+		}
+	}
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	// We must clear out any previous schema before loading it here as this is called
@@ -96,6 +122,7 @@ func (t *Tracker) LoadKeyspace(conn queryservice.QueryService, target *querypb.T
 	// tablet is simply restarted or potentially when we elect a new primary.
 	t.clearKeyspaceTables(target.Keyspace)
 	t.updateTables(target.Keyspace, res)
+	t.updateForeignKeys(target.Keyspace, fkRes)
 	t.tracked[target.Keyspace].setLoaded(true)
 	log.Infof("finished loading schema for keyspace %s. Found %d columns in total across the tables", target.Keyspace, len(res.Rows))
 	return nil
@@ -148,6 +175,11 @@ func (t *Tracker) initKeyspace(th *discovery.TabletHealth) error {
 		}
 		return err
 	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tablesChanged != nil {
+		t.tablesChanged(th.Target.Keyspace, nil)
+	}
 	return nil
 }
 
@@ -178,6 +210,26 @@ func (t *Tracker) Tables(ks string) map[string][]vindexes.Column {
 	return m
 }
 
+// ForeignKeys returns a map with the foreign keys declared by all known tables in the keyspace.
+// The map is a copy, since the tracker keeps updating its own map as the schema changes.
+func (t *Tracker) ForeignKeys(ks string) map[string][]*vindexes.ForeignKey {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := t.foreignKeys[ks]
+	res := make(map[string][]*vindexes.ForeignKey, len(m))
+	for tbl, fks := range m {
+		res[tbl] = append([]*vindexes.ForeignKey(nil), fks...)
+	}
+	return res
+}
+
+func (t *Tracker) shouldTrackForeignKeys() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.trackForeignKeys
+}
+
 func (t *Tracker) updateSchema(th *discovery.TabletHealth) bool {
 	tablesUpdated := th.Stats.TableSchemaChanged
 	tables, err := sqltypes.BuildBindVariable(tablesUpdated)
@@ -197,6 +249,15 @@ func (t *Tracker) updateSchema(th *discovery.TabletHealth) bool {
 		}
 		return false
 	}
+	var fkRes *sqltypes.Result
+	if t.shouldTrackForeignKeys() {
+		fkRes, err = th.Conn.Execute(t.ctx, th.Target, mysql.FetchUpdatedForeignKeys, bv, 0, 0, nil)
+		if err != nil {
+			t.tracked[th.Target.Keyspace].setLoaded(false)
+			log.Warningf("error fetching foreign keys for %v: %v", tablesUpdated, err)
+			return false
+		}
+	}
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -205,8 +266,15 @@ func (t *Tracker) updateSchema(th *discovery.TabletHealth) bool {
 	// so this is the only chance to delete
 	for _, tbl := range tablesUpdated {
 		t.tables.delete(th.Target.Keyspace, tbl)
+		if m := t.foreignKeys[th.Target.Keyspace]; m != nil {
+			delete(m, tbl)
+		}
 	}
 	t.updateTables(th.Target.Keyspace, res)
+	t.updateForeignKeys(th.Target.Keyspace, fkRes)
+	if t.tablesChanged != nil {
+		t.tablesChanged(th.Target.Keyspace, tablesUpdated)
+	}
 	return true
 }
 
@@ -225,6 +293,52 @@ func (t *Tracker) updateTables(keyspace string, res *sqltypes.Result) {
 	}
 }
 
+// updateForeignKeys adds the foreign keys found in the result. The rows are ordered by
+// table and constraint name, with one row per column of the constraint.
+func (t *Tracker) updateForeignKeys(keyspace string, res *sqltypes.Result) {
+	if res == nil {
+		return
+	}
+	m := t.foreignKeys[keyspace]
+	if m == nil {
+		m = make(map[tableNameStr][]*vindexes.ForeignKey)
+		t.foreignKeys[keyspace] = m
+	}
+	var fk *vindexes.ForeignKey
+	for _, row := range res.Rows {
+		tbl := row[0].ToString()
+		name := row[1].ToString()
+		if fk == nil || fk.Table.String() != tbl || fk.Name != name {
+			fk = &vindexes.ForeignKey{
+				Name:        name,
+				Table:       sqlparser.NewIdentifierCS(tbl),
+				ParentTable: sqlparser.NewIdentifierCS(row[3].ToString()),
+				OnUpdate:    referenceAction(row[5].ToString()),
+				OnDelete:    referenceAction(row[6].ToString()),
+			}
+			m[tbl] = append(m[tbl], fk)
+		}
+		fk.Columns = append(fk.Columns, sqlparser.NewIdentifierCI(row[2].ToString()))
+		fk.ParentColumns = append(fk.ParentColumns, sqlparser.NewIdentifierCI(row[4].ToString()))
+	}
+}
+
+// referenceAction maps the rules reported by information_schema.referential_constraints
+func referenceAction(rule string) sqlparser.ReferenceAction {
+	switch strings.ToUpper(rule) {
+	case "CASCADE":
+		return sqlparser.Cascade
+	case "SET NULL":
+		return sqlparser.SetNull
+	case "SET DEFAULT":
+		return sqlparser.SetDefault
+	case "NO ACTION":
+		return sqlparser.NoAction
+	default:
+		return sqlparser.Restrict
+	}
+}
+
 // RegisterSignalReceiver allows a function to register to be called when new schema is available
 func (t *Tracker) RegisterSignalReceiver(f func()) {
 	t.mu.Lock()
@@ -235,6 +349,15 @@ func (t *Tracker) RegisterSignalReceiver(f func()) {
 	t.signal = f
 }
 
+// RegisterTablesChangedReceiver allows a function to register to be called
+// with the tables whose schema changed. When a whole keyspace is reloaded, the
+// function is called with no tables.
+func (t *Tracker) RegisterTablesChangedReceiver(f func(keyspace string, tables []string)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tablesChanged = f
+}
+
 // AddNewKeyspace adds keyspace to the tracker.
 func (t *Tracker) AddNewKeyspace(conn queryservice.QueryService, target *querypb.Target) error {
 	updateController := t.newUpdateController()
@@ -282,4 +405,5 @@ func (t *Tracker) clearKeyspaceTables(ks string) {
 	if t.tables != nil && t.tables.m != nil {
 		delete(t.tables.m, ks)
 	}
+	delete(t.foreignKeys, ks)
 }
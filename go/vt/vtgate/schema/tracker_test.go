@@ -18,6 +18,7 @@ package schema
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -170,6 +171,13 @@ func TestTracking(t *testing.T) {
 			tracker.RegisterSignalReceiver(func() {
 				wg.Done()
 			})
+			var changedMu sync.Mutex
+			var changed []string
+			tracker.RegisterTablesChangedReceiver(func(keyspace string, tables []string) {
+				changedMu.Lock()
+				defer changedMu.Unlock()
+				changed = append(changed, keyspace+":"+strings.Join(tables, ","))
+			})
 
 			for _, d := range tcase.deltas {
 				ch <- &discovery.TabletHealth{
@@ -188,6 +196,11 @@ func TestTracking(t *testing.T) {
 			_, keyspacePresent := tracker.tracked[target.Keyspace]
 			require.Equal(t, true, keyspacePresent)
 
+			// The keyspace was loaded as a whole.
+			changedMu.Lock()
+			require.Equal(t, []string{"ks:"}, changed)
+			changedMu.Unlock()
+
 			for k, v := range tcase.exp {
 				utils.MustMatch(t, v, tracker.GetColumns("ks", k), "mismatch for table: ", k)
 			}
@@ -263,6 +276,68 @@ func TestTrackingUnHealthyTablet(t *testing.T) {
 	require.Equal(t, []string{mysql.FetchTables, mysql.FetchUpdatedTables, mysql.FetchTables}, sbc.StringQueries())
 }
 
+func TestTrackingForeignKeys(t *testing.T) {
+	target := &querypb.Target{
+		Keyspace:   "ks",
+		Shard:      "-80",
+		TabletType: topodatapb.TabletType_PRIMARY,
+		Cell:       "aa",
+	}
+	tablet := &topodatapb.Tablet{
+		Keyspace: target.Keyspace,
+		Shard:    target.Shard,
+		Type:     target.TabletType,
+	}
+
+	sbc := sandboxconn.NewSandboxConn(tablet)
+	sbc.SetResults([]*sqltypes.Result{
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields("table_name|col_name|col_type|collation_name", "varchar|varchar|varchar|varchar"),
+			"parent|id|int|",
+			"parent|a|int|",
+			"child|id|int|",
+			"child|pid|int|",
+			"child|pa|int|",
+		),
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"table_name|constraint_name|column_name|referenced_table_name|referenced_column_name|update_rule|delete_rule",
+				"varchar|varchar|varchar|varchar|varchar|varchar|varchar",
+			),
+			"child|fk_parent|pid|parent|id|CASCADE|SET NULL",
+			"child|fk_parent|pa|parent|a|CASCADE|SET NULL",
+			"child|fk_self|pid|child|id|NO ACTION|RESTRICT",
+		),
+	})
+
+	tracker := NewTracker(nil, nil)
+	tracker.TrackForeignKeys()
+	tracker.tracked[target.Keyspace] = tracker.newUpdateController()
+	require.NoError(t, tracker.LoadKeyspace(sbc, target))
+	require.Equal(t, []string{mysql.FetchTables, mysql.FetchForeignKeys}, sbc.StringQueries())
+
+	utils.MustMatch(t, map[string][]*vindexes.ForeignKey{
+		"child": {{
+			Name:          "fk_parent",
+			Table:         sqlparser.NewIdentifierCS("child"),
+			Columns:       []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("pid"), sqlparser.NewIdentifierCI("pa")},
+			ParentTable:   sqlparser.NewIdentifierCS("parent"),
+			ParentColumns: []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("id"), sqlparser.NewIdentifierCI("a")},
+			OnDelete:      sqlparser.SetNull,
+			OnUpdate:      sqlparser.Cascade,
+		}, {
+			Name:          "fk_self",
+			Table:         sqlparser.NewIdentifierCS("child"),
+			Columns:       []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("pid")},
+			ParentTable:   sqlparser.NewIdentifierCS("child"),
+			ParentColumns: []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("id")},
+			OnDelete:      sqlparser.Restrict,
+			OnUpdate:      sqlparser.NoAction,
+		}},
+	}, tracker.ForeignKeys("ks"))
+	require.Empty(t, tracker.ForeignKeys("other_ks"))
+}
+
 func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
 	c := make(chan struct{})
 	go func() {
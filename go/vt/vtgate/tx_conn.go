@@ -39,6 +39,9 @@ import (
 type TxConn struct {
 	tabletGateway *TabletGateway
 	mode          vtgatepb.TransactionMode
+	// tablesCommitted, if set, is called with the tables written by
+	// every transaction after it commits.
+	tablesCommitted func(tables []string)
 }
 
 // NewTxConn builds a new TxConn.
@@ -77,6 +80,12 @@ func (txc *TxConn) Commit(ctx context.Context, session *SafeSession) error {
 		twopc = txc.mode == vtgatepb.TransactionMode_TWOPC
 	}
 
+	tables := session.TablesWritten()
+	if txc.tablesCommitted != nil && len(tables) > 0 {
+		// A failed commit can still have committed some of the shards.
+		defer txc.tablesCommitted(tables)
+	}
+
 	if twopc {
 		return txc.commit2PC(ctx, session)
 	}
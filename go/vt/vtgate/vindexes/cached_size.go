@@ -150,6 +150,36 @@ func (cached *ConsistentLookupUnique) CachedSize(alloc bool) int64 {
 	size += cached.clCommon.CachedSize(true)
 	return size
 }
+func (cached *ForeignKey) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(112)
+	}
+	// field Name string
+	size += hack.RuntimeAllocSize(int64(len(cached.Name)))
+	// field Table vitess.io/vitess/go/vt/sqlparser.IdentifierCS
+	size += cached.Table.CachedSize(false)
+	// field Columns []vitess.io/vitess/go/vt/sqlparser.IdentifierCI
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.Columns)) * int64(32))
+		for _, elem := range cached.Columns {
+			size += elem.CachedSize(false)
+		}
+	}
+	// field ParentTable vitess.io/vitess/go/vt/sqlparser.IdentifierCS
+	size += cached.ParentTable.CachedSize(false)
+	// field ParentColumns []vitess.io/vitess/go/vt/sqlparser.IdentifierCI
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ParentColumns)) * int64(32))
+		for _, elem := range cached.ParentColumns {
+			size += elem.CachedSize(false)
+		}
+	}
+	return size
+}
 func (cached *Hash) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
@@ -406,7 +436,7 @@ func (cached *Table) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(176)
+		size += int64(240)
 	}
 	// field Type string
 	size += hack.RuntimeAllocSize(int64(len(cached.Type)))
@@ -448,6 +478,20 @@ func (cached *Table) CachedSize(alloc bool) int64 {
 	{
 		size += hack.RuntimeAllocSize(int64(cap(cached.Pinned)))
 	}
+	// field ParentForeignKeys []*vitess.io/vitess/go/vt/vtgate/vindexes.ForeignKey
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ParentForeignKeys)) * int64(8))
+		for _, elem := range cached.ParentForeignKeys {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field ChildForeignKeys []*vitess.io/vitess/go/vt/vtgate/vindexes.ForeignKey
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.ChildForeignKeys)) * int64(8))
+		for _, elem := range cached.ChildForeignKeys {
+			size += elem.CachedSize(true)
+		}
+	}
 	return size
 }
 func (cached *UnicodeLooseMD5) CachedSize(alloc bool) int64 {
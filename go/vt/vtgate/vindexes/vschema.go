@@ -23,6 +23,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"vitess.io/vitess/go/sqlescape"
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
@@ -97,6 +98,15 @@ type Table struct {
 	Columns                 []Column               `json:"columns,omitempty"`
 	Pinned                  []byte                 `json:"pinned,omitempty"`
 	ColumnListAuthoritative bool                   `json:"column_list_authoritative,omitempty"`
+	// ResultCacheTTL is how long vtgate can cache the results of the
+	// SELECTs on this table, or 0 if they are not cached.
+	ResultCacheTTL time.Duration `json:"result_cache_ttl,omitempty"`
+
+	// ParentForeignKeys are the foreign keys declared on this table,
+	// and ChildForeignKeys are the foreign keys of other tables that
+	// reference it. Both are only populated by the schema tracker.
+	ParentForeignKeys []*ForeignKey `json:"parent_foreign_keys,omitempty"`
+	ChildForeignKeys  []*ForeignKey `json:"child_foreign_keys,omitempty"`
 }
 
 // Keyspace contains the keyspcae info for each Table.
@@ -153,6 +163,48 @@ func (col *Column) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// ForeignKey describes a foreign key constraint between a child
+// table and the parent table it references. Both tables always
+// belong to the same keyspace.
+type ForeignKey struct {
+	Name          string
+	Table         sqlparser.IdentifierCS
+	Columns       []sqlparser.IdentifierCI
+	ParentTable   sqlparser.IdentifierCS
+	ParentColumns []sqlparser.IdentifierCI
+	OnDelete      sqlparser.ReferenceAction
+	OnUpdate      sqlparser.ReferenceAction
+}
+
+// MarshalJSON returns a JSON representation of ForeignKey.
+func (fk *ForeignKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name          string   `json:"name"`
+		Table         string   `json:"table"`
+		Columns       []string `json:"columns"`
+		ParentTable   string   `json:"parent_table"`
+		ParentColumns []string `json:"parent_columns"`
+		OnDelete      string   `json:"on_delete,omitempty"`
+		OnUpdate      string   `json:"on_update,omitempty"`
+	}{
+		Name:          fk.Name,
+		Table:         fk.Table.String(),
+		Columns:       identifierStrings(fk.Columns),
+		ParentTable:   fk.ParentTable.String(),
+		ParentColumns: identifierStrings(fk.ParentColumns),
+		OnDelete:      sqlparser.String(fk.OnDelete),
+		OnUpdate:      sqlparser.String(fk.OnUpdate),
+	})
+}
+
+func identifierStrings(cols []sqlparser.IdentifierCI) []string {
+	res := make([]string, 0, len(cols))
+	for _, col := range cols {
+		res = append(res, col.String())
+	}
+	return res
+}
+
 // KeyspaceSchema contains the schema(table) for a keyspace.
 type KeyspaceSchema struct {
 	Keyspace *Keyspace
@@ -289,6 +341,13 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 			}
 			t.Pinned = decoded
 		}
+		if table.ResultCacheTtl != "" {
+			ttl, err := time.ParseDuration(table.ResultCacheTtl)
+			if err != nil || ttl < 0 {
+				return fmt.Errorf("invalid result_cache_ttl %q for table: %s", table.ResultCacheTtl, tname)
+			}
+			t.ResultCacheTTL = ttl
+		}
 
 		// If keyspace is sharded, then any table that's not a reference or pinned must have vindexes.
 		if keyspace.Sharded && t.Type != TypeReference && table.Pinned == "" && len(table.ColumnVindexes) == 0 {
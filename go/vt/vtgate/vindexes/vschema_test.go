@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"vitess.io/vitess/go/test/utils"
 
@@ -252,6 +253,29 @@ func TestUnshardedVSchemaValid(t *testing.T) {
 	}
 }
 
+func TestVSchemaResultCacheTTL(t *testing.T) {
+	srvVSchema := &vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"unsharded": {
+				Tables: map[string]*vschemapb.Table{
+					"t1": {ResultCacheTtl: "5s"},
+					"t2": {},
+				},
+			},
+			"invalid": {
+				Tables: map[string]*vschemapb.Table{
+					"t1": {ResultCacheTtl: "5"},
+				},
+			},
+		},
+	}
+	got := BuildVSchema(srvVSchema)
+	require.NoError(t, got.Keyspaces["unsharded"].Error)
+	assert.Equal(t, 5*time.Second, got.Keyspaces["unsharded"].Tables["t1"].ResultCacheTTL)
+	assert.Zero(t, got.Keyspaces["unsharded"].Tables["t2"].ResultCacheTTL)
+	assert.EqualError(t, got.Keyspaces["invalid"].Error, `invalid result_cache_ttl "5" for table: t1`)
+}
+
 func TestUnshardedVSchema(t *testing.T) {
 	good := vschemapb.SrvVSchema{
 		Keyspaces: map[string]*vschemapb.Keyspace{
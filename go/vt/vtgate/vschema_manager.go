@@ -49,6 +49,7 @@ type VSchemaManager struct {
 // SchemaInfo is an interface to schema tracker.
 type SchemaInfo interface {
 	Tables(ks string) map[string][]vindexes.Column
+	ForeignKeys(ks string) map[string][]*vindexes.ForeignKey
 }
 
 // GetCurrentSrvVschema returns a copy of the latest SrvVschema from the
@@ -209,5 +210,20 @@ func (vm *VSchemaManager) updateFromSchema(vschema *vindexes.VSchema) {
 				vTbl.ColumnListAuthoritative = true
 			}
 		}
+
+		for tblName, fks := range vm.schema.ForeignKeys(ksName) {
+			child := ks.Tables[tblName]
+			if child == nil {
+				continue
+			}
+			for _, fk := range fks {
+				parent := ks.Tables[fk.ParentTable.String()]
+				if parent == nil {
+					continue
+				}
+				child.ParentForeignKeys = append(child.ParentForeignKeys, fk)
+				parent.ChildForeignKeys = append(parent.ChildForeignKeys, fk)
+			}
+		}
 	}
 }
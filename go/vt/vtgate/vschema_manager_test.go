@@ -250,7 +250,8 @@ func makeTestSrvVSchema(ks string, sharded bool, tbls map[string]*vschemapb.Tabl
 }
 
 type fakeSchema struct {
-	t map[string][]vindexes.Column
+	t   map[string][]vindexes.Column
+	fks map[string][]*vindexes.ForeignKey
 }
 
 var _ SchemaInfo = (*fakeSchema)(nil)
@@ -258,3 +259,7 @@ var _ SchemaInfo = (*fakeSchema)(nil)
 func (f *fakeSchema) Tables(string) map[string][]vindexes.Column {
 	return f.t
 }
+
+func (f *fakeSchema) ForeignKeys(string) map[string][]*vindexes.ForeignKey {
+	return f.fks
+}
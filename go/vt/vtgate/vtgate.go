@@ -90,7 +90,7 @@ var (
 	lockHeartbeatTime = flag.Duration("lock_heartbeat_time", 5*time.Second, "If there is lock function used. This will keep the lock connection active by using this heartbeat")
 	warnShardedOnly   = flag.Bool("warn_sharded_only", false, "If any features that are only available in unsharded mode are used, query execution warnings will be added to the session")
 
-	foreignKeyMode = flag.String("foreign_key_mode", "allow", "This is to provide how to handle foreign key constraint in create/alter table. Valid values are: allow, disallow")
+	foreignKeyMode = flag.String("foreign_key_mode", "allow", "This is to provide how to handle foreign key constraint in create/alter table. Valid values are: allow, disallow, managed")
 
 	// flags to enable/disable online and direct DDL statements
 	enableOnlineDDL = flag.Bool("enable_online_ddl", true, "Allow users to submit, review and control Online DDL")
@@ -214,10 +214,17 @@ func Init(
 	resolver := NewResolver(srvResolver, serv, cell, sc)
 	vsm := newVStreamManager(srvResolver, serv, cell)
 
+	if strings.ToLower(*foreignKeyMode) == "managed" && !*enableSchemaChangeSignal {
+		log.Fatalf("--foreign_key_mode=managed requires the schema tracker, enable it with --schema_change_signal")
+	}
+
 	var si SchemaInfo // default nil
 	var st *vtschema.Tracker
 	if *enableSchemaChangeSignal {
 		st = vtschema.NewTracker(gw.hc.Subscribe(), schemaChangeUser)
+		if strings.ToLower(*foreignKeyMode) == "managed" {
+			st.TrackForeignKeys()
+		}
 		addKeyspaceToTracker(ctx, srvResolver, st, gw)
 		si = st
 	}
@@ -247,6 +254,16 @@ func Init(
 		st.RegisterSignalReceiver(executor.vm.Rebuild)
 	}
 
+	// invalidate the cached results when the tables they use change
+	if executor.results != nil {
+		if *enableSchemaChangeSignal {
+			st.RegisterTablesChangedReceiver(executor.results.schemaChanged)
+		}
+		if *resultCacheVStreamInvalidation {
+			executor.results.startVStreamInvalidation(ctx, vsm)
+		}
+	}
+
 	// TODO: call serv.WatchSrvVSchema here
 
 	rpcVTGate = &VTGate{
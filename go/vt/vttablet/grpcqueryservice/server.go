@@ -291,7 +291,7 @@ func (q *query) MessageStream(request *querypb.MessageStreamRequest, stream quer
 		request.EffectiveCallerId,
 		request.ImmediateCallerId,
 	)
-	err = q.server.MessageStream(ctx, request.Target, request.Name, func(qr *sqltypes.Result) error {
+	err = q.server.MessageStream(ctx, request.Target, request.Name, request.Group, func(qr *sqltypes.Result) error {
 		return stream.Send(&querypb.MessageStreamResponse{
 			Result: sqltypes.ResultToProto3(qr),
 		})
@@ -306,7 +306,7 @@ func (q *query) MessageAck(ctx context.Context, request *querypb.MessageAckReque
 		request.EffectiveCallerId,
 		request.ImmediateCallerId,
 	)
-	count, err := q.server.MessageAck(ctx, request.Target, request.Name, request.Ids)
+	count, err := q.server.MessageAck(ctx, request.Target, request.Name, request.Group, request.Ids)
 	if err != nil {
 		return nil, vterrors.ToGRPC(err)
 	}
@@ -539,7 +539,7 @@ func (conn *gRPCQueryClient) BeginStreamExecute(ctx context.Context, target *que
 }
 
 // MessageStream streams messages.
-func (conn *gRPCQueryClient) MessageStream(ctx context.Context, target *querypb.Target, name string, callback func(*sqltypes.Result) error) error {
+func (conn *gRPCQueryClient) MessageStream(ctx context.Context, target *querypb.Target, name, group string, callback func(*sqltypes.Result) error) error {
 	// Please see comments in StreamExecute to see how this works.
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -556,6 +556,7 @@ func (conn *gRPCQueryClient) MessageStream(ctx context.Context, target *querypb.
 			EffectiveCallerId: callerid.EffectiveCallerIDFromContext(ctx),
 			ImmediateCallerId: callerid.ImmediateCallerIDFromContext(ctx),
 			Name:              name,
+			Group:             group,
 		}
 		stream, err := conn.c.MessageStream(ctx, req)
 		if err != nil {
@@ -585,7 +586,7 @@ func (conn *gRPCQueryClient) MessageStream(ctx context.Context, target *querypb.
 }
 
 // MessageAck acks messages.
-func (conn *gRPCQueryClient) MessageAck(ctx context.Context, target *querypb.Target, name string, ids []*querypb.Value) (int64, error) {
+func (conn *gRPCQueryClient) MessageAck(ctx context.Context, target *querypb.Target, name, group string, ids []*querypb.Value) (int64, error) {
 	conn.mu.RLock()
 	defer conn.mu.RUnlock()
 	if conn.cc == nil {
@@ -597,6 +598,7 @@ func (conn *gRPCQueryClient) MessageAck(ctx context.Context, target *querypb.Tar
 		ImmediateCallerId: callerid.ImmediateCallerIDFromContext(ctx),
 		Name:              name,
 		Ids:               ids,
+		Group:             group,
 	}
 	reply, err := conn.c.MessageAck(ctx, req)
 	if err != nil {
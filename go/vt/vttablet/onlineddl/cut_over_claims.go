@@ -0,0 +1,210 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onlineddl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo"
+)
+
+const (
+	// cutOverClaimsDir is the directory of the global topo, under the keyspace, that holds the claims of
+	// the migrations of the keyspace that are cutting over.
+	cutOverClaimsDir = "online_ddl_cut_overs"
+	// cutOverClaimTimeout is how long a claim is honored. Claims are normally released once the migration
+	// completes or fails, but a tablet may fail to do so, e.g. if it crashes in the middle of a cut-over.
+	cutOverClaimTimeout = 10 * time.Minute
+	// cutOverClaimRefreshInterval is how often the claim of a migration is refreshed while it cuts over.
+	cutOverClaimRefreshInterval = time.Minute
+	// cutOverLockTimeout is how long to wait for the keyspace lock before giving up for this review.
+	cutOverLockTimeout = 5 * time.Second
+)
+
+// cutOverClaim is the content of a claim file. Migrations with --cut-over-max-concurrent claim a
+// cut-over slot of their keyspace in the global topo before cutting over, so that the limit applies
+// to all the shards of the keyspace.
+type cutOverClaim struct {
+	UUID      string `json:"uuid"`
+	Shard     string `json:"shard"`
+	ClaimedAt int64  `json:"claimed_at"`
+}
+
+func (e *Executor) cutOverClaimPath(uuid string) string {
+	return path.Join(topo.KeyspacesPath, e.keyspace, cutOverClaimsDir, fmt.Sprintf("%s_%s", uuid, e.shard))
+}
+
+// claimCutOver claims one of the maxConcurrent cut-over slots of the keyspace for the given migration.
+// The claims are first read without the keyspace lock, so that migrations waiting for a slot don't take
+// the lock on every review, and are counted again and created under the lock once a slot looks free. A
+// migration that already holds a slot refreshes its claim. It returns the reason the migration has to
+// wait if all the slots are taken, or an empty string if the migration holds a slot.
+func (e *Executor) claimCutOver(ctx context.Context, uuid string, maxConcurrent int) (reason string, err error) {
+	conn, err := e.ts.ConnForCell(ctx, topo.GlobalCell)
+	if err != nil {
+		return "", err
+	}
+	claimPath := e.cutOverClaimPath(uuid)
+	claims, claimed, err := readCutOverClaims(ctx, conn, claimPath, false)
+	if err != nil {
+		return "", err
+	}
+	if claimed {
+		// A gh-ost migration keeps its claim until it completes.
+		return "", refreshCutOverClaim(ctx, conn, claimPath)
+	}
+	if claims >= maxConcurrent {
+		return cutOverClaimsReason(claims, e.keyspace, maxConcurrent), nil
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, cutOverLockTimeout)
+	defer cancel()
+	lockCtx, unlock, lockErr := e.ts.LockKeyspace(lockCtx, e.keyspace, "online-ddl cut-over")
+	if lockErr != nil {
+		return fmt.Sprintf("unable to lock keyspace %s: %v", e.keyspace, lockErr), nil
+	}
+	defer unlock(&err)
+
+	claims, claimed, err = readCutOverClaims(lockCtx, conn, claimPath, true)
+	if err != nil || claimed {
+		return "", err
+	}
+	if claims >= maxConcurrent {
+		return cutOverClaimsReason(claims, e.keyspace, maxConcurrent), nil
+	}
+	contents, err := json.Marshal(&cutOverClaim{UUID: uuid, Shard: e.shard, ClaimedAt: time.Now().Unix()})
+	if err != nil {
+		return "", err
+	}
+	if _, err := conn.Create(lockCtx, claimPath, contents); err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+func cutOverClaimsReason(claims int, keyspace string, maxConcurrent int) string {
+	return fmt.Sprintf("%d migrations are cutting over in keyspace %s, the maximum is %d", claims, keyspace, maxConcurrent)
+}
+
+// readCutOverClaims counts the claims of the keyspace that have not expired, and tells whether the claim
+// at claimPath is one of them. Expired claims are removed if removeExpired is set, which must only be done
+// under the keyspace lock.
+func readCutOverClaims(ctx context.Context, conn topo.Conn, claimPath string, removeExpired bool) (claims int, claimed bool, err error) {
+	dir := path.Dir(claimPath)
+	entries, err := conn.ListDir(ctx, dir, false)
+	if err != nil && !topo.IsErrType(err, topo.NoNode) {
+		return 0, false, err
+	}
+	for _, entry := range entries {
+		entryPath := path.Join(dir, entry.Name)
+		if entryPath == claimPath {
+			return 0, true, nil
+		}
+		contents, _, err := conn.Get(ctx, entryPath)
+		if err != nil {
+			if topo.IsErrType(err, topo.NoNode) {
+				continue
+			}
+			return 0, false, err
+		}
+		var claim cutOverClaim
+		if err := json.Unmarshal(contents, &claim); err != nil || time.Since(time.Unix(claim.ClaimedAt, 0)) > cutOverClaimTimeout {
+			if !removeExpired {
+				continue
+			}
+			log.Warningf("removing expired cut-over claim %s: %s", entryPath, contents)
+			if err := conn.Delete(ctx, entryPath, nil); err != nil && !topo.IsErrType(err, topo.NoNode) {
+				return 0, false, err
+			}
+			continue
+		}
+		claims++
+	}
+	return claims, false, nil
+}
+
+// refreshCutOverClaim resets the time of the claim at claimPath, if it still exists, so that the claim
+// doesn't expire while its migration is cutting over.
+func refreshCutOverClaim(ctx context.Context, conn topo.Conn, claimPath string) error {
+	contents, version, err := conn.Get(ctx, claimPath)
+	if err != nil {
+		if topo.IsErrType(err, topo.NoNode) {
+			return nil
+		}
+		return err
+	}
+	var claim cutOverClaim
+	if err := json.Unmarshal(contents, &claim); err != nil {
+		return err
+	}
+	claim.ClaimedAt = time.Now().Unix()
+	if contents, err = json.Marshal(&claim); err != nil {
+		return err
+	}
+	// The claim may have been removed as expired in the meantime, in which case it is not recreated.
+	if _, err := conn.Update(ctx, claimPath, contents, version); err != nil && !topo.IsErrType(err, topo.BadVersion) && !topo.IsErrType(err, topo.NoNode) {
+		return err
+	}
+	return nil
+}
+
+// keepCutOverClaim refreshes the claim of the given migration every cutOverClaimRefreshInterval, until
+// the returned function is called.
+func (e *Executor) keepCutOverClaim(ctx context.Context, uuid string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(cutOverClaimRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			conn, err := e.ts.ConnForCell(ctx, topo.GlobalCell)
+			if err == nil {
+				err = refreshCutOverClaim(ctx, conn, e.cutOverClaimPath(uuid))
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Errorf("failed to refresh the cut-over claim of migration %s: %v", uuid, err)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// releaseCutOver releases the cut-over slot claimed by the given migration, if any.
+func (e *Executor) releaseCutOver(ctx context.Context, uuid string) error {
+	conn, err := e.ts.ConnForCell(ctx, topo.GlobalCell)
+	if err != nil {
+		return err
+	}
+	if err := conn.Delete(ctx, e.cutOverClaimPath(uuid), nil); err != nil && !topo.IsErrType(err, topo.NoNode) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onlineddl
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/schema"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/memorytopo"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+func TestCutOverMaxConcurrent(t *testing.T) {
+	ctx := context.Background()
+	ts := memorytopo.NewServer("zone1")
+	require.NoError(t, ts.CreateKeyspace(ctx, "ks", &topodatapb.Keyspace{}))
+	// Each shard's primary has its own executor.
+	e1 := &Executor{ts: ts, keyspace: "ks", shard: "-80"}
+	e2 := &Executor{ts: ts, keyspace: "ks", shard: "80-"}
+
+	newMigration := func(uuid string) *schema.OnlineDDL {
+		return &schema.OnlineDDL{UUID: uuid, Strategy: schema.DDLStrategyVitess, Options: "--cut-over-max-concurrent=2"}
+	}
+	m1, m2, m3 := newMigration("uuid1"), newMigration("uuid2"), newMigration("uuid3")
+
+	reason, err := e1.cutOverWaitReason(ctx, m1)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	reason, err = e2.cutOverWaitReason(ctx, m2)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	// A migration keeps its slot until it's released.
+	reason, err = e1.cutOverWaitReason(ctx, m1)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	// The limit applies across the shards of the keyspace.
+	reason, err = e1.cutOverWaitReason(ctx, m3)
+	require.NoError(t, err)
+	assert.Equal(t, "2 migrations are cutting over in keyspace ks, the maximum is 2", reason)
+	reason, err = e2.cutOverWaitReason(ctx, m3)
+	require.NoError(t, err)
+	assert.Equal(t, "2 migrations are cutting over in keyspace ks, the maximum is 2", reason)
+
+	// Migrations waiting for a slot don't take the keyspace lock.
+	lockCtx, unlock, err := ts.LockKeyspace(ctx, "ks", "test")
+	require.NoError(t, err)
+	reason, err = e1.cutOverWaitReason(ctx, m3)
+	require.NoError(t, err)
+	assert.Equal(t, "2 migrations are cutting over in keyspace ks, the maximum is 2", reason)
+	unlock(&err)
+	require.NoError(t, err)
+	require.NoError(t, lockCtx.Err())
+
+	// A migration that holds a slot refreshes its claim.
+	conn, err := ts.ConnForCell(ctx, topo.GlobalCell)
+	require.NoError(t, err)
+	claimPath := e1.cutOverClaimPath(m1.UUID)
+	contents, err := json.Marshal(&cutOverClaim{UUID: m1.UUID, Shard: "-80", ClaimedAt: time.Now().Add(-cutOverClaimTimeout / 2).Unix()})
+	require.NoError(t, err)
+	_, err = conn.Update(ctx, claimPath, contents, nil)
+	require.NoError(t, err)
+	reason, err = e1.cutOverWaitReason(ctx, m1)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	contents, _, err = conn.Get(ctx, claimPath)
+	require.NoError(t, err)
+	var claim cutOverClaim
+	require.NoError(t, json.Unmarshal(contents, &claim))
+	assert.WithinDuration(t, time.Now(), time.Unix(claim.ClaimedAt, 0), time.Minute)
+
+	e2.releaseCutOverPolicySlot(ctx, m2)
+	reason, err = e2.cutOverWaitReason(ctx, m3)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	// Expired claims are ignored.
+	contents, err = json.Marshal(&cutOverClaim{UUID: m1.UUID, Shard: "-80", ClaimedAt: time.Now().Add(-2 * cutOverClaimTimeout).Unix()})
+	require.NoError(t, err)
+	_, err = conn.Update(ctx, claimPath, contents, nil)
+	require.NoError(t, err)
+	reason, err = e1.cutOverWaitReason(ctx, m2)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	entries, err := conn.ListDir(ctx, path.Dir(claimPath), false)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	assert.ElementsMatch(t, []string{"uuid2_-80", "uuid3_80-"}, names)
+}
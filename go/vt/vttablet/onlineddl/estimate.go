@@ -0,0 +1,474 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onlineddl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/dbconnpool"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/schema"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle"
+)
+
+var (
+	// defaultCopyRowsPerSecond is the assumed row copy rate of a migration, used when there is no history
+	// of completed migrations on this shard to learn the rate from.
+	defaultCopyRowsPerSecond = 10000.0
+	// binlogApplyBytesPerSecond is the assumed rate at which a migration applies the binary log events
+	// written during the copy.
+	binlogApplyBytesPerSecond = 16.0 * 1024 * 1024
+	// binlogSampleInterval is the interval over which the binary log write rate is sampled
+	binlogSampleInterval = time.Second
+)
+
+// MigrationEstimate is the outcome of a dry run of a migration: a rough prediction of what running the
+// migration takes on this shard, based on the table's statistics and on the current state of the server.
+type MigrationEstimate struct {
+	Keyspace string
+	Shard    string
+	Table    string
+	// InstantDDL is true when the migration is eligible to run as an INSTANT DDL, which takes no time and no extra disk
+	InstantDDL bool
+	TableRows  int64
+	// TableSize is the size of the table's data and indexes, in bytes
+	TableSize            int64
+	CopyRowsPerSecond    float64
+	CopyTime             time.Duration
+	BinlogBytesPerSecond float64
+	CatchUpTime          time.Duration
+	// ExtraDiskBytes is the peak extra disk space used by the migration: the shadow table, the binary logs
+	// of the copied rows, and the binary logs written by the workload while the migration runs.
+	ExtraDiskBytes int64
+	// Throttled is true when the migration would be throttled right now
+	Throttled bool
+	Warnings  []string
+}
+
+// estimate computes the copy time, catch-up time and extra disk space of the migration from the table size,
+// the copy rate and the binary log write rate.
+func (est *MigrationEstimate) estimate(dataLength int64) {
+	if est.CopyRowsPerSecond > 0 {
+		est.CopyTime = time.Duration(float64(est.TableRows) / est.CopyRowsPerSecond * float64(time.Second))
+	}
+	// The workload keeps writing while the table is copied. Once the copy is done, the migration needs to apply
+	// the backlog while the workload keeps adding to it.
+	backlogBytes := est.BinlogBytesPerSecond * est.CopyTime.Seconds()
+	if est.BinlogBytesPerSecond >= binlogApplyBytesPerSecond {
+		est.Warnings = append(est.Warnings, fmt.Sprintf("binary log write rate of %.0f bytes/sec exceeds the estimated apply rate of %.0f bytes/sec: the migration may never catch up", est.BinlogBytesPerSecond, binlogApplyBytesPerSecond))
+	} else if backlogBytes > 0 {
+		est.CatchUpTime = time.Duration(backlogBytes / (binlogApplyBytesPerSecond - est.BinlogBytesPerSecond) * float64(time.Second))
+	}
+	// The copied rows are written once to the shadow table and once to the binary logs, assuming binary logs
+	// are not purged while the migration runs.
+	workloadBinlogBytes := est.BinlogBytesPerSecond * (est.CopyTime + est.CatchUpTime).Seconds()
+	est.ExtraDiskBytes = est.TableSize + dataLength + int64(workloadBinlogBytes)
+}
+
+// ToResult returns the estimate as a single row result
+func (est *MigrationEstimate) ToResult() *sqltypes.Result {
+	warnings, _ := json.Marshal(est.Warnings)
+	if est.Warnings == nil {
+		warnings = []byte("[]")
+	}
+	return &sqltypes.Result{
+		Fields: []*querypb.Field{
+			{Name: "keyspace", Type: sqltypes.VarChar},
+			{Name: "shard", Type: sqltypes.VarChar},
+			{Name: "table", Type: sqltypes.VarChar},
+			{Name: "instant_ddl", Type: sqltypes.Int64},
+			{Name: "table_rows", Type: sqltypes.Int64},
+			{Name: "table_size", Type: sqltypes.Int64},
+			{Name: "copy_rows_per_second", Type: sqltypes.Float64},
+			{Name: "copy_seconds", Type: sqltypes.Float64},
+			{Name: "binlog_bytes_per_second", Type: sqltypes.Float64},
+			{Name: "catch_up_seconds", Type: sqltypes.Float64},
+			{Name: "extra_disk_bytes", Type: sqltypes.Int64},
+			{Name: "throttled", Type: sqltypes.Int64},
+			{Name: "warnings", Type: sqltypes.VarChar},
+		},
+		Rows: [][]sqltypes.Value{{
+			sqltypes.NewVarChar(est.Keyspace),
+			sqltypes.NewVarChar(est.Shard),
+			sqltypes.NewVarChar(est.Table),
+			sqltypes.NewInt64(boolToInt64(est.InstantDDL)),
+			sqltypes.NewInt64(est.TableRows),
+			sqltypes.NewInt64(est.TableSize),
+			sqltypes.NewFloat64(est.CopyRowsPerSecond),
+			sqltypes.NewFloat64(est.CopyTime.Seconds()),
+			sqltypes.NewFloat64(est.BinlogBytesPerSecond),
+			sqltypes.NewFloat64(est.CatchUpTime.Seconds()),
+			sqltypes.NewInt64(est.ExtraDiskBytes),
+			sqltypes.NewInt64(boolToInt64(est.Throttled)),
+			sqltypes.NewVarChar(string(warnings)),
+		}},
+	}
+}
+
+func boolToInt64(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// EstimateMigration predicts the copy time, catch-up time and peak extra disk space of the given migration on this
+// shard, without running it. It also reports whether the migration is eligible for INSTANT DDL, and warns about
+// risky changes.
+func (e *Executor) EstimateMigration(ctx context.Context, onlineDDL *schema.OnlineDDL) (*MigrationEstimate, error) {
+	est := &MigrationEstimate{
+		Keyspace: e.keyspace,
+		Shard:    e.shard,
+		Table:    onlineDDL.Table,
+	}
+	ddlAction, err := onlineDDL.GetAction()
+	if err != nil {
+		return nil, err
+	}
+	if ddlAction != sqlparser.AlterDDLAction || onlineDDL.IsView() {
+		// CREATE, DROP and REVERT do not copy any data
+		return est, nil
+	}
+	ddlStmt, _, err := schema.ParseOnlineDDLStatement(onlineDDL.SQL)
+	if err != nil {
+		return nil, err
+	}
+	alterTable, ok := ddlStmt.(*sqlparser.AlterTable)
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "expected ALTER TABLE. Got %v", sqlparser.CanonicalString(ddlStmt))
+	}
+	createTable, err := e.getCreateTableStatement(ctx, onlineDDL.Table)
+	if err != nil {
+		return nil, err
+	}
+	est.Warnings = analyzeRiskyAlter(alterTable, createTable)
+
+	conn, err := dbconnpool.NewDBConnection(ctx, e.env.Config().DB.DbaWithDB())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_, capableOf, _ := mysql.GetFlavor(conn.ServerVersion, nil)
+	instantPlan, err := AnalyzeInstantDDL(alterTable, createTable, capableOf)
+	if err != nil {
+		return nil, err
+	}
+	if instantPlan != nil {
+		est.InstantDDL = true
+		if onlineDDL.StrategySetting().IsFastOverRevertibleFlag() {
+			// The migration runs as INSTANT DDL: no copy and no extra disk
+			return est, nil
+		}
+		est.Warnings = append(est.Warnings, "migration is eligible for INSTANT DDL, use --fast-over-revertible to apply it instantly")
+	}
+
+	dataLength, err := e.readTableSize(ctx, conn, onlineDDL.Table, est)
+	if err != nil {
+		return nil, err
+	}
+	est.CopyRowsPerSecond, err = e.readCopyRowsPerSecond(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if est.BinlogBytesPerSecond, err = readBinlogBytesPerSecond(ctx, conn); err != nil {
+		est.Warnings = append(est.Warnings, fmt.Sprintf("unable to read the binary log write rate: %v", err))
+	}
+	if err := e.lagThrottler.CheckIsReady(); err == nil {
+		checkResult := e.lagThrottler.CheckByType(ctx, throttlerOnlineDDLApp, "", throttleCheckFlags, throttle.ThrottleCheckPrimaryWrite)
+		if checkResult.StatusCode != http.StatusOK {
+			est.Throttled = true
+			est.Warnings = append(est.Warnings, fmt.Sprintf("migration would be throttled right now, which delays the copy: %v", checkResult.Error))
+		}
+	}
+	est.estimate(dataLength)
+	return est, nil
+}
+
+// readTableSize reads the table's row count and size from its status, and returns the table's data length
+func (e *Executor) readTableSize(ctx context.Context, conn *dbconnpool.DBConnection, tableName string, est *MigrationEstimate) (dataLength int64, err error) {
+	parsed := sqlparser.BuildParsedQuery(sqlShowTableStatus, tableName)
+	rs, err := conn.ExecuteFetch(parsed.Query, math.MaxInt64, true)
+	if err != nil {
+		return 0, err
+	}
+	row := rs.Named().Row()
+	if row == nil {
+		return 0, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "Cannot SHOW TABLE STATUS LIKE '%s'", tableName)
+	}
+	est.TableRows = row.AsInt64("Rows", 0)
+	dataLength = row.AsInt64("Data_length", 0)
+	est.TableSize = dataLength + row.AsInt64("Index_length", 0)
+	return dataLength, nil
+}
+
+// readCopyRowsPerSecond learns the row copy rate from recently completed migrations, falling back to
+// defaultCopyRowsPerSecond when there are none
+func (e *Executor) readCopyRowsPerSecond(ctx context.Context) (float64, error) {
+	rs, err := e.execQuery(ctx, sqlSelectCompletedMigrationsCopyRate)
+	if err != nil {
+		return 0, err
+	}
+	row := rs.Named().Row()
+	if row == nil {
+		return defaultCopyRowsPerSecond, nil
+	}
+	rowsCopied := row.AsInt64("rows_copied", 0)
+	seconds := row.AsInt64("elapsed_seconds", 0)
+	if rowsCopied <= 0 || seconds <= 0 {
+		return defaultCopyRowsPerSecond, nil
+	}
+	return float64(rowsCopied) / float64(seconds), nil
+}
+
+// readBinlogBytesPerSecond samples the sizes of the binary logs twice, and returns the rate at which they grow
+func readBinlogBytesPerSecond(ctx context.Context, conn *dbconnpool.DBConnection) (float64, error) {
+	readSizes := func() (map[string]int64, error) {
+		rs, err := conn.ExecuteFetch(sqlShowBinaryLogs, math.MaxInt64, true)
+		if err != nil {
+			return nil, err
+		}
+		sizes := map[string]int64{}
+		for _, row := range rs.Named().Rows {
+			sizes[row.AsString("Log_name", "")] = row.AsInt64("File_size", 0)
+		}
+		return sizes, nil
+	}
+	before, err := readSizes()
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-time.After(binlogSampleInterval):
+	}
+	after, err := readSizes()
+	if err != nil {
+		return 0, err
+	}
+	return binlogGrowthRate(before, after, time.Since(start)), nil
+}
+
+// binlogGrowthRate returns the rate at which binary logs grew between two samples of their sizes. Binary logs
+// purged in between are ignored, and binary logs created in between count in full.
+func binlogGrowthRate(before, after map[string]int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	var written int64
+	for name, size := range after {
+		written += size - before[name]
+	}
+	if written < 0 {
+		return 0
+	}
+	return float64(written) / elapsed.Seconds()
+}
+
+var (
+	// integerTypeSizes are the storage sizes of integer types, in bytes
+	integerTypeSizes = map[string]int{
+		"tinyint":   1,
+		"smallint":  2,
+		"mediumint": 3,
+		"int":       4,
+		"integer":   4,
+		"bigint":    8,
+	}
+	// blobTypeSizes rank the TEXT and BLOB types by their maximum length
+	blobTypeSizes = map[string]int{
+		"tinytext":   1,
+		"tinyblob":   1,
+		"text":       2,
+		"blob":       2,
+		"mediumtext": 3,
+		"mediumblob": 3,
+		"longtext":   4,
+		"longblob":   4,
+	}
+	// stringTypes are the types whose maximum length is given by the column's length
+	stringTypes = map[string]bool{
+		"char":      true,
+		"varchar":   true,
+		"binary":    true,
+		"varbinary": true,
+	}
+	// fractionalTypes are the types whose length is the fractional seconds precision
+	fractionalTypes = map[string]bool{
+		"time":      true,
+		"datetime":  true,
+		"timestamp": true,
+	}
+)
+
+// analyzeRiskyAlter returns warnings about changes in the ALTER TABLE that may fail on, or lose, existing data:
+// new unique keys, columns becoming NOT NULL, and shrinking column types.
+func analyzeRiskyAlter(alterTable *sqlparser.AlterTable, createTable *sqlparser.CreateTable) (warnings []string) {
+	findColumn := func(colName string) *sqlparser.ColumnDefinition {
+		for _, col := range createTable.TableSpec.Columns {
+			if strings.EqualFold(colName, col.Name.String()) {
+				return col
+			}
+		}
+		return nil
+	}
+	for _, alterOption := range alterTable.AlterOptions {
+		switch opt := alterOption.(type) {
+		case *sqlparser.AddIndexDefinition:
+			info := opt.IndexDefinition.Info
+			if info.Primary || info.Unique {
+				name := info.Name.String()
+				if info.Primary {
+					name = "PRIMARY"
+				}
+				warnings = append(warnings, fmt.Sprintf("new unique key %s: rows with duplicate values in existing data are lost", name))
+			}
+		case *sqlparser.ModifyColumn:
+			if col := findColumn(opt.NewColDefinition.Name.String()); col != nil {
+				warnings = append(warnings, analyzeColumnChange(col, opt.NewColDefinition)...)
+			}
+		case *sqlparser.ChangeColumn:
+			if col := findColumn(opt.OldColumn.Name.String()); col != nil {
+				warnings = append(warnings, analyzeColumnChange(col, opt.NewColDefinition)...)
+			}
+		}
+	}
+	return warnings
+}
+
+// analyzeColumnChange returns warnings when the new definition of a column cannot hold all values of the old one
+func analyzeColumnChange(from, to *sqlparser.ColumnDefinition) (warnings []string) {
+	name := from.Name.String()
+	isNullable := func(col *sqlparser.ColumnDefinition) bool {
+		return col.Type.Options == nil || col.Type.Options.Null == nil || *col.Type.Options.Null
+	}
+	if isNullable(from) && !isNullable(to) {
+		warnings = append(warnings, fmt.Sprintf("column %s changes from NULL to NOT NULL: existing NULL values are converted", name))
+	}
+	if reason := shrinkReason(&from.Type, &to.Type); reason != "" {
+		warnings = append(warnings, fmt.Sprintf("column %s %s: existing values may be truncated", name, reason))
+	}
+	return warnings
+}
+
+// shrinkReason describes how the new column type is narrower than the old one, or returns an empty string
+// when it is not
+func shrinkReason(from, to *sqlparser.ColumnType) string {
+	fromType, toType := strings.ToLower(from.Type), strings.ToLower(to.Type)
+	fromLength, toLength := literalInt(from.Length), literalInt(to.Length)
+	shrinks := fmt.Sprintf("shrinks from %s to %s", columnTypeString(from), columnTypeString(to))
+	switch {
+	case integerTypeSizes[fromType] > 0 && integerTypeSizes[toType] > 0:
+		if integerTypeSizes[toType] < integerTypeSizes[fromType] {
+			return shrinks
+		}
+		if integerTypeSizes[toType] == integerTypeSizes[fromType] && from.Unsigned != to.Unsigned {
+			return fmt.Sprintf("changes from %s to %s", columnTypeString(from), columnTypeString(to))
+		}
+	case stringTypes[fromType] && stringTypes[toType]:
+		if toLength < fromLength {
+			return shrinks
+		}
+	case blobTypeSizes[fromType] > 0 && blobTypeSizes[toType] > 0:
+		if blobTypeSizes[toType] < blobTypeSizes[fromType] {
+			return shrinks
+		}
+	case blobTypeSizes[fromType] > 0 && stringTypes[toType]:
+		return shrinks
+	case stringTypes[fromType] && blobTypeSizes[toType] > 0:
+		// TINYTEXT and TINYBLOB hold up to 255 bytes, the other TEXT and BLOB types hold 64KB or more
+		if blobTypeSizes[toType] == 1 && fromLength > 255 {
+			return shrinks
+		}
+	case fromType == "decimal" && toType == "decimal":
+		// The precision of a DECIMAL defaults to 10 and its scale to 0. Both the integer digits
+		// (precision - scale) and the fractional digits (scale) must fit in the new type.
+		fromPrecision, fromScale := literalIntOrDefault(from.Length, 10), literalInt(from.Scale)
+		toPrecision, toScale := literalIntOrDefault(to.Length, 10), literalInt(to.Scale)
+		if toScale < fromScale || toPrecision-toScale < fromPrecision-fromScale {
+			return shrinks
+		}
+		if to.Unsigned && !from.Unsigned {
+			return fmt.Sprintf("changes from %s to %s", columnTypeString(from), columnTypeString(to))
+		}
+	case fractionalTypes[fromType] && fromType == toType:
+		if toLength < fromLength {
+			return shrinks
+		}
+	case (fromType == "enum" || fromType == "set") && fromType == toType:
+		toValues := map[string]bool{}
+		for _, value := range to.EnumValues {
+			toValues[value] = true
+		}
+		for _, value := range from.EnumValues {
+			if !toValues[value] {
+				return fmt.Sprintf("drops %s value %s", fromType, value)
+			}
+		}
+	case fromType != toType:
+		return fmt.Sprintf("changes type from %s to %s", columnTypeString(from), columnTypeString(to))
+	}
+	return ""
+}
+
+func literalInt(l *sqlparser.Literal) int {
+	if l == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(l.Val)
+	return n
+}
+
+// literalIntOrDefault returns the value of an integer literal, or defaultValue if it is not set
+func literalIntOrDefault(l *sqlparser.Literal, defaultValue int) int {
+	if l == nil {
+		return defaultValue
+	}
+	return literalInt(l)
+}
+
+// columnTypeString returns the type, length and signedness of a column type
+func columnTypeString(t *sqlparser.ColumnType) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(t.Type))
+	if t.Length != nil {
+		b.WriteString("(")
+		b.WriteString(t.Length.Val)
+		if t.Scale != nil {
+			b.WriteString(",")
+			b.WriteString(t.Scale.Val)
+		}
+		b.WriteString(")")
+	}
+	if t.Unsigned {
+		b.WriteString(" unsigned")
+	}
+	return b.String()
+}
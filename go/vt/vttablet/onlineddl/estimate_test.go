@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onlineddl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+func TestMigrationEstimate(t *testing.T) {
+	est := &MigrationEstimate{
+		TableRows:            1000000,
+		TableSize:            300 * 1024 * 1024,
+		CopyRowsPerSecond:    10000,
+		BinlogBytesPerSecond: binlogApplyBytesPerSecond / 4,
+	}
+	est.estimate(200 * 1024 * 1024)
+	assert.Equal(t, 100*time.Second, est.CopyTime)
+	// 100 seconds of writes at a quarter of the apply rate take 100/3 seconds to apply
+	assert.InDelta(t, 33.3, est.CatchUpTime.Seconds(), 0.1)
+	// shadow table, binary logs of the copied rows, and 133.3 seconds of writes
+	assert.InDelta(t, float64(500*1024*1024)+133.3*binlogApplyBytesPerSecond/4, float64(est.ExtraDiskBytes), binlogApplyBytesPerSecond/4*0.1)
+	assert.Empty(t, est.Warnings)
+
+	est = &MigrationEstimate{
+		TableRows:            1000000,
+		CopyRowsPerSecond:    10000,
+		BinlogBytesPerSecond: binlogApplyBytesPerSecond * 2,
+	}
+	est.estimate(0)
+	assert.Zero(t, est.CatchUpTime)
+	require.Len(t, est.Warnings, 1)
+	assert.Contains(t, est.Warnings[0], "may never catch up")
+}
+
+func TestMigrationEstimateToResult(t *testing.T) {
+	est := &MigrationEstimate{
+		Keyspace:   "ks",
+		Shard:      "-80",
+		Table:      "t",
+		InstantDDL: true,
+		CopyTime:   1500 * time.Millisecond,
+	}
+	row := est.ToResult().Named().Row()
+	require.NotNil(t, row)
+	assert.Equal(t, "-80", row.AsString("shard", ""))
+	assert.Equal(t, int64(1), row.AsInt64("instant_ddl", 0))
+	assert.Equal(t, 1.5, row.AsFloat64("copy_seconds", 0))
+	assert.Equal(t, "[]", row.AsString("warnings", ""))
+}
+
+func TestBinlogGrowthRate(t *testing.T) {
+	before := map[string]int64{"binlog.000001": 1000, "binlog.000002": 500}
+	after := map[string]int64{"binlog.000002": 1500, "binlog.000003": 1000}
+	// binlog.000001 was purged: 1000 bytes were written to binlog.000002 and 1000 to binlog.000003
+	assert.Equal(t, 1000.0, binlogGrowthRate(before, after, 2*time.Second))
+	assert.Equal(t, 0.0, binlogGrowthRate(before, before, 0))
+}
+
+func TestAnalyzeRiskyAlter(t *testing.T) {
+	tt := []struct {
+		alter    string
+		warnings []string
+	}{
+		{
+			alter: "alter table t add column i2 int not null, add key i1_idx(i1)",
+		},
+		{
+			alter:    "alter table t add unique key name_uidx(name)",
+			warnings: []string{"new unique key name_uidx: rows with duplicate values in existing data are lost"},
+		},
+		{
+			alter:    "alter table t modify column i1 int not null",
+			warnings: []string{"column i1 changes from NULL to NOT NULL: existing NULL values are converted"},
+		},
+		{
+			alter: "alter table t modify column i1 bigint, modify column name varchar(255), modify column ts timestamp(6)",
+		},
+		{
+			alter: "alter table t modify column i1 smallint, change column name title varchar(16) not null",
+			warnings: []string{
+				"column i1 shrinks from int to smallint: existing values may be truncated",
+				"column name shrinks from varchar(64) to varchar(16): existing values may be truncated",
+			},
+		},
+		{
+			alter:    "alter table t modify column price decimal(8,1)",
+			warnings: []string{"column price shrinks from decimal(10,2) to decimal(8,1): existing values may be truncated"},
+		},
+		{
+			alter:    "alter table t modify column price decimal(10,4)",
+			warnings: []string{"column price shrinks from decimal(10,2) to decimal(10,4): existing values may be truncated"},
+		},
+		{
+			alter: "alter table t modify column price decimal(12,4)",
+		},
+		{
+			alter:    "alter table t modify column price decimal",
+			warnings: []string{"column price shrinks from decimal(10,2) to decimal: existing values may be truncated"},
+		},
+		{
+			alter:    "alter table t modify column price decimal(10,2) unsigned",
+			warnings: []string{"column price changes from decimal(10,2) to decimal(10,2) unsigned: existing values may be truncated"},
+		},
+		{
+			alter:    "alter table t modify column i1 int unsigned",
+			warnings: []string{"column i1 changes from int to int unsigned: existing values may be truncated"},
+		},
+		{
+			alter:    "alter table t modify column body varchar(1024) not null",
+			warnings: []string{"column body shrinks from text to varchar(1024): existing values may be truncated"},
+		},
+		{
+			alter:    "alter table t modify column color enum('red','blue') not null",
+			warnings: []string{"column color drops enum value 'green': existing values may be truncated"},
+		},
+		{
+			alter:    "alter table t modify column name int",
+			warnings: []string{"column name changes type from varchar(64) to int: existing values may be truncated"},
+		},
+	}
+	create := "create table t(id int not null, i1 int, name varchar(64) not null, price decimal(10,2) not null, body text not null, color enum('red','green','blue') not null, ts timestamp(3) null, primary key(id))"
+	stmt, err := sqlparser.ParseStrictDDL(create)
+	require.NoError(t, err)
+	createTable, ok := stmt.(*sqlparser.CreateTable)
+	require.True(t, ok)
+	for _, tc := range tt {
+		t.Run(tc.alter, func(t *testing.T) {
+			stmt, err := sqlparser.ParseStrictDDL(tc.alter)
+			require.NoError(t, err)
+			alterTable, ok := stmt.(*sqlparser.AlterTable)
+			require.True(t, ok)
+			assert.Equal(t, tc.warnings, analyzeRiskyAlter(alterTable, createTable))
+		})
+	}
+}
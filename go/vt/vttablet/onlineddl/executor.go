@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"os"
 	"path"
 	"strconv"
@@ -150,6 +151,7 @@ var (
 	onlineDDLUser            = "vt-online-ddl-internal"
 	onlineDDLGrant           = fmt.Sprintf("'%s'@'%s'", onlineDDLUser, "%")
 	throttlerOnlineDDLApp    = "online-ddl"
+	throttlerCutOverApp      = "online-ddl:cut-over"
 	throttleCheckFlags       = &throttle.CheckFlags{}
 )
 
@@ -1456,7 +1458,7 @@ exit $exit_code
 		if onlineDDL.StrategySetting().IsAllowZeroInDateFlag() {
 			args = append(args, "--allow-zero-in-date")
 		}
-		if execute && onlineDDL.StrategySetting().IsPostponeCompletion() {
+		if execute && (onlineDDL.StrategySetting().IsPostponeCompletion() || onlineDDL.StrategySetting().HasCutOverPolicy()) {
 			args = append(args, "--postpone-cut-over-flag-file", e.ghostPostponeFlagFileName(onlineDDL.UUID))
 		}
 
@@ -2426,6 +2428,7 @@ func (e *Executor) failMigration(ctx context.Context, onlineDDL *schema.OnlineDD
 		_ = e.updateMigrationMessage(ctx, onlineDDL.UUID, withError.Error())
 	}
 	e.ownedRunningMigrations.Delete(onlineDDL.UUID)
+	e.releaseCutOverPolicySlot(ctx, onlineDDL)
 	return withError
 }
 
@@ -3202,6 +3205,69 @@ func (e *Executor) isVReplMigrationReadyToCutOver(ctx context.Context, s *VReplS
 	return true, nil
 }
 
+// cutOverWaitReason evaluates the cut-over policy of a migration that is ready to complete, as given by
+// the --cut-over-window, --cut-over-max-lag and --cut-over-max-concurrent strategy options. It returns
+// the reason the migration has to wait, or an empty string if it may cut over now. With
+// --cut-over-max-concurrent, a migration that may cut over holds a cut-over slot of the keyspace, which
+// must be released with releaseCutOver once the migration completes or fails.
+func (e *Executor) cutOverWaitReason(ctx context.Context, onlineDDL *schema.OnlineDDL) (reason string, err error) {
+	setting := onlineDDL.StrategySetting()
+	window, err := setting.CutOverWindow()
+	if err != nil {
+		return "", err
+	}
+	if window != nil && !window.Contains(time.Now()) {
+		return fmt.Sprintf("outside of cut-over window %q", window.String()), nil
+	}
+	maxConcurrent, err := setting.CutOverMaxConcurrent()
+	if err != nil {
+		return "", err
+	}
+	maxLag, err := setting.CutOverMaxLag()
+	if err != nil {
+		return "", err
+	}
+	if maxLag > 0 {
+		// Replication lag is measured by the throttler, which must be enabled.
+		if err := e.lagThrottler.CheckIsReady(); err != nil {
+			return fmt.Sprintf("unable to check replication lag: %v", err), nil
+		}
+		checkResult := e.lagThrottler.CheckByType(ctx, throttlerCutOverApp, "", throttleCheckFlags, throttle.ThrottleCheckPrimaryWrite)
+		switch checkResult.StatusCode {
+		case http.StatusNotFound, http.StatusExpectationFailed, http.StatusInternalServerError:
+			return fmt.Sprintf("unable to check replication lag: %v", checkResult.Error), nil
+		}
+		lag := time.Duration(checkResult.Value * float64(time.Second))
+		if lag > maxLag {
+			return fmt.Sprintf("replication lag %v exceeds the maximum of %v", lag.Round(time.Millisecond), maxLag), nil
+		}
+	}
+	if maxConcurrent > 0 {
+		// The limit applies to the whole keyspace, while each shard's primary runs its own migrations.
+		return e.claimCutOver(ctx, onlineDDL.UUID, maxConcurrent)
+	}
+	return "", nil
+}
+
+// keepCutOverPolicySlot keeps the cut-over slot of a migration with --cut-over-max-concurrent from
+// expiring while it cuts over, until the returned function is called.
+func (e *Executor) keepCutOverPolicySlot(ctx context.Context, onlineDDL *schema.OnlineDDL) (stop func()) {
+	if maxConcurrent, _ := onlineDDL.StrategySetting().CutOverMaxConcurrent(); maxConcurrent == 0 {
+		return func() {}
+	}
+	return e.keepCutOverClaim(ctx, onlineDDL.UUID)
+}
+
+// releaseCutOverPolicySlot releases the cut-over slot of a migration with --cut-over-max-concurrent.
+func (e *Executor) releaseCutOverPolicySlot(ctx context.Context, onlineDDL *schema.OnlineDDL) {
+	if maxConcurrent, _ := onlineDDL.StrategySetting().CutOverMaxConcurrent(); maxConcurrent == 0 {
+		return
+	}
+	if err := e.releaseCutOver(ctx, onlineDDL.UUID); err != nil {
+		log.Errorf("failed to release the cut-over slot of migration %s: %v", onlineDDL.UUID, err)
+	}
+}
+
 // isVReplMigrationRunning sees if there is a VReplication migration actively running
 func (e *Executor) isVReplMigrationRunning(ctx context.Context, uuid string) (isRunning bool, s *VReplStream, err error) {
 	s, err = e.readVReplStream(ctx, uuid, true)
@@ -3333,8 +3399,23 @@ func (e *Executor) reviewRunningMigrations(ctx context.Context) (countRunnning i
 						// override. Even if migration is ready, we do not complete it.
 						isReady = false
 					}
+					if isReady && onlineDDL.StrategySetting().HasCutOverPolicy() {
+						// The migration completes by itself, but only when its cut-over policy allows it.
+						reason, err := e.cutOverWaitReason(ctx, onlineDDL)
+						if err != nil {
+							return countRunnning, cancellable, err
+						}
+						_ = e.updateMigrationCutOverWaitReason(ctx, uuid, reason)
+						if reason != "" {
+							isReady = false
+						}
+					}
 					if isReady {
-						if err := e.cutOverVReplMigration(ctx, s); err != nil {
+						stopKeepingSlot := e.keepCutOverPolicySlot(ctx, onlineDDL)
+						err := e.cutOverVReplMigration(ctx, s)
+						stopKeepingSlot()
+						e.releaseCutOverPolicySlot(ctx, onlineDDL)
+						if err != nil {
 							_ = e.updateMigrationMessage(ctx, uuid, err.Error())
 							if merr, ok := err.(*mysql.SQLError); ok {
 								switch merr.Num {
@@ -3395,6 +3476,21 @@ func (e *Executor) reviewRunningMigrations(ctx context.Context) (countRunnning i
 					// is missing. So we may as well cancel it.
 					message := fmt.Sprintf("cancelling a gh-ost running migration %s which is not owned (not started, or is assumed to be terminated) by this executor", uuid)
 					cancellable = append(cancellable, newCancellableMigration(uuid, message))
+				} else if onlineDDL.StrategySetting().HasCutOverPolicy() && !postponeCompletion && migrationRow.AsBool("ready_to_complete", false) {
+					// gh-ost postpones the cut-over for as long as the postpone flag file exists. We remove the
+					// file once the cut-over policy allows it.
+					// The cut-over slot, if any, is released once gh-ost reports the migration as complete
+					// or failed.
+					reason, err := e.cutOverWaitReason(ctx, onlineDDL)
+					if err != nil {
+						return countRunnning, cancellable, err
+					}
+					_ = e.updateMigrationCutOverWaitReason(ctx, uuid, reason)
+					if reason == "" {
+						if err := e.deleteGhostPostponeFlagFile(uuid); err != nil {
+							return countRunnning, cancellable, err
+						}
+					}
 				}
 			}
 		}
@@ -4031,6 +4127,18 @@ func (e *Executor) updateMigrationReadyToComplete(ctx context.Context, uuid stri
 	return nil
 }
 
+func (e *Executor) updateMigrationCutOverWaitReason(ctx context.Context, uuid string, reason string) error {
+	query, err := sqlparser.ParseAndBind(sqlUpdateMigrationCutOverWaitReason,
+		sqltypes.StringBindVariable(reason),
+		sqltypes.StringBindVariable(uuid),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = e.execQuery(ctx, query)
+	return err
+}
+
 func (e *Executor) updateMigrationStowawayTable(ctx context.Context, uuid string, tableName string) error {
 	query, err := sqlparser.ParseAndBind(sqlUpdateMigrationStowawayTable,
 		sqltypes.StringBindVariable(tableName),
@@ -4141,10 +4249,18 @@ func (e *Executor) CompleteMigration(ctx context.Context, uuid string) (result *
 		return nil, err
 	}
 	defer e.triggerNextCheckInterval()
-	if err := e.deleteGhostPostponeFlagFile(uuid); err != nil {
-		// This should work without error even if the migration is not a gh-ost migration, and even
-		// if the file does not exist. An error here indicates a general system error of sorts.
-		return nil, err
+	deletePostponeFlagFile := true
+	if onlineDDL, _, err := e.readMigration(ctx, uuid); err == nil && onlineDDL.StrategySetting().HasCutOverPolicy() {
+		// A gh-ost migration with a cut-over policy keeps its postpone flag file. The file is removed
+		// by reviewRunningMigrations() once the policy allows the cut-over.
+		deletePostponeFlagFile = false
+	}
+	if deletePostponeFlagFile {
+		if err := e.deleteGhostPostponeFlagFile(uuid); err != nil {
+			// This should work without error even if the migration is not a gh-ost migration, and even
+			// if the file does not exist. An error here indicates a general system error of sorts.
+			return nil, err
+		}
 	}
 	rs, err := e.execQuery(ctx, query)
 	if err != nil {
@@ -4323,6 +4439,15 @@ func (e *Executor) SubmitMigration(
 		return nil, err
 	}
 
+	if onlineDDL.StrategySetting().IsDryRun() {
+		// A dry run only estimates the migration. Nothing is submitted.
+		estimate, err := e.EstimateMigration(ctx, onlineDDL)
+		if err != nil {
+			return nil, err
+		}
+		return estimate.ToResult(), nil
+	}
+
 	if onlineDDL.StrategySetting().IsSingleton() || onlineDDL.StrategySetting().IsSingletonContext() {
 		// we need two wrap some logic within a mutex, so as to make sure we can't submit the migration whilst
 		// another query submits a conflicting migration
@@ -4476,6 +4601,9 @@ func (e *Executor) onSchemaMigrationStatus(ctx context.Context,
 	if !dryRun {
 		switch status {
 		case schema.OnlineDDLStatusComplete, schema.OnlineDDLStatusFailed:
+			if onlineDDL, _, err := e.readMigration(ctx, uuid); err == nil {
+				e.releaseCutOverPolicySlot(ctx, onlineDDL)
+			}
 			e.triggerNextCheckInterval()
 		}
 	}
@@ -81,6 +81,7 @@ const (
 	alterSchemaMigrationsComponentThrottled            = "ALTER TABLE _vt.schema_migrations add column component_throttled tinytext NOT NULL"
 	alterSchemaMigrationsCancelledTimestamp            = "ALTER TABLE _vt.schema_migrations add column cancelled_timestamp timestamp NULL DEFAULT NULL"
 	alterSchemaMigrationsTablePostponeLaunch           = "ALTER TABLE _vt.schema_migrations add column postpone_launch tinyint unsigned NOT NULL DEFAULT 0"
+	alterSchemaMigrationsTableCutOverWaitReason        = "ALTER TABLE _vt.schema_migrations add column cut_over_wait_reason varchar(1024) NOT NULL DEFAULT ''"
 
 	sqlInsertMigration = `INSERT IGNORE INTO _vt.schema_migrations (
 		migration_uuid,
@@ -156,6 +157,11 @@ const (
 		WHERE
 			migration_uuid=%a
 	`
+	sqlUpdateMigrationCutOverWaitReason = `UPDATE _vt.schema_migrations
+			SET cut_over_wait_reason=%a
+		WHERE
+			migration_uuid=%a
+	`
 	sqlUpdateMigrationStowawayTable = `UPDATE _vt.schema_migrations
 			SET stowaway_table=%a
 		WHERE
@@ -325,6 +331,8 @@ const (
 		FROM _vt.schema_migrations
 		WHERE
 			migration_status='running'
+		ORDER BY
+			id
 	`
 	sqlSelectCompleteMigrationsOnTable = `SELECT
 			migration_uuid,
@@ -442,6 +450,16 @@ const (
 			migration_status='ready'
 		ORDER BY id
 	`
+	sqlSelectCompletedMigrationsCopyRate = `SELECT
+			IFNULL(SUM(rows_copied), 0) AS rows_copied,
+			IFNULL(SUM(TIMESTAMPDIFF(SECOND, started_timestamp, completed_timestamp)), 0) AS elapsed_seconds
+		FROM _vt.schema_migrations
+		WHERE
+			migration_status='complete'
+			AND strategy IN ('online', 'vitess')
+			AND rows_copied > 0
+			AND completed_timestamp > NOW() - INTERVAL 30 DAY
+	`
 	sqlSelectPTOSCMigrationTriggers = `SELECT
 			TRIGGER_SCHEMA as trigger_schema,
 			TRIGGER_NAME as trigger_name
@@ -541,6 +559,7 @@ const (
 	sqlDropTable        = "DROP TABLE `%a`"
 	sqlShowColumnsFrom  = "SHOW COLUMNS FROM `%a`"
 	sqlShowTableStatus  = "SHOW TABLE STATUS LIKE '%a'"
+	sqlShowBinaryLogs   = "SHOW BINARY LOGS"
 	sqlShowCreateTable  = "SHOW CREATE TABLE `%a`"
 	sqlGetAutoIncrement = `
 		SELECT
@@ -648,4 +667,5 @@ var ApplyDDL = []string{
 	alterSchemaMigrationsComponentThrottled,
 	alterSchemaMigrationsCancelledTimestamp,
 	alterSchemaMigrationsTablePostponeLaunch,
+	alterSchemaMigrationsTableCutOverWaitReason,
 }
@@ -385,7 +385,7 @@ func (sbc *SandboxConn) BeginStreamExecute(ctx context.Context, target *querypb.
 }
 
 // MessageStream is part of the QueryService interface.
-func (sbc *SandboxConn) MessageStream(ctx context.Context, target *querypb.Target, name string, callback func(*sqltypes.Result) error) (err error) {
+func (sbc *SandboxConn) MessageStream(ctx context.Context, target *querypb.Target, name, group string, callback func(*sqltypes.Result) error) (err error) {
 	if err := sbc.getError(); err != nil {
 		return err
 	}
@@ -398,7 +398,7 @@ func (sbc *SandboxConn) MessageStream(ctx context.Context, target *querypb.Targe
 }
 
 // MessageAck is part of the QueryService interface.
-func (sbc *SandboxConn) MessageAck(ctx context.Context, target *querypb.Target, name string, ids []*querypb.Value) (count int64, err error) {
+func (sbc *SandboxConn) MessageAck(ctx context.Context, target *querypb.Target, name, group string, ids []*querypb.Value) (count int64, err error) {
 	sbc.MessageIDs = ids
 	return int64(len(ids)), nil
 }
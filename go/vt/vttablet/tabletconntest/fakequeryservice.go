@@ -573,6 +573,9 @@ var (
 	// MessageName is a test message name.
 	MessageName = "vitess_message"
 
+	// MessageGroup is a test consumer group.
+	MessageGroup = "billing"
+
 	// MessageStreamResult is a test stream result.
 	MessageStreamResult = &sqltypes.Result{
 		Fields: []*querypb.Field{{
@@ -599,7 +602,7 @@ var (
 )
 
 // MessageStream is part of the queryservice.QueryService interface
-func (f *FakeQueryService) MessageStream(ctx context.Context, target *querypb.Target, name string, callback func(*sqltypes.Result) error) (err error) {
+func (f *FakeQueryService) MessageStream(ctx context.Context, target *querypb.Target, name, group string, callback func(*sqltypes.Result) error) (err error) {
 	if f.HasError {
 		return f.TabletError
 	}
@@ -609,6 +612,9 @@ func (f *FakeQueryService) MessageStream(ctx context.Context, target *querypb.Ta
 	if name != MessageName {
 		f.t.Errorf("name: %s, want %s", name, MessageName)
 	}
+	if group != MessageGroup {
+		f.t.Errorf("group: %s, want %s", group, MessageGroup)
+	}
 	if err := callback(MessageStreamResult); err != nil {
 		f.t.Logf("MessageStream callback failed: %v", err)
 	}
@@ -616,7 +622,7 @@ func (f *FakeQueryService) MessageStream(ctx context.Context, target *querypb.Ta
 }
 
 // MessageAck is part of the queryservice.QueryService interface
-func (f *FakeQueryService) MessageAck(ctx context.Context, target *querypb.Target, name string, ids []*querypb.Value) (count int64, err error) {
+func (f *FakeQueryService) MessageAck(ctx context.Context, target *querypb.Target, name, group string, ids []*querypb.Value) (count int64, err error) {
 	if f.HasError {
 		return 0, f.TabletError
 	}
@@ -626,6 +632,9 @@ func (f *FakeQueryService) MessageAck(ctx context.Context, target *querypb.Targe
 	if name != MessageName {
 		f.t.Errorf("name: %s, want %s", name, MessageName)
 	}
+	if group != MessageGroup {
+		f.t.Errorf("group: %s, want %s", group, MessageGroup)
+	}
 	if !sqltypes.Proto3ValuesEqual(ids, MessageIDs) {
 		f.t.Errorf("ids: %v, want %v", ids, MessageIDs)
 	}
@@ -54,6 +54,8 @@ func (tm *TabletManager) Backup(ctx context.Context, logger logutil.Logger, req
 	if err != nil {
 		return vterrors.Wrap(err, "failed to find backup engine")
 	}
+	// Incremental backups copy binary logs while mysqld is running.
+	shouldDrain := engine.ShouldDrainForBackup() && req.IncrementalFromPos == ""
 	// get Tablet info from topo so that it is up to date
 	tablet, err := tm.TopoServer.GetTablet(ctx, tm.tabletAlias)
 	if err != nil {
@@ -65,7 +67,7 @@ func (tm *TabletManager) Backup(ctx context.Context, logger logutil.Logger, req
 
 	// prevent concurrent backups, and record stats
 	backupMode := backupModeOnline
-	if engine.ShouldDrainForBackup() {
+	if shouldDrain {
 		backupMode = backupModeOffline
 	}
 	if err := tm.beginBackup(backupMode); err != nil {
@@ -74,7 +76,7 @@ func (tm *TabletManager) Backup(ctx context.Context, logger logutil.Logger, req
 	defer tm.endBackup(backupMode)
 
 	var originalType topodatapb.TabletType
-	if engine.ShouldDrainForBackup() {
+	if shouldDrain {
 		if err := tm.lock(ctx); err != nil {
 			return err
 		}
@@ -115,11 +117,13 @@ func (tm *TabletManager) Backup(ctx context.Context, logger logutil.Logger, req
 		Shard:        tablet.Shard,
 		TabletAlias:  topoproto.TabletAliasString(tablet.Alias),
 		BackupTime:   time.Now(),
+
+		IncrementalFromPos: req.IncrementalFromPos,
 	}
 
 	returnErr := mysqlctl.Backup(ctx, backupParams)
 
-	if engine.ShouldDrainForBackup() {
+	if shouldDrain {
 		bgCtx := context.Background()
 		// Starting from here we won't be able to recover if we get stopped by a cancelled
 		// context. It is also possible that the context already timed out during the
@@ -152,8 +156,8 @@ func (tm *TabletManager) Backup(ctx context.Context, logger logutil.Logger, req
 }
 
 // RestoreFromBackup deletes all local data and then restores the data from the latest backup [at
-// or before the backupTime value if specified]
-func (tm *TabletManager) RestoreFromBackup(ctx context.Context, logger logutil.Logger, backupTime time.Time) error {
+// or before the backupTime value if specified], or to the point in time requested
+func (tm *TabletManager) RestoreFromBackup(ctx context.Context, logger logutil.Logger, request *tabletmanagerdatapb.RestoreFromBackupRequest) error {
 	if err := tm.lock(ctx); err != nil {
 		return err
 	}
@@ -171,7 +175,7 @@ func (tm *TabletManager) RestoreFromBackup(ctx context.Context, logger logutil.L
 	l := logutil.NewTeeLogger(logutil.NewConsoleLogger(), logger)
 
 	// now we can run restore
-	err = tm.restoreDataLocked(ctx, l, 0 /* waitForBackupInterval */, true /* deleteBeforeRestore */, backupTime)
+	err = tm.restoreDataLocked(ctx, l, 0 /* waitForBackupInterval */, true /* deleteBeforeRestore */, request)
 
 	// re-run health check to be sure to capture any replication delay
 	tm.QueryServiceControl.BroadcastHealth()
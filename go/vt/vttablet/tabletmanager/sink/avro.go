@@ -0,0 +1,376 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// avroEncoder encodes changes as Debezium Avro change events. If a schema
+// registry is configured, the schemas are registered in it, and records
+// use its wire format: a zero byte and the 4 byte schema id. Otherwise,
+// records use the Avro single object encoding, which starts with the
+// fingerprint of the schema.
+type avroEncoder struct {
+	topicPrefix string
+	registry    *schemaRegistry
+	tables      tableCache
+	schemas     map[string]*avroTableSchemas
+}
+
+// avroTableSchemas are the key and value schemas of a table.
+type avroTableSchemas struct {
+	table *tableInfo
+	key   *avroSchema
+	value *avroSchema
+}
+
+// avroSchema is a schema and how it's referenced by records.
+type avroSchema struct {
+	schema []byte
+	// header is written before the encoded records.
+	header []byte
+}
+
+func newAvroEncoder(topicPrefix, registryURL string) *avroEncoder {
+	e := &avroEncoder{
+		topicPrefix: topicPrefix,
+		schemas:     make(map[string]*avroTableSchemas),
+	}
+	if registryURL != "" {
+		e.registry = &schemaRegistry{
+			url:    strings.TrimSuffix(registryURL, "/"),
+			client: &http.Client{Timeout: 30 * time.Second},
+		}
+	}
+	return e
+}
+
+func (e *avroEncoder) Encode(change *Change) (*Record, error) {
+	table := e.tables.get(change)
+	topic := topicName(e.topicPrefix, change)
+	schemas := e.schemas[change.Table]
+	if schemas == nil || schemas.table != table {
+		var err error
+		if schemas, err = e.buildSchemas(topic, table); err != nil {
+			return nil, err
+		}
+		e.schemas[change.Table] = schemas
+	}
+
+	record := &Record{Topic: topic}
+	if schemas.key != nil {
+		row := change.After
+		if row == nil {
+			row = change.Before
+		}
+		key := bytes.NewBuffer(append([]byte(nil), schemas.key.header...))
+		for _, idx := range table.keys {
+			if err := writeAvroValue(key, table.columns[idx], row[idx]); err != nil {
+				return nil, err
+			}
+		}
+		record.Key = key.Bytes()
+	}
+
+	value := bytes.NewBuffer(append([]byte(nil), schemas.value.header...))
+	for _, row := range [][]sqltypes.Value{change.Before, change.After} {
+		if row == nil {
+			writeAvroLong(value, 0)
+			continue
+		}
+		writeAvroLong(value, 1)
+		for i, col := range table.columns {
+			if err := writeAvroValue(value, col, row[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	src := newSource(e.topicPrefix, change)
+	for _, s := range []string{src.Connector, src.Name} {
+		writeAvroString(value, s)
+	}
+	writeAvroLong(value, src.TsMs)
+	for _, s := range []string{src.Snapshot, src.Db, src.Table, src.Shard, src.Vgtid, change.op()} {
+		writeAvroString(value, s)
+	}
+	writeAvroLong(value, 1)
+	writeAvroLong(value, time.Now().UnixMilli())
+	record.Value = value.Bytes()
+	return record, nil
+}
+
+func (e *avroEncoder) buildSchemas(topic string, table *tableInfo) (*avroTableSchemas, error) {
+	ns := avroNamespace(topic)
+	schemas := &avroTableSchemas{table: table}
+	var keyFields []avroField
+	for _, idx := range table.keys {
+		keyFields = append(keyFields, newAvroField(table.columns[idx]))
+	}
+	valueFields := make([]avroField, 0, len(table.columns))
+	for _, col := range table.columns {
+		valueFields = append(valueFields, newAvroField(col))
+	}
+	null := json.RawMessage("null")
+	value := &avroRecordSchema{
+		Name: ns + ".Envelope",
+		Type: "record",
+		Fields: []avroField{
+			{Name: "before", Type: []interface{}{"null", &avroRecordSchema{Name: ns + ".Value", Type: "record", Fields: valueFields}}, Default: null},
+			{Name: "after", Type: []interface{}{"null", ns + ".Value"}, Default: null},
+			{Name: "source", Type: &avroRecordSchema{
+				Name: "io.debezium.connector.vitess.Source",
+				Type: "record",
+				Fields: []avroField{
+					{Name: "connector", Type: "string"},
+					{Name: "name", Type: "string"},
+					{Name: "ts_ms", Type: "long"},
+					{Name: "snapshot", Type: "string"},
+					{Name: "db", Type: "string"},
+					{Name: "table", Type: "string"},
+					{Name: "shard", Type: "string"},
+					{Name: "vgtid", Type: "string"},
+				},
+			}},
+			{Name: "op", Type: "string"},
+			{Name: "ts_ms", Type: []interface{}{"null", "long"}, Default: null},
+		},
+	}
+	var err error
+	if schemas.value, err = e.newSchema(topic+"-value", value); err != nil {
+		return nil, err
+	}
+	if len(keyFields) > 0 {
+		key := &avroRecordSchema{Name: ns + ".Key", Type: "record", Fields: keyFields}
+		if schemas.key, err = e.newSchema(topic+"-key", key); err != nil {
+			return nil, err
+		}
+	}
+	return schemas, nil
+}
+
+func (e *avroEncoder) newSchema(subject string, record *avroRecordSchema) (*avroSchema, error) {
+	schema, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if e.registry != nil {
+		id, err := e.registry.register(subject, schema)
+		if err != nil {
+			return nil, err
+		}
+		header := make([]byte, 5)
+		binary.BigEndian.PutUint32(header[1:], id)
+		return &avroSchema{schema: schema, header: header}, nil
+	}
+	// The fingerprint is computed on the parsing canonical form, which
+	// is the schema without defaults.
+	record.stripDefaults()
+	canonical, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	header := []byte{0xc3, 0x01, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint64(header[2:], avroFingerprint(canonical))
+	return &avroSchema{schema: schema, header: header}, nil
+}
+
+// avroRecordSchema is an Avro record schema. Names are always full names,
+// and the fields are ordered as in the parsing canonical form.
+type avroRecordSchema struct {
+	Name   string      `json:"name"`
+	Type   string      `json:"type"`
+	Fields []avroField `json:"fields"`
+}
+
+type avroField struct {
+	Name    string          `json:"name"`
+	Type    interface{}     `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+func newAvroField(col *column) avroField {
+	var typ string
+	switch col.kind {
+	case kindInt:
+		typ = "int"
+	case kindLong:
+		typ = "long"
+	case kindFloat:
+		typ = "float"
+	case kindDouble:
+		typ = "double"
+	case kindBytes:
+		typ = "bytes"
+	default:
+		typ = "string"
+	}
+	return avroField{Name: avroName(col.name), Type: []interface{}{"null", typ}, Default: json.RawMessage("null")}
+}
+
+func (r *avroRecordSchema) stripDefaults() {
+	for i := range r.Fields {
+		r.Fields[i].Default = nil
+		switch typ := r.Fields[i].Type.(type) {
+		case *avroRecordSchema:
+			typ.stripDefaults()
+		case []interface{}:
+			for _, t := range typ {
+				if record, ok := t.(*avroRecordSchema); ok {
+					record.stripDefaults()
+				}
+			}
+		}
+	}
+}
+
+// avroName turns s into a valid Avro name.
+func avroName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || b[0] >= '0' && b[0] <= '9' {
+		return "_" + string(b)
+	}
+	return string(b)
+}
+
+func avroNamespace(topic string) string {
+	parts := strings.Split(topic, ".")
+	for i, part := range parts {
+		parts[i] = avroName(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+func writeAvroValue(buf *bytes.Buffer, col *column, val sqltypes.Value) error {
+	if val.IsNull() {
+		writeAvroLong(buf, 0)
+		return nil
+	}
+	writeAvroLong(buf, 1)
+	switch col.kind {
+	case kindInt, kindLong:
+		var n int64
+		var err error
+		if val.IsUnsigned() {
+			var u uint64
+			u, err = val.ToUint64()
+			n = int64(u)
+		} else {
+			n, err = val.ToInt64()
+		}
+		if err != nil {
+			return fmt.Errorf("column %s: %v", col.name, err)
+		}
+		writeAvroLong(buf, n)
+	case kindFloat, kindDouble:
+		f, err := strconv.ParseFloat(val.ToString(), 64)
+		if err != nil {
+			return fmt.Errorf("column %s: %v", col.name, err)
+		}
+		if col.kind == kindFloat {
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(f)))
+			buf.Write(b[:])
+		} else {
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+			buf.Write(b[:])
+		}
+	case kindBytes:
+		writeAvroLong(buf, int64(len(val.Raw())))
+		buf.Write(val.Raw())
+	default:
+		writeAvroString(buf, col.text(val))
+	}
+	return nil
+}
+
+func writeAvroLong(buf *bytes.Buffer, n int64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutVarint(b[:], n)])
+}
+
+func writeAvroString(buf *bytes.Buffer, s string) {
+	writeAvroLong(buf, int64(len(s)))
+	buf.WriteString(s)
+}
+
+const avroEmptyFingerprint = 0xc15d213aa4d7a795
+
+var avroFingerprintTable = func() (table [256]uint64) {
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (avroEmptyFingerprint & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return table
+}()
+
+// avroFingerprint returns the CRC-64-AVRO fingerprint of a schema.
+func avroFingerprint(schema []byte) uint64 {
+	fp := uint64(avroEmptyFingerprint)
+	for _, b := range schema {
+		fp = (fp >> 8) ^ avroFingerprintTable[byte(fp)^b]
+	}
+	return fp
+}
+
+// schemaRegistry registers schemas in a Confluent compatible schema
+// registry.
+type schemaRegistry struct {
+	url    string
+	client *http.Client
+}
+
+func (sr *schemaRegistry) register(subject string, schema []byte) (uint32, error) {
+	body, err := json.Marshal(map[string]string{"schema": string(schema)})
+	if err != nil {
+		return 0, err
+	}
+	resp, err := sr.client.Post(sr.url+"/subjects/"+subject+"/versions", "application/vnd.schemaregistry.v1+json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("registering schema of %s: %s", subject, resp.Status)
+	}
+	var result struct {
+		ID uint32 `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("registering schema of %s: %v", subject, err)
+	}
+	return result.ID, nil
+}
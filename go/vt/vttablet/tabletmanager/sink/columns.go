@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/schema"
+)
+
+// columnKind is the representation of a column in change events. It
+// follows the defaults of the Debezium MySQL connector: decimals and
+// temporal values are strings, and binary values are bytes, which
+// are base64 encoded in JSON.
+type columnKind int
+
+const (
+	kindString = columnKind(iota)
+	kindInt
+	kindLong
+	kindFloat
+	kindDouble
+	kindBytes
+)
+
+// column describes how the values of a field are encoded.
+type column struct {
+	name string
+	kind columnKind
+	// enumValues maps the indexes of enum and set values to
+	// their text, since the binlog only contains the indexes.
+	enumValues map[string]string
+}
+
+func newColumns(fields []*querypb.Field) []*column {
+	columns := make([]*column, 0, len(fields))
+	for _, field := range fields {
+		col := &column{name: field.Name}
+		switch field.Type {
+		case querypb.Type_INT8, querypb.Type_UINT8, querypb.Type_INT16, querypb.Type_UINT16,
+			querypb.Type_INT24, querypb.Type_UINT24, querypb.Type_INT32, querypb.Type_YEAR:
+			col.kind = kindInt
+		case querypb.Type_UINT32, querypb.Type_INT64, querypb.Type_UINT64:
+			col.kind = kindLong
+		case querypb.Type_FLOAT32:
+			col.kind = kindFloat
+		case querypb.Type_FLOAT64:
+			col.kind = kindDouble
+		case querypb.Type_BLOB, querypb.Type_BINARY, querypb.Type_VARBINARY, querypb.Type_BIT, querypb.Type_GEOMETRY:
+			col.kind = kindBytes
+		case querypb.Type_ENUM:
+			col.enumValues = schema.ParseEnumOrSetTokensMap(schema.ParseEnumValues(field.ColumnType))
+		case querypb.Type_SET:
+			col.enumValues = schema.ParseEnumOrSetTokensMap(schema.ParseSetValues(field.ColumnType))
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// text returns the text of a string column value.
+func (col *column) text(val sqltypes.Value) string {
+	if len(col.enumValues) == 0 {
+		return val.ToString()
+	}
+	switch val.Type() {
+	case querypb.Type_ENUM:
+		if text, ok := col.enumValues[val.ToString()]; ok {
+			return text
+		}
+	case querypb.Type_SET:
+		// Sets are bitmaps of their values.
+		bits, err := strconv.ParseUint(val.ToString(), 10, 64)
+		if err != nil {
+			break
+		}
+		var texts []string
+		for i := 0; i < 64 && bits != 0; i, bits = i+1, bits>>1 {
+			if bits&1 != 0 {
+				texts = append(texts, col.enumValues[strconv.Itoa(i+1)])
+			}
+		}
+		return strings.Join(texts, ",")
+	}
+	return val.ToString()
+}
+
+// source is the source block of change events, which describes where
+// the change comes from.
+type source struct {
+	Connector string `json:"connector"`
+	Name      string `json:"name"`
+	TsMs      int64  `json:"ts_ms"`
+	Snapshot  string `json:"snapshot"`
+	Db        string `json:"db"`
+	Table     string `json:"table"`
+	Shard     string `json:"shard"`
+	Vgtid     string `json:"vgtid"`
+}
+
+func newSource(topicPrefix string, change *Change) *source {
+	vgtid, _ := json.Marshal([]struct {
+		Keyspace string `json:"keyspace"`
+		Shard    string `json:"shard"`
+		Gtid     string `json:"gtid"`
+	}{{
+		Keyspace: change.Keyspace,
+		Shard:    change.Shard,
+		Gtid:     change.Position,
+	}})
+	return &source{
+		Connector: "vitess",
+		Name:      topicPrefix,
+		TsMs:      change.Timestamp * 1000,
+		Snapshot:  "false",
+		Db:        change.Keyspace,
+		Table:     change.Table,
+		Shard:     change.Shard,
+		Vgtid:     string(vgtid),
+	}
+}
+
+// topicName returns the Debezium topic name of a change.
+func topicName(topicPrefix string, change *Change) string {
+	return topicPrefix + "." + change.Keyspace + "." + change.Table
+}
+
+// tableInfo caches the columns of a table, which only change when
+// the stream sends new fields.
+type tableInfo struct {
+	fields  []*querypb.Field
+	columns []*column
+	// keys are the indexes of the primary key columns.
+	keys []int
+}
+
+type tableCache map[string]*tableInfo
+
+func (tc *tableCache) get(change *Change) *tableInfo {
+	if *tc == nil {
+		*tc = make(tableCache)
+	}
+	table := (*tc)[change.Table]
+	if table == nil || !sameFields(table.fields, change.Fields) {
+		table = &tableInfo{
+			fields:  change.Fields,
+			columns: newColumns(change.Fields),
+			keys:    change.keyIndexes(),
+		}
+		(*tc)[change.Table] = table
+	}
+	return table
+}
+
+func sameFields(a, b []*querypb.Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+)
+
+func init() {
+	Register("file", newFileSink)
+}
+
+const defaultFileMaxBytes = 128 * 1024 * 1024
+
+// fileSink writes records to files of newline delimited JSON, one record
+// per line, like {"topic":"...","key":{...},"value":{...}}. The file is
+// rotated once it's larger than max_bytes. Files are named
+// <name>.<creation time in nanoseconds>.ndjson, so that they sort in the
+// order they were written. Only the last file is still being written to.
+//
+// Every Flush replaces the <name>.checkpoint file, which records the file
+// and the offset the records were flushed up to, and the position of the
+// stream at that point. When the sink is created again, the records after
+// the checkpoint are removed, and the stream resumes at its position, so
+// that every change is written exactly once.
+type fileSink struct {
+	dir      string
+	name     string
+	maxBytes int64
+	// position is the position of the last checkpoint.
+	position string
+
+	file     *os.File
+	fileName string
+	w        *bufio.Writer
+	size     int64
+}
+
+// fileCheckpoint is the content of the checkpoint file.
+type fileCheckpoint struct {
+	// File is the name of the file the records were flushed to.
+	File string `json:"file"`
+	// Offset is the size of File after the flush.
+	Offset int64 `json:"offset"`
+	// Position is the position of the stream after the flush.
+	Position string `json:"position"`
+}
+
+func newFileSink(name string, spec *binlogdatapb.Sink) (Sink, error) {
+	if spec.Format != "" && spec.Format != "json" {
+		return nil, fmt.Errorf("file sink only supports the json format")
+	}
+	if err := checkParams(spec.Params, append(commonParams, "dir", "max_bytes")...); err != nil {
+		return nil, err
+	}
+	fs := &fileSink{
+		dir:      spec.Params["dir"],
+		name:     name,
+		maxBytes: defaultFileMaxBytes,
+	}
+	if fs.dir == "" {
+		return nil, fmt.Errorf("file sink requires a dir")
+	}
+	if maxBytes, ok := spec.Params["max_bytes"]; ok {
+		n, err := strconv.ParseInt(maxBytes, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid max_bytes %q", maxBytes)
+		}
+		fs.maxBytes = n
+	}
+	if err := os.MkdirAll(fs.dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := fs.restoreCheckpoint(); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+func (fs *fileSink) checkpointPath() string {
+	return filepath.Join(fs.dir, fs.name+".checkpoint")
+}
+
+// restoreCheckpoint removes the records that were written after the last
+// checkpoint, and reopens the file of the checkpoint to append to it.
+func (fs *fileSink) restoreCheckpoint() error {
+	var checkpoint fileCheckpoint
+	content, err := os.ReadFile(fs.checkpointPath())
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(content, &checkpoint); err != nil {
+			return fmt.Errorf("invalid checkpoint %s: %v", fs.checkpointPath(), err)
+		}
+	}
+
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, fs.name+".") || !strings.HasSuffix(name, ".ndjson") {
+			continue
+		}
+		// Files are named so that they sort in the order they
+		// were created.
+		if checkpoint.File == "" || name > checkpoint.File {
+			if err := os.Remove(filepath.Join(fs.dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	if checkpoint.File == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(filepath.Join(fs.dir, checkpoint.File), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(checkpoint.Offset); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Seek(checkpoint.Offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	fs.file = file
+	fs.fileName = checkpoint.File
+	fs.w = bufio.NewWriter(file)
+	fs.size = checkpoint.Offset
+	fs.position = checkpoint.Position
+	return nil
+}
+
+func (fs *fileSink) Write(ctx context.Context, records []*Record) error {
+	for _, record := range records {
+		if fs.file == nil || fs.size >= fs.maxBytes {
+			if err := fs.rotate(); err != nil {
+				return err
+			}
+		}
+		n, err := fs.writeRecord(record)
+		fs.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *fileSink) writeRecord(record *Record) (int, error) {
+	key := record.Key
+	if key == nil {
+		key = []byte("null")
+	}
+	var line bytes.Buffer
+	line.WriteString(`{"topic":`)
+	writeJSONString(&line, record.Topic)
+	line.WriteString(`,"key":`)
+	line.Write(key)
+	line.WriteString(`,"value":`)
+	line.Write(record.Value)
+	line.WriteString("}\n")
+	return fs.w.Write(line.Bytes())
+}
+
+func (fs *fileSink) rotate() error {
+	if err := fs.closeFile(); err != nil {
+		return err
+	}
+	fileName := fmt.Sprintf("%s.%d.ndjson", fs.name, time.Now().UnixNano())
+	file, err := os.OpenFile(filepath.Join(fs.dir, fileName), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	fs.file = file
+	fs.fileName = fileName
+	fs.w = bufio.NewWriter(file)
+	fs.size = 0
+	return nil
+}
+
+func (fs *fileSink) Flush(ctx context.Context, position string) error {
+	if fs.file == nil {
+		return nil
+	}
+	if err := fs.syncFile(); err != nil {
+		return err
+	}
+	return fs.writeCheckpoint(&fileCheckpoint{File: fs.fileName, Offset: fs.size, Position: position})
+}
+
+func (fs *fileSink) Position() string {
+	return fs.position
+}
+
+// syncFile writes the buffered records to the current file, and syncs it.
+func (fs *fileSink) syncFile() error {
+	if err := fs.w.Flush(); err != nil {
+		return err
+	}
+	return fs.file.Sync()
+}
+
+// writeCheckpoint atomically replaces the checkpoint file.
+func (fs *fileSink) writeCheckpoint(checkpoint *fileCheckpoint) error {
+	content, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	tmpPath := fs.checkpointPath() + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, fs.checkpointPath()); err != nil {
+		return err
+	}
+	// The rename is only durable once the directory is synced.
+	dir, err := os.Open(fs.dir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	if err := dir.Sync(); err != nil {
+		return err
+	}
+	fs.position = checkpoint.Position
+	return nil
+}
+
+// closeFile syncs and closes the current file. The records written after
+// the last Flush are not checkpointed, so they are removed if the sink is
+// created again.
+func (fs *fileSink) closeFile() error {
+	if fs.file == nil {
+		return nil
+	}
+	err := fs.syncFile()
+	if closeErr := fs.file.Close(); err == nil {
+		err = closeErr
+	}
+	fs.file = nil
+	fs.fileName = ""
+	fs.w = nil
+	return err
+}
+
+func (fs *fileSink) Close() error {
+	return fs.closeFile()
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+)
+
+func TestFileSink(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := New("wf_1", &binlogdatapb.Sink{
+		Type:   "file",
+		Params: map[string]string{"dir": dir, "max_bytes": "100"},
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	records := []*Record{
+		{Topic: "wf.ks.t1", Key: []byte(`{"id":1}`), Value: []byte(`{"op":"c"}`)},
+		{Topic: "wf.ks.t1", Key: []byte(`{"id":2}`), Value: []byte(`{"op":"c"}`)},
+		{Topic: "wf.ks.t2", Value: []byte(`{"op":"d"}`)},
+	}
+	require.NoError(t, s.Write(ctx, records[:2]))
+	require.NoError(t, s.Write(ctx, records[2:]))
+	require.NoError(t, s.Flush(ctx, "MySQL56/00000000-0000-0000-0000-000000000001:1-10"))
+	assert.Equal(t, "MySQL56/00000000-0000-0000-0000-000000000001:1-10", s.Position())
+
+	// The file is rotated once it's larger than 100 bytes, which is
+	// after the second record.
+	files, err := filepath.Glob(filepath.Join(dir, "wf_1.*.ndjson"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	sort.Strings(files)
+
+	var lines []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")...)
+	}
+	assert.Equal(t, []string{
+		`{"topic":"wf.ks.t1","key":{"id":1},"value":{"op":"c"}}`,
+		`{"topic":"wf.ks.t1","key":{"id":2},"value":{"op":"c"}}`,
+		`{"topic":"wf.ks.t2","key":null,"value":{"op":"d"}}`,
+	}, lines)
+}
+
+func TestFileSinkCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	spec := &binlogdatapb.Sink{
+		Type:   "file",
+		Params: map[string]string{"dir": dir, "max_bytes": "100"},
+	}
+	readLines := func() []string {
+		files, err := filepath.Glob(filepath.Join(dir, "wf_1.*.ndjson"))
+		require.NoError(t, err)
+		sort.Strings(files)
+		var lines []string
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+			lines = append(lines, strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")...)
+		}
+		return lines
+	}
+	record := func(id string) *Record {
+		return &Record{Topic: "wf.ks.t1", Key: []byte(`{"id":` + id + `}`), Value: []byte(`{"op":"c"}`)}
+	}
+
+	s, err := New("wf_1", spec)
+	require.NoError(t, err)
+	assert.Equal(t, "", s.Position())
+	require.NoError(t, s.Write(ctx, []*Record{record("1")}))
+	require.NoError(t, s.Flush(ctx, "MySQL56/00000000-0000-0000-0000-000000000001:1-10"))
+	// The records written after the last flush are lost if the stream
+	// stops, even if they reached the files, like after a rotation.
+	require.NoError(t, s.Write(ctx, []*Record{record("2"), record("3")}))
+	require.NoError(t, s.Close())
+	require.Len(t, readLines(), 3)
+
+	s, err = New("wf_1", spec)
+	require.NoError(t, err)
+	assert.Equal(t, "MySQL56/00000000-0000-0000-0000-000000000001:1-10", s.Position())
+	assert.Equal(t, []string{`{"topic":"wf.ks.t1","key":{"id":1},"value":{"op":"c"}}`}, readLines())
+
+	// Writes continue after the checkpoint.
+	require.NoError(t, s.Write(ctx, []*Record{record("2")}))
+	require.NoError(t, s.Flush(ctx, "MySQL56/00000000-0000-0000-0000-000000000001:1-11"))
+	require.NoError(t, s.Close())
+
+	s, err = New("wf_1", spec)
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, "MySQL56/00000000-0000-0000-0000-000000000001:1-11", s.Position())
+	assert.Equal(t, []string{
+		`{"topic":"wf.ks.t1","key":{"id":1},"value":{"op":"c"}}`,
+		`{"topic":"wf.ks.t1","key":{"id":2},"value":{"op":"c"}}`,
+	}, readLines())
+}
+
+func TestFileSinkFormat(t *testing.T) {
+	_, err := New("wf_1", &binlogdatapb.Sink{
+		Type:   "file",
+		Format: "avro",
+		Params: map[string]string{"dir": t.TempDir()},
+	})
+	assert.EqualError(t, err, "file sink only supports the json format")
+}
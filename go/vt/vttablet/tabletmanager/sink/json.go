@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// jsonEncoder encodes changes as Debezium JSON change events, without
+// the schema, as produced by the JSON converter with schemas disabled.
+type jsonEncoder struct {
+	topicPrefix string
+	tables      tableCache
+}
+
+func (e *jsonEncoder) Encode(change *Change) (*Record, error) {
+	table := e.tables.get(change)
+	record := &Record{Topic: topicName(e.topicPrefix, change)}
+	if len(table.keys) > 0 {
+		var key bytes.Buffer
+		key.WriteByte('{')
+		for i, idx := range table.keys {
+			if i > 0 {
+				key.WriteByte(',')
+			}
+			writeJSONString(&key, table.columns[idx].name)
+			key.WriteByte(':')
+			row := change.After
+			if row == nil {
+				row = change.Before
+			}
+			writeJSONValue(&key, table.columns[idx], row[idx])
+		}
+		key.WriteByte('}')
+		record.Key = key.Bytes()
+	}
+
+	var value bytes.Buffer
+	value.WriteString(`{"before":`)
+	writeJSONRow(&value, table.columns, change.Before)
+	value.WriteString(`,"after":`)
+	writeJSONRow(&value, table.columns, change.After)
+	value.WriteString(`,"source":`)
+	src, err := json.Marshal(newSource(e.topicPrefix, change))
+	if err != nil {
+		return nil, err
+	}
+	value.Write(src)
+	value.WriteString(`,"op":`)
+	writeJSONString(&value, change.op())
+	value.WriteString(`,"ts_ms":`)
+	value.WriteString(strconv.FormatInt(time.Now().UnixMilli(), 10))
+	value.WriteByte('}')
+	record.Value = value.Bytes()
+	return record, nil
+}
+
+func writeJSONRow(buf *bytes.Buffer, columns []*column, row []sqltypes.Value) {
+	if row == nil {
+		buf.WriteString("null")
+		return
+	}
+	buf.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, col.name)
+		buf.WriteByte(':')
+		writeJSONValue(buf, col, row[i])
+	}
+	buf.WriteByte('}')
+}
+
+func writeJSONValue(buf *bytes.Buffer, col *column, val sqltypes.Value) {
+	if val.IsNull() {
+		buf.WriteString("null")
+		return
+	}
+	switch col.kind {
+	case kindInt, kindLong, kindFloat, kindDouble:
+		// MySQL formats numbers as valid JSON numbers, but anything
+		// else is quoted to be safe.
+		if _, err := strconv.ParseFloat(val.ToString(), 64); err == nil {
+			buf.Write(val.Raw())
+			return
+		}
+		writeJSONString(buf, val.ToString())
+	case kindBytes:
+		writeJSONString(buf, base64.StdEncoding.EncodeToString(val.Raw()))
+	default:
+		writeJSONString(buf, col.text(val))
+	}
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+)
+
+func init() {
+	Register("kafka", newKafkaSink)
+}
+
+const (
+	defaultKafkaBatchBytes = 1000000
+	defaultKafkaTimeout    = 30 * time.Second
+	defaultKafkaClientID   = "vitess"
+	kafkaMaxRetries        = 5
+)
+
+// newKafkaProducer creates the producer of a kafka sink. Tests replace it.
+var newKafkaProducer = func(brokers []string, config *sarama.Config) (sarama.SyncProducer, error) {
+	return sarama.NewSyncProducer(brokers, config)
+}
+
+// kafkaSink produces records to a Kafka cluster. Records are buffered
+// until Flush, which produces them to the partition chosen by the hash
+// of their key, like the default partitioner of the Java client, and
+// waits for their acknowledgement.
+//
+// With acks=all, the producer is idempotent, so the retries of a Flush
+// don't duplicate records. Kafka can't tell which records of a stream
+// were acknowledged before it stopped though, so the records written
+// after the last saved position are produced again when the stream
+// restarts: each change is delivered at least once. Consumers that need
+// each change exactly once can skip the changes whose source position
+// they have already seen.
+type kafkaSink struct {
+	producer sarama.SyncProducer
+	pending  []*sarama.ProducerMessage
+}
+
+func newKafkaSink(name string, spec *binlogdatapb.Sink) (Sink, error) {
+	if err := checkParams(spec.Params, append(commonParams, "brokers", "acks", "client_id", "timeout", "max_batch_bytes", "tls")...); err != nil {
+		return nil, err
+	}
+	var brokers []string
+	for _, broker := range strings.Split(spec.Params["brokers"], ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("kafka sink requires brokers")
+	}
+	config, err := newKafkaConfig(spec.Params)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := newKafkaProducer(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaSink{producer: producer}, nil
+}
+
+// newKafkaConfig returns the configuration of the producer.
+func newKafkaConfig(params map[string]string) (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.ClientID = defaultKafkaClientID
+	// Idempotent producers need the version 0.11 of the protocol.
+	config.Version = sarama.V0_11_0_0
+	config.Producer.Return.Successes = true
+	config.Producer.Partitioner = newKafkaPartitioner
+	config.Producer.Retry.Max = kafkaMaxRetries
+	config.Producer.MaxMessageBytes = defaultKafkaBatchBytes
+	timeout := defaultKafkaTimeout
+
+	if clientID, ok := params["client_id"]; ok {
+		config.ClientID = clientID
+	}
+	switch acks := params["acks"]; acks {
+	case "", "all", "-1":
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Producer.Idempotent = true
+		config.Net.MaxOpenRequests = 1
+	case "1":
+		config.Producer.RequiredAcks = sarama.WaitForLocal
+	default:
+		return nil, fmt.Errorf("invalid acks %q: must be all or 1", acks)
+	}
+	if value, ok := params["timeout"]; ok {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", value)
+		}
+		timeout = d
+	}
+	config.Producer.Timeout = timeout
+	config.Net.DialTimeout = timeout
+	config.Net.ReadTimeout = timeout
+	config.Net.WriteTimeout = timeout
+	if maxBatchBytes, ok := params["max_batch_bytes"]; ok {
+		n, err := strconv.Atoi(maxBatchBytes)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid max_batch_bytes %q", maxBatchBytes)
+		}
+		config.Producer.MaxMessageBytes = n
+	}
+	if useTLS, _ := strconv.ParseBool(params["tls"]); useTLS {
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = &tls.Config{}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (ks *kafkaSink) Write(ctx context.Context, records []*Record) error {
+	for _, record := range records {
+		msg := &sarama.ProducerMessage{
+			Topic: record.Topic,
+			Value: sarama.ByteEncoder(record.Value),
+		}
+		if record.Key != nil {
+			msg.Key = sarama.ByteEncoder(record.Key)
+		}
+		ks.pending = append(ks.pending, msg)
+	}
+	return nil
+}
+
+func (ks *kafkaSink) Flush(ctx context.Context, position string) error {
+	if len(ks.pending) == 0 {
+		return nil
+	}
+	if err := ks.producer.SendMessages(ks.pending); err != nil {
+		if errs, ok := err.(sarama.ProducerErrors); ok && len(errs) > 0 {
+			return fmt.Errorf("failed to produce %d records, first error: %v", len(errs), errs[0].Err)
+		}
+		return err
+	}
+	ks.pending = nil
+	return nil
+}
+
+// Position returns "", since the records are not produced atomically
+// with the position.
+func (ks *kafkaSink) Position() string {
+	return ""
+}
+
+func (ks *kafkaSink) Close() error {
+	return ks.producer.Close()
+}
+
+// kafkaPartitioner partitions records by the murmur2 hash of their key,
+// like the default partitioner of the Java client, so that the changes to
+// a row are consumed in order. Records without a key, from tables without
+// a primary key, are spread over the partitions.
+type kafkaPartitioner struct {
+	roundRobin sarama.Partitioner
+}
+
+func newKafkaPartitioner(topic string) sarama.Partitioner {
+	return &kafkaPartitioner{roundRobin: sarama.NewRoundRobinPartitioner(topic)}
+}
+
+func (kp *kafkaPartitioner) Partition(msg *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if msg.Key == nil {
+		return kp.roundRobin.Partition(msg, numPartitions)
+	}
+	key, err := msg.Key.Encode()
+	if err != nil {
+		return -1, err
+	}
+	return (murmur2(key) & 0x7fffffff) % numPartitions, nil
+}
+
+func (kp *kafkaPartitioner) RequiresConsistency() bool {
+	return true
+}
+
+// MessageRequiresConsistency lets the producer choose another partition
+// for the records without a key, if the chosen one is unavailable.
+func (kp *kafkaPartitioner) MessageRequiresConsistency(msg *sarama.ProducerMessage) bool {
+	return msg.Key != nil
+}
+
+// murmur2 is the hash used by the default partitioner of the Kafka clients.
+func murmur2(data []byte) int32 {
+	const (
+		seed = uint32(0x9747b28c)
+		m    = uint32(0x5bd1e995)
+		r    = 24
+	)
+	length := len(data)
+	h := seed ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
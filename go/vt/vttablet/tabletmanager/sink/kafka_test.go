@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+)
+
+func TestMurmur2(t *testing.T) {
+	// From the tests of the Kafka clients.
+	testcases := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+	for in, want := range testcases {
+		assert.Equal(t, want, murmur2([]byte(in)), in)
+	}
+}
+
+// fakeProducer records the messages it produces to topics with two
+// partitions, partitioned by the configured partitioner.
+type fakeProducer struct {
+	config *sarama.Config
+
+	mu       sync.Mutex
+	produced map[string][][]*sarama.ProducerMessage
+	// failSend is the number of calls to SendMessages that fail.
+	failSend int
+	closed   bool
+}
+
+func (fp *fakeProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	if err := fp.SendMessages([]*sarama.ProducerMessage{msg}); err != nil {
+		return -1, -1, err
+	}
+	return msg.Partition, msg.Offset, nil
+}
+
+func (fp *fakeProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	if fp.failSend > 0 {
+		fp.failSend--
+		var errs sarama.ProducerErrors
+		for _, msg := range msgs {
+			errs = append(errs, &sarama.ProducerError{Msg: msg, Err: sarama.ErrNotEnoughReplicas})
+		}
+		return errs
+	}
+	for _, msg := range msgs {
+		partition, err := fp.config.Producer.Partitioner(msg.Topic).Partition(msg, 2)
+		if err != nil {
+			return err
+		}
+		msg.Partition = partition
+		partitions := fp.produced[msg.Topic]
+		if partitions == nil {
+			partitions = make([][]*sarama.ProducerMessage, 2)
+			fp.produced[msg.Topic] = partitions
+		}
+		partitions[partition] = append(partitions[partition], msg)
+	}
+	return nil
+}
+
+func (fp *fakeProducer) Close() error {
+	fp.closed = true
+	return nil
+}
+
+func newTestKafkaSink(t *testing.T, params map[string]string) (Sink, *fakeProducer) {
+	var fp *fakeProducer
+	defer func(f func([]string, *sarama.Config) (sarama.SyncProducer, error)) { newKafkaProducer = f }(newKafkaProducer)
+	newKafkaProducer = func(brokers []string, config *sarama.Config) (sarama.SyncProducer, error) {
+		assert.Equal(t, []string{"kafka1:9092", "kafka2:9092"}, brokers)
+		fp = &fakeProducer{config: config, produced: make(map[string][][]*sarama.ProducerMessage)}
+		return fp, nil
+	}
+	params["brokers"] = "kafka1:9092, kafka2:9092"
+	s, err := New("wf_1", &binlogdatapb.Sink{Type: "kafka", Params: params})
+	require.NoError(t, err)
+	return s, fp
+}
+
+func TestKafkaSink(t *testing.T) {
+	ctx := context.Background()
+	s, fp := newTestKafkaSink(t, map[string]string{})
+	assert.True(t, fp.config.Producer.Idempotent)
+	assert.Equal(t, sarama.WaitForAll, fp.config.Producer.RequiredAcks)
+
+	var records []*Record
+	for i := 0; i < 10; i++ {
+		records = append(records, &Record{
+			Topic: "wf.ks.t1",
+			Key:   []byte(`{"id":` + strconv.Itoa(i) + `}`),
+			Value: []byte(`{"op":"c"}`),
+		})
+	}
+	require.NoError(t, s.Write(ctx, records[:5]))
+	require.NoError(t, s.Write(ctx, records[5:]))
+	assert.Empty(t, fp.produced)
+	require.NoError(t, s.Flush(ctx, "MySQL56/00000000-0000-0000-0000-000000000001:1-10"))
+	assert.Equal(t, "", s.Position())
+
+	// Records with the same key are always produced to the same
+	// partition, in order.
+	want := make([][]*Record, 2)
+	for _, record := range records {
+		partition := (murmur2(record.Key) & 0x7fffffff) % 2
+		want[partition] = append(want[partition], record)
+	}
+	got := make([][]*Record, 2)
+	for partition, msgs := range fp.produced["wf.ks.t1"] {
+		for _, msg := range msgs {
+			key, err := msg.Key.Encode()
+			require.NoError(t, err)
+			value, err := msg.Value.Encode()
+			require.NoError(t, err)
+			got[partition] = append(got[partition], &Record{Topic: msg.Topic, Key: key, Value: value})
+		}
+	}
+	assert.Equal(t, want, got)
+
+	require.NoError(t, s.Close())
+	assert.True(t, fp.closed)
+}
+
+func TestKafkaSinkError(t *testing.T) {
+	ctx := context.Background()
+	s, fp := newTestKafkaSink(t, map[string]string{"acks": "1", "timeout": "5s"})
+	assert.False(t, fp.config.Producer.Idempotent)
+	assert.Equal(t, sarama.WaitForLocal, fp.config.Producer.RequiredAcks)
+	fp.failSend = 1
+
+	record := &Record{Topic: "wf.ks.t2", Value: []byte(`{"op":"d"}`)}
+	require.NoError(t, s.Write(ctx, []*Record{record}))
+	err := s.Flush(ctx, "")
+	assert.EqualError(t, err, "failed to produce 1 records, first error: "+sarama.ErrNotEnoughReplicas.Error())
+
+	// The records are produced again by the next flush.
+	require.NoError(t, s.Flush(ctx, ""))
+	require.Len(t, fp.produced["wf.ks.t2"][0], 1)
+	assert.Nil(t, fp.produced["wf.ks.t2"][0][0].Key)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package sink implements the sinks of sink workflows. A sink workflow is a
+VReplication workflow that, instead of applying the changes of its source
+to the target database, encodes them as Debezium compatible change events
+and writes them to a sink, like rotating files or a Kafka cluster.
+*/
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"vitess.io/vitess/go/sqltypes"
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// Record is an encoded change event.
+type Record struct {
+	// Topic is the name of the topic of the event, which is
+	// <topic_prefix>.<keyspace>.<table>.
+	Topic string
+	// Key identifies the changed row. It's nil for tables
+	// without a primary key.
+	Key []byte
+	// Value is the change event.
+	Value []byte
+}
+
+// Sink writes records. A sink is used by a single stream, so its
+// methods are never called concurrently.
+type Sink interface {
+	// Write writes the records of a transaction. The records may
+	// be buffered until the next Flush.
+	Write(ctx context.Context, records []*Record) error
+	// Flush makes all the records written so far durable. position
+	// is the position of the stream after the last written
+	// transaction, which is saved after Flush succeeds.
+	Flush(ctx context.Context, position string) error
+	// Position returns the position of the last successful Flush,
+	// if the sink keeps it atomically with the records, or "" if
+	// it doesn't. A restarted stream resumes at this position if it
+	// is after its saved position, so that the transactions that
+	// were flushed but not saved are not written again.
+	Position() string
+	// Close releases the resources of the sink.
+	Close() error
+}
+
+// Factory creates a Sink. name identifies the stream that writes
+// to the sink, and is unique within a tablet.
+type Factory func(name string, spec *binlogdatapb.Sink) (Sink, error)
+
+var factories = make(map[string]Factory)
+
+// Register registers a sink under the given name. It is meant to be
+// called from init functions, and panics if a sink with the same name
+// was already registered.
+func Register(name string, factory Factory) {
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("sink %s is already registered", name))
+	}
+	factories[name] = factory
+}
+
+// New creates the sink described by spec.
+func New(name string, spec *binlogdatapb.Sink) (Sink, error) {
+	factory, ok := factories[spec.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown sink type %q", spec.Type)
+	}
+	return factory(name, spec)
+}
+
+// Change is a change to a row, which encoders turn into a Record.
+type Change struct {
+	Keyspace string
+	Shard    string
+	Table    string
+	Fields   []*querypb.Field
+	// Before and After are the row before and after the change.
+	// Before is nil for inserts and After is nil for deletes.
+	Before []sqltypes.Value
+	After  []sqltypes.Value
+	// Position is the GTID position of the transaction that
+	// made the change.
+	Position string
+	// Timestamp is the time of the change on the source, in seconds.
+	Timestamp int64
+}
+
+// op returns the Debezium operation of the change.
+func (c *Change) op() string {
+	switch {
+	case c.Before == nil:
+		return "c"
+	case c.After == nil:
+		return "d"
+	default:
+		return "u"
+	}
+}
+
+// keyIndexes returns the indexes of the primary key fields.
+func (c *Change) keyIndexes() []int {
+	var indexes []int
+	for i, field := range c.Fields {
+		if field.Flags&uint32(querypb.MySqlFlag_PRI_KEY_FLAG) != 0 {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// Encoder encodes changes into records.
+type Encoder interface {
+	Encode(change *Change) (*Record, error)
+}
+
+// NewEncoder returns the encoder of the given format. Topics are
+// prefixed with topicPrefix. The avro format accepts the
+// schema_registry_url parameter.
+func NewEncoder(format, topicPrefix string, params map[string]string) (Encoder, error) {
+	switch format {
+	case "", "json":
+		return &jsonEncoder{topicPrefix: topicPrefix}, nil
+	case "avro":
+		return newAvroEncoder(topicPrefix, params["schema_registry_url"]), nil
+	default:
+		return nil, fmt.Errorf("unknown sink format %q: must be json or avro", format)
+	}
+}
+
+// checkParams returns an error if params contains a parameter that is
+// not in allowed.
+func checkParams(params map[string]string, allowed ...string) error {
+	var unknown []string
+	for param := range params {
+		found := false
+		for _, a := range allowed {
+			if param == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, param)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown sink parameters: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// commonParams are the parameters accepted by all sinks, which are
+// used by the stream and the encoders.
+var commonParams = []string{"topic_prefix", "schema_registry_url"}
@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+var testFields = []*querypb.Field{{
+	Name:  "id",
+	Type:  querypb.Type_INT64,
+	Flags: uint32(querypb.MySqlFlag_PRI_KEY_FLAG),
+}, {
+	Name: "name",
+	Type: querypb.Type_VARCHAR,
+}, {
+	Name:       "color",
+	Type:       querypb.Type_ENUM,
+	ColumnType: "enum('red','green')",
+}, {
+	Name:       "tags",
+	Type:       querypb.Type_SET,
+	ColumnType: "set('a','b','c')",
+}, {
+	Name: "data",
+	Type: querypb.Type_BLOB,
+}, {
+	Name: "price",
+	Type: querypb.Type_DECIMAL,
+}}
+
+func testChange(before, after []sqltypes.Value) *Change {
+	return &Change{
+		Keyspace:  "ks",
+		Shard:     "0",
+		Table:     "t1",
+		Fields:    testFields,
+		Before:    before,
+		After:     after,
+		Position:  "MySQL56/00000000-0000-0000-0000-000000000001:1-10",
+		Timestamp: 1600000000,
+	}
+}
+
+func testRow(id int64, name string) []sqltypes.Value {
+	return []sqltypes.Value{
+		sqltypes.NewInt64(id),
+		sqltypes.NewVarChar(name),
+		sqltypes.MakeTrusted(querypb.Type_ENUM, []byte("2")),
+		sqltypes.MakeTrusted(querypb.Type_SET, []byte("5")),
+		sqltypes.MakeTrusted(querypb.Type_BLOB, []byte{0, 1, 2}),
+		sqltypes.MakeTrusted(querypb.Type_DECIMAL, []byte("1.50")),
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("wf_1", &binlogdatapb.Sink{Type: "unknown"})
+	assert.EqualError(t, err, `unknown sink type "unknown"`)
+
+	_, err = New("wf_1", &binlogdatapb.Sink{Type: "file", Params: map[string]string{"dir": t.TempDir(), "b": "1", "a": "1"}})
+	assert.EqualError(t, err, "unknown sink parameters: a, b")
+
+	_, err = New("wf_1", &binlogdatapb.Sink{Type: "file"})
+	assert.EqualError(t, err, "file sink requires a dir")
+
+	_, err = New("wf_1", &binlogdatapb.Sink{Type: "kafka", Params: map[string]string{"brokers": "localhost:9092", "acks": "0"}})
+	assert.EqualError(t, err, `invalid acks "0": must be all or 1`)
+
+	_, err = NewEncoder("xml", "wf", nil)
+	assert.EqualError(t, err, `unknown sink format "xml": must be json or avro`)
+}
+
+func TestJSONEncoder(t *testing.T) {
+	enc, err := NewEncoder("json", "wf", nil)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name   string
+		change *Change
+		op     string
+		before interface{}
+		after  interface{}
+	}{{
+		name:   "insert",
+		change: testChange(nil, testRow(1, "a")),
+		op:     "c",
+		after: map[string]interface{}{
+			"id":    float64(1),
+			"name":  "a",
+			"color": "green",
+			"tags":  "a,c",
+			"data":  "AAEC",
+			"price": "1.50",
+		},
+	}, {
+		name:   "delete",
+		change: testChange(testRow(1, "a"), nil),
+		op:     "d",
+		before: map[string]interface{}{
+			"id":    float64(1),
+			"name":  "a",
+			"color": "green",
+			"tags":  "a,c",
+			"data":  "AAEC",
+			"price": "1.50",
+		},
+	}}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			record, err := enc.Encode(tc.change)
+			require.NoError(t, err)
+			assert.Equal(t, "wf.ks.t1", record.Topic)
+			assert.Equal(t, `{"id":1}`, string(record.Key))
+
+			var value map[string]interface{}
+			require.NoError(t, json.Unmarshal(record.Value, &value))
+			assert.Equal(t, tc.op, value["op"])
+			assert.Equal(t, tc.before, value["before"])
+			assert.Equal(t, tc.after, value["after"])
+			assert.Equal(t, map[string]interface{}{
+				"connector": "vitess",
+				"name":      "wf",
+				"ts_ms":     float64(1600000000000),
+				"snapshot":  "false",
+				"db":        "ks",
+				"table":     "t1",
+				"shard":     "0",
+				"vgtid":     `[{"keyspace":"ks","shard":"0","gtid":"MySQL56/00000000-0000-0000-0000-000000000001:1-10"}]`,
+			}, value["source"])
+		})
+	}
+}
+
+func TestJSONEncoderNoPrimaryKey(t *testing.T) {
+	enc, err := NewEncoder("", "wf", nil)
+	require.NoError(t, err)
+	change := &Change{
+		Keyspace: "ks",
+		Table:    "t2",
+		Fields:   []*querypb.Field{{Name: "val", Type: querypb.Type_VARCHAR}},
+		After:    []sqltypes.Value{sqltypes.NULL},
+	}
+	record, err := enc.Encode(change)
+	require.NoError(t, err)
+	assert.Nil(t, record.Key)
+	assert.Contains(t, string(record.Value), `"after":{"val":null}`)
+}
+
+func TestAvroEncoder(t *testing.T) {
+	enc, err := NewEncoder("avro", "wf", nil)
+	require.NoError(t, err)
+	change := testChange(nil, testRow(-3, "a"))
+	record, err := enc.Encode(change)
+	require.NoError(t, err)
+	assert.Equal(t, "wf.ks.t1", record.Topic)
+
+	schemas := enc.(*avroEncoder).schemas["t1"]
+	require.NotNil(t, schemas)
+
+	// The key uses the single object encoding, and contains the non null
+	// union branch followed by the zigzag encoded id.
+	require.Len(t, record.Key, 12)
+	assert.Equal(t, []byte{0xc3, 0x01}, record.Key[:2])
+	assert.Equal(t, schemas.key.header, record.Key[:10])
+	assert.Equal(t, []byte{0x02, 0x05}, record.Key[10:])
+
+	var keySchema avroRecordSchema
+	require.NoError(t, json.Unmarshal(schemas.key.schema, &keySchema))
+	assert.Equal(t, "wf.ks.t1.Key", keySchema.Name)
+
+	// The fingerprint is computed on the schema without defaults.
+	canonical := bytes.ReplaceAll(schemas.key.schema, []byte(`,"default":null`), nil)
+	assert.Equal(t, avroFingerprint(canonical), binary.LittleEndian.Uint64(record.Key[2:10]))
+
+	// The value starts with a null before and a non null after.
+	value := record.Value[len(schemas.value.header):]
+	assert.Equal(t, []byte{0x00, 0x02, 0x02, 0x05}, value[:4])
+}
+
+func TestAvroFingerprint(t *testing.T) {
+	// From the test cases of the Avro specification.
+	assert.Equal(t, uint64(7195948357588979594), avroFingerprint([]byte(`"null"`)))
+}
+
+func TestAvroName(t *testing.T) {
+	assert.Equal(t, "a_b", avroName("a-b"))
+	assert.Equal(t, "_1a", avroName("1a"))
+	assert.Equal(t, "wf.ks._1t", avroNamespace("wf.ks.1t"))
+}
@@ -42,12 +42,13 @@ const (
 	StopAction    VDiffAction = "stop"
 	ResumeAction  VDiffAction = "resume"
 	DeleteAction  VDiffAction = "delete"
+	RepairAction  VDiffAction = "repair"
 	AllActionArg              = "all"
 	LastActionArg             = "last"
 )
 
 var (
-	Actions    = []VDiffAction{CreateAction, ShowAction, StopAction, ResumeAction, DeleteAction}
+	Actions    = []VDiffAction{CreateAction, ShowAction, StopAction, ResumeAction, DeleteAction, RepairAction}
 	ActionArgs = []string{AllActionArg, LastActionArg}
 )
 
@@ -92,6 +93,10 @@ func (vde *Engine) PerformVDiffAction(ctx context.Context, req *tabletmanagerdat
 		if err := vde.handleDeleteAction(ctx, dbClient, action, req, resp); err != nil {
 			return nil, err
 		}
+	case RepairAction:
+		if err := vde.handleRepairAction(ctx, dbClient, action, req, resp); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("action %s not supported", action)
 	}
@@ -308,6 +313,13 @@ func (vde *Engine) handleDeleteAction(ctx context.Context, dbClient binlogplayer
 	if _, err = dbClient.ExecuteFetch(query, 1); err != nil {
 		return err
 	}
+	if req.ActionArg == AllActionArg {
+		// Later incremental vdiffs of the workflow start from scratch.
+		query = fmt.Sprintf(sqlDeleteVDiffCheckpoints, encodeString(vde.dbName), encodeString(req.Workflow))
+		if _, err = dbClient.ExecuteFetch(query, 1); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -80,9 +80,19 @@ func newController(ctx context.Context, row sqltypes.RowNamedValues, dbClientFac
 	ts *topo.Server, vde *Engine, options *tabletmanagerdata.VDiffOptions) (*controller, error) {
 
 	log.Infof("VDiff controller initializing for %+v", row)
-	id, _ := row["id"].ToInt64()
+	ct := newControllerForRow(row, dbClientFactory, ts, vde, options)
+	ctx, ct.cancel = context.WithCancel(ctx)
+	go ct.run(ctx)
+
+	return ct, nil
+}
+
+// newControllerForRow returns a controller for the vdiff of a _vt.vdiff row, without running it.
+func newControllerForRow(row sqltypes.RowNamedValues, dbClientFactory func() binlogplayer.DBClient,
+	ts *topo.Server, vde *Engine, options *tabletmanagerdata.VDiffOptions) *controller {
 
-	ct := &controller{
+	id, _ := row["id"].ToInt64()
+	return &controller{
 		id:              id,
 		uuid:            row["vdiff_uuid"].ToString(),
 		workflow:        row["workflow"].ToString(),
@@ -94,10 +104,6 @@ func newController(ctx context.Context, row sqltypes.RowNamedValues, dbClientFac
 		sources:         make(map[string]*migrationSource),
 		options:         options,
 	}
-	ctx, ct.cancel = context.WithCancel(ctx)
-	go ct.run(ctx)
-
-	return ct, nil
 }
 
 func (ct *controller) Stop() {
@@ -149,6 +155,15 @@ type migrationSource struct {
 	position mysql.Position
 }
 
+// sourceTopoServer returns the topo server of the source keyspace. For Mount+Migrate,
+// the source tablets will be in a different Vitess cluster with its own TopoServer.
+func (ct *controller) sourceTopoServer(ctx context.Context) (*topo.Server, error) {
+	if ct.externalCluster == "" {
+		return ct.ts, nil
+	}
+	return ct.ts.OpenExternalVitessClusterServer(ctx, ct.externalCluster)
+}
+
 func (ct *controller) updateState(dbClient binlogplayer.DBClient, state VDiffState, err error) error {
 	extraCols := ""
 	switch state {
@@ -176,6 +191,27 @@ func (ct *controller) start(ctx context.Context, dbClient binlogplayer.DBClient)
 		return vterrors.Errorf(vtrpcpb.Code_CANCELED, "context has expired")
 	default:
 	}
+	if err := ct.loadSources(ctx, dbClient); err != nil {
+		return err
+	}
+
+	wd, err := newWorkflowDiffer(ct, ct.options)
+	if err != nil {
+		return err
+	}
+	if err := ct.updateState(dbClient, StartedState, nil); err != nil {
+		return err
+	}
+	if err := wd.diff(ctx); err != nil {
+		log.Errorf("Encountered an error performing workflow diff for vdiff %s: %v", ct.uuid, err)
+		return err
+	}
+
+	return nil
+}
+
+// loadSources reads the source shards and the filter of the workflow from its vreplication streams.
+func (ct *controller) loadSources(ctx context.Context, dbClient binlogplayer.DBClient) error {
 	ct.workflowFilter = fmt.Sprintf("where workflow = %s and db_name = %s", encodeString(ct.workflow), encodeString(ct.vde.dbName))
 	query := fmt.Sprintf(sqlGetVReplicationEntry, ct.workflowFilter)
 	qr, err := dbClient.ExecuteFetch(query, -1)
@@ -215,23 +251,7 @@ func (ct *controller) start(ctx context.Context, dbClient binlogplayer.DBClient)
 		}
 	}
 
-	if err := ct.validate(); err != nil {
-		return err
-	}
-
-	wd, err := newWorkflowDiffer(ct, ct.options)
-	if err != nil {
-		return err
-	}
-	if err := ct.updateState(dbClient, StartedState, nil); err != nil {
-		return err
-	}
-	if err := wd.diff(ctx); err != nil {
-		log.Errorf("Encountered an error performing workflow diff for vdiff %s: %v", ct.uuid, err)
-		return err
-	}
-
-	return nil
+	return ct.validate()
 }
 
 // markStoppedByRequest records the fact that this VDiff was stopped via user
@@ -0,0 +1,284 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/grpcclient"
+	"vitess.io/vitess/go/vt/mysqlctl/fakemysqldaemon"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/memorytopo"
+	"vitess.io/vitess/go/vt/vttablet/queryservice"
+	"vitess.io/vitess/go/vt/vttablet/queryservice/fakes"
+	"vitess.io/vitess/go/vt/vttablet/tabletconn"
+	"vitess.io/vitess/go/vt/vttablet/tabletconntest"
+	"vitess.io/vitess/go/vt/vttablet/tabletmanager/vreplication"
+	"vitess.io/vitess/go/vt/vttablet/tmclient"
+	"vitess.io/vitess/go/vt/vttablet/tmclienttest"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+const (
+	testCell           = "cell1"
+	testSourceKeyspace = "source"
+	testTargetKeyspace = "target"
+	testShard          = "0"
+	testDBName         = "vt_target"
+	testWorkflow       = "wf1"
+	testPosition       = "MySQL56/16b1039f-22b6-11ed-b765-0a43f95f28a3:1-10"
+)
+
+// env is the test env of the running test. The tablet connections and the tablet manager client
+// are registered once, and serve the env of the test that uses them.
+var env *testEnv
+
+func init() {
+	tabletconn.RegisterDialer("vdiff.test", func(tablet *topodatapb.Tablet, failFast grpcclient.FailFast) (queryservice.QueryService, error) {
+		return &fakeTabletConn{QueryService: fakes.ErrorQueryService, tablet: tablet}, nil
+	})
+	tabletconntest.SetProtocol("go.vt.vttablet.tabletmanager.vdiff.framework_test", "vdiff.test")
+
+	tmclient.RegisterTabletManagerClientFactory("vdiff.test", func() tmclient.TabletManagerClient {
+		return &fakeTMClient{}
+	})
+	tmclienttest.SetProtocol("go.vt.vttablet.tabletmanager.vdiff", "vdiff.test")
+}
+
+// testEnv is a vdiff engine on the primary tablet of the target keyspace, whose databases,
+// tablets and tablet manager RPCs are faked.
+type testEnv struct {
+	ts  *topo.Server
+	vde *Engine
+	vre *vreplication.Engine
+	// dbClient serves the queries of the vdiff engine.
+	dbClient *fakeDBClient
+	// vreDBClient serves the queries of the vreplication engine.
+	vreDBClient *fakeDBClient
+
+	mu sync.Mutex
+	// schema is returned by GetSchema.
+	schema *tabletmanagerdatapb.SchemaDefinition
+	// rows are the rows streamed by the tablets of each keyspace.
+	rows map[string]*sqltypes.Result
+	// streamQueries are the queries streamed from the tablets of each keyspace.
+	streamQueries map[string][]string
+	// vreplicationQueries are the queries sent to the VReplicationExec RPC.
+	vreplicationQueries []string
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	ctx := context.Background()
+	ts := memorytopo.NewServer(testCell)
+	for _, keyspace := range []string{testSourceKeyspace, testTargetKeyspace} {
+		require.NoError(t, ts.CreateKeyspace(ctx, keyspace, &topodatapb.Keyspace{}))
+		require.NoError(t, ts.CreateShard(ctx, keyspace, testShard))
+	}
+	newTablet := func(uid uint32, keyspace string, tabletType topodatapb.TabletType) *topodatapb.Tablet {
+		tablet := &topodatapb.Tablet{
+			Alias:    &topodatapb.TabletAlias{Cell: testCell, Uid: uid},
+			Keyspace: keyspace,
+			Shard:    testShard,
+			Type:     tabletType,
+		}
+		require.NoError(t, ts.CreateTablet(ctx, tablet))
+		return tablet
+	}
+	newTablet(100, testSourceKeyspace, topodatapb.TabletType_REPLICA)
+	newTablet(201, testTargetKeyspace, topodatapb.TabletType_REPLICA)
+	primary := newTablet(200, testTargetKeyspace, topodatapb.TabletType_PRIMARY)
+
+	te := &testEnv{
+		ts:            ts,
+		dbClient:      newFakeDBClient(),
+		vreDBClient:   newFakeDBClient(),
+		rows:          make(map[string]*sqltypes.Result),
+		streamQueries: make(map[string][]string),
+	}
+	te.vre = vreplication.NewTestEngine(ts, testCell, fakemysqldaemon.NewFakeMysqlDaemon(nil),
+		func() binlogplayer.DBClient { return te.vreDBClient },
+		func() binlogplayer.DBClient { return te.vreDBClient },
+		testDBName, nil)
+	te.vre.Open(ctx)
+	te.vde = &Engine{
+		isOpen:                  true,
+		controllers:             make(map[int64]*controller),
+		ctx:                     ctx,
+		ts:                      ts,
+		dbClientFactoryFiltered: func() binlogplayer.DBClient { return te.dbClient },
+		dbClientFactoryDba:      func() binlogplayer.DBClient { return te.dbClient },
+		dbName:                  testDBName,
+		vre:                     te.vre,
+		thisTablet:              primary,
+	}
+	env = te
+	t.Cleanup(func() {
+		te.vre.Close()
+		env = nil
+	})
+	return te
+}
+
+func (te *testEnv) setRows(keyspace string, result *sqltypes.Result) {
+	te.mu.Lock()
+	defer te.mu.Unlock()
+	te.rows[keyspace] = result
+}
+
+func (te *testEnv) getStreamQueries(keyspace string) []string {
+	te.mu.Lock()
+	defer te.mu.Unlock()
+	return te.streamQueries[keyspace]
+}
+
+func (te *testEnv) getVReplicationQueries() []string {
+	te.mu.Lock()
+	defer te.mu.Unlock()
+	return te.vreplicationQueries
+}
+
+// fakeTabletConn streams the rows of its keyspace in the test env.
+type fakeTabletConn struct {
+	queryservice.QueryService
+	tablet *topodatapb.Tablet
+}
+
+// VStreamRows is part of queryservice.QueryService.
+func (ftc *fakeTabletConn) VStreamRows(ctx context.Context, request *binlogdatapb.VStreamRowsRequest, send func(*binlogdatapb.VStreamRowsResponse) error) error {
+	env.mu.Lock()
+	env.streamQueries[ftc.tablet.Keyspace] = append(env.streamQueries[ftc.tablet.Keyspace], request.Query)
+	result := env.rows[ftc.tablet.Keyspace]
+	env.mu.Unlock()
+	if result == nil {
+		return fmt.Errorf("no rows for keyspace %s", ftc.tablet.Keyspace)
+	}
+	qr := sqltypes.ResultToProto3(result)
+	return send(&binlogdatapb.VStreamRowsResponse{Fields: qr.Fields, Rows: qr.Rows, Gtid: testPosition})
+}
+
+// fakeTMClient serves the tablet manager RPCs that a vdiff sends, from the test env.
+type fakeTMClient struct {
+	tmclient.TabletManagerClient
+}
+
+// GetSchema is part of the tmclient.TabletManagerClient interface.
+func (tmc *fakeTMClient) GetSchema(ctx context.Context, tablet *topodatapb.Tablet, request *tabletmanagerdatapb.GetSchemaRequest) (*tabletmanagerdatapb.SchemaDefinition, error) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	return env.schema, nil
+}
+
+// WaitForPosition is part of the tmclient.TabletManagerClient interface.
+func (tmc *fakeTMClient) WaitForPosition(ctx context.Context, tablet *topodatapb.Tablet, pos string) error {
+	return nil
+}
+
+// VReplicationExec is part of the tmclient.TabletManagerClient interface.
+func (tmc *fakeTMClient) VReplicationExec(ctx context.Context, tablet *topodatapb.Tablet, query string) (*querypb.QueryResult, error) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	env.vreplicationQueries = append(env.vreplicationQueries, query)
+	return &querypb.QueryResult{}, nil
+}
+
+// fakeDBClient returns the results and errors that were set for queries, and an empty result
+// for the other ones. It records all the queries it runs.
+type fakeDBClient struct {
+	mu      sync.Mutex
+	results map[string]*sqltypes.Result
+	errors  map[string]error
+	queries []string
+}
+
+func newFakeDBClient() *fakeDBClient {
+	return &fakeDBClient{results: make(map[string]*sqltypes.Result), errors: make(map[string]error)}
+}
+
+func (dc *fakeDBClient) setResult(query string, result *sqltypes.Result) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.results[query] = result
+}
+
+func (dc *fakeDBClient) setError(query string, err error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.errors[query] = err
+}
+
+func (dc *fakeDBClient) getQueries() []string {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return append([]string(nil), dc.queries...)
+}
+
+// DBName is part of the binlogplayer.DBClient interface.
+func (dc *fakeDBClient) DBName() string {
+	return testDBName
+}
+
+// Connect is part of the binlogplayer.DBClient interface.
+func (dc *fakeDBClient) Connect() error {
+	return nil
+}
+
+// Begin is part of the binlogplayer.DBClient interface.
+func (dc *fakeDBClient) Begin() error {
+	_, err := dc.ExecuteFetch("begin", 1)
+	return err
+}
+
+// Commit is part of the binlogplayer.DBClient interface.
+func (dc *fakeDBClient) Commit() error {
+	_, err := dc.ExecuteFetch("commit", 1)
+	return err
+}
+
+// Rollback is part of the binlogplayer.DBClient interface.
+func (dc *fakeDBClient) Rollback() error {
+	_, err := dc.ExecuteFetch("rollback", 1)
+	return err
+}
+
+// Close is part of the binlogplayer.DBClient interface.
+func (dc *fakeDBClient) Close() {
+}
+
+// ExecuteFetch is part of the binlogplayer.DBClient interface.
+func (dc *fakeDBClient) ExecuteFetch(query string, maxrows int) (*sqltypes.Result, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.queries = append(dc.queries, query)
+	if err, ok := dc.errors[query]; ok {
+		return nil, err
+	}
+	if result, ok := dc.results[query]; ok {
+		return result, nil
+	}
+	return &sqltypes.Result{}, nil
+}
@@ -0,0 +1,453 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/prototext"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/log"
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vttablet/tabletconn"
+)
+
+// Once the diff of a table completes, a checkpoint is recorded for it in _vt.vdiff_checkpoint:
+// the source positions that the table was compared at, and the primary keys of the rows that
+// did not match. An incremental diff streams the binary logs of the sources from the checkpoint
+// positions to find the rows changed since, and only compares those rows and the rows that did
+// not match before. Tables without a usable checkpoint are diffed in full.
+
+// incrementalDiffMaxRows is the maximum number of rows that an incremental diff compares
+// for a table. Tables with more changed rows are diffed in full.
+var incrementalDiffMaxRows = 100000
+
+var errTooManyChangedRows = errors.New("too many changed rows")
+
+// tableCheckpoint is the state recorded after the last completed diff of a table.
+type tableCheckpoint struct {
+	// vdiffID is the id of the vdiff that recorded the checkpoint.
+	vdiffID int64
+	// positions are the source positions that the table was compared at, by source shard.
+	positions map[string]string
+	// mismatchedPKs are the primary keys of the rows that did not match.
+	mismatchedPKs [][]sqltypes.Value
+}
+
+// incrementalDiff restricts a table diff to the rows changed since its checkpoint.
+type incrementalDiff struct {
+	// positions are the source positions up to which the changes were read, by source shard.
+	// They are recorded as the checkpoint positions once the diff completes.
+	positions map[string]string
+	// pks are the primary keys of the rows to compare, keyed by pkKey.
+	pks map[string][]sqltypes.Value
+}
+
+// pkKey returns a key for a primary key that is unique across all the values of the key.
+func pkKey(pk []sqltypes.Value) string {
+	var buf strings.Builder
+	for _, val := range pk {
+		if val.IsNull() {
+			buf.WriteString("-;")
+			continue
+		}
+		fmt.Fprintf(&buf, "%d:", len(val.Raw()))
+		buf.Write(val.Raw())
+	}
+	return buf.String()
+}
+
+// pkFields returns the fields of the primary key columns of the table.
+func (td *tableDiffer) pkFields() []*querypb.Field {
+	fields := make([]*querypb.Field, len(td.tablePlan.pkCols))
+	for i, colIndex := range td.tablePlan.pkCols {
+		fields[i] = td.tablePlan.table.Fields[colIndex]
+	}
+	return fields
+}
+
+// trackMismatch records the primary key of a row that did not match, for the table checkpoint.
+func (td *tableDiffer) trackMismatch(row []sqltypes.Value) {
+	if td.mismatchedPKs == nil {
+		return
+	}
+	if len(td.mismatchedPKs) >= incrementalDiffMaxRows {
+		log.Infof("Too many mismatched rows in table %s to record them for incremental diffs", td.table.Name)
+		td.mismatchedPKs = nil
+		return
+	}
+	pk := td.rowPK(row)
+	td.mismatchedPKs[pkKey(pk)] = pk
+}
+
+func (td *tableDiffer) getCheckpoint(dbClient binlogplayer.DBClient) (*tableCheckpoint, error) {
+	ct := td.wd.ct
+	query := fmt.Sprintf(sqlGetVDiffCheckpoint, encodeString(ct.vde.dbName), encodeString(ct.workflow), encodeString(td.table.Name))
+	qr, err := dbClient.ExecuteFetch(query, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(qr.Rows) == 0 {
+		return nil, nil
+	}
+	row := qr.Named().Row()
+	checkpoint := &tableCheckpoint{vdiffID: row.AsInt64("vdiff_id", 0)}
+	if err := json.Unmarshal(row.AsBytes("positions", []byte("{}")), &checkpoint.positions); err != nil {
+		return nil, err
+	}
+	var pks querypb.QueryResult
+	if err := prototext.Unmarshal(row.AsBytes("mismatched_pks", nil), &pks); err != nil {
+		return nil, err
+	}
+	checkpoint.mismatchedPKs = sqltypes.Proto3ToResult(&pks).Rows
+	return checkpoint, nil
+}
+
+// saveCheckpoint records the checkpoint of the table once its diff completed. The checkpoint is
+// removed instead when a later incremental diff cannot rely on it.
+func (td *tableDiffer) saveCheckpoint(dbClient binlogplayer.DBClient) error {
+	ct := td.wd.ct
+	var positions []byte
+	if td.mismatchedPKs != nil && len(td.tablePlan.aggregates) == 0 && td.wd.opts.CoreOptions.MaxRows >= 0 {
+		qr, err := dbClient.ExecuteFetch(fmt.Sprintf(sqlGetTablePositions, ct.id, encodeString(td.table.Name)), 1)
+		if err != nil {
+			return err
+		}
+		if len(qr.Rows) == 1 {
+			positions = qr.Named().Row().AsBytes("positions", nil)
+		}
+	}
+	if len(positions) == 0 {
+		// Either the table was not fully compared, or the rows that did not match are not all known.
+		query := fmt.Sprintf(sqlDeleteVDiffCheckpoint, encodeString(ct.vde.dbName), encodeString(ct.workflow), encodeString(td.table.Name))
+		_, err := dbClient.ExecuteFetch(query, 1)
+		return err
+	}
+
+	keys := make([]string, 0, len(td.mismatchedPKs))
+	for key := range td.mismatchedPKs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pks := &querypb.QueryResult{Fields: td.pkFields()}
+	for _, key := range keys {
+		pks.Rows = append(pks.Rows, sqltypes.RowToProto3(td.mismatchedPKs[key]))
+	}
+	pksBytes, err := prototext.Marshal(pks)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(sqlSaveVDiffCheckpoint, encodeString(ct.vde.dbName), encodeString(ct.workflow), encodeString(td.table.Name),
+		ct.id, encodeString(string(positions)), encodeString(string(pksBytes)))
+	_, err = dbClient.ExecuteFetch(query, 1)
+	return err
+}
+
+// updateTablePositions records the source positions that the table is compared at.
+func (td *tableDiffer) updateTablePositions(dbClient binlogplayer.DBClient, positions map[string]string) error {
+	positionsJSON, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(sqlUpdateTablePositions, encodeString(string(positionsJSON)), td.wd.ct.id, encodeString(td.table.Name))
+	_, err = dbClient.ExecuteFetch(query, 1)
+	return err
+}
+
+// prepareIncremental restricts the diff of the table to the rows changed since its checkpoint,
+// and the rows that did not match then. The table is diffed in full when that is not possible.
+func (td *tableDiffer) prepareIncremental(ctx context.Context, dbClient binlogplayer.DBClient) error {
+	ct := td.wd.ct
+	fullDiff := func(reason string) error {
+		log.Infof("Diffing table %s in full for vdiff %s: %s", td.table.Name, ct.uuid, reason)
+		insertVDiffLog(ctx, dbClient, ct.id, fmt.Sprintf("Diffing table %s in full: %s", td.table.Name, reason))
+		return nil
+	}
+
+	checkpoint, err := td.getCheckpoint(dbClient)
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		return fullDiff("no previous diff of the table completed")
+	}
+	for shard := range ct.sources {
+		if checkpoint.positions[shard] == "" {
+			return fullDiff(fmt.Sprintf("source shard %s was not part of the previous diff", shard))
+		}
+	}
+	sourceSel, sourcePKs, err := td.sourcePKColumns()
+	if err != nil {
+		return fullDiff(err.Error())
+	}
+	filterSel := &sqlparser.Select{From: sourceSel.From, Where: sourceSel.Where}
+	for _, col := range sourcePKs {
+		filterSel.SelectExprs = append(filterSel.SelectExprs, &sqlparser.AliasedExpr{Expr: col})
+	}
+	sourceTable, err := sqlparser.TableFromStatement(sqlparser.String(sourceSel))
+	if err != nil {
+		return fullDiff(err.Error())
+	}
+	filter := &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match:  sourceTable.Name.String(),
+			Filter: sqlparser.String(filterSel),
+		}},
+	}
+
+	sourceTopoServer, err := ct.sourceTopoServer(ctx)
+	if err != nil {
+		return err
+	}
+	incr := &incrementalDiff{
+		positions: make(map[string]string),
+		pks:       make(map[string][]sqltypes.Value),
+	}
+	for _, pk := range checkpoint.mismatchedPKs {
+		incr.pks[pkKey(pk)] = pk
+	}
+	var mu sync.Mutex
+	err = td.forEachSource(func(source *migrationSource) error {
+		pos, err := td.streamChangedRows(ctx, sourceTopoServer, source.shard, filter, checkpoint.positions[source.shard], func(pk []sqltypes.Value) error {
+			mu.Lock()
+			defer mu.Unlock()
+			incr.pks[pkKey(pk)] = pk
+			if len(incr.pks) > incrementalDiffMaxRows {
+				return errTooManyChangedRows
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		incr.positions[source.shard] = pos
+		return nil
+	})
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case len(incr.pks) > incrementalDiffMaxRows:
+		return fullDiff(fmt.Sprintf("more than %d rows changed since the previous diff", incrementalDiffMaxRows))
+	case err != nil:
+		return fullDiff(fmt.Sprintf("cannot read the rows changed since the previous diff: %v", err))
+	}
+
+	if len(incr.pks) != 0 {
+		if err := td.restrictToPKs(sourcePKs, incr.pks); err != nil {
+			return err
+		}
+	}
+	td.incremental = incr
+	insertVDiffLog(ctx, dbClient, ct.id, fmt.Sprintf("Diffing %d changed or mismatched rows of table %s", len(incr.pks), td.table.Name))
+	return nil
+}
+
+// sourcePKColumns returns the select of the source query and the source columns of the primary key.
+// It returns an error when the diff of the table cannot be restricted to a set of primary keys.
+func (td *tableDiffer) sourcePKColumns() (*sqlparser.Select, []*sqlparser.ColName, error) {
+	if len(td.tablePlan.aggregates) != 0 {
+		return nil, nil, fmt.Errorf("the table is aggregated")
+	}
+	for _, field := range td.pkFields() {
+		if !sqltypes.IsIntegral(field.Type) && !sqltypes.IsQuoted(field.Type) {
+			return nil, nil, fmt.Errorf("primary key column %s has type %v", field.Name, field.Type)
+		}
+	}
+	statement, err := sqlparser.Parse(td.tablePlan.sourceQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	sel, ok := statement.(*sqlparser.Select)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected: %v", sqlparser.String(statement))
+	}
+	sourcePKs := make([]*sqlparser.ColName, len(td.tablePlan.pkCols))
+	for i, colIndex := range td.tablePlan.pkCols {
+		expr, ok := sel.SelectExprs[colIndex].(*sqlparser.AliasedExpr)
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected select expression %s", sqlparser.String(sel.SelectExprs[colIndex]))
+		}
+		col, ok := expr.Expr.(*sqlparser.ColName)
+		if !ok {
+			return nil, nil, fmt.Errorf("primary key column %s is an expression on the source", td.tablePlan.comparePKs[i].colName)
+		}
+		sourcePKs[i] = col
+	}
+	return sel, sourcePKs, nil
+}
+
+// restrictToPKs restricts the source and target queries of the table to the rows with the given
+// primary keys.
+func (td *tableDiffer) restrictToPKs(sourcePKs []*sqlparser.ColName, pks map[string][]sqltypes.Value) error {
+	var err error
+	if td.tablePlan.sourceQuery, err = restrictQuery(td.tablePlan.sourceQuery, sourcePKs, pks); err != nil {
+		return err
+	}
+	targetPKs := make([]*sqlparser.ColName, len(td.tablePlan.comparePKs))
+	for i, pk := range td.tablePlan.comparePKs {
+		targetPKs[i] = sqlparser.NewColName(pk.colName)
+	}
+	td.tablePlan.targetQuery, err = restrictQuery(td.tablePlan.targetQuery, targetPKs, pks)
+	return err
+}
+
+// streamChangedRows streams the binary logs of a source shard from the given position up to the
+// current position of the source, and calls found with the primary key of every changed row.
+// It returns the position that the changes were read up to.
+func (td *tableDiffer) streamChangedRows(ctx context.Context, sourceTopoServer *topo.Server, shard string, filter *binlogdatapb.Filter,
+	startPos string, found func(pk []sqltypes.Value) error) (string, error) {
+	ct := td.wd.ct
+	tablet, err := pickTablet(ctx, sourceTopoServer, td.wd.opts.PickerOptions.SourceCell, ct.sourceKeyspace, shard, td.wd.opts.PickerOptions.TabletTypes)
+	if err != nil {
+		return "", err
+	}
+	stopPos, err := ct.tmc.PrimaryPosition(ctx, tablet)
+	if err != nil {
+		return "", err
+	}
+	start, err := binlogplayer.DecodePosition(startPos)
+	if err != nil {
+		return "", err
+	}
+	stop, err := binlogplayer.DecodePosition(stopPos)
+	if err != nil {
+		return "", err
+	}
+	if start.AtLeast(stop) {
+		return startPos, nil
+	}
+
+	conn, err := tabletconn.GetDialer()(tablet, false)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close(ctx)
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req := &binlogdatapb.VStreamRequest{
+		Target: &querypb.Target{
+			Keyspace:   tablet.Keyspace,
+			Shard:      shard,
+			TabletType: tablet.Type,
+		},
+		Position: startPos,
+		Filter:   filter,
+	}
+	var fields []*querypb.Field
+	reached := false
+	err = conn.VStream(streamCtx, req, func(events []*binlogdatapb.VEvent) error {
+		for _, event := range events {
+			switch event.Type {
+			case binlogdatapb.VEventType_FIELD:
+				fields = event.FieldEvent.Fields
+			case binlogdatapb.VEventType_ROW:
+				for _, change := range event.RowEvent.RowChanges {
+					for _, row := range []*querypb.Row{change.Before, change.After} {
+						if row == nil {
+							continue
+						}
+						if err := found(sqltypes.MakeRowTrusted(fields, row)); err != nil {
+							return err
+						}
+					}
+				}
+			case binlogdatapb.VEventType_GTID:
+				pos, err := binlogplayer.DecodePosition(event.Gtid)
+				if err != nil {
+					return err
+				}
+				if pos.AtLeast(stop) {
+					reached = true
+					cancel()
+					return nil
+				}
+			}
+		}
+		return nil
+	})
+	if reached {
+		return stopPos, nil
+	}
+	if err == nil {
+		err = fmt.Errorf("stream on tablet %v ended before position %s", tablet.Alias, stopPos)
+	}
+	return "", err
+}
+
+// restrictQuery adds a condition to a select query so that it only returns the rows with the
+// given primary keys.
+func restrictQuery(query string, pkCols []*sqlparser.ColName, pks map[string][]sqltypes.Value) (string, error) {
+	statement, err := sqlparser.Parse(query)
+	if err != nil {
+		return "", err
+	}
+	sel, ok := statement.(*sqlparser.Select)
+	if !ok {
+		return "", fmt.Errorf("unexpected: %v", sqlparser.String(statement))
+	}
+
+	keys := make([]string, 0, len(pks))
+	for key := range pks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf := sqlparser.NewTrackedBuffer(nil)
+	writeTuple := func(n int, write func(i int)) {
+		if n > 1 {
+			buf.WriteByte('(')
+		}
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			write(i)
+		}
+		if n > 1 {
+			buf.WriteByte(')')
+		}
+	}
+	writeTuple(len(pkCols), func(i int) { buf.Myprintf("%v", pkCols[i]) })
+	buf.WriteString(" in (")
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		pk := pks[key]
+		writeTuple(len(pk), func(j int) { pk[j].EncodeSQL(buf) })
+	}
+	buf.WriteByte(')')
+	expr, err := sqlparser.ParseExpr(buf.String())
+	if err != nil {
+		return "", err
+	}
+	sel.AddWhere(expr)
+	return sqlparser.String(sel), nil
+}
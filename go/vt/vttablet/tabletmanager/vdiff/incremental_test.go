@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+func TestRestrictQuery(t *testing.T) {
+	pks := make(map[string][]sqltypes.Value)
+	for _, pk := range [][]sqltypes.Value{
+		{sqltypes.NewInt64(2), sqltypes.NewVarChar("b")},
+		{sqltypes.NewInt64(1), sqltypes.NewVarChar("a'")},
+	} {
+		pks[pkKey(pk)] = pk
+	}
+	query, err := restrictQuery("select c1, c2, c3 from t1 where in_keyrange('-80') order by c1 asc, c2 asc",
+		[]*sqlparser.ColName{sqlparser.NewColName("c1"), sqlparser.NewColName("c2")}, pks)
+	require.NoError(t, err)
+	assert.Equal(t, "select c1, c2, c3 from t1 where in_keyrange('-80') and (c1, c2) in ((1, 'a\\''), (2, 'b')) order by c1 asc, c2 asc", query)
+
+	pks = map[string][]sqltypes.Value{pkKey([]sqltypes.Value{sqltypes.NewInt64(3)}): {sqltypes.NewInt64(3)}}
+	query, err = restrictQuery("select c1, c3 from t1 order by c1 asc", []*sqlparser.ColName{sqlparser.NewColName("c1")}, pks)
+	require.NoError(t, err)
+	assert.Equal(t, "select c1, c3 from t1 where c1 in (3) order by c1 asc", query)
+}
+
+func TestPKKey(t *testing.T) {
+	// Keys must not collide when the values differ but their concatenation is the same.
+	assert.NotEqual(t, pkKey([]sqltypes.Value{sqltypes.NewVarChar("ab"), sqltypes.NewVarChar("c")}),
+		pkKey([]sqltypes.Value{sqltypes.NewVarChar("a"), sqltypes.NewVarChar("bc")}))
+	assert.NotEqual(t, pkKey([]sqltypes.Value{sqltypes.NULL}), pkKey([]sqltypes.Value{sqltypes.NewVarChar("")}))
+}
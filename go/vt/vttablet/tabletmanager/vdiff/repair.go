@@ -0,0 +1,421 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/log"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtctl/schematools"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// A repair makes the rows that did not match in a completed vdiff match again. The primary keys
+// of those rows are taken from the checkpoints of the tables (see incremental.go). The rows are
+// compared again at a new source snapshot, and the target rows that still differ are upserted or
+// deleted while the target vreplication streams are stopped at that snapshot.
+
+// vdiffRepairThrottlerAppName is the throttler app name of the repairs. Throttling vreplication
+// also throttles them.
+const vdiffRepairThrottlerAppName = "vdiff-repair:vreplication"
+
+// repairBatchSize is the number of statements that a repair applies in one transaction.
+var repairBatchSize = 100
+
+// tableRepair is the repair of the rows that did not match in a table.
+type tableRepair struct {
+	// statements make the target rows match the source rows.
+	statements []string
+	// sourceRows are the source rows being repaired, keyed by pkKey.
+	sourceRows map[string][]sqltypes.Value
+	// mismatches is the number of rows that still did not match after the repair.
+	mismatches int
+}
+
+func (vde *Engine) handleRepairAction(ctx context.Context, dbClient binlogplayer.DBClient, action VDiffAction, req *tabletmanagerdatapb.VDiffRequest, resp *tabletmanagerdatapb.VDiffResponse) error {
+	vdiffUUID, err := uuid.Parse(req.ActionArg)
+	if err != nil {
+		return fmt.Errorf("action argument %s not supported", req.ActionArg)
+	}
+	query := fmt.Sprintf(sqlGetVDiffByKeyspaceWorkflowUUID, encodeString(req.Keyspace), encodeString(req.Workflow), encodeString(vdiffUUID.String()))
+	qr, err := dbClient.ExecuteFetch(query, 1)
+	if err != nil {
+		return err
+	}
+	if len(qr.Rows) == 0 {
+		return fmt.Errorf("no vdiff found for UUID %s keyspace %s and workflow %s on tablet %v",
+			vdiffUUID, req.Keyspace, req.Workflow, vde.thisTablet.Alias)
+	}
+	row := qr.Named().Row()
+	if state := VDiffState(strings.ToLower(row.AsString("state", ""))); state != CompletedState {
+		return fmt.Errorf("vdiff %s is %s on tablet %v, only completed vdiffs can be repaired",
+			vdiffUUID, state, vde.thisTablet.Alias)
+	}
+	options := &tabletmanagerdatapb.VDiffOptions{}
+	if err := json.Unmarshal(row.AsBytes("options", []byte("{}")), options); err != nil {
+		return err
+	}
+	if options.CoreOptions == nil {
+		options.CoreOptions = &tabletmanagerdatapb.VDiffCoreOptions{}
+	}
+	options.CoreOptions.DryRun = req.Options.GetCoreOptions().GetDryRun()
+
+	ct := newControllerForRow(row, vde.dbClientFactoryDba, vde.ts, vde, options)
+	if err := ct.loadSources(ctx, dbClient); err != nil {
+		return err
+	}
+	wd, err := newWorkflowDiffer(ct, options)
+	if err != nil {
+		return err
+	}
+	result, err := wd.repair(ctx, dbClient)
+	if err != nil {
+		insertVDiffLog(ctx, dbClient, ct.id, fmt.Sprintf("Repair error: %s", err))
+		return err
+	}
+	resp.Id = ct.id
+	resp.VdiffUuid = vdiffUUID.String()
+	resp.Output = sqltypes.ResultToProto3(result)
+	return nil
+}
+
+// repair repairs the rows that did not match in the tables of the vdiff. When the dry run option
+// is set, it only returns the statements that it would apply.
+func (wd *workflowDiffer) repair(ctx context.Context, dbClient binlogplayer.DBClient) (*sqltypes.Result, error) {
+	ct := wd.ct
+	dryRun := wd.opts.CoreOptions.DryRun
+	schm, err := schematools.GetSchema(ctx, ct.ts, ct.tmc, ct.vde.thisTablet.Alias, &tabletmanagerdatapb.GetSchemaRequest{})
+	if err != nil {
+		return nil, vterrors.Wrap(err, "GetSchema")
+	}
+	if err := wd.buildPlan(dbClient, ct.filter, schm); err != nil {
+		return nil, vterrors.Wrap(err, "buildPlan")
+	}
+
+	result := &sqltypes.Result{
+		Fields: []*querypb.Field{
+			{Name: "table_name", Type: sqltypes.VarChar},
+			{Name: "rows_repaired", Type: sqltypes.Int64},
+			{Name: "rows_mismatched", Type: sqltypes.Int64},
+		},
+	}
+	if dryRun {
+		result.Fields = []*querypb.Field{
+			{Name: "table_name", Type: sqltypes.VarChar},
+			{Name: "statement", Type: sqltypes.VarChar},
+		}
+	}
+	tables := make([]string, 0, len(wd.tableDiffers))
+	for table := range wd.tableDiffers {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	// The rows to repair are read for all the tables first, so that no table is repaired
+	// when the rows of another one can't be.
+	tablePKs := make(map[string]map[string][]sqltypes.Value, len(tables))
+	for _, table := range tables {
+		pks, err := wd.tableDiffers[table].repairPKs(dbClient)
+		if err != nil {
+			return nil, err
+		}
+		tablePKs[table] = pks
+	}
+	for _, table := range tables {
+		select {
+		case <-ctx.Done():
+			return nil, vterrors.Errorf(vtrpcpb.Code_CANCELED, "context has expired")
+		default:
+		}
+		pks := tablePKs[table]
+		if len(pks) == 0 {
+			continue
+		}
+		td := wd.tableDiffers[table]
+		log.Infof("Repairing %d rows of table %s for vdiff %s", len(pks), table, ct.uuid)
+		// The whole table is not compared again, only the rows that did not match.
+		td.lastPK = nil
+		repair, err := td.repair(ctx, pks, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		if dryRun {
+			for _, statement := range repair.statements {
+				result.Rows = append(result.Rows, []sqltypes.Value{sqltypes.NewVarChar(table), sqltypes.NewVarChar(statement)})
+			}
+			continue
+		}
+		insertVDiffLog(ctx, dbClient, ct.id, fmt.Sprintf("Repaired %d rows of table %s, %d rows still do not match",
+			len(repair.statements), table, repair.mismatches))
+		result.Rows = append(result.Rows, []sqltypes.Value{
+			sqltypes.NewVarChar(table),
+			sqltypes.NewInt64(int64(len(repair.statements))),
+			sqltypes.NewInt64(int64(repair.mismatches)),
+		})
+	}
+	return result, nil
+}
+
+// repairPKs returns the primary keys of the rows of the table that did not match in the vdiff,
+// keyed by pkKey. It returns an error when they are not all recorded for the vdiff, rather than
+// repairing only some of them.
+func (td *tableDiffer) repairPKs(dbClient binlogplayer.DBClient) (map[string][]sqltypes.Value, error) {
+	ct := td.wd.ct
+	qr, err := dbClient.ExecuteFetch(fmt.Sprintf(sqlGetVDiffTable, ct.id, encodeString(td.table.Name)), 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(qr.Rows) == 0 || !qr.Named().Row().AsBool("mismatch", false) {
+		return nil, nil
+	}
+	checkpoint, err := td.getCheckpoint(dbClient)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case checkpoint == nil:
+		return nil, fmt.Errorf("cannot repair table %s: the rows that did not match in vdiff %s are not recorded on tablet %v, they are only recorded when the table was compared in full and at most %d rows did not match",
+			td.table.Name, ct.uuid, ct.vde.thisTablet.Alias, incrementalDiffMaxRows)
+	case checkpoint.vdiffID != ct.id:
+		return nil, fmt.Errorf("cannot repair table %s: it was diffed again after vdiff %s on tablet %v, only the most recent vdiff of a table can be repaired",
+			td.table.Name, ct.uuid, ct.vde.thisTablet.Alias)
+	case len(checkpoint.mismatchedPKs) == 0:
+		return nil, fmt.Errorf("cannot repair table %s: no rows that did not match are recorded for vdiff %s on tablet %v",
+			td.table.Name, ct.uuid, ct.vde.thisTablet.Alias)
+	}
+	pks := make(map[string][]sqltypes.Value, len(checkpoint.mismatchedPKs))
+	for _, pk := range checkpoint.mismatchedPKs {
+		pks[pkKey(pk)] = pk
+	}
+	return pks, nil
+}
+
+// repair compares the rows with the given primary keys on the source and the target, and builds
+// the statements that make the target rows match the source rows. Unless dryRun is set, it applies
+// them while the target streams are stopped, and compares the rows again. The statements are
+// applied with the connection of the vreplication streams.
+func (td *tableDiffer) repair(ctx context.Context, pks map[string][]sqltypes.Value, dryRun bool) (*tableRepair, error) {
+	if td.wd.ct.sourceTimeZone != "" {
+		return nil, fmt.Errorf("cannot repair table %s: its datetime columns are converted to another time zone", td.table.Name)
+	}
+	_, sourcePKs, err := td.sourcePKColumns()
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot repair table %s", td.table.Name)
+	}
+	if err := td.restrictToPKs(sourcePKs, pks); err != nil {
+		return nil, err
+	}
+	repair := &tableRepair{sourceRows: make(map[string][]sqltypes.Value)}
+	err = td.initialize(ctx, func(ctx context.Context) error {
+		if err := td.buildRepair(ctx, repair); err != nil {
+			return err
+		}
+		if dryRun || len(repair.statements) == 0 {
+			return nil
+		}
+		dbClient := td.wd.ct.vde.vre.NewDBClient()
+		if err := dbClient.Connect(); err != nil {
+			return err
+		}
+		defer dbClient.Close()
+		if err := td.applyRepair(ctx, dbClient, repair.statements); err != nil {
+			return err
+		}
+		return td.verifyRepair(dbClient, repair)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repair, nil
+}
+
+// rowPK returns the primary key values of a row.
+func (td *tableDiffer) rowPK(row []sqltypes.Value) []sqltypes.Value {
+	pk := make([]sqltypes.Value, len(td.tablePlan.pkCols))
+	for i, colIndex := range td.tablePlan.pkCols {
+		pk[i] = row[colIndex]
+	}
+	return pk
+}
+
+func (td *tableDiffer) buildRepair(ctx context.Context, repair *tableRepair) error {
+	sourceExecutor := newPrimitiveExecutor(ctx, td.sourcePrimitive, "source")
+	targetExecutor := newPrimitiveExecutor(ctx, td.targetPrimitive, "target")
+	var sourceRow, targetRow []sqltypes.Value
+	var err error
+	advanceSource := true
+	advanceTarget := true
+	for {
+		if advanceSource {
+			if sourceRow, err = sourceExecutor.next(); err != nil {
+				return err
+			}
+		}
+		if advanceTarget {
+			if targetRow, err = targetExecutor.next(); err != nil {
+				return err
+			}
+		}
+		if sourceRow == nil && targetRow == nil {
+			return nil
+		}
+		advanceSource = true
+		advanceTarget = true
+
+		var c int
+		switch {
+		case sourceRow == nil:
+			c = 1
+		case targetRow == nil:
+			c = -1
+		default:
+			if c, err = td.compare(sourceRow, targetRow, td.tablePlan.comparePKs, false); err != nil {
+				return err
+			}
+		}
+		switch {
+		case c < 0:
+			repair.sourceRows[pkKey(td.rowPK(sourceRow))] = sourceRow
+			repair.statements = append(repair.statements, td.upsertStatement(sourceRow))
+			advanceTarget = false
+		case c > 0:
+			repair.statements = append(repair.statements, td.deleteStatement(targetRow))
+			advanceSource = false
+		default:
+			repair.sourceRows[pkKey(td.rowPK(sourceRow))] = sourceRow
+			if c, err = td.compare(sourceRow, targetRow, td.tablePlan.compareCols, true); err != nil {
+				return err
+			}
+			if c != 0 {
+				repair.statements = append(repair.statements, td.upsertStatement(sourceRow))
+			}
+		}
+	}
+}
+
+// upsertStatement returns the statement that inserts a source row into the target, or updates
+// the target row with the same primary key.
+func (td *tableDiffer) upsertStatement(row []sqltypes.Value) string {
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.Myprintf("insert into %v(", sqlparser.NewIdentifierCS(td.table.Name))
+	for i, col := range td.tablePlan.compareCols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.Myprintf("%v", sqlparser.NewIdentifierCI(col.colName))
+	}
+	buf.WriteString(") values (")
+	for i, col := range td.tablePlan.compareCols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		row[col.colIndex].EncodeSQL(buf)
+	}
+	buf.WriteString(") on duplicate key update ")
+	for i, col := range td.tablePlan.compareCols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		colName := sqlparser.NewIdentifierCI(col.colName)
+		buf.Myprintf("%v = values(%v)", colName, colName)
+	}
+	return buf.String()
+}
+
+// deleteStatement returns the statement that deletes a target row.
+func (td *tableDiffer) deleteStatement(row []sqltypes.Value) string {
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.Myprintf("delete from %v where ", sqlparser.NewIdentifierCS(td.table.Name))
+	for i, pk := range td.tablePlan.comparePKs {
+		if i > 0 {
+			buf.WriteString(" and ")
+		}
+		buf.Myprintf("%v = ", sqlparser.NewIdentifierCI(pk.colName))
+		row[pk.colIndex].EncodeSQL(buf)
+	}
+	return buf.String()
+}
+
+// applyRepair applies the repair statements in batches, waiting for the throttler before each batch.
+func (td *tableDiffer) applyRepair(ctx context.Context, dbClient binlogplayer.DBClient, statements []string) error {
+	vre := td.wd.ct.vde.vre
+	for len(statements) > 0 {
+		for !vre.ThrottleCheckOKOrWait(ctx, vdiffRepairThrottlerAppName) {
+			select {
+			case <-ctx.Done():
+				return vterrors.Errorf(vtrpcpb.Code_CANCELED, "context has expired")
+			default:
+			}
+		}
+		batch := statements
+		if len(batch) > repairBatchSize {
+			batch = batch[:repairBatchSize]
+		}
+		statements = statements[len(batch):]
+		if err := dbClient.Begin(); err != nil {
+			return err
+		}
+		for _, statement := range batch {
+			if _, err := dbClient.ExecuteFetch(statement, 1); err != nil {
+				_ = dbClient.Rollback()
+				return vterrors.Wrapf(err, "repairing table %s", td.table.Name)
+			}
+		}
+		if err := dbClient.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyRepair compares the repaired target rows with the source rows, and counts the rows that
+// still do not match.
+func (td *tableDiffer) verifyRepair(dbClient binlogplayer.DBClient, repair *tableRepair) error {
+	qr, err := dbClient.ExecuteFetch(td.tablePlan.targetQuery, -1)
+	if err != nil {
+		return err
+	}
+	found := 0
+	for _, targetRow := range qr.Rows {
+		sourceRow, ok := repair.sourceRows[pkKey(td.rowPK(targetRow))]
+		if !ok {
+			repair.mismatches++
+			continue
+		}
+		found++
+		c, err := td.compare(sourceRow, targetRow, td.tablePlan.compareCols, true)
+		if err != nil {
+			return err
+		}
+		if c != 0 {
+			repair.mismatches++
+		}
+	}
+	repair.mismatches += len(repair.sourceRows) - found
+	return nil
+}
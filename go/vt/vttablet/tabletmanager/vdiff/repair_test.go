@@ -0,0 +1,233 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+)
+
+func TestRepairStatements(t *testing.T) {
+	pk := compareColInfo{colIndex: 0, isPK: true, colName: "id"}
+	td := &tableDiffer{
+		table: &tabletmanagerdatapb.TableDefinition{Name: "t1"},
+		tablePlan: &tablePlan{
+			compareCols: []compareColInfo{pk, {colIndex: 1, colName: "val"}, {colIndex: 2, colName: "desc"}},
+			comparePKs:  []compareColInfo{pk},
+			pkCols:      []int{0},
+		},
+	}
+	row := []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewVarChar("it's"), sqltypes.NULL}
+	assert.Equal(t, "insert into t1(id, val, `desc`) values (1, 'it\\'s', null) on duplicate key update id = values(id), val = values(val), `desc` = values(`desc`)",
+		td.upsertStatement(row))
+	assert.Equal(t, "delete from t1 where id = 1", td.deleteStatement(row))
+	assert.Equal(t, []sqltypes.Value{sqltypes.NewInt64(1)}, td.rowPK(row))
+}
+
+const testVDiffUUID = "2c5a5b1e-4a56-11ed-9a1e-0a43f95f28a3"
+
+var repairTestFields = sqltypes.MakeTestFields("id|val", "int64|varchar")
+
+// setupRepair sets up the test env for the repair of a completed vdiff with the id 1, of the
+// tables t1 and t2. The rows of t1 with the given primary keys did not match.
+func setupRepair(t *testing.T, te *testEnv, mismatchedPKs ...string) {
+	te.schema = &tabletmanagerdatapb.SchemaDefinition{}
+	for _, table := range []string{"t1", "t2"} {
+		te.schema.TableDefinitions = append(te.schema.TableDefinitions, &tabletmanagerdatapb.TableDefinition{
+			Name:              table,
+			Columns:           []string{"id", "val"},
+			PrimaryKeyColumns: []string{"id"},
+			Fields:            repairTestFields,
+		})
+	}
+
+	options := `{"picker_options":{"source_cell":"cell1","target_cell":"cell1","tablet_types":"replica"},"core_options":{"timeout_seconds":60}}`
+	te.dbClient.setResult(fmt.Sprintf(sqlGetVDiffByKeyspaceWorkflowUUID, encodeString(testTargetKeyspace), encodeString(testWorkflow), encodeString(testVDiffUUID)),
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields("id|vdiff_uuid|workflow|keyspace|state|options", "int64|varchar|varchar|varchar|varchar|varbinary"),
+			fmt.Sprintf("1|%s|%s|%s|completed|%s", testVDiffUUID, testWorkflow, testTargetKeyspace, options)))
+
+	source, err := prototext.Marshal(&binlogdatapb.BinlogSource{
+		Keyspace: testSourceKeyspace,
+		Shard:    testShard,
+		Filter:   &binlogdatapb.Filter{Rules: []*binlogdatapb.Rule{{Match: "/.*"}}},
+	})
+	require.NoError(t, err)
+	workflowFilter := fmt.Sprintf("where workflow = %s and db_name = %s", encodeString(testWorkflow), encodeString(testDBName))
+	streams := sqltypes.MakeTestResult(sqltypes.MakeTestFields("id|source|pos", "int64|varbinary|varbinary"),
+		fmt.Sprintf("1|%s|%s", source, testPosition))
+	te.dbClient.setResult(fmt.Sprintf(sqlGetVReplicationEntry, workflowFilter), streams)
+	te.dbClient.setResult("select id, source, pos from _vt.vreplication "+workflowFilter, streams)
+	te.vreDBClient.setResult(binlogplayer.ReadVReplicationStatus(1),
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields("pos|state|message", "varbinary|varbinary|varbinary"), testPosition+"|Running|"))
+
+	tableFields := sqltypes.MakeTestFields("lastpk|mismatch|report", "varbinary|int64|varbinary")
+	te.dbClient.setResult(fmt.Sprintf(sqlGetVDiffTable, 1, encodeString("t1")), sqltypes.MakeTestResult(tableFields, "|1|{}"))
+	te.dbClient.setResult(fmt.Sprintf(sqlGetVDiffTable, 1, encodeString("t2")), sqltypes.MakeTestResult(tableFields, "|0|{}"))
+	setCheckpoint(t, te, "t1", 1, mismatchedPKs...)
+}
+
+// setCheckpoint records the checkpoint of a table in the test env.
+func setCheckpoint(t *testing.T, te *testEnv, table string, vdiffID int64, pks ...string) {
+	mismatchedPKs, err := prototext.Marshal(sqltypes.ResultToProto3(sqltypes.MakeTestResult(repairTestFields[:1], pks...)))
+	require.NoError(t, err)
+	te.dbClient.setResult(fmt.Sprintf(sqlGetVDiffCheckpoint, encodeString(testDBName), encodeString(testWorkflow), encodeString(table)),
+		sqltypes.MakeTestResult(sqltypes.MakeTestFields("vdiff_id|positions|mismatched_pks", "int64|varbinary|varbinary"),
+			fmt.Sprintf("%d|{}|%s", vdiffID, mismatchedPKs)))
+}
+
+func repairRequest(dryRun bool) *tabletmanagerdatapb.VDiffRequest {
+	return &tabletmanagerdatapb.VDiffRequest{
+		Keyspace:  testTargetKeyspace,
+		Workflow:  testWorkflow,
+		Action:    string(RepairAction),
+		ActionArg: testVDiffUUID,
+		Options:   &tabletmanagerdatapb.VDiffOptions{CoreOptions: &tabletmanagerdatapb.VDiffCoreOptions{DryRun: dryRun}},
+	}
+}
+
+// repairWrites returns the queries of the repair, which are run on the vreplication connection.
+func repairWrites(dbClient *fakeDBClient) []string {
+	var writes []string
+	for _, query := range dbClient.getQueries() {
+		switch {
+		case query == "begin", query == "commit", query == "rollback",
+			strings.HasPrefix(query, "insert into t1"), strings.HasPrefix(query, "delete from t1"), strings.HasPrefix(query, "select id, val from t1"):
+			writes = append(writes, query)
+		}
+	}
+	return writes
+}
+
+func TestRepair(t *testing.T) {
+	ctx := context.Background()
+	defer func(batchSize int) { repairBatchSize = batchSize }(repairBatchSize)
+	repairBatchSize = 2
+
+	const (
+		targetQuery = "select id, val from t1 where id in (1, 2, 3) order by id asc"
+		upsert1     = "insert into t1(id, val) values (1, 'a') on duplicate key update id = values(id), val = values(val)"
+		upsert2     = "insert into t1(id, val) values (2, 'b') on duplicate key update id = values(id), val = values(val)"
+		delete3     = "delete from t1 where id = 3"
+	)
+	newRepairEnv := func(t *testing.T) *testEnv {
+		te := newTestEnv(t)
+		setupRepair(t, te, "1", "2", "3")
+		// Row 1 is missing on the target, row 2 differs, and row 3 is missing on the source.
+		te.setRows(testSourceKeyspace, sqltypes.MakeTestResult(repairTestFields, "1|a", "2|b"))
+		te.setRows(testTargetKeyspace, sqltypes.MakeTestResult(repairTestFields, "2|x", "3|c"))
+		return te
+	}
+
+	t.Run("repair", func(t *testing.T) {
+		te := newRepairEnv(t)
+		te.vreDBClient.setResult(targetQuery, sqltypes.MakeTestResult(repairTestFields, "1|a", "2|x"))
+
+		resp, err := te.vde.PerformVDiffAction(ctx, repairRequest(false))
+		require.NoError(t, err)
+		assert.Equal(t, testVDiffUUID, resp.VdiffUuid)
+		result := sqltypes.Proto3ToResult(resp.Output)
+		require.Len(t, result.Rows, 1)
+		assert.Equal(t, `[VARCHAR("t1") INT64(3) INT64(1)]`, fmt.Sprintf("%v", result.Rows[0]))
+
+		// Only the rows that did not match are compared again.
+		assert.Equal(t, []string{"select id, val from t1 where id in (1, 2, 3) order by id asc"}, te.getStreamQueries(testSourceKeyspace))
+		assert.Equal(t, []string{targetQuery}, te.getStreamQueries(testTargetKeyspace))
+		// The repair is applied in batches on the vreplication connection, and verified.
+		assert.Equal(t, []string{"begin", upsert1, upsert2, "commit", "begin", delete3, "commit", targetQuery}, repairWrites(te.vreDBClient))
+		assert.Empty(t, repairWrites(te.dbClient))
+		// The target streams are restarted once the repair is verified.
+		vreplicationQueries := te.getVReplicationQueries()
+		require.NotEmpty(t, vreplicationQueries)
+		assert.Contains(t, vreplicationQueries[len(vreplicationQueries)-1], "set state='Running', message='', stop_pos=''")
+		assert.Contains(t, te.dbClient.getQueries(), "insert into _vt.vdiff_log(vdiff_id, message) values (1, 'Repaired 3 rows of table t1, 1 rows still do not match')")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		te := newRepairEnv(t)
+
+		resp, err := te.vde.PerformVDiffAction(ctx, repairRequest(true))
+		require.NoError(t, err)
+		result := sqltypes.Proto3ToResult(resp.Output)
+		var statements []string
+		for _, row := range result.Rows {
+			assert.Equal(t, "t1", row[0].ToString())
+			statements = append(statements, row[1].ToString())
+		}
+		assert.Equal(t, []string{upsert1, upsert2, delete3}, statements)
+		assert.Empty(t, repairWrites(te.vreDBClient))
+	})
+
+	t.Run("statement fails", func(t *testing.T) {
+		te := newRepairEnv(t)
+		te.vreDBClient.setError(upsert2, fmt.Errorf("duplicate entry"))
+
+		_, err := te.vde.PerformVDiffAction(ctx, repairRequest(false))
+		require.ErrorContains(t, err, "repairing table t1: duplicate entry")
+		assert.Equal(t, []string{"begin", upsert1, upsert2, "rollback"}, repairWrites(te.vreDBClient))
+	})
+
+	errorTests := []struct {
+		name  string
+		setup func(t *testing.T, te *testEnv)
+		err   string
+	}{{
+		name: "no checkpoint",
+		setup: func(t *testing.T, te *testEnv) {
+			te.dbClient.setResult(fmt.Sprintf(sqlGetVDiffCheckpoint, encodeString(testDBName), encodeString(testWorkflow), encodeString("t1")), &sqltypes.Result{})
+		},
+		err: "cannot repair table t1: the rows that did not match in vdiff " + testVDiffUUID + " are not recorded",
+	}, {
+		name:  "diffed again",
+		setup: func(t *testing.T, te *testEnv) { setCheckpoint(t, te, "t1", 2, "1") },
+		err:   "cannot repair table t1: it was diffed again after vdiff " + testVDiffUUID,
+	}, {
+		name:  "no mismatched rows",
+		setup: func(t *testing.T, te *testEnv) { setCheckpoint(t, te, "t1", 1) },
+		err:   "cannot repair table t1: no rows that did not match are recorded for vdiff " + testVDiffUUID,
+	}, {
+		// No table is repaired when the rows of another table can't be.
+		name: "other table not recorded",
+		setup: func(t *testing.T, te *testEnv) {
+			te.dbClient.setResult(fmt.Sprintf(sqlGetVDiffTable, 1, encodeString("t2")),
+				sqltypes.MakeTestResult(sqltypes.MakeTestFields("lastpk|mismatch|report", "varbinary|int64|varbinary"), "|1|{}"))
+		},
+		err: "cannot repair table t2: the rows that did not match in vdiff " + testVDiffUUID + " are not recorded",
+	}}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := newRepairEnv(t)
+			tt.setup(t, te)
+
+			_, err := te.vde.PerformVDiffAction(ctx, repairRequest(false))
+			require.ErrorContains(t, err, tt.err)
+			assert.Empty(t, te.getStreamQueries(testSourceKeyspace))
+			assert.Empty(t, repairWrites(te.vreDBClient))
+		})
+	}
+}
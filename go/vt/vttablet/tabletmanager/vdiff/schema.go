@@ -39,6 +39,8 @@ func init() {
 		`ALTER TABLE _vt.vdiff_table MODIFY COLUMN lastpk varbinary(2000)`,
 		`ALTER TABLE _vt.vdiff_table MODIFY COLUMN table_rows bigint not null default 0`,
 		`ALTER TABLE _vt.vdiff_table MODIFY COLUMN rows_compared bigint not null default 0`,
+		`ALTER TABLE _vt.vdiff_table ADD COLUMN positions json`,
+		sqlCreateVDiffCheckpointTable,
 	)
 	withDDL = withddl.New(ddls)
 }
@@ -81,6 +83,18 @@ const (
 		message text NOT NULL,
 		primary key (id)) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
 
+	// sqlCreateVDiffCheckpointTable holds, for each table of a workflow, the source positions
+	// of its last completed diff and the primary keys of the rows that did not match then.
+	sqlCreateVDiffCheckpointTable = `CREATE TABLE IF NOT EXISTS _vt.vdiff_checkpoint (
+		db_name varbinary(255) NOT NULL,
+		workflow varbinary(255) NOT NULL,
+		table_name varbinary(128) NOT NULL,
+		vdiff_id bigint NOT NULL,
+		positions json,
+		mismatched_pks longblob,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		primary key (db_name, workflow, table_name)) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+
 	sqlNewVDiff    = "insert into _vt.vdiff(keyspace, workflow, state, options, shard, db_name, vdiff_uuid) values(%s, %s, '%s', %s, '%s', '%s', '%s')"
 	sqlResumeVDiff = `update _vt.vdiff as vd, _vt.vdiff_table as vdt set vd.options = %s, vd.started_at = NULL, vd.completed_at = NULL, vd.state = 'pending',
 					vdt.state = 'pending' where vd.vdiff_uuid = %s and vd.id = vdt.vdiff_id and vd.state in ('completed', 'stopped')
@@ -124,5 +138,16 @@ const (
 	sqlUpdateTableStateAndReport = "update _vt.vdiff_table set state = %s, rows_compared = %d, report = %s where vdiff_id = %d and table_name = %s"
 	sqlUpdateTableMismatch       = "update _vt.vdiff_table set mismatch = true where vdiff_id = %d and table_name = %s"
 
+	// sqlUpdateTablePositions only sets the positions on the first run of a table diff, so that
+	// they are kept when the diff is resumed.
+	sqlUpdateTablePositions = "update _vt.vdiff_table set positions = %s where vdiff_id = %d and table_name = %s and positions is null"
+	sqlGetTablePositions    = "select positions as positions from _vt.vdiff_table where vdiff_id = %d and table_name = %s"
+
+	sqlGetVDiffCheckpoint  = "select vdiff_id as vdiff_id, positions as positions, mismatched_pks as mismatched_pks from _vt.vdiff_checkpoint where db_name = %s and workflow = %s and table_name = %s"
+	sqlSaveVDiffCheckpoint = `insert into _vt.vdiff_checkpoint(db_name, workflow, table_name, vdiff_id, positions, mismatched_pks) values (%s, %s, %s, %d, %s, %s)
+							on duplicate key update vdiff_id = values(vdiff_id), positions = values(positions), mismatched_pks = values(mismatched_pks)`
+	sqlDeleteVDiffCheckpoint  = "delete from _vt.vdiff_checkpoint where db_name = %s and workflow = %s and table_name = %s"
+	sqlDeleteVDiffCheckpoints = "delete from _vt.vdiff_checkpoint where db_name = %s and workflow = %s"
+
 	sqlGetIncompleteTables = "select table_name as table_name from _vt.vdiff_table where vdiff_id = %d and state != 'completed'"
 )
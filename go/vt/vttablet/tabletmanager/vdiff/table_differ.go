@@ -69,14 +69,22 @@ type tableDiffer struct {
 	sourceQuery string
 	table       *tabletmanagerdatapb.TableDefinition
 	lastPK      *querypb.QueryResult
+
+	// incremental is set when only the rows changed since the last diff of the table are compared.
+	incremental *incrementalDiff
+	// mismatchedPKs has the primary keys of the rows that did not match, keyed by pkKey. It is
+	// nil when they are not all known.
+	mismatchedPKs map[string][]sqltypes.Value
 }
 
 func newTableDiffer(wd *workflowDiffer, table *tabletmanagerdatapb.TableDefinition, sourceQuery string) *tableDiffer {
 	return &tableDiffer{wd: wd, table: table, sourceQuery: sourceQuery}
 }
 
-// initialize
-func (td *tableDiffer) initialize(ctx context.Context) error {
+// initialize starts the data streams of the table on the source and target tablets. When
+// whileStopped is set, it is called once the streams started and before the target vreplication
+// streams are restarted, so that the target does not change while it runs.
+func (td *tableDiffer) initialize(ctx context.Context, whileStopped func(ctx context.Context) error) error {
 	vdiffEngine := td.wd.ct.vde
 	vdiffEngine.snapshotMu.Lock()
 	defer vdiffEngine.snapshotMu.Unlock()
@@ -128,6 +136,9 @@ func (td *tableDiffer) initialize(ctx context.Context) error {
 		return err
 	}
 	td.setupRowSorters()
+	if whileStopped != nil {
+		return whileStopped(ctx)
+	}
 	return nil
 }
 
@@ -195,15 +206,9 @@ func (td *tableDiffer) selectTablets(ctx context.Context, cell, tabletTypes stri
 	ct := td.wd.ct
 	var err1, err2 error
 
-	// For Mount+Migrate, the source tablets will be in a different
-	// Vitess cluster with its own TopoServer.
-	sourceTopoServer := ct.ts
-	if ct.externalCluster != "" {
-		extTS, err := ct.ts.OpenExternalVitessClusterServer(ctx, ct.externalCluster)
-		if err != nil {
-			return err
-		}
-		sourceTopoServer = extTS
+	sourceTopoServer, err := ct.sourceTopoServer(ctx)
+	if err != nil {
+		return err
 	}
 	wg.Add(1)
 	go func() {
@@ -444,6 +449,11 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare *int64, debug, on
 		}
 	}
 	dr.TableName = td.table.Name
+	td.mismatchedPKs = make(map[string][]sqltypes.Value)
+	if td.lastPK != nil && (dr.MismatchedRows > 0 || dr.ExtraRowsSource > 0 || dr.ExtraRowsTarget > 0) {
+		// The rows that did not match before the diff was resumed are not known.
+		td.mismatchedPKs = nil
+	}
 
 	sourceExecutor := newPrimitiveExecutor(ctx, td.sourcePrimitive, "source")
 	targetExecutor := newPrimitiveExecutor(ctx, td.targetPrimitive, "target")
@@ -506,9 +516,10 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare *int64, debug, on
 				return nil, vterrors.Wrap(err, "unexpected error generating diff")
 			}
 			dr.ExtraRowsTargetDiffs = append(dr.ExtraRowsTargetDiffs, diffRow)
+			td.trackMismatch(targetRow)
 
 			// drain target, update count
-			count, err := targetExecutor.drain(ctx)
+			count, err := targetExecutor.drain(ctx, td.trackMismatch)
 			if err != nil {
 				return nil, err
 			}
@@ -524,7 +535,8 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare *int64, debug, on
 				return nil, vterrors.Wrap(err, "unexpected error generating diff")
 			}
 			dr.ExtraRowsSourceDiffs = append(dr.ExtraRowsSourceDiffs, diffRow)
-			count, err := sourceExecutor.drain(ctx)
+			td.trackMismatch(sourceRow)
+			count, err := sourceExecutor.drain(ctx, td.trackMismatch)
 			if err != nil {
 				return nil, err
 			}
@@ -549,6 +561,7 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare *int64, debug, on
 				dr.ExtraRowsSourceDiffs = append(dr.ExtraRowsSourceDiffs, diffRow)
 			}
 			dr.ExtraRowsSource++
+			td.trackMismatch(sourceRow)
 			advanceTarget = false
 			continue
 		case c > 0:
@@ -560,6 +573,7 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare *int64, debug, on
 				dr.ExtraRowsTargetDiffs = append(dr.ExtraRowsTargetDiffs, diffRow)
 			}
 			dr.ExtraRowsTarget++
+			td.trackMismatch(targetRow)
 			advanceSource = false
 			continue
 		}
@@ -584,6 +598,7 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare *int64, debug, on
 				dr.MismatchedRowsDiffs = append(dr.MismatchedRowsDiffs, &DiffMismatch{Source: sourceDiffRow, Target: targetDiffRow})
 			}
 			dr.MismatchedRows++
+			td.trackMismatch(sourceRow)
 		default:
 			dr.MatchingRows++
 		}
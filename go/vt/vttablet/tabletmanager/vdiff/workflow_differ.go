@@ -24,6 +24,7 @@ import (
 
 	"google.golang.org/protobuf/encoding/prototext"
 
+	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/binlog/binlogplayer"
 
 	"vitess.io/vitess/go/vt/schema"
@@ -104,10 +105,29 @@ func (wd *workflowDiffer) diffTable(ctx context.Context, dbClient binlogplayer.D
 	if err := td.updateTableState(ctx, dbClient, StartedState); err != nil {
 		return err
 	}
-	if err := td.initialize(ctx); err != nil {
+	if wd.opts.CoreOptions.Incremental {
+		if err := td.prepareIncremental(ctx, dbClient); err != nil {
+			return err
+		}
+	}
+	if td.incremental != nil && len(td.incremental.pks) == 0 {
+		return wd.completeUnchangedTable(ctx, dbClient, td)
+	}
+	if err := td.initialize(ctx, nil); err != nil {
 		return err
 	}
 	log.Infof("Table initialization done on table %s for vdiff %s", td.table.Name, wd.ct.uuid)
+	positions := make(map[string]string, len(wd.ct.sources))
+	if td.incremental != nil {
+		positions = td.incremental.positions
+	} else {
+		for shard, source := range wd.ct.sources {
+			positions[shard] = source.snapshotPosition
+		}
+	}
+	if err := td.updateTablePositions(dbClient, positions); err != nil {
+		return err
+	}
 	dr, err := td.diff(ctx, &wd.opts.CoreOptions.MaxRows, wd.opts.ReportOptions.DebugQuery, false, wd.opts.CoreOptions.MaxExtraRowsToCompare)
 	if err != nil {
 		log.Errorf("Encountered an error diffing table %s for vdiff %s: %v", td.table.Name, wd.ct.uuid, err)
@@ -128,7 +148,25 @@ func (wd *workflowDiffer) diffTable(ctx context.Context, dbClient binlogplayer.D
 	if err := td.updateTableStateAndReport(ctx, dbClient, CompletedState, dr); err != nil {
 		return err
 	}
-	return nil
+	return td.saveCheckpoint(dbClient)
+}
+
+// completeUnchangedTable completes the incremental diff of a table with no rows changed
+// since its last diff, and no rows that did not match then.
+func (wd *workflowDiffer) completeUnchangedTable(ctx context.Context, dbClient binlogplayer.DBClient, td *tableDiffer) error {
+	log.Infof("No rows changed in table %s since its last diff for vdiff %s", td.table.Name, wd.ct.uuid)
+	if err := td.updateTablePositions(dbClient, td.incremental.positions); err != nil {
+		return err
+	}
+	// A resumed diff keeps its report.
+	if td.lastPK == nil {
+		dr := &DiffReport{TableName: td.table.Name}
+		if err := td.updateTableStateAndReport(ctx, dbClient, CompletedState, dr); err != nil {
+			return err
+		}
+	}
+	td.mismatchedPKs = make(map[string][]sqltypes.Value)
+	return td.saveCheckpoint(dbClient)
 }
 
 func (wd *workflowDiffer) diff(ctx context.Context) error {
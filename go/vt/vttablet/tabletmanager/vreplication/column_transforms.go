@@ -0,0 +1,386 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/sqltypes"
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// ColumnTransform transforms the values of a column before they are
+// written to the target, in both the copy and the replication phase.
+// Transform is never called for NULL values. Transforms must be
+// deterministic: the same input must always produce the same output,
+// so that rows copied and rows replicated converge to the same values.
+type ColumnTransform interface {
+	Transform(val sqltypes.Value) (sqltypes.Value, error)
+}
+
+// ColumnTransformFactory creates a ColumnTransform from the arguments
+// specified for a column in the workflow.
+type ColumnTransformFactory func(args map[string]string) (ColumnTransform, error)
+
+var columnTransformFactories = make(map[string]ColumnTransformFactory)
+
+// RegisterColumnTransform registers a column transform under the given
+// name. It is meant to be called from init functions, and panics if a
+// transform with the same name was already registered.
+func RegisterColumnTransform(name string, factory ColumnTransformFactory) {
+	if _, ok := columnTransformFactories[name]; ok {
+		panic(fmt.Sprintf("column transform %s is already registered", name))
+	}
+	columnTransformFactories[name] = factory
+}
+
+func init() {
+	RegisterColumnTransform("hash", newHashTransform)
+	RegisterColumnTransform("redact", newRedactTransform)
+	RegisterColumnTransform("tokenize", newTokenizeTransform)
+	RegisterColumnTransform("truncate_date", newTruncateDateTransform)
+	RegisterColumnTransform("json_remove", newJSONRemoveTransform)
+}
+
+// newColumnTransforms builds the transforms specified in a rule. The
+// returned map is keyed by target column name.
+func newColumnTransforms(specs map[string]*binlogdatapb.ColumnTransform) (map[string]ColumnTransform, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	transforms := make(map[string]ColumnTransform, len(specs))
+	for colName, spec := range specs {
+		factory, ok := columnTransformFactories[spec.GetName()]
+		if !ok {
+			return nil, fmt.Errorf("column transform for %s: unknown transform %q", colName, spec.GetName())
+		}
+		transform, err := factory(spec.GetArgs())
+		if err != nil {
+			return nil, fmt.Errorf("column transform for %s: %v", colName, err)
+		}
+		transforms[colName] = transform
+	}
+	return transforms, nil
+}
+
+// bindColumnTransforms maps the transforms of target columns to the fields
+// streamed from the source, which is how TablePlan looks them up. Only
+// columns that are selected as is can be transformed, and primary key
+// columns cannot be transformed because they identify the rows to change.
+func (tpb *tablePlanBuilder) bindColumnTransforms(transforms map[string]ColumnTransform, pkrefs []string) (map[string]ColumnTransform, error) {
+	if len(transforms) == 0 {
+		return nil, nil
+	}
+	bound := make(map[string]ColumnTransform, len(transforms))
+	for colName, transform := range transforms {
+		cexpr := tpb.findCol(sqlparser.NewIdentifierCI(colName))
+		if cexpr == nil {
+			return nil, fmt.Errorf("column transform for %s: column not found in table %s", colName, tpb.name.String())
+		}
+		col, ok := cexpr.expr.(*sqlparser.ColName)
+		if !ok || cexpr.operation != opExpr {
+			return nil, fmt.Errorf("column transform for %s: only columns that are selected as is can be transformed", colName)
+		}
+		field := col.Name.String()
+		for _, pkref := range pkrefs {
+			if strings.EqualFold(pkref, field) {
+				return nil, fmt.Errorf("column transform for %s: primary key columns cannot be transformed", colName)
+			}
+		}
+		for _, other := range tpb.colExprs {
+			if other != cexpr && other.references[field] {
+				return nil, fmt.Errorf("column transform for %s: column %s is also used by %s", colName, field, other.colName.String())
+			}
+		}
+		bound[field] = transform
+	}
+	return bound, nil
+}
+
+// transformRow applies the column transforms to a row streamed by the
+// copy phase.
+func (tp *TablePlan) transformRow(row *querypb.Row) (*querypb.Row, error) {
+	vals := sqltypes.MakeRowTrusted(tp.Fields, row)
+	for i, field := range tp.Fields {
+		transform, ok := tp.ColumnTransforms[field.Name]
+		if !ok || vals[i].IsNull() {
+			continue
+		}
+		val, err := transform.Transform(vals[i])
+		if err != nil {
+			return nil, fmt.Errorf("column transform for %s: %v", field.Name, err)
+		}
+		vals[i] = val
+	}
+	return sqltypes.RowToProto3(vals), nil
+}
+
+// checkTransformArgs returns an error if args contains an argument
+// that is not in allowed.
+func checkTransformArgs(args map[string]string, allowed ...string) error {
+	var unknown []string
+	for arg := range args {
+		found := false
+		for _, a := range allowed {
+			if arg == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, arg)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown arguments: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// hashTransform replaces values with the hex encoded SHA-256 of the salted
+// value. The optional length argument truncates the hash, so that it fits
+// narrower columns.
+type hashTransform struct {
+	salt   string
+	length int
+}
+
+func newHashTransform(args map[string]string) (ColumnTransform, error) {
+	if err := checkTransformArgs(args, "salt", "length"); err != nil {
+		return nil, err
+	}
+	ht := &hashTransform{
+		salt:   args["salt"],
+		length: sha256.Size * 2,
+	}
+	if length, ok := args["length"]; ok {
+		n, err := strconv.Atoi(length)
+		if err != nil || n <= 0 || n > sha256.Size*2 {
+			return nil, fmt.Errorf("invalid length %q: must be between 1 and %d", length, sha256.Size*2)
+		}
+		ht.length = n
+	}
+	return ht, nil
+}
+
+func (ht *hashTransform) Transform(val sqltypes.Value) (sqltypes.Value, error) {
+	h := sha256.New()
+	h.Write([]byte(ht.salt))
+	h.Write(val.Raw())
+	return sqltypes.NewVarChar(hex.EncodeToString(h.Sum(nil))[:ht.length]), nil
+}
+
+// redactTransform replaces values with a constant, or with NULL if no
+// value argument is specified.
+type redactTransform struct {
+	value *string
+}
+
+func newRedactTransform(args map[string]string) (ColumnTransform, error) {
+	if err := checkTransformArgs(args, "value"); err != nil {
+		return nil, err
+	}
+	rt := &redactTransform{}
+	if value, ok := args["value"]; ok {
+		rt.value = &value
+	}
+	return rt, nil
+}
+
+func (rt *redactTransform) Transform(val sqltypes.Value) (sqltypes.Value, error) {
+	if rt.value == nil {
+		return sqltypes.NULL, nil
+	}
+	return sqltypes.MakeTrusted(val.Type(), []byte(*rt.value)), nil
+}
+
+// tokenizeTransform replaces every letter and digit of a value with another
+// one of the same class, derived from a keyed HMAC of the value. Other
+// characters, like separators, are kept. The result has the same format and
+// length as the original value: the same input always yields the same
+// token, which keeps tokenized columns usable for joins.
+type tokenizeTransform struct {
+	key []byte
+}
+
+func newTokenizeTransform(args map[string]string) (ColumnTransform, error) {
+	if err := checkTransformArgs(args, "key"); err != nil {
+		return nil, err
+	}
+	key := args["key"]
+	if key == "" {
+		return nil, fmt.Errorf("tokenize requires a key")
+	}
+	return &tokenizeTransform{key: []byte(key)}, nil
+}
+
+func (tt *tokenizeTransform) Transform(val sqltypes.Value) (sqltypes.Value, error) {
+	raw := val.Raw()
+	mac := hmac.New(sha256.New, tt.key)
+	mac.Write(raw)
+	seed := mac.Sum(nil)
+
+	// The keystream is extended by rehashing the seed, for values
+	// longer than a single digest.
+	stream := seed
+	next := func() byte {
+		if len(stream) == 0 {
+			sum := sha256.Sum256(seed)
+			seed = sum[:]
+			stream = seed
+		}
+		b := stream[0]
+		stream = stream[1:]
+		return b
+	}
+	out := make([]byte, len(raw))
+	for i, c := range raw {
+		switch {
+		case c >= '0' && c <= '9':
+			out[i] = '0' + next()%10
+		case c >= 'a' && c <= 'z':
+			out[i] = 'a' + next()%26
+		case c >= 'A' && c <= 'Z':
+			out[i] = 'A' + next()%26
+		default:
+			out[i] = c
+		}
+	}
+	return sqltypes.MakeTrusted(val.Type(), out), nil
+}
+
+// truncateDateTransform truncates date and time values to the start of
+// their year, month, day or hour.
+type truncateDateTransform struct {
+	// keep is the number of leading characters of the
+	// 'YYYY-MM-DD hh:mm:ss' format that are kept.
+	keep int
+}
+
+func newTruncateDateTransform(args map[string]string) (ColumnTransform, error) {
+	if err := checkTransformArgs(args, "unit"); err != nil {
+		return nil, err
+	}
+	switch unit := args["unit"]; unit {
+	case "year":
+		return &truncateDateTransform{keep: 4}, nil
+	case "month":
+		return &truncateDateTransform{keep: 7}, nil
+	case "", "day":
+		return &truncateDateTransform{keep: 10}, nil
+	case "hour":
+		return &truncateDateTransform{keep: 13}, nil
+	default:
+		return nil, fmt.Errorf("invalid unit %q: must be one of year, month, day or hour", unit)
+	}
+}
+
+func (tt *truncateDateTransform) Transform(val sqltypes.Value) (sqltypes.Value, error) {
+	raw := val.Raw()
+	if len(raw) < 10 || raw[4] != '-' || raw[7] != '-' {
+		return sqltypes.NULL, fmt.Errorf("cannot truncate %q: not a date", raw)
+	}
+	out := make([]byte, len(raw))
+	copy(out, raw)
+	for i := tt.keep; i < len(out); i++ {
+		if out[i] < '0' || out[i] > '9' {
+			continue
+		}
+		// Months and days start at 1, time fields at 0.
+		if i == 6 || i == 9 {
+			out[i] = '1'
+		} else {
+			out[i] = '0'
+		}
+	}
+	return sqltypes.MakeTrusted(val.Type(), out), nil
+}
+
+// jsonRemoveTransform removes fields from JSON documents. Paths are comma
+// separated, and address object members like '$.address.street'.
+type jsonRemoveTransform struct {
+	paths [][]string
+}
+
+func newJSONRemoveTransform(args map[string]string) (ColumnTransform, error) {
+	if err := checkTransformArgs(args, "paths"); err != nil {
+		return nil, err
+	}
+	jt := &jsonRemoveTransform{}
+	for _, path := range strings.Split(args["paths"], ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "$.") {
+			return nil, fmt.Errorf("invalid path %q: must start with '$.'", path)
+		}
+		keys := strings.Split(strings.TrimPrefix(path, "$."), ".")
+		for _, key := range keys {
+			if key == "" {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+		}
+		jt.paths = append(jt.paths, keys)
+	}
+	if len(jt.paths) == 0 {
+		return nil, fmt.Errorf("json_remove requires paths")
+	}
+	return jt, nil
+}
+
+func (jt *jsonRemoveTransform) Transform(val sqltypes.Value) (sqltypes.Value, error) {
+	dec := json.NewDecoder(bytes.NewReader(val.Raw()))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return sqltypes.NULL, fmt.Errorf("cannot parse JSON: %v", err)
+	}
+	for _, keys := range jt.paths {
+		removeJSONPath(doc, keys)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return sqltypes.NULL, err
+	}
+	return sqltypes.MakeTrusted(val.Type(), bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+func removeJSONPath(doc interface{}, keys []string) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if len(keys) == 1 {
+		delete(obj, keys[0])
+		return
+	}
+	removeJSONPath(obj[keys[0]], keys[1:])
+}
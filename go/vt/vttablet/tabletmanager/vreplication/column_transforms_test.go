@@ -0,0 +1,201 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/bytes2"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func TestColumnTransforms(t *testing.T) {
+	testcases := []struct {
+		name string
+		args map[string]string
+		in   sqltypes.Value
+		out  sqltypes.Value
+		err  string
+	}{{
+		name: "hash",
+		args: map[string]string{"salt": "abc"},
+		in:   sqltypes.NewVarChar("bob@example.com"),
+		out:  sqltypes.NewVarChar("47ab7828865405f360df1d5c2093612e61366dab3c611713b68589d0be7399b0"),
+	}, {
+		name: "hash",
+		args: map[string]string{"salt": "abc", "length": "8"},
+		in:   sqltypes.NewVarChar("bob@example.com"),
+		out:  sqltypes.NewVarChar("47ab7828"),
+	}, {
+		name: "hash",
+		args: map[string]string{"length": "65"},
+		err:  `invalid length "65": must be between 1 and 64`,
+	}, {
+		name: "redact",
+		in:   sqltypes.NewVarChar("555-1234"),
+		out:  sqltypes.NULL,
+	}, {
+		name: "redact",
+		args: map[string]string{"value": "0"},
+		in:   sqltypes.NewInt64(42),
+		out:  sqltypes.NewInt64(0),
+	}, {
+		name: "redact",
+		args: map[string]string{"salt": "abc"},
+		err:  "unknown arguments: salt",
+	}, {
+		name: "tokenize",
+		err:  "tokenize requires a key",
+	}, {
+		name: "truncate_date",
+		in:   sqltypes.MakeTrusted(querypb.Type_DATETIME, []byte("2022-05-17 13:45:10.123")),
+		out:  sqltypes.MakeTrusted(querypb.Type_DATETIME, []byte("2022-05-17 00:00:00.000")),
+	}, {
+		name: "truncate_date",
+		args: map[string]string{"unit": "year"},
+		in:   sqltypes.MakeTrusted(querypb.Type_DATE, []byte("2022-05-17")),
+		out:  sqltypes.MakeTrusted(querypb.Type_DATE, []byte("2022-01-01")),
+	}, {
+		name: "truncate_date",
+		args: map[string]string{"unit": "month"},
+		in:   sqltypes.MakeTrusted(querypb.Type_TIMESTAMP, []byte("2022-05-17 13:45:10")),
+		out:  sqltypes.MakeTrusted(querypb.Type_TIMESTAMP, []byte("2022-05-01 00:00:00")),
+	}, {
+		name: "truncate_date",
+		args: map[string]string{"unit": "hour"},
+		in:   sqltypes.MakeTrusted(querypb.Type_DATETIME, []byte("2022-05-17 13:45:10")),
+		out:  sqltypes.MakeTrusted(querypb.Type_DATETIME, []byte("2022-05-17 13:00:00")),
+	}, {
+		name: "truncate_date",
+		args: map[string]string{"unit": "week"},
+		err:  `invalid unit "week": must be one of year, month, day or hour`,
+	}, {
+		name: "json_remove",
+		args: map[string]string{"paths": "$.ssn, $.address.street"},
+		in:   sqltypes.MakeTrusted(querypb.Type_JSON, []byte(`{"name": "bob", "ssn": "123-45-6789", "address": {"street": "1 Main St", "zip": 12345.0}}`)),
+		out:  sqltypes.MakeTrusted(querypb.Type_JSON, []byte(`{"address":{"zip":12345.0},"name":"bob"}`)),
+	}, {
+		name: "json_remove",
+		args: map[string]string{"paths": "ssn"},
+		err:  `invalid path "ssn": must start with '$.'`,
+	}, {
+		name: "mask",
+		err:  `unknown transform "mask"`,
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			transforms, err := newColumnTransforms(map[string]*binlogdatapb.ColumnTransform{
+				"c": {Name: tcase.name, Args: tcase.args},
+			})
+			if tcase.err != "" {
+				assert.ErrorContains(t, err, tcase.err)
+				return
+			}
+			require.NoError(t, err)
+			out, err := transforms["c"].Transform(tcase.in)
+			require.NoError(t, err)
+			assert.Equal(t, tcase.out, out)
+		})
+	}
+}
+
+func TestTokenizeTransform(t *testing.T) {
+	tokenize := func(key, val string) string {
+		tt, err := newTokenizeTransform(map[string]string{"key": key})
+		require.NoError(t, err)
+		out, err := tt.Transform(sqltypes.NewVarChar(val))
+		require.NoError(t, err)
+		return out.ToString()
+	}
+
+	in := "4111-1111-1111-1111 Exp 12/28"
+	token := tokenize("k1", in)
+	assert.NotEqual(t, in, token)
+	assert.Regexp(t, `^[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{4} [A-Z][a-z]{2} [0-9]{2}/[0-9]{2}$`, token)
+	// Tokens are deterministic for a given key, and differ across keys.
+	assert.Equal(t, token, tokenize("k1", in))
+	assert.NotEqual(t, token, tokenize("k2", in))
+}
+
+func TestTablePlanColumnTransforms(t *testing.T) {
+	colInfos := map[string][]*ColumnInfo{
+		"t1": {&ColumnInfo{Name: "c1", IsPK: true}, &ColumnInfo{Name: "email"}},
+	}
+	hashEmail := map[string]*binlogdatapb.ColumnTransform{
+		"email": {Name: "hash", Args: map[string]string{"salt": "abc", "length": "8"}},
+	}
+	row := func(c1 int64, email string) *querypb.Row {
+		return sqltypes.RowToProto3([]sqltypes.Value{sqltypes.NewInt64(c1), sqltypes.NewVarChar(email)})
+	}
+	for _, filter := range []string{"select c1, email from t1", "select * from t1"} {
+		t.Run(filter, func(t *testing.T) {
+			input := &binlogdatapb.Filter{
+				Rules: []*binlogdatapb.Rule{{
+					Match:            "t1",
+					Filter:           filter,
+					ColumnTransforms: hashEmail,
+				}},
+			}
+			plan, err := buildReplicatorPlan(getSource(input), colInfos, nil, binlogplayer.NewStats())
+			require.NoError(t, err)
+			tplan, err := plan.buildExecutionPlan(&binlogdatapb.FieldEvent{
+				TableName: "t1",
+				Fields:    sqltypes.MakeTestFields("c1|email", "int64|varchar"),
+			})
+			require.NoError(t, err)
+
+			var queries []string
+			executor := func(sql string) (*sqltypes.Result, error) {
+				queries = append(queries, sql)
+				return &sqltypes.Result{}, nil
+			}
+			// The copy phase and the replication phase both write the transformed values.
+			_, err = tplan.applyBulkInsert(&bytes2.Buffer{}, &binlogdatapb.VStreamRowsResponse{
+				Rows: []*querypb.Row{row(1, "bob@example.com")},
+			}, executor)
+			require.NoError(t, err)
+			_, err = tplan.applyChange(&binlogdatapb.RowChange{Before: row(1, "bob@example.com"), After: row(1, "alice@example.com")}, executor)
+			require.NoError(t, err)
+			assert.Equal(t, []string{
+				"insert into t1(c1,email) values (1,'47ab7828')",
+				"update t1 set email='15d6fc12' where c1=1",
+			}, queries)
+		})
+	}
+
+	// Primary key columns and expressions cannot be transformed.
+	for filter, transforms := range map[string]map[string]*binlogdatapb.ColumnTransform{
+		"select c1, email from t1":                      {"c1": {Name: "redact"}},
+		"select c1, concat(email, '') as email from t1": hashEmail,
+	} {
+		input := &binlogdatapb.Filter{
+			Rules: []*binlogdatapb.Rule{{
+				Match:            "t1",
+				Filter:           filter,
+				ColumnTransforms: transforms,
+			}},
+		}
+		_, err := buildReplicatorPlan(getSource(input), colInfos, nil, binlogplayer.NewStats())
+		assert.Error(t, err, filter)
+	}
+}
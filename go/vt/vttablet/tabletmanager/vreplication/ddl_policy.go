@@ -0,0 +1,575 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/sqlescape"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/schemadiff"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+)
+
+// ddlChange is a kind of schema change that can be allowed by a DDLPolicy.
+type ddlChange string
+
+const (
+	ddlAddColumn   = ddlChange("adding columns")
+	ddlDropColumn  = ddlChange("dropping columns")
+	ddlWidenColumn = ddlChange("widening columns")
+	ddlIndex       = ddlChange("changing indexes")
+)
+
+// incompatibleDDLError is returned for the DDLs that cannot be applied to the
+// target by the APPLY_COMPATIBLE OnDdl action.
+type incompatibleDDLError struct {
+	reason string
+}
+
+func (e *incompatibleDDLError) Error() string {
+	return e.reason
+}
+
+func incompatibleDDL(format string, args ...any) error {
+	return &incompatibleDDLError{reason: fmt.Sprintf(format, args...)}
+}
+
+// applyCompatibleDDL handles a DDL for the APPLY_COMPATIBLE OnDdl action. The
+// changes of the DDL are mapped to the target table and applied if they are
+// allowed by the ddl policy of the stream. Otherwise the stream is stopped
+// with an error that explains why the DDL could not be applied.
+func (vp *vplayer) applyCompatibleDDL(ctx context.Context, event *binlogdatapb.VEvent, stats *VrLogStats) error {
+	alter, err := vp.compatibleDDL(event.Statement)
+	var incompatible *incompatibleDDLError
+	if errors.As(err, &incompatible) {
+		if err := vp.vr.dbClient.Begin(); err != nil {
+			return err
+		}
+		if _, err := vp.updatePos(event.Timestamp); err != nil {
+			return err
+		}
+		if err := vp.vr.setState(binlogplayer.BlpStopped, fmt.Sprintf("Stopped at incompatible DDL %s: %v", event.Statement, err)); err != nil {
+			return err
+		}
+		if err := vp.vr.dbClient.Commit(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	if err != nil {
+		return err
+	}
+	if alter != "" {
+		// As with the EXEC action, the position cannot be saved
+		// transactionally with the DDL.
+		log.Infof("Applying %s for DDL %s", alter, event.Statement)
+		if _, err := vp.vr.dbClient.ExecuteWithRetry(ctx, alter); err != nil {
+			return err
+		}
+		stats.Send(alter)
+		// The columns of the target table are used to build its plan
+		// when the fields of the source table are received again.
+		colInfoMap, err := vp.vr.buildColInfoMap(ctx)
+		if err != nil {
+			return err
+		}
+		vp.vr.colInfoMap = colInfoMap
+		vp.replicatorPlan.ColInfoMap = colInfoMap
+	}
+	posReached, err := vp.updatePos(event.Timestamp)
+	if err != nil {
+		return err
+	}
+	if posReached {
+		return io.EOF
+	}
+	return nil
+}
+
+// compatibleDDL returns the ALTER TABLE statement to run on the target for a
+// source DDL, or an empty statement if the DDL doesn't affect the target.
+func (vp *vplayer) compatibleDDL(ddl string) (string, error) {
+	stmt, err := sqlparser.Parse(ddl)
+	if err != nil {
+		return "", incompatibleDDL("cannot parse DDL: %v", err)
+	}
+	ddlStmt, ok := stmt.(sqlparser.DDLStatement)
+	if !ok {
+		return "", nil
+	}
+	var tplan *TablePlan
+	for _, table := range ddlStmt.AffectedTables() {
+		if tplan = vp.replicatorPlan.TablePlans[table.Name.String()]; tplan != nil {
+			break
+		}
+	}
+	if tplan == nil {
+		// None of the replicated tables is changed.
+		return "", nil
+	}
+	alter, ok := stmt.(*sqlparser.AlterTable)
+	if !ok {
+		return "", incompatibleDDL("only ALTER TABLE statements can be applied to the target")
+	}
+	rule, err := MatchTable(tplan.TargetName, vp.vr.source.Filter)
+	if err != nil {
+		return "", err
+	}
+	if rule == nil {
+		return "", fmt.Errorf("no rule found for table %s", tplan.TargetName)
+	}
+	qr, err := vp.vr.dbClient.ExecuteFetch(fmt.Sprintf("show create table %s", sqlescape.EscapeID(tplan.TargetName)), 1)
+	if err != nil {
+		return "", err
+	}
+	if len(qr.Rows) != 1 || len(qr.Rows[0]) < 2 {
+		return "", fmt.Errorf("unexpected result for show create table %s: %v", tplan.TargetName, qr.Rows)
+	}
+	return planDDL(alter, rule, qr.Rows[0][1].ToString(), vp.vr.source.DdlPolicy)
+}
+
+// planDDL returns the ALTER TABLE statement that applies the changes of a
+// source ALTER TABLE to the target table, whose CREATE TABLE statement is
+// given. The changes are mapped through the filter of the rule of the table,
+// and classified by diffing the target table before and after they are
+// applied. It returns an empty statement if the target table is not changed,
+// and an incompatibleDDLError if a change cannot be applied, or is not allowed
+// by the policy.
+func planDDL(alter *sqlparser.AlterTable, rule *binlogdatapb.Rule, targetCreateTable string, policy *binlogdatapb.DDLPolicy) (string, error) {
+	stmt, err := sqlparser.ParseStrictDDL(targetCreateTable)
+	if err != nil {
+		return "", err
+	}
+	createTable, ok := stmt.(*sqlparser.CreateTable)
+	if !ok {
+		return "", fmt.Errorf("unexpected: %v", sqlparser.String(stmt))
+	}
+	before, err := schemadiff.NewCreateTableEntity(createTable)
+	if err != nil {
+		return "", err
+	}
+	cm, err := newDDLColumnMap(rule)
+	if err != nil {
+		return "", err
+	}
+	mapped, err := cm.mapAlterTable(alter, before)
+	if err != nil {
+		return "", err
+	}
+	if len(mapped.AlterOptions) == 0 {
+		return "", nil
+	}
+	after, err := before.ApplyAlterTable(mapped)
+	if err != nil {
+		return "", incompatibleDDL("cannot apply %s to the target table: %v", sqlparser.String(mapped), err)
+	}
+	diff, err := before.TableDiff(after, &schemadiff.DiffHints{})
+	if err != nil {
+		return "", err
+	}
+	if diff.IsEmpty() {
+		return "", nil
+	}
+	for _, opt := range diff.AlterTable().AlterOptions {
+		change, err := classifyAlterOption(opt, before)
+		if err != nil {
+			return "", err
+		}
+		if !ddlPolicyAllows(policy, change) {
+			return "", incompatibleDDL("%s is not allowed by the ddl policy: %s", change, sqlparser.String(opt))
+		}
+	}
+	return diff.StatementString(), nil
+}
+
+// ddlPolicyAllows returns true if the change is allowed by the policy. All
+// the changes are allowed if there is no policy.
+func ddlPolicyAllows(policy *binlogdatapb.DDLPolicy, change ddlChange) bool {
+	if policy == nil {
+		return true
+	}
+	switch change {
+	case ddlAddColumn:
+		return policy.AddColumns
+	case ddlDropColumn:
+		return policy.DropColumns
+	case ddlWidenColumn:
+		return policy.WidenColumns
+	case ddlIndex:
+		return policy.Indexes
+	}
+	return false
+}
+
+// classifyAlterOption returns the kind of change of an option of an ALTER
+// TABLE generated by schemadiff for the given table.
+func classifyAlterOption(opt sqlparser.AlterOption, table *schemadiff.CreateTableEntity) (ddlChange, error) {
+	switch opt := opt.(type) {
+	case *sqlparser.AddColumns:
+		return ddlAddColumn, nil
+	case *sqlparser.DropColumn:
+		return ddlDropColumn, nil
+	case *sqlparser.ModifyColumn:
+		to := opt.NewColDefinition
+		for _, from := range table.TableSpec.Columns {
+			if !from.Name.Equal(to.Name) {
+				continue
+			}
+			if !isWideningColumnChange(&from.Type, &to.Type) {
+				return "", incompatibleDDL("changing column %s from %s to %s is not compatible", to.Name.String(),
+					columnTypeString(&from.Type), columnTypeString(&to.Type))
+			}
+			return ddlWidenColumn, nil
+		}
+		return "", incompatibleDDL("column %s not found in the target table", to.Name.String())
+	case *sqlparser.AddIndexDefinition:
+		if opt.IndexDefinition.Info.Primary {
+			return "", incompatibleDDL("changing the primary key is not compatible")
+		}
+		return ddlIndex, nil
+	case *sqlparser.DropKey:
+		if opt.Type != sqlparser.NormalKeyType {
+			return "", incompatibleDDL("%s is not compatible", sqlparser.String(opt))
+		}
+		return ddlIndex, nil
+	case *sqlparser.RenameIndex, *sqlparser.AlterIndex:
+		return ddlIndex, nil
+	}
+	return "", incompatibleDDL("%s is not compatible", sqlparser.String(opt))
+}
+
+var (
+	integerTypeRanks = map[string]int{"tinyint": 1, "smallint": 2, "mediumint": 3, "int": 4, "integer": 4, "bigint": 5}
+	textTypeRanks    = map[string]int{"tinytext": 1, "text": 2, "mediumtext": 3, "longtext": 4}
+	blobTypeRanks    = map[string]int{"tinyblob": 1, "blob": 2, "mediumblob": 3, "longblob": 4}
+)
+
+// isWideningColumnChange returns true if all the values of a column of the
+// from type are kept unchanged by a column of the to type. Changes that keep
+// the type, e.g. of the default value or the comment of the column, are also
+// considered widening.
+func isWideningColumnChange(from, to *sqlparser.ColumnType) bool {
+	if columnNotNull(to) && !columnNotNull(from) {
+		return false
+	}
+	if from.Charset != to.Charset || columnCollate(from) != columnCollate(to) || from.Zerofill != to.Zerofill {
+		return false
+	}
+	fromType, toType := strings.ToLower(from.Type), strings.ToLower(to.Type)
+	switch {
+	case integerTypeRanks[fromType] > 0 && integerTypeRanks[toType] > 0:
+		if from.Unsigned == to.Unsigned {
+			return integerTypeRanks[toType] >= integerTypeRanks[fromType]
+		}
+		// Unsigned values fit in any larger signed type.
+		return from.Unsigned && integerTypeRanks[toType] > integerTypeRanks[fromType]
+	case textTypeRanks[fromType] > 0 && textTypeRanks[toType] > 0:
+		return textTypeRanks[toType] >= textTypeRanks[fromType]
+	case blobTypeRanks[fromType] > 0 && blobTypeRanks[toType] > 0:
+		return blobTypeRanks[toType] >= blobTypeRanks[fromType]
+	case (fromType == "char" || fromType == "varchar") && (toType == "varchar" || toType == fromType):
+		return columnLength(to.Length, 1) >= columnLength(from.Length, 1)
+	case (fromType == "binary" || fromType == "varbinary") && toType == "varbinary":
+		// binary values are padded, so they only keep their values
+		// when they are converted to varbinary.
+		return columnLength(to.Length, 1) >= columnLength(from.Length, 1)
+	case fromType == "decimal" && toType == "decimal":
+		fromPrecision, fromScale := columnLength(from.Length, 10), columnLength(from.Scale, 0)
+		toPrecision, toScale := columnLength(to.Length, 10), columnLength(to.Scale, 0)
+		return from.Unsigned == to.Unsigned && toScale >= fromScale && toPrecision-toScale >= fromPrecision-fromScale
+	case fromType == "float" && toType == "double":
+		return from.Length == nil && to.Length == nil && from.Unsigned == to.Unsigned
+	case (fromType == "enum" || fromType == "set") && toType == fromType:
+		// Values can only be appended, since they are stored by their
+		// position.
+		if len(to.EnumValues) < len(from.EnumValues) {
+			return false
+		}
+		for i, value := range from.EnumValues {
+			if to.EnumValues[i] != value {
+				return false
+			}
+		}
+		return true
+	}
+	return strings.EqualFold(columnTypeString(from), columnTypeString(to))
+}
+
+func columnNotNull(ct *sqlparser.ColumnType) bool {
+	return ct.Options != nil && ct.Options.Null != nil && !*ct.Options.Null
+}
+
+func columnCollate(ct *sqlparser.ColumnType) string {
+	if ct.Options == nil {
+		return ""
+	}
+	return strings.ToLower(ct.Options.Collate)
+}
+
+// columnLength returns the value of a length or scale of a column type, or
+// the default value if it is not set.
+func columnLength(l *sqlparser.Literal, defaultValue int) int {
+	if l == nil {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(l.Val)
+	if err != nil {
+		return defaultValue
+	}
+	return n
+}
+
+// columnTypeString returns the column type without its options.
+func columnTypeString(ct *sqlparser.ColumnType) string {
+	typ := *ct
+	typ.Options = nil
+	return sqlparser.String(&typ)
+}
+
+// ddlColumnMap maps the columns of a source table to the columns of its
+// target table, as defined by the filter of the rule of the table.
+type ddlColumnMap struct {
+	// all is set when all the source columns are copied to target columns
+	// with the same names, e.g. for "select *" filters.
+	all bool
+	// columns maps the lowered names of the source columns that are
+	// copied as they are to the names of their target columns.
+	columns map[string]sqlparser.IdentifierCI
+	// computed has the lowered names of the source columns that are used
+	// by expressions of the filter.
+	computed map[string]bool
+	// filtered has the lowered names of the source columns that are used
+	// by the where clause of the filter.
+	filtered map[string]bool
+}
+
+func newDDLColumnMap(rule *binlogdatapb.Rule) (*ddlColumnMap, error) {
+	cm := &ddlColumnMap{
+		columns:  make(map[string]sqlparser.IdentifierCI),
+		computed: make(map[string]bool),
+		filtered: make(map[string]bool),
+	}
+	if rule.Filter == "" || key.IsKeyRange(rule.Filter) {
+		cm.all = true
+		return cm, nil
+	}
+	sel, _, err := analyzeSelectFrom(rule.Filter)
+	if err != nil {
+		return nil, err
+	}
+	referencedColumns := func(node sqlparser.SQLNode, columns map[string]bool) {
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			if col, ok := node.(*sqlparser.ColName); ok {
+				columns[col.Name.Lowered()] = true
+			}
+			return true, nil
+		}, node)
+	}
+	for _, selExpr := range sel.SelectExprs {
+		switch selExpr := selExpr.(type) {
+		case *sqlparser.StarExpr:
+			cm.all = true
+		case *sqlparser.AliasedExpr:
+			col, ok := selExpr.Expr.(*sqlparser.ColName)
+			if !ok {
+				referencedColumns(selExpr.Expr, cm.computed)
+				continue
+			}
+			if _, ok := cm.columns[col.Name.Lowered()]; ok {
+				// Columns copied to several target columns are
+				// handled like expressions.
+				cm.computed[col.Name.Lowered()] = true
+				continue
+			}
+			target := selExpr.As
+			if target.IsEmpty() {
+				target = col.Name
+			}
+			cm.columns[col.Name.Lowered()] = target
+		}
+	}
+	for name := range cm.computed {
+		delete(cm.columns, name)
+	}
+	if sel.Where != nil {
+		referencedColumns(sel.Where, cm.filtered)
+	}
+	return cm, nil
+}
+
+// target returns the name of the target column of a source column, and
+// false if the source column is not copied to the target as it is.
+func (cm *ddlColumnMap) target(name sqlparser.IdentifierCI) (sqlparser.IdentifierCI, bool) {
+	if cm.computed[name.Lowered()] {
+		return sqlparser.IdentifierCI{}, false
+	}
+	if target, ok := cm.columns[name.Lowered()]; ok {
+		return target, true
+	}
+	if cm.all {
+		return name, true
+	}
+	return sqlparser.IdentifierCI{}, false
+}
+
+// mapAlterTable maps the options of a source ALTER TABLE to the target table.
+// The options that don't affect the target table are dropped.
+func (cm *ddlColumnMap) mapAlterTable(alter *sqlparser.AlterTable, table *schemadiff.CreateTableEntity) (*sqlparser.AlterTable, error) {
+	if alter.PartitionSpec != nil || alter.PartitionOption != nil {
+		return nil, incompatibleDDL("partitioning changes are not compatible")
+	}
+	mapped := &sqlparser.AlterTable{Table: table.Table}
+	for _, opt := range alter.AlterOptions {
+		switch opt := opt.(type) {
+		case *sqlparser.AddColumns:
+			if !cm.all {
+				// The filter doesn't select the new columns.
+				continue
+			}
+			for _, col := range opt.Columns {
+				mapped.AlterOptions = append(mapped.AlterOptions, &sqlparser.AddColumns{
+					Columns: []*sqlparser.ColumnDefinition{sqlparser.CloneRefOfColumnDefinition(col)},
+					First:   opt.First,
+					After:   sqlparser.CloneRefOfColName(opt.After),
+				})
+			}
+		case *sqlparser.DropColumn:
+			name := opt.Name.Name
+			if cm.computed[name.Lowered()] || cm.filtered[name.Lowered()] {
+				return nil, incompatibleDDL("column %s is used by the filter of the table", name.String())
+			}
+			if _, ok := cm.columns[name.Lowered()]; ok {
+				return nil, incompatibleDDL("column %s is selected by the filter of the table", name.String())
+			}
+			if !cm.all || !tableHasColumn(table, name) {
+				continue
+			}
+			mapped.AlterOptions = append(mapped.AlterOptions, &sqlparser.DropColumn{Name: &sqlparser.ColName{Name: name}})
+		case *sqlparser.ModifyColumn:
+			modify, err := cm.mapModifyColumn(opt.NewColDefinition, opt.First, opt.After)
+			if err != nil {
+				return nil, err
+			}
+			if modify != nil {
+				mapped.AlterOptions = append(mapped.AlterOptions, modify)
+			}
+		case *sqlparser.ChangeColumn:
+			if !opt.OldColumn.Name.Equal(opt.NewColDefinition.Name) {
+				return nil, incompatibleDDL("renaming column %s is not compatible", opt.OldColumn.Name.String())
+			}
+			modify, err := cm.mapModifyColumn(opt.NewColDefinition, opt.First, opt.After)
+			if err != nil {
+				return nil, err
+			}
+			if modify != nil {
+				mapped.AlterOptions = append(mapped.AlterOptions, modify)
+			}
+		case *sqlparser.AddIndexDefinition:
+			if index := cm.mapIndex(opt.IndexDefinition); index != nil {
+				mapped.AlterOptions = append(mapped.AlterOptions, &sqlparser.AddIndexDefinition{IndexDefinition: index})
+			}
+		case *sqlparser.DropKey:
+			if opt.Type == sqlparser.NormalKeyType && !tableHasIndex(table, opt.Name) {
+				continue
+			}
+			mapped.AlterOptions = append(mapped.AlterOptions, sqlparser.CloneRefOfDropKey(opt))
+		case *sqlparser.RenameIndex:
+			if !tableHasIndex(table, opt.OldName) {
+				continue
+			}
+			mapped.AlterOptions = append(mapped.AlterOptions, sqlparser.CloneRefOfRenameIndex(opt))
+		case *sqlparser.AlterIndex:
+			if !tableHasIndex(table, opt.Name) {
+				continue
+			}
+			mapped.AlterOptions = append(mapped.AlterOptions, sqlparser.CloneRefOfAlterIndex(opt))
+		default:
+			return nil, incompatibleDDL("%s is not compatible", sqlparser.String(opt))
+		}
+	}
+	return mapped, nil
+}
+
+// mapModifyColumn returns the modification of the target column of a source
+// column, or nil if the source column is not copied to the target.
+func (cm *ddlColumnMap) mapModifyColumn(col *sqlparser.ColumnDefinition, first bool, after *sqlparser.ColName) (*sqlparser.ModifyColumn, error) {
+	if cm.computed[col.Name.Lowered()] {
+		return nil, incompatibleDDL("column %s is used by an expression of the filter of the table", col.Name.String())
+	}
+	target, ok := cm.target(col.Name)
+	if !ok {
+		return nil, nil
+	}
+	mapped := sqlparser.CloneRefOfColumnDefinition(col)
+	mapped.Name = target
+	modify := &sqlparser.ModifyColumn{NewColDefinition: mapped}
+	if len(cm.columns) == 0 {
+		// The target columns are in the same order as the source
+		// columns.
+		modify.First = first
+		modify.After = sqlparser.CloneRefOfColName(after)
+	}
+	return modify, nil
+}
+
+// mapIndex returns the definition of a source index on the target columns,
+// or nil if not all the columns of the index are copied to the target.
+func (cm *ddlColumnMap) mapIndex(index *sqlparser.IndexDefinition) *sqlparser.IndexDefinition {
+	mapped := sqlparser.CloneRefOfIndexDefinition(index)
+	for _, col := range mapped.Columns {
+		if col.Expression != nil {
+			if !cm.all || len(cm.columns) != 0 {
+				return nil
+			}
+			continue
+		}
+		target, ok := cm.target(col.Column)
+		if !ok {
+			return nil
+		}
+		col.Column = target
+	}
+	return mapped
+}
+
+func tableHasColumn(table *schemadiff.CreateTableEntity, name sqlparser.IdentifierCI) bool {
+	for _, col := range table.TableSpec.Columns {
+		if col.Name.Equal(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func tableHasIndex(table *schemadiff.CreateTableEntity, name sqlparser.IdentifierCI) bool {
+	for _, index := range table.TableSpec.Indexes {
+		if index.Info.Name.Equal(name) {
+			return true
+		}
+	}
+	return false
+}
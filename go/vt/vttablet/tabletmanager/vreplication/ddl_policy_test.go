@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+)
+
+func TestPlanDDL(t *testing.T) {
+	copyRule := &binlogdatapb.Rule{Match: "t1", Filter: "select * from t1"}
+	copyTarget := "create table t1 (id int, c1 varchar(10), c2 int, primary key (id))"
+	materializeRule := &binlogdatapb.Rule{Match: "t2", Filter: "select id, c1 as c1name, c2 + 1 as c2p1 from t1 where c4 = 'x'"}
+	materializeTarget := "create table t2 (id int, c1name varchar(10), c2p1 bigint, primary key (id))"
+
+	testcases := []struct {
+		name   string
+		rule   *binlogdatapb.Rule
+		target string
+		ddl    string
+		policy *binlogdatapb.DDLPolicy
+		want   string
+		err    string
+	}{{
+		name:   "add column",
+		rule:   copyRule,
+		target: copyTarget,
+		ddl:    "alter table t1 add column c3 int",
+		want:   "alter table t1 add column c3 int",
+	}, {
+		name:   "widen column",
+		rule:   copyRule,
+		target: copyTarget,
+		ddl:    "alter table t1 modify column c2 bigint",
+		want:   "alter table t1 modify column c2 bigint",
+	}, {
+		name:   "narrow column",
+		rule:   copyRule,
+		target: copyTarget,
+		ddl:    "alter table t1 modify column c1 varchar(5)",
+		err:    "changing column c1 from varchar(10) to varchar(5) is not compatible",
+	}, {
+		name:   "add index",
+		rule:   copyRule,
+		target: copyTarget,
+		ddl:    "alter table t1 add key c2_idx (c2)",
+		want:   "alter table t1 add key c2_idx (c2)",
+	}, {
+		name:   "drop column not allowed",
+		rule:   copyRule,
+		target: copyTarget,
+		ddl:    "alter table t1 drop column c2",
+		policy: &binlogdatapb.DDLPolicy{AddColumns: true},
+		err:    "dropping columns is not allowed by the ddl policy: drop column c2",
+	}, {
+		name:   "rename column",
+		rule:   copyRule,
+		target: copyTarget,
+		ddl:    "alter table t1 change column c1 c4 varchar(10)",
+		err:    "renaming column c1 is not compatible",
+	}, {
+		name:   "table options",
+		rule:   copyRule,
+		target: copyTarget,
+		ddl:    "alter table t1 engine=MyISAM",
+		err:    "engine MyISAM is not compatible",
+	}, {
+		name:   "widen renamed column",
+		rule:   materializeRule,
+		target: materializeTarget,
+		ddl:    "alter table t1 modify column c1 varchar(20)",
+		want:   "alter table t2 modify column c1name varchar(20)",
+	}, {
+		name:   "column not selected",
+		rule:   materializeRule,
+		target: materializeTarget,
+		ddl:    "alter table t1 add column c3 int, drop column c5",
+	}, {
+		name:   "index on selected columns",
+		rule:   materializeRule,
+		target: materializeTarget,
+		ddl:    "alter table t1 add key c1_idx (c1), add key c3_idx (c3)",
+		want:   "alter table t2 add key c1_idx (c1name)",
+	}, {
+		name:   "drop selected column",
+		rule:   materializeRule,
+		target: materializeTarget,
+		ddl:    "alter table t1 drop column c1",
+		err:    "column c1 is selected by the filter of the table",
+	}, {
+		name:   "drop filtered column",
+		rule:   materializeRule,
+		target: materializeTarget,
+		ddl:    "alter table t1 drop column c4",
+		err:    "column c4 is used by the filter of the table",
+	}, {
+		name:   "modify column of expression",
+		rule:   materializeRule,
+		target: materializeTarget,
+		ddl:    "alter table t1 modify column c2 bigint",
+		err:    "column c2 is used by an expression of the filter of the table",
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			stmt, err := sqlparser.Parse(tcase.ddl)
+			require.NoError(t, err)
+			got, err := planDDL(stmt.(*sqlparser.AlterTable), tcase.rule, tcase.target, tcase.policy)
+			if tcase.err != "" {
+				require.Error(t, err)
+				assert.IsType(t, &incompatibleDDLError{}, err)
+				assert.Equal(t, tcase.err, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tcase.want, got)
+		})
+	}
+}
+
+func TestIsWideningColumnChange(t *testing.T) {
+	testcases := []struct {
+		from, to string
+		want     bool
+	}{
+		{"int", "bigint", true},
+		{"bigint", "int", false},
+		{"int unsigned", "bigint", true},
+		{"int unsigned", "int", false},
+		{"int", "int unsigned", false},
+		{"varchar(10)", "varchar(20)", true},
+		{"char(10)", "varchar(10)", true},
+		{"varchar(10)", "char(10)", false},
+		{"binary(4)", "binary(8)", false},
+		{"text", "mediumtext", true},
+		{"decimal(10,2)", "decimal(12,4)", true},
+		{"decimal(10,2)", "decimal(10,4)", false},
+		{"float", "double", true},
+		{"enum('a','b')", "enum('a','b','c')", true},
+		{"enum('a','b')", "enum('b','a')", false},
+		{"int not null", "int", true},
+		{"int", "int not null", false},
+		{"int", "int default 1", true},
+		{"varchar(10)", "varchar(10) character set latin1", false},
+	}
+	for _, tcase := range testcases {
+		t.Run(tcase.from+" to "+tcase.to, func(t *testing.T) {
+			from, to := parseColumnType(t, tcase.from), parseColumnType(t, tcase.to)
+			assert.Equal(t, tcase.want, isWideningColumnChange(from, to))
+		})
+	}
+}
+
+func parseColumnType(t *testing.T, columnType string) *sqlparser.ColumnType {
+	stmt, err := sqlparser.Parse("create table t (c " + columnType + ")")
+	require.NoError(t, err)
+	return &stmt.(*sqlparser.CreateTable).TableSpec.Columns[0].Type
+}
@@ -62,6 +62,11 @@ const (
   table_name varbinary(128),
   lastpk varbinary(2000),
   primary key (vrepl_id, table_name))`
+
+	// pos is the source position of the snapshot the rows of a table were
+	// copied from, while tables are copied concurrently. It's cleared once
+	// the stream has fast-forwarded past it.
+	alterCopyStateAddPos = `alter table _vt.copy_state add column pos varbinary(10000)`
 )
 
 var withDDL *withddl.WithDDL
@@ -75,7 +80,7 @@ const (
 func init() {
 	allddls := append([]string{}, binlogplayer.CreateVReplicationTable()...)
 	allddls = append(allddls, binlogplayer.AlterVReplicationTable...)
-	allddls = append(allddls, createReshardingJournalTable, createCopyState)
+	allddls = append(allddls, createReshardingJournalTable, createCopyState, alterCopyStateAddPos)
 	allddls = append(allddls, createVReplicationLogTable)
 	withDDL = withddl.New(allddls)
 
@@ -316,6 +321,12 @@ func (vre *Engine) getDBClient(isAdmin bool) binlogplayer.DBClient {
 	return vre.dbClientFactoryFiltered()
 }
 
+// NewDBClient returns a new client for the database of the tablet, with the user
+// that the vreplication streams apply their changes with.
+func (vre *Engine) NewDBClient() binlogplayer.DBClient {
+	return vre.dbClientFactoryFiltered()
+}
+
 // ExecWithDBA runs the specified query as the DBA user
 func (vre *Engine) ExecWithDBA(query string) (*sqltypes.Result, error) {
 	return vre.exec(query, true /*runAsAdmin*/)
@@ -688,6 +699,13 @@ func (vre *Engine) transitionJournal(je *journalEvent) {
 	log.Infof("Completed transition for journal:workload %v", je)
 }
 
+// ThrottleCheckOKOrWait checks the tablet throttler on behalf of the given app,
+// waiting a bit when the app is throttled. It returns true when the app may
+// go ahead with its writes.
+func (vre *Engine) ThrottleCheckOKOrWait(ctx context.Context, appName string) bool {
+	return vre.throttlerClient.ThrottleCheckOKOrWaitAppName(ctx, appName)
+}
+
 // WaitForPos waits for the replication to reach the specified position.
 func (vre *Engine) WaitForPos(ctx context.Context, id int, pos string) error {
 	start := time.Now()
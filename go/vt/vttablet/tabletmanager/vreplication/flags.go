@@ -34,8 +34,11 @@ var (
 	relayLogMaxItems = 5000
 
 	copyPhaseDuration   = 1 * time.Hour
+	copyPhaseWorkers    = 1
 	replicaLagTolerance = 1 * time.Minute
 
+	parallelApplyWorkers = 1
+
 	vreplicationHeartbeatUpdateInterval = 1
 	vreplicationExperimentalFlags       = int64(0x01) // enable vreplicationExperimentalFlagOptimizeInserts by default
 	vreplicationStoreCompressedGTID     = false
@@ -53,6 +56,8 @@ func registerVReplicationFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&relayLogMaxItems, "relay_log_max_items", relayLogMaxItems, "Maximum number of rows for VReplication target buffering.")
 
 	fs.DurationVar(&copyPhaseDuration, "vreplication_copy_phase_duration", copyPhaseDuration, "Duration for each copy phase loop (before running the next catchup: default 1h)")
+	fs.IntVar(&copyPhaseWorkers, "vreplication_copy_phase_workers", copyPhaseWorkers, "Number of workers each stream uses in the copy phase to copy tables, and batches of rows of a table, concurrently. Streams with aggregations and Online DDL migrations always use one worker.")
+	fs.IntVar(&parallelApplyWorkers, "vreplication_parallel_apply_workers", parallelApplyWorkers, "Number of connections each stream uses in the replication phase to apply transactions that don't change the same rows concurrently. Streams with aggregations and Online DDL migrations always apply one transaction at a time.")
 	fs.DurationVar(&replicaLagTolerance, "vreplication_replica_lag_tolerance", replicaLagTolerance, "Replica lag threshold duration: once lag is below this we switch from copy phase to the replication (streaming) phase")
 
 	// vreplicationHeartbeatUpdateInterval determines how often the time_updated column is updated if there are no real events on the source and the source
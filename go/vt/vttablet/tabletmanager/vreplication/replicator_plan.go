@@ -83,7 +83,7 @@ func (rp *ReplicatorPlan) buildExecutionPlan(fieldEvent *binlogdatapb.FieldEvent
 		return &tplanv, nil
 	}
 	// select * construct was used. We need to use the field names.
-	tplan, err := rp.buildFromFields(prelim.TargetName, prelim.Lastpk, fieldEvent.Fields)
+	tplan, err := rp.buildFromFields(prelim.TargetName, prelim.Lastpk, prelim.ColumnTransforms, fieldEvent.Fields)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +94,7 @@ func (rp *ReplicatorPlan) buildExecutionPlan(fieldEvent *binlogdatapb.FieldEvent
 // buildFromFields builds a full TablePlan, but uses the field info as the
 // full column list. This happens when the query used was a 'select *', which
 // requires us to wait for the field info sent by the source.
-func (rp *ReplicatorPlan) buildFromFields(tableName string, lastpk *sqltypes.Result, fields []*querypb.Field) (*TablePlan, error) {
+func (rp *ReplicatorPlan) buildFromFields(tableName string, lastpk *sqltypes.Result, columnTransforms map[string]ColumnTransform, fields []*querypb.Field) (*TablePlan, error) {
 	tpb := &tablePlanBuilder{
 		name:     sqlparser.NewIdentifierCS(tableName),
 		lastpk:   lastpk,
@@ -133,7 +133,13 @@ func (rp *ReplicatorPlan) buildFromFields(tableName string, lastpk *sqltypes.Res
 	if err := tpb.analyzePK(rp.ColInfoMap[tableName]); err != nil {
 		return nil, err
 	}
-	return tpb.generate(), nil
+	tplan := tpb.generate()
+	var err error
+	tplan.ColumnTransforms, err = tpb.bindColumnTransforms(columnTransforms, tplan.PKReferences)
+	if err != nil {
+		return nil, err
+	}
+	return tplan, nil
 }
 
 // MarshalJSON performs a custom JSON Marshalling.
@@ -187,6 +193,10 @@ type TablePlan struct {
 	BulkInsertFront  *sqlparser.ParsedQuery
 	BulkInsertValues *sqlparser.ParsedQuery
 	BulkInsertOnDup  *sqlparser.ParsedQuery
+	// BulkReplaceFront is used instead of BulkInsertFront
+	// if the plan is Idempotent. It's nil if the plan has
+	// a group by.
+	BulkReplaceFront *sqlparser.ParsedQuery
 	// Insert, Update and Delete are used by vplayer.
 	// If the plan is an insertIgnore type, then Insert
 	// and Update contain 'insert ignore' statements and
@@ -203,6 +213,20 @@ type TablePlan struct {
 	FieldsToSkip            map[string]bool
 	ConvertCharset          map[string](*binlogdatapb.CharsetConversion)
 	HasExtraSourcePkColumns bool
+	// ColumnTransforms are applied to the values of the fields
+	// they're keyed by, before they're written to the target.
+	ColumnTransforms map[string]ColumnTransform
+	// Replace is used instead of Insert if the plan is
+	// Idempotent. It's nil if the plan has a group by.
+	Replace *sqlparser.ParsedQuery
+	// Idempotent is set while tables are copied concurrently,
+	// for the tables whose rows may already reflect the changes
+	// being applied, because they were copied from a snapshot
+	// past the position of the stream. Rows are then replaced
+	// instead of inserted, and updates are applied as a delete
+	// followed by a replace, so that replaying an event
+	// converges to the same row.
+	Idempotent bool
 }
 
 // MarshalJSON performs a custom JSON Marshalling.
@@ -233,13 +257,23 @@ func (tp *TablePlan) MarshalJSON() ([]byte, error) {
 
 func (tp *TablePlan) applyBulkInsert(sqlbuffer *bytes2.Buffer, rows *binlogdatapb.VStreamRowsResponse, executor func(string) (*sqltypes.Result, error)) (*sqltypes.Result, error) {
 	sqlbuffer.Reset()
-	sqlbuffer.WriteString(tp.BulkInsertFront.Query)
+	if tp.Idempotent {
+		sqlbuffer.WriteString(tp.BulkReplaceFront.Query)
+	} else {
+		sqlbuffer.WriteString(tp.BulkInsertFront.Query)
+	}
 	sqlbuffer.WriteString(" values ")
 
 	for i, row := range rows.Rows {
 		if i > 0 {
 			sqlbuffer.WriteString(", ")
 		}
+		if len(tp.ColumnTransforms) > 0 {
+			var err error
+			if row, err = tp.transformRow(row); err != nil {
+				return nil, err
+			}
+		}
 		if err := tp.BulkInsertValues.AppendFromRow(sqlbuffer, tp.Fields, row, tp.FieldsToSkip); err != nil {
 			return nil, err
 		}
@@ -299,8 +333,16 @@ func (tp *TablePlan) isOutsidePKRange(bindvars map[string]*querypb.BindVariable,
 // Most values will just bind directly. But some values may need manipulation:
 // - text values with charset conversion
 // - enum values converted to text via Online DDL
+// - values transformed by a column transform of the workflow
 // - ...any other future possible values
 func (tp *TablePlan) bindFieldVal(field *querypb.Field, val *sqltypes.Value) (*querypb.BindVariable, error) {
+	if transform, ok := tp.ColumnTransforms[field.Name]; ok && !val.IsNull() {
+		transformed, err := transform.Transform(*val)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "column transform for %s", field.Name)
+		}
+		val = &transformed
+	}
 	if conversion, ok := tp.ConvertCharset[field.Name]; ok && !val.IsNull() {
 		// Non-null string value, for which we have a charset conversion instruction
 		valString := val.ToString()
@@ -377,14 +419,14 @@ func (tp *TablePlan) applyChange(rowChange *binlogdatapb.RowChange, executor fun
 		if tp.isOutsidePKRange(bindvars, before, after, "insert") {
 			return nil, nil
 		}
-		return execParsedQuery(tp.Insert, bindvars, executor)
+		return execParsedQuery(tp.insertStatement(), bindvars, executor)
 	case before && !after:
 		if tp.Delete == nil {
 			return nil, nil
 		}
 		return execParsedQuery(tp.Delete, bindvars, executor)
 	case before && after:
-		if !tp.pkChanged(bindvars) && !tp.HasExtraSourcePkColumns {
+		if !tp.pkChanged(bindvars) && !tp.HasExtraSourcePkColumns && !tp.Idempotent {
 			return execParsedQuery(tp.Update, bindvars, executor)
 		}
 		if tp.Delete != nil {
@@ -395,12 +437,20 @@ func (tp *TablePlan) applyChange(rowChange *binlogdatapb.RowChange, executor fun
 		if tp.isOutsidePKRange(bindvars, before, after, "insert") {
 			return nil, nil
 		}
-		return execParsedQuery(tp.Insert, bindvars, executor)
+		return execParsedQuery(tp.insertStatement(), bindvars, executor)
 	}
 	// Unreachable.
 	return nil, nil
 }
 
+// insertStatement returns the statement used to apply a row insert.
+func (tp *TablePlan) insertStatement() *sqlparser.ParsedQuery {
+	if tp.Idempotent {
+		return tp.Replace
+	}
+	return tp.Insert
+}
+
 func execParsedQuery(pq *sqlparser.ParsedQuery, bindvars map[string]*querypb.BindVariable, executor func(string) (*sqltypes.Result, error)) (*sqltypes.Result, error) {
 	sql, err := pq.GenerateQuery(bindvars, nil)
 	if err != nil {
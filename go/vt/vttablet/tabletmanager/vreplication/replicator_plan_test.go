@@ -24,9 +24,11 @@ import (
 	"vitess.io/vitess/go/vt/binlog/binlogplayer"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"vitess.io/vitess/go/sqltypes"
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
 )
 
 type TestReplicatorPlan struct {
@@ -772,3 +774,49 @@ func TestBuildPlayerPlanExclude(t *testing.T) {
 	wantPlan, _ := json.Marshal(want)
 	assert.Equal(t, string(gotPlan), string(wantPlan))
 }
+
+func TestTablePlanIdempotent(t *testing.T) {
+	PrimaryKeyInfos := map[string][]*ColumnInfo{
+		"t1": {&ColumnInfo{Name: "c1", IsPK: true}, &ColumnInfo{Name: "c2"}},
+	}
+	input := &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match:  "t1",
+			Filter: "select c1, c2 from t1",
+		}},
+	}
+	copyState := map[string]*sqltypes.Result{
+		"t1": sqltypes.MakeTestResult(sqltypes.MakeTestFields("c1", "int64"), "10"),
+	}
+	plan, err := buildReplicatorPlan(getSource(input), PrimaryKeyInfos, copyState, binlogplayer.NewStats())
+	require.NoError(t, err)
+	tplan, err := plan.buildExecutionPlan(&binlogdatapb.FieldEvent{
+		TableName: "t1",
+		Fields:    sqltypes.MakeTestFields("c1|c2", "int64|varchar"),
+	})
+	require.NoError(t, err)
+	tplan.Idempotent = true
+
+	var queries []string
+	executor := func(sql string) (*sqltypes.Result, error) {
+		queries = append(queries, sql)
+		return &sqltypes.Result{}, nil
+	}
+	row := func(c1 int64, c2 string) *querypb.Row {
+		return sqltypes.RowToProto3([]sqltypes.Value{sqltypes.NewInt64(c1), sqltypes.NewVarChar(c2)})
+	}
+
+	// Inserts replace the row, and updates delete it before replacing it.
+	_, err = tplan.applyChange(&binlogdatapb.RowChange{After: row(1, "a")}, executor)
+	require.NoError(t, err)
+	_, err = tplan.applyChange(&binlogdatapb.RowChange{Before: row(1, "a"), After: row(1, "b")}, executor)
+	require.NoError(t, err)
+	_, err = tplan.applyChange(&binlogdatapb.RowChange{Before: row(1, "b")}, executor)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"replace into t1(c1,c2) select 1, 'a' from dual where (1) <= (10)",
+		"delete from t1 where c1=1 and (1) <= (10)",
+		"replace into t1(c1,c2) select 1, 'b' from dual where (1) <= (10)",
+		"delete from t1 where c1=1 and (1) <= (10)",
+	}, queries)
+}
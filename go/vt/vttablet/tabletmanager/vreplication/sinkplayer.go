@@ -0,0 +1,447 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/vttablet/tabletmanager/sink"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// sinkPlayer streams the binlog events of a sink workflow into its sink,
+// instead of applying them to the target database. There is no copy
+// phase: the stream starts at its start position, or at the current
+// position of the source if it has none.
+//
+// The records of a transaction are written to the sink on commit, and
+// the position is saved after the sink is flushed. Sinks that keep the
+// position of their last flush with the records, like the file sink,
+// let a restarted stream resume right after the last flushed transaction,
+// so they receive every change exactly once. Other sinks, like the kafka
+// sink, receive the records written after the last save again when the
+// stream restarts, so they receive every change at least once.
+type sinkPlayer struct {
+	vr       *vreplicator
+	startPos mysql.Position
+	stopPos  mysql.Position
+
+	sink    sink.Sink
+	encoder sink.Encoder
+	// tables are the source tables matched by name, which are used to
+	// validate journals of table migrations.
+	tables map[string]bool
+	fields map[string][]*querypb.Field
+	// changes are the changes of the current transaction, which are
+	// encoded on commit, once their position is known.
+	changes []*sink.Change
+
+	pos mysql.Position
+	// savePos is the position of the last complete transaction, which
+	// is saved at the end of each batch of events.
+	savePos      mysql.Position
+	savePosTs    int64
+	unsaved      bool
+	unflushed    bool
+	timeLastSave time.Time
+
+	lastTimestampNs          int64
+	timeOffsetNs             int64
+	numAccumulatedHeartbeats int
+}
+
+func newSinkPlayer(vr *vreplicator) *sinkPlayer {
+	return &sinkPlayer{
+		vr:           vr,
+		tables:       make(map[string]bool),
+		fields:       make(map[string][]*querypb.Field),
+		timeLastSave: time.Now(),
+	}
+}
+
+// play streams events into the sink until the context is canceled, the
+// stop position is reached, or an error occurs.
+func (sp *sinkPlayer) play(ctx context.Context) error {
+	settings, _, err := sp.vr.readSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if settings.State == binlogplayer.BlpStopped || settings.State == binlogplayer.BlpError {
+		return nil
+	}
+	sp.startPos, sp.stopPos = settings.StartPos, settings.StopPos
+	sp.pos, sp.savePos = settings.StartPos, settings.StartPos
+
+	spec := sp.vr.source.Sink
+	topicPrefix := spec.Params["topic_prefix"]
+	if topicPrefix == "" {
+		topicPrefix = settings.WorkflowName
+	}
+	if sp.encoder, err = sink.NewEncoder(spec.Format, topicPrefix, spec.Params); err != nil {
+		return err
+	}
+	if sp.sink, err = sink.New(fmt.Sprintf("%s_%d", settings.WorkflowName, sp.vr.id), spec); err != nil {
+		return err
+	}
+	defer sp.sink.Close()
+
+	// The stream may have stopped after the sink was flushed, but before
+	// the position was saved. It then resumes at the position of the sink.
+	if position := sp.sink.Position(); position != "" {
+		sinkPos, err := binlogplayer.DecodePosition(position)
+		if err != nil {
+			return err
+		}
+		if !sinkPos.Equal(sp.startPos) && sinkPos.AtLeast(sp.startPos) {
+			log.Infof("Sink of stream %v is at position %v, after the saved position %v", sp.vr.id, sinkPos, sp.startPos)
+			sp.startPos, sp.pos = sinkPos, sinkPos
+			sp.setSavePos(0)
+			if posReached, err := sp.save(ctx); err != nil || posReached {
+				return err
+			}
+		}
+	}
+
+	if !sp.stopPos.IsZero() && sp.startPos.AtLeast(sp.stopPos) {
+		return sp.vr.setState(binlogplayer.BlpStopped, fmt.Sprintf("Stop position %v already reached: %v", sp.startPos, sp.stopPos))
+	}
+	if err := sp.vr.setState(binlogplayer.BlpRunning, ""); err != nil {
+		return err
+	}
+
+	filter, err := sp.buildVStreamFilter()
+	if err != nil {
+		sp.vr.stats.ErrorCounts.Add([]string{"Plan"}, 1)
+		return err
+	}
+	return sp.fetchAndApply(ctx, filter)
+}
+
+// buildVStreamFilter returns the filter of the stream. Since there are
+// no target tables, rules with a select filter match the table they
+// select from.
+func (sp *sinkPlayer) buildVStreamFilter() (*binlogdatapb.Filter, error) {
+	filter := &binlogdatapb.Filter{FieldEventMode: sp.vr.source.Filter.FieldEventMode}
+	for _, rule := range sp.vr.source.Filter.Rules {
+		match := rule.Match
+		if rule.Filter != "" && !key.IsKeyRange(rule.Filter) {
+			_, from, err := analyzeSelectFrom(rule.Filter)
+			if err != nil {
+				return nil, err
+			}
+			match = from
+		}
+		if match == "" || match[0] != '/' {
+			sp.tables[match] = true
+		}
+		filter.Rules = append(filter.Rules, &binlogdatapb.Rule{Match: match, Filter: rule.Filter})
+	}
+	return filter, nil
+}
+
+// fetchAndApply fetches events into a relay log and applies them,
+// like vplayer.fetchAndApply.
+func (sp *sinkPlayer) fetchAndApply(ctx context.Context, filter *binlogdatapb.Filter) error {
+	startPos := "current"
+	if !sp.startPos.IsZero() {
+		startPos = mysql.EncodePosition(sp.startPos)
+	}
+	log.Infof("Starting VReplication sink player id: %v, startPos: %v, stop: %v, filter: %v", sp.vr.id, startPos, sp.stopPos, sp.vr.source)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	relay := newRelayLog(ctx, relayLogMaxItems, relayLogMaxSize)
+
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- sp.vr.sourceVStreamer.VStream(ctx, startPos, nil, filter, func(events []*binlogdatapb.VEvent) error {
+			return relay.Send(events)
+		})
+	}()
+
+	applyErr := make(chan error, 1)
+	go func() {
+		applyErr <- sp.applyEvents(ctx, relay)
+	}()
+
+	select {
+	case err := <-applyErr:
+		defer func() {
+			cancel()
+			<-streamErr
+		}()
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	case err := <-streamErr:
+		defer func() {
+			cancel()
+			<-applyErr
+		}()
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		if err == nil || err == io.EOF {
+			return errors.New("vstream ended")
+		}
+		return err
+	}
+}
+
+func (sp *sinkPlayer) applyEvents(ctx context.Context, relay *relayLog) error {
+	defer sp.vr.stats.ReplicationLagSeconds.Set(math.MaxInt64)
+	defer sp.vr.stats.VReplicationLags.Add(strconv.Itoa(int(sp.vr.id)), math.MaxInt64)
+	throttlerAppName := sp.vr.throttlerAppName()
+	var sbm int64 = -1
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !sp.vr.vre.throttlerClient.ThrottleCheckOKOrWaitAppName(ctx, throttlerAppName) {
+			_ = sp.vr.updateTimeThrottled(VPlayerComponentName)
+			continue
+		}
+
+		items, err := relay.Fetch()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			behind := time.Now().UnixNano() - sp.lastTimestampNs - sp.timeOffsetNs
+			sp.vr.stats.ReplicationLagSeconds.Set(behind / 1e9)
+			sp.vr.stats.VReplicationLags.Add(strconv.Itoa(int(sp.vr.id)), time.Duration(behind/1e9)*time.Second)
+		}
+		for _, events := range items {
+			for _, event := range events {
+				if event.Timestamp != 0 {
+					sp.lastTimestampNs = event.Timestamp * 1e9
+					sp.timeOffsetNs = time.Now().UnixNano() - event.CurrentTime
+					sbm = event.CurrentTime/1e9 - event.Timestamp
+				}
+				if err := sp.applyEvent(ctx, event); err != nil {
+					if err != io.EOF {
+						sp.vr.stats.ErrorCounts.Add([]string{"Apply"}, 1)
+						log.Errorf("Error applying event to sink: %s", err.Error())
+					}
+					return err
+				}
+			}
+		}
+		// Records are flushed at the end of each batch, and positions
+		// that only skip events are saved at most once every idleTimeout.
+		if sp.unflushed || (sp.unsaved && time.Since(sp.timeLastSave) >= idleTimeout) {
+			posReached, err := sp.save(ctx)
+			if err != nil {
+				return err
+			}
+			if posReached {
+				return io.EOF
+			}
+		}
+
+		if sbm >= 0 {
+			sp.vr.stats.ReplicationLagSeconds.Set(sbm)
+			sp.vr.stats.VReplicationLags.Add(strconv.Itoa(int(sp.vr.id)), time.Duration(sbm)*time.Second)
+		}
+	}
+}
+
+func (sp *sinkPlayer) applyEvent(ctx context.Context, event *binlogdatapb.VEvent) error {
+	switch event.Type {
+	case binlogdatapb.VEventType_GTID:
+		pos, err := binlogplayer.DecodePosition(event.Gtid)
+		if err != nil {
+			return err
+		}
+		sp.pos = pos
+	case binlogdatapb.VEventType_FIELD:
+		sp.fields[event.FieldEvent.TableName] = event.FieldEvent.Fields
+	case binlogdatapb.VEventType_ROW:
+		fields, ok := sp.fields[event.RowEvent.TableName]
+		if !ok {
+			return fmt.Errorf("unexpected event on table %s", event.RowEvent.TableName)
+		}
+		for _, change := range event.RowEvent.RowChanges {
+			c := &sink.Change{
+				Keyspace:  sp.vr.source.Keyspace,
+				Shard:     sp.vr.source.Shard,
+				Table:     event.RowEvent.TableName,
+				Fields:    fields,
+				Timestamp: event.Timestamp,
+			}
+			if change.Before != nil {
+				c.Before = sqltypes.MakeRowTrusted(fields, change.Before)
+			}
+			if change.After != nil {
+				c.After = sqltypes.MakeRowTrusted(fields, change.After)
+			}
+			sp.changes = append(sp.changes, c)
+		}
+	case binlogdatapb.VEventType_COMMIT:
+		if len(sp.changes) > 0 {
+			position := mysql.EncodePosition(sp.pos)
+			records := make([]*sink.Record, 0, len(sp.changes))
+			for _, change := range sp.changes {
+				change.Position = position
+				record, err := sp.encoder.Encode(change)
+				if err != nil {
+					return err
+				}
+				records = append(records, record)
+			}
+			start := time.Now()
+			if err := sp.sink.Write(ctx, records); err != nil {
+				return err
+			}
+			sp.vr.stats.QueryTimings.Record("sink", start)
+			sp.vr.stats.QueryCount.Add("sink", 1)
+			sp.changes = nil
+			sp.unflushed = true
+		}
+		sp.setSavePos(event.Timestamp)
+	case binlogdatapb.VEventType_OTHER:
+		sp.setSavePos(event.Timestamp)
+	case binlogdatapb.VEventType_DDL:
+		sp.setSavePos(event.Timestamp)
+		// DDLs can't be applied to a sink. Their effect on the rows
+		// is reflected by the fields that follow them.
+		if sp.vr.source.OnDdl == binlogdatapb.OnDDLAction_STOP {
+			if _, err := sp.save(ctx); err != nil {
+				return err
+			}
+			if err := sp.vr.setState(binlogplayer.BlpStopped, fmt.Sprintf("Stopped at DDL %s", event.Statement)); err != nil {
+				return err
+			}
+			return io.EOF
+		}
+	case binlogdatapb.VEventType_JOURNAL:
+		if event.Journal.MigrationType == binlogdatapb.MigrationType_TABLES {
+			jtables := make(map[string]bool)
+			for _, table := range event.Journal.Tables {
+				jtables[table] = true
+			}
+			found, notFound := false, false
+			for table := range sp.tables {
+				if jtables[table] {
+					found = true
+				} else {
+					notFound = true
+				}
+			}
+			switch {
+			case found && notFound:
+				if err := sp.vr.setState(binlogplayer.BlpStopped, "unable to handle journal event: tables were partially matched"); err != nil {
+					return err
+				}
+				return io.EOF
+			case notFound:
+				return nil
+			}
+		}
+		// The records written so far must be flushed before the
+		// streams of the new sources take over.
+		if _, err := sp.save(ctx); err != nil {
+			return err
+		}
+		log.Infof("Sink player registering journal event %+v", event.Journal)
+		if err := sp.vr.vre.registerJournal(event.Journal, int(sp.vr.id)); err != nil {
+			if err := sp.vr.setState(binlogplayer.BlpStopped, err.Error()); err != nil {
+				return err
+			}
+		}
+		return io.EOF
+	case binlogdatapb.VEventType_HEARTBEAT:
+		if event.Throttled {
+			if err := sp.vr.updateTimeThrottled(VStreamerComponentName); err != nil {
+				return err
+			}
+		}
+		if len(sp.changes) == 0 {
+			tm := time.Now().Unix()
+			sp.vr.stats.RecordHeartbeat(tm)
+			sp.numAccumulatedHeartbeats++
+			if sp.numAccumulatedHeartbeats >= vreplicationHeartbeatUpdateInterval ||
+				sp.numAccumulatedHeartbeats >= vreplicationMinimumHeartbeatUpdateInterval {
+				sp.numAccumulatedHeartbeats = 0
+				return sp.vr.updateHeartbeatTime(tm)
+			}
+		}
+	}
+	return nil
+}
+
+// setSavePos marks the current position as the end of a complete
+// transaction, to be saved with the next save.
+func (sp *sinkPlayer) setSavePos(ts int64) {
+	if sp.pos.IsZero() {
+		// The stream started at the current position, and has not
+		// received a GTID yet.
+		return
+	}
+	sp.savePos = sp.pos
+	sp.savePosTs = ts
+	sp.unsaved = true
+}
+
+// save flushes the sink and saves the position of the last complete
+// transaction. It returns true if the stop position was reached.
+func (sp *sinkPlayer) save(ctx context.Context) (posReached bool, err error) {
+	if sp.unflushed {
+		start := time.Now()
+		if err := sp.sink.Flush(ctx, mysql.EncodePosition(sp.savePos)); err != nil {
+			return false, fmt.Errorf("error %v flushing sink", err)
+		}
+		sp.vr.stats.QueryTimings.Record("sink", start)
+		sp.unflushed = false
+	}
+	if !sp.unsaved {
+		return false, nil
+	}
+	sp.numAccumulatedHeartbeats = 0
+	update := binlogplayer.GenerateUpdatePos(sp.vr.id, sp.savePos, time.Now().Unix(), sp.savePosTs, sp.vr.stats.CopyRowCount.Get(), vreplicationStoreCompressedGTID)
+	if _, err := sp.vr.dbClient.Execute(update); err != nil {
+		return false, fmt.Errorf("error %v updating position", err)
+	}
+	sp.unsaved = false
+	sp.timeLastSave = time.Now()
+	sp.vr.stats.SetLastPosition(sp.savePos)
+	if !sp.stopPos.IsZero() && sp.savePos.AtLeast(sp.stopPos) {
+		log.Infof("Stopped at position: %v", sp.stopPos)
+		if err := sp.vr.setState(binlogplayer.BlpStopped, fmt.Sprintf("Stopped at position %v", sp.stopPos)); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
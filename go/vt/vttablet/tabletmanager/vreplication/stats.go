@@ -98,6 +98,34 @@ func (st *vrStats) register() {
 			return result
 		})
 
+	stats.NewGaugesFuncWithMultiLabels(
+		"VReplicationParallelApplyQueueDepth",
+		"vreplication transactions received but not yet saved per stream applying transactions concurrently",
+		[]string{"source_keyspace", "source_shard", "workflow", "counts"},
+		func() map[string]int64 {
+			st.mu.Lock()
+			defer st.mu.Unlock()
+			result := make(map[string]int64, len(st.controllers))
+			for _, ct := range st.controllers {
+				result[ct.source.Keyspace+"."+ct.source.Shard+"."+ct.workflow+"."+fmt.Sprintf("%v", ct.id)] = ct.blpStats.ParallelApplyQueueDepth.Get()
+			}
+			return result
+		})
+
+	stats.NewGaugesFuncWithMultiLabels(
+		"VReplicationParallelApplyLagSeconds",
+		"vreplication seconds the saved position trails the last transaction received per stream applying transactions concurrently",
+		[]string{"source_keyspace", "source_shard", "workflow", "counts"},
+		func() map[string]int64 {
+			st.mu.Lock()
+			defer st.mu.Unlock()
+			result := make(map[string]int64, len(st.controllers))
+			for _, ct := range st.controllers {
+				result[ct.source.Keyspace+"."+ct.source.Shard+"."+ct.workflow+"."+fmt.Sprintf("%v", ct.id)] = ct.blpStats.ParallelApplyLagSeconds.Get()
+			}
+			return result
+		})
+
 	stats.NewRateFunc(
 		"VReplicationQPS",
 		"vreplication operations per second aggregated across all streams",
@@ -402,6 +430,8 @@ func (st *vrStats) status() *EngineStatus {
 			CopyRowCount:          ct.blpStats.CopyRowCount.Get(),
 			CopyLoopCount:         ct.blpStats.CopyLoopCount.Get(),
 			NoopQueryCounts:       ct.blpStats.NoopQueryCount.Counts(),
+			ApplyQueueDepth:       ct.blpStats.ParallelApplyQueueDepth.Get(),
+			ApplyLagSeconds:       ct.blpStats.ParallelApplyLagSeconds.Get(),
 		}
 		i++
 	}
@@ -434,6 +464,8 @@ type ControllerStatus struct {
 	CopyRowCount          int64
 	CopyLoopCount         int64
 	NoopQueryCounts       map[string]int64
+	ApplyQueueDepth       int64
+	ApplyLagSeconds       int64
 }
 
 var vreplicationTemplate = `
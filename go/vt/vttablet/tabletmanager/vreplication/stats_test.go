@@ -161,6 +161,11 @@ func TestVReplicationStats(t *testing.T) {
 	require.Equal(t, int64(100), testStats.status().Controllers[0].CopyLoopCount)
 	require.Equal(t, int64(200), testStats.status().Controllers[0].CopyRowCount)
 
+	blpStats.ParallelApplyQueueDepth.Set(3)
+	blpStats.ParallelApplyLagSeconds.Set(2)
+	require.Equal(t, int64(3), testStats.status().Controllers[0].ApplyQueueDepth)
+	require.Equal(t, int64(2), testStats.status().Controllers[0].ApplyLagSeconds)
+
 	var tm int64 = 1234567890
 	blpStats.RecordHeartbeat(tm)
 	require.Equal(t, tm, blpStats.Heartbeat())
@@ -221,6 +221,10 @@ func buildTablePlan(tableName string, rule *binlogdatapb.Rule, colInfos []*Colum
 		tokensMap := schema.ParseEnumOrSetTokensMap(v)
 		enumValuesMap[k] = tokensMap
 	}
+	columnTransforms, err := newColumnTransforms(rule.ColumnTransforms)
+	if err != nil {
+		return nil, err
+	}
 
 	if expr, ok := sel.SelectExprs[0].(*sqlparser.StarExpr); ok {
 		// If it's a "select *", we return a partial plan, and complete
@@ -239,6 +243,8 @@ func buildTablePlan(tableName string, rule *binlogdatapb.Rule, colInfos []*Colum
 			Stats:          stats,
 			EnumValuesMap:  enumValuesMap,
 			ConvertCharset: rule.ConvertCharset,
+			// The transforms are bound to fields once they are known.
+			ColumnTransforms: columnTransforms,
 		}
 
 		return tablePlan, nil
@@ -316,6 +322,10 @@ func buildTablePlan(tableName string, rule *binlogdatapb.Rule, colInfos []*Colum
 	tablePlan.SendRule = sendRule
 	tablePlan.EnumValuesMap = enumValuesMap
 	tablePlan.ConvertCharset = rule.ConvertCharset
+	tablePlan.ColumnTransforms, err = tpb.bindColumnTransforms(columnTransforms, tablePlan.PKReferences)
+	if err != nil {
+		return nil, err
+	}
 	return tablePlan, nil
 }
 
@@ -352,7 +362,9 @@ func (tpb *tablePlanBuilder) generate() *TablePlan {
 		BulkInsertFront:         tpb.generateInsertPart(sqlparser.NewTrackedBuffer(bvf.formatter)),
 		BulkInsertValues:        tpb.generateValuesPart(sqlparser.NewTrackedBuffer(bvf.formatter), bvf),
 		BulkInsertOnDup:         tpb.generateOnDupPart(sqlparser.NewTrackedBuffer(bvf.formatter)),
+		BulkReplaceFront:        tpb.generateBulkReplaceFront(sqlparser.NewTrackedBuffer(bvf.formatter)),
 		Insert:                  tpb.generateInsertStatement(),
+		Replace:                 tpb.generateReplaceStatement(),
 		Update:                  tpb.generateUpdateStatement(),
 		Delete:                  tpb.generateDeleteStatement(),
 		PKReferences:            pkrefs,
@@ -643,12 +655,47 @@ func (tpb *tablePlanBuilder) generateInsertStatement() *sqlparser.ParsedQuery {
 	return buf.ParsedQuery()
 }
 
+// generateReplaceStatement generates the idempotent counterpart of the
+// insert statement. Only plans without a group by have one, because
+// the rows of grouped plans accumulate the values of several events.
+func (tpb *tablePlanBuilder) generateReplaceStatement() *sqlparser.ParsedQuery {
+	if tpb.onInsert != insertNormal {
+		return nil
+	}
+	bvf := &bindvarFormatter{}
+	buf := sqlparser.NewTrackedBuffer(bvf.formatter)
+
+	buf.Myprintf("replace into %v(", tpb.name)
+	tpb.generateColumnsPart(buf)
+	if tpb.lastpk == nil {
+		buf.Myprintf(" values ", tpb.name)
+		tpb.generateValuesPart(buf, bvf)
+	} else {
+		tpb.generateSelectPart(buf, bvf)
+	}
+	return buf.ParsedQuery()
+}
+
+// generateBulkReplaceFront generates the idempotent counterpart of
+// BulkInsertFront, for the plans that have a Replace statement.
+func (tpb *tablePlanBuilder) generateBulkReplaceFront(buf *sqlparser.TrackedBuffer) *sqlparser.ParsedQuery {
+	if tpb.onInsert != insertNormal {
+		return nil
+	}
+	buf.Myprintf("replace into %v(", tpb.name)
+	return tpb.generateColumnsPart(buf)
+}
+
 func (tpb *tablePlanBuilder) generateInsertPart(buf *sqlparser.TrackedBuffer) *sqlparser.ParsedQuery {
 	if tpb.onInsert == insertIgnore {
 		buf.Myprintf("insert ignore into %v(", tpb.name)
 	} else {
 		buf.Myprintf("insert into %v(", tpb.name)
 	}
+	return tpb.generateColumnsPart(buf)
+}
+
+func (tpb *tablePlanBuilder) generateColumnsPart(buf *sqlparser.TrackedBuffer) *sqlparser.ParsedQuery {
 	separator := ""
 	for _, cexpr := range tpb.colExprs {
 		if tpb.isColumnGenerated(cexpr.colName) {
@@ -110,41 +110,71 @@ func (vc *vcopier) initTablesForCopy(ctx context.Context) error {
 // copyNext also builds the copyState metadata that contains the tables and their last
 // primary key that was copied. A nil Result means that nothing has been copied.
 // A table that was fully copied is removed from copyState.
+// If the stream copies tables concurrently, steps 2, 3 and 4 are instead performed
+// by copyTablesConcurrently, for several tables at a time.
 func (vc *vcopier) copyNext(ctx context.Context, settings binlogplayer.VRSettings) error {
-	qr, err := vc.vr.dbClient.Execute(fmt.Sprintf("select table_name, lastpk from _vt.copy_state where vrepl_id=%d", vc.vr.id))
+	tables, copyState, snapshots, err := vc.readCopyState()
 	if err != nil {
 		return err
 	}
-	var tableToCopy string
+	if len(copyState) == 0 {
+		return fmt.Errorf("unexpected: there are no tables to copy")
+	}
+	if err := vc.catchup(ctx, copyState, snapshots); err != nil {
+		return err
+	}
+	workers, err := vc.copyWorkers()
+	if err != nil {
+		return err
+	}
+	// If some rows were copied from snapshots that the stream hasn't
+	// fast-forwarded to yet, the copy has to go on concurrently, even
+	// if the number of workers was lowered in the meantime.
+	if workers > 1 || len(snapshots) != 0 {
+		return vc.copyTablesConcurrently(ctx, tables, copyState, snapshots, workers)
+	}
+	return vc.copyTable(ctx, tables[0], copyState)
+}
+
+// readCopyState reads the tables left to copy from copy_state, in order,
+// along with their lastpk. If tables are being copied concurrently, it also
+// returns the positions of the snapshots their rows were copied from.
+func (vc *vcopier) readCopyState() ([]string, map[string]*sqltypes.Result, map[string]mysql.Position, error) {
+	qr, err := vc.vr.dbClient.Execute(fmt.Sprintf("select table_name, lastpk, pos from _vt.copy_state where vrepl_id=%d", vc.vr.id))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var tables []string
 	copyState := make(map[string]*sqltypes.Result)
+	snapshots := make(map[string]mysql.Position)
 	for _, row := range qr.Rows {
 		tableName := row[0].ToString()
 		lastpk := row[1].ToString()
-		if tableToCopy == "" {
-			tableToCopy = tableName
-		}
+		tables = append(tables, tableName)
 		copyState[tableName] = nil
 		if lastpk != "" {
 			var r querypb.QueryResult
 			if err := prototext.Unmarshal([]byte(lastpk), &r); err != nil {
-				return err
+				return nil, nil, nil, err
 			}
 			copyState[tableName] = sqltypes.Proto3ToResult(&r)
 		}
+		if pos := row[2].ToString(); pos != "" {
+			snapshot, err := mysql.DecodePosition(pos)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			snapshots[tableName] = snapshot
+		}
 	}
-	if len(copyState) == 0 {
-		return fmt.Errorf("unexpected: there are no tables to copy")
-	}
-	if err := vc.catchup(ctx, copyState); err != nil {
-		return err
-	}
-	return vc.copyTable(ctx, tableToCopy, copyState)
+	return tables, copyState, snapshots, nil
 }
 
 // catchup replays events to the subset of the tables that have been copied
 // until replication is caught up. In order to stop, the seconds behind primary has
-// to fall below replicationLagTolerance.
-func (vc *vcopier) catchup(ctx context.Context, copyState map[string]*sqltypes.Result) error {
+// to fall below replicationLagTolerance. Events are replayed idempotently for the
+// tables whose rows were copied from the given snapshots.
+func (vc *vcopier) catchup(ctx context.Context, copyState map[string]*sqltypes.Result, snapshots map[string]mysql.Position) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	defer vc.vr.stats.PhaseTimings.Record("catchup", time.Now())
@@ -161,8 +191,10 @@ func (vc *vcopier) catchup(ctx context.Context, copyState map[string]*sqltypes.R
 
 	// Start vreplication.
 	errch := make(chan error, 1)
+	vp := newVPlayer(vc.vr, settings, copyState, mysql.Position{}, "catchup")
+	vp.idempotentTables = snapshotTables(snapshots)
 	go func() {
-		errch <- newVPlayer(vc.vr, settings, copyState, mysql.Position{}, "catchup").play(ctx)
+		errch <- vp.play(ctx)
 	}()
 
 	// Wait for catchup.
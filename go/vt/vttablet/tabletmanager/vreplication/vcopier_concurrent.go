@@ -0,0 +1,515 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+
+	"vitess.io/vitess/go/bytes2"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// concurrentCopy copies several tables, and several batches of rows of
+// each table, at the same time, during one iteration of the copy phase.
+//
+// Every table being copied has a reader, which streams its rows from the
+// source and sends them as batches to a pool of inserters. Each inserter
+// has its own connection, and inserts a batch in a transaction that also
+// updates the lastpk of the table in copy_state. The batches of a table are
+// inserted concurrently but committed in order, so that lastpk always covers
+// exactly the rows that were committed. A table is not split into ranges
+// of its primary key: it is read by a single reader, and has a single row
+// in copy_state.
+//
+// Unlike copyTable, the stream is not fast-forwarded to the snapshot of each
+// table, since the tables are copied from different snapshots. Instead, the
+// position of the stream is left untouched while copying, and is therefore
+// never past the snapshots. Each snapshot is saved in the pos column of
+// copy_state, and the events up to it are replayed idempotently for its table
+// (see TablePlan.Idempotent). At the end of the iteration, the stream is
+// fast-forwarded through the snapshots, in order: a table is done once the
+// stream reaches the snapshot it was fully copied from.
+type concurrentCopy struct {
+	vc   *vcopier
+	plan *ReplicatorPlan
+
+	batches chan *copyBatch
+	errors  concurrency.FirstErrorRecorder
+	cancel  context.CancelFunc
+
+	// mu protects the connection of the stream, which is shared
+	// by the readers, and the fields below.
+	mu sync.Mutex
+	// startPos is the position of the stream.
+	startPos mysql.Position
+	// snapshots contains the positions of the snapshots
+	// of all the tables that were copied concurrently.
+	snapshots map[string]mysql.Position
+	// finished contains the tables that were fully copied.
+	finished map[string]bool
+}
+
+// tableCopy is the state of the reader of a table.
+type tableCopy struct {
+	name            string
+	tablePlan       *TablePlan
+	pkfields        []*querypb.Field
+	snapshot        string
+	updateCopyState *sqlparser.ParsedQuery
+}
+
+// copyBatch is a batch of rows of a table.
+type copyBatch struct {
+	table  *tableCopy
+	rows   *binlogdatapb.VStreamRowsResponse
+	lastpk []byte
+	// prev is closed once the previous batch of the
+	// table is committed, and done once this one is.
+	prev <-chan struct{}
+	done chan struct{}
+}
+
+// copyWorkers returns the number of workers used to copy the tables of
+// the stream. Tables are copied one at a time unless the events of all
+// of them can be replayed idempotently. Online DDL migrations are always
+// copied one batch at a time, as they rely on the errors of the target
+// to validate the rows against the new schema.
+func (vc *vcopier) copyWorkers() (int, error) {
+	if copyPhaseWorkers <= 1 || vc.vr.WorkflowType == int32(binlogdatapb.VReplicationWorkflowType_OnlineDDL) {
+		return 1, nil
+	}
+	plan, err := buildReplicatorPlan(vc.vr.source, vc.vr.colInfoMap, nil, vc.vr.stats)
+	if err != nil {
+		return 0, err
+	}
+	for _, tablePlan := range plan.TargetTables {
+		// Plans with a group by have no Replace statement. Plans that
+		// are only built once the fields are known (select *) have none.
+		if tablePlan.Insert != nil && tablePlan.Replace == nil {
+			return 1, nil
+		}
+	}
+	return copyPhaseWorkers, nil
+}
+
+// copyTablesConcurrently copies the given tables with the given number of
+// workers, until they are all copied or copyPhaseDuration elapses. snapshots
+// contains the positions of the snapshots of the tables that were copied
+// concurrently by a previous iteration, if it didn't complete.
+func (vc *vcopier) copyTablesConcurrently(ctx context.Context, tables []string, copyState map[string]*sqltypes.Result, snapshots map[string]mysql.Position, workers int) error {
+	defer vc.vr.stats.PhaseTimings.Record("copy", time.Now())
+	defer vc.vr.stats.CopyLoopCount.Add(1)
+
+	plan, err := buildReplicatorPlan(vc.vr.source, vc.vr.colInfoMap, nil, vc.vr.stats)
+	if err != nil {
+		return err
+	}
+	settings, err := binlogplayer.ReadVRSettings(vc.vr.dbClient, vc.vr.id)
+	if err != nil {
+		return err
+	}
+
+	dbClients := make([]*vdbClient, 0, workers)
+	defer func() {
+		for _, dbClient := range dbClients {
+			dbClient.Close()
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		dbClient, err := vc.vr.newWorkerDBClient()
+		if err != nil {
+			return err
+		}
+		dbClients = append(dbClients, dbClient)
+	}
+
+	copyCtx, cancel := context.WithTimeout(ctx, copyPhaseDuration)
+	defer cancel()
+
+	cc := &concurrentCopy{
+		vc:        vc,
+		plan:      plan,
+		batches:   make(chan *copyBatch, workers),
+		cancel:    cancel,
+		startPos:  settings.StartPos,
+		snapshots: make(map[string]mysql.Position, len(snapshots)),
+		finished:  make(map[string]bool),
+	}
+	for tableName, pos := range snapshots {
+		cc.snapshots[tableName] = pos
+	}
+
+	var inserters sync.WaitGroup
+	for _, dbClient := range dbClients {
+		inserters.Add(1)
+		go func(dbClient *vdbClient) {
+			defer inserters.Done()
+			cc.insertBatches(copyCtx, dbClient)
+		}(dbClient)
+	}
+
+	rowsCopiedTicker := time.NewTicker(rowsCopiedUpdateInterval)
+	defer rowsCopiedTicker.Stop()
+
+	var readers sync.WaitGroup
+	slots := make(chan struct{}, workers)
+readTables:
+	for _, tableName := range tables {
+		select {
+		case slots <- struct{}{}:
+		case <-copyCtx.Done():
+			break readTables
+		}
+		started := make(chan struct{})
+		readers.Add(1)
+		go func(tableName string) {
+			defer readers.Done()
+			defer func() { <-slots }()
+			var once sync.Once
+			setStarted := func() { once.Do(func() { close(started) }) }
+			defer setStarted()
+			if err := cc.copyTable(copyCtx, tableName, copyState[tableName], rowsCopiedTicker.C, setStarted); err != nil {
+				cc.errors.RecordError(err)
+				cancel()
+			}
+		}(tableName)
+		// If the stream has no position yet, the snapshot of the
+		// first table becomes its position. The other tables must
+		// be copied from later snapshots.
+		if settings.StartPos.IsZero() {
+			select {
+			case <-started:
+			case <-copyCtx.Done():
+			}
+			settings.StartPos = cc.position()
+		}
+	}
+	readers.Wait()
+	close(cc.batches)
+	inserters.Wait()
+
+	if err := cc.errors.Error(); err != nil {
+		return err
+	}
+	// If the engine is shutting down, the next run will fast-forward.
+	if ctx.Err() != nil {
+		return nil
+	}
+	return cc.fastForward(ctx)
+}
+
+func (cc *concurrentCopy) position() mysql.Position {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.startPos
+}
+
+// copyTable streams the rows of a table, after lastpk, to the inserters.
+// started is called once the snapshot of the table is known.
+func (cc *concurrentCopy) copyTable(ctx context.Context, tableName string, lastpk *sqltypes.Result, rowsCopied <-chan time.Time, started func()) error {
+	vr := cc.vc.vr
+	log.Infof("Copying table %s, lastpk: %v", tableName, lastpk)
+
+	initialPlan, ok := cc.plan.TargetTables[tableName]
+	if !ok {
+		return fmt.Errorf("plan not found for table: %s, current plans are: %#v", tableName, cc.plan.TargetTables)
+	}
+	var lastpkpb *querypb.QueryResult
+	if lastpk != nil {
+		lastpkpb = sqltypes.ResultToProto3(lastpk)
+	}
+
+	table := &tableCopy{name: tableName}
+	// prev is the done channel of the last batch sent to the inserters.
+	prev := make(chan struct{})
+	close(prev)
+	err := vr.sourceVStreamer.VStreamRows(ctx, initialPlan.SendRule.Filter, lastpkpb, func(rows *binlogdatapb.VStreamRowsResponse) error {
+		for {
+			select {
+			case <-rowsCopied:
+				cc.mu.Lock()
+				update := binlogplayer.GenerateUpdateRowsCopied(vr.id, vr.stats.CopyRowCount.Get())
+				_, _ = vr.dbClient.Execute(update)
+				cc.mu.Unlock()
+			case <-ctx.Done():
+				return io.EOF
+			default:
+			}
+			if rows.Throttled {
+				cc.mu.Lock()
+				_ = vr.updateTimeThrottled(RowStreamerComponentName)
+				cc.mu.Unlock()
+				return nil
+			}
+			if rows.Heartbeat {
+				cc.mu.Lock()
+				_ = vr.updateHeartbeatTime(time.Now().Unix())
+				cc.mu.Unlock()
+				return nil
+			}
+			// verify throttler is happy, otherwise keep looping
+			if vr.vre.throttlerClient.ThrottleCheckOKOrWaitAppName(ctx, cc.vc.throttlerAppName) {
+				break // out of 'for' loop
+			} else { // we're throttled
+				cc.mu.Lock()
+				_ = vr.updateTimeThrottled(VCopierComponentName)
+				cc.mu.Unlock()
+			}
+		}
+		if table.tablePlan == nil {
+			if len(rows.Fields) == 0 {
+				return fmt.Errorf("expecting field event first, got: %v", rows)
+			}
+			if err := cc.setSnapshot(tableName, rows.Gtid); err != nil {
+				return err
+			}
+			started()
+			fieldEvent := &binlogdatapb.FieldEvent{
+				TableName: initialPlan.SendRule.Match,
+			}
+			fieldEvent.Fields = append(fieldEvent.Fields, rows.Fields...)
+			tablePlan, err := cc.plan.buildExecutionPlan(fieldEvent)
+			if err != nil {
+				return err
+			}
+			tablePlan.Idempotent = true
+			table.tablePlan = tablePlan
+			table.pkfields = append(table.pkfields, rows.Pkfields...)
+			table.snapshot = rows.Gtid
+			buf := sqlparser.NewTrackedBuffer(nil)
+			buf.Myprintf("update _vt.copy_state set lastpk=%a, pos=%a where vrepl_id=%s and table_name=%s", ":lastpk", ":pos", strconv.Itoa(int(vr.id)), encodeString(tableName))
+			table.updateCopyState = buf.ParsedQuery()
+		}
+		if len(rows.Rows) == 0 {
+			return nil
+		}
+
+		lastpk, err := prototext.Marshal(&querypb.QueryResult{
+			Fields: table.pkfields,
+			Rows:   []*querypb.Row{rows.Lastpk},
+		})
+		if err != nil {
+			return err
+		}
+		// The rows may be reused by the streamer once we return.
+		batch := &copyBatch{
+			table:  table,
+			rows:   proto.Clone(rows).(*binlogdatapb.VStreamRowsResponse),
+			lastpk: lastpk,
+			prev:   prev,
+			done:   make(chan struct{}),
+		}
+		select {
+		case cc.batches <- batch:
+		case <-ctx.Done():
+			return io.EOF
+		}
+		prev = batch.done
+		return nil
+	})
+	// If the iteration is over, the table will be copied further by the next one.
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	select {
+	case <-prev:
+	case <-ctx.Done():
+		return nil
+	}
+	log.Infof("Copy of %v finished at snapshot %v", tableName, table.snapshot)
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.finished[tableName] = true
+	return nil
+}
+
+// setSnapshot records the position of the snapshot of a table. If the
+// stream has no position yet, this position becomes the position of
+// the stream.
+func (cc *concurrentCopy) setSnapshot(tableName, gtid string) error {
+	vr := cc.vc.vr
+	pos, err := mysql.DecodePosition(gtid)
+	if err != nil {
+		return err
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.startPos.IsZero() {
+		update := binlogplayer.GenerateUpdatePos(vr.id, pos, time.Now().Unix(), 0, vr.stats.CopyRowCount.Get(), vreplicationStoreCompressedGTID)
+		if _, err := vr.dbClient.Execute(update); err != nil {
+			return err
+		}
+		cc.startPos = pos
+	}
+	if !pos.AtLeast(cc.startPos) {
+		return fmt.Errorf("snapshot of table %s at %v is behind the position of the stream: %v", tableName, pos, cc.startPos)
+	}
+	cc.snapshots[tableName] = pos
+	return nil
+}
+
+// insertBatches inserts the batches sent by the readers, until the
+// readers are done. If a batch fails, the iteration is canceled.
+func (cc *concurrentCopy) insertBatches(ctx context.Context, dbClient *vdbClient) {
+	var sqlbuffer bytes2.Buffer
+	for batch := range cc.batches {
+		if ctx.Err() != nil {
+			continue
+		}
+		if err := cc.insertBatch(ctx, dbClient, &sqlbuffer, batch); err != nil {
+			dbClient.Rollback()
+			if ctx.Err() == nil {
+				cc.errors.RecordError(err)
+			}
+			cc.cancel()
+		}
+	}
+}
+
+func (cc *concurrentCopy) insertBatch(ctx context.Context, dbClient *vdbClient, sqlbuffer *bytes2.Buffer, batch *copyBatch) error {
+	vr := cc.vc.vr
+	if err := dbClient.Begin(); err != nil {
+		return err
+	}
+	_, err := batch.table.tablePlan.applyBulkInsert(sqlbuffer, batch.rows, func(sql string) (*sqltypes.Result, error) {
+		start := time.Now()
+
+		qr, err := dbClient.ExecuteWithRetry(ctx, sql)
+		if err != nil {
+			return nil, err
+		}
+		vr.stats.QueryTimings.Record("copy", start)
+		vr.stats.CopyRowCount.Add(int64(qr.RowsAffected))
+		vr.stats.QueryCount.Add("copy", 1)
+		return qr, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit the batches of a table in order, so that lastpk
+	// doesn't skip the rows of a batch that failed.
+	select {
+	case <-batch.prev:
+	case <-ctx.Done():
+		return io.EOF
+	}
+	updateState, err := batch.table.updateCopyState.GenerateQuery(map[string]*querypb.BindVariable{
+		"lastpk": sqltypes.BytesBindVariable(batch.lastpk),
+		"pos":    sqltypes.StringBindVariable(batch.table.snapshot),
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := dbClient.Execute(updateState); err != nil {
+		return err
+	}
+	if err := dbClient.Commit(); err != nil {
+		return err
+	}
+	close(batch.done)
+	return nil
+}
+
+// fastForward fast-forwards the stream through the snapshots of the tables
+// that were copied concurrently, from the oldest to the most recent. Once the
+// stream reaches the snapshot of a table that was fully copied, the rows of
+// the table are consistent with its position, and the table is removed from
+// copy_state. Once it reaches the most recent one, the rows of all the tables
+// are, and the snapshots are cleared.
+func (cc *concurrentCopy) fastForward(ctx context.Context) error {
+	defer cc.vc.vr.stats.PhaseTimings.Record("fastforward", time.Now())
+	vr := cc.vc.vr
+
+	for len(cc.snapshots) != 0 {
+		var next mysql.Position
+		for _, pos := range cc.snapshots {
+			if next.IsZero() || next.AtLeast(pos) {
+				next = pos
+			}
+		}
+		settings, err := binlogplayer.ReadVRSettings(vr.dbClient, vr.id)
+		if err != nil {
+			return err
+		}
+		if !settings.StartPos.AtLeast(next) {
+			_, copyState, _, err := cc.vc.readCopyState()
+			if err != nil {
+				return err
+			}
+			vp := newVPlayer(vr, settings, copyState, next, "fastforward")
+			vp.idempotentTables = snapshotTables(cc.snapshots)
+			if err := vp.play(ctx); err != nil {
+				return err
+			}
+			if settings, err = binlogplayer.ReadVRSettings(vr.dbClient, vr.id); err != nil {
+				return err
+			}
+			if !settings.StartPos.AtLeast(next) {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("fast-forward stopped at %v, before the snapshot at %v", settings.StartPos, next)
+			}
+		}
+		for tableName, pos := range cc.snapshots {
+			if !next.AtLeast(pos) {
+				continue
+			}
+			if cc.finished[tableName] {
+				log.Infof("Copy of %v is consistent at %v", tableName, pos)
+				buf := sqlparser.NewTrackedBuffer(nil)
+				buf.Myprintf("delete from _vt.copy_state where vrepl_id=%s and table_name=%s", strconv.Itoa(int(vr.id)), encodeString(tableName))
+				if _, err := vr.dbClient.Execute(buf.String()); err != nil {
+					return err
+				}
+			}
+			delete(cc.snapshots, tableName)
+		}
+	}
+	_, err := vr.dbClient.Execute(fmt.Sprintf("update _vt.copy_state set pos=null where vrepl_id=%d", vr.id))
+	return err
+}
+
+// snapshotTables returns the names of the tables of snapshots.
+func snapshotTables(snapshots map[string]mysql.Position) map[string]bool {
+	tables := make(map[string]bool, len(snapshots))
+	for tableName := range snapshots {
+		tables[tableName] = true
+	}
+	return tables
+}
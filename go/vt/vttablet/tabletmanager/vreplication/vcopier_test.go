@@ -547,6 +547,111 @@ func TestPlayerCopyTables(t *testing.T) {
 
 }
 
+// TestPlayerCopyTablesConcurrently copies several tables, and several batches
+// of rows of each table, at the same time.
+func TestPlayerCopyTablesConcurrently(t *testing.T) {
+	defer deleteTablet(addTablet(100))
+
+	reset := vstreamer.AdjustPacketSize(1)
+	defer reset()
+
+	savedCopyPhaseWorkers := copyPhaseWorkers
+	copyPhaseWorkers = 4
+	defer func() { copyPhaseWorkers = savedCopyPhaseWorkers }()
+
+	// The queries of the workers are interleaved: check the data instead.
+	doNotLogDBQueries = true
+	defer func() { doNotLogDBQueries = false }()
+
+	execStatements(t, []string{
+		"create table src1(id int, val varbinary(128), primary key(id))",
+		"insert into src1 values(1, 'aaa'), (2, 'bbb'), (3, 'ccc'), (4, 'ddd'), (5, 'eee')",
+		fmt.Sprintf("create table %s.dst1(id int, val varbinary(128), primary key(id))", vrepldb),
+		"create table src2(id int, val varbinary(128), primary key(id))",
+		"insert into src2 values(1, 'fff'), (2, 'ggg')",
+		fmt.Sprintf("create table %s.dst2(id int, val varbinary(128), primary key(id))", vrepldb),
+		"create table src3(id int, val varbinary(128), primary key(id))",
+		fmt.Sprintf("create table %s.dst3(id int, val varbinary(128), primary key(id))", vrepldb),
+	})
+	defer execStatements(t, []string{
+		"drop table src1",
+		fmt.Sprintf("drop table %s.dst1", vrepldb),
+		"drop table src2",
+		fmt.Sprintf("drop table %s.dst2", vrepldb),
+		"drop table src3",
+		fmt.Sprintf("drop table %s.dst3", vrepldb),
+	})
+	env.SchemaEngine.Reload(context.Background())
+
+	filter := &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match:  "dst1",
+			Filter: "select * from src1",
+		}, {
+			Match:  "dst2",
+			Filter: "select * from src2",
+		}, {
+			Match:  "dst3",
+			Filter: "select * from src3",
+		}},
+	}
+	bls := &binlogdatapb.BinlogSource{
+		Keyspace: env.KeyspaceName,
+		Shard:    env.ShardName,
+		Filter:   filter,
+		OnDdl:    binlogdatapb.OnDDLAction_IGNORE,
+	}
+	query := binlogplayer.CreateVReplicationState("test", bls, "", binlogplayer.VReplicationInit, playerEngine.dbName, 0, 0)
+	qr, err := playerEngine.Exec(query)
+	require.NoError(t, err)
+	defer func() {
+		query := fmt.Sprintf("delete from _vt.vreplication where id = %d", qr.InsertID)
+		_, err := playerEngine.Exec(query)
+		require.NoError(t, err)
+	}()
+
+	// Wait for the copy phase to complete.
+	require.Eventually(t, func() bool {
+		return compareQueryResults(t, fmt.Sprintf("select state from _vt.vreplication where id = %d", qr.InsertID), [][]string{{"Running"}}, env.Mysqld.FetchSuperQuery) == nil
+	}, 10*time.Second, 10*time.Millisecond)
+	expectQueryResult(t, fmt.Sprintf("select count(*) from _vt.copy_state where vrepl_id = %d", qr.InsertID), [][]string{{"0"}})
+	expectData(t, "dst1", [][]string{
+		{"1", "aaa"},
+		{"2", "bbb"},
+		{"3", "ccc"},
+		{"4", "ddd"},
+		{"5", "eee"},
+	})
+	expectData(t, "dst2", [][]string{
+		{"1", "fff"},
+		{"2", "ggg"},
+	})
+	expectData(t, "dst3", [][]string{})
+	validateCopyRowCountStat(t, 7)
+
+	// The rows are replicated as usual after the copy phase.
+	execStatements(t, []string{
+		"insert into src1 values(6, 'hhh')",
+		"update src2 set val = 'iii' where id = 1",
+		"insert into src3 values(1, 'jjj')",
+	})
+	require.Eventually(t, func() bool {
+		return compareQueryResults(t, fmt.Sprintf("select * from %s.dst3", vrepldb), [][]string{{"1", "jjj"}}, env.Mysqld.FetchSuperQuery) == nil
+	}, 10*time.Second, 10*time.Millisecond)
+	expectData(t, "dst1", [][]string{
+		{"1", "aaa"},
+		{"2", "bbb"},
+		{"3", "ccc"},
+		{"4", "ddd"},
+		{"5", "eee"},
+		{"6", "hhh"},
+	})
+	expectData(t, "dst2", [][]string{
+		{"1", "iii"},
+		{"2", "ggg"},
+	})
+}
+
 // TestPlayerCopyBigTable ensures the copy-catchup back-and-forth loop works correctly.
 func TestPlayerCopyBigTable(t *testing.T) {
 	defer deleteTablet(addTablet(100))
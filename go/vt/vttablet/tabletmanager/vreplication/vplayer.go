@@ -43,6 +43,10 @@ type vplayer struct {
 	stopPos   mysql.Position
 	saveStop  bool
 	copyState map[string]*sqltypes.Result
+	// idempotentTables are the tables being copied concurrently, whose
+	// rows may have been copied from a snapshot past startPos. Events are
+	// replayed idempotently for them. See TablePlan.Idempotent.
+	idempotentTables map[string]bool
 
 	replicatorPlan *ReplicatorPlan
 	tablePlans     map[string]*TablePlan
@@ -221,11 +225,17 @@ func (vp *vplayer) applyRowEvent(ctx context.Context, rowEvent *binlogdatapb.Row
 	if tplan == nil {
 		return fmt.Errorf("unexpected event on table %s", rowEvent.TableName)
 	}
+	return vp.applyRowChanges(ctx, vp.vr.dbClient, tplan, rowEvent)
+}
+
+// applyRowChanges applies the changes of a row event with the given
+// connection.
+func (vp *vplayer) applyRowChanges(ctx context.Context, dbClient *vdbClient, tplan *TablePlan, rowEvent *binlogdatapb.RowEvent) error {
 	for _, change := range rowEvent.RowChanges {
 		_, err := tplan.applyChange(change, func(sql string) (*sqltypes.Result, error) {
 			stats := NewVrLogStats("ROWCHANGE")
 			start := time.Now()
-			qr, err := vp.vr.dbClient.ExecuteWithRetry(ctx, sql)
+			qr, err := dbClient.ExecuteWithRetry(ctx, sql)
 			vp.vr.stats.QueryCount.Add(vp.phase, 1)
 			vp.vr.stats.QueryTimings.Record(vp.phase, start)
 			stats.Send(sql)
@@ -330,6 +340,9 @@ func (vp *vplayer) applyEvents(ctx context.Context, relay *relayLog) error {
 	// can estimate this value more accurately.
 	defer vp.vr.stats.ReplicationLagSeconds.Set(math.MaxInt64)
 	defer vp.vr.stats.VReplicationLags.Add(strconv.Itoa(int(vp.vr.id)), math.MaxInt64)
+	if workers := vp.parallelApplyWorkers(); workers > 1 {
+		return vp.applyEventsInParallel(ctx, relay, workers)
+	}
 	var sbm int64 = -1
 	for {
 		if ctx.Err() != nil {
@@ -476,6 +489,7 @@ func (vp *vplayer) applyEvent(ctx context.Context, event *binlogdatapb.VEvent, m
 		if err != nil {
 			return err
 		}
+		tplan.Idempotent = vp.idempotentTables[tplan.TargetName]
 		vp.tablePlans[event.FieldEvent.TableName] = tplan
 		stats.Send(fmt.Sprintf("%v", event.FieldEvent))
 
@@ -579,6 +593,10 @@ func (vp *vplayer) applyEvent(ctx context.Context, event *binlogdatapb.VEvent, m
 			if posReached {
 				return io.EOF
 			}
+		case binlogdatapb.OnDDLAction_APPLY_COMPATIBLE:
+			if err := vp.applyCompatibleDDL(ctx, event, stats); err != nil {
+				return err
+			}
 		}
 	case binlogdatapb.VEventType_JOURNAL:
 		if vp.vr.dbClient.InTransaction {
@@ -2302,6 +2302,93 @@ func TestPlayerBatching(t *testing.T) {
 	})
 }
 
+func TestPlayerParallelApply(t *testing.T) {
+	defer deleteTablet(addTablet(100))
+
+	savedParallelApplyWorkers := parallelApplyWorkers
+	parallelApplyWorkers = 4
+	defer func() { parallelApplyWorkers = savedParallelApplyWorkers }()
+
+	execStatements(t, []string{
+		"create table t1(id int, val varbinary(128), primary key(id), unique key(val))",
+		fmt.Sprintf("create table %s.t1(id int, val varbinary(128), primary key(id), unique key(val))", vrepldb),
+		"create table t2(id int, val varbinary(128), primary key(id))",
+		fmt.Sprintf("create table %s.t2(id int, val varbinary(128), primary key(id))", vrepldb),
+	})
+	defer execStatements(t, []string{
+		"drop table t1",
+		fmt.Sprintf("drop table %s.t1", vrepldb),
+		"drop table t2",
+		fmt.Sprintf("drop table %s.t2", vrepldb),
+	})
+	env.SchemaEngine.Reload(context.Background())
+
+	filter := &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match: "/.*",
+		}},
+	}
+	bls := &binlogdatapb.BinlogSource{
+		Keyspace: env.KeyspaceName,
+		Shard:    env.ShardName,
+		Filter:   filter,
+		OnDdl:    binlogdatapb.OnDDLAction_EXEC,
+	}
+	cancel, id := startVReplication(t, bls, "")
+	defer cancel()
+
+	// The queries of the workers are interleaved: check the data instead.
+	doNotLogDBQueries = true
+	defer func() { doNotLogDBQueries = false }()
+
+	execStatements(t, []string{
+		"insert into t1 values(1, 'aaa')",
+		"insert into t2 values(1, 'aaa')",
+		"update t1 set val = 'bbb' where id = 1",
+		"insert into t2 values(2, 'bbb')",
+		// The rows of t1 conflict through the unique key.
+		"delete from t1 where id = 1",
+		"insert into t1 values(2, 'bbb')",
+		"begin",
+		"insert into t2 values(3, 'ccc')",
+		"update t2 set val = 'ddd' where id = 1",
+		"commit",
+		// DDLs are applied once the transactions before them are committed.
+		"alter table t2 add column val2 varbinary(128)",
+		"insert into t2 values(4, 'eee', 'fff')",
+		"update t2 set val2 = 'ggg' where id = 2",
+		"update t1 set id = 3 where id = 2",
+	})
+	pos, err := binlogplayer.DecodePosition(primaryPosition(t))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		qr, err := env.Mysqld.FetchSuperQuery(context.Background(), fmt.Sprintf("select pos from _vt.vreplication where id = %d", id))
+		if err != nil || len(qr.Rows) != 1 {
+			return false
+		}
+		saved, err := binlogplayer.DecodePosition(qr.Rows[0][0].ToString())
+		return err == nil && saved.AtLeast(pos)
+	}, 10*time.Second, 10*time.Millisecond)
+
+	expectData(t, "t1", [][]string{
+		{"3", "bbb"},
+	})
+	expectData(t, "t2", [][]string{
+		{"1", "ddd", ""},
+		{"2", "bbb", "ggg"},
+		{"3", "ccc", ""},
+		{"4", "eee", "fff"},
+	})
+	require.Eventually(t, func() bool {
+		for _, ct := range globalStats.status().Controllers {
+			if ct.ApplyQueueDepth != 0 {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
 func TestPlayerRelayLogMaxSize(t *testing.T) {
 	defer deleteTablet(addTablet(100))
 
@@ -0,0 +1,635 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/log"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// parallelApplier applies the transactions received by a vplayer on a
+// pool of connections, so that a stream can keep up with a busy source.
+//
+// The coordinator, which is the applyEvents thread, collects the row events
+// of each transaction along with its writeset: the keys of the rows it
+// changes, before and after the change, for the primary key and every
+// unique key of the target table. Transactions are handed to the workers in
+// the order they were received, and the worker that picks one up first waits
+// for the transactions received before it that have any key in common to
+// commit. Transactions that don't conflict are therefore committed
+// concurrently, and possibly out of order.
+//
+// The position of the stream is saved separately from the transactions, and
+// only covers the longest prefix of the received transactions that are all
+// committed. If the stream restarts, the transactions that were committed
+// past that prefix are applied again. This is why the table plans are
+// Idempotent, like the ones of the tables copied concurrently: replaying the
+// row events of a transaction that was already applied converges to the
+// same rows.
+//
+// The other events, like DDLs, journals, or statements, are barriers: the
+// coordinator waits for all the transactions received so far to commit and
+// saves their position, then applies the event on the connection of the
+// stream, the same way a vplayer that applies one transaction at a time
+// does.
+type parallelApplier struct {
+	vp         *vplayer
+	ctx        context.Context
+	cancel     context.CancelFunc
+	txns       chan *applyTxn
+	workers    sync.WaitGroup
+	dbClients  []*vdbClient
+	errors     concurrency.FirstErrorRecorder
+	uniqueKeys map[string][]uniqueKey
+
+	// txn is the transaction being received by the coordinator.
+	txn *applyTxn
+	// serial is set while the coordinator applies the transaction being
+	// received on the connection of the stream.
+	serial bool
+
+	// mu protects the fields below.
+	mu sync.Mutex
+	// saved is signaled when saving is reset.
+	saved sync.Cond
+	// pending contains the transactions received whose position is
+	// not saved yet, in order.
+	pending []*applyTxn
+	// writesets maps the keys of the rows changed by the pending
+	// transactions to the last transaction that changed them.
+	writesets map[rowKey]*applyTxn
+	// saving is set while a position is being saved.
+	saving        bool
+	timeLastSaved time.Time
+	// lastTimestamp is the timestamp of the last transaction received.
+	lastTimestamp int64
+}
+
+// applyTxn is a transaction applied by a worker.
+type applyTxn struct {
+	events   []*binlogdatapb.VEvent
+	plans    []*TablePlan
+	writeset map[rowKey]bool
+	// deps are the transactions received before this one
+	// that change some of the same rows.
+	deps      []*applyTxn
+	pos       mysql.Position
+	timestamp int64
+	// lag is the replication lag when the transaction was received,
+	// or -1 if it's unknown.
+	lag      int64
+	received time.Time
+	// committed is protected by the mutex of the parallelApplier.
+	// done is closed once the transaction is committed.
+	committed bool
+	done      chan struct{}
+}
+
+// rowKey identifies the rows of a table that have the same values for the
+// columns of one of its unique keys. Values that are equal for the collation
+// of their column have the same key. Rows that are different for a unique
+// key may also share a row key, which makes their transactions conflict
+// needlessly, but never lets conflicting transactions run concurrently.
+type rowKey struct {
+	table string
+	// index is empty for the primary key.
+	index string
+	hash  uint64
+}
+
+// uniqueKey is a unique secondary key of a target table.
+type uniqueKey struct {
+	name    string
+	columns []string
+}
+
+// parallelApplyWorkers returns the number of connections used to apply the
+// transactions of the stream. Transactions are applied one at a time unless
+// the events of all the tables can be replayed idempotently, and when the
+// player has to stop at a position, since that position must be saved
+// exactly. Online DDL migrations rely on the errors of the target to
+// validate the rows against the new schema.
+func (vp *vplayer) parallelApplyWorkers() int {
+	if parallelApplyWorkers <= 1 || !vp.stopPos.IsZero() || len(vp.copyState) != 0 ||
+		vp.vr.WorkflowType == int32(binlogdatapb.VReplicationWorkflowType_OnlineDDL) {
+		return 1
+	}
+	for _, tablePlan := range vp.replicatorPlan.TargetTables {
+		if tablePlan.Insert != nil && tablePlan.Replace == nil {
+			return 1
+		}
+	}
+	return parallelApplyWorkers
+}
+
+// applyEventsInParallel is applyEvents for a vplayer that applies
+// transactions with the given number of workers.
+func (vp *vplayer) applyEventsInParallel(ctx context.Context, relay *relayLog, workers int) error {
+	pa, err := vp.newParallelApplier(ctx, workers)
+	if err != nil {
+		return err
+	}
+	defer pa.close()
+
+	var sbm int64 = -1
+	for {
+		if err := pa.error(); err != nil {
+			return err
+		}
+		// check throttler.
+		if !vp.vr.vre.throttlerClient.ThrottleCheckOKOrWaitAppName(pa.ctx, vp.throttlerAppName) {
+			_ = vp.vr.updateTimeThrottled(VPlayerComponentName)
+			continue
+		}
+
+		items, err := relay.Fetch()
+		if err != nil {
+			return err
+		}
+		// No events were received. This likely means that there's a network partition.
+		// So, we should assume we're falling behind.
+		if len(items) == 0 {
+			behind := time.Now().UnixNano() - vp.lastTimestampNs - vp.timeOffsetNs
+			vp.vr.stats.ReplicationLagSeconds.Set(behind / 1e9)
+			vp.vr.stats.VReplicationLags.Add(strconv.Itoa(int(vp.vr.id)), time.Duration(behind/1e9)*time.Second)
+		}
+		// The positions of empty transactions are only saved along with
+		// the next transaction, or once every idleTimeout.
+		if err := pa.savePos(vp.vr.dbClient); err != nil {
+			return err
+		}
+		for _, events := range items {
+			for _, event := range events {
+				if event.Timestamp != 0 {
+					vp.lastTimestampNs = event.Timestamp * 1e9
+					vp.timeOffsetNs = time.Now().UnixNano() - event.CurrentTime
+					sbm = event.CurrentTime/1e9 - event.Timestamp
+				}
+				if err := pa.applyEvent(event, sbm); err != nil {
+					if err != io.EOF {
+						vp.vr.stats.ErrorCounts.Add([]string{"Apply"}, 1)
+						log.Errorf("Error applying event: %s", err.Error())
+					}
+					return err
+				}
+			}
+		}
+		pa.recordLag(sbm)
+	}
+}
+
+func (vp *vplayer) newParallelApplier(ctx context.Context, workers int) (*parallelApplier, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	pa := &parallelApplier{
+		vp:            vp,
+		ctx:           ctx,
+		cancel:        cancel,
+		txns:          make(chan *applyTxn, workers),
+		writesets:     make(map[rowKey]*applyTxn),
+		timeLastSaved: vp.timeLastSaved,
+	}
+	pa.saved.L = &pa.mu
+	uniqueKeys, err := vp.readUniqueKeys()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	pa.uniqueKeys = uniqueKeys
+	for i := 0; i < workers; i++ {
+		dbClient, err := vp.vr.newWorkerDBClient()
+		if err != nil {
+			pa.close()
+			return nil, err
+		}
+		pa.dbClients = append(pa.dbClients, dbClient)
+		pa.workers.Add(1)
+		go func() {
+			defer pa.workers.Done()
+			pa.applyTxns(dbClient)
+		}()
+	}
+	return pa, nil
+}
+
+// readUniqueKeys returns the unique secondary keys of the target tables.
+func (vp *vplayer) readUniqueKeys() (map[string][]uniqueKey, error) {
+	var tables []string
+	for tableName := range vp.replicatorPlan.TargetTables {
+		tables = append(tables, encodeString(tableName))
+	}
+	uniqueKeys := make(map[string][]uniqueKey)
+	if len(tables) == 0 {
+		return uniqueKeys, nil
+	}
+	qr, err := vp.vr.dbClient.Execute(fmt.Sprintf("select table_name, index_name, column_name from information_schema.statistics "+
+		"where table_schema = database() and table_name in (%s) and non_unique = 0 and index_name != 'PRIMARY' "+
+		"order by table_name, index_name, seq_in_index", strings.Join(tables, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range qr.Rows {
+		tableName, indexName, columnName := row[0].ToString(), row[1].ToString(), row[2].ToString()
+		keys := uniqueKeys[tableName]
+		if len(keys) == 0 || keys[len(keys)-1].name != indexName {
+			keys = append(keys, uniqueKey{name: indexName})
+		}
+		keys[len(keys)-1].columns = append(keys[len(keys)-1].columns, columnName)
+		uniqueKeys[tableName] = keys
+	}
+	return uniqueKeys, nil
+}
+
+// close stops the workers. The transactions that are not committed yet
+// are rolled back.
+func (pa *parallelApplier) close() {
+	pa.cancel()
+	close(pa.txns)
+	pa.workers.Wait()
+	for _, dbClient := range pa.dbClients {
+		dbClient.Rollback()
+		dbClient.Close()
+	}
+	pa.vp.vr.stats.ParallelApplyQueueDepth.Set(0)
+	pa.vp.vr.stats.ParallelApplyLagSeconds.Set(0)
+}
+
+func (pa *parallelApplier) error() error {
+	if err := pa.errors.Error(); err != nil {
+		return err
+	}
+	return pa.ctx.Err()
+}
+
+// applyEvent is called by the coordinator for every event received.
+func (pa *parallelApplier) applyEvent(event *binlogdatapb.VEvent, sbm int64) error {
+	vp := pa.vp
+	switch event.Type {
+	case binlogdatapb.VEventType_FIELD:
+		tplan, err := vp.replicatorPlan.buildExecutionPlan(event.FieldEvent)
+		if err != nil {
+			return err
+		}
+		tplan.Idempotent = true
+		vp.tablePlans[event.FieldEvent.TableName] = tplan
+		NewVrLogStats(event.Type.String()).Send(fmt.Sprintf("%v", event.FieldEvent))
+		return nil
+	case binlogdatapb.VEventType_ROW:
+		if pa.serial {
+			break
+		}
+		tplan := vp.tablePlans[event.RowEvent.TableName]
+		if tplan == nil {
+			return fmt.Errorf("unexpected event on table %s", event.RowEvent.TableName)
+		}
+		if pa.txn == nil {
+			pa.txn = &applyTxn{
+				writeset: make(map[rowKey]bool),
+				done:     make(chan struct{}),
+			}
+		}
+		pa.txn.events = append(pa.txn.events, event)
+		pa.txn.plans = append(pa.txn.plans, tplan)
+		pa.addWriteset(pa.txn.writeset, tplan, event.RowEvent)
+		return nil
+	case binlogdatapb.VEventType_COMMIT:
+		if pa.serial {
+			pa.serial = false
+			break
+		}
+		return pa.schedule(event, sbm)
+	case binlogdatapb.VEventType_INSERT, binlogdatapb.VEventType_DELETE, binlogdatapb.VEventType_UPDATE,
+		binlogdatapb.VEventType_REPLACE, binlogdatapb.VEventType_SAVEPOINT:
+		if !pa.serial {
+			if err := pa.applySerially(); err != nil {
+				return err
+			}
+		}
+	case binlogdatapb.VEventType_DDL:
+		if err := pa.drain(); err != nil {
+			return err
+		}
+		if err := vp.applyEvent(pa.ctx, event, false); err != nil {
+			return err
+		}
+		// The DDL may have changed the unique keys of the target tables.
+		uniqueKeys, err := vp.readUniqueKeys()
+		if err != nil {
+			return err
+		}
+		pa.uniqueKeys = uniqueKeys
+		return nil
+	case binlogdatapb.VEventType_OTHER, binlogdatapb.VEventType_JOURNAL:
+		if err := pa.drain(); err != nil {
+			return err
+		}
+	}
+	return vp.applyEvent(pa.ctx, event, false)
+}
+
+// schedule hands the transaction ended by commit to the workers.
+func (pa *parallelApplier) schedule(commit *binlogdatapb.VEvent, sbm int64) error {
+	txn := pa.txn
+	pa.txn = nil
+	if txn == nil {
+		txn = &applyTxn{done: make(chan struct{})}
+	}
+	txn.pos = pa.vp.pos
+	txn.timestamp = commit.Timestamp
+	txn.lag = sbm
+	txn.received = time.Now()
+
+	pa.mu.Lock()
+	for key := range txn.writeset {
+		if dep, ok := pa.writesets[key]; ok && !hasTxn(txn.deps, dep) {
+			txn.deps = append(txn.deps, dep)
+		}
+		pa.writesets[key] = txn
+	}
+	if len(txn.events) == 0 {
+		txn.committed = true
+		close(txn.done)
+	}
+	pa.pending = append(pa.pending, txn)
+	pa.lastTimestamp = txn.timestamp
+	pa.vp.vr.stats.ParallelApplyQueueDepth.Set(int64(len(pa.pending)))
+	pa.mu.Unlock()
+
+	if len(txn.events) == 0 {
+		return nil
+	}
+	select {
+	case pa.txns <- txn:
+		return nil
+	case <-pa.ctx.Done():
+		return pa.error()
+	}
+}
+
+func hasTxn(txns []*applyTxn, txn *applyTxn) bool {
+	for _, t := range txns {
+		if t == txn {
+			return true
+		}
+	}
+	return false
+}
+
+// applySerially switches to applying the transaction being received on the
+// connection of the stream, once the transactions received before it are
+// committed. It's used for the transactions that contain statements, which
+// have no writeset.
+func (pa *parallelApplier) applySerially() error {
+	if err := pa.drain(); err != nil {
+		return err
+	}
+	pa.serial = true
+	txn := pa.txn
+	pa.txn = nil
+	if txn == nil {
+		return nil
+	}
+	if err := pa.vp.vr.dbClient.Begin(); err != nil {
+		return err
+	}
+	for i, event := range txn.events {
+		if err := pa.vp.applyRowChanges(pa.ctx, pa.vp.vr.dbClient, txn.plans[i], event.RowEvent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drain waits for all the transactions received so far to be committed, and
+// saves their position.
+func (pa *parallelApplier) drain() error {
+	pa.mu.Lock()
+	pending := pa.pending
+	pa.mu.Unlock()
+	for _, txn := range pending {
+		select {
+		case <-txn.done:
+		case <-pa.ctx.Done():
+			return pa.error()
+		}
+	}
+
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	// A worker may still be saving the position of its transaction. The
+	// position must not be saved by the worker after the coordinator saves
+	// the position of the barrier.
+	for pa.saving {
+		pa.saved.Wait()
+	}
+	return pa.savePosLocked(pa.vp.vr.dbClient, true)
+}
+
+// applyTxns applies the transactions handed to the workers, with the
+// connection of a worker.
+func (pa *parallelApplier) applyTxns(dbClient *vdbClient) {
+	for txn := range pa.txns {
+		if err := pa.applyTxn(dbClient, txn); err != nil {
+			if pa.ctx.Err() == nil {
+				pa.vp.vr.stats.ErrorCounts.Add([]string{"Apply"}, 1)
+				log.Errorf("Error applying transaction: %s", err.Error())
+				pa.errors.RecordError(err)
+			}
+			pa.cancel()
+			return
+		}
+	}
+}
+
+func (pa *parallelApplier) applyTxn(dbClient *vdbClient, txn *applyTxn) error {
+	for _, dep := range txn.deps {
+		select {
+		case <-dep.done:
+		case <-pa.ctx.Done():
+			return pa.ctx.Err()
+		}
+	}
+	if err := dbClient.Begin(); err != nil {
+		return err
+	}
+	for i, event := range txn.events {
+		stats := NewVrLogStats(event.Type.String())
+		if err := pa.vp.applyRowChanges(pa.ctx, dbClient, txn.plans[i], event.RowEvent); err != nil {
+			return err
+		}
+		stats.Send(fmt.Sprintf("%v", event.RowEvent))
+	}
+	if err := dbClient.Commit(); err != nil {
+		return err
+	}
+	pa.mu.Lock()
+	txn.committed = true
+	pa.mu.Unlock()
+	close(txn.done)
+	return pa.savePos(dbClient)
+}
+
+// savePos saves the position of the committed transactions with the given
+// connection, unless it's already being saved. The saver then saves the
+// position of the transactions that are committed meanwhile.
+func (pa *parallelApplier) savePos(dbClient *vdbClient) error {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	if pa.saving {
+		return nil
+	}
+	return pa.savePosLocked(dbClient, false)
+}
+
+// savePosLocked saves the position of the longest prefix of the pending
+// transactions that are all committed. If the prefix only contains empty
+// transactions, the position is only saved if force is set, or once every
+// idleTimeout, for the same reasons as in applyEvents. mu must be held, and
+// is released while saving.
+func (pa *parallelApplier) savePosLocked(dbClient *vdbClient, force bool) error {
+	pa.saving = true
+	defer func() {
+		pa.saving = false
+		pa.saved.Broadcast()
+	}()
+	for {
+		n, empty := 0, true
+		for n < len(pa.pending) && pa.pending[n].committed {
+			empty = empty && len(pa.pending[n].events) == 0
+			n++
+		}
+		if n == 0 || (empty && !force && time.Since(pa.timeLastSaved) < idleTimeout) {
+			return nil
+		}
+		txn := pa.pending[n-1]
+		for _, saved := range pa.pending[:n] {
+			for key := range saved.writeset {
+				if pa.writesets[key] == saved {
+					delete(pa.writesets, key)
+				}
+			}
+		}
+		pa.pending = pa.pending[n:]
+
+		pa.mu.Unlock()
+		err := pa.updatePos(dbClient, txn)
+		pa.mu.Lock()
+		if err != nil {
+			return err
+		}
+		pa.timeLastSaved = time.Now()
+		pa.vp.vr.stats.ParallelApplyQueueDepth.Set(int64(len(pa.pending)))
+		if len(pa.pending) == 0 || txn.timestamp == 0 {
+			pa.vp.vr.stats.ParallelApplyLagSeconds.Set(0)
+		} else {
+			pa.vp.vr.stats.ParallelApplyLagSeconds.Set(pa.lastTimestamp - txn.timestamp)
+		}
+	}
+}
+
+func (pa *parallelApplier) updatePos(dbClient *vdbClient, txn *applyTxn) error {
+	vr := pa.vp.vr
+	update := binlogplayer.GenerateUpdatePos(vr.id, txn.pos, time.Now().Unix(), txn.timestamp, vr.stats.CopyRowCount.Get(), vreplicationStoreCompressedGTID)
+	if _, err := dbClient.Execute(update); err != nil {
+		return fmt.Errorf("error %v updating position", err)
+	}
+	vr.stats.SetLastPosition(txn.pos)
+	if txn.lag >= 0 {
+		lag := txn.lag + int64(time.Since(txn.received)/time.Second)
+		vr.stats.ReplicationLagSeconds.Set(lag)
+		vr.stats.VReplicationLags.Add(strconv.Itoa(int(vr.id)), time.Duration(lag)*time.Second)
+	}
+	return nil
+}
+
+// recordLag records the replication lag of the last events received, once
+// the position of all the transactions received is saved.
+func (pa *parallelApplier) recordLag(sbm int64) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	if sbm < 0 || len(pa.pending) != 0 {
+		return
+	}
+	pa.vp.vr.stats.ReplicationLagSeconds.Set(sbm)
+	pa.vp.vr.stats.VReplicationLags.Add(strconv.Itoa(int(pa.vp.vr.id)), time.Duration(sbm)*time.Second)
+}
+
+// addWriteset adds the keys of the rows changed by a row event, before and
+// after the change, to writeset.
+func (pa *parallelApplier) addWriteset(writeset map[rowKey]bool, tplan *TablePlan, rowEvent *binlogdatapb.RowEvent) {
+	for _, change := range rowEvent.RowChanges {
+		for _, row := range []*querypb.Row{change.Before, change.After} {
+			if row == nil {
+				continue
+			}
+			vals := sqltypes.MakeRowTrusted(tplan.Fields, row)
+			writeset[rowKey{table: tplan.TargetName, hash: hashColumns(tplan.Fields, vals, tplan.PKReferences)}] = true
+			for _, key := range pa.uniqueKeys[tplan.TargetName] {
+				writeset[rowKey{table: tplan.TargetName, index: key.name, hash: hashColumns(tplan.Fields, vals, key.columns)}] = true
+			}
+		}
+	}
+}
+
+// hashColumns returns the hash of the values of the given columns of a row.
+// The values of a column that's not in the fields all hash the same.
+func hashColumns(fields []*querypb.Field, vals []sqltypes.Value, columns []string) uint64 {
+	var hash uint64
+	for _, column := range columns {
+		hash *= 31
+		for i, field := range fields {
+			if strings.EqualFold(field.Name, column) {
+				hash += hashValue(field, vals[i])
+				break
+			}
+		}
+	}
+	return hash
+}
+
+// hashValue returns the hash of a value. Text values that are equal for the
+// collation of their column hash the same. The values of a column whose
+// collation is unknown all hash the same.
+func hashValue(field *querypb.Field, val sqltypes.Value) uint64 {
+	if val.IsNull() {
+		return 0
+	}
+	if sqltypes.IsText(field.Type) {
+		coll := collations.Local().LookupByID(collations.ID(field.Charset))
+		if coll == nil {
+			return 0
+		}
+		return uint64(coll.Hash(val.Raw(), 0))
+	}
+	return xxhash.Sum64(val.Raw())
+}
@@ -38,6 +38,7 @@ import (
 	"vitess.io/vitess/go/vt/binlog/binlogplayer"
 	"vitess.io/vitess/go/vt/log"
 	"vitess.io/vitess/go/vt/mysqlctl"
+	"vitess.io/vitess/go/vt/vterrors"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
@@ -176,6 +177,11 @@ func (vr *vreplicator) Replicate(ctx context.Context) error {
 }
 
 func (vr *vreplicator) replicate(ctx context.Context) error {
+	// Sink workflows don't write to the target database.
+	if vr.source.Sink != nil {
+		return newSinkPlayer(vr).play(ctx)
+	}
+
 	// Manage SQL_MODE in the same way that mysqldump does.
 	// Save the original sql_mode, set it to a permissive mode,
 	// and then reset it back to the original value at the end.
@@ -485,6 +491,33 @@ func (vr *vreplicator) setSQLMode(ctx context.Context) (func(), error) {
 	return resetFunc, nil
 }
 
+// newWorkerDBClient opens a connection for a worker that copies or
+// applies rows concurrently with others, with the session settings of
+// the connection of the stream. Foreign key checks are disabled, since
+// the workers don't preserve the order of the changes across tables.
+func (vr *vreplicator) newWorkerDBClient() (*vdbClient, error) {
+	dbClient := vr.vre.dbClientFactoryFiltered()
+	if err := dbClient.Connect(); err != nil {
+		return nil, vterrors.Wrap(err, "can't connect to database")
+	}
+	sqlMode := SQLMode
+	if vr.WorkflowType == int32(binlogdatapb.VReplicationWorkflowType_OnlineDDL) {
+		sqlMode = StrictSQLMode
+	}
+	for _, query := range []string{
+		"set @@session.time_zone = '+00:00'",
+		"set names binary",
+		fmt.Sprintf(setSQLModeQueryf, sqlMode),
+		"set foreign_key_checks=0",
+	} {
+		if _, err := dbClient.ExecuteFetch(query, 1); err != nil {
+			dbClient.Close()
+			return nil, err
+		}
+	}
+	return newVDBClient(dbClient, vr.stats), nil
+}
+
 // throttlerAppName returns the app name to be used by throttlerClient for this particular workflow
 // example results:
 //   - "vreplication" for most flows
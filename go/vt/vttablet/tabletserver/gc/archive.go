@@ -0,0 +1,368 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/klauspost/pgzip"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/schema"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+const (
+	// archivesDirectory is the directory of the backup storage under which
+	// the table archives of all shards are kept.
+	archivesDirectory = "table_archives"
+
+	// ArchiveSchemaFile is the file of a table archive which has the
+	// CREATE TABLE statement of the archived table.
+	ArchiveSchemaFile = "schema"
+	// ArchiveRowsFile is the file of a table archive which has the rows
+	// of the archived table. See ReadArchiveRows for its format.
+	ArchiveRowsFile = "rows"
+	// ArchiveManifestFile is the file of a table archive which describes it.
+	// It's written last, so an archive without it is incomplete.
+	ArchiveManifestFile = "MANIFEST"
+)
+
+var (
+	sqlShowCreateTable    = "show create table `%a`"
+	sqlSelectAllRows      = "select * from `%a`"
+	archiveReentranceFlag int64
+)
+
+// VStreamer defines the functions of VStreamer
+// that the table GC needs.
+type VStreamer interface {
+	StreamRows(ctx context.Context, query string, lastpk []sqltypes.Value, send func(*binlogdatapb.VStreamRowsResponse) error) error
+}
+
+// ArchiveManifest is the content of the MANIFEST file of a table archive.
+type ArchiveManifest struct {
+	Keyspace string
+	Shard    string
+	// Table is the name of the archived table, in ARCHIVE state.
+	Table string
+	// Rows is the number of archived rows.
+	Rows int64
+	// FinishedTime is the time the archive was completed, in RFC 3339 format.
+	FinishedTime string
+}
+
+// ReadArchiveManifest reads the MANIFEST file of a table archive. It fails if the
+// archive is incomplete.
+func ReadArchiveManifest(ctx context.Context, bh backupstorage.BackupHandle) (*ArchiveManifest, error) {
+	rc, err := bh.ReadFile(ctx, ArchiveManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't read MANIFEST of archive %s, it may be incomplete: %v", bh.Name(), err)
+	}
+	defer rc.Close()
+
+	manifest := &ArchiveManifest{}
+	if err := json.NewDecoder(rc).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("can't decode MANIFEST of archive %s: %v", bh.Name(), err)
+	}
+	return manifest, nil
+}
+
+// ArchiveDirectory returns the directory of the backup storage which has the
+// table archives of a shard. An archive is named after the GC UUID of its table.
+func ArchiveDirectory(keyspace, shard string) string {
+	return path.Join(archivesDirectory, keyspace, shard)
+}
+
+// archive copies the rows of a table in ARCHIVE state to the backup storage.
+// This function is non-reentrant: there's only one instance of this function running at any given time.
+// A timer keeps calling this function, so if it bails out (e.g. on error) it will later resume work
+func (collector *TableGC) archive(ctx context.Context) (tableName string, err error) {
+	if atomic.CompareAndSwapInt64(&archiveReentranceFlag, 0, 1) {
+		defer atomic.StoreInt64(&archiveReentranceFlag, 0)
+	} else {
+		// An instance of this function is already running
+		return "", nil
+	}
+
+	tableName, found := collector.nextTableToArchive()
+	if !found {
+		// Nothing do do here...
+		return "", nil
+	}
+	_, _, uuid, _, err := schema.AnalyzeGCTableName(tableName)
+	if err != nil {
+		return tableName, err
+	}
+
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return tableName, err
+	}
+	defer bs.Close()
+
+	dir := ArchiveDirectory(collector.keyspace, collector.shard)
+	archived, err := archiveExists(ctx, bs, dir, uuid)
+	if err != nil {
+		return tableName, err
+	}
+	if archived {
+		// A previous run archived the table, but did not get to transition it.
+		log.Infof("TableGC: archive of %s already exists in %s", tableName, dir)
+	} else {
+		log.Infof("TableGC: archive begin for %s", tableName)
+		if err := collector.archiveTable(ctx, bs, dir, tableName, uuid); err != nil {
+			return tableName, err
+		}
+	}
+
+	collector.submitTransitionRequest(ctx, schema.ArchiveTableGCState, tableName, true, uuid)
+	collector.removeArchivingTable(tableName)
+	// finished with this table. Maybe more tables are looking to be archived.
+	log.Infof("TableGC: archive complete for %s", tableName)
+	time.AfterFunc(time.Second, func() { collector.archiveRequestsChan <- true })
+	return tableName, nil
+}
+
+// archiveExists returns true if the backup storage has a complete archive by the given name.
+// An archive without a MANIFEST is incomplete, e.g. if the tablet crashed while writing it,
+// and is removed so that it can be written again.
+func archiveExists(ctx context.Context, bs backupstorage.BackupStorage, dir, name string) (bool, error) {
+	bhs, err := bs.ListBackups(ctx, dir)
+	if err != nil {
+		return false, err
+	}
+	for _, bh := range bhs {
+		if bh.Name() != name {
+			continue
+		}
+		if _, err := ReadArchiveManifest(ctx, bh); err != nil {
+			log.Warningf("TableGC: removing incomplete archive %s in %s: %+v", name, dir, err)
+			if err := bs.RemoveBackup(ctx, dir, name); err != nil {
+				return false, err
+			}
+			return false, nil
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// archiveTable writes the CREATE TABLE statement and the rows of a table into a new archive.
+// The rows are read with the vstreamer rowstreamer, which is throttled like the purge.
+// The MANIFEST is written last, once the rows are all written.
+func (collector *TableGC) archiveTable(ctx context.Context, bs backupstorage.BackupStorage, dir, tableName, uuid string) (err error) {
+	createTable, err := collector.showCreateTable(ctx, tableName)
+	if err != nil {
+		return err
+	}
+
+	bh, err := bs.StartBackup(ctx, dir, uuid)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if abortErr := bh.AbortBackup(ctx); abortErr != nil {
+				log.Errorf("TableGC: error aborting archive of %s: %+v", tableName, abortErr)
+			}
+		}
+	}()
+
+	sw, err := bh.AddFile(ctx, ArchiveSchemaFile, int64(len(createTable)))
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(sw, createTable); err != nil {
+		sw.Close()
+		return err
+	}
+	if err = sw.Close(); err != nil {
+		return err
+	}
+
+	rw, err := bh.AddFile(ctx, ArchiveRowsFile, backupstorage.FileSizeUnknown)
+	if err != nil {
+		return err
+	}
+	aw := NewArchiveRowsWriter(rw)
+	query := sqlparser.BuildParsedQuery(sqlSelectAllRows, tableName).Query
+	err = collector.vstreamer.StreamRows(ctx, query, nil, func(response *binlogdatapb.VStreamRowsResponse) error {
+		return aw.Write(response.Fields, response.Rows)
+	})
+	if err != nil {
+		aw.Close()
+		rw.Close()
+		return err
+	}
+	if err = aw.Close(); err != nil {
+		rw.Close()
+		return err
+	}
+	if err = rw.Close(); err != nil {
+		return err
+	}
+
+	manifest, err := json.MarshalIndent(&ArchiveManifest{
+		Keyspace:     collector.keyspace,
+		Shard:        collector.shard,
+		Table:        tableName,
+		Rows:         aw.Rows(),
+		FinishedTime: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	mw, err := bh.AddFile(ctx, ArchiveManifestFile, int64(len(manifest)))
+	if err != nil {
+		return err
+	}
+	if _, err = mw.Write(manifest); err != nil {
+		mw.Close()
+		return err
+	}
+	if err = mw.Close(); err != nil {
+		return err
+	}
+	return bh.EndBackup(ctx)
+}
+
+// showCreateTable returns the CREATE TABLE statement of a table.
+func (collector *TableGC) showCreateTable(ctx context.Context, tableName string) (string, error) {
+	conn, err := collector.pool.Get(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Recycle()
+
+	parsed := sqlparser.BuildParsedQuery(sqlShowCreateTable, tableName)
+	res, err := conn.Exec(ctx, parsed.Query, math.MaxInt32, true)
+	if err != nil {
+		return "", err
+	}
+	if len(res.Rows) != 1 || len(res.Rows[0]) < 2 {
+		return "", fmt.Errorf("unexpected result for %s: %v", parsed.Query, res.Rows)
+	}
+	return res.Rows[0][1].ToString(), nil
+}
+
+// ArchiveRowsWriter writes the rows file of a table archive.
+type ArchiveRowsWriter struct {
+	gz            *pgzip.Writer
+	fieldsWritten bool
+	rows          int64
+	buf           [binary.MaxVarintLen64]byte
+}
+
+// NewArchiveRowsWriter returns an ArchiveRowsWriter which compresses into w.
+func NewArchiveRowsWriter(w io.Writer) *ArchiveRowsWriter {
+	return &ArchiveRowsWriter{gz: pgzip.NewWriter(w)}
+}
+
+// Write appends a batch of rows. Only the fields of the first batch are written.
+func (aw *ArchiveRowsWriter) Write(fields []*querypb.Field, rows []*querypb.Row) error {
+	qr := &querypb.QueryResult{Rows: rows}
+	if !aw.fieldsWritten && len(fields) != 0 {
+		qr.Fields = fields
+		aw.fieldsWritten = true
+	}
+	if len(qr.Fields) == 0 && len(qr.Rows) == 0 {
+		// e.g. heartbeats and throttled responses of the rowstreamer
+		return nil
+	}
+	if !aw.fieldsWritten {
+		return fmt.Errorf("received rows before fields")
+	}
+	b, err := qr.MarshalVT()
+	if err != nil {
+		return err
+	}
+	n := binary.PutUvarint(aw.buf[:], uint64(len(b)))
+	if _, err := aw.gz.Write(aw.buf[:n]); err != nil {
+		return err
+	}
+	if _, err := aw.gz.Write(b); err != nil {
+		return err
+	}
+	aw.rows += int64(len(rows))
+	return nil
+}
+
+// Rows returns the number of rows written so far.
+func (aw *ArchiveRowsWriter) Rows() int64 {
+	return aw.rows
+}
+
+// Close flushes the compressed rows. It does not close the underlying writer.
+func (aw *ArchiveRowsWriter) Close() error {
+	return aw.gz.Close()
+}
+
+// ReadArchiveRows reads the rows file of a table archive, which is a compressed
+// sequence of length-prefixed QueryResult messages. The first message has the
+// fields of the table, and the following ones have its rows. send is called once
+// per message, with the fields set in the first call only.
+func ReadArchiveRows(r io.Reader, send func(*sqltypes.Result) error) error {
+	gz, err := pgzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	br := bufio.NewReader(gz)
+	var fields []*querypb.Field
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		b := make([]byte, size)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return err
+		}
+		qr := &querypb.QueryResult{}
+		if err := qr.UnmarshalVT(b); err != nil {
+			return err
+		}
+		if fields == nil {
+			if len(qr.Fields) == 0 {
+				return fmt.Errorf("archive has rows before fields")
+			}
+			fields = qr.Fields
+		}
+		if err := send(sqltypes.CustomProto3ToResult(fields, qr)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/fakesqldb"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/mysqlctl/filebackupstorage"
+	"vitess.io/vitess/go/vt/schema"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+func TestArchiveRows(t *testing.T) {
+	fields := sqltypes.MakeTestFields("id|val", "int64|varchar")
+	batch1 := sqltypes.MakeTestResult(fields, "1|a", "2|b")
+	batch2 := sqltypes.MakeTestResult(fields, "3|c")
+
+	var buf bytes.Buffer
+	aw := NewArchiveRowsWriter(&buf)
+	// The rowstreamer sends the fields first, and then the rows without fields.
+	require.NoError(t, aw.Write(fields, nil))
+	require.NoError(t, aw.Write(nil, sqltypes.RowsToProto3(batch1.Rows)))
+	// Heartbeats have neither fields nor rows.
+	require.NoError(t, aw.Write(nil, nil))
+	require.NoError(t, aw.Write(nil, sqltypes.RowsToProto3(batch2.Rows)))
+	require.NoError(t, aw.Close())
+	assert.EqualValues(t, 3, aw.Rows())
+
+	var results []*sqltypes.Result
+	err := ReadArchiveRows(&buf, func(qr *sqltypes.Result) error {
+		results = append(results, qr)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(results))
+	assert.Equal(t, fields, results[0].Fields)
+	assert.Empty(t, results[0].Rows)
+	assert.Equal(t, batch1.Rows, results[1].Rows)
+	assert.Equal(t, batch2.Rows, results[2].Rows)
+}
+
+func TestArchiveRowsBeforeFields(t *testing.T) {
+	var buf bytes.Buffer
+	aw := NewArchiveRowsWriter(&buf)
+	err := aw.Write(nil, []*querypb.Row{{Lengths: []int64{1}, Values: []byte("1")}})
+	assert.EqualError(t, err, "received rows before fields")
+}
+
+func TestArchiveDirectory(t *testing.T) {
+	assert.Equal(t, "table_archives/commerce/-80", ArchiveDirectory("commerce", "-80"))
+}
+
+// fakeVStreamer streams the rows of a table the way the rowstreamer does: the
+// fields first, and then the rows.
+type fakeVStreamer struct {
+	result *sqltypes.Result
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (vs *fakeVStreamer) StreamRows(ctx context.Context, query string, lastpk []sqltypes.Value, send func(*binlogdatapb.VStreamRowsResponse) error) error {
+	vs.mu.Lock()
+	vs.queries = append(vs.queries, query)
+	vs.mu.Unlock()
+	if err := send(&binlogdatapb.VStreamRowsResponse{Fields: vs.result.Fields}); err != nil {
+		return err
+	}
+	return send(&binlogdatapb.VStreamRowsResponse{Rows: sqltypes.RowsToProto3(vs.result.Rows)})
+}
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	filebackupstorage.FileBackupStorageRoot = t.TempDir()
+	backupstorage.BackupStorageImplementation = "file"
+
+	db := fakesqldb.New(t)
+	defer db.Close()
+
+	const uuid = "6ace8bcef73211ea87e9f875a4d24e90"
+	const tableName = "_vt_ARCHIVE_" + uuid + "_20200915120410"
+	const createTable = "CREATE TABLE `" + tableName + "` (\n  `id` int NOT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB"
+	db.AddQuery(sqlShowVtTables, sqltypes.MakeTestResult(sqltypes.MakeTestFields("Tables_in_vt_ks|Table_type", "varchar|varchar"),
+		tableName+"|BASE TABLE",
+		// Tables that are not due yet are left alone.
+		"_vt_ARCHIVE_7ace8bcef73211ea87e9f875a4d24e90_29990915120410|BASE TABLE",
+	))
+	db.AddQuery("show create table `"+tableName+"`", sqltypes.MakeTestResult(sqltypes.MakeTestFields("Table|Create Table", "varchar|varchar"),
+		tableName+"|"+createTable))
+
+	vs := &fakeVStreamer{result: sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int32"), "1", "2", "3")}
+	collector := NewTableGC(tabletenv.NewEnv(tabletenv.NewDefaultConfig(), "TableGCTest"), nil,
+		func() topodatapb.TabletType { return topodatapb.TabletType_PRIMARY }, nil, vs)
+	collector.keyspace = "ks"
+	collector.shard = "0"
+	collector.isPrimary = 1
+	var err error
+	collector.lifecycleStates, err = schema.ParseGCLifecycle("hold,archive,purge,evac,drop")
+	require.NoError(t, err)
+	collector.pool.Open(db.ConnParams(), db.ConnParams(), db.ConnParams())
+	defer collector.pool.Close()
+
+	// checkTables enlists the ARCHIVE table, and archive archives it and transitions it to PURGE.
+	require.NoError(t, collector.checkTables(ctx))
+	assert.Equal(t, map[string]bool{tableName: true}, collector.archivingTables)
+	archived, err := collector.archive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, tableName, archived)
+	assert.Empty(t, collector.archivingTables)
+	assert.Equal(t, []string{"select * from `" + tableName + "`"}, vs.queries)
+	transition := waitForTransition(t, collector)
+	assert.Equal(t, tableName, transition.fromTableName)
+	assert.Equal(t, schema.PurgeTableGCState, transition.toGCState)
+
+	bs, err := backupstorage.GetBackupStorage()
+	require.NoError(t, err)
+	defer bs.Close()
+	bhs, err := bs.ListBackups(ctx, "table_archives/ks/0")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(bhs))
+	assert.Equal(t, uuid, bhs[0].Name())
+	manifest, err := ReadArchiveManifest(ctx, bhs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "ks", manifest.Keyspace)
+	assert.Equal(t, "0", manifest.Shard)
+	assert.Equal(t, tableName, manifest.Table)
+	assert.EqualValues(t, 3, manifest.Rows)
+	rc, err := bhs[0].ReadFile(ctx, ArchiveSchemaFile)
+	require.NoError(t, err)
+	b, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, createTable, string(b))
+
+	// If the table is still in ARCHIVE state, e.g. because the transition failed,
+	// the complete archive is not written again.
+	require.NoError(t, collector.checkTables(ctx))
+	_, err = collector.archive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(vs.queries))
+	waitForTransition(t, collector)
+
+	// An incomplete archive, which has no MANIFEST, is written again.
+	require.NoError(t, bs.RemoveBackup(ctx, "table_archives/ks/0", uuid))
+	bh, err := bs.StartBackup(ctx, "table_archives/ks/0", uuid)
+	require.NoError(t, err)
+	w, err := bh.AddFile(ctx, ArchiveSchemaFile, int64(len(createTable)))
+	require.NoError(t, err)
+	_, err = io.WriteString(w, createTable)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, bh.EndBackup(ctx))
+
+	require.NoError(t, collector.checkTables(ctx))
+	_, err = collector.archive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(vs.queries))
+	waitForTransition(t, collector)
+	bhs, err = bs.ListBackups(ctx, "table_archives/ks/0")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(bhs))
+	_, err = ReadArchiveManifest(ctx, bhs[0])
+	require.NoError(t, err)
+}
+
+func waitForTransition(t *testing.T, collector *TableGC) *transitionRequest {
+	t.Helper()
+	select {
+	case transition := <-collector.transitionRequestsChan:
+		return transition
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for a transition request")
+		return nil
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"vitess.io/vitess/go/timer"
 	"vitess.io/vitess/go/vt/dbconnpool"
 	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	"vitess.io/vitess/go/vt/schema"
 	"vitess.io/vitess/go/vt/servenv"
@@ -66,7 +67,7 @@ func registerGCFlags(fs *pflag.FlagSet) {
 	// purgeReentranceInterval marks the interval between searching tables to purge
 	fs.DurationVar(&purgeReentranceInterval, "gc_purge_check_interval", purgeReentranceInterval, "Interval between purge discovery checks")
 	// gcLifecycle is the sequence of steps the table goes through in the process of getting dropped
-	fs.StringVar(&gcLifecycle, "table_gc_lifecycle", gcLifecycle, "States for a DROP TABLE garbage collection cycle. Default is 'hold,purge,evac,drop', use any subset ('drop' implcitly always included)")
+	fs.StringVar(&gcLifecycle, "table_gc_lifecycle", gcLifecycle, "States for a DROP TABLE garbage collection cycle. Default is 'hold,purge,evac,drop', use any subset ('drop' implcitly always included). Add 'archive' to copy the rows of a table to the backup storage before it is purged")
 }
 
 var (
@@ -92,7 +93,7 @@ func init() {
 // This service "garbage collects" tables:
 // - it checks for magically-named tables (e.g. _vt_EVAC_f6338b2af8af11eaa210f875a4d24e90_20200920063522)
 // - it analyzes a table's state from its name
-// - it applies operations on the table (namely purge for PURGE tables)
+// - it applies operations on the table (namely archive for ARCHIVE tables and purge for PURGE tables)
 // - when due time, it transitions a table (via RENAME TABLE) to the next state
 // - finally, it issues a DROP TABLE
 // The sequence of steps is controlled by the command line variable --table_gc_lifecycle
@@ -108,11 +109,13 @@ type TableGC struct {
 
 	env            tabletenv.Env
 	pool           *connpool.Pool
+	vstreamer      VStreamer
 	tabletTypeFunc func() topodatapb.TabletType
 	ts             *topo.Server
 
-	initMutex  sync.Mutex
-	purgeMutex sync.Mutex
+	initMutex    sync.Mutex
+	purgeMutex   sync.Mutex
+	archiveMutex sync.Mutex
 
 	tickers [](*timer.SuspendableTicker)
 
@@ -120,6 +123,8 @@ type TableGC struct {
 	dropTablesChan         chan string
 	transitionRequestsChan chan *transitionRequest
 	purgeRequestsChan      chan bool
+	archivingTables        map[string]bool
+	archiveRequestsChan    chan bool
 	// lifecycleStates indicates what states a GC table goes through. The user can set
 	// this with --table_gc_lifecycle, such that some states can be skipped.
 	lifecycleStates map[schema.TableGCState]bool
@@ -133,11 +138,12 @@ type Status struct {
 	isPrimary bool
 	IsOpen    bool
 
-	purgingTables []string
+	purgingTables   []string
+	archivingTables []string
 }
 
-// NewTableGC creates a table collector
-func NewTableGC(env tabletenv.Env, ts *topo.Server, tabletTypeFunc func() topodatapb.TabletType, lagThrottler *throttle.Throttler) *TableGC {
+// NewTableGC creates a table collector. The vstreamer streams the rows of ARCHIVE tables.
+func NewTableGC(env tabletenv.Env, ts *topo.Server, tabletTypeFunc func() topodatapb.TabletType, lagThrottler *throttle.Throttler, vstreamer VStreamer) *TableGC {
 	collector := &TableGC{
 		throttlerClient: throttle.NewBackgroundClient(lagThrottler, throttlerAppName, throttle.ThrottleCheckPrimaryWrite),
 		isPrimary:       0,
@@ -146,6 +152,7 @@ func NewTableGC(env tabletenv.Env, ts *topo.Server, tabletTypeFunc func() topoda
 		env:            env,
 		tabletTypeFunc: tabletTypeFunc,
 		ts:             ts,
+		vstreamer:      vstreamer,
 		pool: connpool.NewPool(env, "TableGCPool", tabletenv.ConnPoolConfig{
 			Size:               2,
 			IdleTimeoutSeconds: env.Config().OltpReadPool.IdleTimeoutSeconds,
@@ -157,6 +164,8 @@ func NewTableGC(env tabletenv.Env, ts *topo.Server, tabletTypeFunc func() topoda
 		dropTablesChan:         make(chan string),
 		transitionRequestsChan: make(chan *transitionRequest),
 		purgeRequestsChan:      make(chan bool),
+		archivingTables:        map[string]bool{},
+		archiveRequestsChan:    make(chan bool),
 	}
 
 	return collector
@@ -183,6 +192,12 @@ func (collector *TableGC) Open() (err error) {
 	if err != nil {
 		return fmt.Errorf("Error parsing --table_gc_lifecycle flag: %+v", err)
 	}
+	if collector.lifecycleStates[schema.ArchiveTableGCState] {
+		if _, err := backupstorage.GetBackupStorage(); err != nil {
+			// Tables will wait in ARCHIVE state until a backup storage is configured.
+			log.Errorf("TableGC: --table_gc_lifecycle includes archive, but the backup storage is not available: %+v", err)
+		}
+	}
 
 	log.Info("TableGC: opening")
 	collector.pool.Open(collector.env.Config().DB.AllPrivsWithDB(), collector.env.Config().DB.DbaWithDB(), collector.env.Config().DB.AppDebugWithDB())
@@ -288,6 +303,7 @@ func (collector *TableGC) Operate(ctx context.Context) {
 			{
 				// relay the request
 				go func() { collector.purgeRequestsChan <- true }()
+				go func() { collector.archiveRequestsChan <- true }()
 			}
 		case <-collector.purgeRequestsChan:
 			{
@@ -297,6 +313,14 @@ func (collector *TableGC) Operate(ctx context.Context) {
 					}
 				}()
 			}
+		case <-collector.archiveRequestsChan:
+			{
+				go func() {
+					if tableName, err := collector.archive(ctx); err != nil {
+						log.Errorf("TableGC: error archiving table %s: %+v", tableName, err)
+					}
+				}()
+			}
 		case dropTableName := <-collector.dropTablesChan:
 			{
 				if err := collector.dropTable(ctx, dropTableName); err != nil {
@@ -319,6 +343,8 @@ func (collector *TableGC) nextState(fromState schema.TableGCState) *schema.Table
 	var state schema.TableGCState
 	switch fromState {
 	case schema.HoldTableGCState:
+		state = schema.ArchiveTableGCState
+	case schema.ArchiveTableGCState:
 		state = schema.PurgeTableGCState
 	case schema.PurgeTableGCState:
 		state = schema.EvacTableGCState
@@ -426,6 +452,15 @@ func (collector *TableGC) checkTables(ctx context.Context) error {
 			// Hold period expired. Moving to next state
 			collector.submitTransitionRequest(ctx, state, tableName, isBaseTable, uuid)
 		}
+		if state == schema.ArchiveTableGCState {
+			if isBaseTable {
+				// This table needs to be archived. Make sure to enlist it (we may already have)
+				collector.addArchivingTable(tableName)
+			} else {
+				// This is a view. There are no rows to archive. Just transition into next phase
+				collector.submitTransitionRequest(ctx, state, tableName, isBaseTable, uuid)
+			}
+		}
 		if state == schema.PurgeTableGCState {
 			if isBaseTable {
 				// This table needs to be purged. Make sure to enlist it (we may already have)
@@ -629,11 +664,42 @@ func (collector *TableGC) nextTableToPurge() (tableName string, ok bool) {
 	collector.purgeMutex.Lock()
 	defer collector.purgeMutex.Unlock()
 
-	if len(collector.purgingTables) == 0 {
+	return oldestTable(collector.purgingTables)
+}
+
+// addArchivingTable adds a table to the list of archiving (or pending archiving) tables
+func (collector *TableGC) addArchivingTable(tableName string) {
+	collector.archiveMutex.Lock()
+	defer collector.archiveMutex.Unlock()
+
+	collector.archivingTables[tableName] = true
+}
+
+// removeArchivingTable removes a table from the archiving list; likely this is called when
+// the table is fully archived and is renamed away to be purged.
+func (collector *TableGC) removeArchivingTable(tableName string) {
+	collector.archiveMutex.Lock()
+	defer collector.archiveMutex.Unlock()
+
+	delete(collector.archivingTables, tableName)
+}
+
+// nextTableToArchive returns the name of the next table we should start archiving.
+// We pick the table with the oldest timestamp.
+func (collector *TableGC) nextTableToArchive() (tableName string, ok bool) {
+	collector.archiveMutex.Lock()
+	defer collector.archiveMutex.Unlock()
+
+	return oldestTable(collector.archivingTables)
+}
+
+// oldestTable returns the GC table with the oldest timestamp.
+func oldestTable(tables map[string]bool) (tableName string, ok bool) {
+	if len(tables) == 0 {
 		return "", false
 	}
 	tableNames := []string{}
-	for tableName := range collector.purgingTables {
+	for tableName := range tables {
 		tableNames = append(tableNames, tableName)
 	}
 	sort.SliceStable(tableNames, func(i, j int) bool {
@@ -661,5 +727,11 @@ func (collector *TableGC) Status() *Status {
 		status.purgingTables = append(status.purgingTables, tableName)
 	}
 
+	collector.archiveMutex.Lock()
+	defer collector.archiveMutex.Unlock()
+	for tableName := range collector.archivingTables {
+		status.archivingTables = append(status.archivingTables, tableName)
+	}
+
 	return status
 }
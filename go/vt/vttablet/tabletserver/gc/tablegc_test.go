@@ -101,6 +101,21 @@ func TestNextState(t *testing.T) {
 			state:     schema.HoldTableGCState,
 			next:      schema.PurgeTableGCState,
 		},
+		{
+			lifecycle: "hold,archive,purge,evac,drop",
+			state:     schema.HoldTableGCState,
+			next:      schema.ArchiveTableGCState,
+		},
+		{
+			lifecycle: "hold,archive,purge,evac,drop",
+			state:     schema.ArchiveTableGCState,
+			next:      schema.PurgeTableGCState,
+		},
+		{
+			lifecycle: "hold,archive,drop",
+			state:     schema.ArchiveTableGCState,
+			next:      schema.DropTableGCState,
+		},
 		{
 			lifecycle: "hold,purge",
 			state:     schema.PurgeTableGCState,
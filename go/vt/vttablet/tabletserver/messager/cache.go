@@ -70,6 +70,31 @@ func (mh *messageHeap) Pop() any {
 	return x
 }
 
+// fifoMessageHeap orders messages of the same priority
+// by time_next, oldest first.
+type fifoMessageHeap messageHeap
+
+func (mh fifoMessageHeap) Len() int {
+	return len(mh)
+}
+
+func (mh fifoMessageHeap) Less(i, j int) bool {
+	return mh[i].Priority < mh[j].Priority ||
+		(mh[i].Priority == mh[j].Priority && mh[i].TimeNext < mh[j].TimeNext)
+}
+
+func (mh fifoMessageHeap) Swap(i, j int) {
+	mh[i], mh[j] = mh[j], mh[i]
+}
+
+func (mh *fifoMessageHeap) Push(x any) {
+	(*messageHeap)(mh).Push(x)
+}
+
+func (mh *fifoMessageHeap) Pop() any {
+	return (*messageHeap)(mh).Pop()
+}
+
 //_______________________________________________
 
 // cache is the cache for the messager. Messages initially
@@ -85,6 +110,8 @@ type cache struct {
 	size int
 
 	sendQueue messageHeap
+	// fifo sends the oldest messages first instead of the newest.
+	fifo bool
 	// inQueue is used to efficiently find items in sendQueue.
 	// The message id is the key.
 	inQueue map[string]*MessageRow
@@ -105,6 +132,21 @@ func newCache(size int) *cache {
 	return mc
 }
 
+// newFIFOCache creates a new cache that sends the oldest messages first.
+func newFIFOCache(size int) *cache {
+	mc := newCache(size)
+	mc.fifo = true
+	return mc
+}
+
+// queue returns the send queue ordered as configured.
+func (mc *cache) queue() heap.Interface {
+	if mc.fifo {
+		return (*fifoMessageHeap)(&mc.sendQueue)
+	}
+	return &mc.sendQueue
+}
+
 func (mc *cache) IsEmpty() bool {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
@@ -138,7 +180,7 @@ func (mc *cache) Add(mr *MessageRow) bool {
 	if _, ok := mc.inQueue[id]; ok {
 		return true
 	}
-	heap.Push(&mc.sendQueue, mr)
+	heap.Push(mc.queue(), mr)
 	mc.inQueue[id] = mr
 	return true
 }
@@ -156,7 +198,7 @@ func (mc *cache) Pop() *MessageRow {
 		if len(mc.sendQueue) == 0 {
 			return nil
 		}
-		mr := heap.Pop(&mc.sendQueue).(*MessageRow)
+		mr := heap.Pop(mc.queue()).(*MessageRow)
 		// If message was previously marked as defunct, drop
 		// it and continue.
 		if mr.defunct {
@@ -17,9 +17,14 @@ limitations under the License.
 package messager
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
 	"sync"
 
+	"vitess.io/vitess/go/acl"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/sync2"
 	"vitess.io/vitess/go/vt/log"
@@ -38,6 +43,7 @@ type TabletService interface {
 	tabletenv.Env
 	PostponeMessages(ctx context.Context, target *querypb.Target, querygen QueryGenerator, ids []string) (count int64, err error)
 	PurgeMessages(ctx context.Context, target *querypb.Target, querygen QueryGenerator, timeCutoff int64) (count int64, err error)
+	DeadLetterMessages(ctx context.Context, target *querypb.Target, querygen QueryGenerator, ids []string) (count int64, err error)
 }
 
 // VStreamer defines  the functions of VStreamer
@@ -52,6 +58,9 @@ type Engine struct {
 	mu       sync.Mutex
 	isOpen   bool
 	managers map[string]*messageManager
+	// groupManagers are the managers of the named consumer
+	// groups, by table name and group.
+	groupManagers map[string]map[string]*messageManager
 
 	tsv          TabletService
 	se           *schema.Engine
@@ -61,13 +70,16 @@ type Engine struct {
 
 // NewEngine creates a new Engine.
 func NewEngine(tsv TabletService, se *schema.Engine, vs VStreamer) *Engine {
-	return &Engine{
-		tsv:          tsv,
-		se:           se,
-		vs:           vs,
-		postponeSema: sync2.NewSemaphore(tsv.Config().MessagePostponeParallelism, 0),
-		managers:     make(map[string]*messageManager),
+	me := &Engine{
+		tsv:           tsv,
+		se:            se,
+		vs:            vs,
+		postponeSema:  sync2.NewSemaphore(tsv.Config().MessagePostponeParallelism, 0),
+		managers:      make(map[string]*messageManager),
+		groupManagers: make(map[string]map[string]*messageManager),
 	}
+	tsv.Exporter().HandleFunc("/debug/messages", me.handleHTTPMessages)
+	return me
 }
 
 // Open starts the Engine service.
@@ -102,37 +114,59 @@ func (me *Engine) Close() {
 	for _, mm := range me.managers {
 		mm.Close()
 	}
+	for _, groups := range me.groupManagers {
+		for _, mm := range groups {
+			mm.Close()
+		}
+	}
 	me.managers = make(map[string]*messageManager)
+	me.groupManagers = make(map[string]map[string]*messageManager)
 	log.Info("Messager: closed")
 }
 
-func (me *Engine) GetGenerator(name string) (QueryGenerator, error) {
+// GetGenerator returns the query generator of a consumer group
+// of the message table. The default group is "".
+func (me *Engine) GetGenerator(name, group string) (QueryGenerator, error) {
 	me.mu.Lock()
 	defer me.mu.Unlock()
-	mm := me.managers[name]
-	if mm == nil {
+	if me.managers[name] == nil {
 		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "message table %s not found in schema", name)
 	}
+	mm := me.getManager(name, group)
+	if mm == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "consumer group %s not found for message table %s", group, name)
+	}
 	return mm, nil
 }
 
-// Subscribe subscribes to messages from the requested table.
+func (me *Engine) getManager(name, group string) *messageManager {
+	if group == "" {
+		return me.managers[name]
+	}
+	return me.groupManagers[name][group]
+}
+
+// Subscribe subscribes to messages from the requested table
+// for a consumer group. The default group is "".
 // The function returns a done channel that will be closed when
 // the subscription ends, which can be initiated by the send function
 // returning io.EOF. The engine can also end a subscription which is
 // usually triggered by Close. It's the responsibility of the send
 // function to promptly return if the done channel is closed. Otherwise,
 // the engine's Close function will hang indefinitely.
-func (me *Engine) Subscribe(ctx context.Context, name string, send func(*sqltypes.Result) error) (done <-chan struct{}, err error) {
+func (me *Engine) Subscribe(ctx context.Context, name, group string, send func(*sqltypes.Result) error) (done <-chan struct{}, err error) {
 	me.mu.Lock()
 	defer me.mu.Unlock()
 	if !me.isOpen {
 		return nil, vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "messager engine is closed, probably because this is not a primary any more")
 	}
-	mm := me.managers[name]
-	if mm == nil {
+	if me.managers[name] == nil {
 		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "message table %s not found", name)
 	}
+	mm := me.getManager(name, group)
+	if mm == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "consumer group %s not found for message table %s", group, name)
+	}
 	return mm.Subscribe(ctx, send), nil
 }
 
@@ -147,6 +181,10 @@ func (me *Engine) schemaChanged(tables map[string]*schema.Table, created, altere
 		log.Infof("Stopping messager for dropped/updated table: %v", name)
 		mm.Close()
 		delete(me.managers, name)
+		for _, gm := range me.groupManagers[name] {
+			gm.Close()
+		}
+		delete(me.groupManagers, name)
 	}
 
 	for _, name := range append(created, altered...) {
@@ -163,5 +201,55 @@ func (me *Engine) schemaChanged(tables map[string]*schema.Table, created, altere
 		me.managers[name] = mm
 		log.Infof("Starting messager for table: %v", name)
 		mm.Open()
+		if len(t.MessageInfo.ConsumerGroups) == 0 {
+			continue
+		}
+		groups := make(map[string]*messageManager)
+		for _, group := range t.MessageInfo.ConsumerGroups {
+			gm := newMessageManagerForGroup(me.tsv, me.vs, t, group, me.postponeSema)
+			groups[group] = gm
+			log.Infof("Starting messager for table: %v, consumer group: %v", name, group)
+			gm.Open()
+		}
+		me.groupManagers[name] = groups
+	}
+}
+
+func (me *Engine) handleHTTPMessages(response http.ResponseWriter, request *http.Request) {
+	if err := acl.CheckAccessHTTP(request, acl.DEBUGGING); err != nil {
+		acl.SendError(response, err)
+		return
+	}
+	me.mu.Lock()
+	managers := make([]*messageManager, 0, len(me.managers))
+	for _, mm := range me.managers {
+		managers = append(managers, mm)
+	}
+	for _, groups := range me.groupManagers {
+		for _, mm := range groups {
+			managers = append(managers, mm)
+		}
+	}
+	me.mu.Unlock()
+
+	statuses := make([]*messageManagerStatus, 0, len(managers))
+	for _, mm := range managers {
+		statuses = append(statuses, mm.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Name != statuses[j].Name {
+			return statuses[i].Name < statuses[j].Name
+		}
+		return statuses[i].Group < statuses[j].Group
+	})
+
+	response.Header().Set("Content-Type", "application/json; charset=utf-8")
+	b, err := json.MarshalIndent(statuses, "", " ")
+	if err != nil {
+		response.Write([]byte(err.Error()))
+		return
 	}
+	buf := bytes.NewBuffer(nil)
+	json.HTMLEscape(buf, b)
+	response.Write(buf.Bytes())
 }
@@ -21,6 +21,9 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"vitess.io/vitess/go/mysql/fakesqldb"
 	"vitess.io/vitess/go/sqltypes"
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
@@ -113,9 +116,9 @@ func TestSubscribe(t *testing.T) {
 	f1, ch1 := newEngineReceiver()
 	f2, ch2 := newEngineReceiver()
 	// Each receiver is subscribed to different managers.
-	engine.Subscribe(context.Background(), "t1", f1)
+	engine.Subscribe(context.Background(), "t1", "", f1)
 	<-ch1
-	engine.Subscribe(context.Background(), "t2", f2)
+	engine.Subscribe(context.Background(), "t2", "", f2)
 	<-ch2
 	engine.managers["t1"].Add(&MessageRow{Row: []sqltypes.Value{sqltypes.NewVarBinary("1")}})
 	engine.managers["t2"].Add(&MessageRow{Row: []sqltypes.Value{sqltypes.NewVarBinary("2")}})
@@ -124,14 +127,14 @@ func TestSubscribe(t *testing.T) {
 
 	// Error case.
 	want := "message table t3 not found"
-	_, err := engine.Subscribe(context.Background(), "t3", f1)
+	_, err := engine.Subscribe(context.Background(), "t3", "", f1)
 	if err == nil || err.Error() != want {
 		t.Errorf("Subscribe: %v, want %s", err, want)
 	}
 
 	// After close, Subscribe should return a closed channel.
 	engine.Close()
-	_, err = engine.Subscribe(context.Background(), "t1", nil)
+	_, err = engine.Subscribe(context.Background(), "t1", "", nil)
 	if got, want := vterrors.Code(err), vtrpcpb.Code_UNAVAILABLE; got != want {
 		t.Errorf("Subscribed on closed engine error code: %v, want %v", got, want)
 	}
@@ -146,15 +149,48 @@ func TestEngineGenerate(t *testing.T) {
 		"t1": meTable,
 	}, []string{"t1"}, nil, nil)
 
-	if _, err := engine.GetGenerator("t1"); err != nil {
+	if _, err := engine.GetGenerator("t1", ""); err != nil {
 		t.Error(err)
 	}
 	want := "message table t2 not found in schema"
-	if _, err := engine.GetGenerator("t2"); err == nil || err.Error() != want {
+	if _, err := engine.GetGenerator("t2", ""); err == nil || err.Error() != want {
 		t.Errorf("engine.GenerateAckQuery(invalid): %v, want %s", err, want)
 	}
 }
 
+func TestEngineConsumerGroups(t *testing.T) {
+	db := fakesqldb.New(t)
+	defer db.Close()
+	engine := newTestEngine(db)
+	defer engine.Close()
+	groupTable := &schema.Table{
+		Type:        schema.Message,
+		MessageInfo: newMMTable().MessageInfo,
+	}
+	groupTable.MessageInfo.ConsumerGroups = []string{"billing", "audit"}
+	engine.schemaChanged(map[string]*schema.Table{
+		"t1": groupTable,
+	}, []string{"t1"}, nil, nil)
+	assert.Equal(t, map[string]bool{"billing": true, "audit": true}, extractManagerNames(engine.groupManagers["t1"]))
+
+	_, err := engine.GetGenerator("t1", "billing")
+	require.NoError(t, err)
+	_, err = engine.GetGenerator("t1", "shipping")
+	require.EqualError(t, err, "consumer group shipping not found for message table t1")
+
+	f1, ch1 := newEngineReceiver()
+	_, err = engine.Subscribe(context.Background(), "t1", "audit", f1)
+	require.NoError(t, err)
+	<-ch1
+	engine.groupManagers["t1"]["audit"].Add(&MessageRow{Row: []sqltypes.Value{sqltypes.NewVarBinary("1")}})
+	<-ch1
+	_, err = engine.Subscribe(context.Background(), "t1", "shipping", f1)
+	require.EqualError(t, err, "consumer group shipping not found for message table t1")
+
+	engine.schemaChanged(map[string]*schema.Table{}, nil, nil, []string{"t1"})
+	assert.Empty(t, engine.groupManagers)
+}
+
 func newTestEngine(db *fakesqldb.DB) *Engine {
 	config := tabletenv.NewDefaultConfig()
 	tsv := &fakeTabletServer{
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
@@ -47,10 +48,20 @@ var (
 		[]string{"TableName", "Metric"})
 )
 
+// StatsLabel returns the TableName label of the stats
+// of a consumer group of a message table.
+func StatsLabel(name, group string) string {
+	if group == "" {
+		return name
+	}
+	return name + "/" + group
+}
+
 type QueryGenerator interface {
 	GenerateAckQuery(ids []string) (string, map[string]*querypb.BindVariable)
 	GeneratePostponeQuery(ids []string) (string, map[string]*querypb.BindVariable)
 	GeneratePurgeQuery(timeCutoff int64) (string, map[string]*querypb.BindVariable)
+	GenerateDeadLetterQueries(ids []string) ([]string, map[string]*querypb.BindVariable)
 }
 
 type messageReceiver struct {
@@ -156,6 +167,26 @@ type receiverWithStatus struct {
 // The postpone functions also needs to obtain a semaphore that limits
 // the number of tx pool connections they can occupy.
 //
+// Dead letters
+// If the table has max attempts, the send loop does not send messages
+// that were already sent that many times. Instead, they're dead-lettered
+// asynchronously by either moving them to the dead letter table or by
+// acking them in place with a dead_letter_reason. The dead-lettering
+// shares the postpone semaphore.
+//
+// Consumer groups
+// Every consumer group of a table has its own messageManager that keeps
+// the delivery state of the group in its own columns. The default group
+// uses time_next, epoch and time_acked. Only the manager of the default
+// group purges messages, after they were acked by all groups.
+//
+// Partitioned delivery
+// If the table has a partition key, messages are read and sent in order
+// of time_next, and only one message per partition key is sent at a time.
+// The key is held by its message until that message is acked or
+// dead-lettered. Messages of a held key are dropped from the cache and
+// read again by the poller after the key is released.
+//
 // Client load balancing
 // The messages are sent to the clients in a round-robin fashion.
 // If, for some reason, a client is closed, the load balancer resets
@@ -179,6 +210,20 @@ type messageManager struct {
 	purgeTicks   *timer.Timer
 	postponeSema *sync2.Semaphore
 
+	// maxAttempts is the number of sends after which a message is
+	// dead-lettered. 0 means messages are sent indefinitely.
+	maxAttempts     int
+	deadLetterTable string
+	deadLettered    sync2.AtomicInt64
+
+	// group is the consumer group the messages are sent to.
+	// It's empty for the default group.
+	group     string
+	statsName string
+	// partitionKeyIndex is the index of the partition key in
+	// the streamed columns, or -1 if the table has none.
+	partitionKeyIndex int
+
 	mu     sync.Mutex
 	isOpen bool
 	// cond waits on curReceiver == -1 || cache.IsEmpty():
@@ -188,6 +233,10 @@ type messageManager struct {
 	receivers       []*receiverWithStatus
 	curReceiver     int
 	messagesPending bool
+	// partitionKeys maps the held partition keys to the id of the
+	// message that holds them, and holders is the reverse.
+	partitionKeys map[string]string
+	holders       map[string]string
 	// streamCancel is set when a vstream is running, and is reset
 	// to nil after a cancel. This allows for startVStream and stopVStream
 	// to be idempotent.
@@ -231,15 +280,28 @@ type messageManager struct {
 
 	vsFilter                  *binlogdatapb.Filter
 	readByPriorityAndTimeNext *sqlparser.ParsedQuery
-	ackQuery                  *sqlparser.ParsedQuery
-	postponeQuery             *sqlparser.ParsedQuery
-	purgeQuery                *sqlparser.ParsedQuery
+	// readUnheldByPriorityAndTimeNext skips the messages of held
+	// partition keys, except for the messages that hold them.
+	readUnheldByPriorityAndTimeNext *sqlparser.ParsedQuery
+	readHeld                        *sqlparser.ParsedQuery
+	ackQuery                        *sqlparser.ParsedQuery
+	postponeQuery                   *sqlparser.ParsedQuery
+	purgeQuery                      *sqlparser.ParsedQuery
+	// deadLetterQueries are executed in a single transaction
+	// to dead-letter messages that exceeded maxAttempts.
+	deadLetterQueries []*sqlparser.ParsedQuery
 }
 
 // newMessageManager creates a new message manager.
 // Calls into tsv have to be made asynchronously. Otherwise,
 // it can lead to deadlocks.
 func newMessageManager(tsv TabletService, vs VStreamer, table *schema.Table, postponeSema *sync2.Semaphore) *messageManager {
+	return newMessageManagerForGroup(tsv, vs, table, "", postponeSema)
+}
+
+// newMessageManagerForGroup creates a new message manager that sends
+// the messages to a consumer group. The default group is "".
+func newMessageManagerForGroup(tsv TabletService, vs VStreamer, table *schema.Table, group string, postponeSema *sync2.Semaphore) *messageManager {
 	mm := &messageManager{
 		tsv:  tsv,
 		vs:   vs,
@@ -252,48 +314,102 @@ func newMessageManager(tsv TabletService, vs VStreamer, table *schema.Table, pos
 		minBackoff:      table.MessageInfo.MinBackoff,
 		maxBackoff:      table.MessageInfo.MaxBackoff,
 		batchSize:       table.MessageInfo.BatchSize,
+		maxAttempts:     table.MessageInfo.MaxAttempts,
+		deadLetterTable: table.MessageInfo.DeadLetterTable,
+		group:           group,
+		statsName:       StatsLabel(table.Name.String(), group),
 		cache:           newCache(table.MessageInfo.CacheSize),
 		pollerTicks:     timer.NewTimer(table.MessageInfo.PollInterval),
 		purgeTicks:      timer.NewTimer(table.MessageInfo.PollInterval),
 		postponeSema:    postponeSema,
 		messagesPending: true,
+
+		partitionKeyIndex: -1,
+		partitionKeys:     make(map[string]string),
+		holders:           make(map[string]string),
 	}
 	mm.cond.L = &mm.mu
 
+	timeNext := schema.MessageGroupColumn("time_next", group)
+	epoch := schema.MessageGroupColumn("epoch", group)
+	timeAcked := schema.MessageGroupColumn("time_acked", group)
+
 	columnList := buildSelectColumnList(table)
-	vsQuery := fmt.Sprintf("select priority, time_next, epoch, time_acked, %s from %v", columnList, mm.name)
+	stateList := fmt.Sprintf("priority, %s, %s, %s", timeNext, epoch, timeAcked)
+	vsQuery := fmt.Sprintf("select %s, %s from %v", stateList, columnList, mm.name)
 	mm.vsFilter = &binlogdatapb.Filter{
 		Rules: []*binlogdatapb.Rule{{
 			Match:  table.Name.String(),
 			Filter: vsQuery,
 		}},
 	}
+	// There should be a poller_idx defined on (time_acked, priority, time_next desc)
+	// for this to be as effecient as possible
+	order := fmt.Sprintf("priority, %s desc", timeNext)
+	if key := table.MessageInfo.PartitionKey; key != "" {
+		for i, field := range table.MessageInfo.Fields {
+			if strings.EqualFold(field.Name, key) {
+				mm.partitionKeyIndex = i
+			}
+		}
+		// The messages of a partition key must be sent in order.
+		order = fmt.Sprintf("priority, %s", timeNext)
+		mm.cache = newFIFOCache(table.MessageInfo.CacheSize)
+		mm.readUnheldByPriorityAndTimeNext = sqlparser.BuildParsedQuery(
+			"select %s, %s from %v where %s is null and %s < %a and (%v not in %a or id in %a) order by %s limit %a",
+			stateList, columnList, mm.name, timeAcked, timeNext, ":time_next", sqlparser.NewIdentifierCI(key), "::held_keys", "::held_ids", order, ":max")
+		mm.readHeld = sqlparser.BuildParsedQuery(
+			"select %s, %s from %v where id in %a and %s is null",
+			stateList, columnList, mm.name, "::held_ids", timeAcked)
+	}
 	mm.readByPriorityAndTimeNext = sqlparser.BuildParsedQuery(
-		// There should be a poller_idx defined on (time_acked, priority, time_next desc)
-		// for this to be as effecient as possible
-		"select priority, time_next, epoch, time_acked, %s from %v where time_acked is null and time_next < %a order by priority, time_next desc limit %a",
-		columnList, mm.name, ":time_next", ":max")
+		"select %s, %s from %v where %s is null and %s < %a order by %s limit %a",
+		stateList, columnList, mm.name, timeAcked, timeNext, ":time_next", order, ":max")
 	mm.ackQuery = sqlparser.BuildParsedQuery(
-		"update %v set time_acked = %a, time_next = null where id in %a and time_acked is null",
-		mm.name, ":time_acked", "::ids")
-	mm.purgeQuery = sqlparser.BuildParsedQuery(
-		"delete from %v where time_acked < %a limit 500", mm.name, ":time_acked")
+		"update %v set %s = %a, %s = null where id in %a and %s is null",
+		mm.name, timeAcked, ":time_acked", timeNext, "::ids", timeAcked)
+	mm.purgeQuery = buildPurgeQuery(table)
 
-	mm.postponeQuery = buildPostponeQuery(mm.name, mm.minBackoff, mm.maxBackoff)
+	mm.postponeQuery = buildPostponeQuery(mm.name, group, mm.minBackoff, mm.maxBackoff)
+
+	if mm.maxAttempts > 0 {
+		mm.deadLetterQueries = buildDeadLetterQueries(table, group)
+	}
 
 	return mm
 }
 
-func buildPostponeQuery(name sqlparser.IdentifierCS, minBackoff, maxBackoff time.Duration) *sqlparser.ParsedQuery {
+// buildPurgeQuery builds the query that deletes the messages that were
+// acked by all consumer groups. Messages that were dead-lettered in place
+// must be kept around until they're requeued or purged by an operator.
+func buildPurgeQuery(t *schema.Table) *sqlparser.ParsedQuery {
+	var conditions []string
 	var args []any
+	args = append(args, t.Name)
+	for _, group := range append([]string{""}, t.MessageInfo.ConsumerGroups...) {
+		conditions = append(conditions, schema.MessageGroupColumn("time_acked", group)+" < %a")
+		args = append(args, ":time_acked")
+		if t.MessageInfo.MaxAttempts > 0 && t.MessageInfo.DeadLetterTable == "" {
+			conditions = append(conditions, schema.MessageGroupColumn("dead_letter_reason", group)+" is null")
+		}
+	}
+	return sqlparser.BuildParsedQuery(
+		"delete from %v where "+strings.Join(conditions, " and ")+" limit 500", args...)
+}
+
+func buildPostponeQuery(name sqlparser.IdentifierCS, group string, minBackoff, maxBackoff time.Duration) *sqlparser.ParsedQuery {
+	var args []any
+	timeNext := schema.MessageGroupColumn("time_next", group)
+	epoch := schema.MessageGroupColumn("epoch", group)
+	timeAcked := schema.MessageGroupColumn("time_acked", group)
 
 	// since messages are immediately postponed upon sending, we need to add exponential backoff on top
 	// of the ackWaitTime, otherwise messages will be resent too quickly.
-	buf := bytes.NewBufferString("update %v set time_next = %a + %a + ")
+	buf := bytes.NewBufferString(fmt.Sprintf("update %%v set %s = %%a + %%a + ", timeNext))
 	args = append(args, name, ":time_now", ":wait_time")
 
 	// have backoff be +/- 33%, whenever this is injected, append (:min_backoff, :jitter)
-	jitteredBackoff := "FLOOR((%a<<ifnull(epoch, 0)) * %a)"
+	jitteredBackoff := fmt.Sprintf("FLOOR((%%a<<ifnull(%s, 0)) * %%a)", epoch)
 
 	//
 	// if the jittered backoff is less than min_backoff, just set it to :min_backoff
@@ -324,12 +440,48 @@ func buildPostponeQuery(name sqlparser.IdentifierCS, minBackoff, maxBackoff time
 	buf.WriteString(")")
 
 	// now that we've identified time_next, finish the statement
-	buf.WriteString(", epoch = ifnull(epoch, 0)+1 where id in %a and time_acked is null")
+	buf.WriteString(fmt.Sprintf(", %s = ifnull(%s, 0)+1 where id in %%a and %s is null", epoch, epoch, timeAcked))
 	args = append(args, "::ids")
 
 	return sqlparser.BuildParsedQuery(buf.String(), args...)
 }
 
+// buildDeadLetterQueries builds the queries that dead-letter messages.
+// If the table has a dead letter table, the rows are copied there along
+// with the reason and deleted from the message table. Otherwise, they're
+// acked in place for the consumer group with the reason.
+func buildDeadLetterQueries(t *schema.Table, group string) []*sqlparser.ParsedQuery {
+	dlt := t.MessageInfo.DeadLetterTable
+	if dlt == "" {
+		timeAcked := schema.MessageGroupColumn("time_acked", group)
+		return []*sqlparser.ParsedQuery{sqlparser.BuildParsedQuery(
+			"update %v set %s = %a, %s = null, %s = %a where id in %a and %s is null",
+			t.Name, timeAcked, ":time_acked", schema.MessageGroupColumn("time_next", group),
+			schema.MessageGroupColumn("dead_letter_reason", group), ":dead_letter_reason", "::ids", timeAcked)}
+	}
+	buf := sqlparser.NewTrackedBuffer(nil)
+	for _, c := range t.Fields {
+		col := sqlparser.NewIdentifierCI(c.Name)
+		// The reason is set by the insert, even if the message table has the column.
+		if col.EqualString("dead_letter_reason") {
+			continue
+		}
+		if buf.Len() != 0 {
+			buf.WriteString(", ")
+		}
+		buf.Myprintf("%v", col)
+	}
+	columnList := buf.String()
+	return []*sqlparser.ParsedQuery{
+		sqlparser.BuildParsedQuery(
+			"insert into %v(%s, dead_letter_reason) select %s, %a from %v where id in %a and time_acked is null",
+			sqlparser.NewIdentifierCS(dlt), columnList, columnList, ":dead_letter_reason", t.Name, "::ids"),
+		sqlparser.BuildParsedQuery(
+			"delete from %v where id in %a and time_acked is null",
+			t.Name, "::ids"),
+	}
+}
+
 // buildSelectColumnList is a convenience function that
 // builds a 'select' list for the user-defined columns.
 func buildSelectColumnList(t *schema.Table) string {
@@ -359,7 +511,10 @@ func (mm *messageManager) Open() {
 	go mm.runSend() // calls the offsetting mm.wg.Done()
 	// TODO(sougou): improve ticks to add randomness.
 	mm.pollerTicks.Start(mm.runPoller)
-	mm.purgeTicks.Start(mm.runPurge)
+	// Messages are purged only once, by the default group.
+	if mm.group == "" {
+		mm.purgeTicks.Start(mm.runPurge)
+	}
 }
 
 // Close stops the messageManager service.
@@ -382,7 +537,9 @@ func (mm *messageManager) Close() {
 		rcvr.receiver.cancel()
 	}
 	mm.receivers = nil
-	MessageStats.Set([]string{mm.name.String(), "ClientCount"}, 0)
+	mm.partitionKeys = make(map[string]string)
+	mm.holders = make(map[string]string)
+	MessageStats.Set([]string{mm.statsName, "ClientCount"}, 0)
 	log.Infof("messageManager - clearing cache")
 	mm.cache.Clear()
 	log.Infof("messageManager - sending a broadcast")
@@ -424,7 +581,7 @@ func (mm *messageManager) Subscribe(ctx context.Context, send func(*sqltypes.Res
 		mm.startVStream()
 	}
 	mm.receivers = append(mm.receivers, withStatus)
-	MessageStats.Set([]string{mm.name.String(), "ClientCount"}, int64(len(mm.receivers)))
+	MessageStats.Set([]string{mm.statsName, "ClientCount"}, int64(len(mm.receivers)))
 	if mm.curReceiver == -1 {
 		mm.rescanReceivers(-1)
 	}
@@ -448,7 +605,7 @@ func (mm *messageManager) unsubscribe(receiver *messageReceiver) {
 		n := len(mm.receivers)
 		copy(mm.receivers[i:n-1], mm.receivers[i+1:n])
 		mm.receivers = mm.receivers[0 : n-1]
-		MessageStats.Set([]string{mm.name.String(), "ClientCount"}, int64(len(mm.receivers)))
+		MessageStats.Set([]string{mm.statsName, "ClientCount"}, int64(len(mm.receivers)))
 		break
 	}
 	// curReceiver is obsolete. Recompute.
@@ -493,6 +650,11 @@ func (mm *messageManager) Add(mr *MessageRow) bool {
 	}
 	// If cache is empty, we have to broadcast that we're not empty
 	// any more.
+	// If messages are pending, older messages of the partition key
+	// may not be in the cache yet. The poller reads them in order.
+	if mm.partitionKeyIndex != -1 && mm.messagesPending {
+		return false
+	}
 	if mm.cache.IsEmpty() {
 		defer mm.cond.Broadcast()
 	}
@@ -522,6 +684,7 @@ func (mm *messageManager) runSend() {
 		mm.mu.Lock()
 
 		var rows [][]sqltypes.Value
+		var deadLetterIDs, heldIDs []string
 		for {
 			if !mm.isOpen {
 				return
@@ -552,19 +715,41 @@ func (mm *messageManager) runSend() {
 				if mr == nil {
 					break
 				}
+				if !mm.holdPartitionKey(mr) {
+					heldIDs = append(heldIDs, mr.Row[0].ToString())
+					continue
+				}
+				// Epoch is the number of times the message was already sent.
+				if mm.maxAttempts > 0 && mr.Epoch >= int64(mm.maxAttempts) {
+					deadLetterIDs = append(deadLetterIDs, mr.Row[0].ToString())
+					continue
+				}
 				if mr.Epoch >= 1 {
 					lateCount++
 				}
 				rows = append(rows, mr.Row)
 			}
-			MessageStats.Add([]string{mm.name.String(), "Delayed"}, lateCount)
+			MessageStats.Add([]string{mm.statsName, "Delayed"}, lateCount)
+
+			if heldIDs != nil {
+				// These messages are read again by the poller
+				// once their partition key is released.
+				mm.cache.Discard(heldIDs)
+				heldIDs = nil
+			}
+
+			if deadLetterIDs != nil {
+				mm.wg.Add(1)
+				go mm.deadLetter(deadLetterIDs) // calls the offsetting mm.wg.Done()
+				deadLetterIDs = nil
+			}
 
 			// If we have rows to send, break out of this loop.
 			if rows != nil {
 				break
 			}
 		}
-		MessageStats.Add([]string{mm.name.String(), "Sent"}, int64(len(rows)))
+		MessageStats.Add([]string{mm.statsName, "Sent"}, int64(len(rows)))
 		// If we're here, there is a current receiver, and messages
 		// to send. Reserve the receiver and find the next one.
 		receiver := mm.receivers[mm.curReceiver]
@@ -630,7 +815,82 @@ func (mm *messageManager) postpone(tsv TabletService, ackWaitTime time.Duration,
 	defer cancel()
 	if _, err := tsv.PostponeMessages(ctx, nil, mm, ids); err != nil {
 		// This can happen during spikes. Record the incident for monitoring.
-		MessageStats.Add([]string{mm.name.String(), "PostponeFailed"}, 1)
+		MessageStats.Add([]string{mm.statsName, "PostponeFailed"}, 1)
+	}
+}
+
+// deadLetter dead-letters messages that exceeded maxAttempts. If that
+// fails, the messages are postponed, which ensures they'll be retried
+// on a later send.
+func (mm *messageManager) deadLetter(ids []string) {
+	defer func() {
+		mm.tsv.LogError()
+		mm.wg.Done()
+	}()
+
+	defer func() {
+		// Hold cacheManagementMu for the same reason as send.
+		mm.cacheManagementMu.Lock()
+		defer mm.cacheManagementMu.Unlock()
+		mm.cache.Discard(ids)
+	}()
+
+	if !mm.postponeSema.Acquire() {
+		// Unreachable.
+		return
+	}
+	ctx, cancel := context.WithTimeout(tabletenv.LocalContext(), mm.ackWaitTime)
+	count, err := mm.tsv.DeadLetterMessages(ctx, nil, mm, ids)
+	cancel()
+	mm.postponeSema.Release()
+	if err != nil {
+		MessageStats.Add([]string{mm.statsName, "DeadLetterFailed"}, 1)
+		log.Errorf("Unable to dead-letter messages %v of %s: %v", ids, mm.name.String(), err)
+		mm.postpone(mm.tsv, mm.ackWaitTime, ids)
+		return
+	}
+	MessageStats.Add([]string{mm.statsName, "DeadLettered"}, count)
+	mm.deadLettered.Add(count)
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.releasePartitionKeys(ids)
+}
+
+// holdPartitionKey makes the message hold its partition key. It returns
+// false if the key is held by another message. It must be called while
+// holding mm.mu.
+func (mm *messageManager) holdPartitionKey(mr *MessageRow) bool {
+	if mm.partitionKeyIndex == -1 || mr.Row[mm.partitionKeyIndex].IsNull() {
+		return true
+	}
+	id := mr.Row[0].ToString()
+	key := mr.Row[mm.partitionKeyIndex].ToString()
+	if holder, ok := mm.partitionKeys[key]; ok {
+		return holder == id
+	}
+	mm.partitionKeys[key] = id
+	mm.holders[id] = key
+	return true
+}
+
+// releasePartitionKeys releases the partition keys held by the messages,
+// and makes the poller read the next messages of those keys. It must be
+// called while holding mm.mu.
+func (mm *messageManager) releasePartitionKeys(ids []string) {
+	released := false
+	for _, id := range ids {
+		key, ok := mm.holders[id]
+		if !ok {
+			continue
+		}
+		delete(mm.holders, id)
+		delete(mm.partitionKeys, key)
+		released = true
+	}
+	if released {
+		mm.messagesPending = true
+		mm.cond.Broadcast()
 	}
 }
 
@@ -660,7 +920,7 @@ func (mm *messageManager) runVStream(ctx context.Context) {
 			return
 		default:
 		}
-		MessageStats.Add([]string{mm.name.String(), "VStreamFailed"}, 1)
+		MessageStats.Add([]string{mm.statsName, "VStreamFailed"}, 1)
 		log.Infof("VStream ended: %v, retrying in 5 seconds", err)
 		time.Sleep(5 * time.Second)
 	}
@@ -670,7 +930,7 @@ func (mm *messageManager) runVStream(ctx context.Context) {
 // Whether it's an insert or an update, if the new value of the
 // row indicates that the message is eligible to be sent, it's added to
 // the cache.
-// Deletes are ignored.
+// Deletes and acks only release the partition keys held by the messages.
 // If the poller updates lastPollPosition, then all GTIDs up to that
 // point are deemed obsolete and are skipped.
 func (mm *messageManager) runOneVStream(ctx context.Context) error {
@@ -745,8 +1005,16 @@ func (mm *messageManager) processRowEvent(fields []*querypb.Field, rowEvent *bin
 	}
 
 	now := time.Now().UnixNano()
+	var done []string
 	for _, rc := range rowEvent.RowChanges {
 		if rc.After == nil {
+			if rc.Before != nil && mm.partitionKeyIndex != -1 {
+				mr, err := BuildMessageRow(sqltypes.MakeRowTrusted(fields, rc.Before))
+				if err != nil {
+					return err
+				}
+				done = append(done, mr.Row[0].ToString())
+			}
 			continue
 		}
 		row := sqltypes.MakeRowTrusted(fields, rc.After)
@@ -754,11 +1022,20 @@ func (mm *messageManager) processRowEvent(fields []*querypb.Field, rowEvent *bin
 		if err != nil {
 			return err
 		}
-		if mr.TimeAcked != 0 || mr.TimeNext > now {
+		if mr.TimeAcked != 0 {
+			done = append(done, mr.Row[0].ToString())
+			continue
+		}
+		if mr.TimeNext > now {
 			continue
 		}
 		mm.Add(mr)
 	}
+	if done != nil && mm.partitionKeyIndex != -1 {
+		mm.mu.Lock()
+		defer mm.mu.Unlock()
+		mm.releasePartitionKeys(done)
+	}
 	return nil
 }
 
@@ -782,13 +1059,31 @@ func (mm *messageManager) runPoller() {
 		cancel()
 	}()
 
+	// Release the partition keys of the messages that were acked or
+	// deleted without the vstream noticing.
+	if len(mm.holders) != 0 {
+		if err := mm.refreshPartitionKeys(ctx); err != nil {
+			return
+		}
+	}
+
 	size := mm.cache.Size()
 	bindVars := map[string]*querypb.BindVariable{
 		"time_next": sqltypes.Int64BindVariable(time.Now().UnixNano()),
 		"max":       sqltypes.Int64BindVariable(int64(size)),
 	}
+	pq := mm.readByPriorityAndTimeNext
+	if len(mm.holders) != 0 {
+		pq = mm.readUnheldByPriorityAndTimeNext
+		keys := make([]string, 0, len(mm.partitionKeys))
+		for key := range mm.partitionKeys {
+			keys = append(keys, key)
+		}
+		bindVars["held_keys"] = idsBindVariable(keys)
+		bindVars["held_ids"] = idsBindVariable(mm.heldIDs())
+	}
 
-	qr, err := mm.readPending(ctx, bindVars)
+	qr, err := mm.readRows(ctx, pq, bindVars, true)
 	if err != nil {
 		return
 	}
@@ -817,6 +1112,42 @@ func (mm *messageManager) runPoller() {
 	}
 }
 
+// refreshPartitionKeys releases the partition keys held by messages that
+// are not pending anymore. It must be called while holding mm.mu.
+func (mm *messageManager) refreshPartitionKeys(ctx context.Context) error {
+	ids := mm.heldIDs()
+	qr, err := mm.readRows(ctx, mm.readHeld, map[string]*querypb.BindVariable{
+		"held_ids": idsBindVariable(ids),
+	}, false)
+	if err != nil {
+		return err
+	}
+	pending := make(map[string]bool, len(qr.Rows))
+	for _, row := range qr.Rows {
+		mr, err := BuildMessageRow(row)
+		if err != nil {
+			return err
+		}
+		pending[mr.Row[0].ToString()] = true
+	}
+	var done []string
+	for _, id := range ids {
+		if !pending[id] {
+			done = append(done, id)
+		}
+	}
+	mm.releasePartitionKeys(done)
+	return nil
+}
+
+func (mm *messageManager) heldIDs() []string {
+	ids := make([]string, 0, len(mm.holders))
+	for id := range mm.holders {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 func (mm *messageManager) runPurge() {
 	go func() {
 		ctx, cancel := context.WithTimeout(tabletenv.LocalContext(), mm.purgeTicks.Interval())
@@ -827,10 +1158,10 @@ func (mm *messageManager) runPurge() {
 		for {
 			count, err := mm.tsv.PurgeMessages(ctx, nil, mm, time.Now().Add(-mm.purgeAfter).UnixNano())
 			if err != nil {
-				MessageStats.Add([]string{mm.name.String(), "PurgeFailed"}, 1)
+				MessageStats.Add([]string{mm.statsName, "PurgeFailed"}, 1)
 				log.Errorf("Unable to delete messages: %v", err)
 			} else {
-				MessageStats.Add([]string{mm.name.String(), "Purged"}, count)
+				MessageStats.Add([]string{mm.statsName, "Purged"}, count)
 			}
 			// If deleted 500 or more, we should continue.
 			if count < 500 {
@@ -842,16 +1173,7 @@ func (mm *messageManager) runPurge() {
 
 // GenerateAckQuery returns the query and bind vars for acking a message.
 func (mm *messageManager) GenerateAckQuery(ids []string) (string, map[string]*querypb.BindVariable) {
-	idbvs := &querypb.BindVariable{
-		Type:   querypb.Type_TUPLE,
-		Values: make([]*querypb.Value, 0, len(ids)),
-	}
-	for _, id := range ids {
-		idbvs.Values = append(idbvs.Values, &querypb.Value{
-			Type:  querypb.Type_VARBINARY,
-			Value: []byte(id),
-		})
-	}
+	idbvs := idsBindVariable(ids)
 	return mm.ackQuery.Query, map[string]*querypb.BindVariable{
 		"time_acked": sqltypes.Int64BindVariable(time.Now().UnixNano()),
 		"ids":        idbvs,
@@ -860,16 +1182,7 @@ func (mm *messageManager) GenerateAckQuery(ids []string) (string, map[string]*qu
 
 // GeneratePostponeQuery returns the query and bind vars for postponing a message.
 func (mm *messageManager) GeneratePostponeQuery(ids []string) (string, map[string]*querypb.BindVariable) {
-	idbvs := &querypb.BindVariable{
-		Type:   querypb.Type_TUPLE,
-		Values: make([]*querypb.Value, 0, len(ids)),
-	}
-	for _, id := range ids {
-		idbvs.Values = append(idbvs.Values, &querypb.Value{
-			Type:  querypb.Type_VARBINARY,
-			Value: []byte(id),
-		})
-	}
+	idbvs := idsBindVariable(ids)
 
 	bvs := map[string]*querypb.BindVariable{
 		"time_now":    sqltypes.Int64BindVariable(time.Now().UnixNano()),
@@ -893,6 +1206,41 @@ func (mm *messageManager) GeneratePurgeQuery(timeCutoff int64) (string, map[stri
 	}
 }
 
+// GenerateDeadLetterQueries returns the queries and bind vars for
+// dead-lettering messages. The queries must be executed in order
+// within the same transaction.
+func (mm *messageManager) GenerateDeadLetterQueries(ids []string) ([]string, map[string]*querypb.BindVariable) {
+	idbvs := idsBindVariable(ids)
+	queries := make([]string, 0, len(mm.deadLetterQueries))
+	for _, pq := range mm.deadLetterQueries {
+		queries = append(queries, pq.Query)
+	}
+	bvs := map[string]*querypb.BindVariable{
+		"dead_letter_reason": sqltypes.StringBindVariable(fmt.Sprintf("max attempts (%d) exceeded", mm.maxAttempts)),
+		"ids":                idbvs,
+	}
+	if mm.deadLetterTable == "" {
+		bvs["time_acked"] = sqltypes.Int64BindVariable(time.Now().UnixNano())
+	}
+	return queries, bvs
+}
+
+// idsBindVariable builds the tuple bind variable of message ids
+// or partition keys.
+func idsBindVariable(ids []string) *querypb.BindVariable {
+	idbvs := &querypb.BindVariable{
+		Type:   querypb.Type_TUPLE,
+		Values: make([]*querypb.Value, 0, len(ids)),
+	}
+	for _, id := range ids {
+		idbvs.Values = append(idbvs.Values, &querypb.Value{
+			Type:  querypb.Type_VARBINARY,
+			Value: []byte(id),
+		})
+	}
+	return idbvs
+}
+
 // BuildMessageRow builds a MessageRow from a db row.
 func BuildMessageRow(row []sqltypes.Value) (*MessageRow, error) {
 	mr := &MessageRow{Row: row[4:]}
@@ -927,8 +1275,10 @@ func BuildMessageRow(row []sqltypes.Value) (*MessageRow, error) {
 	return mr, nil
 }
 
-func (mm *messageManager) readPending(ctx context.Context, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
-	query, err := mm.readByPriorityAndTimeNext.GenerateQuery(bindVars, nil)
+// readRows reads messages from the message table. If updatePosition is set,
+// lastPollPosition is moved to the position of the snapshot that was read.
+func (mm *messageManager) readRows(ctx context.Context, pq *sqlparser.ParsedQuery, bindVars map[string]*querypb.BindVariable, updatePosition bool) (*sqltypes.Result, error) {
+	query, err := pq.GenerateQuery(bindVars, nil)
 	if err != nil {
 		mm.tsv.Stats().InternalErrors.Add("Messages", 1)
 		log.Errorf("Error reading rows from message table: %v", err)
@@ -939,7 +1289,7 @@ func (mm *messageManager) readPending(ctx context.Context, bindVars map[string]*
 		if response.Fields != nil {
 			qr.Fields = response.Fields
 		}
-		if response.Gtid != "" {
+		if response.Gtid != "" && updatePosition {
 			pos, err := mysql.DecodePosition(response.Gtid)
 			if err != nil {
 				return err
@@ -963,6 +1313,36 @@ func (mm *messageManager) getReceiverCount() int {
 	return len(mm.receivers)
 }
 
+// messageManagerStatus is the status of a message manager
+// as reported by /debug/messages.
+type messageManagerStatus struct {
+	Name              string
+	Group             string `json:",omitempty"`
+	ReceiverCount     int
+	AckWaitTime       string
+	MaxAttempts       int
+	DeadLetterTable   string `json:",omitempty"`
+	DeadLettered      int64
+	HeldPartitionKeys int
+}
+
+func (mm *messageManager) status() *messageManagerStatus {
+	mm.mu.Lock()
+	receiverCount := len(mm.receivers)
+	heldPartitionKeys := len(mm.partitionKeys)
+	mm.mu.Unlock()
+	return &messageManagerStatus{
+		Name:              mm.name.String(),
+		Group:             mm.group,
+		ReceiverCount:     receiverCount,
+		AckWaitTime:       mm.ackWaitTime.String(),
+		MaxAttempts:       mm.maxAttempts,
+		DeadLetterTable:   mm.deadLetterTable,
+		DeadLettered:      mm.deadLettered.Get(),
+		HeldPartitionKeys: heldPartitionKeys,
+	}
+}
+
 func (mm *messageManager) getLastPollPosition() *mysql.Position {
 	mm.cacheManagementMu.Lock()
 	defer mm.cacheManagementMu.Unlock()
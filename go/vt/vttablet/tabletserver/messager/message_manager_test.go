@@ -32,6 +32,7 @@ import (
 	"vitess.io/vitess/go/test/utils"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/sync2"
@@ -204,6 +205,54 @@ func TestMessageManagerAdd(t *testing.T) {
 	}
 }
 
+func TestMessageManagerDeadLetter(t *testing.T) {
+	tsv := newFakeTabletServer()
+	ti := newMMTable()
+	ti.MessageInfo.MaxAttempts = 2
+	mm := newMessageManager(tsv, newFakeVStreamer(), ti, sync2.NewSemaphore(1, 0))
+	mm.Open()
+	defer mm.Close()
+
+	r1 := newTestReceiver(1)
+	mm.Subscribe(context.Background(), r1.rcv)
+	<-r1.ch
+
+	ch := make(chan string, 20)
+	tsv.SetChannel(ch)
+
+	// Messages that were sent fewer than max attempts times are sent.
+	mm.Add(&MessageRow{Epoch: 1, Row: []sqltypes.Value{sqltypes.NewVarBinary("1"), sqltypes.NULL}})
+	<-r1.ch
+	assert.Equal(t, "postpone", <-ch)
+
+	// Messages that were sent max attempts times are dead-lettered instead.
+	mm.Add(&MessageRow{Epoch: 2, Row: []sqltypes.Value{sqltypes.NewVarBinary("2"), sqltypes.NULL}})
+	assert.Equal(t, "deadletter", <-ch)
+	select {
+	case got := <-r1.ch:
+		t.Errorf("Received: %v, want nothing", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.EqualValues(t, 1, tsv.deadLetterCount.Get())
+
+	// Verify item has been removed from cache.
+	for i := 0; i < 10; i++ {
+		mm.cache.mu.Lock()
+		_, inFlight := mm.cache.inFlight["2"]
+		mm.cache.mu.Unlock()
+		if !inFlight {
+			break
+		}
+		runtime.Gosched()
+		time.Sleep(10 * time.Millisecond)
+	}
+	mm.cache.mu.Lock()
+	_, inFlight := mm.cache.inFlight["2"]
+	mm.cache.mu.Unlock()
+	assert.False(t, inFlight)
+	assert.EqualValues(t, 1, mm.status().DeadLettered)
+}
+
 func TestMessageManagerSend(t *testing.T) {
 	tsv := newFakeTabletServer()
 	mm := newMessageManager(tsv, newFakeVStreamer(), newMMTable(), sync2.NewSemaphore(1, 0))
@@ -713,6 +762,111 @@ func TestMessagesPending2(t *testing.T) {
 	}
 }
 
+func newPartitionedMMRow(id, timeNext int64, key string) *querypb.Row {
+	return sqltypes.RowToProto3([]sqltypes.Value{
+		sqltypes.NewInt64(1),
+		sqltypes.NewInt64(timeNext),
+		sqltypes.NewInt64(0),
+		sqltypes.NULL,
+		sqltypes.NewInt64(id),
+		sqltypes.NewVarBinary(key),
+	})
+}
+
+func TestMessageManagerPartitionKey(t *testing.T) {
+	fvs := newFakeVStreamer()
+	fvs.setPollerResponse([]*binlogdatapb.VStreamResultsResponse{{
+		Fields: testDBFields,
+		Rows: []*querypb.Row{
+			newPartitionedMMRow(1, 1, "a"),
+			newPartitionedMMRow(2, 2, "a"),
+			newPartitionedMMRow(3, 3, "b"),
+		},
+	}})
+	ti := newMMTable()
+	ti.MessageInfo.PartitionKey = "message"
+	ti.MessageInfo.PollInterval = 10 * time.Millisecond
+	mm := newMessageManager(newFakeTabletServer(), fvs, ti, sync2.NewSemaphore(1, 0))
+	mm.Open()
+	defer mm.Close()
+
+	r1 := newTestReceiver(100)
+	mm.Subscribe(context.Background(), r1.rcv)
+	<-r1.ch
+
+	// Message 2 is not sent while message 1 holds partition key a.
+	got := make(map[string]bool)
+	for len(got) < 2 {
+		qr := <-r1.ch
+		got[qr.Rows[0][0].ToString()] = true
+	}
+	assert.Equal(t, map[string]bool{"1": true, "3": true}, got)
+	assert.Equal(t, 2, mm.status().HeldPartitionKeys)
+
+	// Once message 1 is not pending anymore, the poller releases the key.
+	fvs.setPollerResponse([]*binlogdatapb.VStreamResultsResponse{{
+		Fields: testDBFields,
+		Rows: []*querypb.Row{
+			newPartitionedMMRow(2, 2, "a"),
+		},
+	}})
+	for {
+		qr := <-r1.ch
+		if qr.Rows[0][0].ToString() == "2" {
+			break
+		}
+	}
+}
+
+func TestMessageManagerPartitionKeyRelease(t *testing.T) {
+	ti := newMMTable()
+	ti.MessageInfo.PartitionKey = "message"
+	mm := newMessageManager(newFakeTabletServer(), newFakeVStreamer(), ti, sync2.NewSemaphore(1, 0))
+
+	mr := &MessageRow{Row: []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewVarBinary("a")}}
+	assert.True(t, mm.holdPartitionKey(mr))
+	// The holder can be sent again.
+	assert.True(t, mm.holdPartitionKey(mr))
+	assert.False(t, mm.holdPartitionKey(&MessageRow{Row: []sqltypes.Value{sqltypes.NewInt64(2), sqltypes.NewVarBinary("a")}}))
+	// Messages without a partition key are sent independently.
+	assert.True(t, mm.holdPartitionKey(&MessageRow{Row: []sqltypes.Value{sqltypes.NewInt64(3), sqltypes.NULL}}))
+
+	// An ack releases the key.
+	fields := []*querypb.Field{
+		{Name: "priority", Type: sqltypes.Int64},
+		{Name: "time_next", Type: sqltypes.Int64},
+		{Name: "epoch", Type: sqltypes.Int64},
+		{Name: "time_acked", Type: sqltypes.Int64},
+		{Name: "id", Type: sqltypes.Int64},
+		{Name: "message", Type: sqltypes.VarBinary},
+	}
+	err := mm.processRowEvent(fields, &binlogdatapb.RowEvent{
+		TableName: "foo",
+		RowChanges: []*binlogdatapb.RowChange{{
+			After: sqltypes.RowToProto3([]sqltypes.Value{
+				sqltypes.NewInt64(1),
+				sqltypes.NULL,
+				sqltypes.NewInt64(1),
+				sqltypes.NewInt64(10),
+				sqltypes.NewInt64(1),
+				sqltypes.NewVarBinary("a"),
+			}),
+		}},
+	})
+	require.NoError(t, err)
+	assert.True(t, mm.holdPartitionKey(&MessageRow{Row: []sqltypes.Value{sqltypes.NewInt64(2), sqltypes.NewVarBinary("a")}}))
+
+	// A delete releases the key.
+	err = mm.processRowEvent(fields, &binlogdatapb.RowEvent{
+		TableName: "foo",
+		RowChanges: []*binlogdatapb.RowChange{{
+			Before: newPartitionedMMRow(2, 2, "a"),
+		}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, mm.partitionKeys)
+}
+
 func TestMessageManagerPurge(t *testing.T) {
 	tsv := newFakeTabletServer()
 
@@ -822,10 +976,100 @@ func TestMMGenerateWithBackoff(t *testing.T) {
 	}
 }
 
+func TestMMGenerateDeadLetter(t *testing.T) {
+	ti := newMMTable()
+	ti.MessageInfo.MaxAttempts = 3
+	mm := newMessageManager(newFakeTabletServer(), newFakeVStreamer(), ti, sync2.NewSemaphore(1, 0))
+
+	wantids := sqltypes.TestBindVariable([]any{[]byte{'1'}, []byte{'2'}})
+
+	queries, bv := mm.GenerateDeadLetterQueries([]string{"1", "2"})
+	wantQueries := []string{
+		"update foo set time_acked = :time_acked, time_next = null, dead_letter_reason = :dead_letter_reason where id in ::ids and time_acked is null",
+	}
+	assert.Equal(t, wantQueries, queries)
+	_, ok := bv["time_acked"]
+	assert.True(t, ok, "time_acked is absent in %v", bv)
+	// time_acked cannot be compared.
+	delete(bv, "time_acked")
+	wantbv := map[string]*querypb.BindVariable{
+		"dead_letter_reason": sqltypes.StringBindVariable("max attempts (3) exceeded"),
+		"ids":                wantids,
+	}
+	utils.MustMatch(t, wantbv, bv, "did not match")
+
+	// Acked messages that were dead-lettered must not be purged.
+	query, _ := mm.GeneratePurgeQuery(3)
+	assert.Equal(t, "delete from foo where time_acked < :time_acked and dead_letter_reason is null limit 500", query)
+
+	ti = newMMTable()
+	ti.Fields = []*querypb.Field{
+		{Name: "id", Type: sqltypes.VarBinary},
+		{Name: "priority", Type: sqltypes.Int64},
+		{Name: "time_next", Type: sqltypes.Int64},
+		{Name: "epoch", Type: sqltypes.Int64},
+		{Name: "time_acked", Type: sqltypes.Int64},
+		{Name: "message", Type: sqltypes.VarBinary},
+		// The column of the reason is not copied.
+		{Name: "dead_letter_reason", Type: sqltypes.VarBinary},
+	}
+	ti.MessageInfo.MaxAttempts = 3
+	ti.MessageInfo.DeadLetterTable = "foo_dlq"
+	mm = newMessageManager(newFakeTabletServer(), newFakeVStreamer(), ti, sync2.NewSemaphore(1, 0))
+
+	queries, bv = mm.GenerateDeadLetterQueries([]string{"1", "2"})
+	wantQueries = []string{
+		"insert into foo_dlq(id, priority, time_next, epoch, time_acked, message, dead_letter_reason) select id, priority, time_next, epoch, time_acked, message, :dead_letter_reason from foo where id in ::ids and time_acked is null",
+		"delete from foo where id in ::ids and time_acked is null",
+	}
+	assert.Equal(t, wantQueries, queries)
+	utils.MustMatch(t, wantbv, bv, "did not match")
+
+	query, _ = mm.GeneratePurgeQuery(3)
+	assert.Equal(t, "delete from foo where time_acked < :time_acked limit 500", query)
+}
+
+func TestMMGenerateConsumerGroup(t *testing.T) {
+	ti := newMMTable()
+	ti.MessageInfo.ConsumerGroups = []string{"billing"}
+	ti.MessageInfo.MaxAttempts = 3
+	mm := newMessageManagerForGroup(newFakeTabletServer(), newFakeVStreamer(), ti, "billing", sync2.NewSemaphore(1, 0))
+
+	assert.Equal(t, "select priority, time_next_billing, epoch_billing, time_acked_billing, id, message from foo", mm.vsFilter.Rules[0].Filter)
+	assert.Equal(t, "select priority, time_next_billing, epoch_billing, time_acked_billing, id, message from foo where time_acked_billing is null and time_next_billing < :time_next order by priority, time_next_billing desc limit :max", mm.readByPriorityAndTimeNext.Query)
+
+	query, _ := mm.GenerateAckQuery([]string{"1", "2"})
+	assert.Equal(t, "update foo set time_acked_billing = :time_acked, time_next_billing = null where id in ::ids and time_acked_billing is null", query)
+
+	query, _ = mm.GeneratePostponeQuery([]string{"1", "2"})
+	assert.Equal(t, "update foo set time_next_billing = :time_now + :wait_time + IF(FLOOR((:min_backoff<<ifnull(epoch_billing, 0)) * :jitter) < :min_backoff, :min_backoff, FLOOR((:min_backoff<<ifnull(epoch_billing, 0)) * :jitter)), epoch_billing = ifnull(epoch_billing, 0)+1 where id in ::ids and time_acked_billing is null", query)
+
+	queries, _ := mm.GenerateDeadLetterQueries([]string{"1", "2"})
+	assert.Equal(t, []string{
+		"update foo set time_acked_billing = :time_acked, time_next_billing = null, dead_letter_reason_billing = :dead_letter_reason where id in ::ids and time_acked_billing is null",
+	}, queries)
+
+	// Messages are purged once all groups acked them.
+	query, _ = mm.GeneratePurgeQuery(3)
+	assert.Equal(t, "delete from foo where time_acked < :time_acked and dead_letter_reason is null and time_acked_billing < :time_acked and dead_letter_reason_billing is null limit 500", query)
+}
+
+func TestMMGeneratePartitionKey(t *testing.T) {
+	ti := newMMTable()
+	ti.MessageInfo.PartitionKey = "message"
+	mm := newMessageManager(newFakeTabletServer(), newFakeVStreamer(), ti, sync2.NewSemaphore(1, 0))
+
+	assert.Equal(t, 1, mm.partitionKeyIndex)
+	assert.Equal(t, "select priority, time_next, epoch, time_acked, id, message from foo where time_acked is null and time_next < :time_next order by priority, time_next limit :max", mm.readByPriorityAndTimeNext.Query)
+	assert.Equal(t, "select priority, time_next, epoch, time_acked, id, message from foo where time_acked is null and time_next < :time_next and (message not in ::held_keys or id in ::held_ids) order by priority, time_next limit :max", mm.readUnheldByPriorityAndTimeNext.Query)
+	assert.Equal(t, "select priority, time_next, epoch, time_acked, id, message from foo where id in ::held_ids and time_acked is null", mm.readHeld.Query)
+}
+
 type fakeTabletServer struct {
 	tabletenv.Env
-	postponeCount sync2.AtomicInt64
-	purgeCount    sync2.AtomicInt64
+	postponeCount   sync2.AtomicInt64
+	purgeCount      sync2.AtomicInt64
+	deadLetterCount sync2.AtomicInt64
 
 	mu sync.Mutex
 	ch chan string
@@ -868,6 +1112,17 @@ func (fts *fakeTabletServer) PurgeMessages(ctx context.Context, target *querypb.
 	return 0, nil
 }
 
+func (fts *fakeTabletServer) DeadLetterMessages(ctx context.Context, target *querypb.Target, gen QueryGenerator, ids []string) (count int64, err error) {
+	fts.deadLetterCount.Add(1)
+	fts.mu.Lock()
+	ch := fts.ch
+	fts.mu.Unlock()
+	if ch != nil {
+		ch <- "deadletter"
+	}
+	return int64(len(ids)), nil
+}
+
 type fakeVStreamer struct {
 	streamInvocations sync2.AtomicInt64
 	mu                sync.Mutex
@@ -77,6 +77,7 @@ func analyzeUpdate(upd *sqlparser.Update, tables map[string]*schema.Table) (plan
 		buf := sqlparser.NewTrackedBuffer(nil)
 		buf.Myprintf("%v", upd.Where)
 		plan.WhereClause = buf.ParsedQuery()
+		plan.PKWhereClause = analyzePKWhereClause(upd.Where, plan.Table)
 	}
 
 	// Situations when we pass-through:
@@ -106,6 +107,7 @@ func analyzeDelete(del *sqlparser.Delete, tables map[string]*schema.Table) (plan
 		buf := sqlparser.NewTrackedBuffer(nil)
 		buf.Myprintf("%v", del.Where)
 		plan.WhereClause = buf.ParsedQuery()
+		plan.PKWhereClause = analyzePKWhereClause(del.Where, plan.Table)
 	}
 
 	if PassthroughDMLs || plan.Table == nil || del.Limit != nil {
@@ -119,6 +121,55 @@ func analyzeDelete(del *sqlparser.Delete, tables map[string]*schema.Table) (plan
 	return plan, nil
 }
 
+// analyzePKWhereClause returns a WHERE clause which compares the primary key
+// columns of the table, in primary key order, to the values they are pinned to
+// by where. It returns nil unless every primary key column is compared for
+// equality to a literal or a bind variable i.e. the DML touches at most one row.
+func analyzePKWhereClause(where *sqlparser.Where, table *schema.Table) *sqlparser.ParsedQuery {
+	if table == nil || !table.HasPrimary() {
+		return nil
+	}
+	values := make([]sqlparser.Expr, len(table.PKColumns))
+	for _, filter := range sqlparser.SplitAndExpression(nil, where.Expr) {
+		comparison, ok := filter.(*sqlparser.ComparisonExpr)
+		if !ok || comparison.Operator != sqlparser.EqualOp {
+			continue
+		}
+		col, val := comparison.Left, comparison.Right
+		if _, ok := col.(*sqlparser.ColName); !ok {
+			col, val = val, col
+		}
+		colName, ok := col.(*sqlparser.ColName)
+		if !ok {
+			continue
+		}
+		switch val.(type) {
+		case *sqlparser.Literal, sqlparser.Argument:
+		default:
+			continue
+		}
+		for i, pkIndex := range table.PKColumns {
+			if pkIndex < len(table.Fields) && values[i] == nil && colName.Name.EqualString(table.Fields[pkIndex].Name) {
+				values[i] = val
+			}
+		}
+	}
+
+	buf := sqlparser.NewTrackedBuffer(nil)
+	for i, val := range values {
+		if val == nil {
+			return nil
+		}
+		if i == 0 {
+			buf.Myprintf(" where ")
+		} else {
+			buf.Myprintf(" and ")
+		}
+		buf.Myprintf("%v = %v", sqlparser.NewIdentifierCI(table.GetPKColumn(i).Name), val)
+	}
+	return buf.ParsedQuery()
+}
+
 func analyzeInsert(ins *sqlparser.Insert, tables map[string]*schema.Table) (plan *Plan, err error) {
 	plan = &Plan{
 		PlanID:    PlanInsert,
@@ -67,6 +67,8 @@ func (cached *Plan) CachedSize(alloc bool) int64 {
 	}
 	// field WhereClause *vitess.io/vitess/go/vt/sqlparser.ParsedQuery
 	size += cached.WhereClause.CachedSize(true)
+	// field PKWhereClause *vitess.io/vitess/go/vt/sqlparser.ParsedQuery
+	size += cached.PKWhereClause.CachedSize(true)
 	// field FullStmt vitess.io/vitess/go/vt/sqlparser.Statement
 	if cc, ok := cached.FullStmt.(cachedObject); ok {
 		size += cc.CachedSize(true)
@@ -169,6 +169,12 @@ type Plan struct {
 	// to serialize e.g. UPDATEs going to the same row.
 	WhereClause *sqlparser.ParsedQuery
 
+	// PKWhereClause is set for DMLs which pin every primary key column
+	// to a single value. It compares the columns in primary key order
+	// e.g. " where eid = :eid and id = 1" and is preferred over WhereClause
+	// by the hot row protection because equivalent DMLs share it.
+	PKWhereClause *sqlparser.ParsedQuery
+
 	// FullStmt can be used when the query does not operate on tables
 	FullStmt sqlparser.Statement
 
@@ -127,9 +127,11 @@ func (qre *QueryExecutor) Execute() (reply *sqltypes.Result, err error) {
 		qre.tsv.Stats().ResultHistogram.Add(int64(len(reply.Rows)))
 	}(time.Now())
 
-	if err = qre.checkPermissions(); err != nil {
+	release, err := qre.checkPermissions()
+	if err != nil {
 		return nil, err
 	}
+	defer release()
 
 	if qre.plan.PlanID == p.PlanNextval {
 		return qre.execNextval()
@@ -282,9 +284,11 @@ func (qre *QueryExecutor) Stream(callback StreamCallback) error {
 		qre.recordUserQuery("Stream", int64(time.Since(start)))
 	}(time.Now())
 
-	if err := qre.checkPermissions(); err != nil {
+	release, err := qre.checkPermissions()
+	if err != nil {
 		return err
 	}
+	defer release()
 
 	switch qre.plan.PlanID {
 	case p.PlanSelectStream:
@@ -361,8 +365,8 @@ func (qre *QueryExecutor) Stream(callback StreamCallback) error {
 	})
 }
 
-// MessageStream streams messages from a message table.
-func (qre *QueryExecutor) MessageStream(callback StreamCallback) error {
+// MessageStream streams messages from a message table to a consumer group.
+func (qre *QueryExecutor) MessageStream(group string, callback StreamCallback) error {
 	qre.logStats.OriginalSQL = qre.query
 	qre.logStats.PlanType = qre.plan.PlanID.String()
 
@@ -371,11 +375,13 @@ func (qre *QueryExecutor) MessageStream(callback StreamCallback) error {
 		qre.recordUserQuery("MessageStream", int64(time.Since(start)))
 	}(time.Now())
 
-	if err := qre.checkPermissions(); err != nil {
+	release, err := qre.checkPermissions()
+	if err != nil {
 		return err
 	}
+	defer release()
 
-	done, err := qre.tsv.messager.Subscribe(qre.ctx, qre.plan.TableName().String(), func(r *sqltypes.Result) error {
+	done, err := qre.tsv.messager.Subscribe(qre.ctx, qre.plan.TableName().String(), group, func(r *sqltypes.Result) error {
 		select {
 		case <-qre.ctx.Done():
 			return io.EOF
@@ -391,11 +397,14 @@ func (qre *QueryExecutor) MessageStream(callback StreamCallback) error {
 }
 
 // checkPermissions returns an error if the query does not pass all checks
-// (denied query, table ACL).
-func (qre *QueryExecutor) checkPermissions() error {
+// (denied query, table ACL). It also applies the action of the query rule
+// that matches the query, and returns a function that must be called once
+// the query is done to release what the action holds.
+func (qre *QueryExecutor) checkPermissions() (release func(), err error) {
+	release = func() {}
 	// Skip permissions check if the context is local.
 	if tabletenv.IsLocalContext(qre.ctx) {
-		return nil
+		return release, nil
 	}
 
 	// Check if the query relates to a table that is in the denylist.
@@ -410,14 +419,14 @@ func (qre *QueryExecutor) checkPermissions() error {
 	bufferingTimeoutCtx, cancel := context.WithTimeout(qre.ctx, maxQueryBufferDuration)
 	defer cancel()
 
-	action, ruleCancelCtx, desc := qre.plan.Rules.GetAction(remoteAddr, username, qre.bindVars, qre.marginComments)
-	switch action {
+	rule := qre.plan.Rules.GetRule(remoteAddr, username, qre.bindVars, qre.marginComments)
+	switch rule.Action() {
 	case rules.QRFail:
-		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "disallowed due to rule: %s", desc)
+		return release, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "disallowed due to rule: %s", rule.Description)
 	case rules.QRFailRetry:
-		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "disallowed due to rule: %s", desc)
+		return release, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "disallowed due to rule: %s", rule.Description)
 	case rules.QRBuffer:
-		if ruleCancelCtx != nil {
+		if ruleCancelCtx := rule.CancelContext(); ruleCancelCtx != nil {
 			// We buffer up to some timeout. The timeout is determined by ctx.Done().
 			// If we're not at timeout yet, we fail the query
 			select {
@@ -425,43 +434,90 @@ func (qre *QueryExecutor) checkPermissions() error {
 				// good! We have buffered the query, and buffering is completed
 			case <-bufferingTimeoutCtx.Done():
 				// Sorry, timeout while waiting for buffering to complete
-				return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "buffer timeout in rule: %s", desc)
+				return release, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "buffer timeout in rule: %s", rule.Description)
 			}
 		}
+	case rules.QRThrottle:
+		if !rule.AcquireThrottle(qre.ctx) {
+			return release, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "throttled by rule: %s", rule.Description)
+		}
+		release = rule.ReleaseThrottle
+	case rules.QRMaxExecutionTime:
+		var cancel context.CancelFunc
+		qre.ctx, cancel = context.WithTimeout(qre.ctx, rule.MaxExecutionTime())
+		release = cancel
+	case rules.QRRewrite:
+		if err := qre.rewriteQuery(rule); err != nil {
+			return release, vterrors.Wrapf(err, "failed to rewrite query by rule: %s", rule.Description)
+		}
 	default:
 		// no rules against this query. Good to proceed
 	}
+	// If the query is not allowed by the table ACLs, release what the action
+	// holds right away.
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
 	// Skip ACL check for queries against the dummy dual table
 	if qre.plan.TableName().String() == "dual" {
-		return nil
+		return release, nil
 	}
 
 	// Skip the ACL check if the connecting user is an exempted superuser.
 	if qre.tsv.qe.exemptACL != nil && qre.tsv.qe.exemptACL.IsMember(&querypb.VTGateCallerID{Username: username}) {
 		qre.tsv.qe.tableaclExemptCount.Add(1)
-		return nil
+		return release, nil
 	}
 
 	callerID := callerid.ImmediateCallerIDFromContext(qre.ctx)
 	if callerID == nil {
 		if qre.tsv.qe.strictTableACL {
-			return vterrors.Errorf(vtrpcpb.Code_UNAUTHENTICATED, "missing caller id")
+			return release, vterrors.Errorf(vtrpcpb.Code_UNAUTHENTICATED, "missing caller id")
 		}
-		return nil
+		return release, nil
 	}
 
 	// Skip the ACL check if the caller id is an exempted superuser.
 	if qre.tsv.qe.exemptACL != nil && qre.tsv.qe.exemptACL.IsMember(callerID) {
 		qre.tsv.qe.tableaclExemptCount.Add(1)
-		return nil
+		return release, nil
 	}
 
 	for i, auth := range qre.plan.Authorized {
 		if err := qre.checkAccess(auth, qre.plan.Permissions[i].TableName, callerID); err != nil {
-			return err
+			return release, err
 		}
 	}
 
+	return release, nil
+}
+
+// rewriteQuery replaces the query and its plan with the query rewritten by a
+// REWRITE rule, and its plan. The rules are not applied to the rewritten query.
+func (qre *QueryExecutor) rewriteQuery(rule *rules.Rule) error {
+	if qre.plan.PlanID == p.PlanMessageStream {
+		return nil
+	}
+	query, err := rule.RewriteQuery(qre.query)
+	if err != nil {
+		return err
+	}
+	if query == qre.query {
+		return nil
+	}
+	var plan *TabletPlan
+	if qre.plan.PlanID == p.PlanSelectStream {
+		plan, err = qre.tsv.qe.GetStreamPlan(query)
+	} else {
+		plan, err = qre.tsv.qe.GetPlan(qre.ctx, qre.logStats, query, skipQueryPlanCache(qre.options))
+	}
+	if err != nil {
+		return err
+	}
+	qre.query = query
+	qre.plan = plan
 	return nil
 }
 
@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/tx"
 
@@ -795,7 +796,7 @@ func TestQueryExecutorMessageStreamACL(t *testing.T) {
 	}
 
 	// Should not fail because u1 has permission.
-	err = qre.MessageStream(func(qr *sqltypes.Result) error {
+	err = qre.MessageStream("", func(qr *sqltypes.Result) error {
 		return io.EOF
 	})
 	if err != nil {
@@ -808,7 +809,7 @@ func TestQueryExecutorMessageStreamACL(t *testing.T) {
 	}
 	qre.ctx = callerid.NewContext(context.Background(), nil, callerID)
 	// Should fail because u2 does not have permission.
-	err = qre.MessageStream(func(qr *sqltypes.Result) error {
+	err = qre.MessageStream("", func(qr *sqltypes.Result) error {
 		return io.EOF
 	})
 
@@ -1203,6 +1204,118 @@ func TestQueryExecutorDenyListQRRetry(t *testing.T) {
 	}
 }
 
+func TestQueryExecutorRuleThrottle(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table limit 1000"
+	db.AddQuery(query, &sqltypes.Result{Fields: getTestTableFields()})
+
+	throttleRule := rules.NewQueryRule("throttle test_table", "throttle test_table", rules.QRThrottle)
+	err := throttleRule.SetThrottle(1, 0, time.Millisecond)
+	require.NoError(t, err)
+	throttleRule.AddTableCond("test_table")
+
+	rulesName := "ruleThrottle"
+	qrs := rules.New()
+	qrs.Add(throttleRule)
+
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	defer tsv.StopService()
+	tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	tsv.qe.queryRuleSources.RegisterSource(rulesName)
+	defer tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	err = tsv.qe.queryRuleSources.SetRules(rulesName, qrs)
+	require.NoError(t, err)
+
+	qre := newTestQueryExecutor(ctx, tsv, query, 0)
+	_, err = qre.Execute()
+	require.NoError(t, err)
+
+	// The limits are shared by all the copies of the rule.
+	require.True(t, throttleRule.AcquireThrottle(ctx))
+	qre = newTestQueryExecutor(ctx, tsv, query, 0)
+	_, err = qre.Execute()
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	assert.EqualError(t, err, "throttled by rule: throttle test_table")
+	throttleRule.ReleaseThrottle()
+
+	qre = newTestQueryExecutor(ctx, tsv, query, 0)
+	_, err = qre.Execute()
+	require.NoError(t, err)
+}
+
+func TestQueryExecutorRuleMaxExecutionTime(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table limit 1000"
+	db.AddQuery(query, &sqltypes.Result{Fields: getTestTableFields()})
+
+	timeoutRule := rules.NewQueryRule("limit test_table", "limit test_table", rules.QRMaxExecutionTime)
+	err := timeoutRule.SetMaxExecutionTime(time.Minute)
+	require.NoError(t, err)
+
+	rulesName := "ruleMaxExecutionTime"
+	qrs := rules.New()
+	qrs.Add(timeoutRule)
+
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	defer tsv.StopService()
+	tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	tsv.qe.queryRuleSources.RegisterSource(rulesName)
+	defer tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	err = tsv.qe.queryRuleSources.SetRules(rulesName, qrs)
+	require.NoError(t, err)
+
+	qre := newTestQueryExecutor(ctx, tsv, query, 0)
+	_, err = qre.Execute()
+	require.NoError(t, err)
+	deadline, ok := qre.ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 10*time.Second)
+	// The context of the query is cancelled once it is done.
+	assert.Error(t, qre.ctx.Err())
+}
+
+func TestQueryExecutorRuleRewrite(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table where name = 1 limit 1000"
+	rewrittenQuery := "select * from test_table force index (`index`) where name = 1 limit 1000"
+	expected := &sqltypes.Result{
+		Fields: getTestTableFields(),
+	}
+	// Only the rewritten query is expected by the database.
+	db.AddQuery(rewrittenQuery, expected)
+
+	rewriteRule := rules.NewQueryRule("force index", "force index", rules.QRRewrite)
+	err := rewriteRule.AddIndexHint("test_table", "force index (`index`)")
+	require.NoError(t, err)
+	err = rewriteRule.SetQueryCond("select.*")
+	require.NoError(t, err)
+
+	rulesName := "ruleRewrite"
+	qrs := rules.New()
+	qrs.Add(rewriteRule)
+
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	defer tsv.StopService()
+	tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	tsv.qe.queryRuleSources.RegisterSource(rulesName)
+	defer tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	err = tsv.qe.queryRuleSources.SetRules(rulesName, qrs)
+	require.NoError(t, err)
+
+	qre := newTestQueryExecutor(ctx, tsv, query, 0)
+	got, err := qre.Execute()
+	require.NoError(t, err)
+	assert.Equal(t, expected, got)
+	assert.Equal(t, rewrittenQuery, qre.query)
+	assert.Equal(t, rewrittenQuery, qre.plan.Original)
+}
+
 func TestReplaceSchemaName(t *testing.T) {
 	db := setUpQueryExecutorTest(t)
 	defer db.Close()
@@ -45,7 +45,7 @@ func (cached *Rule) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(256)
+		size += int64(288)
 	}
 	// field Description string
 	size += hack.RuntimeAllocSize(int64(len(cached.Description)))
@@ -79,6 +79,15 @@ func (cached *Rule) CachedSize(alloc bool) int64 {
 			size += elem.CachedSize(false)
 		}
 	}
+	// field throttle *vitess.io/vitess/go/vt/vttablet/tabletserver/rules.throttle
+	size += cached.throttle.CachedSize(true)
+	// field indexHints []vitess.io/vitess/go/vt/vttablet/tabletserver/rules.indexHint
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.indexHints)) * int64(56))
+		for _, elem := range cached.indexHints {
+			size += elem.CachedSize(false)
+		}
+	}
 	return size
 }
 func (cached *Rules) CachedSize(alloc bool) int64 {
@@ -112,6 +121,27 @@ func (cached *bvcre) CachedSize(alloc bool) int64 {
 	}
 	return size
 }
+func (cached *indexHint) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(64)
+	}
+	// field table string
+	size += hack.RuntimeAllocSize(int64(len(cached.table)))
+	// field hint string
+	size += hack.RuntimeAllocSize(int64(len(cached.hint)))
+	// field hints vitess.io/vitess/go/vt/sqlparser.IndexHints
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.hints)) * int64(8))
+		for _, elem := range cached.hints {
+			size += elem.CachedSize(true)
+		}
+	}
+	return size
+}
 func (cached *namedRegexp) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
@@ -128,3 +158,21 @@ func (cached *namedRegexp) CachedSize(alloc bool) int64 {
 	}
 	return size
 }
+func (cached *throttle) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(48)
+	}
+	// field slots *vitess.io/vitess/go/sync2.Semaphore
+	if cached.slots != nil {
+		size += hack.RuntimeAllocSize(int64(16))
+	}
+	// field limiter *golang.org/x/time/rate.Limiter
+	if cached.limiter != nil {
+		size += hack.RuntimeAllocSize(int64(80))
+	}
+	return size
+}
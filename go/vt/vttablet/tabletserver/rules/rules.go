@@ -23,11 +23,16 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
 
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 
 	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/sync2"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/planbuilder"
@@ -178,12 +183,27 @@ func (qrs *Rules) GetAction(
 	bindVars map[string]*querypb.BindVariable,
 	marginComments sqlparser.MarginComments,
 ) (action Action, cancelCtx context.Context, desc string) {
+	if qr := qrs.GetRule(ip, user, bindVars, marginComments); qr != nil {
+		return qr.act, qr.cancelCtx, qr.Description
+	}
+	return QRContinue, nil, ""
+}
+
+// GetRule runs the input against the rules engine and returns the first rule
+// that fires, or nil if no rule fires. The parameters of the action of the rule,
+// e.g. the limits of a THROTTLE action, can then be obtained from the rule.
+func (qrs *Rules) GetRule(
+	ip,
+	user string,
+	bindVars map[string]*querypb.BindVariable,
+	marginComments sqlparser.MarginComments,
+) *Rule {
 	for _, qr := range qrs.rules {
 		if act := qr.GetAction(ip, user, bindVars, marginComments); act != QRContinue {
-			return act, qr.cancelCtx, qr.Description
+			return qr
 		}
 	}
-	return QRContinue, nil, ""
+	return nil
 }
 
 //-----------------------------------------------
@@ -217,6 +237,16 @@ type Rule struct {
 	// Action to be performed on trigger
 	act Action
 
+	// Limits of the THROTTLE action. They are shared by the copies of the
+	// rule, so that they apply to all the plans the rule was filtered into.
+	throttle *throttle
+
+	// Timeout of the MAX_EXECUTION_TIME action.
+	maxExecutionTime time.Duration
+
+	// Index hints added by the REWRITE action, sorted by table name.
+	indexHints []indexHint
+
 	// a rule can be dynamically cancelled. This function determines whether it is cancelled
 	cancelCtx context.Context
 }
@@ -266,21 +296,26 @@ func (qr *Rule) Equal(other *Rule) bool {
 		reflect.DeepEqual(qr.plans, other.plans) &&
 		reflect.DeepEqual(qr.tableNames, other.tableNames) &&
 		reflect.DeepEqual(qr.bindVarConds, other.bindVarConds) &&
-		qr.act == other.act)
+		qr.act == other.act &&
+		qr.throttle.Equal(other.throttle) &&
+		qr.maxExecutionTime == other.maxExecutionTime &&
+		indexHintsEqual(qr.indexHints, other.indexHints))
 }
 
 // Copy performs a deep copy of a Rule.
 func (qr *Rule) Copy() (newqr *Rule) {
 	newqr = &Rule{
-		Description:     qr.Description,
-		Name:            qr.Name,
-		requestIP:       qr.requestIP,
-		user:            qr.user,
-		query:           qr.query,
-		leadingComment:  qr.leadingComment,
-		trailingComment: qr.trailingComment,
-		act:             qr.act,
-		cancelCtx:       qr.cancelCtx,
+		Description:      qr.Description,
+		Name:             qr.Name,
+		requestIP:        qr.requestIP,
+		user:             qr.user,
+		query:            qr.query,
+		leadingComment:   qr.leadingComment,
+		trailingComment:  qr.trailingComment,
+		act:              qr.act,
+		throttle:         qr.throttle,
+		maxExecutionTime: qr.maxExecutionTime,
+		cancelCtx:        qr.cancelCtx,
 	}
 	if qr.plans != nil {
 		newqr.plans = make([]planbuilder.PlanType, len(qr.plans))
@@ -294,6 +329,10 @@ func (qr *Rule) Copy() (newqr *Rule) {
 		newqr.bindVarConds = make([]BindVarCond, len(qr.bindVarConds))
 		copy(newqr.bindVarConds, qr.bindVarConds)
 	}
+	if qr.indexHints != nil {
+		newqr.indexHints = make([]indexHint, len(qr.indexHints))
+		copy(newqr.indexHints, qr.indexHints)
+	}
 	return newqr
 }
 
@@ -329,6 +368,27 @@ func (qr *Rule) MarshalJSON() ([]byte, error) {
 	if qr.act != QRContinue {
 		safeEncode(b, `,"Action":`, qr.act)
 	}
+	if qr.throttle != nil {
+		if qr.throttle.maxConcurrency != 0 {
+			safeEncode(b, `,"MaxConcurrency":`, qr.throttle.maxConcurrency)
+		}
+		if qr.throttle.maxQPS != 0 {
+			safeEncode(b, `,"MaxQPS":`, qr.throttle.maxQPS)
+		}
+		if qr.throttle.queueTimeout != 0 {
+			safeEncode(b, `,"QueueTimeout":`, qr.throttle.queueTimeout.String())
+		}
+	}
+	if qr.maxExecutionTime != 0 {
+		safeEncode(b, `,"MaxExecutionTime":`, qr.maxExecutionTime.String())
+	}
+	if qr.indexHints != nil {
+		hints := make(map[string]string, len(qr.indexHints))
+		for _, ih := range qr.indexHints {
+			hints[ih.table] = ih.hint
+		}
+		safeEncode(b, `,"IndexHints":`, hints)
+	}
 	_, _ = b.WriteString("}")
 	return b.Bytes(), nil
 }
@@ -384,6 +444,54 @@ func (qr *Rule) SetTrailingCommentCond(pattern string) (err error) {
 	return
 }
 
+// SetThrottle sets the limits of the THROTTLE action. Matching queries wait
+// until less than maxConcurrency of them are running, and until running them
+// doesn't exceed maxQPS queries per second. A limit of 0 means no limit. A
+// query that still has to wait after queueTimeout is rejected. A queueTimeout
+// of 0 means that queries wait until their context is done.
+func (qr *Rule) SetThrottle(maxConcurrency, maxQPS int, queueTimeout time.Duration) error {
+	if maxConcurrency < 0 || maxQPS < 0 || queueTimeout < 0 {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "negative throttle limit")
+	}
+	if maxConcurrency == 0 && maxQPS == 0 {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "MaxConcurrency or MaxQPS must be set to throttle queries")
+	}
+	qr.throttle = newThrottle(maxConcurrency, maxQPS, queueTimeout)
+	return nil
+}
+
+// SetMaxExecutionTime sets the timeout of the MAX_EXECUTION_TIME action.
+func (qr *Rule) SetMaxExecutionTime(timeout time.Duration) error {
+	if timeout <= 0 {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "MaxExecutionTime must be positive")
+	}
+	qr.maxExecutionTime = timeout
+	return nil
+}
+
+// AddIndexHint adds an index hint for a table to the REWRITE action, e.g.
+// "FORCE INDEX (idx)". The hint replaces the index hints of the table in
+// the queries that match the rule.
+func (qr *Rule) AddIndexHint(tableName, hint string) error {
+	stmt, err := sqlparser.Parse("select 1 from t " + hint)
+	if err != nil {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid index hint %s for table %s: %v", hint, tableName, err)
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || len(sel.From) != 1 {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid index hint %s for table %s", hint, tableName)
+	}
+	table, ok := sel.From[0].(*sqlparser.AliasedTableExpr)
+	if !ok || len(table.Hints) == 0 || !table.As.IsEmpty() || len(table.Partitions) != 0 {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid index hint %s for table %s", hint, tableName)
+	}
+	qr.indexHints = append(qr.indexHints, indexHint{table: tableName, hint: hint, hints: table.Hints})
+	sort.SliceStable(qr.indexHints, func(i, j int) bool {
+		return qr.indexHints[i].table < qr.indexHints[j].table
+	})
+	return nil
+}
+
 // makeExact forces a full string match for the regex instead of substring
 func makeExact(pattern string) string {
 	return fmt.Sprintf("^%s$", pattern)
@@ -507,6 +615,77 @@ func (qr *Rule) GetAction(
 	return qr.act
 }
 
+// Action returns the action of the rule. It returns QRContinue for a nil rule.
+func (qr *Rule) Action() Action {
+	if qr == nil {
+		return QRContinue
+	}
+	return qr.act
+}
+
+// CancelContext returns the context that cancels the rule, or nil if the rule
+// cannot be cancelled.
+func (qr *Rule) CancelContext() context.Context {
+	return qr.cancelCtx
+}
+
+// AcquireThrottle waits until a query can run within the limits of the
+// THROTTLE action. It returns false if the query has to be rejected, because
+// the queue timeout elapsed or the context is done. A successful call must be
+// followed by a call to ReleaseThrottle once the query is done.
+func (qr *Rule) AcquireThrottle(ctx context.Context) bool {
+	if qr.throttle == nil {
+		return true
+	}
+	return qr.throttle.acquire(ctx)
+}
+
+// ReleaseThrottle releases the slot acquired by AcquireThrottle.
+func (qr *Rule) ReleaseThrottle() {
+	if qr.throttle != nil {
+		qr.throttle.release()
+	}
+}
+
+// MaxExecutionTime returns the timeout of the MAX_EXECUTION_TIME action.
+func (qr *Rule) MaxExecutionTime() time.Duration {
+	return qr.maxExecutionTime
+}
+
+// RewriteQuery returns the query with the index hints of the REWRITE action.
+// The hints of a table replace the hints it has in the query. The query is
+// returned as it is if none of its tables has a hint.
+func (qr *Rule) RewriteQuery(query string) (string, error) {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return "", err
+	}
+	rewritten := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		table, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok {
+			return true, nil
+		}
+		name, err := table.TableName()
+		if err != nil {
+			// Derived tables are walked into.
+			return true, nil
+		}
+		for _, ih := range qr.indexHints {
+			if ih.table == name.Name.String() {
+				table.Hints = sqlparser.CloneIndexHints(ih.hints)
+				rewritten = true
+				break
+			}
+		}
+		return true, nil
+	}, stmt)
+	if !rewritten {
+		return query, nil
+	}
+	return sqlparser.String(stmt), nil
+}
+
 func reMatch(re *regexp.Regexp, val string) bool {
 	return re == nil || re.MatchString(val)
 }
@@ -563,6 +742,9 @@ const (
 	QRFail
 	QRFailRetry
 	QRBuffer
+	QRThrottle
+	QRMaxExecutionTime
+	QRRewrite
 )
 
 // MarshalJSON marshals to JSON.
@@ -576,12 +758,99 @@ func (act Action) MarshalJSON() ([]byte, error) {
 		str = "FAIL_RETRY"
 	case QRBuffer:
 		str = "BUFFER"
+	case QRThrottle:
+		str = "THROTTLE"
+	case QRMaxExecutionTime:
+		str = "MAX_EXECUTION_TIME"
+	case QRRewrite:
+		str = "REWRITE"
 	default:
 		str = "INVALID"
 	}
 	return json.Marshal(str)
 }
 
+// throttle limits the concurrency and the rate of the queries that match a
+// THROTTLE rule.
+type throttle struct {
+	maxConcurrency int
+	maxQPS         int
+	queueTimeout   time.Duration
+
+	slots   *sync2.Semaphore
+	limiter *rate.Limiter
+}
+
+func newThrottle(maxConcurrency, maxQPS int, queueTimeout time.Duration) *throttle {
+	t := &throttle{
+		maxConcurrency: maxConcurrency,
+		maxQPS:         maxQPS,
+		queueTimeout:   queueTimeout,
+	}
+	if maxConcurrency > 0 {
+		t.slots = sync2.NewSemaphore(maxConcurrency, 0)
+	}
+	if maxQPS > 0 {
+		// A burst of 1 spreads the queries evenly over each second.
+		t.limiter = rate.NewLimiter(rate.Limit(maxQPS), 1)
+	}
+	return t
+}
+
+// Equal returns true if other has the same limits as this throttle.
+func (t *throttle) Equal(other *throttle) bool {
+	if t == nil || other == nil {
+		return t == nil && other == nil
+	}
+	return t.maxConcurrency == other.maxConcurrency &&
+		t.maxQPS == other.maxQPS &&
+		t.queueTimeout == other.queueTimeout
+}
+
+func (t *throttle) acquire(ctx context.Context) bool {
+	if t.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.queueTimeout)
+		defer cancel()
+	}
+	if t.limiter != nil {
+		// Wait fails right away if the query cannot run before the
+		// context is done.
+		if err := t.limiter.Wait(ctx); err != nil {
+			return false
+		}
+	}
+	if t.slots != nil {
+		return t.slots.AcquireContext(ctx)
+	}
+	return true
+}
+
+func (t *throttle) release() {
+	if t.slots != nil {
+		t.slots.Release()
+	}
+}
+
+// indexHint is an index hint of a REWRITE action.
+type indexHint struct {
+	table string
+	hint  string
+	hints sqlparser.IndexHints
+}
+
+func indexHintsEqual(a, b []indexHint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].table != b[i].table || a[i].hint != b[i].hint {
+			return false
+		}
+	}
+	return true
+}
+
 // BindVarCond represents a bind var condition.
 type BindVarCond struct {
 	name       string
@@ -861,12 +1130,19 @@ func MapStrOperator(strop string) (op Operator, err error) {
 // BuildQueryRule builds a query rule from a ruleInfo.
 func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 	qr = NewQueryRule("", "", QRFail)
+	var (
+		maxConcurrency, maxQPS         int
+		queueTimeout, maxExecutionTime time.Duration
+		indexHints                     map[string]any
+	)
 	for k, v := range ruleInfo {
 		var sv string
 		var lv []any
+		var nv int
 		var ok bool
 		switch k {
-		case "Name", "Description", "RequestIP", "User", "Query", "Action", "LeadingComment", "TrailingComment":
+		case "Name", "Description", "RequestIP", "User", "Query", "Action", "LeadingComment", "TrailingComment",
+			"QueueTimeout", "MaxExecutionTime":
 			sv, ok = v.(string)
 			if !ok {
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want string for %s", k)
@@ -876,6 +1152,16 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 			if !ok {
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want list for %s", k)
 			}
+		case "MaxConcurrency", "MaxQPS":
+			nv, ok = getint(v)
+			if !ok {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want int for %s", k)
+			}
+		case "IndexHints":
+			indexHints, ok = v.(map[string]any)
+			if !ok {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want json object for %s", k)
+			}
 		default:
 			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unrecognized tag %s", k)
 		}
@@ -948,14 +1234,80 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 				qr.act = QRFailRetry
 			case "BUFFER":
 				qr.act = QRBuffer
+			case "THROTTLE":
+				qr.act = QRThrottle
+			case "MAX_EXECUTION_TIME":
+				qr.act = QRMaxExecutionTime
+			case "REWRITE":
+				qr.act = QRRewrite
 			default:
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid Action %s", sv)
 			}
+		case "MaxConcurrency":
+			maxConcurrency = nv
+		case "MaxQPS":
+			maxQPS = nv
+		case "QueueTimeout":
+			queueTimeout, err = time.ParseDuration(sv)
+			if err != nil {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid QueueTimeout %s: %v", sv, err)
+			}
+		case "MaxExecutionTime":
+			maxExecutionTime, err = time.ParseDuration(sv)
+			if err != nil {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid MaxExecutionTime %s: %v", sv, err)
+			}
+		}
+	}
+	// The parameters of the actions are validated once the action is known.
+	switch qr.act {
+	case QRThrottle:
+		if err := qr.SetThrottle(maxConcurrency, maxQPS, queueTimeout); err != nil {
+			return nil, err
+		}
+	case QRMaxExecutionTime:
+		if err := qr.SetMaxExecutionTime(maxExecutionTime); err != nil {
+			return nil, err
+		}
+	case QRRewrite:
+		if len(indexHints) == 0 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "IndexHints must be set for the REWRITE action")
+		}
+	}
+	if qr.act != QRThrottle && (maxConcurrency != 0 || maxQPS != 0 || queueTimeout != 0) {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "MaxConcurrency, MaxQPS and QueueTimeout can only be set for the THROTTLE action")
+	}
+	if qr.act != QRMaxExecutionTime && maxExecutionTime != 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "MaxExecutionTime can only be set for the MAX_EXECUTION_TIME action")
+	}
+	if qr.act != QRRewrite && len(indexHints) != 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "IndexHints can only be set for the REWRITE action")
+	}
+	for tableName, v := range indexHints {
+		hint, ok := v.(string)
+		if !ok {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want string for the index hint of %s", tableName)
+		}
+		if err := qr.AddIndexHint(tableName, hint); err != nil {
+			return nil, err
 		}
 	}
 	return qr, nil
 }
 
+// getint returns the value of a JSON number that is a whole number.
+func getint(v any) (int, bool) {
+	num, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	n, err := num.Int64()
+	if err != nil {
+		return 0, false
+	}
+	return int(n), true
+}
+
 func buildBindVarCondition(bvc any) (name string, onAbsent, onMismatch bool, op Operator, value any, err error) {
 	bvcinfo, ok := bvc.(map[string]any)
 	if !ok {
@@ -18,13 +18,16 @@ package rules
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"reflect"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/sqlparser"
@@ -731,6 +734,19 @@ var invalidjsons = []InvalidJSONCase{
 	{`[{"BindVarConds": [{"Name": "a", "OnAbsent": true, "OnMismatch": true, "Operator": "NOMATCH", "Value": "["}]}]`, "processing [: error parsing regexp: missing closing ]: `[$`"},
 	{`[{"Action": 1 }]`, "want string for Action"},
 	{`[{"Action": "foo" }]`, "invalid Action foo"},
+	{`[{"Action": "THROTTLE" }]`, "MaxConcurrency or MaxQPS must be set to throttle queries"},
+	{`[{"Action": "THROTTLE", "MaxConcurrency": "1" }]`, "want int for MaxConcurrency"},
+	{`[{"Action": "THROTTLE", "MaxQPS": 1.5 }]`, "want int for MaxQPS"},
+	{`[{"Action": "THROTTLE", "MaxQPS": -1 }]`, "negative throttle limit"},
+	{`[{"Action": "THROTTLE", "MaxQPS": 1, "QueueTimeout": "1" }]`, "invalid QueueTimeout 1: time: missing unit in duration \"1\""},
+	{`[{"Action": "FAIL", "MaxConcurrency": 1 }]`, "MaxConcurrency, MaxQPS and QueueTimeout can only be set for the THROTTLE action"},
+	{`[{"Action": "MAX_EXECUTION_TIME" }]`, "MaxExecutionTime must be positive"},
+	{`[{"Action": "FAIL", "MaxExecutionTime": "1s" }]`, "MaxExecutionTime can only be set for the MAX_EXECUTION_TIME action"},
+	{`[{"Action": "REWRITE" }]`, "IndexHints must be set for the REWRITE action"},
+	{`[{"Action": "REWRITE", "IndexHints": [] }]`, "want json object for IndexHints"},
+	{`[{"Action": "REWRITE", "IndexHints": {"a": 1} }]`, "want string for the index hint of a"},
+	{`[{"Action": "REWRITE", "IndexHints": {"a": "where 1"} }]`, "invalid index hint where 1 for table a"},
+	{`[{"Action": "FAIL", "IndexHints": {"a": "force index (b)"} }]`, "IndexHints can only be set for the REWRITE action"},
 }
 
 func TestInvalidJSON(t *testing.T) {
@@ -768,6 +784,90 @@ func TestBuildQueryRuleActionFail(t *testing.T) {
 	}
 }
 
+func TestImportActions(t *testing.T) {
+	var qrs = New()
+	jsondata := `[{
+		"Description": "desc1",
+		"Name": "name1",
+		"Action": "THROTTLE",
+		"MaxConcurrency": 10,
+		"MaxQPS": 100,
+		"QueueTimeout": "1s"
+	},{
+		"Description": "desc2",
+		"Name": "name2",
+		"Action": "MAX_EXECUTION_TIME",
+		"MaxExecutionTime": "1m30s"
+	},{
+		"Description": "desc3",
+		"Name": "name3",
+		"Action": "REWRITE",
+		"IndexHints": {"a": "FORCE INDEX (idx_a)", "b": "IGNORE INDEX (idx_b1, idx_b2)"}
+	}]`
+	err := qrs.UnmarshalJSON([]byte(jsondata))
+	require.NoError(t, err)
+	assert.Equal(t, compacted(jsondata), marshalled(qrs))
+	assert.True(t, qrs.Equal(qrs.Copy()))
+
+	other := New()
+	err = other.UnmarshalJSON([]byte(strings.Replace(jsondata, `"MaxQPS": 100`, `"MaxQPS": 200`, 1)))
+	require.NoError(t, err)
+	assert.False(t, qrs.Equal(other))
+}
+
+func TestThrottle(t *testing.T) {
+	qr := NewQueryRule("throttle", "throttle", QRThrottle)
+	err := qr.SetThrottle(1, 0, 10*time.Millisecond)
+	require.NoError(t, err)
+	// Copies share the limits of the rule.
+	qrCopy := qr.Copy()
+
+	ctx := context.Background()
+	require.True(t, qr.AcquireThrottle(ctx))
+	assert.False(t, qrCopy.AcquireThrottle(ctx))
+	qr.ReleaseThrottle()
+	require.True(t, qrCopy.AcquireThrottle(ctx))
+	qrCopy.ReleaseThrottle()
+
+	// Queries wait for their turn when the rate is exceeded.
+	err = qr.SetThrottle(0, 1, 0)
+	require.NoError(t, err)
+	require.True(t, qr.AcquireThrottle(ctx))
+	qr.ReleaseThrottle()
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	assert.False(t, qr.AcquireThrottle(ctx))
+
+	assert.True(t, NewQueryRule("fail", "fail", QRFail).AcquireThrottle(ctx))
+}
+
+func TestRewriteQuery(t *testing.T) {
+	qr := NewQueryRule("rewrite", "rewrite", QRRewrite)
+	require.NoError(t, qr.AddIndexHint("a", "FORCE INDEX (idx_a)"))
+	require.NoError(t, qr.AddIndexHint("b", "use index for order by (idx_b)"))
+
+	testcases := []struct {
+		in, out string
+	}{{
+		in:  "select * from a where id = :id",
+		out: "select * from a force index (idx_a) where id = :id",
+	}, {
+		in:  "select * from a as x ignore index (idx_x) join b on x.id = b.id",
+		out: "select * from a as x force index (idx_a) join b use index for order by (idx_b) on x.id = b.id",
+	}, {
+		in:  "select * from (select * from b) as t where id in (select id from a)",
+		out: "select * from (select * from b use index for order by (idx_b)) as t where id in (select id from a force index (idx_a))",
+	}, {
+		in:  "select * from c",
+		out: "select * from c",
+	}}
+	for _, tcase := range testcases {
+		got, err := qr.RewriteQuery(tcase.in)
+		require.NoError(t, err)
+		assert.Equal(t, tcase.out, got)
+	}
+}
+
 func TestBadAddBindVarCond(t *testing.T) {
 	qr1 := NewQueryRule("rule 1", "r1", QRFail)
 	err := qr1.AddBindVarCond("a", true, false, QRMatch, uint64(1))
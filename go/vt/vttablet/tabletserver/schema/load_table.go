@@ -19,6 +19,7 @@ package schema
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -32,6 +33,8 @@ import (
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/connpool"
 )
 
+var consumerGroupRegexp = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 // LoadTable creates a Table from the schema info in the database.
 func LoadTable(conn *connpool.DBConn, databaseName, tableName string, comment string) (*Table, error) {
 	ta := NewTable(tableName)
@@ -67,16 +70,7 @@ func fetchColumns(ta *Table, conn *connpool.DBConn, databaseName, sqlTableName s
 
 func loadMessageInfo(ta *Table, comment string) error {
 	ta.MessageInfo = &MessageInfo{}
-	// Extract keyvalues.
-	keyvals := make(map[string]string)
-	inputs := strings.Split(comment, ",")
-	for _, input := range inputs {
-		kv := strings.Split(input, "=")
-		if len(kv) != 2 {
-			continue
-		}
-		keyvals[kv[0]] = kv[1]
-	}
+	keyvals := parseMessageKeyvals(comment)
 
 	var err error
 	if ta.MessageInfo.AckWaitDuration, err = getDuration(keyvals, "vt_ack_wait"); err != nil {
@@ -104,23 +98,57 @@ func loadMessageInfo(ta *Table, comment string) error {
 
 	ta.MessageInfo.MaxBackoff, _ = getDuration(keyvals, "vt_max_backoff")
 
+	// 0 means messages are retried indefinitely
+	ta.MessageInfo.MaxAttempts, _ = getNum(keyvals, "vt_max_attempts")
+	ta.MessageInfo.DeadLetterTable = strings.TrimSpace(keyvals["vt_dead_letter_table"])
+	if ta.MessageInfo.DeadLetterTable != "" && ta.MessageInfo.MaxAttempts <= 0 {
+		return fmt.Errorf("vt_dead_letter_table requires vt_max_attempts to be set: %s", ta.Name.String())
+	}
+
+	ta.MessageInfo.PartitionKey = strings.TrimSpace(keyvals["vt_partition_key"])
+	ta.MessageInfo.ConsumerGroups = parseMessageCols(keyvals, "vt_consumer_groups")
+	for i, group := range ta.MessageInfo.ConsumerGroups {
+		group = strings.ToLower(strings.TrimSpace(group))
+		if !consumerGroupRegexp.MatchString(group) {
+			return fmt.Errorf("invalid consumer group '%s' for message table: %s", group, ta.Name.String())
+		}
+		ta.MessageInfo.ConsumerGroups[i] = group
+	}
+	if len(ta.MessageInfo.ConsumerGroups) > 0 && ta.MessageInfo.DeadLetterTable != "" {
+		return fmt.Errorf("vt_dead_letter_table cannot be used with vt_consumer_groups: %s", ta.Name.String())
+	}
+
 	// these columns are required for message manager to function properly, but only
 	// id is required to be streamed to subscribers
 	requiredCols := []string{
 		"id",
 		"priority",
-		"time_next",
-		"epoch",
-		"time_acked",
 	}
 
 	// by default, these columns are loaded for the message manager, but not sent to subscribers
 	// via stream * from msg_tbl
 	hiddenCols := map[string]struct{}{
-		"priority":   {},
-		"time_next":  {},
-		"epoch":      {},
-		"time_acked": {},
+		"priority": {},
+	}
+
+	// every consumer group, including the default one, keeps its own
+	// delivery state in its own set of columns
+	stateCols := []string{"time_next", "epoch", "time_acked"}
+	// messages that exceed their max attempts are acked in place with a reason,
+	// unless they're moved to a dead letter table
+	if ta.MessageInfo.MaxAttempts > 0 {
+		if ta.MessageInfo.DeadLetterTable == "" {
+			stateCols = append(stateCols, "dead_letter_reason")
+		} else {
+			hiddenCols["dead_letter_reason"] = struct{}{}
+		}
+	}
+	for _, group := range append([]string{""}, ta.MessageInfo.ConsumerGroups...) {
+		for _, col := range stateCols {
+			col = MessageGroupColumn(col, group)
+			requiredCols = append(requiredCols, col)
+			hiddenCols[col] = struct{}{}
+		}
 	}
 
 	// make sure required columns exist in the table schema
@@ -154,9 +182,62 @@ func loadMessageInfo(ta *Table, comment string) error {
 		ta.MessageInfo.Fields = getDefaultMessageFields(ta.Fields, hiddenCols)
 	}
 
+	// the partition key is read from the streamed columns
+	if ta.MessageInfo.PartitionKey != "" {
+		found := false
+		for _, field := range ta.MessageInfo.Fields {
+			if strings.EqualFold(field.Name, ta.MessageInfo.PartitionKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("partition key %s must be a streamed column of message table: %s", ta.MessageInfo.PartitionKey, ta.Name.String())
+		}
+	}
+
 	return nil
 }
 
+// MessageGroupColumn returns the name of the column that keeps
+// the delivery state col of a consumer group. The default group
+// uses the column itself.
+func MessageGroupColumn(col, group string) string {
+	if group == "" {
+		return col
+	}
+	return col + "_" + group
+}
+
+// parseMessageKeyvals extracts the key values from the comment of a message table.
+func parseMessageKeyvals(comment string) map[string]string {
+	keyvals := make(map[string]string)
+	inputs := strings.Split(comment, ",")
+	for _, input := range inputs {
+		kv := strings.Split(input, "=")
+		if len(kv) != 2 {
+			continue
+		}
+		keyvals[kv[0]] = kv[1]
+	}
+	return keyvals
+}
+
+// ParseMessageDeadLetterInfo returns the max attempts and dead letter table
+// from the comment of a message table. An empty deadLetterTable means that
+// dead-lettered messages are acked in place. It returns an error if the
+// comment is not that of a message table with max attempts.
+func ParseMessageDeadLetterInfo(comment string) (maxAttempts int, deadLetterTable string, err error) {
+	if !strings.Contains(comment, "vitess_message") {
+		return 0, "", fmt.Errorf("not a message table")
+	}
+	keyvals := parseMessageKeyvals(comment)
+	if maxAttempts, _ = getNum(keyvals, "vt_max_attempts"); maxAttempts <= 0 {
+		return 0, "", fmt.Errorf("vt_max_attempts not specified for message table")
+	}
+	return maxAttempts, strings.TrimSpace(keyvals["vt_dead_letter_table"]), nil
+}
+
 func getDuration(in map[string]string, key string) (time.Duration, error) {
 	sv := in[key]
 	if sv == "" {
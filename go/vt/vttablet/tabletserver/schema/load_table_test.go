@@ -134,6 +134,42 @@ func TestLoadTableMessage(t *testing.T) {
 	want.MessageInfo.MaxBackoff = 100 * time.Second
 	assert.Equal(t, want, table)
 
+	// Test loading max attempts with a dead letter table
+	table, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_min_backoff=10,vt_max_backoff=100,vt_max_attempts=5,vt_dead_letter_table=test_table_dlq", db)
+	require.NoError(t, err)
+	want.MessageInfo.MaxAttempts = 5
+	want.MessageInfo.DeadLetterTable = "test_table_dlq"
+	assert.Equal(t, want, table)
+	want.MessageInfo.MaxAttempts = 0
+	want.MessageInfo.DeadLetterTable = ""
+
+	// Test loading max attempts without a dead letter table, which requires a dead_letter_reason column
+	_, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_max_attempts=5", db)
+	require.EqualError(t, err, "dead_letter_reason missing from message table: test_table")
+
+	// Test loading a dead letter table without max attempts
+	_, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_dead_letter_table=test_table_dlq", db)
+	require.EqualError(t, err, "vt_dead_letter_table requires vt_max_attempts to be set: test_table")
+
+	// Test loading a partition key
+	table, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_min_backoff=10,vt_max_backoff=100,vt_partition_key=message", db)
+	require.NoError(t, err)
+	want.MessageInfo.PartitionKey = "message"
+	assert.Equal(t, want, table)
+	want.MessageInfo.PartitionKey = ""
+
+	// Test loading a partition key that is not streamed
+	_, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_partition_key=epoch", db)
+	require.EqualError(t, err, "partition key epoch must be a streamed column of message table: test_table")
+
+	// Test loading consumer groups without their columns
+	_, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_consumer_groups=billing", db)
+	require.EqualError(t, err, "time_next_billing missing from message table: test_table")
+
+	// Test loading an invalid consumer group
+	_, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_consumer_groups=bill-ing", db)
+	require.EqualError(t, err, "invalid consumer group 'bill-ing' for message table: test_table")
+
 	//
 	// multiple tests for vt_message_cols
 	//
@@ -202,6 +238,49 @@ func TestLoadTableMessage(t *testing.T) {
 	}
 }
 
+func TestLoadTableMessageConsumerGroups(t *testing.T) {
+	db := fakesqldb.New(t)
+	defer db.Close()
+	db.ClearQueryPattern()
+	fields := sqltypes.MakeTestFields(
+		"id|priority|time_next|epoch|time_acked|time_next_billing|epoch_billing|time_acked_billing|message",
+		"int64|int64|int64|int64|int64|int64|int64|int64|varbinary",
+	)
+	db.MockQueriesForTable("test_table", &sqltypes.Result{Fields: fields})
+
+	table, err := newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_consumer_groups=billing,vt_partition_key=message", db)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing"}, table.MessageInfo.ConsumerGroups)
+	assert.Equal(t, "message", table.MessageInfo.PartitionKey)
+	// The columns of the groups are not streamed.
+	var streamed []string
+	for _, field := range table.MessageInfo.Fields {
+		streamed = append(streamed, field.Name)
+	}
+	assert.Equal(t, []string{"id", "message"}, streamed)
+
+	_, err = newTestLoadTable("USER_TABLE", "vitess_message,vt_ack_wait=30,vt_purge_after=120,vt_batch_size=1,vt_cache_size=10,vt_poller_interval=30,vt_consumer_groups=billing,vt_max_attempts=3,vt_dead_letter_table=test_table_dlq", db)
+	require.EqualError(t, err, "vt_dead_letter_table cannot be used with vt_consumer_groups: test_table")
+}
+
+func TestParseMessageDeadLetterInfo(t *testing.T) {
+	maxAttempts, deadLetterTable, err := ParseMessageDeadLetterInfo("vitess_message,vt_ack_wait=30,vt_max_attempts=5,vt_dead_letter_table=msg_dlq")
+	require.NoError(t, err)
+	assert.Equal(t, 5, maxAttempts)
+	assert.Equal(t, "msg_dlq", deadLetterTable)
+
+	maxAttempts, deadLetterTable, err = ParseMessageDeadLetterInfo("vitess_message,vt_ack_wait=30,vt_max_attempts=5")
+	require.NoError(t, err)
+	assert.Equal(t, 5, maxAttempts)
+	assert.Equal(t, "", deadLetterTable)
+
+	_, _, err = ParseMessageDeadLetterInfo("vitess_message,vt_ack_wait=30")
+	assert.EqualError(t, err, "vt_max_attempts not specified for message table")
+
+	_, _, err = ParseMessageDeadLetterInfo("vitess_sequence")
+	assert.EqualError(t, err, "not a message table")
+}
+
 func newTestLoadTable(tableType string, comment string, db *fakesqldb.DB) (*Table, error) {
 	ctx := context.Background()
 	appParams := db.ConnParams()
@@ -104,6 +104,27 @@ type MessageInfo struct {
 	// MaxBackoff specifies the longest duration message manager
 	// should wait before rescheduling a message
 	MaxBackoff time.Duration
+
+	// MaxAttempts specifies the number of times a message is
+	// sent before it's dead-lettered. 0 means unlimited.
+	MaxAttempts int
+
+	// DeadLetterTable specifies the table dead-lettered messages
+	// are moved to. If empty, dead-lettered messages are instead
+	// acked in place with their dead_letter_reason set.
+	DeadLetterTable string
+
+	// PartitionKey specifies the column that partitions messages.
+	// Only one message per partition is sent at a time, and the
+	// next one is sent after it's acked. If empty, messages are
+	// sent independently of each other.
+	PartitionKey string
+
+	// ConsumerGroups specifies the named groups that each receive
+	// every message in addition to the default group. A group keeps
+	// its delivery state in the time_next_<group>, epoch_<group> and
+	// time_acked_<group> columns.
+	ConsumerGroups []string
 }
 
 // NewTable creates a new Table.
@@ -144,6 +144,7 @@ func registerTabletEnvFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&currentConfig.HotRowProtection.MaxQueueSize, "hot_row_protection_max_queue_size", defaultConfig.HotRowProtection.MaxQueueSize, "Maximum number of BeginExecute RPCs which will be queued for the same row (range).")
 	fs.IntVar(&currentConfig.HotRowProtection.MaxGlobalQueueSize, "hot_row_protection_max_global_queue_size", defaultConfig.HotRowProtection.MaxGlobalQueueSize, "Global queue limit across all row (ranges). Useful to prevent that the queue can grow unbounded.")
 	fs.IntVar(&currentConfig.HotRowProtection.MaxConcurrency, "hot_row_protection_concurrent_transactions", defaultConfig.HotRowProtection.MaxConcurrency, "Number of concurrent transactions let through to the txpool/MySQL for the same hot row. Should be > 1 to have enough 'ready' transactions in MySQL and benefit from a pipelining effect.")
+	fs.IntVar(&currentConfig.HotRowProtection.LockWaitTimeoutThreshold, "hot_row_protection_lock_wait_timeout_threshold", defaultConfig.HotRowProtection.LockWaitTimeoutThreshold, "Number of lock wait timeouts within a minute after which a row is detected as hot. Only one transaction at a time is let through for a detected hot row, until it has not timed out for 5 minutes. 0 disables the detection.")
 
 	fs.BoolVar(&currentConfig.EnableTransactionLimit, "enable_transaction_limit", defaultConfig.EnableTransactionLimit, "If true, limit on number of transactions open at the same time will be enforced for all users. User trying to open a new transaction after exhausting their limit will receive an error immediately, regardless of whether there are available slots or not.")
 	fs.BoolVar(&currentConfig.EnableTransactionLimitDryRun, "enable_transaction_limit_dry_run", defaultConfig.EnableTransactionLimitDryRun, "If true, limit on number of transactions open at the same time will be tracked for all users, but not enforced.")
@@ -346,6 +347,9 @@ type HotRowProtectionConfig struct {
 	MaxQueueSize       int    `json:"maxQueueSize,omitempty"`
 	MaxGlobalQueueSize int    `json:"maxGlobalQueueSize,omitempty"`
 	MaxConcurrency     int    `json:"maxConcurrency,omitempty"`
+	// LockWaitTimeoutThreshold is the number of lock wait timeouts within a
+	// minute after which a row is detected as hot. 0 disables the detection.
+	LockWaitTimeoutThreshold int `json:"lockWaitTimeoutThreshold,omitempty"`
 }
 
 // HealthcheckConfig contains the config for healthcheck.
@@ -450,6 +454,9 @@ func (c *TabletConfig) Verify() error {
 	if v := c.HotRowProtection.MaxConcurrency; v <= 0 {
 		return fmt.Errorf("-hot_row_protection_concurrent_transactions must be > 0 (specified value: %v)", v)
 	}
+	if v := c.HotRowProtection.LockWaitTimeoutThreshold; v < 0 {
+		return fmt.Errorf("-hot_row_protection_lock_wait_timeout_threshold must be >= 0 (specified value: %v)", v)
+	}
 	return nil
 }
 
@@ -526,6 +533,9 @@ var defaultConfig = TabletConfig{
 		// Allow more than 1 transaction for the same hot row through to have enough
 		// of them ready in MySQL and profit from a pipelining effect.
 		MaxConcurrency: 5,
+		// Rows which repeatedly time out waiting for their lock are detected
+		// as hot.
+		LockWaitTimeoutThreshold: 3,
 	},
 	Consolidator:                Enable,
 	ConsolidatorStreamTotalSize: 128 * 1024 * 1024,
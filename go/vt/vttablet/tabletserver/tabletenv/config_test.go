@@ -126,6 +126,7 @@ healthcheck:
   intervalSeconds: 20
   unhealthyThresholdSeconds: 7200
 hotRowProtection:
+  lockWaitTimeoutThreshold: 3
   maxConcurrency: 5
   maxGlobalQueueSize: 1000
   maxQueueSize: 20
@@ -184,7 +184,7 @@ func NewTabletServer(name string, config *tabletenv.TabletConfig, topoServer *to
 	tsv.messager = messager.NewEngine(tsv, tsv.se, tsv.vstreamer)
 
 	tsv.onlineDDLExecutor = onlineddl.NewExecutor(tsv, alias, topoServer, tsv.lagThrottler, tabletTypeFunc, tsv.onlineDDLExecutorToggleTableBuffer)
-	tsv.tableGC = gc.NewTableGC(tsv, topoServer, tabletTypeFunc, tsv.lagThrottler)
+	tsv.tableGC = gc.NewTableGC(tsv, topoServer, tabletTypeFunc, tsv.lagThrottler, tsv.vstreamer)
 
 	tsv.sm = &stateManager{
 		statelessql: tsv.statelessql,
@@ -783,6 +783,9 @@ func (tsv *TabletServer) execute(ctx context.Context, target *querypb.Target, sq
 			}
 			result, err = qre.Execute()
 			if err != nil {
+				if tsv.enableHotRowProtection && reservedID == 0 {
+					tsv.recordLockWaitTimeout(plan, query, bindVariables, err)
+				}
 				return err
 			}
 			result = result.StripMetadata(sqltypes.IncludeFieldsOrDefault(options))
@@ -983,7 +986,14 @@ func (tsv *TabletServer) computeTxSerializerKey(ctx context.Context, logStats *t
 		logComputeRowSerializerKey.Errorf("failed to get plan for query: %v err: %v", sql, err)
 		return "", ""
 	}
+	return txSerializerKey(plan, sql, bindVariables)
+}
 
+// txSerializerKey computes the key of computeTxSerializerKey for a plan.
+// If the DML pins every primary key column, the key is built from the primary
+// key values e.g. "table1 where id = 1 and sub_id = 2" irrespective of how the
+// WHERE clause was written. Otherwise, it falls back to the WHERE clause.
+func txSerializerKey(plan *TabletPlan, sql string, bindVariables map[string]*querypb.BindVariable) (string, string) {
 	switch plan.PlanID {
 	// Serialize only UPDATE or DELETE queries.
 	case planbuilder.PlanUpdate, planbuilder.PlanUpdateLimit,
@@ -998,7 +1008,11 @@ func (tsv *TabletServer) computeTxSerializerKey(ctx context.Context, logStats *t
 		return "", ""
 	}
 
-	where, err := plan.WhereClause.GenerateQuery(bindVariables, nil)
+	whereClause := plan.WhereClause
+	if plan.PKWhereClause != nil {
+		whereClause = plan.PKWhereClause
+	}
+	where, err := whereClause.GenerateQuery(bindVariables, nil)
 	if err != nil {
 		logComputeRowSerializerKey.Errorf("failed to substitute bind vars in where clause: %v query: %v bind vars: %v", err, sql, bindVariables)
 		return "", ""
@@ -1009,8 +1023,22 @@ func (tsv *TabletServer) computeTxSerializerKey(ctx context.Context, logStats *t
 	return key, tableName.String()
 }
 
-// MessageStream streams messages from the requested table.
-func (tsv *TabletServer) MessageStream(ctx context.Context, target *querypb.Target, name string, callback func(*sqltypes.Result) error) (err error) {
+// recordLockWaitTimeout reports to the hot row protection if the DML failed
+// because it timed out waiting for a row lock in MySQL. Rows which do so
+// repeatedly are detected as hot.
+func (tsv *TabletServer) recordLockWaitTimeout(plan *TabletPlan, sql string, bindVariables map[string]*querypb.BindVariable, err error) {
+	sqlErr, ok := mysql.NewSQLErrorFromError(err).(*mysql.SQLError)
+	if !ok || sqlErr.Number() != mysql.ERLockWaitTimeout {
+		return
+	}
+	if key, table := txSerializerKey(plan, sql, bindVariables); key != "" {
+		tsv.qe.txSerializer.RecordLockWaitTimeout(key, table)
+	}
+}
+
+// MessageStream streams messages from the requested table
+// for a consumer group. The default group is empty.
+func (tsv *TabletServer) MessageStream(ctx context.Context, target *querypb.Target, name, group string, callback func(*sqltypes.Result) error) (err error) {
 	return tsv.execRequest(
 		ctx, 0,
 		"MessageStream", "stream", nil,
@@ -1027,59 +1055,70 @@ func (tsv *TabletServer) MessageStream(ctx context.Context, target *querypb.Targ
 				logStats: logStats,
 				tsv:      tsv,
 			}
-			return qre.MessageStream(callback)
+			return qre.MessageStream(group, callback)
 		},
 	)
 }
 
-// MessageAck acks the list of messages for a given message table.
-// It returns the number of messages successfully acked.
-func (tsv *TabletServer) MessageAck(ctx context.Context, target *querypb.Target, name string, ids []*querypb.Value) (count int64, err error) {
+// MessageAck acks the list of messages for a given message table
+// and consumer group. It returns the number of messages successfully acked.
+func (tsv *TabletServer) MessageAck(ctx context.Context, target *querypb.Target, name, group string, ids []*querypb.Value) (count int64, err error) {
 	sids := make([]string, 0, len(ids))
 	for _, val := range ids {
 		sids = append(sids, sqltypes.ProtoToValue(val).ToString())
 	}
-	querygen, err := tsv.messager.GetGenerator(name)
+	querygen, err := tsv.messager.GetGenerator(name, group)
 	if err != nil {
 		return 0, err
 	}
-	count, err = tsv.execDML(ctx, target, func() (string, map[string]*querypb.BindVariable, error) {
+	count, err = tsv.execDML(ctx, target, func() ([]string, map[string]*querypb.BindVariable, error) {
 		query, bv := querygen.GenerateAckQuery(sids)
-		return query, bv, nil
+		return []string{query}, bv, nil
 	})
 	if err != nil {
 		return 0, err
 	}
-	messager.MessageStats.Add([]string{name, "Acked"}, count)
+	messager.MessageStats.Add([]string{messager.StatsLabel(name, group), "Acked"}, count)
 	return count, nil
 }
 
 // PostponeMessages postpones the list of messages for a given message table.
 // It returns the number of messages successfully postponed.
 func (tsv *TabletServer) PostponeMessages(ctx context.Context, target *querypb.Target, querygen messager.QueryGenerator, ids []string) (count int64, err error) {
-	return tsv.execDML(ctx, target, func() (string, map[string]*querypb.BindVariable, error) {
+	return tsv.execDML(ctx, target, func() ([]string, map[string]*querypb.BindVariable, error) {
 		query, bv := querygen.GeneratePostponeQuery(ids)
-		return query, bv, nil
+		return []string{query}, bv, nil
 	})
 }
 
 // PurgeMessages purges messages older than specified time in Unix Nanoseconds.
 // It purges at most 500 messages. It returns the number of messages successfully purged.
 func (tsv *TabletServer) PurgeMessages(ctx context.Context, target *querypb.Target, querygen messager.QueryGenerator, timeCutoff int64) (count int64, err error) {
-	return tsv.execDML(ctx, target, func() (string, map[string]*querypb.BindVariable, error) {
+	return tsv.execDML(ctx, target, func() ([]string, map[string]*querypb.BindVariable, error) {
 		query, bv := querygen.GeneratePurgeQuery(timeCutoff)
-		return query, bv, nil
+		return []string{query}, bv, nil
+	})
+}
+
+// DeadLetterMessages dead-letters the list of messages for a given message table.
+// It returns the number of messages successfully dead-lettered.
+func (tsv *TabletServer) DeadLetterMessages(ctx context.Context, target *querypb.Target, querygen messager.QueryGenerator, ids []string) (count int64, err error) {
+	return tsv.execDML(ctx, target, func() ([]string, map[string]*querypb.BindVariable, error) {
+		queries, bv := querygen.GenerateDeadLetterQueries(ids)
+		return queries, bv, nil
 	})
 }
 
-func (tsv *TabletServer) execDML(ctx context.Context, target *querypb.Target, queryGenerator func() (string, map[string]*querypb.BindVariable, error)) (count int64, err error) {
+// execDML executes the generated queries in a single transaction.
+// It returns the rows affected by the last query.
+func (tsv *TabletServer) execDML(ctx context.Context, target *querypb.Target, queryGenerator func() ([]string, map[string]*querypb.BindVariable, error)) (count int64, err error) {
 	if err = tsv.sm.StartRequest(ctx, target, false /* allowOnShutdown */); err != nil {
 		return 0, err
 	}
 	defer tsv.sm.EndRequest()
 	defer tsv.handlePanicAndSendLogStats("ack", nil, nil)
 
-	query, bv, err := queryGenerator()
+	queries, bv, err := queryGenerator()
 	if err != nil {
 		return 0, err
 	}
@@ -1095,9 +1134,11 @@ func (tsv *TabletServer) execDML(ctx context.Context, target *querypb.Target, qu
 			tsv.Rollback(ctx, target, state.TransactionID)
 		}
 	}()
-	qr, err := tsv.Execute(ctx, target, query, bv, state.TransactionID, 0, nil)
-	if err != nil {
-		return 0, err
+	qr := &sqltypes.Result{}
+	for _, query := range queries {
+		if qr, err = tsv.Execute(ctx, target, query, bv, state.TransactionID, 0, nil); err != nil {
+			return 0, err
+		}
 	}
 	if _, err = tsv.Commit(ctx, target, state.TransactionID); err != nil {
 		state.TransactionID = 0
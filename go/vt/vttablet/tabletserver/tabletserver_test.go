@@ -894,6 +894,63 @@ func TestDMLQueryWithoutWhereClause(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestComputeTxSerializerKey(t *testing.T) {
+	db, tsv := setupTabletServerTest(t, "")
+	defer tsv.StopService()
+	defer db.Close()
+
+	testcases := []struct {
+		sql      string
+		bindVars map[string]*querypb.BindVariable
+		key      string
+	}{{
+		sql: "update test_table set name_string = 'tx1' where pk = 1 and `name` = 2",
+		key: "test_table where pk = 1",
+	}, {
+		// Equivalent statements share the key of the primary key value.
+		sql:      "update test_table set name_string = 'tx2' where `name` = :name and pk = :pk",
+		bindVars: map[string]*querypb.BindVariable{"pk": sqltypes.Int64BindVariable(1), "name": sqltypes.Int64BindVariable(3)},
+		key:      "test_table where pk = 1",
+	}, {
+		sql: "delete from test_table where 1 = pk",
+		key: "test_table where pk = 1",
+	}, {
+		// Statements which do not pin the primary key fall back to the WHERE clause.
+		sql: "update test_table set name_string = 'tx3' where pk > 1",
+		key: "test_table where pk > 1",
+	}, {
+		sql: "delete from test_table",
+		key: "",
+	}}
+	for _, tcase := range testcases {
+		logStats := tabletenv.NewLogStats(ctx, "TestComputeTxSerializerKey")
+		key, _ := tsv.computeTxSerializerKey(ctx, logStats, tcase.sql, tcase.bindVars)
+		assert.Equal(t, tcase.key, key, tcase.sql)
+	}
+}
+
+func TestLockWaitTimeoutDetectsHotRow(t *testing.T) {
+	config := tabletenv.NewDefaultConfig()
+	config.HotRowProtection.Mode = tabletenv.Enable
+	config.HotRowProtection.LockWaitTimeoutThreshold = 1
+	db, tsv := setupTabletServerTestCustom(t, config, "")
+	defer tsv.StopService()
+	defer db.Close()
+
+	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
+	q := "update test_table set name_string = 'tx1' where pk = 1"
+	db.AddRejectedQuery(q+" limit 10001", mysql.NewSQLError(mysql.ERLockWaitTimeout, mysql.SSUnknownSQLState, "Lock wait timeout exceeded; try restarting transaction"))
+
+	_, _, err := tsv.BeginExecute(ctx, &target, nil, q, nil, 0, nil)
+	require.Error(t, err)
+
+	req, err := http.NewRequest("GET", "/debug/hotrows", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	tsv.qe.txSerializer.ServeHTTP(rr, req)
+	assert.Contains(t, rr.Body.String(), "1: test_table where pk = 1 (hot)")
+}
+
 func TestSerializeTransactionsSameRow_ConcurrentTransactions(t *testing.T) {
 	// This test runs three transaction in parallel:
 	// tx1 | tx2 | tx3
@@ -1233,7 +1290,7 @@ func TestMessageStream(t *testing.T) {
 	defer tsv.StopService()
 	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
 
-	err := tsv.MessageStream(ctx, &target, "nomsg", func(qr *sqltypes.Result) error {
+	err := tsv.MessageStream(ctx, &target, "nomsg", "", func(qr *sqltypes.Result) error {
 		return nil
 	})
 	wantErr := "table nomsg not found in schema"
@@ -1243,7 +1300,7 @@ func TestMessageStream(t *testing.T) {
 
 	// Check that the streaming mechanism works.
 	called := false
-	err = tsv.MessageStream(ctx, &target, "msg", func(qr *sqltypes.Result) error {
+	err = tsv.MessageStream(ctx, &target, "msg", "", func(qr *sqltypes.Result) error {
 		called = true
 		return io.EOF
 	})
@@ -1266,20 +1323,25 @@ func TestMessageAck(t *testing.T) {
 		Type:  sqltypes.VarChar,
 		Value: []byte("2"),
 	}}
-	_, err := tsv.MessageAck(ctx, &target, "nonmsg", ids)
+	_, err := tsv.MessageAck(ctx, &target, "nonmsg", "", ids)
 	want := "message table nonmsg not found in schema"
 	require.Error(t, err)
 	require.Contains(t, err.Error(), want)
 
-	_, err = tsv.MessageAck(ctx, &target, "msg", ids)
+	_, err = tsv.MessageAck(ctx, &target, "msg", "", ids)
 	want = "query: 'update msg set time_acked"
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), want)
 
 	db.AddQueryPattern("update msg set time_acked = .*", &sqltypes.Result{RowsAffected: 1})
-	count, err := tsv.MessageAck(ctx, &target, "msg", ids)
+	count, err := tsv.MessageAck(ctx, &target, "msg", "", ids)
 	require.NoError(t, err)
 	require.EqualValues(t, 1, count)
+
+	_, err = tsv.MessageAck(ctx, &target, "msg", "billing", ids)
+	want = "consumer group billing not found for message table msg"
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), want)
 }
 
 func TestRescheduleMessages(t *testing.T) {
@@ -1288,12 +1350,12 @@ func TestRescheduleMessages(t *testing.T) {
 	defer tsv.StopService()
 	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
 
-	_, err := tsv.messager.GetGenerator("nonmsg")
+	_, err := tsv.messager.GetGenerator("nonmsg", "")
 	want := "message table nonmsg not found in schema"
 	require.Error(t, err)
 	require.Contains(t, err.Error(), want)
 
-	gen, err := tsv.messager.GetGenerator("msg")
+	gen, err := tsv.messager.GetGenerator("msg", "")
 	require.NoError(t, err)
 
 	_, err = tsv.PostponeMessages(ctx, &target, gen, []string{"1", "2"})
@@ -1312,12 +1374,12 @@ func TestPurgeMessages(t *testing.T) {
 	defer tsv.StopService()
 	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
 
-	_, err := tsv.messager.GetGenerator("nonmsg")
+	_, err := tsv.messager.GetGenerator("nonmsg", "")
 	want := "message table nonmsg not found in schema"
 	require.Error(t, err)
 	require.Contains(t, err.Error(), want)
 
-	gen, err := tsv.messager.GetGenerator("msg")
+	gen, err := tsv.messager.GetGenerator("msg", "")
 	require.NoError(t, err)
 
 	_, err = tsv.PurgeMessages(ctx, &target, gen, 0)
@@ -21,6 +21,7 @@ package txserializer
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +29,7 @@ import (
 	"context"
 
 	"vitess.io/vitess/go/acl"
+	"vitess.io/vitess/go/cache"
 	"vitess.io/vitess/go/stats"
 	"vitess.io/vitess/go/streamlog"
 	"vitess.io/vitess/go/sync2"
@@ -38,8 +40,18 @@ import (
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
 
+const (
+	// lockWaitTimeoutWindow is the sliding window in which the lock wait
+	// timeouts of a row (range) are counted.
+	lockWaitTimeoutWindow = 1 * time.Minute
+	// hotRowDuration is how long a row (range) stays hot after it last
+	// reached the lock wait timeout threshold.
+	hotRowDuration = 5 * time.Minute
+)
+
 // TxSerializer serializes incoming transactions which target the same row range
-// i.e. table name and WHERE clause are identical.
+// i.e. table name and WHERE clause are identical. If a transaction pins the
+// primary key of a row, the WHERE clause only consists of the primary key values.
 // Additional transactions are queued and woken up in arrival order.
 //
 // This implementation has some parallels to the sync2.Consolidator class.
@@ -53,6 +65,10 @@ import (
 //     limited to avoid that queued transactions can consume the full capacity
 //     of vttablet. This is important if the capaciy is finite. For example, the
 //     number of RPCs in flight could be limited by the RPC subsystem.
+//
+// Additionally, rows which repeatedly time out waiting for their lock in MySQL
+// are detected as hot. Only one transaction at a time is let through for them,
+// until they have not timed out for a while.
 type TxSerializer struct {
 	env tabletenv.Env
 	*sync2.ConsolidatorCache
@@ -62,6 +78,12 @@ type TxSerializer struct {
 	maxQueueSize           int
 	maxGlobalQueueSize     int
 	concurrentTransactions int
+	// lockWaitTimeoutThreshold is the number of lock wait timeouts within
+	// lockWaitTimeoutWindow after which a row (range) is detected as hot.
+	// 0 disables the detection.
+	lockWaitTimeoutThreshold int
+	lockWaitTimeoutWindow    time.Duration
+	hotRowDuration           time.Duration
 
 	// waits stores how many times a transaction was queued because another
 	// transaction was already in flight for the same row (range).
@@ -81,6 +103,13 @@ type TxSerializer struct {
 	waits, waitsDryRun, queueExceeded, queueExceededDryRun *stats.CountersWithSingleLabel
 	globalQueueExceeded, globalQueueExceededDryRun         *stats.Counter
 
+	// lockWaitTimeouts counts per table how many transactions timed out
+	// waiting for a row lock in MySQL.
+	//
+	// hotRowsDetected counts per table how many rows (ranges) were detected
+	// as hot because they reached the lock wait timeout threshold.
+	lockWaitTimeouts, hotRowsDetected *stats.CountersWithSingleLabel
+
 	log                          *logutil.ThrottledLogger
 	logDryRun                    *logutil.ThrottledLogger
 	logWaitsDryRun               *logutil.ThrottledLogger
@@ -90,18 +119,24 @@ type TxSerializer struct {
 	mu         sync.Mutex
 	queues     map[string]*queue
 	globalSize int
+	// hotRows tracks the rows (ranges) which timed out waiting for their lock.
+	// Its values are *hotRow objects.
+	hotRows *cache.LRUCache
 }
 
 // New returns a TxSerializer object.
 func New(env tabletenv.Env) *TxSerializer {
 	config := env.Config()
 	return &TxSerializer{
-		env:                    env,
-		ConsolidatorCache:      sync2.NewConsolidatorCache(1000),
-		dryRun:                 config.HotRowProtection.Mode == tabletenv.Dryrun,
-		maxQueueSize:           config.HotRowProtection.MaxQueueSize,
-		maxGlobalQueueSize:     config.HotRowProtection.MaxGlobalQueueSize,
-		concurrentTransactions: config.HotRowProtection.MaxConcurrency,
+		env:                      env,
+		ConsolidatorCache:        sync2.NewConsolidatorCache(1000),
+		dryRun:                   config.HotRowProtection.Mode == tabletenv.Dryrun,
+		maxQueueSize:             config.HotRowProtection.MaxQueueSize,
+		maxGlobalQueueSize:       config.HotRowProtection.MaxGlobalQueueSize,
+		concurrentTransactions:   config.HotRowProtection.MaxConcurrency,
+		lockWaitTimeoutThreshold: config.HotRowProtection.LockWaitTimeoutThreshold,
+		lockWaitTimeoutWindow:    lockWaitTimeoutWindow,
+		hotRowDuration:           hotRowDuration,
 		waits: env.Exporter().NewCountersWithSingleLabel(
 			"TxSerializerWaits",
 			"Number of times a transaction was queued because another transaction was already in flight for the same row range",
@@ -124,12 +159,23 @@ func New(env tabletenv.Env) *TxSerializer {
 		globalQueueExceededDryRun: env.Exporter().NewCounter(
 			"TxSerializerGlobalQueueExceededDryRun",
 			"Dry-run stats for TxSerializerGlobalQueueExceeded"),
+		lockWaitTimeouts: env.Exporter().NewCountersWithSingleLabel(
+			"TxSerializerLockWaitTimeouts",
+			"Number of transactions that timed out waiting for a row lock in MySQL",
+			"table_name"),
+		hotRowsDetected: env.Exporter().NewCountersWithSingleLabel(
+			"TxSerializerHotRowsDetected",
+			"Number of rows that were detected as hot because they reached the lock wait timeout threshold",
+			"table_name"),
 		log:                          logutil.NewThrottledLogger("HotRowProtection", 5*time.Second),
 		logDryRun:                    logutil.NewThrottledLogger("HotRowProtection DryRun", 5*time.Second),
 		logWaitsDryRun:               logutil.NewThrottledLogger("HotRowProtection Waits DryRun", 5*time.Second),
 		logQueueExceededDryRun:       logutil.NewThrottledLogger("HotRowProtection QueueExceeded DryRun", 5*time.Second),
 		logGlobalQueueExceededDryRun: logutil.NewThrottledLogger("HotRowProtection GlobalQueueExceeded DryRun", 5*time.Second),
 		queues:                       make(map[string]*queue),
+		hotRows: cache.NewLRUCache(1000, func(_ any) int64 {
+			return 1
+		}),
 	}
 
 }
@@ -206,7 +252,12 @@ func (txs *TxSerializer) lockLocked(ctx context.Context, key, table string) (boo
 		// first time.
 
 		// As an optimization, we deferred the creation of the channel until now.
-		q.availableSlots = make(chan struct{}, txs.concurrentTransactions)
+		// Rows detected as hot let only one transaction through at a time.
+		concurrentTransactions := txs.concurrentTransactions
+		if txs.isHotLocked(key) {
+			concurrentTransactions = 1
+		}
+		q.availableSlots = make(chan struct{}, concurrentTransactions)
 		q.availableSlots <- struct{}{}
 
 		// Include first transaction in the count at /debug/hotrows. (It was not
@@ -310,6 +361,58 @@ func (txs *TxSerializer) unlockLocked(key string, returnSlot bool) {
 	<-q.availableSlots
 }
 
+// RecordLockWaitTimeout records that a transaction for the row (range) "key"
+// timed out waiting for its lock in MySQL. Once the lock wait timeouts of a
+// row reach the threshold within lockWaitTimeoutWindow, the row is detected as
+// hot for hotRowDuration.
+func (txs *TxSerializer) RecordLockWaitTimeout(key, table string) {
+	txs.lockWaitTimeouts.Add(table, 1)
+	if txs.lockWaitTimeoutThreshold == 0 {
+		return
+	}
+
+	txs.mu.Lock()
+	defer txs.mu.Unlock()
+
+	now := time.Now()
+	v, ok := txs.hotRows.Get(key)
+	if !ok {
+		v = &hotRow{}
+		txs.hotRows.Set(key, v)
+	}
+	row := v.(*hotRow)
+	row.expireLockWaitTimeouts(now.Add(-txs.lockWaitTimeoutWindow))
+	row.lockWaitTimeouts = append(row.lockWaitTimeouts, now)
+	if len(row.lockWaitTimeouts) < txs.lockWaitTimeoutThreshold {
+		return
+	}
+	// Only the most recent timeouts are needed to check the threshold.
+	row.lockWaitTimeouts = row.lockWaitTimeouts[len(row.lockWaitTimeouts)-txs.lockWaitTimeoutThreshold:]
+
+	wasHot := now.Before(row.hotUntil)
+	row.hotUntil = now.Add(txs.hotRowDuration)
+	if wasHot {
+		return
+	}
+	txs.hotRowsDetected.Add(table, 1)
+	logKey := key
+	if txs.env.Config().SanitizeLogMessages {
+		logKey = txs.sanitizeKey(key)
+	}
+	txs.log.Warningf("Detected hot row (range) '%v' after %v lock wait timeouts within %v. Only one transaction at a time will be let through for it for the next %v.",
+		logKey, len(row.lockWaitTimeouts), txs.lockWaitTimeoutWindow, txs.hotRowDuration)
+}
+
+// isHotLocked returns true if the row (range) "key" is currently detected as hot.
+// The method has the suffix "Locked" to clarify that "txs.mu" must be locked.
+func (txs *TxSerializer) isHotLocked(key string) bool {
+	if txs.lockWaitTimeoutThreshold == 0 {
+		return false
+	}
+	v, ok := txs.hotRows.Get(key)
+	return ok && time.Now().Before(v.(*hotRow).hotUntil)
+}
+
 // Pending returns the number of queued transactions (including the ones which
 // are currently in flight.)
 func (txs *TxSerializer) Pending(key string) int {
@@ -324,6 +427,8 @@ func (txs *TxSerializer) Pending(key string) int {
 }
 
 // ServeHTTP lists the most recent, cached queries and their count.
+// Additionally, it lists the rows (ranges) which are currently queued and the
+// rows which timed out waiting for their lock.
 func (txs *TxSerializer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
 	if streamlog.GetRedactDebugUIQueries() {
 		response.Write([]byte(`
@@ -346,11 +451,53 @@ func (txs *TxSerializer) ServeHTTP(response http.ResponseWriter, request *http.R
 	response.Header().Set("Content-Type", "text/plain")
 	if items == nil {
 		response.Write([]byte("empty\n"))
-		return
+	} else {
+		response.Write([]byte(fmt.Sprintf("Length: %d\n", len(items))))
+		for _, v := range items {
+			response.Write([]byte(fmt.Sprintf("%v: %s\n", v.Count, v.Query)))
+		}
 	}
-	response.Write([]byte(fmt.Sprintf("Length: %d\n", len(items))))
-	for _, v := range items {
-		response.Write([]byte(fmt.Sprintf("%v: %s\n", v.Count, v.Query)))
+
+	txs.mu.Lock()
+	defer txs.mu.Unlock()
+
+	var queued []string
+	for key, q := range txs.queues {
+		// Queues without slots never had a concurrent transaction.
+		if q.availableSlots != nil {
+			queued = append(queued, key)
+		}
+	}
+	if len(queued) != 0 {
+		sort.Strings(queued)
+		response.Write([]byte(fmt.Sprintf("Queues: %d\n", len(queued))))
+		for _, key := range queued {
+			q := txs.queues[key]
+			response.Write([]byte(fmt.Sprintf("%v (max: %v, total: %v): %s\n", q.size, q.max, q.count, key)))
+		}
+	}
+
+	// Rows which neither timed out recently nor are hot anymore are skipped.
+	now := time.Now()
+	var lines []string
+	for _, item := range txs.hotRows.Items() {
+		row := item.Value.(*hotRow)
+		row.expireLockWaitTimeouts(now.Add(-txs.lockWaitTimeoutWindow))
+		hot := now.Before(row.hotUntil)
+		if len(row.lockWaitTimeouts) == 0 && !hot {
+			continue
+		}
+		status := ""
+		if hot {
+			status = " (hot)"
+		}
+		lines = append(lines, fmt.Sprintf("%v: %s%s\n", len(row.lockWaitTimeouts), item.Key, status))
+	}
+	if len(lines) != 0 {
+		response.Write([]byte(fmt.Sprintf("Lock wait timeouts (last %v): %d\n", txs.lockWaitTimeoutWindow, len(lines))))
+		for _, line := range lines {
+			response.Write([]byte(line))
+		}
 	}
 }
 
@@ -383,6 +530,26 @@ type queue struct {
 	availableSlots chan struct{}
 }
 
+// hotRow tracks a row (range) which timed out waiting for its lock in MySQL.
+type hotRow struct {
+	// NOTE: The following fields are guarded by TxSerializer.mu.
+	// lockWaitTimeouts are the times of the most recent lock wait timeouts of
+	// the row (range) within lockWaitTimeoutWindow, oldest first.
+	lockWaitTimeouts []time.Time
+	// hotUntil is the time until which the row (range) is hot. It's zero if
+	// the row never reached the lock wait timeout threshold.
+	hotUntil time.Time
+}
+
+// expireLockWaitTimeouts forgets the lock wait timeouts before "since".
+func (row *hotRow) expireLockWaitTimeouts(since time.Time) {
+	i := 0
+	for i < len(row.lockWaitTimeouts) && row.lockWaitTimeouts[i].Before(since) {
+		i++
+	}
+	row.lockWaitTimeouts = row.lockWaitTimeouts[i:]
+}
+
 func newQueueForFirstTransaction(concurrentTransactions int) *queue {
 	return &queue{
 		size:  1,
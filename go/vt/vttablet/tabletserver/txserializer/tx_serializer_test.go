@@ -41,6 +41,8 @@ func resetVariables(txs *TxSerializer) {
 	txs.queueExceededDryRun.ResetAll()
 	txs.globalQueueExceeded.Reset()
 	txs.globalQueueExceededDryRun.Reset()
+	txs.lockWaitTimeouts.ResetAll()
+	txs.hotRowsDetected.ResetAll()
 }
 
 func TestTxSerializer_NoHotRow(t *testing.T) {
@@ -266,6 +268,170 @@ func TestTxSerializer_ConcurrentTransactions(t *testing.T) {
 	}
 }
 
+// TestTxSerializer_HotRowDetection verifies that a row which reached the lock
+// wait timeout threshold lets only one transaction through at a time.
+func TestTxSerializer_HotRowDetection(t *testing.T) {
+	config := tabletenv.NewDefaultConfig()
+	config.HotRowProtection.MaxQueueSize = 3
+	config.HotRowProtection.MaxGlobalQueueSize = 3
+	config.HotRowProtection.MaxConcurrency = 2
+	config.HotRowProtection.LockWaitTimeoutThreshold = 2
+	txs := New(tabletenv.NewEnv(config, "TxSerializerTest"))
+	resetVariables(txs)
+
+	txs.RecordLockWaitTimeout("t1 where1", "t1")
+	if got, want := txs.hotRowsDetected.Counts()["t1"], int64(0); got != want {
+		t.Errorf("row must not be hot before the threshold: got = %v, want = %v", got, want)
+	}
+	txs.RecordLockWaitTimeout("t1 where1", "t1")
+	if got, want := txs.lockWaitTimeouts.Counts()["t1"], int64(2); got != want {
+		t.Errorf("variable not incremented: got = %v, want = %v", got, want)
+	}
+	if got, want := txs.hotRowsDetected.Counts()["t1"], int64(1); got != want {
+		t.Errorf("variable not incremented: got = %v, want = %v", got, want)
+	}
+
+	// tx1.
+	done1, waited1, err1 := txs.Wait(context.Background(), "t1 where1", "t1")
+	if err1 != nil {
+		t.Error(err1)
+	}
+	if waited1 {
+		t.Errorf("tx1 must never wait: %v", waited1)
+	}
+
+	// tx2 (gets queued and must wait although MaxConcurrency is 2).
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		done2, waited2, err2 := txs.Wait(context.Background(), "t1 where1", "t1")
+		if err2 != nil {
+			t.Error(err2)
+		}
+		if !waited2 {
+			t.Errorf("tx2 must wait: %v", waited2)
+		}
+
+		done2()
+	}()
+	// Wait until tx2 is queued before we check /debug/hotrows.
+	if err := waitForPending(txs, "t1 where1", 2); err != nil {
+		t.Error(err)
+	}
+
+	req, err := http.NewRequest("GET", "/path-is-ignored-in-test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	txs.ServeHTTP(rr, req)
+	want := `Length: 1
+2: t1 where1
+Queues: 1
+2 (max: 2, total: 2): t1 where1
+Lock wait timeouts (last 1m0s): 1
+2: t1 where1 (hot)
+`
+	if got := rr.Body.String(); got != want {
+		t.Errorf("wrong content: got = \n%v\n want = \n%v", got, want)
+	}
+
+	done1()
+	// tx2 must have been unblocked.
+	wg.Wait()
+
+	if got, want := txs.waits.Counts()["t1"], int64(1); got != want {
+		t.Errorf("variable not incremented: got = %v, want = %v", got, want)
+	}
+}
+
+// TestTxSerializer_HotRowDetectionWindow verifies that only the lock wait
+// timeouts within the window are counted, and that hot rows expire.
+func TestTxSerializer_HotRowDetectionWindow(t *testing.T) {
+	config := tabletenv.NewDefaultConfig()
+	config.HotRowProtection.LockWaitTimeoutThreshold = 2
+	txs := New(tabletenv.NewEnv(config, "TxSerializerTest"))
+	resetVariables(txs)
+
+	// ago moves the lock wait timeouts and the hot status of a row back in time.
+	ago := func(key string, d time.Duration) {
+		txs.mu.Lock()
+		defer txs.mu.Unlock()
+		v, ok := txs.hotRows.Get(key)
+		if !ok {
+			t.Fatalf("row %v not tracked", key)
+		}
+		row := v.(*hotRow)
+		for i := range row.lockWaitTimeouts {
+			row.lockWaitTimeouts[i] = row.lockWaitTimeouts[i].Add(-d)
+		}
+		if !row.hotUntil.IsZero() {
+			row.hotUntil = row.hotUntil.Add(-d)
+		}
+	}
+	isHot := func(key string) bool {
+		txs.mu.Lock()
+		defer txs.mu.Unlock()
+		return txs.isHotLocked(key)
+	}
+
+	// Timeouts which are further apart than the window don't make a row hot.
+	txs.RecordLockWaitTimeout("t1 where1", "t1")
+	ago("t1 where1", lockWaitTimeoutWindow+time.Second)
+	txs.RecordLockWaitTimeout("t1 where1", "t1")
+	if isHot("t1 where1") {
+		t.Error("row must not be hot if its timeouts are not within the window")
+	}
+	if got, want := txs.hotRowsDetected.Counts()["t1"], int64(0); got != want {
+		t.Errorf("variable must not be incremented: got = %v, want = %v", got, want)
+	}
+
+	txs.RecordLockWaitTimeout("t1 where1", "t1")
+	if !isHot("t1 where1") {
+		t.Error("row must be hot after two timeouts within the window")
+	}
+	if got, want := txs.hotRowsDetected.Counts()["t1"], int64(1); got != want {
+		t.Errorf("variable not incremented: got = %v, want = %v", got, want)
+	}
+	// Further timeouts keep the row hot, but don't count as a new detection.
+	txs.RecordLockWaitTimeout("t1 where1", "t1")
+	if got, want := txs.hotRowsDetected.Counts()["t1"], int64(1); got != want {
+		t.Errorf("variable must not be incremented: got = %v, want = %v", got, want)
+	}
+
+	// The hot status expires if the row does not time out anymore.
+	ago("t1 where1", hotRowDuration-time.Second)
+	if !isHot("t1 where1") {
+		t.Error("row must still be hot")
+	}
+	ago("t1 where1", 2*time.Second)
+	if isHot("t1 where1") {
+		t.Error("row must not be hot anymore")
+	}
+
+	req, err := http.NewRequest("GET", "/path-is-ignored-in-test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	txs.ServeHTTP(rr, req)
+	if got, want := rr.Body.String(), "Length: 0\n"; got != want {
+		t.Errorf("expired rows must not be listed: got = \n%v\n want = \n%v", got, want)
+	}
+
+	// The row can be detected as hot again.
+	txs.RecordLockWaitTimeout("t1 where1", "t1")
+	txs.RecordLockWaitTimeout("t1 where1", "t1")
+	if !isHot("t1 where1") {
+		t.Error("row must be hot again")
+	}
+	if got, want := txs.hotRowsDetected.Counts()["t1"], int64(2); got != want {
+		t.Errorf("variable not incremented: got = %v, want = %v", got, want)
+	}
+}
+
 func waitForPending(txs *TxSerializer, key string, i int) error {
 	start := time.Now()
 	for {
@@ -81,6 +81,8 @@ const (
 	GreaterThanEqual
 	// NotEqual is used to filter a comparable column if != specific value
 	NotEqual
+	// In is used to filter a tuple of columns on a list of values
+	In
 )
 
 // Filter contains opcodes for filtering.
@@ -97,6 +99,29 @@ type Filter struct {
 	Vindex        vindexes.Vindex
 	VindexColumns []int
 	KeyRange      *topodatapb.KeyRange
+
+	// Parameters for In.
+	// InColumns contains the column numbers of the table, and InValues
+	// the tuples of values to match, keyed by inKey. Values are matched
+	// by their binary representation.
+	InColumns []int
+	InValues  map[string][]sqltypes.Value
+}
+
+// inKey returns the key of a tuple of values in the InValues of a Filter.
+func inKey(values []sqltypes.Value) string {
+	var key strings.Builder
+	for _, value := range values {
+		if value.IsNull() {
+			key.WriteString("-;")
+			continue
+		}
+		raw := value.Raw()
+		key.WriteString(strconv.Itoa(len(raw)))
+		key.WriteByte(':')
+		key.Write(raw)
+	}
+	return key.String()
 }
 
 // ColExpr represents a column expression.
@@ -226,6 +251,14 @@ func (plan *Plan) filter(values, result []sqltypes.Value, charsets []collations.
 			if !key.KeyRangeContains(filter.KeyRange, ksid) {
 				return false, nil
 			}
+		case In:
+			tuple := make([]sqltypes.Value, len(filter.InColumns))
+			for i, col := range filter.InColumns {
+				tuple[i] = values[col]
+			}
+			if _, ok := filter.InValues[inKey(tuple)]; !ok {
+				return false, nil
+			}
 		default:
 			match, err := compare(filter.Opcode, values[filter.ColNum], filter.Value, charsets[filter.ColNum])
 			if err != nil {
@@ -508,6 +541,12 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 	for _, expr := range exprs {
 		switch expr := expr.(type) {
 		case *sqlparser.ComparisonExpr:
+			if expr.Operator == sqlparser.InOp {
+				if err := plan.analyzeIn(expr); err != nil {
+					return err
+				}
+				continue
+			}
 			opcode, err := getOpcode(expr)
 			if err != nil {
 				return err
@@ -523,27 +562,14 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 			if err != nil {
 				return err
 			}
-			val, ok := expr.Right.(*sqlparser.Literal)
-			if !ok {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
-			}
-			//StrVal is varbinary, we do not support varchar since we would have to implement all collation types
-			if val.Type != sqlparser.IntVal && val.Type != sqlparser.StrVal {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
-			}
-			pv, err := evalengine.Translate(val, semantics.EmptySemTable())
+			val, err := filterValue(expr.Right)
 			if err != nil {
-				return err
-			}
-			env := evalengine.EmptyExpressionEnv()
-			resolved, err := env.Evaluate(pv)
-			if err != nil {
-				return err
+				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
 			}
 			plan.Filters = append(plan.Filters, Filter{
 				Opcode: opcode,
 				ColNum: colnum,
-				Value:  resolved.Value(),
+				Value:  val,
 			})
 		case *sqlparser.FuncExpr:
 			if !expr.Name.EqualString("in_keyrange") {
@@ -559,6 +585,83 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 	return nil
 }
 
+// filterValue returns the value of a literal in a where clause.
+func filterValue(expr sqlparser.Expr) (sqltypes.Value, error) {
+	val, ok := expr.(*sqlparser.Literal)
+	if !ok {
+		return sqltypes.NULL, fmt.Errorf("unexpected: %v", sqlparser.String(expr))
+	}
+	//StrVal is varbinary, we do not support varchar since we would have to implement all collation types
+	if val.Type != sqlparser.IntVal && val.Type != sqlparser.StrVal {
+		return sqltypes.NULL, fmt.Errorf("unexpected: %v", sqlparser.String(expr))
+	}
+	pv, err := evalengine.Translate(val, semantics.EmptySemTable())
+	if err != nil {
+		return sqltypes.NULL, err
+	}
+	env := evalengine.EmptyExpressionEnv()
+	resolved, err := env.Evaluate(pv)
+	if err != nil {
+		return sqltypes.NULL, err
+	}
+	return resolved.Value(), nil
+}
+
+// analyzeIn adds an In filter for constructs like "id in (1, 2)"
+// or "(id, val) in ((1, 'a'), (2, 'b'))".
+func (plan *Plan) analyzeIn(expr *sqlparser.ComparisonExpr) error {
+	var colNames []sqlparser.Expr
+	switch left := expr.Left.(type) {
+	case *sqlparser.ColName:
+		colNames = []sqlparser.Expr{left}
+	case sqlparser.ValTuple:
+		colNames = left
+	default:
+		return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
+	}
+	filter := Filter{
+		Opcode:   In,
+		InValues: make(map[string][]sqltypes.Value),
+	}
+	for _, colName := range colNames {
+		qualifiedName, ok := colName.(*sqlparser.ColName)
+		if !ok {
+			return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
+		}
+		if !qualifiedName.Qualifier.IsEmpty() {
+			return fmt.Errorf("unsupported qualifier for column: %v", sqlparser.String(qualifiedName))
+		}
+		colnum, err := findColumn(plan.Table, qualifiedName.Name)
+		if err != nil {
+			return err
+		}
+		filter.InColumns = append(filter.InColumns, colnum)
+	}
+	tuples, ok := expr.Right.(sqlparser.ValTuple)
+	if !ok {
+		return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
+	}
+	for _, tuple := range tuples {
+		exprs := sqlparser.ValTuple{tuple}
+		if len(filter.InColumns) > 1 {
+			if exprs, ok = tuple.(sqlparser.ValTuple); !ok || len(exprs) != len(filter.InColumns) {
+				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
+			}
+		}
+		values := make([]sqltypes.Value, len(exprs))
+		for i, expr := range exprs {
+			val, err := filterValue(expr)
+			if err != nil {
+				return err
+			}
+			values[i] = val
+		}
+		filter.InValues[inKey(values)] = values
+	}
+	plan.Filters = append(plan.Filters, filter)
+	return nil
+}
+
 // splitAndExpression breaks up the Expr into AND-separated conditions
 // and appends them to filters, which can be shuffled and recombined
 // as needed.
@@ -644,6 +644,32 @@ func TestPlanBuilderFilterComparison(t *testing.T) {
 			{Opcode: Equal, ColNum: 0, Value: sqltypes.NewInt64(2)},
 			{Opcode: NotEqual, ColNum: 1, Value: sqltypes.NewVarChar("xyz")},
 		},
+	}, {
+		name:     "in",
+		inFilter: "select * from t1 where id in (1, 2)",
+		outFilters: []Filter{{
+			Opcode:    In,
+			InColumns: []int{0},
+			InValues: map[string][]sqltypes.Value{
+				"1:1": {sqltypes.NewInt64(1)},
+				"1:2": {sqltypes.NewInt64(2)},
+			},
+		}},
+	}, {
+		name:     "in-tuple",
+		inFilter: "select * from t1 where (id, val) in ((1, 'abc'), (2, 'xyz')) and id > 0",
+		outFilters: []Filter{{
+			Opcode:    In,
+			InColumns: []int{0, 1},
+			InValues: map[string][]sqltypes.Value{
+				"1:13:abc": {sqltypes.NewInt64(1), sqltypes.NewVarChar("abc")},
+				"1:23:xyz": {sqltypes.NewInt64(2), sqltypes.NewVarChar("xyz")},
+			},
+		}, {Opcode: GreaterThan, ColNum: 0, Value: sqltypes.NewInt64(0)}},
+	}, {
+		name:     "in-tuple-mismatch",
+		inFilter: "select * from t1 where (id, val) in ((1, 'abc'), (2, 'xyz', 3))",
+		outErr:   "unexpected: (id, val) in ((1, 'abc'), (2, 'xyz', 3))",
 	}}
 
 	for _, tcase := range testcases {
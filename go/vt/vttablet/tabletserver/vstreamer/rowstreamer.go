@@ -19,6 +19,7 @@ package vstreamer
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -244,11 +245,29 @@ func (rs *rowStreamer) buildSelect() (string, error) {
 		prefix = ", "
 	}
 	buf.Myprintf(" from %v", sqlparser.NewIdentifierCS(rs.plan.Table.Name))
+	// In filters are pushed down, so that only the matching rows are read.
+	inFilters := 0
+	for _, filter := range rs.plan.Filters {
+		if filter.Opcode != In {
+			continue
+		}
+		if inFilters == 0 {
+			buf.WriteString(" where ")
+		} else {
+			buf.WriteString(" and ")
+		}
+		rs.formatInFilter(buf, filter)
+		inFilters++
+	}
 	if len(rs.lastpk) != 0 {
 		if len(rs.lastpk) != len(rs.pkColumns) {
 			return "", fmt.Errorf("primary key values don't match length: %v vs %v", rs.lastpk, rs.pkColumns)
 		}
-		buf.WriteString(" where ")
+		if inFilters == 0 {
+			buf.WriteString(" where ")
+		} else {
+			buf.WriteString(" and (")
+		}
 		prefix := ""
 		// This loop handles the case for composite pks. For example,
 		// if lastpk was (1,2), the where clause would be:
@@ -267,6 +286,9 @@ func (rs *rowStreamer) buildSelect() (string, error) {
 			rs.lastpk[lastcol].EncodeSQL(buf)
 			buf.Myprintf(")")
 		}
+		if inFilters != 0 {
+			buf.WriteString(")")
+		}
 	}
 	buf.Myprintf(" order by ", sqlparser.NewIdentifierCS(rs.plan.Table.Name))
 	prefix = ""
@@ -277,6 +299,44 @@ func (rs *rowStreamer) buildSelect() (string, error) {
 	return buf.String(), nil
 }
 
+// formatInFilter writes an In filter as a where clause condition, with the
+// values in a stable order.
+func (rs *rowStreamer) formatInFilter(buf *sqlparser.TrackedBuffer, filter Filter) {
+	formatTuple := func(n int, format func(i int)) {
+		if n > 1 {
+			buf.WriteString("(")
+		}
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			format(i)
+		}
+		if n > 1 {
+			buf.WriteString(")")
+		}
+	}
+	formatTuple(len(filter.InColumns), func(i int) {
+		buf.Myprintf("%v", sqlparser.NewIdentifierCI(rs.plan.Table.Fields[filter.InColumns[i]].Name))
+	})
+	keys := make([]string, 0, len(filter.InValues))
+	for key := range filter.InValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf.WriteString(" in (")
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		values := filter.InValues[key]
+		formatTuple(len(values), func(j int) {
+			values[j].EncodeSQL(buf)
+		})
+	}
+	buf.WriteString(")")
+}
+
 func (rs *rowStreamer) streamQuery(conn *snapshotConn, send func(*binlogdatapb.VStreamRowsResponse) error) error {
 
 	var sendMu sync.Mutex
@@ -327,6 +327,39 @@ func TestStreamRowsFilterVarBinary(t *testing.T) {
 	checkStream(t, "select id1, val from t1 where val = 'newton'", nil, wantQuery, wantStream)
 }
 
+func TestStreamRowsFilterIn(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	execStatements(t, []string{
+		"create table t1(id1 int, id2 int, val varbinary(128), primary key(id1, id2))",
+		"insert into t1 values (1, 2, 'aaa'), (1, 3, 'bbb'), (2, 2, 'ccc'), (3, 4, 'ddd')",
+	})
+
+	defer execStatements(t, []string{
+		"drop table t1",
+	})
+	engine.se.Reload(context.Background())
+
+	time.Sleep(1 * time.Second)
+
+	wantStream := []string{
+		`fields:{name:"id1" type:INT32 table:"t1" org_table:"t1" database:"vttest" org_name:"id1" column_length:11 charset:63} fields:{name:"val" type:VARBINARY table:"t1" org_table:"t1" database:"vttest" org_name:"val" column_length:128 charset:63} pkfields:{name:"id1" type:INT32} pkfields:{name:"id2" type:INT32}`,
+		`rows:{lengths:1 lengths:3 values:"2ccc"} rows:{lengths:1 lengths:3 values:"3ddd"} lastpk:{lengths:1 lengths:1 values:"34"}`,
+	}
+	wantQuery := "select id1, id2, val from t1 where id1 in (2, 3) order by id1, id2"
+	checkStream(t, "select id1, val from t1 where id1 in (3, 2)", nil, wantQuery, wantStream)
+
+	// tuples, with lastpk=1,2
+	wantStream = []string{
+		`fields:{name:"id1" type:INT32 table:"t1" org_table:"t1" database:"vttest" org_name:"id1" column_length:11 charset:63} fields:{name:"id2" type:INT32 table:"t1" org_table:"t1" database:"vttest" org_name:"id2" column_length:11 charset:63} fields:{name:"val" type:VARBINARY table:"t1" org_table:"t1" database:"vttest" org_name:"val" column_length:128 charset:63} pkfields:{name:"id1" type:INT32} pkfields:{name:"id2" type:INT32}`,
+		`rows:{lengths:1 lengths:1 lengths:3 values:"13bbb"} rows:{lengths:1 lengths:1 lengths:3 values:"34ddd"} lastpk:{lengths:1 lengths:1 values:"34"}`,
+	}
+	wantQuery = "select id1, id2, val from t1 where (id1, id2) in ((1, 2), (1, 3), (3, 4)) and ((id1 = 1 and id2 > 2) or (id1 > 1)) order by id1, id2"
+	checkStream(t, "select * from t1 where (id1, id2) in ((1, 3), (3, 4), (1, 2))", []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2)}, wantQuery, wantStream)
+}
+
 func TestStreamRowsMultiPacket(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
@@ -1260,7 +1260,7 @@ func tmRPCTestBackupPanic(ctx context.Context, t *testing.T, client tmclient.Tab
 	expectHandleRPCPanic(t, "Backup", true /*verbose*/, err)
 }
 
-func (fra *fakeRPCTM) RestoreFromBackup(ctx context.Context, logger logutil.Logger, backupTime time.Time) error {
+func (fra *fakeRPCTM) RestoreFromBackup(ctx context.Context, logger logutil.Logger, request *tabletmanagerdatapb.RestoreFromBackupRequest) error {
 	if fra.panics {
 		panic(fmt.Errorf("test-triggered panic"))
 	}
@@ -1269,8 +1269,8 @@ func (fra *fakeRPCTM) RestoreFromBackup(ctx context.Context, logger logutil.Logg
 	return nil
 }
 
-func tmRPCTestRestoreFromBackup(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.Tablet, backupTime time.Time) {
-	stream, err := client.RestoreFromBackup(ctx, tablet, backupTime)
+func tmRPCTestRestoreFromBackup(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.Tablet, req *tabletmanagerdatapb.RestoreFromBackupRequest) {
+	stream, err := client.RestoreFromBackup(ctx, tablet, req)
 	if err != nil {
 		t.Fatalf("RestoreFromBackup failed: %v", err)
 	}
@@ -1278,8 +1278,8 @@ func tmRPCTestRestoreFromBackup(ctx context.Context, t *testing.T, client tmclie
 	compareError(t, "RestoreFromBackup", err, true, testRestoreFromBackupCalled)
 }
 
-func tmRPCTestRestoreFromBackupPanic(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.Tablet, backupTime time.Time) {
-	stream, err := client.RestoreFromBackup(ctx, tablet, backupTime)
+func tmRPCTestRestoreFromBackupPanic(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.Tablet, req *tabletmanagerdatapb.RestoreFromBackupRequest) {
+	stream, err := client.RestoreFromBackup(ctx, tablet, req)
 	if err != nil {
 		t.Fatalf("RestoreFromBackup failed: %v", err)
 	}
@@ -1311,7 +1311,9 @@ func (fra *fakeRPCTM) HandleRPCPanic(ctx context.Context, name string, args, rep
 func Run(t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.Tablet, fakeTM tabletmanager.RPCTM) {
 	ctx := context.Background()
 
-	backupTime := time.Time{}
+	restoreFromBackupRequest := &tabletmanagerdatapb.RestoreFromBackupRequest{
+		BackupTime: logutil.TimeToProto(time.Time{}),
+	}
 
 	// Test RPC specific methods of the interface.
 	tmRPCTestDialExpiredContext(ctx, t, client, tablet)
@@ -1367,7 +1369,7 @@ func Run(t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.T
 
 	// Backup / restore related methods
 	tmRPCTestBackup(ctx, t, client, tablet)
-	tmRPCTestRestoreFromBackup(ctx, t, client, tablet, backupTime)
+	tmRPCTestRestoreFromBackup(ctx, t, client, tablet, restoreFromBackupRequest)
 
 	//
 	// Tests panic handling everywhere now
@@ -1419,7 +1421,7 @@ func Run(t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.T
 	tmRPCTestReplicaWasRestartedPanic(ctx, t, client, tablet)
 	// Backup / restore related methods
 	tmRPCTestBackupPanic(ctx, t, client, tablet)
-	tmRPCTestRestoreFromBackupPanic(ctx, t, client, tablet, backupTime)
+	tmRPCTestRestoreFromBackupPanic(ctx, t, client, tablet, restoreFromBackupRequest)
 
 	client.Close()
 }
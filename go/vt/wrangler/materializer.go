@@ -47,6 +47,7 @@ import (
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	"vitess.io/vitess/go/vt/vttablet/tabletmanager/sink"
 	"vitess.io/vitess/go/vt/vttablet/tabletmanager/vreplication"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
@@ -119,15 +120,57 @@ func shouldInclude(table string, excludes []string) bool {
 	return true
 }
 
+// parseOnDDL returns the OnDdl action and the ddl policy of the streams of a
+// workflow. The action defaults to IGNORE. The policy is a comma-separated list
+// of the changes applied by the APPLY_COMPATIBLE action, e.g. "add_columns,indexes",
+// and all of them are applied if it is empty.
+func parseOnDDL(onDDL, ddlPolicy string) (binlogdatapb.OnDDLAction, *binlogdatapb.DDLPolicy, error) {
+	action := binlogdatapb.OnDDLAction_IGNORE
+	if onDDL != "" {
+		value, ok := binlogdatapb.OnDDLAction_value[strings.ToUpper(onDDL)]
+		if !ok {
+			return action, nil, fmt.Errorf("invalid on_ddl action: %s", onDDL)
+		}
+		action = binlogdatapb.OnDDLAction(value)
+	}
+	if ddlPolicy == "" {
+		return action, nil, nil
+	}
+	if action != binlogdatapb.OnDDLAction_APPLY_COMPATIBLE {
+		return action, nil, fmt.Errorf("a ddl policy can only be used with the APPLY_COMPATIBLE on_ddl action")
+	}
+	policy := &binlogdatapb.DDLPolicy{}
+	for _, change := range strings.Split(ddlPolicy, ",") {
+		switch strings.ToLower(strings.TrimSpace(change)) {
+		case "add_columns":
+			policy.AddColumns = true
+		case "drop_columns":
+			policy.DropColumns = true
+		case "widen_columns":
+			policy.WidenColumns = true
+		case "indexes":
+			policy.Indexes = true
+		default:
+			return action, nil, fmt.Errorf("invalid ddl policy change: %s, must be one of add_columns, drop_columns, widen_columns or indexes", change)
+		}
+	}
+	return action, policy, nil
+}
+
 // MoveTables initiates moving table(s) over to another keyspace
 func (wr *Wrangler) MoveTables(ctx context.Context, workflow, sourceKeyspace, targetKeyspace, tableSpecs,
 	cell, tabletTypes string, allTables bool, excludeTables string, autoStart, stopAfterCopy bool,
-	externalCluster string, dropForeignKeys bool, sourceTimeZone string, sourceShards []string) error {
+	externalCluster string, dropForeignKeys bool, sourceTimeZone string, sourceShards []string, onDDL, ddlPolicy string) error {
 	//FIXME validate tableSpecs, allTables, excludeTables
 	var tables []string
 	var externalTopo *topo.Server
 	var err error
 
+	onDDLAction, policy, err := parseOnDDL(onDDL, ddlPolicy)
+	if err != nil {
+		return err
+	}
+
 	if externalCluster != "" { // when the source is an external mysql cluster mounted using the Mount command
 		externalTopo, err = wr.ts.OpenExternalVitessClusterServer(ctx, externalCluster)
 		if err != nil {
@@ -247,6 +290,8 @@ func (wr *Wrangler) MoveTables(ctx context.Context, workflow, sourceKeyspace, ta
 		StopAfterCopy:         stopAfterCopy,
 		ExternalCluster:       externalCluster,
 		SourceShards:          sourceShards,
+		OnDdl:                 onDDLAction,
+		DdlPolicy:             policy,
 	}
 	if sourceTimeZone != "" {
 		ms.SourceTimeZone = sourceTimeZone
@@ -899,8 +944,12 @@ func (wr *Wrangler) prepareMaterializerStreams(ctx context.Context, ms *vtctldat
 	if err != nil {
 		return nil, err
 	}
-	if err := mz.deploySchema(ctx); err != nil {
-		return nil, err
+	// Sink workflows don't write to the target keyspace, which only
+	// hosts their streams.
+	if ms.Sink == nil {
+		if err := mz.deploySchema(ctx); err != nil {
+			return nil, err
+		}
 	}
 	insertMap := make(map[string]string, len(mz.targetShards))
 	for _, targetShard := range mz.targetShards {
@@ -982,6 +1031,17 @@ func (wr *Wrangler) buildMaterializer(ctx context.Context, ms *vtctldatapb.Mater
 	if len(targetShards) == 0 {
 		return nil, fmt.Errorf("no target shards specified for workflow %s ", ms.Workflow)
 	}
+	if ms.Sink != nil {
+		if len(targetShards) != 1 {
+			return nil, fmt.Errorf("sink workflow %s must be hosted by an unsharded keyspace", ms.Workflow)
+		}
+		if _, err := sink.NewEncoder(ms.Sink.Format, ms.Workflow, ms.Sink.Params); err != nil {
+			return nil, err
+		}
+	}
+	if ms.DdlPolicy != nil && ms.OnDdl != binlogdatapb.OnDDLAction_APPLY_COMPATIBLE {
+		return nil, fmt.Errorf("a ddl policy can only be used with the APPLY_COMPATIBLE on_ddl action")
+	}
 
 	return &materializer{
 		wr:            wr,
@@ -1196,10 +1256,14 @@ func (mz *materializer) generateInserts(ctx context.Context, targetShard *topo.S
 			ExternalCluster: mz.ms.ExternalCluster,
 			SourceTimeZone:  mz.ms.SourceTimeZone,
 			TargetTimeZone:  mz.ms.TargetTimeZone,
+			Sink:            mz.ms.Sink,
+			OnDdl:           mz.ms.OnDdl,
+			DdlPolicy:       mz.ms.DdlPolicy,
 		}
 		for _, ts := range mz.ms.TableSettings {
 			rule := &binlogdatapb.Rule{
-				Match: ts.TargetTable,
+				Match:            ts.TargetTable,
+				ColumnTransforms: ts.ColumnTransforms,
 			}
 
 			if ts.SourceExpression == "" {
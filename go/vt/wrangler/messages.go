@@ -0,0 +1,294 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrangler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/grpcclient"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/tabletconn"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/schema"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// Commands supported by DeadLetters.
+const (
+	DeadLettersList    = "list"
+	DeadLettersRequeue = "requeue"
+	DeadLettersPurge   = "purge"
+)
+
+const (
+	sqlGetMessageTableComment = "select table_comment from information_schema.tables where table_schema = database() and table_name = %a"
+	sqlGetMessageTableColumns = "select column_name from information_schema.columns where table_schema = database() and table_name = %a order by ordinal_position"
+)
+
+// deadLetterTable describes where a message table keeps its dead-lettered messages.
+type deadLetterTable struct {
+	message sqlparser.IdentifierCS
+	// dlq is empty if dead-lettered messages are acked in place.
+	dlq sqlparser.IdentifierCS
+}
+
+// DeadLetters inspects, requeues or purges the dead-lettered messages of a message
+// table on all primaries of the keyspace. If ids is empty, the command applies to
+// all dead-lettered messages. Requeued messages are sent again with their attempts reset.
+func (wr *Wrangler) DeadLetters(ctx context.Context, keyspace, table, command string, ids []string, limit int) (*sqltypes.Result, error) {
+	switch command {
+	case DeadLettersList, DeadLettersRequeue, DeadLettersPurge:
+	default:
+		return nil, fmt.Errorf("unknown dead letters command: %s", command)
+	}
+	vx := newVExec(ctx, "", keyspace, "", wr)
+	if err := vx.getPrimaries(); err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	allErrors := &concurrency.AllErrorRecorder{}
+	results := make(map[*topo.TabletInfo]*sqltypes.Result)
+	var mu sync.Mutex
+	for _, primary := range vx.primaries {
+		wg.Add(1)
+		go func(primary *topo.TabletInfo) {
+			defer wg.Done()
+			qr, err := wr.deadLettersOnTablet(ctx, primary, table, command, ids, limit)
+			if err != nil {
+				allErrors.RecordError(vterrors.Wrapf(err, "%s", primary.AliasString()))
+				return
+			}
+			mu.Lock()
+			results[primary] = qr
+			mu.Unlock()
+		}(primary)
+	}
+	wg.Wait()
+	if allErrors.HasErrors() {
+		return nil, allErrors.AggrError(vterrors.Aggregate)
+	}
+	if command == DeadLettersList {
+		return wr.QueryResultForTabletResults(results), nil
+	}
+	return wr.QueryResultForRowsAffected(results), nil
+}
+
+func (wr *Wrangler) deadLettersOnTablet(ctx context.Context, primary *topo.TabletInfo, table, command string, ids []string, limit int) (*sqltypes.Result, error) {
+	dlt, err := wr.getDeadLetterTable(ctx, primary, table)
+	if err != nil {
+		return nil, err
+	}
+
+	if command == DeadLettersRequeue && !dlt.dlq.IsEmpty() && len(ids) == 0 {
+		// Pin down the messages to requeue so that the delete below
+		// doesn't remove messages dead-lettered in the meantime.
+		if ids, err = wr.getDeadLetterIDs(ctx, primary, dlt.dlq); err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return &sqltypes.Result{}, nil
+		}
+	}
+
+	// The where clause that selects the dead-lettered messages.
+	var conditions []string
+	if dlt.dlq.IsEmpty() {
+		conditions = append(conditions, "dead_letter_reason is not null")
+	}
+	if len(ids) != 0 {
+		idsbv, err := sqltypes.BuildBindVariable(ids)
+		if err != nil {
+			return nil, err
+		}
+		cond, err := sqlparser.BuildParsedQuery("id in %a", "::ids").GenerateQuery(map[string]*querypb.BindVariable{"ids": idsbv}, nil)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+	}
+	where := ""
+	if len(conditions) != 0 {
+		where = " where " + strings.Join(conditions, " and ")
+	}
+
+	source := dlt.dlq
+	if source.IsEmpty() {
+		source = dlt.message
+	}
+	var query string
+	switch command {
+	case DeadLettersList:
+		query = sqlparser.BuildParsedQuery("select * from %v%s order by id limit %d", source, where, limit).Query
+	case DeadLettersPurge:
+		query = sqlparser.BuildParsedQuery("delete from %v%s", source, where).Query
+	case DeadLettersRequeue:
+		timeNext := time.Now().UnixNano()
+		if dlt.dlq.IsEmpty() {
+			query = sqlparser.BuildParsedQuery(
+				"update %v set time_next = %d, epoch = null, time_acked = null, dead_letter_reason = null%s",
+				source, timeNext, where).Query
+			break
+		}
+		columns, err := wr.getMessageColumns(ctx, primary, dlt.message)
+		if err != nil {
+			return nil, err
+		}
+		names := sqlparser.NewTrackedBuffer(nil)
+		exprs := sqlparser.NewTrackedBuffer(nil)
+		for i, col := range columns {
+			if i != 0 {
+				names.WriteString(", ")
+				exprs.WriteString(", ")
+			}
+			names.Myprintf("%v", col)
+			// Reset the delivery state so that the message is sent again.
+			switch col.Lowered() {
+			case "time_next":
+				exprs.Myprintf("%d", timeNext)
+			case "epoch", "time_acked", "dead_letter_reason":
+				exprs.WriteString("null")
+			default:
+				exprs.Myprintf("%v", col)
+			}
+		}
+		// The insert fails if any of the messages is already in the message table.
+		return wr.execDeadLettersTransaction(ctx, primary,
+			sqlparser.BuildParsedQuery("insert into %v(%s) select %s from %v%s", dlt.message, names.String(), exprs.String(), dlt.dlq, where).Query,
+			sqlparser.BuildParsedQuery("delete from %v%s", dlt.dlq, where).Query,
+		)
+	}
+	return wr.execDeadLettersQuery(ctx, primary, query, limit)
+}
+
+// execDeadLettersQuery executes a query on the primary as the dba.
+func (wr *Wrangler) execDeadLettersQuery(ctx context.Context, primary *topo.TabletInfo, query string, maxRows int) (*sqltypes.Result, error) {
+	res, err := wr.tmc.ExecuteFetchAsDba(ctx, primary.Tablet, false, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+		Query:   []byte(query),
+		MaxRows: uint64(maxRows),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sqltypes.Proto3ToResult(res), nil
+}
+
+// execDeadLettersTransaction executes the queries in a single transaction on the primary,
+// so that requeued messages are never lost nor duplicated. It returns the result of the
+// first query.
+func (wr *Wrangler) execDeadLettersTransaction(ctx context.Context, primary *topo.TabletInfo, queries ...string) (*sqltypes.Result, error) {
+	conn, err := tabletconn.GetDialer()(primary.Tablet, grpcclient.FailFast(false))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	target := &querypb.Target{
+		Keyspace:   primary.Keyspace,
+		Shard:      primary.Shard,
+		TabletType: topodatapb.TabletType_PRIMARY,
+	}
+	state, err := conn.Begin(ctx, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	// If the transaction was not committed by the end, it means
+	// that there was an error, roll it back.
+	defer func() {
+		if state.TransactionID != 0 {
+			_, _ = conn.Rollback(ctx, target, state.TransactionID)
+		}
+	}()
+	var qr *sqltypes.Result
+	for _, query := range queries {
+		res, err := conn.Execute(ctx, target, query, nil, state.TransactionID, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		if qr == nil {
+			qr = res
+		}
+	}
+	_, err = conn.Commit(ctx, target, state.TransactionID)
+	state.TransactionID = 0
+	if err != nil {
+		return nil, err
+	}
+	return qr, nil
+}
+
+// getDeadLetterTable reads the dead letter settings from the comment of the message table.
+func (wr *Wrangler) getDeadLetterTable(ctx context.Context, primary *topo.TabletInfo, table string) (*deadLetterTable, error) {
+	query, err := sqlparser.ParseAndBind(sqlGetMessageTableComment, sqltypes.StringBindVariable(table))
+	if err != nil {
+		return nil, err
+	}
+	qr, err := wr.execDeadLettersQuery(ctx, primary, query, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(qr.Rows) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+	_, dlq, err := schema.ParseMessageDeadLetterInfo(qr.Rows[0][0].ToString())
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "table %s does not dead-letter messages", table)
+	}
+	return &deadLetterTable{
+		message: sqlparser.NewIdentifierCS(table),
+		dlq:     sqlparser.NewIdentifierCS(dlq),
+	}, nil
+}
+
+func (wr *Wrangler) getDeadLetterIDs(ctx context.Context, primary *topo.TabletInfo, dlq sqlparser.IdentifierCS) ([]string, error) {
+	query := sqlparser.BuildParsedQuery("select id from %v", dlq).Query
+	qr, err := wr.execDeadLettersQuery(ctx, primary, query, 10000)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, row := range qr.Rows {
+		ids = append(ids, row[0].ToString())
+	}
+	return ids, nil
+}
+
+func (wr *Wrangler) getMessageColumns(ctx context.Context, primary *topo.TabletInfo, table sqlparser.IdentifierCS) ([]sqlparser.IdentifierCI, error) {
+	query, err := sqlparser.ParseAndBind(sqlGetMessageTableColumns, sqltypes.StringBindVariable(table.String()))
+	if err != nil {
+		return nil, err
+	}
+	qr, err := wr.execDeadLettersQuery(ctx, primary, query, 10000)
+	if err != nil {
+		return nil, err
+	}
+	var columns []sqlparser.IdentifierCI
+	for _, row := range qr.Rows {
+		columns = append(columns, sqlparser.NewIdentifierCI(row[0].ToString()))
+	}
+	return columns, nil
+}
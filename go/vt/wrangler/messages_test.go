@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrangler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/grpcclient"
+	"vitess.io/vitess/go/vt/vttablet/queryservice"
+	"vitess.io/vitess/go/vt/vttablet/queryservice/fakes"
+	"vitess.io/vitess/go/vt/vttablet/tabletconn"
+	"vitess.io/vitess/go/vt/vttablet/tabletconntest"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+const (
+	getMsgComment = "select table_comment from information_schema.tables where table_schema = database() and table_name = 'msg'"
+	getMsgColumns = "select column_name from information_schema.columns where table_schema = database() and table_name = 'msg' order by ordinal_position"
+)
+
+// txTablet has to be a global for RegisterDialer to work.
+var txTablet *fakeTxTablet
+
+func init() {
+	tabletconn.RegisterDialer("TxTest", func(tablet *topodatapb.Tablet, failFast grpcclient.FailFast) (queryservice.QueryService, error) {
+		return txTablet, nil
+	})
+}
+
+// fakeTxTablet records the transactions that are run on a primary.
+type fakeTxTablet struct {
+	queryservice.QueryService
+	// results are the results of the queries, by query. Other queries
+	// return an empty result.
+	results map[string]*sqltypes.Result
+	// failQuery, if set, fails when it is executed.
+	failQuery string
+
+	mu         sync.Mutex
+	queries    []string
+	committed  [][]string
+	rolledBack int
+}
+
+// newTxTablet makes the tablet connections of the test use a new fakeTxTablet.
+func newTxTablet() *fakeTxTablet {
+	tabletconntest.SetProtocol("go.vt.wrangler.messages_test", "TxTest")
+	txTablet = &fakeTxTablet{
+		QueryService: fakes.ErrorQueryService,
+		results:      make(map[string]*sqltypes.Result),
+	}
+	return txTablet
+}
+
+func (tablet *fakeTxTablet) Begin(ctx context.Context, target *querypb.Target, options *querypb.ExecuteOptions) (queryservice.TransactionState, error) {
+	tablet.mu.Lock()
+	defer tablet.mu.Unlock()
+	tablet.queries = nil
+	return queryservice.TransactionState{TransactionID: 1}, nil
+}
+
+func (tablet *fakeTxTablet) Execute(ctx context.Context, target *querypb.Target, sql string, bindVariables map[string]*querypb.BindVariable, transactionID, reservedID int64, options *querypb.ExecuteOptions) (*sqltypes.Result, error) {
+	tablet.mu.Lock()
+	defer tablet.mu.Unlock()
+	if transactionID != 1 {
+		return nil, fmt.Errorf("query %s is not in a transaction", sql)
+	}
+	if sql == tablet.failQuery {
+		return nil, fmt.Errorf("query %s failed", sql)
+	}
+	tablet.queries = append(tablet.queries, sql)
+	if qr, ok := tablet.results[sql]; ok {
+		return qr, nil
+	}
+	return &sqltypes.Result{}, nil
+}
+
+func (tablet *fakeTxTablet) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
+	tablet.mu.Lock()
+	defer tablet.mu.Unlock()
+	tablet.committed = append(tablet.committed, tablet.queries)
+	return 0, nil
+}
+
+func (tablet *fakeTxTablet) Rollback(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
+	tablet.mu.Lock()
+	defer tablet.mu.Unlock()
+	tablet.rolledBack++
+	return 0, nil
+}
+
+func newDeadLettersEnv(t *testing.T) *testMaterializerEnv {
+	newTxTablet()
+	ms := &vtctldatapb.MaterializeSettings{
+		SourceKeyspace: "ks",
+		TargetKeyspace: "ks",
+	}
+	return newTestMaterializerEnv(t, ms, []string{"0"}, []string{"0"})
+}
+
+func TestDeadLettersMarked(t *testing.T) {
+	ctx := context.Background()
+	env := newDeadLettersEnv(t)
+	defer env.close()
+
+	comment := sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_comment", "varchar"), "vitess_message,vt_ack_wait=30,vt_max_attempts=3")
+
+	env.tmc.expectVRQuery(100, getMsgComment, comment)
+	env.tmc.expectVRQuery(100, "select * from msg where dead_letter_reason is not null order by id limit 10", sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields("id|dead_letter_reason", "int64|varchar"),
+		"1|max attempts (3) exceeded",
+	))
+	qr, err := env.wr.DeadLetters(ctx, "ks", "msg", DeadLettersList, nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(qr.Rows))
+
+	env.tmc.expectVRQuery(100, getMsgComment, comment)
+	env.tmc.expectVRQuery(100, "/update msg set time_next = [0-9]+, epoch = null, time_acked = null, dead_letter_reason = null where dead_letter_reason is not null and id in \\('1', '2'\\)", &sqltypes.Result{RowsAffected: 2})
+	_, err = env.wr.DeadLetters(ctx, "ks", "msg", DeadLettersRequeue, []string{"1", "2"}, 10)
+	require.NoError(t, err)
+
+	env.tmc.expectVRQuery(100, getMsgComment, comment)
+	env.tmc.expectVRQuery(100, "delete from msg where dead_letter_reason is not null", &sqltypes.Result{RowsAffected: 1})
+	_, err = env.wr.DeadLetters(ctx, "ks", "msg", DeadLettersPurge, nil, 10)
+	require.NoError(t, err)
+
+	env.tmc.verifyQueries(t)
+}
+
+func TestDeadLettersMoved(t *testing.T) {
+	ctx := context.Background()
+	env := newDeadLettersEnv(t)
+	defer env.close()
+
+	comment := sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_comment", "varchar"), "vitess_message,vt_ack_wait=30,vt_max_attempts=3,vt_dead_letter_table=msg_dlq")
+
+	env.tmc.expectVRQuery(100, getMsgComment, comment)
+	env.tmc.expectVRQuery(100, "select id from msg_dlq", sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "1", "2"))
+	env.tmc.expectVRQuery(100, getMsgColumns, sqltypes.MakeTestResult(sqltypes.MakeTestFields("column_name", "varchar"),
+		"id", "priority", "time_next", "epoch", "time_acked", "message", "dead_letter_reason"))
+	_, err := env.wr.DeadLetters(ctx, "ks", "msg", DeadLettersRequeue, nil, 10)
+	require.NoError(t, err)
+	// The messages are inserted and deleted in the same transaction.
+	require.Len(t, txTablet.committed, 1)
+	require.Len(t, txTablet.committed[0], 2)
+	assert.Regexp(t, "^insert into msg\\(id, priority, time_next, epoch, time_acked, message, dead_letter_reason\\) select id, priority, [0-9]+, null, null, message, null from msg_dlq where id in \\('1', '2'\\)$",
+		txTablet.committed[0][0])
+	assert.Equal(t, "delete from msg_dlq where id in ('1', '2')", txTablet.committed[0][1])
+
+	// If the delete fails, the insert is rolled back.
+	txTablet.failQuery = "delete from msg_dlq where id in ('1')"
+	env.tmc.expectVRQuery(100, getMsgComment, comment)
+	env.tmc.expectVRQuery(100, getMsgColumns, sqltypes.MakeTestResult(sqltypes.MakeTestFields("column_name", "varchar"), "id", "message"))
+	_, err = env.wr.DeadLetters(ctx, "ks", "msg", DeadLettersRequeue, []string{"1"}, 10)
+	require.ErrorContains(t, err, "query delete from msg_dlq where id in ('1') failed")
+	assert.Len(t, txTablet.committed, 1)
+	assert.Equal(t, 1, txTablet.rolledBack)
+
+	env.tmc.expectVRQuery(100, getMsgComment, comment)
+	env.tmc.expectVRQuery(100, "delete from msg_dlq where id in ('3')", &sqltypes.Result{RowsAffected: 1})
+	_, err = env.wr.DeadLetters(ctx, "ks", "msg", DeadLettersPurge, []string{"3"}, 10)
+	require.NoError(t, err)
+
+	env.tmc.verifyQueries(t)
+}
+
+func TestDeadLettersErrors(t *testing.T) {
+	ctx := context.Background()
+	env := newDeadLettersEnv(t)
+	defer env.close()
+
+	_, err := env.wr.DeadLetters(ctx, "ks", "msg", "drop", nil, 10)
+	require.EqualError(t, err, "unknown dead letters command: drop")
+
+	env.tmc.expectVRQuery(100, getMsgComment, sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_comment", "varchar"), "vitess_message,vt_ack_wait=30"))
+	_, err = env.wr.DeadLetters(ctx, "ks", "msg", DeadLettersList, nil, 10)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "table msg does not dead-letter messages")
+
+	env.tmc.verifyQueries(t)
+}
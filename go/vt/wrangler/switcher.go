@@ -20,6 +20,7 @@ import (
 	"context"
 	"time"
 
+	"vitess.io/vitess/go/vt/topo"
 	"vitess.io/vitess/go/vt/vtctl/workflow"
 
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
@@ -56,8 +57,8 @@ func (r *switcher) dropSourceShards(ctx context.Context) error {
 	return r.ts.dropSourceShards(ctx)
 }
 
-func (r *switcher) switchShardReads(ctx context.Context, cells []string, servedTypes []topodatapb.TabletType, direction workflow.TrafficSwitchDirection) error {
-	return r.ts.switchShardReads(ctx, cells, servedTypes, direction)
+func (r *switcher) switchShardReads(ctx context.Context, cells []string, servedTypes []topodatapb.TabletType, sourceShards, targetShards []*topo.ShardInfo, direction workflow.TrafficSwitchDirection) error {
+	return r.ts.switchShardReads(ctx, cells, servedTypes, sourceShards, targetShards, direction)
 }
 
 func (r *switcher) switchTableReads(ctx context.Context, cells []string, servedTypes []topodatapb.TabletType, direction workflow.TrafficSwitchDirection) error {
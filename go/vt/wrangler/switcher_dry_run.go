@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/vt/topo"
 	"vitess.io/vitess/go/vt/vtctl/workflow"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
@@ -47,23 +48,23 @@ func (dr *switcherDryRun) deleteRoutingRules(ctx context.Context) error {
 	return nil
 }
 
-func (dr *switcherDryRun) switchShardReads(ctx context.Context, cells []string, servedTypes []topodatapb.TabletType, direction workflow.TrafficSwitchDirection) error {
-	sourceShards := make([]string, 0)
-	targetShards := make([]string, 0)
-	for _, source := range dr.ts.Sources() {
-		sourceShards = append(sourceShards, source.GetShard().ShardName())
+func (dr *switcherDryRun) switchShardReads(ctx context.Context, cells []string, servedTypes []topodatapb.TabletType, sourceShards, targetShards []*topo.ShardInfo, direction workflow.TrafficSwitchDirection) error {
+	sourceShardNames := make([]string, 0)
+	targetShardNames := make([]string, 0)
+	for _, source := range sourceShards {
+		sourceShardNames = append(sourceShardNames, source.ShardName())
 	}
-	for _, target := range dr.ts.Targets() {
-		targetShards = append(targetShards, target.GetShard().ShardName())
+	for _, target := range targetShards {
+		targetShardNames = append(targetShardNames, target.ShardName())
 	}
-	sort.Strings(sourceShards)
-	sort.Strings(targetShards)
+	sort.Strings(sourceShardNames)
+	sort.Strings(targetShardNames)
 	if direction == workflow.DirectionForward {
 		dr.drLog.Log(fmt.Sprintf("Switch reads from keyspace %s to keyspace %s for shards %s to shards %s",
-			dr.ts.SourceKeyspaceName(), dr.ts.TargetKeyspaceName(), strings.Join(sourceShards, ","), strings.Join(targetShards, ",")))
+			dr.ts.SourceKeyspaceName(), dr.ts.TargetKeyspaceName(), strings.Join(sourceShardNames, ","), strings.Join(targetShardNames, ",")))
 	} else {
 		dr.drLog.Log(fmt.Sprintf("Switch reads from keyspace %s to keyspace %s for shards %s to shards %s",
-			dr.ts.TargetKeyspaceName(), dr.ts.SourceKeyspaceName(), strings.Join(targetShards, ","), strings.Join(sourceShards, ",")))
+			dr.ts.TargetKeyspaceName(), dr.ts.SourceKeyspaceName(), strings.Join(targetShardNames, ","), strings.Join(sourceShardNames, ",")))
 	}
 	return nil
 }
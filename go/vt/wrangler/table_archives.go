@@ -0,0 +1,295 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrangler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/grpcclient"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/tabletconn"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/gc"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// ListArchivedTables lists the table archives that the table GC of the keyspace's
+// tablets wrote to the backup storage. An archive is named after the GC UUID of its table.
+// Incomplete archives are not listed.
+func (wr *Wrangler) ListArchivedTables(ctx context.Context, keyspace string) (*sqltypes.Result, error) {
+	shards, err := wr.ts.GetShardNames(ctx, keyspace)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer bs.Close()
+
+	qr := &sqltypes.Result{
+		Fields: []*querypb.Field{
+			{Name: "shard", Type: sqltypes.VarChar},
+			{Name: "archive", Type: sqltypes.VarChar},
+			{Name: "rows", Type: sqltypes.Int64},
+		},
+	}
+	for _, shard := range shards {
+		bhs, err := bs.ListBackups(ctx, gc.ArchiveDirectory(keyspace, shard))
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "%s/%s", keyspace, shard)
+		}
+		for _, bh := range bhs {
+			manifest, err := gc.ReadArchiveManifest(ctx, bh)
+			if err != nil {
+				continue
+			}
+			qr.Rows = append(qr.Rows, []sqltypes.Value{
+				sqltypes.NewVarChar(shard),
+				sqltypes.NewVarChar(bh.Name()),
+				sqltypes.NewInt64(manifest.Rows),
+			})
+		}
+	}
+	return qr, nil
+}
+
+// RestoreArchivedTable creates a table on all primaries of the keyspace, and copies
+// into it the rows of the shard's table archive. The table is created with the schema
+// of the archived table, but under the given name. It fails if the table exists.
+//
+// Archives are written per shard, and each shard restores its own archive: the rows are
+// not routed through the vindexes of the keyspace. Restoring a table that was archived
+// before the keyspace was resharded is therefore not supported, and fails if any of
+// the current shards has no archive by the given name.
+//
+// The rows of a shard are inserted in a single transaction. If the restore fails on
+// any shard, the restored table is dropped on all shards.
+func (wr *Wrangler) RestoreArchivedTable(ctx context.Context, keyspace, archive, table string) (*sqltypes.Result, error) {
+	vx := newVExec(ctx, "", keyspace, "", wr)
+	if err := vx.getPrimaries(); err != nil {
+		return nil, err
+	}
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer bs.Close()
+
+	// Check that every shard has a complete archive before creating any table.
+	archives := make(map[*topo.TabletInfo]backupstorage.BackupHandle)
+	for _, primary := range vx.primaries {
+		bh, err := findArchive(ctx, bs, primary, archive)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "%s", primary.AliasString())
+		}
+		archives[primary] = bh
+	}
+
+	var wg sync.WaitGroup
+	allErrors := &concurrency.AllErrorRecorder{}
+	results := make(map[*topo.TabletInfo]*sqltypes.Result)
+	var mu sync.Mutex
+	for _, primary := range vx.primaries {
+		wg.Add(1)
+		go func(primary *topo.TabletInfo) {
+			defer wg.Done()
+			qr, err := wr.restoreArchivedTableOnTablet(ctx, archives[primary], primary, table)
+			if err != nil {
+				allErrors.RecordError(vterrors.Wrapf(err, "%s", primary.AliasString()))
+				return
+			}
+			mu.Lock()
+			results[primary] = qr
+			mu.Unlock()
+		}(primary)
+	}
+	wg.Wait()
+	if allErrors.HasErrors() {
+		for primary := range results {
+			if err := wr.dropRestoredTable(ctx, primary, table); err != nil {
+				allErrors.RecordError(vterrors.Wrapf(err, "%s: failed to drop restored table %s", primary.AliasString(), table))
+			}
+		}
+		return nil, allErrors.AggrError(vterrors.Aggregate)
+	}
+	return wr.QueryResultForRowsAffected(results), nil
+}
+
+// findArchive returns the archive of the shard of the primary by the given name,
+// if it's complete.
+func findArchive(ctx context.Context, bs backupstorage.BackupStorage, primary *topo.TabletInfo, archive string) (backupstorage.BackupHandle, error) {
+	dir := gc.ArchiveDirectory(primary.Keyspace, primary.Shard)
+	bhs, err := bs.ListBackups(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, bh := range bhs {
+		if bh.Name() != archive {
+			continue
+		}
+		if _, err := gc.ReadArchiveManifest(ctx, bh); err != nil {
+			return nil, err
+		}
+		return bh, nil
+	}
+	return nil, fmt.Errorf("archive %s not found in %s, tables archived before a reshard can't be restored", archive, dir)
+}
+
+// restoreArchivedTableOnTablet creates the table and inserts the archived rows in a
+// single transaction. If it fails after creating the table, the table is dropped.
+func (wr *Wrangler) restoreArchivedTableOnTablet(ctx context.Context, bh backupstorage.BackupHandle, primary *topo.TabletInfo, table string) (qr *sqltypes.Result, err error) {
+	createTable, err := readArchivedCreateTable(ctx, bh, table)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := wr.tmc.ExecuteFetchAsDba(ctx, primary.Tablet, false, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+		Query:        []byte(createTable),
+		ReloadSchema: true,
+	}); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if dropErr := wr.dropRestoredTable(ctx, primary, table); dropErr != nil {
+				err = vterrors.Wrapf(err, "failed to drop restored table %s: %v", table, dropErr)
+			}
+		}
+	}()
+
+	rows, err := bh.ReadFile(ctx, gc.ArchiveRowsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conn, err := tabletconn.GetDialer()(primary.Tablet, grpcclient.FailFast(false))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	target := &querypb.Target{
+		Keyspace:   primary.Keyspace,
+		Shard:      primary.Shard,
+		TabletType: topodatapb.TabletType_PRIMARY,
+	}
+	state, err := conn.Begin(ctx, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	// If the transaction was not committed by the end, it means
+	// that there was an error, roll it back.
+	defer func() {
+		if state.TransactionID != 0 {
+			_, _ = conn.Rollback(ctx, target, state.TransactionID)
+		}
+	}()
+
+	tableName := sqlparser.NewIdentifierCS(table)
+	var names string
+	qr = &sqltypes.Result{}
+	err = gc.ReadArchiveRows(rows, func(res *sqltypes.Result) error {
+		if len(res.Fields) != 0 {
+			buf := sqlparser.NewTrackedBuffer(nil)
+			for i, field := range res.Fields {
+				if i != 0 {
+					buf.WriteString(", ")
+				}
+				buf.Myprintf("%v", sqlparser.NewIdentifierCI(field.Name))
+			}
+			names = buf.String()
+		}
+		if len(res.Rows) == 0 {
+			return nil
+		}
+		values := sqlparser.NewTrackedBuffer(nil)
+		for i, row := range res.Rows {
+			if i != 0 {
+				values.WriteString(", ")
+			}
+			values.WriteByte('(')
+			for j, value := range row {
+				if j != 0 {
+					values.WriteString(", ")
+				}
+				value.EncodeSQL(values)
+			}
+			values.WriteByte(')')
+		}
+		query := sqlparser.BuildParsedQuery("insert into %v(%s) values %s", tableName, names, values.String()).Query
+		inserted, err := conn.Execute(ctx, target, query, nil, state.TransactionID, 0, nil)
+		if err != nil {
+			return err
+		}
+		qr.RowsAffected += inserted.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Commit(ctx, target, state.TransactionID)
+	state.TransactionID = 0
+	if err != nil {
+		return nil, err
+	}
+	return qr, nil
+}
+
+// dropRestoredTable drops a table that was created by RestoreArchivedTable.
+func (wr *Wrangler) dropRestoredTable(ctx context.Context, primary *topo.TabletInfo, table string) error {
+	query := sqlparser.BuildParsedQuery("drop table if exists %v", sqlparser.NewIdentifierCS(table)).Query
+	_, err := wr.tmc.ExecuteFetchAsDba(ctx, primary.Tablet, false, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+		Query:        []byte(query),
+		ReloadSchema: true,
+	})
+	return err
+}
+
+// readArchivedCreateTable reads the CREATE TABLE statement of an archive,
+// and renames the archived table to the given name.
+func readArchivedCreateTable(ctx context.Context, bh backupstorage.BackupHandle, table string) (string, error) {
+	rc, err := bh.ReadFile(ctx, gc.ArchiveSchemaFile)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	stmt, err := sqlparser.Parse(string(b))
+	if err != nil {
+		return "", err
+	}
+	createTable, ok := stmt.(*sqlparser.CreateTable)
+	if !ok {
+		return "", fmt.Errorf("archive schema is not a CREATE TABLE statement: %s", b)
+	}
+	createTable.Table = sqlparser.TableName{Name: sqlparser.NewIdentifierCS(table)}
+	return sqlparser.String(createTable), nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wrangler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/mysqlctl/filebackupstorage"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/gc"
+
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+const archivedCreateTable = "CREATE TABLE `_vt_HOLD_6ace8bcef73211ea87e9f875a4d24e90_20200915120410` (\n  `id` int NOT NULL,\n  `val` varchar(10) DEFAULT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB"
+
+func newTableArchivesEnv(t *testing.T) *testMaterializerEnv {
+	filebackupstorage.FileBackupStorageRoot = t.TempDir()
+	backupstorage.BackupStorageImplementation = "file"
+
+	ms := &vtctldatapb.MaterializeSettings{
+		SourceKeyspace: "ks",
+		TargetKeyspace: "ks",
+	}
+	return newTestMaterializerEnv(t, ms, []string{"0"}, []string{"0"})
+}
+
+// writeTestArchive writes a table archive the way the table GC does. The archive is
+// incomplete if complete is false: it has no MANIFEST.
+func writeTestArchive(t *testing.T, name string, complete bool, results ...*sqltypes.Result) {
+	ctx := context.Background()
+	bs, err := backupstorage.GetBackupStorage()
+	require.NoError(t, err)
+	defer bs.Close()
+
+	bh, err := bs.StartBackup(ctx, gc.ArchiveDirectory("ks", "0"), name)
+	require.NoError(t, err)
+
+	w, err := bh.AddFile(ctx, gc.ArchiveSchemaFile, int64(len(archivedCreateTable)))
+	require.NoError(t, err)
+	_, err = io.WriteString(w, archivedCreateTable)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	w, err = bh.AddFile(ctx, gc.ArchiveRowsFile, backupstorage.FileSizeUnknown)
+	require.NoError(t, err)
+	aw := gc.NewArchiveRowsWriter(w)
+	for _, qr := range results {
+		require.NoError(t, aw.Write(qr.Fields, sqltypes.RowsToProto3(qr.Rows)))
+	}
+	require.NoError(t, aw.Close())
+	require.NoError(t, w.Close())
+
+	if complete {
+		manifest, err := json.Marshal(&gc.ArchiveManifest{Keyspace: "ks", Shard: "0", Rows: aw.Rows()})
+		require.NoError(t, err)
+		w, err = bh.AddFile(ctx, gc.ArchiveManifestFile, int64(len(manifest)))
+		require.NoError(t, err)
+		_, err = w.Write(manifest)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+	require.NoError(t, bh.EndBackup(ctx))
+}
+
+func TestListArchivedTables(t *testing.T) {
+	ctx := context.Background()
+	env := newTableArchivesEnv(t)
+	defer env.close()
+
+	qr, err := env.wr.ListArchivedTables(ctx, "ks")
+	require.NoError(t, err)
+	assert.Empty(t, qr.Rows)
+
+	fields := sqltypes.MakeTestFields("id|val", "int32|varchar")
+	writeTestArchive(t, "6ace8bcef73211ea87e9f875a4d24e90", true, sqltypes.MakeTestResult(fields, "1|a"))
+	// Incomplete archives are not listed.
+	writeTestArchive(t, "7ace8bcef73211ea87e9f875a4d24e90", false, sqltypes.MakeTestResult(fields, "1|a"))
+	qr, err = env.wr.ListArchivedTables(ctx, "ks")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(qr.Rows))
+	assert.Equal(t, "0", qr.Rows[0][0].ToString())
+	assert.Equal(t, "6ace8bcef73211ea87e9f875a4d24e90", qr.Rows[0][1].ToString())
+	assert.Equal(t, "1", qr.Rows[0][2].ToString())
+}
+
+func TestRestoreArchivedTable(t *testing.T) {
+	ctx := context.Background()
+	env := newTableArchivesEnv(t)
+	defer env.close()
+	tablet := newTxTablet()
+
+	fields := sqltypes.MakeTestFields("id|val", "int32|varchar")
+	writeTestArchive(t, "6ace8bcef73211ea87e9f875a4d24e90", true,
+		sqltypes.MakeTestResult(fields, "1|a", "2|b"),
+		sqltypes.MakeTestResult(fields, "3|null"),
+	)
+	writeTestArchive(t, "7ace8bcef73211ea87e9f875a4d24e90", false, sqltypes.MakeTestResult(fields, "1|a"))
+
+	insert1 := "insert into t_restored(id, val) values (1, 'a'), (2, 'b')"
+	insert2 := "insert into t_restored(id, val) values (3, null)"
+	tablet.results[insert1] = &sqltypes.Result{RowsAffected: 2}
+	tablet.results[insert2] = &sqltypes.Result{RowsAffected: 1}
+	env.tmc.expectVRQuery(100, "/^create table t_restored \\(", &sqltypes.Result{})
+	qr, err := env.wr.RestoreArchivedTable(ctx, "ks", "6ace8bcef73211ea87e9f875a4d24e90", "t_restored")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(qr.Rows))
+	assert.Equal(t, "3", qr.Rows[0][1].ToString())
+	// The rows are inserted in a single transaction.
+	assert.Equal(t, [][]string{{insert1, insert2}}, tablet.committed)
+	env.tmc.verifyQueries(t)
+
+	// If an insert fails, the transaction is rolled back and the table is dropped.
+	tablet.failQuery = insert2
+	env.tmc.expectVRQuery(100, "/^create table t_restored \\(", &sqltypes.Result{})
+	env.tmc.expectVRQuery(100, "drop table if exists t_restored", &sqltypes.Result{})
+	_, err = env.wr.RestoreArchivedTable(ctx, "ks", "6ace8bcef73211ea87e9f875a4d24e90", "t_restored")
+	require.ErrorContains(t, err, "query insert into t_restored(id, val) values (3, null) failed")
+	assert.Len(t, tablet.committed, 1)
+	assert.Equal(t, 1, tablet.rolledBack)
+	env.tmc.verifyQueries(t)
+
+	_, err = env.wr.RestoreArchivedTable(ctx, "ks", "no_such_archive", "t_restored")
+	require.ErrorContains(t, err, "archive no_such_archive not found in table_archives/ks/0, tables archived before a reshard can't be restored")
+	_, err = env.wr.RestoreArchivedTable(ctx, "ks", "7ace8bcef73211ea87e9f875a4d24e90", "t_restored")
+	require.ErrorContains(t, err, "can't read MANIFEST of archive 7ace8bcef73211ea87e9f875a4d24e90, it may be incomplete")
+
+	env.tmc.verifyQueries(t)
+}
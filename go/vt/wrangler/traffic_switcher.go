@@ -26,6 +26,8 @@ import (
 	"sync"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/sets"
+
 	"vitess.io/vitess/go/sqlescape"
 	"vitess.io/vitess/go/vt/discovery"
 
@@ -278,25 +280,26 @@ func (wr *Wrangler) getWorkflowState(ctx context.Context, targetKeyspace, workfl
 	} else {
 		state.WorkflowType = workflow.TypeReshard
 
-		// we assume a consistent state, so only choose one shard
-		var shard *topo.ShardInfo
+		// reads may be switched progressively, for some of the shards, so check all of them
+		var shards []*topo.ShardInfo
 		if reverse {
-			shard = ts.TargetShards()[0]
+			shards = ts.TargetShards()
 		} else {
-			shard = ts.SourceShards()[0]
+			shards = ts.SourceShards()
 		}
 
-		state.RdonlyCellsSwitched, state.RdonlyCellsNotSwitched, err = ws.GetCellsWithShardReadsSwitched(ctx, keyspace, shard, topodatapb.TabletType_RDONLY)
+		state.RdonlyCellsSwitched, state.RdonlyCellsNotSwitched, state.RdonlyShardsSwitched, err = wr.getCellsWithShardsReadsSwitched(ctx, ws, keyspace, shards, topodatapb.TabletType_RDONLY)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		state.ReplicaCellsSwitched, state.ReplicaCellsNotSwitched, err = ws.GetCellsWithShardReadsSwitched(ctx, keyspace, shard, topodatapb.TabletType_REPLICA)
+		state.ReplicaCellsSwitched, state.ReplicaCellsNotSwitched, state.ReplicaShardsSwitched, err = wr.getCellsWithShardsReadsSwitched(ctx, ws, keyspace, shards, topodatapb.TabletType_REPLICA)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		if !shard.IsPrimaryServing {
+		// we assume a consistent state for writes, so only choose one shard
+		if !shards[0].IsPrimaryServing {
 			state.WritesSwitched = true
 		}
 	}
@@ -304,9 +307,51 @@ func (wr *Wrangler) getWorkflowState(ctx context.Context, targetKeyspace, workfl
 	return ts, state, nil
 }
 
+// getCellsWithShardsReadsSwitched returns the cells in which reads are switched for all the given
+// shards, the cells in which they are not, and the shards whose reads are switched in some of the
+// latter cells.
+func (wr *Wrangler) getCellsWithShardsReadsSwitched(ctx context.Context, ws *workflow.Server, keyspace string, shards []*topo.ShardInfo,
+	tabletType topodatapb.TabletType) (cellsSwitched, cellsNotSwitched, shardsSwitched []string, err error) {
+
+	switchedShardsByCell := make(map[string][]string)
+	for _, shard := range shards {
+		cells, _, err := ws.GetCellsWithShardReadsSwitched(ctx, keyspace, shard, tabletType)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, cell := range cells {
+			switchedShardsByCell[cell] = append(switchedShardsByCell[cell], shard.ShardName())
+		}
+	}
+	cells, err := wr.ts.GetCellInfoNames(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	partiallySwitched := sets.NewString()
+	for _, cell := range cells {
+		if len(switchedShardsByCell[cell]) == len(shards) {
+			cellsSwitched = append(cellsSwitched, cell)
+			continue
+		}
+		cellsNotSwitched = append(cellsNotSwitched, cell)
+		partiallySwitched.Insert(switchedShardsByCell[cell]...)
+	}
+	if partiallySwitched.Len() > 0 {
+		shardsSwitched = partiallySwitched.List()
+	}
+	return cellsSwitched, cellsNotSwitched, shardsSwitched, nil
+}
+
 // SwitchReads is a generic way of switching read traffic for a resharding workflow.
 func (wr *Wrangler) SwitchReads(ctx context.Context, targetKeyspace, workflowName string, servedTypes []topodatapb.TabletType,
 	cells []string, direction workflow.TrafficSwitchDirection, dryRun bool) (*[]string, error) {
+	return wr.switchReads(ctx, targetKeyspace, workflowName, servedTypes, cells, nil, direction, dryRun)
+}
+
+// switchReads is SwitchReads, for the given target shards of a Reshard workflow, or for all shards
+// when none are given.
+func (wr *Wrangler) switchReads(ctx context.Context, targetKeyspace, workflowName string, servedTypes []topodatapb.TabletType,
+	cells, shards []string, direction workflow.TrafficSwitchDirection, dryRun bool) (*[]string, error) {
 
 	ts, ws, err := wr.getWorkflowState(ctx, targetKeyspace, workflowName)
 	if err != nil {
@@ -324,10 +369,10 @@ func (wr *Wrangler) SwitchReads(ctx context.Context, targetKeyspace, workflowNam
 		if servedType != topodatapb.TabletType_REPLICA && servedType != topodatapb.TabletType_RDONLY {
 			return nil, fmt.Errorf("tablet type must be REPLICA or RDONLY: %v", servedType)
 		}
-		if direction == workflow.DirectionBackward && servedType == topodatapb.TabletType_REPLICA && len(ws.ReplicaCellsSwitched) == 0 {
+		if direction == workflow.DirectionBackward && servedType == topodatapb.TabletType_REPLICA && len(ws.ReplicaCellsSwitched) == 0 && len(ws.ReplicaShardsSwitched) == 0 {
 			return nil, fmt.Errorf("requesting reversal of SwitchReads for REPLICAs but REPLICA reads have not been switched")
 		}
-		if direction == workflow.DirectionBackward && servedType == topodatapb.TabletType_RDONLY && len(ws.RdonlyCellsSwitched) == 0 {
+		if direction == workflow.DirectionBackward && servedType == topodatapb.TabletType_RDONLY && len(ws.RdonlyCellsSwitched) == 0 && len(ws.RdonlyShardsSwitched) == 0 {
 			return nil, fmt.Errorf("requesting reversal of SwitchReads for RDONLYs but RDONLY reads have not been switched")
 		}
 		switch servedType {
@@ -381,6 +426,16 @@ func (wr *Wrangler) SwitchReads(ctx context.Context, targetKeyspace, workflowNam
 	}
 	defer unlock(&err)
 
+	sourceShards, targetShards := ts.SourceShards(), ts.TargetShards()
+	if len(shards) > 0 {
+		if ts.MigrationType() == binlogdatapb.MigrationType_TABLES {
+			return nil, fmt.Errorf("reads can only be switched for a subset of the shards in Reshard workflows")
+		}
+		if sourceShards, targetShards, err = workflow.ShardsForReads(sourceShards, targetShards, shards); err != nil {
+			return nil, err
+		}
+	}
+
 	if ts.MigrationType() == binlogdatapb.MigrationType_TABLES {
 		if ts.isPartialMigration {
 			ts.Logger().Infof("Partial migration, skipping switchTableReads as traffic is all or nothing per shard and overridden for reads AND writes in the ShardRoutingRule created when switching writes.")
@@ -391,7 +446,7 @@ func (wr *Wrangler) SwitchReads(ctx context.Context, targetKeyspace, workflowNam
 		return sw.logs(), nil
 	}
 	wr.Logger().Infof("About to switchShardReads: %+v, %+v, %+v", cells, servedTypes, direction)
-	if err := ts.switchShardReads(ctx, cells, servedTypes, direction); err != nil {
+	if err := ts.switchShardReads(ctx, cells, servedTypes, sourceShards, targetShards, direction); err != nil {
 		ts.Logger().Errorf("switchShardReads failed: %v", err)
 		return nil, err
 	}
@@ -1055,12 +1110,14 @@ func (ts *trafficSwitcher) switchTableReads(ctx context.Context, cells []string,
 	return ts.TopoServer().RebuildSrvVSchema(ctx, cells)
 }
 
-func (ts *trafficSwitcher) switchShardReads(ctx context.Context, cells []string, servedTypes []topodatapb.TabletType, direction workflow.TrafficSwitchDirection) error {
+// switchShardReads switches the reads for the given source and target shards, which are all
+// the shards of the workflow unless reads are switched progressively.
+func (ts *trafficSwitcher) switchShardReads(ctx context.Context, cells []string, servedTypes []topodatapb.TabletType, sourceShards, targetShards []*topo.ShardInfo, direction workflow.TrafficSwitchDirection) error {
 	var fromShards, toShards []*topo.ShardInfo
 	if direction == workflow.DirectionForward {
-		fromShards, toShards = ts.SourceShards(), ts.TargetShards()
+		fromShards, toShards = sourceShards, targetShards
 	} else {
-		fromShards, toShards = ts.TargetShards(), ts.SourceShards()
+		fromShards, toShards = targetShards, sourceShards
 	}
 	if err := ts.TopoServer().ValidateSrvKeyspace(ctx, ts.TargetKeyspaceName(), strings.Join(cells, ",")); err != nil {
 		err2 := vterrors.Wrapf(err, "Before switching shard reads, found SrvKeyspace for %s is corrupt in cell %s",
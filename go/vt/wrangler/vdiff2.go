@@ -60,7 +60,7 @@ func (wr *Wrangler) VDiff2(ctx context.Context, keyspace, workflowName string, a
 	if err != nil {
 		return nil, err
 	}
-	if action == vdiff2.CreateAction && ts.frozen {
+	if (action == vdiff2.CreateAction || action == vdiff2.RepairAction) && ts.frozen {
 		return nil, fmt.Errorf("invalid VDiff run: writes have been already been switched for workflow %s.%s",
 			keyspace, workflowName)
 	}
@@ -59,12 +59,26 @@ type VReplicationWorkflowParams struct {
 	AllTables, RenameTables bool
 	SourceTimeZone          string
 	DropForeignKeys         bool
+	// OnDDL and DDLPolicy are the OnDdl action and the ddl policy of the
+	// streams, see parseOnDDL.
+	OnDDL, DDLPolicy string
 
 	// Reshard specific
 	SourceShards, TargetShards []string
 	SkipSchemaCopy             bool
 	AutoStart, StopAfterCopy   bool
 
+	// SwitchTraffic specific: Shards are the Reshard target shards to switch reads for. With
+	// Canary, reads are first switched for Shards, or for CanaryCellsPercent of the cells, and
+	// then for the rest of the keyspace if the vtgates report healthy traffic for CanaryDuration.
+	Shards             []string
+	Canary             bool
+	CanaryCellsPercent int
+	CanaryDuration     time.Duration
+	CanaryVTGates      []string
+	CanaryMaxErrorRate float64
+	CanaryMaxLatency   time.Duration
+
 	// Migrate specific
 	ExternalCluster string
 }
@@ -147,25 +161,26 @@ func (vrw *VReplicationWorkflow) stateAsString(ws *workflow.State) string {
 		if !vrw.ts.isPartialMigration { // shard level traffic switching is all or nothing
 			if len(ws.RdonlyCellsNotSwitched) == 0 && len(ws.ReplicaCellsNotSwitched) == 0 && len(ws.ReplicaCellsSwitched) > 0 {
 				s = "All Reads Switched"
-			} else if len(ws.RdonlyCellsSwitched) == 0 && len(ws.ReplicaCellsSwitched) == 0 {
+			} else if len(ws.RdonlyCellsSwitched) == 0 && len(ws.ReplicaCellsSwitched) == 0 &&
+				len(ws.RdonlyShardsSwitched) == 0 && len(ws.ReplicaShardsSwitched) == 0 {
 				s = "Reads Not Switched"
 			} else {
 				stateInfo = append(stateInfo, "Reads partially switched")
 				if len(ws.ReplicaCellsNotSwitched) == 0 {
 					s += "All Replica Reads Switched"
-				} else if len(ws.ReplicaCellsSwitched) == 0 {
+				} else if len(ws.ReplicaCellsSwitched) == 0 && len(ws.ReplicaShardsSwitched) == 0 {
 					s += "Replica not switched"
 				} else {
-					s += "Replica switched in cells: " + strings.Join(ws.ReplicaCellsSwitched, ",")
+					s += "Replica switched " + partialReadsState(ws.ReplicaCellsSwitched, ws.ReplicaShardsSwitched)
 				}
 				stateInfo = append(stateInfo, s)
 				s = ""
 				if len(ws.RdonlyCellsNotSwitched) == 0 {
 					s += "All Rdonly Reads Switched"
-				} else if len(ws.RdonlyCellsSwitched) == 0 {
+				} else if len(ws.RdonlyCellsSwitched) == 0 && len(ws.RdonlyShardsSwitched) == 0 {
 					s += "Rdonly not switched"
 				} else {
-					s += "Rdonly switched in cells: " + strings.Join(ws.RdonlyCellsSwitched, ",")
+					s += "Rdonly switched " + partialReadsState(ws.RdonlyCellsSwitched, ws.RdonlyShardsSwitched)
 				}
 			}
 			stateInfo = append(stateInfo, s)
@@ -195,6 +210,19 @@ func (vrw *VReplicationWorkflow) stateAsString(ws *workflow.State) string {
 	return strings.Join(stateInfo, ". ")
 }
 
+// partialReadsState describes the cells in which reads are switched for all shards, and the
+// source shards whose reads are switched in other cells.
+func partialReadsState(cellsSwitched, shardsSwitched []string) string {
+	var switched []string
+	if len(cellsSwitched) > 0 {
+		switched = append(switched, "in cells: "+strings.Join(cellsSwitched, ","))
+	}
+	if len(shardsSwitched) > 0 {
+		switched = append(switched, "for source shards: "+strings.Join(shardsSwitched, ","))
+	}
+	return strings.Join(switched, ", ")
+}
+
 // Create initiates a workflow
 func (vrw *VReplicationWorkflow) Create(ctx context.Context) error {
 	var err error
@@ -307,8 +335,26 @@ func (vrw *VReplicationWorkflow) SwitchTraffic(direction workflow.TrafficSwitchD
 	if err != nil {
 		return nil, err
 	}
+	if vrw.params.Canary {
+		if vrw.params.Direction == workflow.DirectionBackward {
+			return nil, fmt.Errorf("canary traffic switching is only supported for SwitchTraffic")
+		}
+		if vrw.params.DryRun {
+			return nil, fmt.Errorf("canary traffic switching does not support dry runs")
+		}
+		if !hasReplica && !hasRdonly {
+			return nil, fmt.Errorf("canary traffic switching requires REPLICA or RDONLY tablet types")
+		}
+	} else if len(vrw.params.Shards) > 0 && hasPrimary {
+		return nil, fmt.Errorf("writes are switched for all shards: switch reads for a subset of the shards with REPLICA and RDONLY tablet types only")
+	}
 	if hasReplica || hasRdonly {
-		if rdDryRunResults, err = vrw.switchReads(); err != nil {
+		if vrw.params.Canary {
+			err = vrw.switchReadsWithCanary()
+		} else {
+			rdDryRunResults, err = vrw.switchReads(vrw.getCellsAsArray(), vrw.params.Shards)
+		}
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -400,6 +446,13 @@ func (vrw *VReplicationWorkflow) getCellsAsArray() []string {
 	return nil
 }
 
+func cellsString(cells []string) string {
+	if len(cells) == 0 {
+		return "all cells"
+	}
+	return "cells " + strings.Join(cells, ",")
+}
+
 func (vrw *VReplicationWorkflow) parseTabletTypes() (hasReplica, hasRdonly, hasPrimary bool, err error) {
 	tabletTypes, _, err := discovery.ParseTabletTypesAndOrder(vrw.params.TabletTypes)
 	if err != nil {
@@ -429,7 +482,7 @@ func (vrw *VReplicationWorkflow) initMoveTables() error {
 	return vrw.wr.MoveTables(vrw.ctx, vrw.params.Workflow, vrw.params.SourceKeyspace, vrw.params.TargetKeyspace,
 		vrw.params.Tables, vrw.params.Cells, vrw.params.TabletTypes, vrw.params.AllTables, vrw.params.ExcludeTables,
 		vrw.params.AutoStart, vrw.params.StopAfterCopy, vrw.params.ExternalCluster, vrw.params.DropForeignKeys,
-		vrw.params.SourceTimeZone, vrw.params.SourceShards)
+		vrw.params.SourceTimeZone, vrw.params.SourceShards, vrw.params.OnDDL, vrw.params.DDLPolicy)
 }
 
 func (vrw *VReplicationWorkflow) initReshard() error {
@@ -438,8 +491,7 @@ func (vrw *VReplicationWorkflow) initReshard() error {
 		vrw.params.TargetShards, vrw.params.SkipSchemaCopy, vrw.params.Cells, vrw.params.TabletTypes, vrw.params.AutoStart, vrw.params.StopAfterCopy)
 }
 
-func (vrw *VReplicationWorkflow) switchReads() (*[]string, error) {
-	log.Infof("In VReplicationWorkflow.switchReads() for %+v", vrw)
+func (vrw *VReplicationWorkflow) getNonPrimaryTabletTypes() ([]topodatapb.TabletType, error) {
 	fullTabletTypes, _, err := discovery.ParseTabletTypesAndOrder(vrw.params.TabletTypes)
 	if err != nil {
 		return nil, err
@@ -450,15 +502,91 @@ func (vrw *VReplicationWorkflow) switchReads() (*[]string, error) {
 			nonPrimaryTabletTypes = append(nonPrimaryTabletTypes, tt)
 		}
 	}
+	return nonPrimaryTabletTypes, nil
+}
+
+func (vrw *VReplicationWorkflow) switchReads(cells, shards []string) (*[]string, error) {
+	log.Infof("In VReplicationWorkflow.switchReads() for %+v", vrw)
+	nonPrimaryTabletTypes, err := vrw.getNonPrimaryTabletTypes()
+	if err != nil {
+		return nil, err
+	}
 	var dryRunResults *[]string
-	dryRunResults, err = vrw.wr.SwitchReads(vrw.ctx, vrw.params.TargetKeyspace, vrw.params.Workflow, nonPrimaryTabletTypes,
-		vrw.getCellsAsArray(), vrw.params.Direction, vrw.params.DryRun)
+	dryRunResults, err = vrw.wr.switchReads(vrw.ctx, vrw.params.TargetKeyspace, vrw.params.Workflow, nonPrimaryTabletTypes,
+		cells, shards, vrw.params.Direction, vrw.params.DryRun)
 	if err != nil {
 		return nil, err
 	}
 	return dryRunResults, nil
 }
 
+// canaryRollbackTimeout is how long to wait for the reads of a failed canary to be switched back.
+const canaryRollbackTimeout = 1 * time.Minute
+
+// switchReadsWithCanary switches reads for the canary shards or cells first, and watches the
+// vttablet calls that the vtgates make to the canary shards. If they fail or are slow, the canary
+// reads are switched back. Otherwise, reads are switched for the rest of the keyspace.
+func (vrw *VReplicationWorkflow) switchReadsWithCanary() error {
+	log.Infof("In VReplicationWorkflow.switchReadsWithCanary() for %+v", vrw)
+	if len(vrw.params.CanaryVTGates) == 0 {
+		return fmt.Errorf("canary traffic switching requires the addresses of the vtgates to watch")
+	}
+	cells := vrw.getCellsAsArray()
+	canaryCells := cells
+	if vrw.params.CanaryCellsPercent > 0 {
+		allCells := cells
+		if len(allCells) == 0 {
+			var err error
+			if allCells, err = vrw.wr.ts.GetCellInfoNames(vrw.ctx); err != nil {
+				return err
+			}
+		}
+		canaryCells = workflow.CanaryCells(allCells, vrw.params.CanaryCellsPercent)
+	}
+	canaryShards := vrw.params.Shards
+	if len(canaryShards) == 0 {
+		for _, target := range vrw.ts.TargetShards() {
+			canaryShards = append(canaryShards, target.ShardName())
+		}
+	}
+	tabletTypes, err := vrw.getNonPrimaryTabletTypes()
+	if err != nil {
+		return err
+	}
+
+	vrw.wr.Logger().Printf("Canary: switching reads for shards %s in %s\n", strings.Join(canaryShards, ","), cellsString(canaryCells))
+	if _, err := vrw.switchReads(canaryCells, vrw.params.Shards); err != nil {
+		return err
+	}
+	check := &workflow.CanaryCheck{
+		VTGates:      vrw.params.CanaryVTGates,
+		Keyspace:     vrw.params.TargetKeyspace,
+		Shards:       canaryShards,
+		TabletTypes:  tabletTypes,
+		MaxErrorRate: vrw.params.CanaryMaxErrorRate,
+		MaxLatency:   vrw.params.CanaryMaxLatency,
+	}
+	vrw.wr.Logger().Printf("Canary: watching vtgates %s for %v\n", strings.Join(vrw.params.CanaryVTGates, ","), vrw.params.CanaryDuration)
+	if canaryErr := check.Watch(vrw.ctx, vrw.params.CanaryDuration); canaryErr != nil {
+		vrw.wr.Logger().Errorf("Canary failed: %v. Switching reads back for shards %s in %s\n",
+			canaryErr, strings.Join(canaryShards, ","), cellsString(canaryCells))
+		// The workflow's context may be what ended the watch, so the reads are switched back
+		// with a context of their own.
+		rollbackCtx, cancel := context.WithTimeout(context.Background(), canaryRollbackTimeout)
+		defer cancel()
+		_, err := vrw.wr.switchReads(rollbackCtx, vrw.params.TargetKeyspace, vrw.params.Workflow, tabletTypes,
+			canaryCells, vrw.params.Shards, workflow.DirectionBackward, vrw.params.DryRun)
+		if err != nil {
+			return fmt.Errorf("canary failed: %v, and switching reads back failed: %v", canaryErr, err)
+		}
+		return fmt.Errorf("canary failed, reads were switched back: %v", canaryErr)
+	}
+
+	vrw.wr.Logger().Printf("Canary succeeded: switching reads for all shards in %s\n", cellsString(cells))
+	_, err = vrw.switchReads(cells, nil)
+	return err
+}
+
 func (vrw *VReplicationWorkflow) switchWrites() (*[]string, error) {
 	var journalID int64
 	var dryRunResults *[]string
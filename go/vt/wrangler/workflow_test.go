@@ -19,9 +19,12 @@ package wrangler
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -491,6 +494,177 @@ func TestReshardV2(t *testing.T) {
 	require.NotNil(t, si)
 }
 
+func TestReshardV2Progressive(t *testing.T) {
+	ctx := context.Background()
+	sourceShards := []string{"-80", "80-"}
+	targetShards := []string{"-40", "40-80", "80-c0", "c0-"}
+	p := &VReplicationWorkflowParams{
+		Workflow:                        "test",
+		SourceKeyspace:                  "ks",
+		TargetKeyspace:                  "ks",
+		SourceShards:                    sourceShards,
+		TargetShards:                    targetShards,
+		Cells:                           "cell1,cell2",
+		TabletTypes:                     "replica,rdonly",
+		Timeout:                         DefaultActionTimeout,
+		MaxAllowedTransactionLagSeconds: defaultMaxAllowedTransactionLagSeconds,
+	}
+	tme := newTestShardMigrater(ctx, t, sourceShards, targetShards)
+	defer tme.stopTablets(t)
+	wf, err := tme.wr.NewVReplicationWorkflow(ctx, ReshardWorkflow, p)
+	require.NoError(t, err)
+	require.NotNil(t, wf)
+	require.Equal(t, WorkflowStateNotSwitched, wf.CurrentState())
+	tme.expectNoPreviousJournals()
+	expectReshardQueries(t, tme)
+
+	// the target shards must cover the source shards they overlap
+	tme.expectNoPreviousJournals()
+	wf.params.Shards = []string{"-40"}
+	require.ErrorContains(t, testSwitchForward(t, wf), "target shard 40-80 also overlaps source shard -80")
+
+	// writes cannot be switched for a subset of the shards
+	wf.params.Shards = []string{"-40", "40-80"}
+	wf.params.TabletTypes = "replica,rdonly,primary"
+	require.ErrorContains(t, testSwitchForward(t, wf), "writes are switched for all shards")
+
+	tme.expectNoPreviousJournals()
+	wf.params.TabletTypes = "replica,rdonly"
+	require.NoError(t, testSwitchForward(t, wf))
+	require.Equal(t, "Reads partially switched. Replica switched for source shards: -80. Rdonly switched for source shards: -80. Writes Not Switched", wf.CurrentState())
+
+	tme.expectNoPreviousJournals()
+	require.NoError(t, testReverse(t, wf))
+	require.Equal(t, WorkflowStateNotSwitched, wf.CurrentState())
+
+	tme.expectNoPreviousJournals()
+	wf.params.Shards = nil
+	require.NoError(t, testSwitchForward(t, wf))
+	require.Equal(t, WorkflowStateReadsSwitched, wf.CurrentState())
+}
+
+// fakeVTGateStats serves the vttablet call stats of a vtgate, adding
+// failed calls to shard -40 every time the stats are read when failing.
+// onServe, if set, is called every time the stats are read.
+type fakeVTGateStats struct {
+	mu      sync.Mutex
+	failing bool
+	errors  int
+	onServe func()
+}
+
+func (fv *fakeVTGateStats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	if fv.onServe != nil {
+		fv.onServe()
+	}
+	fmt.Fprintf(w, `{"VttabletCall": {"Histograms": {"Execute.ks.-40.replica": {"Count": %d, "Time": 0}}}, "VttabletCallErrorCount": {"Execute.ks.-40.replica": %d}}`, fv.errors, fv.errors)
+	if fv.failing {
+		fv.errors += 10
+	}
+}
+
+func TestReshardV2Canary(t *testing.T) {
+	defer func(interval time.Duration) { workflow.CanaryCheckInterval = interval }(workflow.CanaryCheckInterval)
+	workflow.CanaryCheckInterval = 10 * time.Millisecond
+
+	ctx := context.Background()
+	sourceShards := []string{"-80", "80-"}
+	targetShards := []string{"-40", "40-80", "80-c0", "c0-"}
+	vtgate := &fakeVTGateStats{failing: true}
+	server := httptest.NewServer(vtgate)
+	defer server.Close()
+	p := &VReplicationWorkflowParams{
+		Workflow:                        "test",
+		SourceKeyspace:                  "ks",
+		TargetKeyspace:                  "ks",
+		SourceShards:                    sourceShards,
+		TargetShards:                    targetShards,
+		Cells:                           "cell1,cell2",
+		TabletTypes:                     "replica,rdonly",
+		Timeout:                         DefaultActionTimeout,
+		MaxAllowedTransactionLagSeconds: defaultMaxAllowedTransactionLagSeconds,
+		Shards:                          []string{"-40", "40-80"},
+		Canary:                          true,
+		CanaryDuration:                  50 * time.Millisecond,
+		CanaryVTGates:                   []string{strings.TrimPrefix(server.URL, "http://")},
+		CanaryMaxErrorRate:              0.01,
+	}
+	tme := newTestShardMigrater(ctx, t, sourceShards, targetShards)
+	defer tme.stopTablets(t)
+	wf, err := tme.wr.NewVReplicationWorkflow(ctx, ReshardWorkflow, p)
+	require.NoError(t, err)
+	require.NotNil(t, wf)
+	tme.expectNoPreviousJournals()
+	expectReshardQueries(t, tme)
+
+	// the canary fails and its reads are switched back
+	tme.expectNoPreviousJournals()
+	tme.expectNoPreviousJournals()
+	require.ErrorContains(t, testSwitchForward(t, wf), "canary failed, reads were switched back")
+	require.Equal(t, WorkflowStateNotSwitched, wf.CurrentState())
+
+	// the canary succeeds and reads are switched for all shards
+	vtgate.mu.Lock()
+	vtgate.failing = false
+	vtgate.mu.Unlock()
+	tme.expectNoPreviousJournals()
+	tme.expectNoPreviousJournals()
+	require.NoError(t, testSwitchForward(t, wf))
+	require.Equal(t, WorkflowStateReadsSwitched, wf.CurrentState())
+}
+
+// TestReshardV2CanaryCanceled tests that the reads of the canary are switched back
+// when the context of the workflow is canceled while the canary is watched.
+func TestReshardV2CanaryCanceled(t *testing.T) {
+	defer func(interval time.Duration) { workflow.CanaryCheckInterval = interval }(workflow.CanaryCheckInterval)
+	workflow.CanaryCheckInterval = 10 * time.Millisecond
+
+	ctx := context.Background()
+	wfCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sourceShards := []string{"-80", "80-"}
+	targetShards := []string{"-40", "40-80", "80-c0", "c0-"}
+	vtgate := &fakeVTGateStats{onServe: cancel}
+	server := httptest.NewServer(vtgate)
+	defer server.Close()
+	p := &VReplicationWorkflowParams{
+		Workflow:                        "test",
+		SourceKeyspace:                  "ks",
+		TargetKeyspace:                  "ks",
+		SourceShards:                    sourceShards,
+		TargetShards:                    targetShards,
+		Cells:                           "cell1,cell2",
+		TabletTypes:                     "replica,rdonly",
+		Timeout:                         DefaultActionTimeout,
+		MaxAllowedTransactionLagSeconds: defaultMaxAllowedTransactionLagSeconds,
+		Shards:                          []string{"-40", "40-80"},
+		Canary:                          true,
+		CanaryDuration:                  time.Hour,
+		CanaryVTGates:                   []string{strings.TrimPrefix(server.URL, "http://")},
+		CanaryMaxErrorRate:              0.01,
+	}
+	tme := newTestShardMigrater(ctx, t, sourceShards, targetShards)
+	defer tme.stopTablets(t)
+	wf, err := tme.wr.NewVReplicationWorkflow(wfCtx, ReshardWorkflow, p)
+	require.NoError(t, err)
+	require.NotNil(t, wf)
+	tme.expectNoPreviousJournals()
+	expectReshardQueries(t, tme)
+
+	tme.expectNoPreviousJournals()
+	tme.expectNoPreviousJournals()
+	// The workflow's context may be canceled while the stats of vtgate are read.
+	err = testSwitchForward(t, wf)
+	require.ErrorContains(t, err, "canary failed, reads were switched back: ")
+	require.ErrorContains(t, err, "context canceled")
+
+	wf, err = tme.wr.NewVReplicationWorkflow(ctx, ReshardWorkflow, p)
+	require.NoError(t, err)
+	require.Equal(t, WorkflowStateNotSwitched, wf.CurrentState())
+}
+
 func TestVRWSchemaValidation(t *testing.T) {
 	ctx := context.Background()
 	sourceShards := []string{"-80", "80-"}